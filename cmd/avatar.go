@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/avatar"
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/urfave/cli/v3"
+)
+
+func handleAvatarServe(ctx context.Context, c *cli.Command) error {
+	listener, err := net.Listen("tcp", c.String("listen"))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	hub := avatar.NewHub()
+	server := &http.Server{
+		Handler:           hub.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("Avatar bridge on %s (Ctrl-C to stop)\n", cliui.Label("ws://"+listener.Addr().String()))
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
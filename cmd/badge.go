@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	shieldsBadgeURL   = "https://img.shields.io/badge/"
+	defaultBadgeLabel = "persona"
+	defaultBadgeColor = "blueviolet"
+)
+
+func badgeCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "badge",
+		Usage:       "Print a README badge for the project persona, or check or update one",
+		Description: "Prints a shields.io badge naming the persona in .agents/ccpersona.json. With --check the\nbadge in a file must name the current persona, so a pre-commit hook can keep the README\nin sync with the config; --update rewrites the badge in place.",
+		Action:      handleBadge,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format: markdown, html, or url",
+				Value: "markdown",
+			},
+			&cli.StringFlag{
+				Name:  "label",
+				Usage: "Left-hand text of the badge",
+				Value: defaultBadgeLabel,
+			},
+			&cli.StringFlag{
+				Name:  "color",
+				Usage: "Badge color: a shields.io color name or hex code",
+				Value: defaultBadgeColor,
+			},
+			&cli.StringFlag{
+				Name:  "check",
+				Usage: "Fail unless the badge in this file (e.g. README.md) names the project persona",
+			},
+			&cli.StringFlag{
+				Name:  "update",
+				Usage: "Rewrite the badge in this file to name the project persona",
+			},
+		},
+	}
+}
+
+func handleBadge(ctx context.Context, c *cli.Command) error {
+	config, err := persona.LoadConfigFromPath(persona.ConfigPath("."))
+	if err != nil {
+		return configError(err)
+	}
+	if config == nil || config.Name == "" {
+		return configError(fmt.Errorf("no project persona in %s; run 'ccpersona config set-persona <name>' first", persona.ConfigPath(".")))
+	}
+	b := personaBadge{label: c.String("label"), message: config.Name, color: c.String("color")}
+
+	switch {
+	case c.String("check") != "":
+		return checkBadge(c.String("check"), b)
+	case c.String("update") != "":
+		return updateBadge(c.String("update"), b)
+	}
+
+	switch c.String("format") {
+	case "markdown", "md":
+		fmt.Println(b.markdown())
+	case "html":
+		fmt.Println(b.html())
+	case "url":
+		fmt.Println(b.url())
+	default:
+		return usageError(fmt.Errorf("unknown --format %q (use markdown, html, or url)", c.String("format")))
+	}
+	return nil
+}
+
+// personaBadge is a shields.io static badge.
+type personaBadge struct {
+	label, message, color string
+}
+
+func (b personaBadge) url() string {
+	return shieldsBadgeURL + shieldsEscape(b.label) + "-" + shieldsEscape(b.message) + "-" + url.PathEscape(b.color)
+}
+
+func (b personaBadge) alt() string {
+	return b.label + ": " + b.message
+}
+
+// markdown links the badge to the config that sets the persona.
+func (b personaBadge) markdown() string {
+	return fmt.Sprintf("[![%s](%s)](%s)", b.alt(), b.url(), filepath.ToSlash(persona.ConfigPath(".")))
+}
+
+func (b personaBadge) html() string {
+	return fmt.Sprintf(`<a href="%s"><img alt="%s" src="%s"></a>`, filepath.ToSlash(persona.ConfigPath(".")), b.alt(), b.url())
+}
+
+// shieldsEscape escapes text for a badge path segment: dashes and
+// underscores are doubled, spaces become underscores.
+func shieldsEscape(s string) string {
+	s = strings.NewReplacer("-", "--", "_", "__", " ", "_").Replace(s)
+	return url.PathEscape(s)
+}
+
+func shieldsUnescape(s string) string {
+	if unescaped, err := url.PathUnescape(s); err == nil {
+		s = unescaped
+	}
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case (s[i] == '-' || s[i] == '_') && i+1 < len(s) && s[i+1] == s[i]:
+			out.WriteByte(s[i])
+			i++
+		case s[i] == '_':
+			out.WriteByte(' ')
+		default:
+			out.WriteByte(s[i])
+		}
+	}
+	return out.String()
+}
+
+// badgePattern matches the badge image with the given label, capturing the
+// escaped message.
+func badgePattern(label string) *regexp.Regexp {
+	return regexp.MustCompile(`!\[[^\]]*\]\(` + regexp.QuoteMeta(shieldsBadgeURL+shieldsEscape(label)) + `-((?:[^-\s)]|--)+)-[^)\s]*\)`)
+}
+
+// findBadge returns the persona named by the badge in content.
+func findBadge(content, label string) (string, bool) {
+	m := badgePattern(label).FindStringSubmatch(content)
+	if m == nil {
+		return "", false
+	}
+	return shieldsUnescape(m[1]), true
+}
+
+func checkBadge(path string, b personaBadge) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	found, ok := findBadge(string(data), b.label)
+	if !ok {
+		return fmt.Errorf("no %s badge in %s; add:\n%s", b.label, path, b.markdown())
+	}
+	if found != b.message {
+		return fmt.Errorf("%s badge in %s says %q but %s sets %q; run 'ccpersona config badge --update %s'",
+			b.label, path, found, persona.ConfigPath("."), b.message, path)
+	}
+	fmt.Printf("%s %s badge in %s matches the project persona (%s)\n", cliui.Success("✓"), b.label, path, b.message)
+	return nil
+}
+
+func updateBadge(path string, b personaBadge) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	pattern := badgePattern(b.label)
+	if !pattern.Match(data) {
+		return fmt.Errorf("no %s badge in %s to update; add:\n%s", b.label, path, b.markdown())
+	}
+	image := fmt.Sprintf("![%s](%s)", b.alt(), b.url())
+	updated := pattern.ReplaceAllLiteral(data, []byte(image))
+	if string(updated) == string(data) {
+		fmt.Printf("%s badge in %s is up to date\n", b.label, path)
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteFile(path, updated, info.Mode().Perm()); err != nil {
+		return err
+	}
+	fmt.Printf("%s %s badge in %s to %s\n", cliui.Success("Updated"), b.label, path, b.message)
+	return nil
+}
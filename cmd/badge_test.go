@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShieldsEscape(t *testing.T) {
+	for _, name := range []string{"zundamon", "strict-reviewer", "my_persona", "two words", "ずんだもん", "a-b_c d"} {
+		escaped := shieldsEscape(name)
+		if strings.Count(escaped, "-")%2 != 0 {
+			t.Errorf("shieldsEscape(%q) = %q has a lone dash", name, escaped)
+		}
+		if got := shieldsUnescape(escaped); got != name {
+			t.Errorf("round trip of %q = %q", name, got)
+		}
+	}
+	b := personaBadge{label: "persona", message: "strict-reviewer", color: "blueviolet"}
+	if want := "https://img.shields.io/badge/persona-strict--reviewer-blueviolet"; b.url() != want {
+		t.Errorf("url = %q, want %q", b.url(), want)
+	}
+}
+
+func TestFindBadge(t *testing.T) {
+	readme := "# Project\n\n[![persona: strict-reviewer](https://img.shields.io/badge/persona-strict--reviewer-blueviolet)](.agents/ccpersona.json)\n" +
+		"![build](https://img.shields.io/badge/build-passing-green)\n"
+	if got, ok := findBadge(readme, "persona"); !ok || got != "strict-reviewer" {
+		t.Errorf("findBadge = %q, %v", got, ok)
+	}
+	if _, ok := findBadge(readme, "agent"); ok {
+		t.Error("a badge with another label should not match")
+	}
+}
+
+func TestCheckAndUpdateBadge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "README.md")
+	old := personaBadge{label: "persona", message: "zundamon", color: "blueviolet"}
+	content := "# Project\n\n" + old.markdown() + "\n\nText.\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkBadge(path, old); err != nil {
+		t.Errorf("matching badge: %v", err)
+	}
+	current := personaBadge{label: "persona", message: "pair-programmer", color: "blueviolet"}
+	if err := checkBadge(path, current); err == nil || !strings.Contains(err.Error(), `"zundamon"`) {
+		t.Errorf("stale badge: err = %v", err)
+	}
+
+	if err := updateBadge(path, current); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkBadge(path, current); err != nil {
+		t.Errorf("after update: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "](.agents/ccpersona.json)") || !strings.HasSuffix(string(data), "\n\nText.\n") {
+		t.Errorf("update should only replace the image:\n%s", data)
+	}
+
+	if err := os.WriteFile(path, []byte("# No badge\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkBadge(path, current); err == nil {
+		t.Error("a file without a badge should fail the check")
+	}
+	if err := updateBadge(path, current); err == nil {
+		t.Error("a file without a badge cannot be updated")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
+)
+
+// audioCacheConfig returns voice.cache of the loaded config, nil for the
+// defaults.
+func audioCacheConfig(c *cli.Command) *voice.CacheConfig {
+	config := loadUnifiedConfig(c, "")
+	if config == nil || config.Voice == nil {
+		return nil
+	}
+	return config.Voice.Cache
+}
+
+func handleCacheStats(ctx context.Context, c *cli.Command) error {
+	config := audioCacheConfig(c)
+	cache, err := voice.NewAudioCache(config)
+	if err != nil {
+		return err
+	}
+	stats, err := cache.Stats()
+	if err != nil {
+		return fmt.Errorf("failed to read the audio cache: %w", err)
+	}
+
+	state := cliui.Success("on")
+	if !config.IsEnabled() {
+		state = cliui.Muted("off (voice.cache.enabled is false)")
+	}
+	fmt.Printf("%s %s\n", cliui.Label("Audio cache:"), state)
+	fmt.Printf("  %s %s\n", cliui.Label("directory:"), stats.Dir)
+	fmt.Printf("  %s %d (%s of %s)\n", cliui.Label("entries:"), stats.Entries, formatBytes(stats.Bytes), formatBytes(stats.Limit))
+	lookups := stats.Hits + stats.Misses
+	if lookups == 0 {
+		fmt.Printf("  %s %s\n", cliui.Label("hits:"), cliui.Muted("no lookups yet"))
+		return nil
+	}
+	fmt.Printf("  %s %d of %d lookups (%.0f%%)\n", cliui.Label("hits:"), stats.Hits, lookups, 100*float64(stats.Hits)/float64(lookups))
+	fmt.Printf("  %s %d characters not sent to a provider\n", cliui.Label("saved:"), stats.CharsSaved)
+	return nil
+}
+
+func handleCacheClear(ctx context.Context, c *cli.Command) error {
+	cache, err := voice.NewAudioCache(audioCacheConfig(c))
+	if err != nil {
+		return err
+	}
+	removed, err := cache.Clear()
+	if err != nil {
+		return fmt.Errorf("failed to clear the audio cache: %w", err)
+	}
+	fmt.Printf("%s Removed %d cached audio file(s)\n", cliui.Success("✓"), removed)
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/ci"
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+func handleCIWatch(ctx context.Context, c *cli.Command) error {
+	repo := c.String("repo")
+	if repo == "" {
+		detected, err := ci.DetectRepo(ctx, ".")
+		if err != nil {
+			return err
+		}
+		repo = detected
+	}
+	branch := c.String("branch")
+	if branch == "" {
+		detected, err := ci.DetectBranch(ctx, ".")
+		if err != nil {
+			return err
+		}
+		branch = detected
+	}
+
+	interval := c.Duration("interval")
+	if interval < 5*time.Second {
+		return usageError(fmt.Errorf("--interval must be at least 5s"))
+	}
+
+	token := ci.Token(ctx)
+	if token == "" {
+		fmt.Fprintln(os.Stderr, cliui.Warn("No GitHub token found (GITHUB_TOKEN, GH_TOKEN, or gh auth); unauthenticated requests are limited to 60 per hour."))
+	}
+	client := ci.NewClient(token)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Watching CI for %s on %s (every %s, Ctrl-C to stop)\n", cliui.Label(repo), cliui.Label(branch), interval)
+
+	watcher := &ci.Watcher{
+		Interval:      interval,
+		UntilComplete: c.Bool("until-complete"),
+		Fetch: func(ctx context.Context) ([]ci.CheckRun, error) {
+			return client.CheckRuns(ctx, repo, branch)
+		},
+		OnError: func(err error) {
+			log.Warn().Err(err).Msg("Failed to poll CI status")
+		},
+		OnChange: func(prev *ci.Status, cur ci.Status) {
+			message := ci.Message(branch, cur)
+			fmt.Printf("%s %s %s\n", cliui.Muted(time.Now().Format("15:04:05")), ciStateLabel(cur.State), message)
+			// The first poll is printed but only establishes the baseline;
+			// --announce-initial speaks it too.
+			if prev == nil && !c.Bool("announce-initial") {
+				return
+			}
+			if cur.State == ci.StateNone {
+				return
+			}
+			urgency := "normal"
+			if cur.State == ci.StateFailed {
+				urgency = "critical"
+			}
+			announce(ctx, c, "ci", message, urgency)
+		},
+	}
+	return watcher.Run(ctx)
+}
+
+func ciStateLabel(state ci.State) string {
+	switch state {
+	case ci.StateSucceeded:
+		return cliui.Success(string(state))
+	case ci.StateFailed:
+		return cliui.Failure(string(state))
+	case ci.StatePending:
+		return cliui.Warn(string(state))
+	default:
+		return cliui.Muted(string(state))
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// clipboardCommand returns the platform's clipboard writer, reading stdin.
+func clipboardCommand(ctx context.Context, goos string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		return exec.CommandContext(ctx, "pbcopy"), nil
+	case "windows":
+		return exec.CommandContext(ctx, "clip"), nil
+	}
+	candidates := [][]string{
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append([][]string{{"wl-copy"}}, candidates...)
+	}
+	for _, args := range candidates {
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return exec.CommandContext(ctx, args[0], args[1:]...), nil
+		}
+	}
+	return nil, fmt.Errorf("no clipboard tool found (install wl-clipboard, xclip, or xsel)")
+}
+
+func copyToClipboard(ctx context.Context, goos, text string) error {
+	cmd, err := clipboardCommand(ctx, goos)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// pasteCommand returns the platform's clipboard reader, writing stdout.
+func pasteCommand(ctx context.Context, goos string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		return exec.CommandContext(ctx, "pbpaste"), nil
+	case "windows":
+		return exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"), nil
+	}
+	candidates := [][]string{
+		{"xclip", "-selection", "clipboard", "-o"},
+		{"xsel", "--clipboard", "--output"},
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append([][]string{{"wl-paste", "--no-newline"}}, candidates...)
+	}
+	for _, args := range candidates {
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return exec.CommandContext(ctx, args[0], args[1:]...), nil
+		}
+	}
+	return nil, fmt.Errorf("no clipboard tool found (install wl-clipboard, xclip, or xsel)")
+}
+
+// readClipboard returns the clipboard's text. Clipboards holding only
+// non-text data, such as an image, fail or read as empty.
+func readClipboard(ctx context.Context, goos string) (string, error) {
+	cmd, err := pasteCommand(ctx, goos)
+	if err != nil {
+		return "", err
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read clipboard: %w", err)
+	}
+	return strings.ToValidUTF8(string(out), ""), nil
+}
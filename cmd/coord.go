@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/daikw/ccpersona/internal/coord"
+	"github.com/rs/zerolog/log"
+)
+
+// claimProject claims a resource of the project in dir. busy reports a hook
+// that should skip its work because the resource stayed claimed. A command
+// that times out, or any other failure, proceeds uncoordinated with a nil
+// claim, which is safe to release.
+func claimProject(dir string, resource coord.Resource, priority coord.Priority) (claim *coord.Claim, busy bool) {
+	claim, err := coord.Acquire(dir, resource, priority)
+	switch {
+	case err == nil:
+		return claim, false
+	case errors.Is(err, coord.ErrBusy) && priority == coord.Hook:
+		log.Debug().Str("resource", string(resource)).Msg("Project is busy in another ccpersona process")
+		return nil, true
+	case errors.Is(err, coord.ErrBusy):
+		log.Warn().Str("resource", string(resource)).Msg("Another ccpersona process is still busy with this project; going ahead")
+	default:
+		log.Debug().Err(err).Msg("Cannot coordinate with other ccpersona processes")
+	}
+	return nil, false
+}
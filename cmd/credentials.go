@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/daikw/ccpersona/internal/voice/provider"
+)
+
+// credentialCheckTimeout bounds the live request that checks a saved key.
+const credentialCheckTimeout = 15 * time.Second
+
+// credentials are the voice settings that decide whether a provider accepts
+// requests.
+type credentials struct {
+	provider, apiKey, baseURL, region string
+}
+
+func voiceCredentials(config *persona.Config) (credentials, voice.VoiceOptions) {
+	opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "")
+	return credentials{opts.Provider, opts.APIKey, opts.BaseURL, opts.Region}, opts
+}
+
+// checkSavedCredentials validates the voice credentials of the config at
+// path when they differ from before, the config as it was before the edit.
+// The file stays as saved; a rejected key is reported as a config error so
+// it is noticed now rather than when a hook stays silent.
+func checkSavedCredentials(ctx context.Context, before *persona.Config, path string) error {
+	after, err := persona.LoadConfigFromPath(path)
+	if err != nil || after == nil {
+		return nil
+	}
+	was, _ := voiceCredentials(before)
+	now, opts := voiceCredentials(after)
+	if now == was {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, credentialCheckTimeout)
+	defer cancel()
+	account, err := voice.CheckCredentials(ctx, opts)
+	if err != nil {
+		fmt.Printf("%s %s credentials: %v\n", cliui.Failure("✗"), opts.Provider, err)
+		return configError(fmt.Errorf("%s did not accept the saved credentials", opts.Provider))
+	}
+	if account == nil && (opts.Provider == voice.ProviderAuto || voice.IsLocalProvider(opts.Provider)) {
+		return nil
+	}
+	fmt.Printf("%s %s credentials work%s\n", cliui.Success("✓"), opts.Provider, accountSummary(account))
+	return nil
+}
+
+// accountSummary formats the plan and quota of an account, e.g.
+// " (creator plan, 12,345 of 100,000 characters used, resets Nov 1)".
+func accountSummary(account *provider.Account) string {
+	if account == nil {
+		return ""
+	}
+	var parts []string
+	if account.Plan != "" {
+		parts = append(parts, account.Plan+" plan")
+	}
+	if account.Limit > 0 {
+		parts = append(parts, fmt.Sprintf("%s of %s %s used", groupDigits(account.Used), groupDigits(account.Limit), account.Unit))
+	}
+	if !account.ResetAt.IsZero() {
+		parts = append(parts, "resets "+account.ResetAt.Local().Format("Jan 2"))
+	}
+	if account.Detail != "" {
+		parts = append(parts, account.Detail)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// groupDigits writes n with thousands separators.
+func groupDigits(n int64) string {
+	if n < 0 {
+		return "-" + groupDigits(-n)
+	}
+	s := fmt.Sprint(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
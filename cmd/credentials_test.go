@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice/provider"
+)
+
+func TestCheckSavedCredentials(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer sk-good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": [{"id": "tts-1"}]}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "ccpersona.json")
+	save := func(key string) {
+		t.Helper()
+		data := `{"voice": {"provider": "openai", "api_key": "` + key + `", "base_url": "` + server.URL + `"}}`
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := &persona.Config{Voice: &persona.VoiceConfig{Provider: "openai", APIKey: "sk-good", BaseURL: server.URL}}
+
+	save("sk-good")
+	if err := checkSavedCredentials(context.Background(), before, path); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 0 {
+		t.Error("unchanged credentials should not be checked")
+	}
+
+	save("sk-bad")
+	err := checkSavedCredentials(context.Background(), before, path)
+	if exitCode(err) != exitConfig {
+		t.Errorf("rejected key: err = %v, want a config error", err)
+	}
+
+	before.Voice.APIKey = "sk-old"
+	save("sk-good")
+	if err := checkSavedCredentials(context.Background(), before, path); err != nil {
+		t.Errorf("accepted key: %v", err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("requests = %d, want 2", requests.Load())
+	}
+}
+
+func TestAccountSummary(t *testing.T) {
+	reset := time.Date(2026, 11, 1, 12, 0, 0, 0, time.Local)
+	got := accountSummary(&provider.Account{Plan: "creator", Used: 12345, Limit: 100000, Unit: "characters", ResetAt: reset})
+	if want := " (creator plan, 12,345 of 100,000 characters used, resets Nov 1)"; got != want {
+		t.Errorf("accountSummary = %q, want %q", got, want)
+	}
+	if got := accountSummary(&provider.Account{}); got != "" {
+		t.Errorf("empty account = %q", got)
+	}
+	if got := accountSummary(nil); got != "" {
+		t.Errorf("nil account = %q", got)
+	}
+}
+
+func TestGroupDigits(t *testing.T) {
+	for n, want := range map[int64]string{0: "0", 999: "999", 1000: "1,000", 1234567: "1,234,567", -4200: "-4,200"} {
+		if got := groupDigits(n); got != want {
+			t.Errorf("groupDigits(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/coord"
+	"github.com/daikw/ccpersona/internal/daemon"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// daemonAvailabilityTTL is how long the daemon trusts an engine or provider
+// that answered, instead of asking before every message.
+const daemonAvailabilityTTL = 30 * time.Second
+
+func handleDaemon(ctx context.Context, c *cli.Command) error {
+	statePath, err := daemon.StatePath()
+	if err != nil {
+		return fmt.Errorf("failed to locate the daemon state file: %w", err)
+	}
+	if status, err := daemon.Query(ctx); err == nil {
+		return fmt.Errorf("a daemon is already running (pid %d); stop it with 'ccpersona runtime daemon stop'", status.PID)
+	}
+
+	listener, err := net.Listen("tcp", c.String("listen"))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	state, err := daemon.NewState(listener.Addr().String())
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+	if err := state.Save(statePath); err != nil {
+		_ = listener.Close()
+		return fmt.Errorf("failed to write %s: %w", statePath, err)
+	}
+	defer state.Remove(statePath)
+
+	voice.CacheAvailability(daemonAvailabilityTTL)
+	srv := daemon.NewServer(state, daemonSpeaker{})
+	server := &http.Server{
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-srv.Stopped():
+			stop()
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	go srv.Run(ctx)
+
+	fmt.Printf("Speech daemon on %s (Ctrl-C to stop)\n", cliui.Label(listener.Addr().String()))
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func handleDaemonStatus(ctx context.Context, c *cli.Command) error {
+	status, err := daemon.Query(ctx)
+	if errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Println(cliui.Muted("The speech daemon is not running; hooks speak by themselves."))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s running (pid %d) since %s\n", cliui.Success("✓"), status.PID, status.Started.Local().Format(time.DateTime))
+	fmt.Printf("  %s %d\n", cliui.Label("queued:"), status.Queued)
+	fmt.Printf("  %s %d\n", cliui.Label("spoken:"), status.Spoken)
+	fmt.Printf("  %s %d\n", cliui.Label("failed:"), status.Failed)
+	return nil
+}
+
+func handleDaemonStop(ctx context.Context, c *cli.Command) error {
+	if err := daemon.Stop(ctx); err != nil {
+		if errors.Is(err, daemon.ErrNotRunning) {
+			fmt.Println(cliui.Muted("The speech daemon is not running."))
+			return nil
+		}
+		return err
+	}
+	fmt.Printf("%s Stopped the speech daemon\n", cliui.Success("✓"))
+	return nil
+}
+
+// daemonSpeaker speaks daemon requests the way a hook process would have,
+// in the project the request came from.
+type daemonSpeaker struct{}
+
+func (daemonSpeaker) Synthesize(ctx context.Context, req daemon.Request) (string, error) {
+	config := *req.Config
+	config.Persona = req.Persona
+	audioFile, err := voice.NewVoiceManager(&config).Synthesize(ctx, req.Text, req.Options)
+	if err != nil {
+		return "", err
+	}
+	recordSpeechIn(req.Dir, req.Persona, req.Options, req.Text, audioFile)
+	return audioFile, nil
+}
+
+func (daemonSpeaker) Play(ctx context.Context, req daemon.Request, audioFile string) error {
+	priority := coord.Hook
+	if req.Event == "" {
+		priority = coord.Interactive
+	}
+	claim, busy := claimProject(req.Dir, coord.Audio, priority)
+	if busy {
+		log.Info().Str("event", req.Event).Str("dir", req.Dir).Msg("Another ccpersona process is speaking in this project; skipping audio")
+		voice.DiscardAudio(audioFile)
+		return nil
+	}
+	defer claim.Release()
+	config := *req.Config
+	config.Persona = req.Persona
+	return voice.NewVoiceEngine(&config).PlayWithOptions(audioFile, true)
+}
+
+// forwardSpeech hands text to the speech daemon when one runs, and reports
+// whether it took it. The daemon answers once the message was played unless
+// voice.playback detaches event; err is then its failure to speak. Audio
+// written to a file or stdout is never forwarded.
+func forwardSpeech(ctx context.Context, config *persona.Config, voiceConfig *voice.Config, opts voice.VoiceOptions, event, text string) (forwarded bool, err error) {
+	if opts.OutputPath != "" || opts.ToStdout || opts.Subtitles != "" || voice.IsMuted() {
+		return false, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return false, nil
+	}
+	err = daemon.Forward(ctx, daemon.Request{
+		Text:    text,
+		Options: opts,
+		Config:  voiceConfig,
+		Persona: voiceConfig.Persona,
+		Event:   event,
+		Dir:     dir,
+		Wait:    config.PlaybackMode(event) == persona.PlaybackWait,
+	})
+	if errors.Is(err, daemon.ErrNotRunning) {
+		log.Debug().Err(err).Msg("Speaking without the daemon")
+		return false, nil
+	}
+	return true, err
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/daemon"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+)
+
+// requestSpeaker keeps the requests a test daemon received.
+type requestSpeaker struct {
+	requests chan daemon.Request
+}
+
+func (s requestSpeaker) Synthesize(ctx context.Context, req daemon.Request) (string, error) {
+	s.requests <- req
+	return "", nil
+}
+
+func (s requestSpeaker) Play(ctx context.Context, req daemon.Request, audioFile string) error {
+	return nil
+}
+
+func TestForwardSpeech(t *testing.T) {
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("HOME", cache)
+	t.Setenv(daemon.DisableEnv, "")
+	t.Setenv(voice.EnvTestMode, "")
+	t.Setenv(voice.EnvMute, "")
+
+	config := &persona.Config{Name: "fable", Voice: &persona.VoiceConfig{Playback: map[string]string{"Stop": persona.PlaybackDetach}}}
+	opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "")
+	voiceConfig := opts.ToConfig(config.VoiceBaseConfig())
+
+	if forwarded, _ := forwardSpeech(context.Background(), config, voiceConfig, opts, "Stop", "hello"); forwarded {
+		t.Fatal("forwarded without a daemon")
+	}
+
+	state, err := daemon.NewState("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	speaker := requestSpeaker{requests: make(chan daemon.Request, 1)}
+	srv := daemon.NewServer(state, speaker)
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.Run(ctx)
+	state.Addr = strings.TrimPrefix(ts.URL, "http://")
+	path, err := daemon.StatePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	toFile := opts
+	toFile.OutputPath = "out.wav"
+	if forwarded, _ := forwardSpeech(context.Background(), config, voiceConfig, toFile, "Stop", "hello"); forwarded {
+		t.Error("forwarded audio meant for a file")
+	}
+
+	forwarded, err := forwardSpeech(context.Background(), config, voiceConfig, opts, "Stop", "hello")
+	if !forwarded || err != nil {
+		t.Fatalf("forwardSpeech() = %v, %v; want forwarded", forwarded, err)
+	}
+	req := <-speaker.requests
+	wd, _ := os.Getwd()
+	if req.Text != "hello" || req.Persona != "fable" || req.Event != "Stop" || req.Dir != wd || req.Wait {
+		t.Errorf("request = %+v", req)
+	}
+}
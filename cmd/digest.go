@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/detach"
+	"github.com/daikw/ccpersona/internal/notify"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// collectDigest queues a spoken announcement for the digest instead of
+// speaking it, and reports whether it did. The first queued event starts a
+// detached `runtime notify digest` that speaks the summary after the
+// interval; if it cannot start, the summary is spoken at once. A summary
+// that is overdue, because that process never ran, is spoken first.
+func collectDigest(ctx context.Context, config *persona.Config, event notify.Event, urgency string) bool {
+	if config == nil || config.Notifications == nil || !config.Notifications.Digest.Enabled() {
+		return false
+	}
+	digest := config.Notifications.Digest
+	entries, err := notify.NewDigest().TakeOverdue(digest.Period(), time.Now())
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read notification digest")
+	}
+	speakDigest(ctx, config, entries)
+	if !digest.Collects(event.Name, urgency) {
+		return false
+	}
+	first, err := notify.NewDigest().Add(notify.DigestEntry{Time: time.Now(), Event: event.Name, Text: event.Text, Project: event.Project})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to queue notification digest entry")
+		return false
+	}
+	if first {
+		if err := startDigest(digest.Period()); err != nil {
+			log.Warn().Err(err).Msg("Failed to schedule the notification digest, speaking it now")
+			flushDigest(ctx, config)
+		}
+	}
+	return true
+}
+
+func startDigest(after time.Duration) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return detach.Start(exec.Command(self, "runtime", "notify", "digest", "--after", after.String()))
+}
+
+// handleNotifyDigest speaks the queued digest, after --after when started by
+// a hook.
+func handleNotifyDigest(ctx context.Context, c *cli.Command) error {
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-time.After(c.Duration("after")):
+	}
+	flushDigest(ctx, loadUnifiedConfig(c, ""))
+	return nil
+}
+
+// flushDigest empties the digest queue and speaks its summary, unless voice
+// is muted or do-not-disturb is active, which drops it.
+func flushDigest(ctx context.Context, config *persona.Config) {
+	entries, err := notify.NewDigest().Take()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read notification digest")
+		return
+	}
+	speakDigest(ctx, config, entries)
+}
+
+func speakDigest(ctx context.Context, config *persona.Config, entries []notify.DigestEntry) {
+	summary := notify.DigestSummary(entries, time.Now())
+	if summary == "" {
+		return
+	}
+	if voice.IsMuted() || dndActive(config) {
+		log.Debug().Int("events", len(entries)).Msg("voice is muted or do-not-disturb is active, dropping notification digest")
+		return
+	}
+	if err := speakMessage(ctx, config, "", summary); err != nil {
+		log.Warn().Err(err).Msg("Failed to speak notification digest")
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/engine"
+	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
+)
+
+func doctorCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "doctor",
+		Usage:       "Check hooks, config, voice engines, audio, and personas, and suggest fixes",
+		Description: "Runs every check and prints ✓, ! (warning), or ✗ (problem) for each, with the command\nor edit that fixes it. Cloud API keys are tried with a live request unless --offline.\nExits 1 when a check fails, so it can gate scripts.",
+		Action:      handleDoctor,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "offline",
+				Usage: "Skip the live API key check; only check that a key is set",
+			},
+		},
+	}
+}
+
+type doctorStatus int
+
+const (
+	doctorOK doctorStatus = iota
+	doctorWarn
+	doctorFail
+)
+
+// doctorCheck is one finding: what was checked, what was found, and for
+// warnings and problems, how to fix it.
+type doctorCheck struct {
+	status doctorStatus
+	name   string
+	detail string
+	fix    string
+}
+
+type doctorGroup struct {
+	title  string
+	checks []doctorCheck
+}
+
+func handleDoctor(ctx context.Context, c *cli.Command) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	config := loadUnifiedConfig(c, "")
+
+	groups := []doctorGroup{
+		{"Hooks", doctorHookChecks(".", homeDir)},
+		{"Config", doctorConfigChecks(".", homeDir)},
+		{"Voice", doctorVoiceChecks(ctx, config, c.Bool("offline"))},
+		{"Audio", doctorAudioChecks()},
+		{"Personas", doctorPersonaChecks(config)},
+	}
+	problems, warnings := printDoctor(groups)
+
+	fmt.Println()
+	switch {
+	case problems > 0:
+		return fmt.Errorf("%d problem(s), %d warning(s)", problems, warnings)
+	case warnings > 0:
+		fmt.Println(cliui.Warn(fmt.Sprintf("%d warning(s); ccpersona works, with the limits above.", warnings)))
+	default:
+		fmt.Println(cliui.Success("All checks passed."))
+	}
+	return nil
+}
+
+// printDoctor prints the groups and counts the problems and warnings.
+func printDoctor(groups []doctorGroup) (problems, warnings int) {
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(cliui.Header(group.title))
+		for _, check := range group.checks {
+			mark := cliui.Success("✓")
+			switch check.status {
+			case doctorWarn:
+				mark = cliui.Warn("!")
+				warnings++
+			case doctorFail:
+				mark = cliui.Failure("✗")
+				problems++
+			}
+			line := check.name
+			if check.detail != "" {
+				line += ": " + check.detail
+			}
+			fmt.Printf("  %s %s\n", mark, line)
+			if check.fix != "" {
+				fmt.Printf("      %s %s\n", cliui.Label("fix:"), check.fix)
+			}
+		}
+	}
+	return problems, warnings
+}
+
+// doctorHookChecks reports the agent settings files under the project and
+// home directories that run ccpersona, and whether any does.
+func doctorHookChecks(projectDir, homeDir string) []doctorCheck {
+	var checks []doctorCheck
+	installed := false
+	barePath := false
+	for _, base := range []string{projectDir, homeDir} {
+		for _, path := range hook.SettingsFiles(base) {
+			commands, err := hook.Commands(path)
+			if err != nil {
+				checks = append(checks, doctorCheck{doctorFail, path, err.Error(), "fix the syntax error in " + path})
+				continue
+			}
+			if filepath.Base(path) == "settings.json" || filepath.Base(path) == "settings.local.json" {
+				if _, issues, err := claudeSettingsIssues(path); err == nil {
+					for _, issue := range issues {
+						// Legacy commands still run; config migrate updates them.
+						if strings.Contains(issue, "config migrate") {
+							checks = append(checks, doctorCheck{doctorWarn, path, issue, "ccpersona config migrate"})
+							continue
+						}
+						checks = append(checks, doctorCheck{doctorFail, path, issue, "edit " + path})
+					}
+				}
+			}
+			if len(commands) == 0 {
+				continue
+			}
+			installed = true
+			checks = append(checks, doctorCheck{status: doctorOK, name: path, detail: strings.Join(commands, "; ")})
+			for _, command := range commands {
+				if fields := strings.Fields(command); len(fields) > 0 && fields[0] == "ccpersona" {
+					barePath = true
+				}
+			}
+		}
+	}
+
+	if !installed {
+		checks = append(checks, doctorCheck{doctorFail, "agent hooks", "no agent settings file runs ccpersona",
+			"ccpersona config integrate claude --global (Cursor, Codex, and Windsurf: see README.ai.md, Hook Integration)"})
+	}
+	if _, err := exec.LookPath("ccpersona"); barePath && err != nil {
+		checks = append(checks, doctorCheck{doctorFail, "ccpersona on PATH", "hooks run \"ccpersona\" but it is not on PATH",
+			"add the directory of the ccpersona binary to PATH, or use its absolute path in the hook commands"})
+	}
+	return checks
+}
+
+// doctorConfigChecks loads the project and global config files strictly,
+// and flags legacy files that only `config migrate` still reads.
+func doctorConfigChecks(projectDir, homeDir string) []doctorCheck {
+	var checks []doctorCheck
+	found := false
+	for _, base := range []string{projectDir, homeDir} {
+		path := persona.ConfigPath(base)
+		config, err := persona.LoadConfigFromPath(path)
+		switch {
+		case err != nil:
+			checks = append(checks, doctorCheck{doctorFail, path, err.Error(), "ccpersona config edit"})
+			found = true
+		case config == nil:
+		default:
+			found = true
+			if err := persona.ValidateConfig(config); err != nil {
+				checks = append(checks, doctorCheck{doctorFail, path, err.Error(), "ccpersona config edit"})
+				continue
+			}
+			checks = append(checks, doctorCheck{status: doctorOK, name: path, detail: "persona " + config.Name})
+		}
+		legacy := filepath.Join(base, persona.ClaudeDir, persona.LegacyPersonaFileName)
+		if _, err := os.Stat(legacy); err == nil {
+			checks = append(checks, doctorCheck{doctorWarn, legacy, "legacy config file", "ccpersona config migrate"})
+		}
+	}
+	if !found {
+		checks = append(checks, doctorCheck{doctorWarn, "config", "no " + persona.ConfigPath(projectDir) + " or global config; built-in defaults apply",
+			"ccpersona config init"})
+	}
+	return checks
+}
+
+// doctorVoiceChecks checks the provider the config resolves to: a local
+// engine must answer, and a cloud provider needs working credentials.
+func doctorVoiceChecks(ctx context.Context, config *persona.Config, offline bool) []doctorCheck {
+	opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "")
+	name := opts.Provider
+	label := name
+	if name == "" {
+		label = "AivisSpeech or VOICEVOX (default)"
+	}
+	checks := []doctorCheck{{status: doctorOK, name: "provider", detail: label}}
+	if voice.IsMuted() {
+		checks = append(checks, doctorCheck{doctorWarn, "mute", "voice is muted", "ccpersona runtime voice unmute"})
+	}
+
+	switch {
+	case name == "" || name == voice.EngineVoicevox || name == voice.EngineAivisSpeech || name == voice.ProviderAuto:
+		return append(checks, doctorEngineChecks(name)...)
+	case voice.IsLocalProvider(name):
+		return checks
+	}
+	if err := voice.MissingCredentials(opts); err != nil {
+		return append(checks, doctorCheck{doctorFail, name + " credentials", err.Error(),
+			"export the environment variable named above, or set voice.api_key in " + persona.ConfigPath(".")})
+	}
+	if offline {
+		return append(checks, doctorCheck{status: doctorOK, name: name + " credentials", detail: "set (not tried: --offline)"})
+	}
+	ctx, cancel := context.WithTimeout(ctx, credentialCheckTimeout)
+	defer cancel()
+	account, err := voice.CheckCredentials(ctx, opts)
+	if err != nil {
+		return append(checks, doctorCheck{doctorFail, name + " credentials", err.Error(),
+			"check the API key and network access, then run 'ccpersona config doctor' again"})
+	}
+	return append(checks, doctorCheck{status: doctorOK, name: name + " credentials", detail: "accepted" + accountSummary(account)})
+}
+
+// doctorEngineChecks checks the local engines. An unreachable engine is a
+// problem when it is the provider, or with no provider set when neither
+// answers; otherwise it is a warning.
+func doctorEngineChecks(provider string) []doctorCheck {
+	var checks []doctorCheck
+	voicevox, aivis := voice.NewVoiceEngine(voice.DefaultConfig()).CheckEngines()
+	reachable := map[string]bool{voice.EngineVoicevox: voicevox, voice.EngineAivisSpeech: aivis}
+	for _, t := range engine.AllEngineTypes() {
+		name := string(t)
+		if reachable[name] {
+			checks = append(checks, doctorCheck{status: doctorOK, name: name, detail: "reachable"})
+			continue
+		}
+		status := doctorWarn
+		if name == provider || (provider == "" && !voicevox && !aivis) {
+			status = doctorFail
+		}
+		checks = append(checks, doctorCheck{status, name, "not reachable", engineFix(t)})
+	}
+	return checks
+}
+
+// engineFix tells how to get an unreachable local engine running.
+func engineFix(t engine.EngineType) string {
+	if _, err := engine.DiscoverEngine(t); err == nil {
+		return fmt.Sprintf("ccpersona runtime engine install %s, or start it manually", t)
+	}
+	if t == engine.VOICEVOX {
+		return "install VOICEVOX from https://voicevox.hiroshiba.jp/, then run 'ccpersona runtime engine install voicevox'"
+	}
+	return "install AivisSpeech from https://aivis-project.com/, then run 'ccpersona runtime engine install aivisspeech'"
+}
+
+// doctorAudioChecks reports the player voice output goes through.
+func doctorAudioChecks() []doctorCheck {
+	if player := voice.PlayerName(); player != "" {
+		return []doctorCheck{{status: doctorOK, name: "player", detail: player}}
+	}
+	fix := "install ffmpeg (ffplay)"
+	switch runtime.GOOS {
+	case "linux":
+		fix = "install alsa-utils (aplay), pulseaudio-utils (paplay), or ffmpeg (ffplay)"
+	case "darwin":
+		fix = "afplay ships with macOS; check that /usr/bin is on PATH"
+	}
+	return []doctorCheck{{doctorFail, "player", "no audio player found", fix}}
+}
+
+// doctorPersonaChecks checks that the persona directories are readable and
+// the configured persona exists.
+func doctorPersonaChecks(config *persona.Config) []doctorCheck {
+	manager, err := persona.NewManager()
+	if err != nil {
+		return []doctorCheck{{doctorFail, "personas", err.Error(), "set HOME"}}
+	}
+	personas, err := manager.ListPersonas()
+	if err != nil {
+		return []doctorCheck{{doctorFail, "personas", err.Error(), "check the permissions of the personas directory"}}
+	}
+
+	var checks []doctorCheck
+	if len(personas) == 0 {
+		checks = append(checks, doctorCheck{doctorWarn, "personas", "none installed",
+			"ccpersona persona template apply " + persona.DefaultTemplate + " <name>"})
+	} else {
+		checks = append(checks, doctorCheck{status: doctorOK, name: "personas", detail: fmt.Sprintf("%d installed", len(personas))})
+	}
+
+	if config == nil || config.Name == "" {
+		return checks
+	}
+	if !manager.PersonaExists(config.Name) {
+		checks = append(checks, doctorCheck{doctorFail, "persona " + config.Name, "configured but not installed",
+			fmt.Sprintf("ccpersona persona edit %s, or ccpersona config set-persona <existing>", config.Name)})
+	} else if _, err := manager.ReadPersonaVoice(config.Name); err != nil {
+		checks = append(checks, doctorCheck{doctorWarn, "persona " + config.Name, "voice in front matter ignored: " + err.Error(),
+			"ccpersona persona edit " + config.Name})
+	}
+	return checks
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/persona"
+)
+
+func doctorStatuses(checks []doctorCheck) map[string]doctorStatus {
+	out := map[string]doctorStatus{}
+	for _, check := range checks {
+		if prev, ok := out[check.name]; !ok || check.status > prev {
+			out[check.name] = check.status
+		}
+	}
+	return out
+}
+
+func TestDoctorHookChecks(t *testing.T) {
+	project, home := t.TempDir(), t.TempDir()
+
+	checks := doctorHookChecks(project, home)
+	if got := doctorStatuses(checks)["agent hooks"]; got != doctorFail || checks[0].fix == "" {
+		t.Fatalf("no hooks: %+v", checks)
+	}
+
+	userSettings := filepath.Join(home, ".claude", "settings.json")
+	cursorHooks := filepath.Join(project, ".cursor", "hooks.json")
+	brokenSettings := filepath.Join(project, ".claude", "settings.local.json")
+	for path, content := range map[string]string{
+		userSettings:   `{"hooks":{"Stop":[{"hooks":[{"type":"command","command":"/opt/bin/ccpersona runtime voice"}]}]}}`,
+		cursorHooks:    `{"version":1,"hooks":{"stop":[{"command":"/opt/bin/ccpersona notify"}]}}`,
+		brokenSettings: `{"hooks":`,
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statuses := doctorStatuses(doctorHookChecks(project, home))
+	if _, ok := statuses["agent hooks"]; ok {
+		t.Errorf("hooks reported missing: %v", statuses)
+	}
+	if statuses[userSettings] != doctorOK {
+		t.Errorf("%s = %v, want ok", userSettings, statuses[userSettings])
+	}
+	if statuses[cursorHooks] != doctorOK {
+		t.Errorf("%s = %v, want ok", cursorHooks, statuses[cursorHooks])
+	}
+	if statuses[brokenSettings] != doctorFail {
+		t.Errorf("%s = %v, want fail", brokenSettings, statuses[brokenSettings])
+	}
+
+	if err := os.WriteFile(userSettings, []byte(`{"hooks":{"Stop":[{"hooks":[{"type":"command","command":"/opt/bin/ccpersona voice"}]}]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := doctorStatuses(doctorHookChecks(project, home))[userSettings]; got != doctorWarn {
+		t.Errorf("legacy command = %v, want warning", got)
+	}
+}
+
+func TestDoctorConfigChecks(t *testing.T) {
+	project, home := t.TempDir(), t.TempDir()
+
+	checks := doctorConfigChecks(project, home)
+	if len(checks) != 1 || checks[0].status != doctorWarn || checks[0].fix != "ccpersona config init" {
+		t.Fatalf("no config: %+v", checks)
+	}
+
+	projectConfig := persona.ConfigPath(project)
+	writeProjectConfig(t, project, `{"name": "zundamon"}`)
+	globalConfig := persona.ConfigPath(home)
+	writeProjectConfig(t, home, `{"name": `)
+	legacy := filepath.Join(project, persona.ClaudeDir, persona.LegacyPersonaFileName)
+	if err := os.MkdirAll(filepath.Dir(legacy), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte(`{"name": "old"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := doctorStatuses(doctorConfigChecks(project, home))
+	want := map[string]doctorStatus{projectConfig: doctorOK, globalConfig: doctorFail, legacy: doctorWarn}
+	for name, status := range want {
+		if statuses[name] != status {
+			t.Errorf("%s = %v, want %v", name, statuses[name], status)
+		}
+	}
+	if _, ok := statuses["config"]; ok {
+		t.Errorf("config reported missing: %v", statuses)
+	}
+}
+
+func TestDoctorVoiceChecks_MissingKey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ELEVENLABS_API_KEY", "")
+	config := &persona.Config{Name: "zundamon", Voice: &persona.VoiceConfig{Provider: "elevenlabs"}}
+
+	statuses := doctorStatuses(doctorVoiceChecks(context.Background(), config, true))
+	if statuses["elevenlabs credentials"] != doctorFail {
+		t.Fatalf("missing key = %v", statuses)
+	}
+
+	t.Setenv("ELEVENLABS_API_KEY", "xi-test")
+	checks := doctorVoiceChecks(context.Background(), config, true)
+	if statuses := doctorStatuses(checks); statuses["elevenlabs credentials"] != doctorOK {
+		t.Fatalf("key from env = %+v", checks)
+	}
+}
+
+func TestPrintDoctor(t *testing.T) {
+	problems, warnings := printDoctor([]doctorGroup{
+		{"Hooks", []doctorCheck{{status: doctorOK, name: "a"}, {doctorFail, "b", "broken", "fix b"}}},
+		{"Audio", []doctorCheck{{doctorWarn, "c", "odd", "fix c"}, {doctorFail, "d", "broken", ""}}},
+	})
+	if problems != 2 || warnings != 1 {
+		t.Fatalf("problems, warnings = %d, %d; want 2, 1", problems, warnings)
+	}
+}
+
+func TestDoctorVoiceChecks_LocalProvider(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	checks := doctorVoiceChecks(context.Background(), &persona.Config{Name: "x", Voice: &persona.VoiceConfig{Provider: "sherpa-cli"}}, true)
+	for _, check := range checks {
+		if strings.HasSuffix(check.name, "credentials") {
+			t.Errorf("local provider checked for credentials: %+v", check)
+		}
+	}
+}
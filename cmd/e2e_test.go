@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/harness"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/e2e golden files")
+
+// elapsedPattern matches the wall-clock durations that latency reports print.
+var elapsedPattern = regexp.MustCompile(` in [0-9.]+(ns|µs|ms|s|m[0-9.]*s?)\b`)
+
+// TestE2E pipes recorded hook payloads through the real CLI against fake TTS
+// servers. Each directory under testdata/e2e is one case:
+//
+//	args         one command line per run; {{payload}} expands to the payload
+//	payload.json stdin for every run
+//	config.json  project .agents/ccpersona.json (optional)
+//	global.json  global ~/.agents/ccpersona.json (optional), for settings
+//	             such as base_url that only the global config can set
+//	files/       copied into the project (transcripts and the like)
+//	golden.txt   expected harness log; regenerate with go test -run TestE2E -update
+//
+// Fixtures may use the placeholders documented on harness.Env.Expand.
+func TestE2E(t *testing.T) {
+	cases, err := filepath.Glob(filepath.Join("testdata", "e2e", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("no e2e cases found")
+	}
+	for _, dir := range cases {
+		dir, _ := filepath.Abs(dir)
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			runE2ECase(t, dir)
+		})
+	}
+}
+
+func runE2ECase(t *testing.T, dir string) {
+	env := harness.New(t)
+
+	if config, err := os.ReadFile(filepath.Join(dir, "config.json")); err == nil {
+		env.WriteConfig(string(config))
+	}
+	if config, err := os.ReadFile(filepath.Join(dir, "global.json")); err == nil {
+		env.WriteGlobalConfig(string(config))
+	}
+	files := filepath.Join(dir, "files")
+	_ = filepath.WalkDir(files, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel, _ := filepath.Rel(files, path)
+		env.WriteFile(filepath.ToSlash(rel), string(data))
+		return nil
+	})
+
+	payload, _ := os.ReadFile(filepath.Join(dir, "payload.json"))
+	argsData, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(ctx context.Context, args []string) error {
+		return newApp().Run(ctx, args)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(argsData)), "\n") {
+		var args []string
+		for _, field := range strings.Fields(line) {
+			if field == "{{payload}}" {
+				field = env.Expand(strings.TrimSpace(string(payload)))
+			}
+			args = append(args, field)
+		}
+		_, _ = env.Run(run, string(payload), args...)
+	}
+
+	got := env.Log()
+	// Payloads expanded into args carry temp paths; keep goldens stable.
+	got = strings.ReplaceAll(got, filepath.ToSlash(env.Project), "{{project}}")
+	got = elapsedPattern.ReplaceAllString(got, " in {{elapsed}}")
+	goldenPath := filepath.Join(dir, "golden.txt")
+	if *updateGolden {
+		if err := os.WriteFile(goldenPath, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("read golden (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("harness log mismatch (run with -update to accept)\n--- got\n%s--- want\n%s", got, want)
+	}
+}
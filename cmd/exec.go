@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v3"
+)
+
+// execLastLineMaxRunes caps how much of the command's final output line is
+// spoken; build tools can print very long lines (e.g. minified stack traces).
+const execLastLineMaxRunes = 120
+
+// ansiEscape matches terminal color/cursor sequences emitted by build tools.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+func handleExec(ctx context.Context, c *cli.Command) error {
+	args := c.Args().Slice()
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("command is required (usage: ccpersona runtime exec -- <command> [args...])")
+	}
+
+	label := c.String("label")
+	if label == "" {
+		label = strings.Join(args, " ")
+	}
+
+	tail := &lastLineWriter{}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = io.MultiWriter(os.Stdout, tail)
+	cmd.Stderr = io.MultiWriter(os.Stderr, tail)
+
+	// The terminal delivers Ctrl-C to the whole foreground process group, so
+	// the child sees it directly. Swallow it here so we can still report how
+	// the command ended.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	start := time.Now()
+	runErr := cmd.Run()
+	elapsed := time.Since(start)
+
+	exitCode := 0
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			// The command never started (not found, not executable, ...).
+			return cli.Exit(fmt.Sprintf("failed to run %s: %v", args[0], runErr), 127)
+		}
+		exitCode = execExitCode(exitErr)
+	}
+
+	message := execResultMessage(label, exitCode, elapsed, tail.LastLine())
+	urgency := "normal"
+	if exitCode != 0 {
+		urgency = "critical"
+	}
+
+	announce(ctx, c, "exec", message, urgency)
+
+	if exitCode != 0 {
+		// Preserve the wrapped command's exit status for scripts and CI.
+		return cli.Exit("", exitCode)
+	}
+	return nil
+}
+
+// execExitCode returns the status a shell reports for the wrapped command:
+// its exit code, or 128 plus the signal number when a signal killed it,
+// where ExitCode reports -1.
+func execExitCode(exitErr *exec.ExitError) int {
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+// execResultMessage builds the short sentence announced when a wrapped
+// command finishes.
+func execResultMessage(label string, exitCode int, elapsed time.Duration, lastLine string) string {
+	var b strings.Builder
+	if exitCode == 0 {
+		fmt.Fprintf(&b, "%s succeeded in %s", label, formatElapsed(elapsed))
+	} else {
+		fmt.Fprintf(&b, "%s failed with exit code %d after %s", label, exitCode, formatElapsed(elapsed))
+	}
+	if lastLine != "" {
+		fmt.Fprintf(&b, ": %s", truncateRunes(lastLine, execLastLineMaxRunes))
+	}
+	return b.String()
+}
+
+// formatElapsed renders a duration at a precision suitable for speech.
+func formatElapsed(d time.Duration) string {
+	if d < time.Second {
+		return "less than a second"
+	}
+	return d.Round(time.Second).String()
+}
+
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "…"
+}
+
+// lastLineWriter remembers the last non-empty line written to it. Only the
+// current partial line and the previous complete line are retained, so
+// arbitrarily long command output does not grow memory.
+type lastLineWriter struct {
+	mu      sync.Mutex
+	current []byte
+	last    string
+}
+
+func (w *lastLineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, b := range p {
+		switch b {
+		case '\n', '\r':
+			w.commit()
+		default:
+			// Keep a bounded prefix of very long lines.
+			if len(w.current) < 4096 {
+				w.current = append(w.current, b)
+			}
+		}
+	}
+	return len(p), nil
+}
+
+func (w *lastLineWriter) commit() {
+	if line := cleanLine(w.current); line != "" {
+		w.last = line
+	}
+	w.current = w.current[:0]
+}
+
+// LastLine returns the most recent non-empty line, including an unterminated
+// trailing line.
+func (w *lastLineWriter) LastLine() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if line := cleanLine(w.current); line != "" {
+		return line
+	}
+	return w.last
+}
+
+func cleanLine(b []byte) string {
+	return strings.TrimSpace(ansiEscape.ReplaceAllString(string(b), ""))
+}
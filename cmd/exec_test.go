@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLastLineWriter(t *testing.T) {
+	w := &lastLineWriter{}
+	_, _ = w.Write([]byte("first\nsecond\n\n  \n"))
+	if got := w.LastLine(); got != "second" {
+		t.Errorf("LastLine() = %q, want %q", got, "second")
+	}
+
+	// An unterminated trailing line counts as the last line.
+	_, _ = w.Write([]byte("third (no newline)"))
+	if got := w.LastLine(); got != "third (no newline)" {
+		t.Errorf("LastLine() = %q, want trailing partial line", got)
+	}
+}
+
+func TestLastLineWriter_SplitWritesAndCarriageReturns(t *testing.T) {
+	w := &lastLineWriter{}
+	_, _ = w.Write([]byte("progress 10%\rprogress 100%\r\nDo"))
+	_, _ = w.Write([]byte("ne\n"))
+	if got := w.LastLine(); got != "Done" {
+		t.Errorf("LastLine() = %q, want %q", got, "Done")
+	}
+}
+
+func TestLastLineWriter_StripsANSI(t *testing.T) {
+	w := &lastLineWriter{}
+	_, _ = w.Write([]byte("\x1b[31mFAIL\x1b[0m pkg/foo\n"))
+	if got := w.LastLine(); got != "FAIL pkg/foo" {
+		t.Errorf("LastLine() = %q, want ANSI sequences stripped", got)
+	}
+}
+
+func TestExecResultMessage(t *testing.T) {
+	ok := execResultMessage("npm test", 0, 83*time.Second, "Tests: 12 passed")
+	if ok != "npm test succeeded in 1m23s: Tests: 12 passed" {
+		t.Errorf("unexpected success message: %q", ok)
+	}
+
+	failed := execResultMessage("make", 2, 400*time.Millisecond, "")
+	if failed != "make failed with exit code 2 after less than a second" {
+		t.Errorf("unexpected failure message: %q", failed)
+	}
+
+	long := execResultMessage("x", 1, time.Second, strings.Repeat("a", 500))
+	if !strings.HasSuffix(long, "…") || len([]rune(long)) > 200 {
+		t.Errorf("last line should be truncated, got %d runes", len([]rune(long)))
+	}
+}
+
+func TestExecExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell and signals")
+	}
+	for _, tt := range []struct {
+		script string
+		want   int
+	}{
+		{"exit 3", 3},
+		{"kill -TERM $$", 143},
+		{"kill -INT $$", 130},
+	} {
+		var exitErr *exec.ExitError
+		if err := exec.Command("sh", "-c", tt.script).Run(); !errors.As(err, &exitErr) {
+			t.Fatalf("%s: err = %v, want an exit error", tt.script, err)
+		}
+		if got := execExitCode(exitErr); got != tt.want {
+			t.Errorf("%s: execExitCode() = %d, want %d", tt.script, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/daikw/ccpersona/internal/trust"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
+)
+
+// Exit codes are a stable contract for wrapper scripts and hook matchers.
+// New codes are added at the end; existing ones are never renumbered.
+const (
+	exitOK          = 0
+	exitFailure     = 1
+	exitConfig      = 2
+	exitProvider    = 3
+	exitParse       = 4
+	exitInputLimit  = 5
+	exitNotFound    = 6
+	exitUsage       = 7
+	exitTrust       = 8
+	exitInterrupted = 130
+)
+
+// exitCodeInfo documents one exit code for `runtime exit-codes`.
+type exitCodeInfo struct {
+	Code    int    `json:"code"`
+	Name    string `json:"name"`
+	Meaning string `json:"meaning"`
+}
+
+var exitCodes = []exitCodeInfo{
+	{exitOK, "ok", "Success"},
+	{exitFailure, "failure", "Any failure not covered by a more specific code"},
+	{exitConfig, "config", "Config file unreadable, unparsable, or invalid"},
+	{exitProvider, "provider_unavailable", "Voice engine or provider not running, unreachable, or missing credentials"},
+	{exitParse, "parse", "Input (hook payload, JSON, transcript) could not be parsed"},
+	{exitInputLimit, "input_limit", "Input exceeded a size limit"},
+	{exitNotFound, "not_found", "Persona, file, or transcript does not exist"},
+	{exitUsage, "usage", "Unknown command or flag, or an invalid flag value"},
+	{exitTrust, "trust", "Signature verification failed or the signing key is not trusted"},
+	{exitInterrupted, "interrupted", "Interrupted by Ctrl-C or a cancelled context"},
+}
+
+// exitError attaches an exit code to an error. It deliberately does not
+// implement cli.ExitCoder, which would make the CLI library exit before
+// main reports the error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode tags err with an exit code; nil stays nil.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+func configError(err error) error { return withExitCode(exitConfig, err) }
+func usageError(err error) error  { return withExitCode(exitUsage, err) }
+
+// exitCode maps an error returned by a command to its exit code: an explicit
+// tag wins, then well-known error types, then exitFailure.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var tagged *exitError
+	if errors.As(err, &tagged) {
+		return tagged.code
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, hook.ErrInputLimit):
+		return exitInputLimit
+	case errors.Is(err, voice.ErrProviderUnavailable):
+		return exitProvider
+	case errors.Is(err, trust.ErrBadSignature), errors.Is(err, trust.ErrUntrustedKey):
+		return exitTrust
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return exitParse
+	case errors.Is(err, fs.ErrNotExist):
+		return exitNotFound
+	default:
+		return exitFailure
+	}
+}
+
+// tagUsageErrors makes flag and argument errors of cmd and its subcommands
+// exit with exitUsage. The CLI library does not inherit OnUsageError.
+func tagUsageErrors(cmd *cli.Command) {
+	cmd.OnUsageError = func(ctx context.Context, c *cli.Command, err error, isSubcommand bool) error {
+		return usageError(err)
+	}
+	for _, sub := range cmd.Commands {
+		tagUsageErrors(sub)
+	}
+}
+
+func handleExitCodes(ctx context.Context, c *cli.Command) error {
+	if c.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(exitCodes)
+	}
+	for _, info := range exitCodes {
+		fmt.Printf("%3d  %s %s\n", info.Code, cliui.Label(fmt.Sprintf("%-20s", info.Name)), info.Meaning)
+	}
+	return nil
+}
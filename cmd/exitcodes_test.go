@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/trust"
+)
+
+func TestExitCode(t *testing.T) {
+	var syntaxErr error = &json.SyntaxError{}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, exitOK},
+		{"plain", errors.New("boom"), exitFailure},
+		{"tagged config", configError(errors.New("bad")), exitConfig},
+		{"tag wins over type", withExitCode(exitUsage, &persona.NotExistError{Name: "x"}), exitUsage},
+		{"wrapped tag", fmt.Errorf("outer: %w", usageError(errors.New("bad flag"))), exitUsage},
+		{"canceled", fmt.Errorf("speak: %w", context.Canceled), exitInterrupted},
+		{"input limit", hook.ErrInputLimit, exitInputLimit},
+		{"json", fmt.Errorf("decode: %w", syntaxErr), exitParse},
+		{"missing persona", fmt.Errorf("%w\nRun 'ccpersona persona list'", &persona.NotExistError{Name: "x"}), exitNotFound},
+		{"signature", fmt.Errorf("verify: %w", trust.ErrBadSignature), exitTrust},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%s: exitCode(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestExitCodesUnique(t *testing.T) {
+	seen := map[int]bool{}
+	names := map[string]bool{}
+	for _, info := range exitCodes {
+		if seen[info.Code] || names[info.Name] {
+			t.Errorf("duplicate exit code entry %+v", info)
+		}
+		seen[info.Code], names[info.Name] = true, true
+	}
+}
+
+func TestUsageErrorExitCode(t *testing.T) {
+	err := newApp().Run(context.Background(), []string{"ccpersona", "runtime", "last", "--no-such-flag"})
+	if got := exitCode(err); got != exitUsage {
+		t.Errorf("unknown flag: exitCode(%v) = %d, want %d", err, got, exitUsage)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/experiment"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/urfave/cli/v3"
+)
+
+func handleExperimentStart(ctx context.Context, c *cli.Command) error {
+	personas := c.Args().Slice()
+	if len(personas) < 2 {
+		return fmt.Errorf("at least two personas are required (usage: ccpersona persona experiment start <a> <b> [more...])")
+	}
+
+	manager, err := persona.NewManager()
+	if err != nil {
+		return err
+	}
+	for _, name := range personas {
+		if !manager.PersonaExists(name) {
+			return fmt.Errorf("%w\nRun 'ccpersona persona list' to see available personas", &persona.NotExistError{Name: name})
+		}
+	}
+
+	err = persona.UpdateConfig(".", func(config *persona.Config) (*persona.Config, error) {
+		if config == nil {
+			config = persona.GetDefaultConfig()
+		}
+		config.Experiment = &persona.ExperimentConfig{Personas: personas}
+		return config, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("%s Experiment started: new sessions in this project alternate between %s\n", cliui.Success("✓"), strings.Join(personas, ", "))
+	fmt.Println("Register 'ccpersona runtime hook' on SessionEnd as well to record session durations.")
+	return nil
+}
+
+func handleExperimentStop(ctx context.Context, c *cli.Command) error {
+	var stopped *persona.Config
+	err := persona.UpdateConfig(".", func(config *persona.Config) (*persona.Config, error) {
+		if config == nil || config.Experiment == nil {
+			return nil, nil
+		}
+		config.Experiment = nil
+		stopped = config
+		return config, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if stopped == nil {
+		fmt.Println("No experiment is running in this project.")
+		return nil
+	}
+	fmt.Printf("%s Experiment stopped; sessions use '%s' again. The session log is kept for 'experiment report'.\n", cliui.Success("✓"), stopped.Name)
+	return nil
+}
+
+func handleExperimentReport(ctx context.Context, c *cli.Command) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	log := &experiment.Log{Path: experiment.LogPath(homeDir, projectDir)}
+	records, err := log.Records()
+	if err != nil {
+		return err
+	}
+	summaries := experiment.Report(records)
+	if len(summaries) == 0 {
+		fmt.Println("No experiment sessions recorded for this project.")
+		return nil
+	}
+
+	fmt.Printf("%-20s %8s %8s %12s %12s\n", "PERSONA", "SESSIONS", "ENDED", "AVG", "TOTAL")
+	for _, s := range summaries {
+		avg, total := "-", "-"
+		if s.Ended > 0 {
+			avg = s.AverageDuration().Round(time.Second).String()
+			total = s.TotalDuration.Round(time.Second).String()
+		}
+		fmt.Printf("%-20s %8d %8d %12s %12s\n", s.Persona, s.Sessions, s.Ended, avg, total)
+	}
+	fmt.Println()
+	fmt.Println(cliui.Muted("Durations cover sessions with a recorded SessionEnd. Log: " + log.Path))
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/usage"
+	"github.com/urfave/cli/v3"
+)
+
+// maxFeedbackComment caps a feedback note, in characters.
+const maxFeedbackComment = 500
+
+func handlePersonaFeedback(ctx context.Context, c *cli.Command) error {
+	args := c.Args().Slice()
+	if len(args) == 0 {
+		return usageError(errors.New("rating is required (usage: ccpersona persona feedback 👍|👎 [comment])"))
+	}
+	rating, ok := usage.ParseRating(args[0])
+	if !ok {
+		return usageError(fmt.Errorf("rating must be 👍 or 👎 (or up/down), got %q", args[0]))
+	}
+	comment := strings.Join(strings.Fields(strings.Join(args[1:], " ")), " ")
+	if runes := []rune(comment); len(runes) > maxFeedbackComment {
+		comment = string(runes[:maxFeedbackComment-1]) + "…"
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	entry, err := feedbackEntry(cwd, c.String("session"))
+	if err != nil {
+		return err
+	}
+	entry.Rating, entry.Comment = rating, comment
+	if err := usage.Add(entry); err != nil {
+		if errors.Is(err, usage.ErrDisabled) {
+			return configError(err)
+		}
+		return fmt.Errorf("failed to record feedback: %w", err)
+	}
+
+	target := "persona " + entry.Persona
+	if entry.Session != "" {
+		target += cliui.Muted(" (session " + shortSession(entry.Session) + ")")
+	}
+	fmt.Printf("%s %s for %s\n", cliui.Success("Recorded"), usage.RatingSymbol(rating), target)
+	return nil
+}
+
+// feedbackEntry returns a feedback entry tied to the session being rated:
+// the one named by id, else the latest session in dir. Without a recorded
+// session, the persona active in dir is rated on its own.
+func feedbackEntry(dir, id string) (usage.Entry, error) {
+	entries, err := usage.Load(time.Time{})
+	if err != nil {
+		return usage.Entry{}, err
+	}
+	var session usage.Entry
+	var found bool
+	if id != "" {
+		if session, found = usage.FindSession(entries, id); !found {
+			return usage.Entry{}, usageError(fmt.Errorf("no session %s in the usage ledger", id))
+		}
+	} else {
+		session, found = usage.LatestSession(entries, dir)
+	}
+	if found && session.Persona != "" {
+		return usage.Entry{
+			Kind:     usage.KindFeedback,
+			Project:  session.Project,
+			Persona:  session.Persona,
+			Platform: session.Platform,
+			Session:  session.Session,
+		}, nil
+	}
+
+	home, _ := os.UserHomeDir()
+	active, err := persona.ResolveActive(dir, home)
+	if err != nil {
+		return usage.Entry{}, configError(err)
+	}
+	active = persona.ApplyEnvToActive(active)
+	if active == nil || active.Name == "" {
+		return usage.Entry{}, configError(errors.New("no persona is active here; rate a session with --session"))
+	}
+	return usage.Entry{Kind: usage.KindFeedback, Project: dir, Persona: active.Name}, nil
+}
+
+// shortSession abbreviates a session ID for display.
+func shortSession(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
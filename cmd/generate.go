@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/generate"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// defaultStrictness is used when --strictness is not given and there is no
+// terminal to ask on.
+const defaultStrictness = 3
+
+func handlePersonaGenerate(ctx context.Context, c *cli.Command) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return usageError(fmt.Errorf("persona name is required (usage: ccpersona persona generate <name>)"))
+	}
+	manager, err := persona.NewManager()
+	if err != nil {
+		return err
+	}
+	if manager.PersonaExists(name) && !c.Bool("force") && !c.Bool("print") {
+		return fmt.Errorf("persona '%s' already exists (use --force to replace it)", name)
+	}
+
+	answers := generate.Answers{
+		Name:       name,
+		Goal:       c.String("goal"),
+		Tone:       c.String("tone"),
+		Language:   c.String("language"),
+		Strictness: int(c.Int("strictness")),
+	}
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		answers, err = interview(bufio.NewReader(os.Stdin), os.Stdout, answers)
+		if err != nil {
+			return err
+		}
+	} else if answers.Strictness == 0 {
+		answers.Strictness = defaultStrictness
+	}
+	if err := answers.Validate(); err != nil {
+		return usageError(err)
+	}
+
+	config := generateConfig()
+	resolved := config.Resolved()
+	fmt.Println(cliui.Muted(fmt.Sprintf("Drafting %s with %s...", name, resolved.Model)))
+	content, err := generate.Draft(ctx, http.DefaultClient, config, answers)
+	if err != nil {
+		return fmt.Errorf("failed to draft persona: %w", err)
+	}
+
+	if c.Bool("print") {
+		fmt.Print(content)
+		return nil
+	}
+
+	if missing := missingSections(content); len(missing) > 0 {
+		fmt.Printf("%s the draft has no %s section(s)\n", cliui.Warn("warning:"), strings.Join(missing, ", "))
+	}
+	findings := persona.ScanPersona([]byte(content))
+	if len(findings) > 0 {
+		fmt.Printf("%s the draft has %d finding(s); review it before use:\n", cliui.Warn("warning:"), len(findings))
+		for _, f := range findings {
+			fmt.Printf("  - %s\n", f)
+		}
+	}
+
+	path, err := manager.ImportPersona(name, []byte(content), nil, c.Bool("force"))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s persona %s %s\n", cliui.Success("Generated"), name, cliui.Muted("("+path+")"))
+	if c.Bool("no-edit") {
+		return nil
+	}
+	return openEditor(path)
+}
+
+// interview asks for the answers that flags did not provide.
+func interview(in *bufio.Reader, out io.Writer, answers generate.Answers) (generate.Answers, error) {
+	ask := func(question, fallback string) (string, error) {
+		if fallback != "" {
+			fmt.Fprintf(out, "%s %s: ", question, cliui.Muted("["+fallback+"]"))
+		} else {
+			fmt.Fprintf(out, "%s: ", question)
+		}
+		line, err := in.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			return line, nil
+		}
+		if err != nil && (err != io.EOF || fallback == "") {
+			return "", fmt.Errorf("interview ended: %w", err)
+		}
+		return fallback, nil
+	}
+
+	var err error
+	for answers.Goal == "" {
+		if answers.Goal, err = ask("What should this persona help with? (e.g. review Go services)", ""); err != nil {
+			return answers, err
+		}
+	}
+	if answers.Tone == "" {
+		if answers.Tone, err = ask("How should it talk? (e.g. calm and encouraging)", "friendly and concise"); err != nil {
+			return answers, err
+		}
+	}
+	if answers.Language == "" {
+		if answers.Language, err = ask("Which language should it speak?", "ja"); err != nil {
+			return answers, err
+		}
+	}
+	for answers.Strictness == 0 {
+		reply, err := ask("How strict, from 1 (relaxed) to 5 (uncompromising)?", strconv.Itoa(defaultStrictness))
+		if err != nil {
+			return answers, err
+		}
+		level, convErr := strconv.Atoi(reply)
+		if convErr != nil || level < 1 || level > 5 {
+			fmt.Fprintln(out, "Enter a number from 1 to 5.")
+			continue
+		}
+		answers.Strictness = level
+	}
+	return answers, nil
+}
+
+// missingSections returns the headings every persona has that content lacks.
+func missingSections(content string) []string {
+	have := map[string]bool{}
+	for _, h := range persona.Headings(content) {
+		have[h.Title] = true
+	}
+	var missing []string
+	for _, section := range generate.Sections {
+		if !have[section] {
+			missing = append(missing, section)
+		}
+	}
+	return missing
+}
+
+// generateConfig returns the generate settings from the global config.
+func generateConfig() *generate.Config {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	config, err := persona.LoadConfigFromPath(persona.ConfigPath(homeDir))
+	if err != nil {
+		log.Warn().Err(err).Msg("Ignoring generate settings from unreadable global config")
+		return nil
+	}
+	if config == nil {
+		return nil
+	}
+	return config.Generate
+}
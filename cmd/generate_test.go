@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/generate"
+)
+
+func TestInterview(t *testing.T) {
+	in := bufio.NewReader(strings.NewReader("\nreview Go services\n\nEnglish\n9\n4\n"))
+	got, err := interview(in, io.Discard, generate.Answers{Name: "calm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := generate.Answers{Name: "calm", Goal: "review Go services", Tone: "friendly and concise", Language: "English", Strictness: 4}
+	if got != want {
+		t.Errorf("interview = %+v, want %+v", got, want)
+	}
+
+	// Answers from flags are not asked again.
+	flags := generate.Answers{Name: "calm", Goal: "g", Tone: "t", Language: "ja", Strictness: 2}
+	got, err = interview(bufio.NewReader(strings.NewReader("")), io.Discard, flags)
+	if err != nil || got != flags {
+		t.Errorf("interview = %+v, %v; want %+v", got, err, flags)
+	}
+
+	if _, err := interview(bufio.NewReader(strings.NewReader("")), io.Discard, generate.Answers{Name: "calm"}); err == nil {
+		t.Error("expected an error when input ends before the goal")
+	}
+}
+
+func TestMissingSections(t *testing.T) {
+	got := missingSections("# 人格: calm\n\n## 口調\nok\n\n## 価値観\n- ok\n")
+	if strings.Join(got, ",") != "考え方,専門性,対話スタイル" {
+		t.Errorf("missingSections = %v", got)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/gitevent"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+func handleIntegrateGit(ctx context.Context, c *cli.Command) error {
+	top, err := gitevent.TopLevel(ctx, ".")
+	if err != nil {
+		return fmt.Errorf("not inside a git repository: %w", err)
+	}
+	hooksDir, err := gitevent.HooksDir(ctx, ".")
+	if err != nil {
+		return err
+	}
+
+	hooks := c.StringSlice("hooks")
+	if len(hooks) == 0 {
+		hooks = gitevent.Hooks
+	}
+
+	if c.Bool("uninstall") {
+		removed, err := gitevent.Uninstall(hooksDir, hooks)
+		if err != nil {
+			return err
+		}
+		err = persona.UpdateConfig(top, func(config *persona.Config) (*persona.Config, error) {
+			if config == nil || config.Git == nil || !config.Git.Enabled {
+				return nil, nil
+			}
+			config.Git.Enabled = false
+			return config, nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		if len(removed) == 0 {
+			fmt.Println("No ccpersona git hooks were installed.")
+			return nil
+		}
+		fmt.Printf("%s Removed git hooks: %s\n", cliui.Success("✓"), strings.Join(removed, ", "))
+		return nil
+	}
+
+	result, err := gitevent.Install(hooksDir, hooks)
+	if err != nil {
+		return err
+	}
+
+	err = persona.UpdateConfig(top, func(config *persona.Config) (*persona.Config, error) {
+		if config == nil {
+			config = persona.GetDefaultConfig()
+		}
+		if config.Git == nil {
+			config.Git = &persona.GitConfig{}
+		}
+		config.Git.Enabled = true
+		return config, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if len(result.Installed) > 0 {
+		fmt.Printf("%s Installed git hooks in %s: %s\n", cliui.Success("✓"), hooksDir, strings.Join(result.Installed, ", "))
+	}
+	for _, hook := range result.Skipped {
+		fmt.Printf("%s %s already exists and was not written by ccpersona; add this line to it manually:\n", cliui.Warn("!"), hook)
+		fmt.Printf("  ccpersona runtime git-event %s \"$@\" </dev/null >/dev/null 2>&1 &\n", hook)
+	}
+	fmt.Printf("Announcements enabled in %s\n", persona.ConfigPath(top))
+	return nil
+}
+
+// handleGitEvent is invoked by installed git hooks. It only speaks when the
+// repository's own config has opted in, so a global install of the hooks (for
+// example via core.hooksPath) stays silent elsewhere.
+func handleGitEvent(ctx context.Context, c *cli.Command) error {
+	hook := c.Args().First()
+	if !gitevent.IsSupported(hook) {
+		return fmt.Errorf("unsupported git hook: %q (supported: %s)", hook, strings.Join(gitevent.Hooks, ", "))
+	}
+
+	top, err := gitevent.TopLevel(ctx, ".")
+	if err != nil {
+		return fmt.Errorf("not inside a git repository: %w", err)
+	}
+	// Load it like any project config, so the repository cannot choose
+	// global-only settings such as voice.base_url.
+	config, err := persona.LoadConfigForPlatform(top, "")
+	if err != nil {
+		return configError(err)
+	}
+	config = persona.WithPersonaVoice(persona.ApplyEnvOverrides(persona.ApplyWorktreeRules(persona.WithGlobalVoices(config), top)))
+	if config == nil || config.Git == nil || !config.Git.Enabled {
+		log.Debug().Str("repo", top).Msg("git announcements not enabled for this repository")
+		return nil
+	}
+	if voice.IsMuted() || dndActive(config) {
+		log.Debug().Msg("voice synthesis is muted or do-not-disturb is active, skipping git announcement")
+		return nil
+	}
+
+	ev, err := gitevent.Collect(ctx, ".", hook, c.Args().Tail(), os.Stdin)
+	if err != nil {
+		return err
+	}
+	if ev.Count == 0 {
+		log.Debug().Str("hook", hook).Msg("no commits involved, skipping git announcement")
+		return nil
+	}
+
+	message, err := gitevent.Render(config.Git.Templates[hook], ev)
+	if err != nil {
+		return err
+	}
+	if message == "" {
+		return nil
+	}
+	return speakMessage(ctx, config, "git", message)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/harness"
+)
+
+func TestGitEventIgnoresProjectBaseURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	env := harness.New(t)
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.email=test@example.com", "-c", "user.name=Test", "commit", "-q", "--allow-empty", "-m", "Fix the parser"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	var leaked atomic.Int32
+	repoHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked.Add(1)
+		http.Error(w, "unexpected", http.StatusTeapot)
+	}))
+	defer repoHost.Close()
+
+	env.WriteGlobalConfig(`{"voice": {"provider": "openai", "base_url": "{{openai}}/v1"}}`)
+	env.WriteConfig(`{
+  "git": {"enabled": true},
+  "voice": {"provider": "openai", "api_key": "sk-test", "voice": "alloy", "format": "wav", "base_url": "` + repoHost.URL + `/v1"}
+}`)
+	run := func(ctx context.Context, args []string) error {
+		return newApp().Run(ctx, args)
+	}
+	if _, err := env.Run(run, "", "runtime", "git-event", "post-commit"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := leaked.Load(); n != 0 {
+		t.Errorf("the repository's base_url received %d requests", n)
+	}
+	if requests := env.OpenAI.Requests(); len(requests) != 1 {
+		t.Errorf("global base_url requests = %v, want 1", requests)
+	}
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
 	"strings"
 
+	"github.com/daikw/ccpersona/internal/coord"
 	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/daikw/ccpersona/internal/notify"
 	"github.com/daikw/ccpersona/internal/persona"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -19,7 +22,15 @@ func handleHook(ctx context.Context, c *cli.Command) error {
 	platformHint := hookPlatformHint(c)
 
 	// Try to detect and parse hook event using unified interface
-	unifiedEvent, err := hook.DetectAndParseForSource(os.Stdin, platformHint)
+	data, err := hook.ReadInput(os.Stdin)
+	if errors.Is(err, hook.ErrInputLimit) {
+		return err
+	}
+	var unifiedEvent *hook.UnifiedHookEvent
+	if err == nil {
+		unifiedEvent, err = hook.ParseInput(data, platformHint)
+		recordCorpus(data, unifiedEvent)
+	}
 	if err != nil {
 		// Fallback to legacy behavior if no stdin data or parse error
 		log.Debug().Err(err).Msg("No hook event data from stdin, using legacy mode")
@@ -43,25 +54,60 @@ func handleHook(ctx context.Context, c *cli.Command) error {
 		Str("event_type", unifiedEvent.EventType).
 		Str("session_id", unifiedEvent.SessionID).
 		Str("cwd", unifiedEvent.CWD).
+		Str("tool_name", unifiedEvent.ToolName).
+		Str("model", unifiedEvent.Model).
+		Bool("subagent", unifiedEvent.IsSubagent).
+		Int("schema", unifiedEvent.Schema.Version).
+		Stringer("capabilities", unifiedEvent.Schema.Capabilities).
 		Msg("Received hook event")
+	recordHookSchema(unifiedEvent)
+	ctx, finishTrace := startHookTrace(ctx, "hook", unifiedEvent)
+	defer finishTrace()
+	recordHookSource(unifiedEvent.Source, unifiedEvent.EventType)
+	forwardHookEvent(ctx, c, unifiedEvent)
+	enterDND(loadUnifiedConfig(c, platform))
+
+	// A persona switch in progress finishes first, so the persona applied
+	// below is the new one. Persona output is never skipped: after the wait
+	// the config is read as it is, since writes replace it atomically.
+	switch unifiedEvent.EventType {
+	case "SessionStart", "sessionStart", "UserPromptSubmit", "pre_user_prompt":
+		claim, _ := claimProject(".", coord.Persona, coord.Hook)
+		defer claim.Release()
+	}
 
 	// Handle different event types (platform-aware)
 	switch unifiedEvent.EventType {
 	case "SessionStart":
-		log.Debug().Str("platform", platform).Msg("Processing SessionStart hook")
-		if err := persona.HandleSessionStartForPlatform(platform); err != nil {
+		log.Debug().Str("platform", platform).Str("start_source", unifiedEvent.StartSource).Msg("Processing SessionStart hook")
+		if err := persona.HandleSessionResume(platform, unifiedEvent.SessionID, unifiedEvent.StartSource, unifiedEvent.TranscriptPath); err != nil {
+			log.Error().Err(err).Msg("Failed to handle session start")
+		}
+		if persona.IsResumeSource(unifiedEvent.StartSource) {
+			startResumeCue(loadUnifiedConfig(c, platform), platform)
+		}
+
+	case "sessionStart":
+		log.Debug().Strs("roots", unifiedEvent.WorkspaceRoots).Msg("Processing Cursor sessionStart hook")
+		if err := persona.HandleSessionStartForWorkspace(platform, unifiedEvent.SessionID, unifiedEvent.WorkspaceRoots, unifiedEvent.FilePath); err != nil {
 			log.Error().Err(err).Msg("Failed to handle session start")
 		}
 
-	case "UserPromptSubmit":
+	case "UserPromptSubmit", "pre_user_prompt":
 		log.Debug().Str("platform", platform).Msg("Processing UserPromptSubmit hook (legacy)")
-		if err := persona.HandleSessionStartForPlatform(platform); err != nil {
+		notify.NewQuestionTracker(unifiedEvent.SessionID).Clear()
+		startAck(loadUnifiedConfig(c, platform), platform)
+		if err := persona.HandlePromptSubmit(platform, unifiedEvent.SessionID); err != nil {
 			log.Error().Err(err).Msg("Failed to handle session start")
 		}
 
 	case "SessionEnd":
 		log.Debug().Msg("Processing SessionEnd hook")
-		// Future: Add farewell voice synthesis or session summary
+		notify.NewQuestionTracker(unifiedEvent.SessionID).Clear()
+		if err := persona.HandleSessionEnd(unifiedEvent.SessionID); err != nil {
+			log.Warn().Err(err).Msg("Failed to record session end")
+		}
+		startTranscriptPrune()
 
 	default:
 		log.Debug().Str("event_type", unifiedEvent.EventType).Msg("Unhandled hook event type")
@@ -85,8 +131,33 @@ func hookPlatformHint(c *cli.Command) string {
 		return "claude-code"
 	case "cursor":
 		return "cursor"
+	case "windsurf":
+		return "windsurf"
 	default:
+		if hook.IsPluginSource(platform) {
+			return platform
+		}
 		log.Warn().Str("platform", platform).Msg("Ignoring unknown hook platform hint")
 		return ""
 	}
 }
+
+// recordHookSchema notes payloads with fields this build does not
+// understand, so `config status` can suggest an update. The fields are
+// ignored meanwhile; the hook goes on as usual.
+func recordHookSchema(event *hook.UnifiedHookEvent) {
+	if !event.Schema.Newer() {
+		return
+	}
+	log.Debug().
+		Strs("unknown", event.Schema.UnknownFields).
+		Strs("mismatched", event.Schema.MismatchedFields).
+		Msg("Hook payload has fields this version does not understand")
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	if err := hook.RecordSchema(hook.SchemaLogPath(homeDir), event); err != nil {
+		log.Debug().Err(err).Msg("Failed to record hook schema")
+	}
+}
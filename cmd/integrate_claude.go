@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/daikw/ccpersona/internal/settings"
+	"github.com/urfave/cli/v3"
+)
+
+// claudeHooks are the hooks `config integrate claude` manages, keyed by the
+// flag that enables them.
+var claudeHooks = []struct {
+	flag    string
+	event   string
+	command string
+}{
+	{"persona", "SessionStart", "ccpersona runtime hook"},
+	{"voice", "Stop", "ccpersona runtime voice"},
+	{"notify", "Notification", "ccpersona runtime notify"},
+}
+
+func handleIntegrateClaude(ctx context.Context, c *cli.Command) error {
+	path := settings.ProjectPath(".")
+	if c.Bool("global") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = settings.UserPath(homeDir)
+	}
+	s, err := settings.Load(path)
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	for _, h := range claudeHooks {
+		var ok bool
+		switch {
+		case c.Bool("uninstall"):
+			ok, err = s.RemoveHook(h.event, h.command)
+		case !c.Bool(h.flag):
+		case hasLegacyClaudeHook(s, h.event, h.command):
+			fmt.Printf("%s %s already runs a legacy spelling of %q; run 'ccpersona config migrate' to update it\n", cliui.Warn("!"), h.event, h.command)
+		default:
+			ok, err = s.AddHook(h.event, "", settings.Hook{Command: h.command})
+		}
+		if err != nil {
+			return err
+		}
+		if ok {
+			changed = append(changed, h.event)
+		}
+	}
+
+	if c.Bool("dry-run") {
+		data, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+		return nil
+	}
+	if len(changed) == 0 {
+		fmt.Printf("Nothing to change in %s\n", path)
+		return nil
+	}
+	if err := s.Save(); err != nil {
+		return err
+	}
+	verb := "Installed"
+	if c.Bool("uninstall") {
+		verb = "Removed"
+	}
+	fmt.Printf("%s %s Claude Code hooks in %s: %s\n", cliui.Success("✓"), verb, path, strings.Join(changed, ", "))
+	return nil
+}
+
+// hasLegacyClaudeHook reports whether event already runs command through a
+// legacy spelling that `config migrate` would rewrite to it, so installing
+// would run it twice.
+func hasLegacyClaudeHook(s *settings.Settings, event, command string) bool {
+	hooks, err := s.Hooks()
+	if err != nil {
+		return false
+	}
+	for _, m := range hooks[event] {
+		for _, h := range m.Hooks {
+			if migrated, legacy := hook.MigrateCommand(h.Command); legacy && migrated == command {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claudeSettingsIssues validates the Claude Code settings at path, adding a
+// note for ccpersona hook commands that `config migrate` would rewrite. A
+// missing file has no issues.
+func claudeSettingsIssues(path string) (*settings.Settings, []string, error) {
+	s, err := settings.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var issues []string
+	for _, issue := range s.Validate() {
+		issues = append(issues, issue.String())
+	}
+	if hooks, err := s.Hooks(); err == nil {
+		events := make([]string, 0, len(hooks))
+		for event := range hooks {
+			events = append(events, event)
+		}
+		sort.Strings(events)
+		for _, event := range events {
+			for _, m := range hooks[event] {
+				for _, h := range m.Hooks {
+					if migrated, legacy := hook.MigrateCommand(h.Command); legacy {
+						issues = append(issues, fmt.Sprintf("hooks.%s: legacy command %q (run 'ccpersona config migrate' to rewrite it as %q)", event, h.Command, migrated))
+					}
+				}
+			}
+		}
+	}
+	return s, issues, nil
+}
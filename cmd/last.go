@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
+)
+
+func handleLast(ctx context.Context, c *cli.Command) error {
+	n := int(c.Int("n"))
+	if n < 1 {
+		return usageError(fmt.Errorf("--n must be at least 1"))
+	}
+
+	projectDir := c.String("project")
+	if projectDir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		projectDir = cwd
+	}
+
+	reader := voice.NewTranscriptReader(voice.DefaultConfig())
+	transcriptPath := c.String("transcript")
+	if transcriptPath == "" {
+		var err error
+		transcriptPath, err = reader.FindProjectTranscript(projectDir)
+		if err != nil {
+			return fmt.Errorf("failed to find transcript: %w", err)
+		}
+	}
+
+	messages, err := reader.GetRecentAssistantMessages(transcriptPath, n)
+	if err != nil {
+		return fmt.Errorf("failed to read assistant messages: %w", err)
+	}
+
+	for i, message := range messages {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(cliui.Header(fmt.Sprintf("[%d/%d]", i+1, len(messages))))
+		fmt.Println(cliui.RenderMarkdown(message))
+	}
+
+	if !c.Bool("speak") {
+		return nil
+	}
+	config := loadUnifiedConfig(c, "")
+	for _, message := range messages {
+		text := strings.TrimSpace(voice.StripMarkdown(message))
+		if text == "" {
+			continue
+		}
+		if err := speakMessage(ctx, config, "", text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/coord"
 	"github.com/daikw/ccpersona/internal/persona"
 	"github.com/urfave/cli/v3"
 )
@@ -54,7 +56,7 @@ func handleSet(ctx context.Context, c *cli.Command) error {
 	if !manager.PersonaExists(name) {
 		// main prints returned errors; embed the guidance instead of
 		// pre-printing here, which produced a duplicate report.
-		return fmt.Errorf("persona '%s' does not exist\nRun 'ccpersona persona list' to see available personas", name)
+		return fmt.Errorf("%w\nRun 'ccpersona persona list' to see available personas", &persona.NotExistError{Name: name})
 	}
 
 	global := c.Bool("global")
@@ -73,19 +75,39 @@ func handleSet(ctx context.Context, c *cli.Command) error {
 		targetDir = "."
 	}
 
-	config, err := persona.LoadConfigFromPath(persona.ConfigPath(targetDir))
-	if err != nil {
-		return fmt.Errorf("failed to load existing config: %w", err)
-	}
-	if config == nil {
-		config = persona.GetDefaultConfig()
-	}
-	config.Name = name
+	// Hooks of this project wait for the switch, agent files included.
+	claim, _ := claimProject(targetDir, coord.Persona, coord.Interactive)
+	defer claim.Release()
 
-	if err := persona.SaveConfig(targetDir, config); err != nil {
+	err = persona.UpdateConfig(targetDir, func(config *persona.Config) (*persona.Config, error) {
+		if config == nil {
+			config = persona.GetDefaultConfig()
+		}
+		config.Name = name
+		return config, nil
+	})
+	if err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
 	fmt.Printf("Persona set to '%s' (%s)\n", name, scope)
+
+	// Subagents follow the persona: the previous persona's generated agent
+	// files are replaced by the new one's.
+	agentsDir := persona.ClaudeAgentsDir(targetDir)
+	result, err := manager.SyncAgents(agentsDir, name)
+	if err != nil {
+		fmt.Printf("%s Agent files were not updated: %v\n", cliui.Warn("!"), err)
+		return nil
+	}
+	for _, file := range result.Written {
+		fmt.Printf("%s Wrote agent %s\n", cliui.Success("✓"), filepath.Join(agentsDir, file))
+	}
+	for _, file := range result.Removed {
+		fmt.Printf("%s Removed agent %s\n", cliui.Success("✓"), filepath.Join(agentsDir, file))
+	}
+	for _, file := range result.Kept {
+		fmt.Printf("%s Kept %s %s\n", cliui.Warn("!"), filepath.Join(agentsDir, file), cliui.Muted("(edited or not generated by ccpersona)"))
+	}
 	return nil
 }
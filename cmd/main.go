@@ -4,8 +4,20 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/daikw/ccpersona/internal/analytics"
+	"github.com/daikw/ccpersona/internal/avatar"
 	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/corpus"
+	"github.com/daikw/ccpersona/internal/daemon"
+	"github.com/daikw/ccpersona/internal/hub"
+	"github.com/daikw/ccpersona/internal/notify"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/scrub"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/daikw/ccpersona/internal/voice/provider"
+	"github.com/daikw/ccpersona/internal/webpush"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
@@ -18,31 +30,39 @@ var (
 
 func main() {
 	// Setup logger
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: scrub.Writer(os.Stderr)})
 
 	app := newApp()
 	if err := app.Run(context.Background(), os.Args); err != nil {
 		// Command handlers own their user-facing messages; report the error
 		// once in plain CLI form instead of a zerolog FTL record.
 		fmt.Fprintf(os.Stderr, "%s %v\n", cliui.Failure("error:"), err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
 func newApp() *cli.Command {
-	return &cli.Command{
+	app := &cli.Command{
 		Name:  "ccpersona",
 		Usage: "Claude Code Persona System - manage personas for Claude Code sessions",
 		Description: `ccpersona helps you manage different personas for Claude Code.
 It allows you to switch between different personality settings, voice configurations,
 and behavioral patterns for your AI assistant.`,
-		Version: fmt.Sprintf("%s (rev: %s)", version, revision),
+		Version: fmt.Sprintf("%s (rev: %s, %s)", version, revision, provider.BuildFlavor),
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:    "verbose",
 				Aliases: []string{"V"},
 				Usage:   "Enable verbose logging",
 			},
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Config profile to apply, such as work or home (default: $CCPERSONA_PROFILE)",
+			},
+			&cli.BoolFlag{
+				Name:  "no-redact",
+				Usage: "Show API keys, tokens, and credential assignments in logs and debug output (default: $CCPERSONA_NO_REDACT)",
+			},
 		},
 		Commands: append([]*cli.Command{
 			configCommand(),
@@ -55,9 +75,24 @@ and behavioral patterns for your AI assistant.`,
 			} else {
 				zerolog.SetGlobalLevel(zerolog.InfoLevel)
 			}
+			// The environment carries the profile to config loading and to
+			// the hooks and players this process starts.
+			if c.IsSet("profile") {
+				if err := os.Setenv(persona.EnvProfile, c.String("profile")); err != nil {
+					return ctx, err
+				}
+			}
+			if c.Bool("no-redact") {
+				if err := os.Setenv(scrub.EnvNoRedact, "1"); err != nil {
+					return ctx, err
+				}
+			}
+			analytics.Record(analytics.KindCommand, commandName(c))
 			return ctx, nil
 		},
 	}
+	tagUsageErrors(app)
+	return app
 }
 
 func hiddenRuntimeCompatibilityCommands() []*cli.Command {
@@ -67,6 +102,14 @@ func hiddenRuntimeCompatibilityCommands() []*cli.Command {
 		notifyCommand(true),
 		mcpCommand(true),
 		engineCommand(true),
+		trustCommand(true),
+		statsCommand(true),
+		rulesCommand(true),
+		lastCommand(true),
+		replCommand(true),
+		speakCommand(true),
+		transcriptsCommand(true),
+		exitCodesCommand(true),
 	}
 }
 
@@ -81,6 +124,10 @@ func configCommand() *cli.Command {
 				Aliases: []string{"g"},
 				Usage:   "Edit global settings",
 			},
+			&cli.BoolFlag{
+				Name:  "no-verify",
+				Usage: "Do not check changed API keys with a live request after editing",
+			},
 		},
 		Commands: []*cli.Command{
 			{
@@ -106,6 +153,10 @@ func configCommand() *cli.Command {
 						Aliases: []string{"g"},
 						Usage:   "Edit global config (~/.agents/ccpersona.json)",
 					},
+					&cli.BoolFlag{
+						Name:  "no-verify",
+						Usage: "Do not check changed API keys with a live request after editing",
+					},
 				},
 			},
 			{
@@ -146,22 +197,90 @@ func configCommand() *cli.Command {
 				},
 			},
 			{
-				Name:   "migrate",
-				Usage:  "Migrate legacy persona/voice config files to .agents/ccpersona.json",
-				Action: handleConfigMigrate,
+				Name:  "integrate",
+				Usage: "Install optional integrations with other tools",
+				Commands: []*cli.Command{
+					{
+						Name:   "git",
+						Usage:  "Install git hooks that announce commits, merges, and pushes for this repository",
+						Action: handleIntegrateGit,
+						Flags: []cli.Flag{
+							&cli.StringSliceFlag{
+								Name:  "hooks",
+								Usage: "Hooks to install (post-commit, post-merge, pre-push; default: all)",
+							},
+							&cli.BoolFlag{
+								Name:  "uninstall",
+								Usage: "Remove ccpersona git hooks and disable announcements",
+							},
+						},
+					},
+					{
+						Name:        "claude",
+						Usage:       "Merge ccpersona hooks into Claude Code settings.json",
+						Description: "Adds SessionStart (persona) and Stop (voice) hooks to .claude/settings.json, or\n~/.claude/settings.json with --global. Other settings and hooks are kept, existing\nccpersona hooks are not duplicated, and malformed hooks are reported instead of\noverwritten.",
+						Action:      handleIntegrateClaude,
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:    "global",
+								Aliases: []string{"g"},
+								Usage:   "Edit ~/.claude/settings.json instead of the project settings",
+							},
+							&cli.BoolFlag{
+								Name:  "persona",
+								Usage: "Apply the persona on SessionStart",
+								Value: true,
+							},
+							&cli.BoolFlag{
+								Name:  "voice",
+								Usage: "Read responses aloud on Stop",
+								Value: true,
+							},
+							&cli.BoolFlag{
+								Name:  "notify",
+								Usage: "Send desktop notifications on Notification",
+							},
+							&cli.BoolFlag{
+								Name:  "uninstall",
+								Usage: "Remove the ccpersona hooks",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "Print the resulting settings.json without writing it",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:        "migrate",
+				Usage:       "Migrate legacy config files, config fields, and hook commands to the current schema",
+				Description: "Merges legacy persona/voice files into .agents/ccpersona.json, renames legacy voice fields\n(voicevox_speaker, speed_scale, ...), and rewrites legacy hook commands (stop_hook, top-level\nvoice/notify/hook, --engine, old --mode values) in Claude Code, Cursor, and Codex settings.\nEvery change is listed.",
+				Action:      handleConfigMigrate,
 				Flags: []cli.Flag{
 					&cli.BoolFlag{
 						Name:    "global",
 						Aliases: []string{"g"},
-						Usage:   "Migrate global config to ~/.agents/ccpersona.json",
+						Usage:   "Migrate global config and settings under the home directory",
 					},
 					&cli.BoolFlag{
 						Name:  "force",
-						Usage: "Overwrite existing .agents/ccpersona.json",
+						Usage: "Overwrite existing .agents/ccpersona.json with merged legacy files",
 						Value: false,
 					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "List the changes without writing any file",
+					},
 				},
 			},
+			statsCommand(false),
+			reportCommand(),
+			rulesCommand(false),
+			sourcesCommand(),
+			voicesCommand(),
+			badgeCommand(),
+			doctorCommand(),
 		},
 	}
 }
@@ -182,12 +301,336 @@ func personaCommand() *cli.Command {
 				Usage:     "Show a persona markdown file",
 				Action:    handlePersonaShow,
 				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "rendered",
+						Aliases: []string{"r"},
+						Usage:   "Render the markdown for the terminal instead of printing it raw",
+					},
+					&cli.StringFlag{
+						Name:    "section",
+						Aliases: []string{"s"},
+						Usage:   "Print only the section with this heading, e.g. 口調",
+					},
+					&cli.BoolFlag{
+						Name:  "fold",
+						Usage: "Print only the section outline with line counts",
+					},
+					&cli.BoolFlag{
+						Name:  "expand",
+						Usage: "Resolve template placeholders such as {{.Project}} as applying the persona here would",
+					},
+					&cli.StringFlag{
+						Name:  "platform",
+						Usage: "Keep only the <!-- if: ... --> sections for this platform (claude-code, codex, cursor)",
+					},
+				},
+			},
+			{
+				Name:        "apply",
+				Usage:       "Write the persona into assistant instruction files (CLAUDE.md, AGENTS.md, .cursor/rules, GEMINI.md)",
+				Description: "Writes the current persona, or <name>, into the instruction files of the chosen assistants in the current directory.\nCLAUDE.md, AGENTS.md, and GEMINI.md keep their other content; only the ccpersona block is replaced.\nAll files are updated together: if one write fails, the others are restored.",
+				ArgsUsage:   "[name]",
+				Action:      handlePersonaApply,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all-platforms",
+						Usage: "Apply to every target: claude, codex, cursor, and gemini",
+					},
+					&cli.StringSliceFlag{
+						Name:  "target",
+						Usage: "Target to apply to (claude, codex, cursor, gemini); repeatable",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show which files would change without writing them",
+					},
+				},
 			},
 			{
 				Name:      "edit",
 				Usage:     "Edit a persona markdown file (creates if missing)",
 				Action:    handleEdit,
 				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "template",
+						Aliases: []string{"t"},
+						Usage:   "Template a missing persona starts from (see 'persona template list')",
+						Value:   persona.DefaultTemplate,
+					},
+				},
+			},
+			{
+				Name:  "template",
+				Usage: "Start personas from the built-in templates",
+				Commands: []*cli.Command{
+					{
+						Name:   "list",
+						Usage:  "List the built-in persona templates",
+						Action: handlePersonaTemplateList,
+					},
+					{
+						Name:      "apply",
+						Usage:     "Create a persona from a template",
+						ArgsUsage: "<template> [name]",
+						Action:    handlePersonaTemplateApply,
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "edit",
+								Usage: "Open the new persona in the editor",
+							},
+							&cli.BoolFlag{
+								Name:  "force",
+								Usage: "Replace an existing persona with the same name",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:      "copy",
+				Aliases:   []string{"cp"},
+				Usage:     "Copy a persona under a new name",
+				ArgsUsage: "<src> <dst>",
+				Action:    handlePersonaCopy,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Replace an existing destination persona",
+					},
+				},
+			},
+			{
+				Name:      "rename",
+				Aliases:   []string{"mv"},
+				Usage:     "Rename a persona and flag or fix configs that reference it",
+				ArgsUsage: "<old> <new>",
+				Action:    handlePersonaRename,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Replace an existing persona with the new name",
+					},
+					&cli.BoolFlag{
+						Name:  "fix-refs",
+						Usage: "Point referencing configs (name, experiment, worktrees) at the new name",
+					},
+					&cli.StringSliceFlag{
+						Name:  "search",
+						Usage: "Directories to search for project configs (default: current directory); the global config is always checked",
+					},
+				},
+			},
+			{
+				Name:      "delete",
+				Aliases:   []string{"rm"},
+				Usage:     "Delete a persona after confirmation",
+				ArgsUsage: "<name>",
+				Action:    handlePersonaDelete,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "Delete without asking",
+					},
+					&cli.StringSliceFlag{
+						Name:  "search",
+						Usage: "Directories to search for project configs that still reference it (default: current directory)",
+					},
+				},
+			},
+			{
+				Name:      "import",
+				Usage:     "Install a persona from a URL or file after scanning it for red flags",
+				ArgsUsage: "<url|path>",
+				Action:    handlePersonaImport,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "Persona name to install as (default: file name without .md)",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Replace an existing persona with the same name",
+					},
+					&cli.BoolFlag{
+						Name:  "allow-unverified",
+						Usage: "Install even if the scan flags prompt injection, hidden characters, or oversized content",
+					},
+					&cli.StringFlag{
+						Name:  "signature",
+						Usage: "Detached minisign signature URL or path (default: <source>.minisig)",
+					},
+					&cli.BoolFlag{
+						Name:  "require-signature",
+						Usage: "Refuse personas without a valid signature from a trusted key",
+					},
+				},
+			},
+			{
+				Name:        "feedback",
+				Usage:       "Rate the current session's persona with 👍 or 👎 and an optional comment",
+				ArgsUsage:   "<👍|👎> [comment]",
+				Description: "Records the rating in the local usage ledger for the latest session in this directory (or --session),\ntied to the persona that session used. Compare personas with: ccpersona config stats --by-persona\nup/down, good/bad, and + / - work where emoji are awkward to type.",
+				Action:      handlePersonaFeedback,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "session",
+						Usage: "Session ID to rate instead of the latest one here",
+					},
+				},
+			},
+			{
+				Name:        "generate",
+				Usage:       "Draft a new persona with an LLM from a short interview, then open it in the editor",
+				ArgsUsage:   "<name>",
+				Description: "Asks what the persona is for, its tone, language, and strictness (flags skip questions), sends them to the LLM\nendpoint in \"generate\" of the global config (default: the Claude API with $ANTHROPIC_API_KEY), and saves the draft\nwith the usual sections for editing. Without a terminal, --goal is required.",
+				Action:      handlePersonaGenerate,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "goal",
+						Usage: "What the persona helps with, e.g. \"review Go services for a payments team\"",
+					},
+					&cli.StringFlag{
+						Name:  "tone",
+						Usage: "How it talks, e.g. \"calm and encouraging\"",
+					},
+					&cli.StringFlag{
+						Name:  "language",
+						Usage: "Language it speaks (default: ja)",
+					},
+					&cli.IntFlag{
+						Name:  "strictness",
+						Usage: "1 (relaxed) to 5 (uncompromising) (default: 3)",
+					},
+					&cli.BoolFlag{
+						Name:  "print",
+						Usage: "Print the draft instead of saving it",
+					},
+					&cli.BoolFlag{
+						Name:  "no-edit",
+						Usage: "Save the draft without opening the editor",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Replace an existing persona with the same name",
+					},
+				},
+			},
+			{
+				Name:        "search",
+				Usage:       "Search the community persona index and optionally install a match",
+				ArgsUsage:   "[keyword]",
+				Description: "Lists shared personas from a static JSON index whose name, description, tags, or author contain the keyword, best rated first.\nThe index is --index, $CCPERSONA_INDEX, persona_index in the global config, or the ccpersona repository's index.\nWith --install, the persona named by the keyword (or the only match) is installed like `persona import`.",
+				Action:      handlePersonaSearch,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "index",
+						Usage: "Index URL or path (default: $CCPERSONA_INDEX, persona_index, or the ccpersona index)",
+					},
+					&cli.StringFlag{
+						Name:  "lang",
+						Usage: "Only list personas written for this language, e.g. ja or en",
+					},
+					&cli.BoolFlag{
+						Name:  "install",
+						Usage: "Install the persona named by the keyword, or the only match",
+					},
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "Persona name to install as (default: the index name)",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Replace an existing persona with the same name",
+					},
+					&cli.BoolFlag{
+						Name:  "allow-unverified",
+						Usage: "Install even if the scan flags prompt injection, hidden characters, or oversized content",
+					},
+					&cli.BoolFlag{
+						Name:  "require-signature",
+						Usage: "Refuse personas without a valid signature from a trusted key",
+					},
+				},
+			},
+			{
+				Name:      "env",
+				Usage:     "Print the environment variables a persona declares as shell exports",
+				ArgsUsage: "[name]",
+				Action:    handlePersonaEnv,
+			},
+			{
+				Name:      "verify",
+				Usage:     "Verify an installed persona against its signature and the trust store",
+				ArgsUsage: "<name>",
+				Action:    handlePersonaVerify,
+			},
+			trustCommand(false),
+			{
+				Name:  "memory",
+				Usage: "Manage facts remembered from earlier sessions in this project",
+				Commands: []*cli.Command{
+					{
+						Name:   "show",
+						Usage:  "Show the memory appended to the persona for this project",
+						Action: handleMemoryShow,
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "refresh",
+								Usage: "Extract new facts from transcripts first",
+							},
+						},
+					},
+					{
+						Name:   "clear",
+						Usage:  "Forget all facts for this project",
+						Action: handleMemoryClear,
+					},
+					{
+						Name:   "edit",
+						Usage:  "Edit the memory file in your editor",
+						Action: handleMemoryEdit,
+					},
+				},
+			},
+			{
+				Name:  "experiment",
+				Usage: "Alternate personas across sessions in this project and compare them",
+				Commands: []*cli.Command{
+					{
+						Name:      "start",
+						Usage:     "Rotate the given personas across new sessions",
+						ArgsUsage: "<persona> <persona> [more...]",
+						Action:    handleExperimentStart,
+					},
+					{
+						Name:   "stop",
+						Usage:  "Stop rotating personas (the session log is kept)",
+						Action: handleExperimentStop,
+					},
+					{
+						Name:   "report",
+						Usage:  "Summarize session counts and durations per persona",
+						Action: handleExperimentReport,
+					},
+				},
+			},
+			{
+				Name:   "prompt",
+				Usage:  "Print the active persona for shell prompts (PS1, starship)",
+				Action: handlePrompt,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "Output format with {persona}, {scope}, {provider} placeholders (env: CCPERSONA_PROMPT_FORMAT)",
+					},
+					&cli.BoolFlag{
+						Name:  "no-cache",
+						Usage: "Resolve the persona without the prompt cache",
+					},
+				},
 			},
 		},
 	}
@@ -203,6 +646,24 @@ func runtimeCommand() *cli.Command {
 			notifyCommand(false),
 			mcpCommand(false),
 			engineCommand(false),
+			execCommand(),
+			gitEventCommand(),
+			ciCommand(),
+			modelsCommand(),
+			lastCommand(false),
+			replCommand(false),
+			speakCommand(false),
+			transcriptsCommand(false),
+			exitCodesCommand(false),
+			avatarCommand(),
+			trayCommand(),
+			pushCommand(),
+			telegramCommand(),
+			recordCommand(),
+			skipCommand(),
+			serveCommand(),
+			daemonCommand(),
+			cacheCommand(),
 		},
 	}
 }
@@ -218,7 +679,7 @@ func hookCommand(hidden bool) *cli.Command {
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "platform",
-				Usage: "Platform hint for ambiguous hook payloads: claude-code, codex, cursor",
+				Usage: "Platform hint for ambiguous hook payloads: claude-code, codex, cursor, windsurf",
 				Value: "",
 			},
 		},
@@ -251,13 +712,17 @@ func voiceCommand(hidden bool) *cli.Command {
 			},
 			&cli.StringFlag{
 				Name:  "mode",
-				Usage: "Reading mode: short (first line) or full (entire text)",
+				Usage: "Reading mode: short (first line), full (entire text), or adaptive (by length; default: voice.reading_mode)",
 				Value: "short",
 			},
+			&cli.BoolFlag{
+				Name:  "diff",
+				Usage: "Read only sentences not spoken in the session's previous message (Stop hook input only)",
+			},
 			// Provider selection
 			&cli.StringFlag{
 				Name:  "provider",
-				Usage: "TTS provider: voicevox, aivisspeech, openai, elevenlabs, polly, gcp",
+				Usage: "TTS provider: voicevox, aivisspeech, openai, elevenlabs, polly, gcp, azure, xtts, sherpa-cli",
 				Value: "aivisspeech",
 			},
 			// Voice selection
@@ -277,6 +742,18 @@ func voiceCommand(hidden bool) *cli.Command {
 				Usage: "Output file path, or '-' for stdout (default: play audio)",
 				Value: "",
 			},
+			&cli.StringFlag{
+				Name:  "subtitles",
+				Usage: "Also write sentence-timed subtitles next to --output: srt or vtt",
+			},
+			&cli.BoolFlag{
+				Name:  "play",
+				Usage: "Also play the audio written with --output",
+			},
+			&cli.BoolFlag{
+				Name:  "recent",
+				Usage: "Also save the audio to the recent audio directory (voice.recent)",
+			},
 			&cli.BoolFlag{
 				Name:  "list-voices",
 				Usage: "List available voices for the specified provider",
@@ -307,44 +784,204 @@ func voiceCommand(hidden bool) *cli.Command {
 				Usage:  "Lift the global voice synthesis mute",
 				Action: handleVoiceUnmute,
 			},
+			{
+				Name:   "explain",
+				Usage:  "Show resolved voice settings and where each one came from",
+				Action: handleVoiceExplain,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "provider",
+						Usage: "Provider passed as a flag, to preview its effect",
+					},
+					&cli.StringFlag{
+						Name:  "platform",
+						Usage: "Resolve as hooks from this platform do: claude-code, codex, cursor, windsurf, or a custom source",
+					},
+					&cli.StringFlag{
+						Name:  "config",
+						Usage: "Path to ccpersona config file (default: .agents/ccpersona.json)",
+					},
+				},
+			},
 			{
 				Name:   "status",
 				Usage:  "Show the current global mute state",
 				Action: handleVoiceStatus,
 			},
 			{
-				Name:  "config",
-				Usage: "Manage voice configuration",
-				Commands: []*cli.Command{
-					{
-						Name:   "show",
-						Usage:  "Show current configuration (secrets masked)",
-						Action: handleVoiceConfigShow,
+				Name:      "play",
+				Usage:     "Play a file handed over by a hook with detached playback",
+				ArgsUsage: "<file>",
+				Hidden:    true,
+				Action:    handleVoicePlay,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "device", Usage: "Output device to play on"},
+					&cli.BoolFlag{Name: "monitor", Usage: "Also play on the default device"},
+				},
+			},
+			{
+				Name:        "batch",
+				Usage:       "Synthesize a document into one audio file per section, with a manifest",
+				Description: "Splits a markdown document at headings (or blank lines) and synthesizes each part\ninto the output directory. manifest.json records each file; running the same\ncommand again skips sections whose text and voice settings are unchanged.",
+				Action:      handleVoiceBatch,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "input",
+						Aliases:  []string{"i"},
+						Usage:    "Markdown or text file to read ('-' for stdin)",
+						Required: true,
 					},
-					{
-						Name:   "validate",
-						Usage:  "Validate configuration file",
-						Action: handleVoiceConfigValidate,
+					&cli.StringFlag{
+						Name:  "split",
+						Usage: "Section boundaries: headings or paragraphs",
+						Value: voice.SplitHeadings,
 					},
-					{
-						Name:   "init",
-						Usage:  "Generate example configuration file",
-						Action: handleVoiceConfigInit,
-						Flags: []cli.Flag{
-							&cli.BoolFlag{
-								Name:  "global",
-								Usage: "Create global config (~/.agents/ccpersona.json)",
-								Value: false,
-							},
-						},
+					&cli.StringFlag{
+						Name:     "output",
+						Aliases:  []string{"o"},
+						Usage:    "Directory for the audio files and manifest",
+						Required: true,
 					},
-				},
-			},
-		},
-	}
-}
-
-func mcpCommand(hidden bool) *cli.Command {
+					&cli.IntFlag{
+						Name:  "parallel",
+						Usage: "Sections synthesized at once",
+						Value: 2,
+					},
+					&cli.IntFlag{
+						Name:  "rate",
+						Usage: "Maximum sections started per minute (0 = unlimited)",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "Synthesize every section again, ignoring the manifest",
+					},
+					&cli.StringFlag{
+						Name:  "provider",
+						Usage: "Provider to use instead of the configured one",
+					},
+					&cli.StringFlag{
+						Name:  "voice",
+						Usage: "Voice ID for cloud providers",
+					},
+					&cli.StringFlag{
+						Name:  "config",
+						Usage: "Path to ccpersona config file (default: .agents/ccpersona.json)",
+					},
+				},
+			},
+			{
+				Name:        "test",
+				Usage:       "Synthesize and play a test sentence, reporting each pipeline stage",
+				Description: "Runs the full pipeline with the resolved configuration and prints the provider,\nsynthesis latency, audio format and duration, and player. Exits non-zero when any\nstage fails, so it can verify a workstation setup from scripts or CI.",
+				Action:      handleVoiceTest,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "text",
+						Usage: "Sentence to synthesize",
+						Value: voiceTestSentence,
+					},
+					&cli.StringFlag{
+						Name:  "provider",
+						Usage: "Provider to test instead of the configured one",
+					},
+					&cli.StringFlag{
+						Name:  "voice",
+						Usage: "Voice ID for cloud providers",
+					},
+					&cli.BoolFlag{
+						Name:  "no-play",
+						Usage: "Stop after synthesis (for machines without a sound device)",
+					},
+					&cli.DurationFlag{
+						Name:  "timeout",
+						Usage: "Give up on synthesis after this long",
+						Value: time.Minute,
+					},
+					&cli.StringFlag{
+						Name:  "config",
+						Usage: "Path to ccpersona config file (default: .agents/ccpersona.json)",
+					},
+				},
+			},
+			{
+				Name:   "ack",
+				Usage:  "Play a prompt acknowledgement phrase (cached per persona)",
+				Action: handleVoiceAck,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "phrase",
+						Usage: "Phrase to play (default: a random configured phrase)",
+					},
+					&cli.BoolFlag{
+						Name:  "warm",
+						Usage: "Synthesize all configured phrases into the cache without playing",
+					},
+					&cli.StringFlag{
+						Name:  "platform",
+						Usage: "Platform whose persona config to use: claude-code, codex, cursor, windsurf",
+					},
+					&cli.StringFlag{
+						Name:  "config",
+						Usage: "Path to ccpersona config file (default: .agents/ccpersona.json)",
+					},
+				},
+			},
+			{
+				Name:  "ref",
+				Usage: "Manage reference WAVs for voice cloning (xtts provider)",
+				Commands: []*cli.Command{
+					{
+						Name:      "add",
+						Usage:     "Store a reference WAV under a name",
+						ArgsUsage: "<name> <file.wav>",
+						Action:    handleVoiceRefAdd,
+					},
+					{
+						Name:   "list",
+						Usage:  "List stored reference WAVs",
+						Action: handleVoiceRefList,
+					},
+					{
+						Name:      "remove",
+						Usage:     "Delete a stored reference WAV",
+						ArgsUsage: "<name>",
+						Action:    handleVoiceRefRemove,
+					},
+				},
+			},
+			{
+				Name:  "config",
+				Usage: "Manage voice configuration",
+				Commands: []*cli.Command{
+					{
+						Name:   "show",
+						Usage:  "Show current configuration (secrets masked)",
+						Action: handleVoiceConfigShow,
+					},
+					{
+						Name:   "validate",
+						Usage:  "Validate configuration file",
+						Action: handleVoiceConfigValidate,
+					},
+					{
+						Name:   "init",
+						Usage:  "Generate example configuration file",
+						Action: handleVoiceConfigInit,
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "global",
+								Usage: "Create global config (~/.agents/ccpersona.json)",
+								Value: false,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func mcpCommand(hidden bool) *cli.Command {
 	return &cli.Command{
 		Name:   "mcp",
 		Usage:  "Start as a stdio MCP server",
@@ -357,7 +994,7 @@ func notifyCommand(hidden bool) *cli.Command {
 	return &cli.Command{
 		Name:        "notify",
 		Aliases:     []string{"notification_hook"},
-		Usage:       "Handle notifications (auto-detects Claude Code, Codex, or Cursor)",
+		Usage:       "Handle notifications (auto-detects Claude Code, Codex, Cursor, or Windsurf)",
 		Description: "The 'notification_hook' alias is legacy and kept for backward compatibility; prefer 'notify' wired to the Notification event.",
 		Action:      handleNotify,
 		Hidden:      hidden,
@@ -373,6 +1010,566 @@ func notifyCommand(hidden bool) *cli.Command {
 				Value: true,
 			},
 		},
+		Commands: []*cli.Command{
+			{
+				Name:   "remind",
+				Usage:  "Repeat an unanswered question alert (started by the Stop hook)",
+				Hidden: true,
+				Action: handleNotifyRemind,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "session", Usage: "Session ID of the question", Required: true},
+					&cli.StringFlag{Name: "token", Usage: "Token of the question to remind about", Required: true},
+					&cli.IntFlag{Name: "every", Usage: "Minutes between reminders", Value: 5},
+					&cli.IntFlag{Name: "max", Usage: "Maximum number of reminders", Value: notify.DefaultMaxRepeats},
+				},
+			},
+			{
+				Name:   "digest",
+				Usage:  "Speak the pending notification digest now",
+				Action: handleNotifyDigest,
+				Flags: []cli.Flag{
+					&cli.DurationFlag{Name: "after", Usage: "Wait this long first (set by hooks)", Hidden: true},
+				},
+			},
+			{
+				Name:   "action",
+				Usage:  "Show a notification with action buttons and handle the click (started by hooks)",
+				Hidden: true,
+				Action: handleNotifyAction,
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "message", Usage: "Notification text", Required: true},
+					&cli.StringFlag{Name: "urgency", Usage: "Notification urgency", Value: "normal"},
+					&cli.StringFlag{Name: "transcript", Usage: "Transcript the actions refer to"},
+					&cli.StringFlag{Name: "project", Usage: "Project directory the actions refer to"},
+				},
+			},
+		},
+	}
+}
+
+func execCommand() *cli.Command {
+	stopAtCommand := 1
+	return &cli.Command{
+		Name:         "exec",
+		Usage:        "Run a command and announce its result with the active persona voice",
+		ArgsUsage:    "-- <command> [args...]",
+		Description:  "Runs the command with inherited stdio, then speaks and notifies the exit status, duration, and last output line. The command's exit code is preserved.",
+		Action:       handleExec,
+		StopOnNthArg: &stopAtCommand,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "label",
+				Usage: "Name used in the announcement (default: the command line)",
+				Value: "",
+			},
+			&cli.BoolFlag{
+				Name:  "voice",
+				Usage: "Speak the result",
+				Value: true,
+			},
+			&cli.BoolFlag{
+				Name:  "desktop",
+				Usage: "Show a desktop notification",
+				Value: true,
+			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Path to ccpersona config file (default: .agents/ccpersona.json)",
+				Value: "",
+			},
+		},
+	}
+}
+
+func lastCommand(hidden bool) *cli.Command {
+	return &cli.Command{
+		Name:        "last",
+		Usage:       "Print the last assistant messages of the current project",
+		Description: "Reads the most recent Claude Code transcript of the current directory (or its nearest parent with transcripts) and prints the final answer of each of the last N turns, oldest first.",
+		Action:      handleLast,
+		Hidden:      hidden,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "n",
+				Usage: "Number of messages to show",
+				Value: 1,
+			},
+			&cli.BoolFlag{
+				Name:  "speak",
+				Usage: "Read the messages aloud with the active persona voice",
+			},
+			&cli.StringFlag{
+				Name:  "project",
+				Usage: "Project directory (default: the current directory)",
+			},
+			&cli.StringFlag{
+				Name:  "transcript",
+				Usage: "Read this transcript instead of the project's latest",
+			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Path to ccpersona config file (default: .agents/ccpersona.json)",
+				Value: "",
+			},
+		},
+	}
+}
+
+func gitEventCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "git-event",
+		Usage:     "Announce a git hook event (invoked by hooks from 'config integrate git')",
+		ArgsUsage: "<hook> [hook args...]",
+		Action:    handleGitEvent,
+	}
+}
+
+func modelsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "models",
+		Usage: "Download and manage offline TTS models (sherpa-cli provider)",
+		Commands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List installed models and the built-in catalog",
+				Action: handleModelsList,
+			},
+			{
+				Name:      "pull",
+				Usage:     "Download a model and verify its checksum",
+				ArgsUsage: "<name>",
+				Action:    handleModelsPull,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "url",
+						Usage: "Archive URL (.tar.bz2, .tar.gz) for models outside the catalog",
+					},
+					&cli.StringFlag{
+						Name:  "sha256",
+						Usage: "Expected SHA-256 of the archive; the download fails on mismatch",
+					},
+				},
+			},
+			{
+				Name:      "verify",
+				Usage:     "Re-check installed files against their recorded checksums",
+				ArgsUsage: "<name>",
+				Action:    handleModelsVerify,
+			},
+			{
+				Name:      "remove",
+				Usage:     "Delete an installed model",
+				ArgsUsage: "<name>",
+				Action:    handleModelsRemove,
+			},
+		},
+	}
+}
+
+func replCommand(hidden bool) *cli.Command {
+	return &cli.Command{
+		Name:        "repl",
+		Usage:       "Speak each line you type with the persona voice",
+		Description: "An interactive prompt for testing pronunciation and demos. Slash commands such as\n/provider, /voice, /speaker, and /speed change the voice on the fly; /help lists them.",
+		Action:      handleRepl,
+		Hidden:      hidden,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "provider",
+				Usage: "Provider to start with instead of the configured one",
+			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Path to ccpersona config file (default: .agents/ccpersona.json)",
+			},
+		},
+	}
+}
+
+func speakCommand(hidden bool) *cli.Command {
+	return &cli.Command{
+		Name:        "speak",
+		Usage:       "Speak text, stdin, or the clipboard with the persona voice",
+		ArgsUsage:   "[text]",
+		Description: "Reads the arguments, --clipboard, or stdin, strips markdown, and speaks the result.\nWith --watch-clipboard, every new copy is spoken until interrupted.",
+		Action:      handleSpeak,
+		Hidden:      hidden,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "clipboard",
+				Usage: "Speak the current clipboard content",
+			},
+			&cli.BoolFlag{
+				Name:  "watch-clipboard",
+				Usage: "Keep running and speak each new clipboard copy",
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "Clipboard polling interval for --watch-clipboard",
+				Value: time.Second,
+			},
+			&cli.IntFlag{
+				Name:  "max-chars",
+				Usage: "Speak at most this many characters, cut at a sentence end where possible",
+				Value: 2000,
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "Speak even when voice is muted",
+			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Path to ccpersona config file (default: .agents/ccpersona.json)",
+			},
+		},
+	}
+}
+
+func transcriptsCommand(hidden bool) *cli.Command {
+	return &cli.Command{
+		Name:   "transcripts",
+		Usage:  "Manage Claude Code transcripts under ~/.claude/projects",
+		Hidden: hidden,
+		Commands: []*cli.Command{
+			{
+				Name:        "prune",
+				Usage:       "Remove or archive transcripts not modified for a while",
+				Description: "Selects session transcripts (with their subagent and tool-result directories) last modified\nbefore --older-than. Use --dry-run to list them first.",
+				Action:      handleTranscriptsPrune,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "older-than",
+						Usage: "Minimum age, such as 30d, 2w, or 12h",
+						Value: "30d",
+					},
+					&cli.StringFlag{
+						Name:  "archive",
+						Usage: "Move transcripts into this directory instead of deleting them",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "List what would be pruned without changing anything",
+					},
+					&cli.BoolFlag{
+						Name:   "auto",
+						Usage:  "Apply the transcripts policy of the global config if due (used by the SessionEnd hook)",
+						Hidden: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func exitCodesCommand(hidden bool) *cli.Command {
+	return &cli.Command{
+		Name:   "exit-codes",
+		Usage:  "List the exit codes ccpersona commands return",
+		Action: handleExitCodes,
+		Hidden: hidden,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the codes as JSON",
+			},
+		},
+	}
+}
+
+func recordCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "record",
+		Usage:       "Archive redacted hook payloads per source to replay against the parser",
+		Description: "While recording is on, every payload the hook and notify commands receive is redacted and saved\nunder ~/.agents/ccpersona/corpus/<source>/<event>/. Replay the corpus after upgrading a tool to\nsee which payloads no longer parse, and copy files into internal/hook/testdata/corpus as regression cases.",
+		Commands: []*cli.Command{
+			{
+				Name:   "on",
+				Usage:  "Start recording hook payloads",
+				Action: handleRecordOn,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "keep-text",
+						Usage: "Keep prompts, responses, and tool input instead of replacing them with their length",
+					},
+					&cli.IntFlag{
+						Name:  "max",
+						Usage: "Payloads kept per source and event; the oldest are dropped",
+						Value: corpus.DefaultMaxPerEvent,
+					},
+				},
+			},
+			{
+				Name:   "off",
+				Usage:  "Stop recording and keep the corpus",
+				Action: handleRecordOff,
+			},
+			{
+				Name:   "status",
+				Usage:  "Show whether recording is on and count payloads per source and event",
+				Action: handleRecordStatus,
+			},
+			{
+				Name:   "replay",
+				Usage:  "Parse every recorded payload again and report those that no longer match",
+				Action: handleRecordReplay,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "dir",
+						Usage: "Corpus directory to replay instead of ~/.agents/ccpersona/corpus",
+					},
+					&cli.BoolFlag{
+						Name:  "verbose",
+						Usage: "List payloads that pass too",
+					},
+				},
+			},
+			{
+				Name:   "clear",
+				Usage:  "Remove the recorded payloads",
+				Action: handleRecordClear,
+			},
+		},
+	}
+}
+
+func avatarCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "avatar",
+		Usage: "Relay speech events to VRM/Live2D avatar frontends for lip-sync",
+		Commands: []*cli.Command{
+			{
+				Name:   "serve",
+				Usage:  "Run the WebSocket bridge that avatar frontends connect to",
+				Action: handleAvatarServe,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "listen",
+						Usage: "Address to listen on; events are only accepted from localhost",
+						Value: avatar.DefaultAddr,
+					},
+				},
+			},
+		},
+	}
+}
+
+func trayCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "tray",
+		Usage:       "Show mute state and persona in the system tray or menu bar",
+		Description: "Runs until Quit is chosen. The menu mutes voice, switches the persona of the\ndirectory's config (the global config when it has none), replays or skips the\nassistant message, and opens the config file. SIGUSR1 skips speech as well.",
+		Action:      handleTray,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "dir",
+				Usage: "Project directory whose persona the tray shows (default: the current directory)",
+			},
+			&cli.StringFlag{
+				Name:  "skip-hotkey",
+				Usage: "Global shortcut that skips speech, such as CTRL+ALT+period (Linux desktops with the GlobalShortcuts portal)",
+			},
+		},
+	}
+}
+
+func skipCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "skip",
+		Usage:       "Stop the message being read and drop the ones waiting to play",
+		Description: "Works across processes: hooks and detached players stop within a moment, and audio\nrequested before the skip is not played. Bind it to a keyboard shortcut in the desktop settings,\nor run the tray with --skip-hotkey.",
+		Action:      handleSkip,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "quiet",
+				Usage: "Print nothing, for keyboard shortcuts",
+			},
+		},
+	}
+}
+
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "serve",
+		Usage:       "Run a shared notification hub that team members' hooks post events to",
+		Description: "Each user's hooks authenticate with their API token from the hub config and post\nthe events their notifications.hub settings select. The hub posts them to the team's\nSlack and Discord webhooks and to each user's own Telegram chat and browsers, which\nsubscribe at /push/ with the API token as the password.",
+		Action:      handleServe,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "listen",
+				Usage: "Address to listen on",
+				Value: hub.DefaultAddr,
+			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Hub config file (default: ~/.agents/ccpersona/hub.json)",
+			},
+			&cli.StringFlag{
+				Name:  "data",
+				Usage: "Directory for push subscriptions (default: ~/.agents/ccpersona/hub)",
+			},
+		},
+	}
+}
+
+func daemonCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "daemon",
+		Usage:       "Speak for hooks from one long-running process with warm engine connections",
+		Description: "While it runs, hook, voice, and notify hand their messages to it instead of resolving the\nengine and starting a player themselves. It keeps connections to engines and providers\nopen and plays messages in order from one queue. Hooks speak by themselves when it is\nnot running, or with CCPERSONA_NO_DAEMON set.",
+		Action:      handleDaemon,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "listen",
+				Usage: "Address to listen on; requests are only accepted from localhost",
+				Value: daemon.DefaultAddr,
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:   "status",
+				Usage:  "Show whether the daemon runs and what it has spoken",
+				Action: handleDaemonStatus,
+			},
+			{
+				Name:   "stop",
+				Usage:  "Stop the running daemon",
+				Action: handleDaemonStop,
+			},
+		},
+	}
+}
+
+func cacheCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "cache",
+		Usage:       "Inspect or clear the cache of synthesized audio",
+		Description: "Short messages spoken by cloud providers are cached by their text and voice settings,\nso repeated phrases such as \"Task completed\" are synthesized once. Tune it with voice.cache.",
+		Commands: []*cli.Command{
+			{
+				Name:   "stats",
+				Usage:  "Show the size of the cache and how often it was used",
+				Action: handleCacheStats,
+			},
+			{
+				Name:   "clear",
+				Usage:  "Remove all cached audio",
+				Action: handleCacheClear,
+			},
+		},
+	}
+}
+
+func pushCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "push",
+		Usage: "Send notifications to subscribed browsers with Web Push",
+		Commands: []*cli.Command{
+			{
+				Name:        "serve",
+				Usage:       "Serve the page browsers subscribe from",
+				Description: "Subscriptions are only accepted from localhost. On a remote server, forward the\nport with ssh -L and open the page as http://localhost, which browsers treat\nas secure.",
+				Action:      handlePushServe,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "listen",
+						Usage: "Address to listen on",
+						Value: webpush.DefaultAddr,
+					},
+				},
+			},
+			{
+				Name:      "test",
+				Usage:     "Push a test notification to every subscribed browser",
+				ArgsUsage: "[text]",
+				Action:    handlePushTest,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "urgency",
+						Usage: "Urgency of the test notification: low, normal, high, critical",
+						Value: "normal",
+					},
+				},
+			},
+		},
+	}
+}
+
+func telegramCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "telegram",
+		Usage: "Send notifications to Telegram and take commands from the chat",
+		Commands: []*cli.Command{
+			{
+				Name:        "serve",
+				Usage:       "Apply /mute, /unmute, /persona, /last, and /status sent to the bot",
+				Description: "Only messages from notifications.telegram.chat_id are accepted. Commands sent\nwhile serve was not running are skipped.",
+				Action:      handleTelegramServe,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "dir",
+						Usage: "Project directory the commands apply to (default: the current directory)",
+					},
+				},
+			},
+			{
+				Name:      "test",
+				Usage:     "Send a test message to the configured chat",
+				ArgsUsage: "[text]",
+				Action:    handleTelegramTest,
+			},
+		},
+	}
+}
+
+func ciCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "ci",
+		Usage: "Announce CI status changes",
+		Commands: []*cli.Command{
+			{
+				Name:   "watch",
+				Usage:  "Poll GitHub checks for a branch and announce status transitions",
+				Action: handleCIWatch,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "repo",
+						Usage: "GitHub repository as owner/name (default: from the origin remote)",
+					},
+					&cli.StringFlag{
+						Name:  "branch",
+						Usage: "Branch to watch (default: current branch)",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "Polling interval",
+						Value: 30 * time.Second,
+					},
+					&cli.BoolFlag{
+						Name:  "until-complete",
+						Usage: "Exit after checks reach a final state",
+					},
+					&cli.BoolFlag{
+						Name:  "announce-initial",
+						Usage: "Also announce the status found on the first poll",
+					},
+					&cli.BoolFlag{
+						Name:  "voice",
+						Usage: "Speak status changes",
+						Value: true,
+					},
+					&cli.BoolFlag{
+						Name:  "desktop",
+						Usage: "Show desktop notifications",
+						Value: true,
+					},
+					&cli.StringFlag{
+						Name:  "config",
+						Usage: "Path to ccpersona config file (default: .agents/ccpersona.json)",
+						Value: "",
+					},
+				},
+			},
+		},
 	}
 }
 
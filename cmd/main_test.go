@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/urfave/cli/v3"
 )
 
+// errStop aborts a command run once Before has inspected it.
+var errStop = errors.New("stop")
+
 func findCommand(commands []*cli.Command, name string) *cli.Command {
 	for _, cmd := range commands {
 		if cmd.Name == name {
@@ -34,7 +39,14 @@ func TestCommandHierarchy_ConfigSubcommands(t *testing.T) {
 		"show",
 		"status",
 		"set-persona",
+		"integrate",
 		"migrate",
+		"stats",
+		"report",
+		"rules",
+		"sources",
+		"voices",
+		"badge", "doctor",
 	} {
 		requireCommand(t, config.Commands, name)
 	}
@@ -44,7 +56,7 @@ func TestCommandHierarchy_PersonaSubcommands(t *testing.T) {
 	app := newApp()
 	persona := requireCommand(t, app.Commands, "persona")
 
-	for _, name := range []string{"list", "show", "edit"} {
+	for _, name := range []string{"list", "show", "apply", "edit", "template", "memory", "experiment", "prompt"} {
 		requireCommand(t, persona.Commands, name)
 	}
 }
@@ -53,7 +65,7 @@ func TestCommandHierarchy_RuntimeSubcommands(t *testing.T) {
 	app := newApp()
 	runtime := requireCommand(t, app.Commands, "runtime")
 
-	for _, name := range []string{"hook", "voice", "notify", "mcp", "engine"} {
+	for _, name := range []string{"hook", "voice", "notify", "mcp", "engine", "exec", "git-event", "ci", "models", "last", "repl", "speak", "transcripts", "exit-codes", "avatar", "tray", "push", "telegram", "record", "skip", "serve", "daemon", "cache"} {
 		cmd := requireCommand(t, runtime.Commands, name)
 		if cmd.Hidden {
 			t.Fatalf("runtime %s should be visible", name)
@@ -81,10 +93,33 @@ func TestCommandHierarchy_RemovesTopLevelBasicCommands(t *testing.T) {
 func TestCommandHierarchy_HiddenRuntimeCompatibility(t *testing.T) {
 	app := newApp()
 
-	for _, name := range []string{"hook", "voice", "notify", "mcp", "engine"} {
+	for _, name := range []string{"hook", "voice", "notify", "mcp", "engine", "last", "repl", "speak", "transcripts", "exit-codes"} {
 		cmd := requireCommand(t, app.Commands, name)
 		if !cmd.Hidden {
 			t.Fatalf("top-level %s should be hidden", name)
 		}
 	}
 }
+
+func TestCommandName(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"ccpersona", "runtime", "voice", "--provider", "openai"}, "runtime voice"},
+		{[]string{"ccpersona", "-V", "persona", "show", "secret-name"}, "persona show"},
+		{[]string{"ccpersona", "voice", "mute"}, "voice mute"},
+	}
+	for _, tt := range tests {
+		app := newApp()
+		var got string
+		app.Before = func(ctx context.Context, c *cli.Command) (context.Context, error) {
+			got = commandName(c)
+			return ctx, errStop
+		}
+		_ = app.Run(context.Background(), tt.args)
+		if got != tt.want {
+			t.Errorf("commandName(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
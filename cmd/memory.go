@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/memory"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/urfave/cli/v3"
+)
+
+// memoryTarget returns the home and project directories the memory commands
+// operate on (the current directory, as for hooks).
+func memoryTarget() (homeDir, projectDir string, err error) {
+	homeDir, err = os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	projectDir, err = os.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return homeDir, projectDir, nil
+}
+
+func memoryMaxChars() int {
+	config, _ := persona.LoadConfigWithFallback()
+	if config != nil && config.Memory != nil {
+		return memory.EffectiveMaxChars(config.Memory.MaxChars)
+	}
+	return memory.DefaultMaxChars
+}
+
+func handleMemoryShow(ctx context.Context, c *cli.Command) error {
+	homeDir, projectDir, err := memoryTarget()
+	if err != nil {
+		return err
+	}
+	path := memory.Path(homeDir, projectDir)
+
+	var file *memory.File
+	if c.Bool("refresh") {
+		file, err = memory.Refresh(homeDir, projectDir, memoryMaxChars())
+	} else {
+		file, err = memory.Load(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s %s\n", cliui.Label("File:"), cliui.Muted(path))
+	if config, _ := persona.LoadConfigWithFallback(); config == nil || config.Memory == nil || !config.Memory.Enabled {
+		fmt.Println(cliui.Warn("Memory is disabled; set \"memory\": {\"enabled\": true} in ccpersona.json to append it at session start."))
+	}
+	if len(file.Facts) == 0 {
+		fmt.Println("No memory recorded for this project.")
+		return nil
+	}
+	fmt.Println()
+	fmt.Print(memory.Render(file.Facts, memoryMaxChars()))
+	return nil
+}
+
+func handleMemoryClear(ctx context.Context, c *cli.Command) error {
+	homeDir, projectDir, err := memoryTarget()
+	if err != nil {
+		return err
+	}
+	if err := memory.Clear(homeDir, projectDir); err != nil {
+		return err
+	}
+	fmt.Println("Memory cleared for this project. Earlier transcripts will not be re-read.")
+	return nil
+}
+
+func handleMemoryEdit(ctx context.Context, c *cli.Command) error {
+	homeDir, projectDir, err := memoryTarget()
+	if err != nil {
+		return err
+	}
+	path := memory.Path(homeDir, projectDir)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := memory.Save(path, projectDir, &memory.File{}); err != nil {
+			return err
+		}
+	}
+	return openEditor(path)
+}
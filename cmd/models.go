@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/models"
+	"github.com/urfave/cli/v3"
+)
+
+func handleModelsList(ctx context.Context, c *cli.Command) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	installed, err := models.List(home)
+	if err != nil {
+		return err
+	}
+	have := map[string]bool{}
+
+	fmt.Println(cliui.Header("Installed models:"))
+	if len(installed) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, m := range installed {
+		have[m.Name] = true
+		fmt.Printf("  %-36s %8.1f MB  %s\n", m.Name, float64(m.Size)/(1<<20), cliui.Muted(m.Path))
+	}
+
+	fmt.Println()
+	fmt.Println(cliui.Header("Available to pull:"))
+	for _, m := range models.Catalog {
+		if have[m.Name] {
+			continue
+		}
+		fmt.Printf("  %-36s %s\n", m.Name, cliui.Muted(m.Description))
+	}
+	return nil
+}
+
+func handleModelsPull(ctx context.Context, c *cli.Command) error {
+	name := c.Args().First()
+	if name == "" {
+		return fmt.Errorf("usage: ccpersona runtime models pull <name> [--url URL] [--sha256 HASH]")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	model, ok := models.Lookup(name)
+	if url := c.String("url"); url != "" {
+		model = models.Model{Name: name, URL: url, SHA256: model.SHA256}
+	} else if !ok {
+		return fmt.Errorf("unknown model %q; pass --url for models outside the catalog (see 'ccpersona runtime models list')", name)
+	}
+	if sum := c.String("sha256"); sum != "" {
+		model.SHA256 = strings.ToLower(sum)
+	}
+
+	fmt.Printf("Downloading %s\n  %s\n", cliui.Label(model.Name), cliui.Muted(model.URL))
+	installed, err := models.Pull(ctx, http.DefaultClient, home, model)
+	if err != nil {
+		return err
+	}
+
+	if model.SHA256 != "" {
+		fmt.Printf("%s Checksum verified (sha256 %s)\n", cliui.Success("✓"), installed.SHA256)
+	} else {
+		fmt.Printf("%s sha256 %s %s\n", cliui.Warn("!"), installed.SHA256, cliui.Muted("(not pinned; pass --sha256 to verify downloads)"))
+	}
+	fmt.Printf("%s Installed %d files to %s\n", cliui.Success("✓"), len(installed.Files), installed.Path)
+	fmt.Printf("Use it with \"voice\": {\"provider\": \"sherpa-cli\", \"model\": %q}\n", installed.Name)
+	return nil
+}
+
+func handleModelsVerify(ctx context.Context, c *cli.Command) error {
+	name := c.Args().First()
+	if name == "" {
+		return fmt.Errorf("usage: ccpersona runtime models verify <name>")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	bad, err := models.Verify(home, name)
+	if err != nil {
+		return err
+	}
+	if len(bad) > 0 {
+		for _, rel := range bad {
+			fmt.Printf("  %s %s\n", cliui.Failure("✗"), rel)
+		}
+		return fmt.Errorf("%d file(s) missing or modified; run 'ccpersona runtime models pull %s' to reinstall", len(bad), name)
+	}
+	fmt.Printf("%s %s: all files match their recorded checksums\n", cliui.Success("✓"), name)
+	return nil
+}
+
+func handleModelsRemove(ctx context.Context, c *cli.Command) error {
+	name := c.Args().First()
+	if name == "" {
+		return fmt.Errorf("usage: ccpersona runtime models remove <name>")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	if err := models.Remove(home, name); err != nil {
+		return err
+	}
+	fmt.Printf("%s Removed %s\n", cliui.Success("✓"), name)
+	return nil
+}
@@ -1,22 +1,36 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/daikw/ccpersona/internal/cursor"
+	"github.com/daikw/ccpersona/internal/detach"
 	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/daikw/ccpersona/internal/notify"
 	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/scrub"
+	"github.com/daikw/ccpersona/internal/tracing"
 	"github.com/daikw/ccpersona/internal/voice"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
 )
 
+// debugf prints a CCPERSONA_DEBUG trace line to stderr. Payloads and
+// synthesized text may carry secrets, so they are scrubbed unless
+// --no-redact is set.
+func debugf(format string, args ...interface{}) {
+	fmt.Fprint(os.Stderr, scrub.String(fmt.Sprintf("[DEBUG] "+format, args...)))
+}
+
 func handleNotify(ctx context.Context, c *cli.Command) error {
 	// Check for debug mode
 	debug := os.Getenv("CCPERSONA_DEBUG") != ""
@@ -26,85 +40,131 @@ func handleNotify(ctx context.Context, c *cli.Command) error {
 	}
 
 	var unifiedEvent *hook.UnifiedHookEvent
+	var data []byte
 	var err error
 
 	// Codex passes JSON as command line argument, Claude Code uses stdin
 	// Check for JSON argument first (Codex style)
 	args := c.Args().Slice()
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] args: %v\n", args)
+		debugf("args: %v\n", args)
 	}
 	if len(args) > 0 && strings.HasPrefix(strings.TrimSpace(args[0]), "{") {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Parsing from argument (Codex style)\n")
+			debugf("Parsing from argument (Codex style)\n")
+		}
+		if data, err = hook.ReadInput(strings.NewReader(args[0])); err == nil {
+			unifiedEvent, err = hook.ParseInput(data, "")
 		}
-		unifiedEvent, err = hook.DetectAndParse(bytes.NewReader([]byte(args[0])))
 	} else {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Parsing from stdin (Claude Code style)\n")
+			debugf("Parsing from stdin (Claude Code style)\n")
+		}
+		if data, err = hook.ReadInput(os.Stdin); err == nil {
+			unifiedEvent, err = hook.ParseInput(data, "")
 		}
-		unifiedEvent, err = hook.DetectAndParse(os.Stdin)
 	}
+	recordCorpus(data, unifiedEvent)
 
+	if errors.Is(err, hook.ErrInputLimit) {
+		return err
+	}
 	if err != nil {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Parse error: %v\n", err)
+			debugf("Parse error: %v\n", err)
 		}
 		return handleLegacyNotification(ctx, c)
 	}
 
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Event: source=%s, type=%s\n", unifiedEvent.Source, unifiedEvent.EventType)
+		debugf("Event: source=%s, type=%s\n", unifiedEvent.Source, unifiedEvent.EventType)
 	}
 
 	log.Debug().
 		Str("source", unifiedEvent.Source).
 		Str("session_id", unifiedEvent.SessionID).
 		Str("event_type", unifiedEvent.EventType).
+		Str("tool_name", unifiedEvent.ToolName).
+		Str("model", unifiedEvent.Model).
+		Bool("subagent", unifiedEvent.IsSubagent).
 		Msg("Received hook event")
+	ctx, finishTrace := startHookTrace(ctx, "notify", unifiedEvent)
+	defer finishTrace()
+	recordHookSource(unifiedEvent.Source, unifiedEvent.EventType)
+	voice.NewSessionRegistry().Touch(unifiedEvent.SessionID)
+	forwardHookEvent(ctx, c, unifiedEvent)
 
 	// Handle based on event source and type
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Routing: IsCodex=%v, IsCursor=%v, IsClaudeCode=%v\n",
-			unifiedEvent.IsCodex(), unifiedEvent.IsCursor(), unifiedEvent.IsClaudeCode())
+		debugf("Routing: IsCodex=%v, IsCursor=%v, IsWindsurf=%v, IsClaudeCode=%v\n",
+			unifiedEvent.IsCodex(), unifiedEvent.IsCursor(), unifiedEvent.IsWindsurf(), unifiedEvent.IsClaudeCode())
 	}
 	if unifiedEvent.IsCodex() {
 		// Codex notify hook - triggered on agent-turn-complete
+		handleAssistantMessage(ctx, c, unifiedEvent)
 		return handleCodexAgentTurnComplete(ctx, c, unifiedEvent)
 	} else if unifiedEvent.IsCursor() {
 		// Cursor events - route to appropriate handler
 		switch unifiedEvent.EventType {
 		case "sessionStart":
-			// Apply persona at session start (platform-aware)
-			if err := persona.HandleSessionStartForPlatform(unifiedEvent.Source); err != nil {
+			// Apply persona at session start, per workspace root
+			if err := persona.HandleSessionStartForWorkspace(unifiedEvent.Source, unifiedEvent.SessionID, unifiedEvent.WorkspaceRoots, unifiedEvent.FilePath); err != nil {
 				log.Error().Err(err).Msg("Failed to handle session start")
 			}
 			return nil
 		case "beforeSubmitPrompt":
-			// Could validate prompts here if needed
+			// The user answered; stop any question reminder
+			notify.NewQuestionTracker(unifiedEvent.SessionID).Clear()
+			startAck(loadUnifiedConfig(c, unifiedEvent.Source), unifiedEvent.Source)
 			return nil
 		case "afterAgentResponse":
 			// Voice synthesis using direct AI response text
+			handleAssistantMessage(ctx, c, unifiedEvent)
 			return handleDirectResponseVoice(ctx, c, unifiedEvent)
 		case "stop":
-			// Stop event doesn't contain AI response, skip voice synthesis
-			log.Debug().Msg("Cursor stop event received (no voice synthesis)")
-			return nil
+			// The stop payload carries no response; read it from Cursor's storage
+			return handleCursorStopVoice(ctx, c, unifiedEvent)
 		default:
 			log.Debug().Str("event_type", unifiedEvent.EventType).Msg("Unhandled Cursor event type")
 			return nil
 		}
+	} else if unifiedEvent.IsWindsurf() {
+		// Windsurf Cascade events - no session start hook, so the persona is
+		// applied on prompts under the apply policy
+		switch unifiedEvent.EventType {
+		case "pre_user_prompt":
+			notify.NewQuestionTracker(unifiedEvent.SessionID).Clear()
+			startAck(loadUnifiedConfig(c, unifiedEvent.Source), unifiedEvent.Source)
+			if err := persona.HandlePromptSubmit(unifiedEvent.Source, unifiedEvent.SessionID); err != nil {
+				log.Error().Err(err).Msg("Failed to handle session start")
+			}
+			return nil
+		case "post_cascade_response":
+			// Voice synthesis using the response in the payload
+			handleAssistantMessage(ctx, c, unifiedEvent)
+			return handleDirectResponseVoice(ctx, c, unifiedEvent)
+		default:
+			log.Debug().Str("event_type", unifiedEvent.EventType).Msg("Unhandled Windsurf event type")
+			return nil
+		}
+	} else if unifiedEvent.IsPlugin() {
+		// User-defined sources map their events onto the Claude Code names
+		return handlePluginEvent(ctx, c, unifiedEvent)
 	} else if unifiedEvent.IsClaudeCode() {
 		// Claude Code events - route to appropriate handler
 		switch unifiedEvent.EventType {
 		case "UserPromptSubmit":
-			// Apply persona at session start (platform-aware)
-			if err := persona.HandleSessionStartForPlatform(unifiedEvent.Source); err != nil {
+			// The user answered; stop any question reminder
+			notify.NewQuestionTracker(unifiedEvent.SessionID).Clear()
+			startAck(loadUnifiedConfig(c, unifiedEvent.Source), unifiedEvent.Source)
+			// Apply the persona under the apply policy
+			if err := persona.HandlePromptSubmit(unifiedEvent.Source, unifiedEvent.SessionID); err != nil {
 				log.Error().Err(err).Msg("Failed to handle session start")
 			}
 			return nil
 		case "Stop":
 			// Voice synthesis for assistant response
+			handleAssistantMessage(ctx, c, unifiedEvent)
 			return handleStopEventVoice(ctx, c, unifiedEvent)
 		case "SubagentStop":
 			log.Debug().Msg("SubagentStop event ignored for voice synthesis")
@@ -121,6 +181,42 @@ func handleNotify(ctx context.Context, c *cli.Command) error {
 	return nil
 }
 
+// handlePluginEvent handles events from sources defined in the sources
+// directory, whose definitions rename their events to SessionStart,
+// UserPromptSubmit, Stop, Notification, or SessionEnd.
+func handlePluginEvent(ctx context.Context, c *cli.Command, event *hook.UnifiedHookEvent) error {
+	switch event.EventType {
+	case "SessionStart":
+		roots := event.WorkspaceRoots
+		if len(roots) == 0 && event.CWD != "" {
+			roots = []string{event.CWD}
+		}
+		if err := persona.HandleSessionStartForWorkspace(event.Source, event.SessionID, roots, event.FilePath); err != nil {
+			log.Error().Err(err).Msg("Failed to handle session start")
+		}
+	case "UserPromptSubmit":
+		notify.NewQuestionTracker(event.SessionID).Clear()
+		startAck(loadUnifiedConfig(c, event.Source), event.Source)
+	case "Stop":
+		handleAssistantMessage(ctx, c, event)
+		if event.AIResponse != "" {
+			return handleDirectResponseVoice(ctx, c, event)
+		}
+		return handleStopEventVoice(ctx, c, event)
+	case "Notification":
+		return handleNotificationEvent(ctx, c, event)
+	case "SessionEnd":
+		notify.NewQuestionTracker(event.SessionID).Clear()
+		if err := persona.HandleSessionEnd(event.SessionID); err != nil {
+			log.Warn().Err(err).Msg("Failed to record session end")
+		}
+		startTranscriptPrune()
+	default:
+		log.Debug().Str("source", event.Source).Str("event_type", event.EventType).Msg("Unhandled plugin event type")
+	}
+	return nil
+}
+
 func handleLegacyNotification(ctx context.Context, c *cli.Command) error {
 	// Legacy notification format (simple JSON with message field)
 	// This is kept for backward compatibility
@@ -141,8 +237,8 @@ func handleCodexAgentTurnComplete(ctx context.Context, c *cli.Command, event *ho
 	}
 
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] LastAssistantMessage: %q\n", codexEvent.LastAssistantMessage)
-		fmt.Fprintf(os.Stderr, "[DEBUG] voice flag: %v\n", c.Bool("voice"))
+		debugf("LastAssistantMessage: %q\n", codexEvent.LastAssistantMessage)
+		debugf("voice flag: %v\n", c.Bool("voice"))
 	}
 
 	log.Info().
@@ -152,7 +248,7 @@ func handleCodexAgentTurnComplete(ctx context.Context, c *cli.Command, event *ho
 		Msg("Codex agent turn complete")
 
 	// Desktop notification (if enabled)
-	if c.Bool("desktop") {
+	if c.Bool("desktop") && !dndActive(loadUnifiedConfig(c, event.Source)) {
 		message := fmt.Sprintf("Turn %s completed", codexEvent.TurnID)
 		if err := showDesktopNotification(message, "normal"); err != nil {
 			log.Warn().Err(err).Msg("Failed to show desktop notification")
@@ -166,8 +262,11 @@ func handleCodexAgentTurnComplete(ctx context.Context, c *cli.Command, event *ho
 			return nil
 		}
 		config := loadUnifiedConfig(c, event.Source)
+		if collectDigest(ctx, config, notifyEvent(event, codexEvent.LastAssistantMessage), "normal") {
+			return nil
+		}
 		opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "")
-		voiceConfig := opts.ToConfig(voice.DefaultConfig())
+		voiceConfig := opts.ToConfig(config.VoiceBaseConfig())
 
 		// Process text according to reading mode
 		reader := voice.NewTranscriptReader(voiceConfig)
@@ -179,9 +278,17 @@ func handleCodexAgentTurnComplete(ctx context.Context, c *cli.Command, event *ho
 			log.Debug().Msg("No text to synthesize after processing, skipping")
 			return nil
 		}
+		text = sessionPrefix(config, event.SessionID, text)
 
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Text to synthesize: %q\n", text)
+			debugf("Text to synthesize: %q\n", text)
+		}
+
+		if forwarded, err := forwardSpeech(ctx, config, voiceConfig, opts, event.EventType, text); forwarded {
+			if err != nil {
+				log.Warn().Err(err).Msg("Daemon failed to speak")
+			}
+			return nil
 		}
 
 		manager := voice.NewVoiceManager(voiceConfig)
@@ -189,17 +296,17 @@ func handleCodexAgentTurnComplete(ctx context.Context, c *cli.Command, event *ho
 		if err != nil {
 			log.Warn().Err(err).Msg("Failed to synthesize voice")
 			if debug {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Synthesize error: %v\n", err)
+				debugf("Synthesize error: %v\n", err)
 			}
 		} else {
 			if debug {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Audio file: %s\n", audioFile)
+				debugf("Audio file: %s\n", audioFile)
 			}
-			engine := voice.NewVoiceEngine(voiceConfig)
-			if err := engine.PlayWithOptions(audioFile, true); err != nil {
+			recordSpeech(config, opts, text, audioFile)
+			if err := playHookAudio(ctx, config, voiceConfig, event.EventType, audioFile); err != nil {
 				log.Warn().Err(err).Msg("Failed to play audio")
 				if debug {
-					fmt.Fprintf(os.Stderr, "[DEBUG] Play error: %v\n", err)
+					debugf("Play error: %v\n", err)
 				}
 			}
 		}
@@ -213,54 +320,58 @@ func handleStopEventVoice(ctx context.Context, c *cli.Command, event *hook.Unifi
 
 	if !c.Bool("voice") {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Voice flag not set, skipping voice synthesis\n")
+			debugf("Voice flag not set, skipping voice synthesis\n")
 		}
 		return nil
 	}
 
-	if voice.IsMuted() {
-		log.Debug().Msg("voice synthesis is globally muted, skipping Stop event voice")
-		return nil
-	}
-
 	// Get transcript path from the event
-	var transcriptPath string
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] RawEvent type: %T\n", event.RawEvent)
+		debugf("RawEvent type: %T\n", event.RawEvent)
 	}
-	switch e := event.RawEvent.(type) {
-	case *hook.StopEvent:
-		transcriptPath = e.TranscriptPath
-	case *hook.CursorStopEvent:
-		transcriptPath = e.TranscriptPath
-	default:
+	transcriptPath, ok := stopTranscriptPath(event)
+	if !ok {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Unknown event type for stop event: %T\n", event.RawEvent)
+			debugf("Unknown event type for stop event: %T\n", event.RawEvent)
 		}
 		return nil
 	}
 
 	if transcriptPath == "" {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] No transcript path in event\n")
+			debugf("No transcript path in event\n")
 		}
 		return nil
 	}
 
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Transcript path: %s\n", transcriptPath)
+		debugf("Transcript path: %s\n", transcriptPath)
 	}
 
 	config := loadUnifiedConfig(c, event.Source)
 	opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "")
-	voiceConfig := opts.ToConfig(voice.DefaultConfig())
+	voiceConfig := opts.ToConfig(config.VoiceBaseConfig())
+	reader := voice.NewTranscriptReader(voiceConfig)
+
+	// A turn that ended on a failed tool call has no reply to read; report
+	// the failure instead of an older message.
+	if toolErr, err := reader.GetFinalToolError(transcriptPath); err != nil {
+		log.Debug().Err(err).Msg("Failed to check transcript for a tool error")
+	} else if toolErr != nil {
+		announceToolError(ctx, c, config, event, toolErr)
+		return nil
+	}
+
+	if voice.IsMuted() {
+		log.Debug().Msg("voice synthesis is globally muted, skipping Stop event voice")
+		return nil
+	}
 
 	// Read latest assistant message from transcript
-	reader := voice.NewTranscriptReader(voiceConfig)
 	text, err := reader.GetLatestAssistantMessage(transcriptPath)
 	if err != nil {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Failed to get assistant message: %v\n", err)
+			debugf("Failed to get assistant message: %v\n", err)
 		}
 		log.Warn().Err(err).Msg("Failed to get assistant message from transcript")
 		return nil
@@ -272,7 +383,7 @@ func handleStopEventVoice(ctx context.Context, c *cli.Command, event *hook.Unifi
 
 	if text == "" {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] No text to synthesize after processing\n")
+			debugf("No text to synthesize after processing\n")
 		}
 		return nil
 	}
@@ -283,47 +394,349 @@ func handleStopEventVoice(ctx context.Context, c *cli.Command, event *hook.Unifi
 		log.Debug().Msg("Skipping duplicate voice synthesis")
 		return nil
 	}
+	message := text
+	if collectDigest(ctx, config, notifyEvent(event, message), "normal") {
+		dedup.Record(message)
+		return nil
+	}
+
+	// Diff mode reads only what changed since the last spoken message
+	var history *voice.SpokenHistory
+	if voiceConfig.DiffMode && event.SessionID != "" {
+		history = voice.NewSpokenHistory(event.SessionID)
+		text = voice.NewSentences(history.Last(), message)
+		if text == "" {
+			dedup.Record(message)
+			history.Record(message)
+			if debug {
+				debugf("No new sentences since the last spoken message\n")
+			}
+			return nil
+		}
+	}
 
+	text = sessionPrefix(config, event.SessionID, text)
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Text to synthesize: %q\n", text)
+		debugf("Text to synthesize: %q\n", text)
 	}
 
-	manager := voice.NewVoiceManager(voiceConfig)
-	audioFile, err := manager.Synthesize(ctx, text, opts)
-	if err != nil {
-		log.Warn().Err(err).Msg("Failed to synthesize voice")
-		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Synthesize error: %v\n", err)
+	forwarded, err := forwardSpeech(ctx, config, voiceConfig, opts, event.EventType, text)
+	var audioFile string
+	if !forwarded {
+		audioFile, err = voice.NewVoiceManager(voiceConfig).Synthesize(ctx, text, opts)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to synthesize voice")
+			if debug {
+				debugf("Synthesize error: %v\n", err)
+			}
+			return nil
 		}
-		return nil
 	}
 
-	dedup.Record(text)
+	// A message the daemon failed to speak is left unrecorded, so the next
+	// hook tries it again.
+	if forwarded && err != nil {
+		log.Warn().Err(err).Msg("Daemon failed to speak")
+		return nil
+	}
+	dedup.Record(message)
 	go dedup.Cleanup()
+	if history != nil {
+		history.Record(message)
+	}
+	if forwarded {
+		return nil
+	}
 
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Audio file: %s\n", audioFile)
+		debugf("Audio file: %s\n", audioFile)
 	}
 
-	engine := voice.NewVoiceEngine(voiceConfig)
-	if err := engine.PlayWithOptions(audioFile, true); err != nil {
+	recordSpeech(config, opts, text, audioFile)
+	if err := playHookAudio(ctx, config, voiceConfig, event.EventType, audioFile); err != nil {
 		log.Warn().Err(err).Msg("Failed to play audio")
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Play error: %v\n", err)
+			debugf("Play error: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// announceToolError delivers the summary of a tool error that ended the turn
+// as a "tool_error" notification with high urgency. Voice still honors the
+// mute in deliver, while desktop and other channels go out.
+func announceToolError(ctx context.Context, c *cli.Command, config *persona.Config, event *hook.UnifiedHookEvent, toolErr *voice.ToolError) {
+	message := toolErr.Summary()
+	dedup := voice.NewDedupTracker(event.SessionID)
+	if dedup.IsDuplicate(message) {
+		log.Debug().Msg("Skipping duplicate tool error announcement")
+		return
+	}
+	nevent := notifyEvent(event, message)
+	nevent.Name = "tool_error"
+	if toolErr.Tool != "" {
+		nevent.Tool = toolErr.Tool
+	}
+	route := routeNotification(c, config, nevent, "high")
+	deliver(ctx, config, route, nevent, sessionPrefix(config, event.SessionID, message), eventActionTarget(event))
+	dedup.Record(message)
+}
+
+// stopTranscriptPath returns the transcript path carried by a stop event.
+func stopTranscriptPath(event *hook.UnifiedHookEvent) (string, bool) {
+	switch e := event.RawEvent.(type) {
+	case *hook.StopEvent:
+		return e.TranscriptPath, true
+	case *hook.CursorStopEvent:
+		return e.TranscriptPath, true
+	case *hook.PluginEvent:
+		return e.TranscriptPath, true
+	default:
+		return "", false
+	}
+}
+
+// handleAssistantMessage runs the triggers and question escalation for the
+// assistant's final message. It runs regardless of --voice and never fails
+// the hook.
+func handleAssistantMessage(ctx context.Context, c *cli.Command, event *hook.UnifiedHookEvent) {
+	config := loadUnifiedConfig(c, event.Source)
+	if config == nil || config.Notifications == nil {
+		return
+	}
+	rules := config.Notifications
+	if !rules.HasTriggers() && !rules.Questions.IsEnabled() && !rules.Keywords.IsEnabled() {
+		return
+	}
+	message := event.AIResponse
+	if message == "" {
+		transcriptPath, _ := stopTranscriptPath(event)
+		if transcriptPath == "" {
+			return
+		}
+		reader := voice.NewTranscriptReader(config.VoiceBaseConfig())
+		var err error
+		message, err = reader.GetLatestAssistantMessage(transcriptPath)
+		if err != nil {
+			log.Debug().Err(err).Msg("No assistant message for triggers")
+			return
 		}
 	}
+	onNewAssistantMessage(ctx, config, event.SessionID, message)
+}
+
+// onNewAssistantMessage is onAssistantMessage for a message the session has
+// not reacted to yet, since Stop hooks can repeat for the same message.
+func onNewAssistantMessage(ctx context.Context, config *persona.Config, sessionID, message string) {
+	dedup := voice.NewMessageDedupTracker(sessionID)
+	if dedup.IsDuplicate(message) {
+		log.Debug().Msg("Skipping triggers for an assistant message already handled")
+		return
+	}
+	dedup.Record(message)
+	onAssistantMessage(ctx, config, sessionID, message)
+}
+
+// onAssistantMessage reacts to the assistant's final message beyond reading it:
+// watched keywords are alerted, triggers fire, and questions are escalated.
+func onAssistantMessage(ctx context.Context, config *persona.Config, sessionID, message string) {
+	alertKeywords(ctx, config, sessionID, message)
+	fireTriggers(ctx, config, message)
+	escalateQuestion(config, sessionID, message)
+}
+
+// alertKeywords delivers a critical alert when message contains a watched
+// keyword. Only the assistant's prose is scanned, not tool calls or their
+// output. The route skips the rules and do-not-disturb, and critical
+// urgency is never held for the digest; only the global mute silences its
+// voice.
+func alertKeywords(ctx context.Context, config *persona.Config, sessionID, message string) {
+	if config == nil || config.Notifications == nil {
+		return
+	}
+	keywords := config.Notifications.Keywords
+	found := keywords.Match(message)
+	if len(found) == 0 {
+		return
+	}
+	log.Warn().Strs("keywords", found).Str("session_id", sessionID).Msg("Watched keyword in assistant message")
+	if keywords.Sound != "" && !voice.IsMuted() {
+		// The alert goes out while the sound plays, so a long clip does
+		// not hold up the Stop hook.
+		engine := voice.NewVoiceEngine(config.VoiceBaseConfig())
+		if err := engine.StartFile(keywords.SoundPath()); err != nil {
+			log.Warn().Err(err).Msg("Failed to play keyword alert sound")
+		}
+	}
+	alert := notify.KeywordAlert(found)
+	event := notify.Event{Name: notify.EventKeyword, Text: alert, Source: "ccpersona", SessionID: sessionID}
+	event.Project, _ = os.Getwd()
+	deliver(ctx, config, keywords.Route(), event, alert, nil)
+}
+
+// fireTriggers runs the actions of every trigger matching message. Sound
+// actions honor the global mute; failures are logged.
+func fireTriggers(ctx context.Context, config *persona.Config, message string) {
+	if config == nil {
+		return
+	}
+	engine := voice.NewVoiceEngine(config.VoiceBaseConfig())
+	for _, i := range config.Notifications.MatchTriggers(message) {
+		for _, action := range config.Notifications.Triggers[i].Actions {
+			if action.Sound != "" && voice.IsMuted() {
+				log.Debug().Msg("voice synthesis is globally muted, skipping trigger sound")
+				continue
+			}
+			if err := notify.RunAction(ctx, action, i, message, engine.PlayFile); err != nil {
+				log.Warn().Err(err).Int("trigger", i).Str("action", action.Kind()).Msg("Trigger action failed")
+			}
+		}
+	}
+}
+
+// escalateQuestion alerts the user when the message ends with a question:
+// the question earcon, a sticky desktop notification, and a detached reminder
+// that repeats until the next prompt clears the question. A message that asks
+// nothing clears any earlier question in the session.
+func escalateQuestion(config *persona.Config, sessionID, message string) {
+	if config == nil || config.Notifications == nil || !config.Notifications.Questions.IsEnabled() {
+		return
+	}
+	q := config.Notifications.Questions
+	var tracker *notify.QuestionTracker
+	if sessionID != "" {
+		tracker = notify.NewQuestionTracker(sessionID)
+	}
+
+	question, ok := notify.DetectQuestion(message)
+	if !ok {
+		if tracker != nil {
+			tracker.Clear()
+		}
+		return
+	}
+
+	alertQuestion(config, q, question)
+
+	if tracker == nil || q.RepeatMinutes <= 0 {
+		return
+	}
+	pending, err := tracker.Mark(question)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to record pending question")
+		return
+	}
+	if err := startQuestionReminder(pending, q); err != nil {
+		log.Warn().Err(err).Msg("Failed to start question reminder")
+	}
+}
+
+// alertQuestion plays the question earcon and shows the desktop alert.
+func alertQuestion(config *persona.Config, q *notify.QuestionConfig, question string) {
+	if q.Sound != "" && !voice.IsMuted() {
+		engine := voice.NewVoiceEngine(config.VoiceBaseConfig())
+		if err := engine.PlayFile(q.SoundPath()); err != nil {
+			log.Warn().Err(err).Msg("Failed to play question sound")
+		}
+	}
+	if dndActive(config) {
+		return
+	}
+	var err error
+	if q.IsSticky() {
+		err = showStickyNotification(question)
+	} else {
+		err = showDesktopNotification(question, "critical")
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to show question notification")
+	}
+}
+
+// startQuestionReminder launches `runtime notify remind` in the background so
+// the hook can return while the reminder waits.
+func startQuestionReminder(pending *notify.PendingQuestion, q *notify.QuestionConfig) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(self, "runtime", "notify", "remind",
+		"--session", pending.SessionID,
+		"--token", pending.Token(),
+		"--every", strconv.Itoa(q.RepeatMinutes),
+		"--max", strconv.Itoa(q.EffectiveMaxRepeats()),
+	)
+	return detach.Start(cmd)
+}
 
+// handleNotifyRemind re-alerts an unanswered question every --every minutes,
+// up to --max times. It exits as soon as the question is answered or replaced
+// by a newer one.
+func handleNotifyRemind(ctx context.Context, c *cli.Command) error {
+	tracker := notify.NewQuestionTracker(c.String("session"))
+	token := c.String("token")
+	interval := time.Duration(c.Int("every")) * time.Minute
+	if interval <= 0 {
+		return usageError(fmt.Errorf("--every must be positive"))
+	}
+
+	for i := 0; i < int(c.Int("max")); i++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+		pending := tracker.Pending()
+		if pending == nil || pending.Token() != token {
+			return nil
+		}
+		config := loadUnifiedConfig(c, "")
+		alertQuestion(config, reminderQuestions(config), "Still waiting for your answer: "+pending.Question)
+	}
 	return nil
 }
 
+// reminderQuestions returns the question settings a reminder alerts with:
+// the configured ones, or plain defaults once they were turned off or when
+// the config has none.
+func reminderQuestions(config *persona.Config) *notify.QuestionConfig {
+	if config != nil && config.Notifications != nil && config.Notifications.Questions.IsEnabled() {
+		return config.Notifications.Questions
+	}
+	return &notify.QuestionConfig{Enabled: true}
+}
+
+// handleCursorStopVoice speaks the last agent message of a Cursor
+// conversation. The stop payload has no response text, so the message is
+// read from the transcript or Cursor's local chat storage.
+func handleCursorStopVoice(ctx context.Context, c *cli.Command, event *hook.UnifiedHookEvent) error {
+	if stop, ok := event.RawEvent.(*hook.CursorStopEvent); ok && stop.Status != "" && stop.Status != "completed" {
+		log.Debug().Str("status", stop.Status).Msg("Cursor turn did not complete, skipping voice synthesis")
+		return nil
+	}
+	if !c.Bool("voice") || voice.IsMuted() {
+		return nil
+	}
+	transcriptPath, _ := stopTranscriptPath(event)
+	message, err := cursor.LastAssistantMessage(event.SessionID, transcriptPath)
+	if err != nil {
+		log.Debug().Err(err).Str("conversation_id", event.SessionID).Msg("No Cursor agent message to speak")
+		return nil
+	}
+	event.AIResponse = message
+	return handleDirectResponseVoice(ctx, c, event)
+}
+
 // handleDirectResponseVoice synthesizes voice from the AIResponse field directly
-// Used for Cursor's afterAgentResponse event which provides the AI response in the event payload
+// Used for Cursor's afterAgentResponse and Windsurf's post_cascade_response
+// events, which provide the AI response in the event payload
 func handleDirectResponseVoice(ctx context.Context, c *cli.Command, event *hook.UnifiedHookEvent) error {
 	debug := os.Getenv("CCPERSONA_DEBUG") != ""
 
 	if !c.Bool("voice") {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Voice flag not set, skipping voice synthesis\n")
+			debugf("Voice flag not set, skipping voice synthesis\n")
 		}
 		return nil
 	}
@@ -336,18 +749,35 @@ func handleDirectResponseVoice(ctx context.Context, c *cli.Command, event *hook.
 	text := event.AIResponse
 	if text == "" {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] No AI response in event\n")
+			debugf("No AI response in event\n")
 		}
 		return nil
 	}
 
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] AI response length: %d\n", len(text))
+		debugf("AI response length: %d\n", len(text))
+	}
+
+	// Cursor reports the same response through afterAgentResponse and stop
+	var dedup *voice.DedupTracker
+	if event.SessionID != "" {
+		dedup = voice.NewDedupTracker(event.SessionID)
+		if dedup.IsDuplicate(text) {
+			log.Debug().Msg("Skipping duplicate voice synthesis")
+			return nil
+		}
 	}
+	message := text
 
 	config := loadUnifiedConfig(c, event.Source)
+	if collectDigest(ctx, config, notifyEvent(event, message), "normal") {
+		if dedup != nil {
+			dedup.Record(message)
+		}
+		return nil
+	}
 	opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "")
-	voiceConfig := opts.ToConfig(voice.DefaultConfig())
+	voiceConfig := opts.ToConfig(config.VoiceBaseConfig())
 
 	// Process text according to reading mode
 	reader := voice.NewTranscriptReader(voiceConfig)
@@ -356,34 +786,50 @@ func handleDirectResponseVoice(ctx context.Context, c *cli.Command, event *hook.
 
 	if text == "" {
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] No text to synthesize after processing\n")
+			debugf("No text to synthesize after processing\n")
 		}
 		return nil
 	}
 
+	text = sessionPrefix(config, event.SessionID, text)
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Text to synthesize: %q\n", text)
+		debugf("Text to synthesize: %q\n", text)
 	}
 
-	manager := voice.NewVoiceManager(voiceConfig)
-	audioFile, err := manager.Synthesize(ctx, text, opts)
-	if err != nil {
-		log.Warn().Err(err).Msg("Failed to synthesize voice")
-		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Synthesize error: %v\n", err)
+	forwarded, err := forwardSpeech(ctx, config, voiceConfig, opts, event.EventType, text)
+	var audioFile string
+	if !forwarded {
+		audioFile, err = voice.NewVoiceManager(voiceConfig).Synthesize(ctx, text, opts)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to synthesize voice")
+			if debug {
+				debugf("Synthesize error: %v\n", err)
+			}
+			return nil
 		}
+	}
+
+	if forwarded && err != nil {
+		log.Warn().Err(err).Msg("Daemon failed to speak")
+		return nil
+	}
+	if dedup != nil {
+		dedup.Record(message)
+		go dedup.Cleanup()
+	}
+	if forwarded {
 		return nil
 	}
 
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Audio file: %s\n", audioFile)
+		debugf("Audio file: %s\n", audioFile)
 	}
 
-	engine := voice.NewVoiceEngine(voiceConfig)
-	if err := engine.PlayWithOptions(audioFile, true); err != nil {
+	recordSpeech(config, opts, text, audioFile)
+	if err := playHookAudio(ctx, config, voiceConfig, event.EventType, audioFile); err != nil {
 		log.Warn().Err(err).Msg("Failed to play audio")
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Play error: %v\n", err)
+			debugf("Play error: %v\n", err)
 		}
 	}
 
@@ -392,49 +838,265 @@ func handleDirectResponseVoice(ctx context.Context, c *cli.Command, event *hook.
 
 func handleNotificationEvent(ctx context.Context, c *cli.Command, event *hook.UnifiedHookEvent) error {
 	message := event.AIResponse
+	config := loadUnifiedConfig(c, event.Source)
+	nevent := notifyEvent(event, message)
+	route := routeNotification(c, config, nevent, notificationUrgency(message))
+	deliver(ctx, config, route, nevent, sessionPrefix(config, event.SessionID, message), eventActionTarget(event))
+	return nil
+}
 
-	// Desktop notification
-	if c.Bool("desktop") {
-		urgency := "normal"
-		if strings.Contains(strings.ToLower(message), "permission") {
-			urgency = "critical"
-		} else if strings.Contains(strings.ToLower(message), "idle") {
-			urgency = "low"
-		} else if strings.Contains(strings.ToLower(message), "error") {
-			urgency = "high"
+// forwardHookEvent publishes the hook event to MQTT and sends it to Telegram
+// and the team hub when their events lists select it, whether or not
+// anything else is delivered for it.
+func forwardHookEvent(ctx context.Context, c *cli.Command, event *hook.UnifiedHookEvent) {
+	config := loadUnifiedConfig(c, event.Source)
+	if config == nil || config.Notifications == nil {
+		return
+	}
+	nevent := notifyEvent(event, event.AIResponse)
+	if config.Notifications.MQTT.ForwardsEvent(event.EventType) {
+		if err := notify.PublishMQTT(ctx, config.Notifications.MQTT, notify.NewMQTTMessage(nevent, "")); err != nil {
+			log.Warn().Err(err).Msg("Failed to publish hook event to MQTT")
 		}
-
-		if err := showDesktopNotification(message, urgency); err != nil {
-			log.Warn().Err(err).Msg("Failed to show desktop notification")
+	}
+	if config.Notifications.Telegram.ForwardsEvent(event.EventType) {
+		if err := sendTelegram(ctx, config.Notifications.Telegram, nevent, ""); err != nil {
+			log.Warn().Err(err).Msg("Failed to send hook event to Telegram")
+		}
+	}
+	if config.Notifications.Hub.ForwardsEvent(event.EventType) {
+		if err := notify.PostHub(ctx, config.Notifications.Hub, notify.NewMQTTMessage(nevent, "")); err != nil {
+			log.Warn().Err(err).Msg("Failed to forward hook event to the hub")
 		}
 	}
+}
+
+// sessionPrefix names the session in spoken text while other sessions are
+// active, so the user can tell their announcements apart.
+func sessionPrefix(config *persona.Config, sessionID, text string) string {
+	if sessionID == "" || !config.SessionNamesEnabled() {
+		return text
+	}
+	name, others := voice.NewSessionRegistry().Touch(sessionID)
+	if others == 0 {
+		return text
+	}
+	return name + " session: " + text
+}
+
+// notifyEvent converts a hook event into what notification rules match on.
+func notifyEvent(event *hook.UnifiedHookEvent, message string) notify.Event {
+	return notify.Event{
+		Name:     event.EventType,
+		Text:     message,
+		Tool:     event.ToolName,
+		Model:    event.Model,
+		Subagent: event.IsSubagent,
+
+		Source:    event.Source,
+		SessionID: event.SessionID,
+		Project:   event.CWD,
+	}
+}
+
+// notificationUrgency guesses the urgency of a Claude Code notification
+// before rules apply: permission prompts are critical, errors high, and idle
+// reminders low.
+func notificationUrgency(message string) string {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "permission"):
+		return "critical"
+	case strings.Contains(lower, "idle"):
+		return "low"
+	case strings.Contains(lower, "error"):
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// speakMessage synthesizes text with the voice resolved from config and blocks
+// until playback finishes, unless voice.playback detaches event. Interactive
+// callers pass no event. Callers are responsible for the mute gate.
+func speakMessage(ctx context.Context, config *persona.Config, event, text string) error {
+	return speakUrgent(ctx, config, event, "", "", text)
+}
 
-	// Voice notification
+// speakUrgent is speakMessage with the speaker style configured for an
+// urgency level, and the voice a notification rule chose ("" for the
+// persona's).
+func speakUrgent(ctx context.Context, config *persona.Config, event, urgency, voiceName, text string) error {
+	opts := resolveVoiceFlag(config, "", voiceName).ForUrgency(urgency)
+	voiceConfig := opts.ToConfig(config.VoiceBaseConfig())
+	if forwarded, err := forwardSpeech(ctx, config, voiceConfig, opts, event, text); forwarded {
+		return err
+	}
+
+	manager := voice.NewVoiceManager(voiceConfig)
+	audioFile, err := manager.Synthesize(ctx, text, opts)
+	if err != nil {
+		return fmt.Errorf("failed to synthesize voice: %w", err)
+	}
+
+	recordSpeech(config, opts, text, audioFile)
+	if err := playHookAudio(ctx, config, voiceConfig, event, audioFile); err != nil {
+		return fmt.Errorf("failed to play audio: %w", err)
+	}
+	return nil
+}
+
+// announce delivers a runtime message (exec, ci) on the channels chosen by
+// the notification rules, falling back to the --desktop and --voice flags.
+func announce(ctx context.Context, c *cli.Command, event, message, urgency string) {
+	config := loadUnifiedConfig(c, "")
+	nevent := notify.Event{Name: event, Text: message, Source: "ccpersona"}
+	nevent.Project, _ = os.Getwd()
+	deliver(ctx, config, routeNotification(c, config, nevent, urgency), nevent, message, nil)
+}
+
+// routeNotification applies the configured notification rules to an event.
+func routeNotification(c *cli.Command, config *persona.Config, event notify.Event, urgency string) notify.Route {
+	var defaults []string
 	if c.Bool("voice") {
-		if voice.IsMuted() {
-			log.Debug().Msg("voice synthesis is globally muted, skipping notification voice")
-			return nil
-		}
-		config := loadUnifiedConfig(c, event.Source)
-		opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "")
-		voiceConfig := opts.ToConfig(voice.DefaultConfig())
+		defaults = append(defaults, notify.ChannelVoice)
+	}
+	if c.Bool("desktop") {
+		defaults = append(defaults, notify.ChannelDesktop)
+	}
+	var rules *notify.Config
+	if config != nil {
+		rules = config.Notifications
+	}
+	route := rules.RouteEvent(event, defaults, urgency)
+	if dndActive(config) {
+		log.Debug().Msg("do-not-disturb is active, dropping voice and desktop channels")
+		route = route.Quiet()
+	}
+	return route
+}
 
-		manager := voice.NewVoiceManager(voiceConfig)
-		audioFile, err := manager.Synthesize(ctx, message, opts)
+// dndActive reports whether the do-not-disturb schedule of config is active.
+func dndActive(config *persona.Config) bool {
+	return config != nil && config.Notifications.DNDActive(time.Now())
+}
+
+// enterDND mutes voice for the rest of the process, and the players and
+// reminders it starts, while do-not-disturb is active. An explicit
+// CCPERSONA_MUTE is left alone.
+func enterDND(config *persona.Config) {
+	if !dndActive(config) {
+		return
+	}
+	if _, ok := voice.MuteOverride(); ok {
+		return
+	}
+	log.Debug().Msg("do-not-disturb is active, muting voice")
+	_ = os.Setenv(voice.EnvMute, "1")
+}
+
+// deliver sends message to every channel in route. Desktop notifications
+// about a hook event get action buttons for target where the platform
+// supports them, MQTT publishes event with its metadata, Telegram and Web
+// Push reach the user's phone, and the hub shares it with the team. Failures
+// are logged and never abort the hook.
+func deliver(ctx context.Context, config *persona.Config, route notify.Route, event notify.Event, message string, target *actionTarget) {
+	ctx, span := tracing.Start(ctx, "notify.deliver")
+	defer span.End()
+	span.Set("ccpersona.event", event.Name)
+	span.Set("ccpersona.urgency", route.Urgency)
+	span.Set("ccpersona.channels", strings.Join(route.Channels, ","))
+	if route.Has(notify.ChannelDesktop) {
+		shown, err := showActionNotification(message, route.Urgency, target)
+		if !shown && err == nil {
+			err = showDesktopNotification(message, route.Urgency)
+		}
 		if err != nil {
-			log.Warn().Err(err).Msg("Failed to synthesize voice")
-		} else {
-			engine := voice.NewVoiceEngine(voiceConfig)
-			if err := engine.PlayWithOptions(audioFile, true); err != nil {
-				log.Warn().Err(err).Msg("Failed to play audio")
-			}
+			log.Warn().Err(err).Msg("Failed to show desktop notification")
+		}
+	}
+	if route.Has(notify.ChannelScreenReader) {
+		if err := notify.AnnounceScreenReader(ctx, message); err != nil {
+			log.Warn().Err(err).Msg("Failed to forward notification to screen reader")
+		}
+	}
+	if route.Has(notify.ChannelMQTT) && config != nil && config.Notifications != nil && config.Notifications.MQTT != nil {
+		if err := notify.PublishMQTT(ctx, config.Notifications.MQTT, notify.NewMQTTMessage(event, route.Urgency)); err != nil {
+			log.Warn().Err(err).Msg("Failed to publish notification to MQTT")
+		}
+	}
+	if route.Has(notify.ChannelTelegram) && config != nil && config.Notifications != nil && config.Notifications.Telegram != nil {
+		tevent := event
+		tevent.Text = message
+		if err := sendTelegram(ctx, config.Notifications.Telegram, tevent, route.Urgency); err != nil {
+			log.Warn().Err(err).Msg("Failed to send notification to Telegram")
+		}
+	}
+	if config != nil && config.Notifications != nil && config.Notifications.Hub.Sends(route) {
+		hevent := event
+		hevent.Text = message
+		if err := notify.PostHub(ctx, config.Notifications.Hub, notify.NewMQTTMessage(hevent, route.Urgency)); err != nil {
+			log.Warn().Err(err).Msg("Failed to forward notification to the hub")
 		}
 	}
+	if config != nil && config.Notifications != nil && config.Notifications.Push.Sends(route) {
+		result, err := sendPush(ctx, config.Notifications.Push, event, route.Urgency, message)
+		if err == nil && len(result.Errors) > 0 {
+			err = errors.Join(result.Errors...)
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to push notification")
+		}
+	}
+	if !route.Has(notify.ChannelVoice) {
+		return
+	}
+	if voice.IsMuted() {
+		log.Debug().Msg("voice synthesis is globally muted, skipping announcement")
+		return
+	}
+	if collectDigest(ctx, config, event, route.Urgency) {
+		return
+	}
+	if err := speakUrgent(ctx, config, event.Name, route.Urgency, route.Voice, message); err != nil {
+		log.Warn().Err(err).Msg("Failed to speak announcement")
+	}
+}
+
+const notificationTitle = "Claude Code"
 
+// showStickyNotification shows a notification that stays until dismissed. It
+// does not wait: a macOS alert blocks until the user clicks it.
+func showStickyNotification(message string) error {
+	cmd, err := buildStickyNotificationCommand(runtime.GOOS, message)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
 	return nil
 }
 
-const notificationTitle = "Claude Code"
+// buildStickyNotificationCommand is buildNotificationCommand for alerts that
+// must not time out: notify-send with no expiry on Linux and an alert dialog on
+// macOS. Windows toasts cannot be made sticky this way and use the regular
+// toast.
+func buildStickyNotificationCommand(goos, message string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		script := `on run argv
+	display alert (item 2 of argv) message (item 1 of argv) as critical
+end run`
+		return exec.Command("osascript", "-e", script, "--", message, notificationTitle), nil
+	case "linux":
+		args := append([]string{"-t", "0"}, notifySendArgs(message, "critical", notificationTitle)...)
+		return exec.Command("notify-send", args...), nil
+	default:
+		return buildNotificationCommand(goos, message, "critical")
+	}
+}
 
 func showDesktopNotification(message, urgency string) error {
 	cmd, err := buildNotificationCommand(runtime.GOOS, message, urgency)
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/detach"
+	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// Notification actions, keyed by the IDs notify-send reports.
+const (
+	actionCopyReply      = "copy"
+	actionOpenTranscript = "transcript"
+	actionOpenProject    = "project"
+)
+
+// actionWaitTimeout bounds how long the background process waits for a click
+// on a notification that never expires.
+const actionWaitTimeout = time.Hour
+
+type notificationAction struct {
+	key   string
+	label string
+}
+
+// actionTarget is the context a hook notification's actions act on.
+type actionTarget struct {
+	transcriptPath string
+	projectDir     string
+}
+
+// eventActionTarget returns the transcript and project of a hook event.
+func eventActionTarget(event *hook.UnifiedHookEvent) *actionTarget {
+	target := &actionTarget{projectDir: event.CWD}
+	if e, ok := event.RawEvent.(*hook.NotificationEvent); ok {
+		target.transcriptPath = e.TranscriptPath
+	} else if path, ok := stopTranscriptPath(event); ok {
+		target.transcriptPath = path
+	}
+	return target
+}
+
+// actions lists the actions that can be offered: only absolute paths that
+// exist, so a payload cannot smuggle options into the opener.
+func (t *actionTarget) actions(goos string) []notificationAction {
+	if t == nil {
+		return nil
+	}
+	var actions []notificationAction
+	if usablePath(t.transcriptPath) {
+		// Windows toasts cannot call back, so nothing can be copied there.
+		if goos != "windows" {
+			actions = append(actions, notificationAction{actionCopyReply, "Copy reply"})
+		}
+		actions = append(actions, notificationAction{actionOpenTranscript, "Open transcript"})
+	}
+	if usablePath(t.projectDir) {
+		actions = append(actions, notificationAction{actionOpenProject, "Open project"})
+	}
+	return actions
+}
+
+func usablePath(path string) bool {
+	if path == "" || !filepath.IsAbs(path) {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// showActionNotification shows a desktop notification with action buttons.
+// It reports false when the platform or the target offers no actions, so the
+// caller shows a plain notification instead. On Linux and macOS a background
+// `runtime notify action` process waits for the click, so the hook returns
+// at once.
+func showActionNotification(message, urgency string, target *actionTarget) (bool, error) {
+	actions := target.actions(runtime.GOOS)
+	if len(actions) == 0 {
+		return false, nil
+	}
+	switch runtime.GOOS {
+	case "windows":
+		cmd := windowsActionToastCommand(message, target)
+		return true, cmd.Run()
+	case "darwin":
+		if _, err := exec.LookPath("alerter"); err != nil {
+			return false, nil
+		}
+	case "linux":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return false, err
+	}
+	cmd := exec.Command(self, "runtime", "notify", "action",
+		"--message", message,
+		"--urgency", urgency,
+		"--transcript", target.transcriptPath,
+		"--project", target.projectDir,
+	)
+	return true, detach.Start(cmd)
+}
+
+// handleNotifyAction shows a notification with action buttons, waits for the
+// user's choice, and carries it out. Started in the background by hooks.
+func handleNotifyAction(ctx context.Context, c *cli.Command) error {
+	target := &actionTarget{
+		transcriptPath: c.String("transcript"),
+		projectDir:     c.String("project"),
+	}
+	message, urgency := c.String("message"), c.String("urgency")
+	actions := target.actions(runtime.GOOS)
+
+	ctx, cancel := context.WithTimeout(ctx, actionWaitTimeout)
+	defer cancel()
+	cmd, err := buildActionNotificationCommand(ctx, runtime.GOOS, message, urgency, actions)
+	if err != nil {
+		return err
+	}
+	out, err := cmd.Output()
+	if err != nil && len(out) == 0 {
+		// notify-send before libnotify 0.7.9 has no actions.
+		log.Debug().Err(err).Msg("Action notification failed, showing a plain one")
+		return showDesktopNotification(message, urgency)
+	}
+	key := chosenAction(runtime.GOOS, string(out), actions)
+	if key == "" {
+		return nil
+	}
+	return performAction(ctx, key, target)
+}
+
+// buildActionNotificationCommand assembles a notification command that
+// blocks until the notification closes and prints the chosen action:
+// notify-send --wait on Linux and alerter on macOS.
+func buildActionNotificationCommand(ctx context.Context, goos, message, urgency string, actions []notificationAction) (*exec.Cmd, error) {
+	switch goos {
+	case "linux":
+		args := []string{"--wait"}
+		for _, a := range actions {
+			args = append(args, "--action="+a.key+"="+a.label)
+		}
+		args = append(args, notifySendArgs(message, urgency, notificationTitle)...)
+		return exec.CommandContext(ctx, "notify-send", args...), nil
+	case "darwin":
+		labels := make([]string, len(actions))
+		for i, a := range actions {
+			labels[i] = a.label
+		}
+		return exec.CommandContext(ctx, "alerter",
+			"-title", notificationTitle,
+			"-message", message,
+			"-actions", strings.Join(labels, ","),
+			"-closeLabel", "Dismiss",
+			"-timeout", fmt.Sprint(int(actionWaitTimeout.Seconds())),
+		), nil
+	default:
+		return nil, fmt.Errorf("notification actions are not supported on %s", goos)
+	}
+}
+
+// chosenAction maps the notifier's output to an action key: notify-send
+// prints the key, alerter the label. Dismissals print neither.
+func chosenAction(goos, output string, actions []notificationAction) string {
+	output = strings.TrimSpace(output)
+	for _, a := range actions {
+		if (goos == "darwin" && output == a.label) || (goos != "darwin" && output == a.key) {
+			return a.key
+		}
+	}
+	return ""
+}
+
+// performAction carries out a chosen notification action.
+func performAction(ctx context.Context, key string, target *actionTarget) error {
+	switch key {
+	case actionCopyReply:
+		config := voice.DefaultConfig()
+		config.ReadingMode = voice.ModeFull
+		reply, err := voice.NewTranscriptReader(config).GetLatestAssistantMessage(target.transcriptPath)
+		if err != nil {
+			return err
+		}
+		return copyToClipboard(ctx, runtime.GOOS, reply)
+	case actionOpenTranscript:
+		return openPath(ctx, runtime.GOOS, target.transcriptPath)
+	case actionOpenProject:
+		return openPath(ctx, runtime.GOOS, target.projectDir)
+	default:
+		return fmt.Errorf("unknown notification action %q", key)
+	}
+}
+
+// openPath opens path with the desktop's default application.
+func openPath(ctx context.Context, goos, path string) error {
+	var cmd *exec.Cmd
+	switch goos {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "open", path)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "explorer", path)
+	default:
+		cmd = exec.CommandContext(ctx, "xdg-open", path)
+	}
+	return cmd.Start()
+}
+
+// windowsActionToastCommand builds a toast whose buttons open the transcript
+// and project through file: URIs, which Windows hands to the default
+// application without a callback. All values are passed through environment
+// variables, as in windowsToastScript.
+func windowsActionToastCommand(message string, target *actionTarget) *exec.Cmd {
+	cmd := exec.Command("powershell", "-NoProfile", "-Command", windowsActionToastScript())
+	cmd.Env = append(os.Environ(),
+		"CCPERSONA_NOTIFY_TITLE="+notificationTitle,
+		"CCPERSONA_NOTIFY_MESSAGE="+message,
+	)
+	for _, a := range target.actions("windows") {
+		switch a.key {
+		case actionOpenTranscript:
+			cmd.Env = append(cmd.Env, "CCPERSONA_NOTIFY_TRANSCRIPT_URI="+fileURI(target.transcriptPath))
+		case actionOpenProject:
+			cmd.Env = append(cmd.Env, "CCPERSONA_NOTIFY_PROJECT_URI="+fileURI(target.projectDir))
+		}
+	}
+	return cmd
+}
+
+// fileURI converts an absolute path to a file: URI.
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // C:/x -> /C:/x
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+func windowsActionToastScript() string {
+	return `
+		[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+		[Windows.UI.Notifications.ToastNotification, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+		[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null
+
+		$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+		$texts = $template.GetElementsByTagName("text")
+		$texts.Item(0).AppendChild($template.CreateTextNode($env:CCPERSONA_NOTIFY_TITLE)) | Out-Null
+		$texts.Item(1).AppendChild($template.CreateTextNode($env:CCPERSONA_NOTIFY_MESSAGE)) | Out-Null
+		$actions = $template.CreateElement("actions")
+		foreach ($pair in @(@("Open transcript", $env:CCPERSONA_NOTIFY_TRANSCRIPT_URI), @("Open project", $env:CCPERSONA_NOTIFY_PROJECT_URI))) {
+			if ($pair[1]) {
+				$action = $template.CreateElement("action")
+				$action.SetAttribute("content", $pair[0])
+				$action.SetAttribute("activationType", "protocol")
+				$action.SetAttribute("arguments", $pair[1])
+				$actions.AppendChild($action) | Out-Null
+			}
+		}
+		$template.DocumentElement.AppendChild($actions) | Out-Null
+		$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+		[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier("Claude Code").Show($toast)
+	`
+}
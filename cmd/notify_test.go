@@ -1,8 +1,22 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
+
+	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/daikw/ccpersona/internal/notify"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
 )
 
 func TestOsascriptNotifyArgs(t *testing.T) {
@@ -78,6 +92,20 @@ func TestNormalizeUrgency(t *testing.T) {
 	}
 }
 
+func TestNotificationUrgency(t *testing.T) {
+	cases := map[string]string{
+		"Claude needs your permission to use Bash": "critical",
+		"Claude is waiting for your input (idle)":  "low",
+		"Build error in main.go":                   "high",
+		"Task finished":                            "normal",
+	}
+	for in, want := range cases {
+		if got := notificationUrgency(in); got != want {
+			t.Errorf("notificationUrgency(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
 func TestNotifySendArgsNormalizesUrgency(t *testing.T) {
 	args := notifySendArgs("error occurred", "high", "Claude Code")
 	if args[0] != "-u" || args[1] != "critical" {
@@ -136,3 +164,284 @@ func TestBuildNotificationCommandWindowsUsesEnv(t *testing.T) {
 		}
 	}
 }
+
+func TestRouteNotificationUsesRulesAndFlags(t *testing.T) {
+	var route notify.Route
+	cmd := &cli.Command{
+		Name: "test",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "voice", Value: true},
+			&cli.BoolFlag{Name: "desktop"},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			config := &persona.Config{Notifications: &notify.Config{Rules: []notify.Rule{
+				{Event: "exec", Contains: "failed", Channels: []string{notify.ChannelScreenReader}},
+			}}}
+			route = routeNotification(c, config, notify.Event{Name: "exec", Text: "make succeeded"}, "normal")
+			if !reflect.DeepEqual(route.Channels, []string{notify.ChannelVoice}) {
+				t.Errorf("default channels = %v", route.Channels)
+			}
+			route = routeNotification(c, config, notify.Event{Name: "exec", Text: "make failed"}, "critical")
+			return nil
+		},
+	}
+	if err := cmd.Run(context.Background(), []string{"test"}); err != nil {
+		t.Fatal(err)
+	}
+	if route.Rule != 0 || !route.Has(notify.ChannelScreenReader) || route.Has(notify.ChannelVoice) {
+		t.Errorf("rule route = %+v", route)
+	}
+}
+
+func TestFireTriggersRunsMatchingActions(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	config := &persona.Config{Notifications: &notify.Config{Triggers: []notify.Trigger{
+		{Contains: "tests passed", Actions: []notify.Action{{Webhook: srv.URL}}},
+		{Contains: "deployed", Actions: []notify.Action{{Webhook: srv.URL}}},
+	}}}
+
+	fireTriggers(context.Background(), config, "All tests passed.")
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("webhook hits = %d, want 1", got)
+	}
+	fireTriggers(context.Background(), config, "Tests passed and deployed.")
+	if got := hits.Load(); got != 3 {
+		t.Fatalf("webhook hits = %d, want 3", got)
+	}
+	fireTriggers(context.Background(), nil, "tests passed")
+}
+
+func TestOnNewAssistantMessageSkipsRepeats(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	config := &persona.Config{Notifications: &notify.Config{Triggers: []notify.Trigger{
+		{Contains: "tests passed", Actions: []notify.Action{{Webhook: srv.URL}}},
+	}}}
+	onNewAssistantMessage(context.Background(), config, "s1", "All tests passed.")
+	onNewAssistantMessage(context.Background(), config, "s1", "All tests passed.")
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("webhook hits = %d, want 1 for a repeated Stop hook", got)
+	}
+	onNewAssistantMessage(context.Background(), config, "s1", "Now the tests passed again.")
+	onNewAssistantMessage(context.Background(), config, "s2", "All tests passed.")
+	if got := hits.Load(); got != 3 {
+		t.Fatalf("webhook hits = %d, want 3", got)
+	}
+}
+
+func TestAlertKeywordsBypassesDND(t *testing.T) {
+	var got []notify.MQTTMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg notify.MQTTMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("decode hub event: %v", err)
+		}
+		got = append(got, msg)
+	}))
+	defer srv.Close()
+
+	config := &persona.Config{Notifications: &notify.Config{
+		Keywords: &notify.KeywordConfig{Words: []string{"DROP TABLE"}, Channels: []string{notify.ChannelHub}},
+		Hub:      &notify.HubConfig{URL: srv.URL, Token: "t", Urgencies: []string{}},
+		DND:      &notify.DND{Windows: []notify.DNDWindow{{Start: "00:00", End: "00:00"}}},
+	}}
+	if !dndActive(config) {
+		t.Fatal("do-not-disturb should be active all day")
+	}
+
+	alertKeywords(context.Background(), config, "s1", "Tests pass.")
+	if len(got) != 0 {
+		t.Fatalf("alert without a keyword: %+v", got)
+	}
+	alertKeywords(context.Background(), config, "s1", "Ran drop table sessions; to reset.")
+	if len(got) != 1 {
+		t.Fatalf("hub events = %d, want 1", len(got))
+	}
+	if got[0].Event != notify.EventKeyword || got[0].Urgency != "critical" || got[0].SessionID != "s1" || !strings.Contains(got[0].Text, "DROP TABLE") {
+		t.Fatalf("hub event = %+v", got[0])
+	}
+}
+
+func TestStopTranscriptPath(t *testing.T) {
+	event := &hook.UnifiedHookEvent{RawEvent: &hook.StopEvent{HookEvent: hook.HookEvent{TranscriptPath: "/tmp/t.jsonl"}}}
+	if path, ok := stopTranscriptPath(event); !ok || path != "/tmp/t.jsonl" {
+		t.Fatalf("stopTranscriptPath() = %q, %v", path, ok)
+	}
+	if _, ok := stopTranscriptPath(&hook.UnifiedHookEvent{}); ok {
+		t.Fatal("events without a transcript should report !ok")
+	}
+}
+
+func TestBuildStickyNotificationCommand(t *testing.T) {
+	cmd, err := buildStickyNotificationCommand("linux", "-Proceed?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"notify-send", "-t", "0", "-u", "critical", "--", notificationTitle, "-Proceed?"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Fatalf("linux args = %v, want %v", cmd.Args, want)
+	}
+
+	cmd, err = buildStickyNotificationCommand("darwin", `"; do shell script "x`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(cmd.Args); n != 6 || cmd.Args[3] != "--" || cmd.Args[4] != `"; do shell script "x` {
+		t.Fatalf("darwin message must be passed as argv data: %v", cmd.Args)
+	}
+	if !strings.Contains(cmd.Args[2], "display alert") {
+		t.Fatalf("darwin should use a persistent alert: %q", cmd.Args[2])
+	}
+}
+
+func TestEscalateQuestionClearsWhenAnswered(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	config := &persona.Config{Notifications: &notify.Config{
+		Questions: &notify.QuestionConfig{Enabled: true, RepeatMinutes: 5},
+	}}
+	tracker := notify.NewQuestionTracker("session-q")
+	if _, err := tracker.Mark("Proceed?"); err != nil {
+		t.Fatal(err)
+	}
+
+	escalateQuestion(config, "session-q", "Done. Everything is merged.")
+	if tracker.Pending() != nil {
+		t.Fatal("a message without a question should clear the pending question")
+	}
+
+	// Disabled escalation leaves state untouched.
+	if _, err := tracker.Mark("Proceed?"); err != nil {
+		t.Fatal(err)
+	}
+	config.Notifications.Questions.Enabled = false
+	escalateQuestion(config, "session-q", "Done.")
+	if tracker.Pending() == nil {
+		t.Fatal("disabled escalation should not touch pending questions")
+	}
+}
+
+func TestReminderQuestions(t *testing.T) {
+	for _, config := range []*persona.Config{nil, {Name: "zundamon"}, {Notifications: &notify.Config{}}} {
+		if q := reminderQuestions(config); q == nil || !q.IsEnabled() {
+			t.Errorf("reminderQuestions(%+v) = %+v, want the defaults", config, q)
+		}
+	}
+	configured := &notify.QuestionConfig{Enabled: true, Sound: "ding.wav"}
+	config := &persona.Config{Notifications: &notify.Config{Questions: configured}}
+	if q := reminderQuestions(config); q != configured {
+		t.Errorf("reminderQuestions() = %+v, want the configured settings", q)
+	}
+}
+
+func TestSessionPrefix(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	if got := sessionPrefix(nil, "solo", "Done."); got != "Done." {
+		t.Errorf("single session = %q, want no prefix", got)
+	}
+	voice.NewSessionRegistry().Touch("other")
+	want := voice.SessionWord("solo") + " session: Done."
+	if got := sessionPrefix(nil, "solo", "Done."); got != want {
+		t.Errorf("with another session = %q, want %q", got, want)
+	}
+	off := false
+	config := &persona.Config{Voice: &persona.VoiceConfig{SessionNames: &off}}
+	if got := sessionPrefix(config, "solo", "Done."); got != "Done." {
+		t.Errorf("session_names false = %q", got)
+	}
+	if got := sessionPrefix(nil, "", "Done."); got != "Done." {
+		t.Errorf("no session ID = %q", got)
+	}
+}
+
+func TestActionTargetActions(t *testing.T) {
+	dir := t.TempDir()
+	transcript := filepath.Join(dir, "session.jsonl")
+	if err := os.WriteFile(transcript, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keys := func(actions []notificationAction) []string {
+		var out []string
+		for _, a := range actions {
+			out = append(out, a.key)
+		}
+		return out
+	}
+
+	target := &actionTarget{transcriptPath: transcript, projectDir: dir}
+	if got, want := keys(target.actions("linux")), []string{actionCopyReply, actionOpenTranscript, actionOpenProject}; !reflect.DeepEqual(got, want) {
+		t.Errorf("linux actions = %v, want %v", got, want)
+	}
+	if got, want := keys(target.actions("windows")), []string{actionOpenTranscript, actionOpenProject}; !reflect.DeepEqual(got, want) {
+		t.Errorf("windows actions = %v, want %v", got, want)
+	}
+
+	unusable := &actionTarget{transcriptPath: "session.jsonl", projectDir: filepath.Join(dir, "missing")}
+	if got := unusable.actions("linux"); len(got) != 0 {
+		t.Errorf("relative or missing paths offered actions: %v", got)
+	}
+	var none *actionTarget
+	if got := none.actions("linux"); got != nil {
+		t.Errorf("nil target offered actions: %v", got)
+	}
+}
+
+func TestBuildActionNotificationCommand(t *testing.T) {
+	actions := []notificationAction{{actionCopyReply, "Copy reply"}, {actionOpenProject, "Open project"}}
+
+	cmd, err := buildActionNotificationCommand(context.Background(), "linux", "-done", "critical", actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]string{"notify-send", "--wait", "--action=copy=Copy reply", "--action=project=Open project"},
+		notifySendArgs("-done", "critical", notificationTitle)...)
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("linux args = %q, want %q", cmd.Args, want)
+	}
+
+	cmd, err = buildActionNotificationCommand(context.Background(), "darwin", "done", "normal", actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cmd.Args, " "); !strings.Contains(got, "-actions Copy reply,Open project") || !strings.Contains(got, "-timeout 3600") {
+		t.Errorf("darwin args = %q", cmd.Args)
+	}
+
+	if _, err := buildActionNotificationCommand(context.Background(), "plan9", "done", "normal", actions); err == nil {
+		t.Error("expected an error on an unsupported platform")
+	}
+}
+
+func TestChosenAction(t *testing.T) {
+	actions := []notificationAction{{actionCopyReply, "Copy reply"}, {actionOpenTranscript, "Open transcript"}}
+	tests := []struct {
+		goos, output, want string
+	}{
+		{"linux", "copy\n", actionCopyReply},
+		{"linux", "Copy reply\n", ""},
+		{"linux", "", ""},
+		{"darwin", "Open transcript\n", actionOpenTranscript},
+		{"darwin", "@CLOSED\n", ""},
+		{"darwin", "@TIMEOUT", ""},
+	}
+	for _, tt := range tests {
+		if got := chosenAction(tt.goos, tt.output, actions); got != tt.want {
+			t.Errorf("chosenAction(%q, %q) = %q, want %q", tt.goos, tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestFileURI(t *testing.T) {
+	if got, want := fileURI("/home/me/my project/a.jsonl"), "file:///home/me/my%20project/a.jsonl"; got != want {
+		t.Errorf("fileURI = %q, want %q", got, want)
+	}
+}
@@ -2,12 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/editor"
+	"github.com/daikw/ccpersona/internal/hook"
 	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/trust"
+	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
 )
 
@@ -26,11 +32,81 @@ func handlePersonaShow(ctx context.Context, c *cli.Command) error {
 	if err != nil {
 		return err
 	}
+	if platform := c.String("platform"); platform != "" {
+		if content, err = persona.FilterPlatformSections(content, platform); err != nil {
+			return fmt.Errorf("persona '%s' has invalid platform sections: %w", personaName, err)
+		}
+	}
+	if c.Bool("expand") {
+		config, err := persona.LoadConfigWithFallback()
+		if err != nil {
+			return configError(err)
+		}
+		if content, err = persona.RenderPersona(content, persona.TemplateVars(persona.ApplyEnvOverrides(config), "")); err != nil {
+			return fmt.Errorf("persona '%s' has an invalid template: %w", personaName, err)
+		}
+	}
 
-	fmt.Println(content)
+	if title := c.String("section"); title != "" {
+		section, ok := persona.Section(content, title)
+		if !ok {
+			return fmt.Errorf("persona '%s' has no section %q (sections: %s)", personaName, title, sectionTitles(content))
+		}
+		content = section
+	}
+
+	switch {
+	case c.Bool("fold"):
+		printFolded(content)
+	case c.Bool("rendered"):
+		fmt.Print(cliui.RenderMarkdown(content))
+	default:
+		fmt.Println(content)
+	}
 	return nil
 }
 
+// printFolded prints the heading outline of a persona with the size of each
+// folded section; expand one with --section.
+func printFolded(content string) {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	headings := persona.Headings(content)
+	for i, h := range headings {
+		end := len(lines)
+		if i+1 < len(headings) {
+			end = headings[i+1].Line
+		}
+		body := 0
+		for _, line := range lines[h.Line+1 : end] {
+			if strings.TrimSpace(line) != "" {
+				body++
+			}
+		}
+		indent := strings.Repeat("  ", h.Level-1)
+		marker := "▸"
+		if body == 0 {
+			marker = " "
+		}
+		fmt.Printf("%s%s %s %s\n", indent, marker, cliui.Header(h.Title), cliui.Muted(fmt.Sprintf("(%d lines)", body)))
+	}
+	if len(headings) == 0 {
+		fmt.Println(cliui.Muted("(no sections)"))
+	}
+}
+
+func sectionTitles(content string) string {
+	var titles []string
+	for _, h := range persona.Headings(content) {
+		if h.Level > 1 {
+			titles = append(titles, h.Title)
+		}
+	}
+	if len(titles) == 0 {
+		return "none"
+	}
+	return strings.Join(titles, ", ")
+}
+
 func handleEdit(ctx context.Context, c *cli.Command) error {
 	personaName := c.Args().Get(0)
 	if personaName == "" {
@@ -44,7 +120,11 @@ func handleEdit(ctx context.Context, c *cli.Command) error {
 
 	// Create persona if it doesn't exist
 	if !manager.PersonaExists(personaName) {
-		if err := manager.CreatePersona(personaName); err != nil {
+		template := c.String("template")
+		if template == "" {
+			template = persona.DefaultTemplate
+		}
+		if err := manager.CreatePersonaFromTemplate(personaName, template, false); err != nil {
 			return err
 		}
 		fmt.Printf("Created new persona: %s\n", personaName)
@@ -53,24 +133,305 @@ func handleEdit(ctx context.Context, c *cli.Command) error {
 	// Get the path to the persona file
 	path := manager.GetPersonaPath(personaName)
 
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vi"
+	if err := openEditor(path); err != nil {
+		return err
 	}
 
-	cmd := exec.Command(editor, path)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	fmt.Printf("Edited persona: %s\n", personaName)
+	return nil
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to open editor: %w", err)
+func handlePersonaImport(ctx context.Context, c *cli.Command) error {
+	source := c.Args().Get(0)
+	if source == "" {
+		return fmt.Errorf("source is required (usage: ccpersona persona import <url|path>)")
 	}
+	return installPersona(ctx, source, importOptions{
+		name:             c.String("name"),
+		signature:        c.String("signature"),
+		force:            c.Bool("force"),
+		allowUnverified:  c.Bool("allow-unverified"),
+		requireSignature: c.Bool("require-signature"),
+	})
+}
 
-	fmt.Printf("Edited persona: %s\n", personaName)
+// importOptions are the flags `persona import` and `persona search
+// --install` share.
+type importOptions struct {
+	name             string
+	signature        string
+	force            bool
+	allowUnverified  bool
+	requireSignature bool
+}
+
+// installPersona fetches, verifies, scans, and installs the persona at
+// source.
+func installPersona(ctx context.Context, source string, opts importOptions) error {
+	name := opts.name
+	if name == "" {
+		name = persona.ImportName(source)
+	}
+	if name == "" {
+		return fmt.Errorf("cannot derive a persona name from %s; pass --name", source)
+	}
+
+	manager, err := persona.NewManager()
+	if err != nil {
+		return err
+	}
+	if manager.PersonaExists(name) && !opts.force {
+		return fmt.Errorf("persona '%s' already exists (use --force to replace it)", name)
+	}
+
+	content, err := persona.FetchPersona(ctx, source)
+	if err != nil {
+		return err
+	}
+	sigSource := opts.signature
+	if sigSource == "" {
+		sigSource = persona.SignatureSource(source)
+	}
+	signature, err := persona.FetchSignature(ctx, sigSource)
+	if err != nil {
+		return err
+	}
+	if signature == nil && opts.signature != "" {
+		return fmt.Errorf("signature %s not found", sigSource)
+	}
+
+	verified, err := verifyPersonaSignature(content, signature)
+	if err != nil {
+		return fmt.Errorf("refusing to install persona %q: %w", name, err)
+	}
+	if !verified && opts.requireSignature {
+		return fmt.Errorf("refusing to install persona %q: no signature from a trusted key", name)
+	}
+
+	findings := persona.ScanPersona(content)
+	if len(findings) > 0 {
+		fmt.Printf("%s %s has %d finding(s):\n", cliui.Warn("warning:"), source, len(findings))
+		for _, f := range findings {
+			fmt.Printf("  - %s\n", f)
+		}
+		if !verified && !opts.allowUnverified {
+			return fmt.Errorf("refusing to install flagged persona %q; review it and rerun with --allow-unverified to install anyway", name)
+		}
+	}
+
+	path, err := manager.ImportPersona(name, content, signature, opts.force)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s persona %s %s\n", cliui.Success("Imported"), name, cliui.Muted("("+path+")"))
+	if len(findings) > 0 && !verified {
+		fmt.Println(cliui.Warn("Installed without verification; review it with: ccpersona persona show " + name))
+	}
+	return nil
+}
+
+func handlePersonaSearch(ctx context.Context, c *cli.Command) error {
+	keyword := strings.Join(c.Args().Slice(), " ")
+	source := persona.IndexSource(c.String("index"), configuredPersonaIndex())
+	index, err := persona.FetchIndex(ctx, source)
+	if err != nil {
+		return err
+	}
+	matches := index.Search(keyword, c.String("lang"))
+
+	if c.Bool("install") {
+		entry, err := pickIndexEntry(matches, keyword)
+		if err != nil {
+			return err
+		}
+		name := c.String("name")
+		if name == "" {
+			name = entry.Name
+		}
+		fmt.Printf("Installing %s from %s\n", entry.Name, cliui.Muted(entry.URL))
+		return installPersona(ctx, entry.URL, importOptions{
+			name:             name,
+			force:            c.Bool("force"),
+			allowUnverified:  c.Bool("allow-unverified"),
+			requireSignature: c.Bool("require-signature"),
+		})
+	}
+
+	if len(matches) == 0 {
+		fmt.Printf("No personas in %s match %q\n", source, keyword)
+		return nil
+	}
+	manager, err := persona.NewManager()
+	if err != nil {
+		return err
+	}
+	for _, entry := range matches {
+		line := cliui.Label(entry.Name)
+		if entry.Votes > 0 {
+			line += fmt.Sprintf("  ★%.1f (%d)", entry.Rating, entry.Votes)
+		}
+		if len(entry.Languages) > 0 {
+			line += "  " + strings.Join(entry.Languages, ", ")
+		}
+		if manager.PersonaExists(entry.Name) {
+			line += "  " + cliui.Muted("(installed)")
+		}
+		fmt.Println(line)
+		if entry.Description != "" {
+			fmt.Printf("  %s\n", entry.Description)
+		}
+		if entry.Author != "" {
+			fmt.Printf("  %s\n", cliui.Muted("by "+entry.Author))
+		}
+		fmt.Printf("  %s\n", cliui.Muted(entry.URL))
+	}
+	fmt.Printf("\nInstall with: ccpersona persona search %s --install\n", matches[0].Name)
+	return nil
+}
+
+// pickIndexEntry chooses the persona `persona search --install` installs:
+// the one named keyword, or the only match.
+func pickIndexEntry(matches []persona.IndexEntry, keyword string) (persona.IndexEntry, error) {
+	for _, entry := range matches {
+		if strings.EqualFold(entry.Name, keyword) {
+			return entry, nil
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return persona.IndexEntry{}, fmt.Errorf("no persona in the index matches %q", keyword)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, entry := range matches {
+		names[i] = entry.Name
+	}
+	return persona.IndexEntry{}, usageError(fmt.Errorf("%d personas match %q (%s); search for the exact name to install one", len(matches), keyword, strings.Join(names, ", ")))
+}
+
+// configuredPersonaIndex returns persona_index from the global config.
+func configuredPersonaIndex() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	config, err := persona.LoadConfigFromPath(persona.ConfigPath(homeDir))
+	if err != nil {
+		log.Warn().Err(err).Msg("Ignoring persona_index from unreadable global config")
+		return ""
+	}
+	if config == nil {
+		return ""
+	}
+	return config.PersonaIndex
+}
+
+// verifyPersonaSignature reports whether signature is a valid signature over
+// content from a trusted key. Unsigned content and signatures from unknown
+// keys are unverified but not errors; a signature that does not match is.
+func verifyPersonaSignature(content, signature []byte) (bool, error) {
+	if signature == nil {
+		fmt.Printf("%s no %s signature found; --require-signature refuses unsigned personas\n", cliui.Warn("unsigned:"), persona.SignatureSuffix)
+		return false, nil
+	}
+	store, err := trust.DefaultStore()
+	if err != nil {
+		return false, err
+	}
+	key, sig, err := store.Verify(content, signature)
+	switch {
+	case err == nil:
+		fmt.Printf("%s signed by %s %s\n", cliui.Success("verified:"), key.ID, cliui.Muted(key.Comment))
+		if sig.TrustedComment != "" {
+			fmt.Printf("  %s %s\n", cliui.Label("trusted comment:"), sig.TrustedComment)
+		}
+		return true, nil
+	case errors.Is(err, trust.ErrUntrustedKey):
+		fmt.Printf("%s %v\n", cliui.Warn("unverified:"), err)
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// handlePersonaEnv prints the persona's environment variables as export
+// statements, for shells and agents without a session env file:
+// eval "$(ccpersona persona env)".
+func handlePersonaEnv(ctx context.Context, c *cli.Command) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		config, err := persona.LoadConfigWithFallback()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if config == nil || config.Name == "" {
+			return fmt.Errorf("no active persona (usage: ccpersona persona env [name])")
+		}
+		name = config.Name
+	}
+	manager, err := persona.NewManager()
+	if err != nil {
+		return err
+	}
+	env, err := manager.ReadPersonaEnv(name)
+	if err != nil {
+		return err
+	}
+	fmt.Print(persona.FormatEnvExports(env))
+	return nil
+}
+
+func handlePersonaVerify(ctx context.Context, c *cli.Command) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return fmt.Errorf("persona name is required (usage: ccpersona persona verify <name>)")
+	}
+	manager, err := persona.NewManager()
+	if err != nil {
+		return err
+	}
+	content, err := manager.ReadPersona(name)
+	if err != nil {
+		return err
+	}
+	signature, err := manager.ReadPersonaSignature(name)
+	if err != nil {
+		return err
+	}
+	if signature == nil {
+		return fmt.Errorf("persona %q is unsigned", name)
+	}
+	store, err := trust.DefaultStore()
+	if err != nil {
+		return err
+	}
+	key, sig, err := store.Verify([]byte(content), signature)
+	if err != nil {
+		return fmt.Errorf("persona %q: %w", name, err)
+	}
+	fmt.Printf("%s %s is signed by %s %s\n", cliui.Success("verified:"), name, key.ID, cliui.Muted(key.Comment))
+	if sig.TrustedComment != "" {
+		fmt.Printf("  %s %s\n", cliui.Label("trusted comment:"), sig.TrustedComment)
+	}
 	return nil
 }
 
+// openEditor opens path in the editor from the global config, $VISUAL, or
+// $EDITOR, falling back to vi/nano and then to an inline line editor.
+func openEditor(path string) error {
+	configured := ""
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		config, err := persona.LoadConfigFromPath(persona.ConfigPath(homeDir))
+		if err != nil {
+			log.Warn().Err(err).Msg("Ignoring editor setting from unreadable global config")
+		} else if config != nil {
+			configured = config.Editor
+		}
+	}
+	return editor.Open(path, configured)
+}
+
 func handleConfig(ctx context.Context, c *cli.Command) error {
 	var configPath string
 	var config *persona.Config
@@ -92,7 +453,7 @@ func handleConfig(ctx context.Context, c *cli.Command) error {
 		if err != nil {
 			// A parse failure must not be swallowed into a default that would
 			// overwrite the existing (broken) config on save.
-			return fmt.Errorf("failed to load global config: %w", err)
+			return configError(fmt.Errorf("failed to load global config: %w", err))
 		}
 		if config == nil {
 			config = persona.GetDefaultConfig()
@@ -105,25 +466,15 @@ func handleConfig(ctx context.Context, c *cli.Command) error {
 
 		config, err = persona.LoadConfigFromPath(configPath)
 		if err != nil {
-			return err
+			return configError(err)
 		}
 		if config == nil {
-			return fmt.Errorf("no project configuration found. Run 'ccpersona config init' first")
+			return configError(fmt.Errorf("no project configuration found. Run 'ccpersona config init' first"))
 		}
 	}
 
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vi"
-	}
-
-	cmd := exec.Command(editor, configPath)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to open editor: %w", err)
+	if err := openEditor(configPath); err != nil {
+		return err
 	}
 
 	if c.Bool("global") {
@@ -131,7 +482,10 @@ func handleConfig(ctx context.Context, c *cli.Command) error {
 	} else {
 		fmt.Println("Edited project configuration")
 	}
-	return nil
+	if c.Bool("no-verify") {
+		return nil
+	}
+	return checkSavedCredentials(ctx, config, configPath)
 }
 
 func handleConfigMigrate(ctx context.Context, c *cli.Command) error {
@@ -145,11 +499,62 @@ func handleConfigMigrate(ctx context.Context, c *cli.Command) error {
 		baseDir = homeDir
 		scope = "global"
 	}
+	dryRun := c.Bool("dry-run")
+	total := 0
+	report := func(path string, changes []string) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Println(cliui.Label(path + ":"))
+		for _, change := range changes {
+			fmt.Printf("  - %s\n", change)
+		}
+		total += len(changes)
+	}
 
-	path, err := persona.MigrateConfig(baseDir, c.Bool("force"))
+	// Legacy persona/voice files are merged into a new unified config.
+	target := persona.ConfigPath(baseDir)
+	if legacy := persona.LegacyConfigFiles(baseDir); len(legacy) > 0 {
+		_, statErr := os.Stat(target)
+		switch {
+		case statErr == nil && !c.Bool("force"):
+			fmt.Printf("%s legacy files ignored because %s exists (use --force to overwrite)\n", cliui.Warn("skip:"), target)
+		case dryRun:
+			notes := make([]string, 0, len(legacy))
+			for _, path := range legacy {
+				notes = append(notes, "merge "+path)
+			}
+			report(target, notes)
+		default:
+			path, notes, err := persona.MigrateConfig(baseDir, c.Bool("force"))
+			if err != nil {
+				return err
+			}
+			report(path, notes)
+		}
+	}
+
+	changes, err := persona.MigrateConfigFields(baseDir, dryRun)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Migrated legacy configuration to %s (%s)\n", path, scope)
+	report(target, changes)
+
+	for _, path := range hook.SettingsFiles(baseDir) {
+		changes, err := hook.MigrateSettingsFile(path, dryRun)
+		if err != nil {
+			return err
+		}
+		report(path, changes)
+	}
+
+	switch {
+	case total == 0:
+		fmt.Printf("Nothing to migrate (%s)\n", scope)
+	case dryRun:
+		fmt.Printf("%d change(s) would be made (%s); run without --dry-run to apply\n", total, scope)
+	default:
+		fmt.Printf("%s %d change(s) (%s)\n", cliui.Success("Migrated"), total, scope)
+	}
 	return nil
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/urfave/cli/v3"
+)
+
+// handlePersonaApply writes the persona into the instruction files of the
+// selected assistants in the current project, all or none of them.
+func handlePersonaApply(ctx context.Context, c *cli.Command) error {
+	targets, err := applyTargets(c.Bool("all-platforms"), c.StringSlice("target"))
+	if err != nil {
+		return usageError(err)
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	config, err := persona.LoadConfigWithFallback()
+	if err != nil {
+		return configError(err)
+	}
+	config = persona.ApplyEnvOverrides(config)
+
+	name := c.Args().First()
+	if name == "" && config != nil {
+		name = config.Name
+	}
+	if name == "" {
+		return usageError(fmt.Errorf("no persona configured; pass a name or run 'ccpersona config set-persona <name>'"))
+	}
+	manager, err := persona.NewManager()
+	if err != nil {
+		return err
+	}
+	if !manager.PersonaExists(name) {
+		return &persona.NotExistError{Name: name}
+	}
+
+	changes, err := manager.PlanApply(root, name, config, targets)
+	if err != nil {
+		return err
+	}
+	dryRun := c.Bool("dry-run")
+	if !dryRun {
+		if err := persona.CommitApply(changes); err != nil {
+			return err
+		}
+	}
+	for _, change := range changes {
+		rel, _ := filepath.Rel(root, change.Path)
+		switch {
+		case !change.Changed:
+			fmt.Printf("%s %s %s\n", cliui.Muted("-"), rel, cliui.Muted("(up to date)"))
+		case dryRun:
+			fmt.Printf("%s %s %s\n", cliui.Warn("~"), rel, cliui.Muted("(would update)"))
+		default:
+			fmt.Printf("%s %s\n", cliui.Success("✓"), rel)
+		}
+	}
+	if !dryRun {
+		fmt.Printf("Applied %s to %d target(s)\n", name, len(changes))
+	}
+	return nil
+}
+
+// applyTargets resolves --all-platforms and --target into apply targets.
+func applyTargets(all bool, names []string) ([]persona.ApplyTarget, error) {
+	if all {
+		return persona.ApplyTargets, nil
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("choose targets with --target or --all-platforms (targets: %s)", applyTargetNames())
+	}
+	var targets []persona.ApplyTarget
+	seen := map[string]bool{}
+	for _, name := range names {
+		target, ok := persona.FindApplyTarget(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown target %q (targets: %s)", name, applyTargetNames())
+		}
+		if !seen[target.Name] {
+			seen[target.Name] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+func applyTargetNames() string {
+	names := make([]string, len(persona.ApplyTargets))
+	for i, target := range persona.ApplyTargets {
+		names[i] = target.Name
+	}
+	return strings.Join(names, ", ")
+}
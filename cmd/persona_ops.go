@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/urfave/cli/v3"
+)
+
+// personaRef is a config file whose fields name a persona.
+type personaRef struct {
+	dir    string
+	fields []string
+}
+
+func handlePersonaCopy(ctx context.Context, c *cli.Command) error {
+	src, dst := c.Args().Get(0), c.Args().Get(1)
+	if src == "" || dst == "" {
+		return fmt.Errorf("source and destination are required (usage: ccpersona persona copy <src> <dst>)")
+	}
+	manager, err := persona.NewManager()
+	if err != nil {
+		return err
+	}
+	path, err := manager.CopyPersona(src, dst, c.Bool("force"))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s to %s %s\n", cliui.Success("Copied"), src, dst, cliui.Muted("("+path+")"))
+	return nil
+}
+
+func handlePersonaRename(ctx context.Context, c *cli.Command) error {
+	oldName, newName := c.Args().Get(0), c.Args().Get(1)
+	if oldName == "" || newName == "" {
+		return fmt.Errorf("old and new names are required (usage: ccpersona persona rename <old> <new>)")
+	}
+	manager, err := persona.NewManager()
+	if err != nil {
+		return err
+	}
+	fixRefs := c.Bool("fix-refs")
+
+	// Rerunning with --fix-refs after a plain rename only updates references.
+	if !(fixRefs && !manager.PersonaExists(oldName) && manager.PersonaExists(newName)) {
+		path, err := manager.RenamePersona(oldName, newName, c.Bool("force"))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %s to %s %s\n", cliui.Success("Renamed"), oldName, newName, cliui.Muted("("+path+")"))
+	}
+
+	refs, err := findPersonaRefs(oldName, c.StringSlice("search"))
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	if !fixRefs {
+		printPersonaRefs(refs, oldName)
+		fmt.Printf("Update them with: ccpersona persona rename %s %s --fix-refs\n", oldName, newName)
+		return nil
+	}
+	for _, ref := range refs {
+		err := persona.UpdateConfig(ref.dir, func(config *persona.Config) (*persona.Config, error) {
+			if config == nil || persona.RenamePersonaRefs(config, oldName, newName) == 0 {
+				return nil, nil
+			}
+			return config, nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %w", persona.ConfigPath(ref.dir), err)
+		}
+		fmt.Printf("%s %s %s\n", cliui.Success("Updated"), persona.ConfigPath(ref.dir), cliui.Muted(strings.Join(ref.fields, ", ")))
+	}
+	return nil
+}
+
+func handlePersonaDelete(ctx context.Context, c *cli.Command) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return fmt.Errorf("persona name is required (usage: ccpersona persona delete <name>)")
+	}
+	manager, err := persona.NewManager()
+	if err != nil {
+		return err
+	}
+	if !manager.PersonaExists(name) {
+		return &persona.NotExistError{Name: name}
+	}
+
+	refs, err := findPersonaRefs(name, c.StringSlice("search"))
+	if err != nil {
+		return err
+	}
+	printPersonaRefs(refs, name)
+
+	if !c.Bool("force") {
+		ok, err := confirm(fmt.Sprintf("Delete persona '%s'? [y/N] ", name))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+	removed, err := manager.DeletePersona(name)
+	if err != nil {
+		return err
+	}
+	for _, path := range removed {
+		fmt.Printf("%s %s\n", cliui.Success("Deleted"), path)
+	}
+	return nil
+}
+
+// findPersonaRefs returns the global config and the project configs found
+// under the search roots that reference name.
+func findPersonaRefs(name string, roots []string) ([]personaRef, error) {
+	dirs := []string{}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, homeDir)
+	}
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+	for _, root := range roots {
+		found, err := persona.FindProjectConfigs(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %s: %w", root, err)
+		}
+		dirs = append(dirs, found...)
+	}
+
+	var refs []personaRef
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		config, err := persona.LoadConfigFromPath(persona.ConfigPath(dir))
+		if err != nil {
+			fmt.Printf("%s %v\n", cliui.Warn("skip:"), err)
+			continue
+		}
+		if fields := persona.PersonaRefs(config, name); len(fields) > 0 {
+			refs = append(refs, personaRef{dir: dir, fields: fields})
+		}
+	}
+	return refs, nil
+}
+
+func printPersonaRefs(refs []personaRef, name string) {
+	if len(refs) == 0 {
+		return
+	}
+	fmt.Printf("%s '%s' is referenced by %d config(s):\n", cliui.Warn("warning:"), name, len(refs))
+	for _, ref := range refs {
+		fmt.Printf("  - %s %s\n", persona.ConfigPath(ref.dir), cliui.Muted(strings.Join(ref.fields, ", ")))
+	}
+}
+
+// confirm asks a yes/no question on the terminal. Without a terminal it
+// refuses, so scripts must pass --force explicitly.
+func confirm(question string) (bool, error) {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false, fmt.Errorf("refusing to delete without confirmation; pass --force when not running interactively")
+	}
+	fmt.Print(question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+func handlePersonaTemplateList(ctx context.Context, c *cli.Command) error {
+	for _, t := range persona.Templates() {
+		fmt.Printf("  %-16s %s\n", cliui.Label(t.Name), t.Description)
+	}
+	fmt.Println(cliui.Muted("\nCreate one with 'ccpersona persona template apply <template> [name]'."))
+	return nil
+}
+
+func handlePersonaTemplateApply(ctx context.Context, c *cli.Command) error {
+	template := c.Args().Get(0)
+	if template == "" {
+		return usageError(fmt.Errorf("template is required (usage: ccpersona persona template apply <template> [name])"))
+	}
+	name := c.Args().Get(1)
+	if name == "" {
+		name = template
+	}
+	manager, err := persona.NewManager()
+	if err != nil {
+		return err
+	}
+	if manager.PersonaExists(name) && !c.Bool("force") {
+		return fmt.Errorf("persona '%s' already exists (use --force to replace it, or pass another name)", name)
+	}
+	if err := manager.CreatePersonaFromTemplate(name, template, c.Bool("force")); err != nil {
+		return err
+	}
+	path := manager.GetPersonaPath(name)
+	fmt.Printf("%s %s from template %s %s\n", cliui.Success("Created"), name, template, cliui.Muted("("+path+")"))
+	if c.Bool("edit") {
+		return openEditor(path)
+	}
+	fmt.Printf("Use it with 'ccpersona config set-persona %s'.\n", name)
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/daikw/ccpersona/internal/coord"
+	"github.com/daikw/ccpersona/internal/detach"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/tracing"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// playHookAudio plays a synthesized file spoken for a hook event. When
+// voice.playback detaches the event, a detached `runtime voice play` process
+// plays it so the hook returns at once; if that cannot start, playback
+// blocks as it does by default.
+func playHookAudio(ctx context.Context, config *persona.Config, voiceConfig *voice.Config, event, audioFile string) (err error) {
+	ctx, span := tracing.Start(ctx, "voice.play")
+	defer func() {
+		span.Fail(err)
+		span.End()
+	}()
+	if config.PlaybackMode(event) == persona.PlaybackDetach {
+		err := startDetachedPlayback(ctx, audioFile, voiceConfig.Output)
+		if err == nil {
+			span.Set("ccpersona.playback", persona.PlaybackDetach)
+			return nil
+		}
+		log.Debug().Err(err).Str("event", event).Msg("Cannot detach playback, waiting for it instead")
+	}
+	span.Set("ccpersona.playback", persona.PlaybackWait)
+	// Commands the user ran (event "") take precedence over hooks.
+	priority := coord.Hook
+	if event == "" {
+		priority = coord.Interactive
+	}
+	claim, busy := claimProject(".", coord.Audio, priority)
+	if busy {
+		log.Info().Str("event", event).Msg("Another ccpersona process is speaking in this project; skipping audio")
+		voice.DiscardAudio(audioFile)
+		return nil
+	}
+	defer claim.Release()
+	return voice.NewVoiceEngine(voiceConfig).PlayWithOptions(audioFile, true)
+}
+
+// startDetachedPlayback hands audioFile, its caption, and the output device
+// to a detached process, which deletes the file when playback ends. The
+// process continues the trace in ctx.
+func startDetachedPlayback(ctx context.Context, audioFile string, output *voice.OutputConfig) error {
+	switch voice.PlayerName() {
+	case "":
+		return errors.New("no audio player found")
+	case "in-process":
+		return errors.New("playback is routed through this process")
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := voice.SaveSpeech(audioFile); err != nil {
+		return err
+	}
+	args := []string{"runtime", "voice", "play"}
+	if output.Active() {
+		args = append(args, "--device", output.Device)
+		if output.Monitor {
+			args = append(args, "--monitor")
+		}
+	}
+	cmd := exec.Command(self, append(args, audioFile)...)
+	cmd.Env = tracing.Environ(ctx, os.Environ())
+	if err := detach.Start(cmd); err != nil {
+		_ = voice.RestoreSpeech(audioFile)
+		return err
+	}
+	return nil
+}
+
+// handleVoicePlay plays a file handed over by a hook with detached playback
+// and deletes it afterwards.
+func handleVoicePlay(ctx context.Context, c *cli.Command) (err error) {
+	audioFile := c.Args().First()
+	if audioFile == "" {
+		return usageError(fmt.Errorf("audio file is required (usage: ccpersona runtime voice play <file>)"))
+	}
+	if err := voice.RestoreSpeech(audioFile); err != nil {
+		log.Debug().Err(err).Msg("Failed to restore caption for detached playback")
+	}
+	_, span, finishTrace := startTrace(ctx, "", "ccpersona voice.play")
+	defer func() {
+		span.Fail(err)
+		finishTrace()
+	}()
+	// Detached playback comes from hooks, so it gives way like one.
+	claim, busy := claimProject(".", coord.Audio, coord.Hook)
+	if busy {
+		log.Info().Msg("Another ccpersona process is speaking in this project; skipping audio")
+		voice.DiscardAudio(audioFile)
+		return nil
+	}
+	defer claim.Release()
+	config := voice.DefaultConfig()
+	if device := c.String("device"); device != "" {
+		// The hook that started this process checked the confirmation.
+		config.Output = &voice.OutputConfig{Device: device, Confirm: true, Monitor: c.Bool("monitor")}
+	}
+	return voice.NewVoiceEngine(config).PlayWithOptions(audioFile, true)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+)
+
+func TestPlayHookAudioFallsBackToWaiting(t *testing.T) {
+	audio := filepath.Join(t.TempDir(), "speech.wav")
+	if err := os.WriteFile(audio, []byte("RIFF"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var played []string
+	defer voice.SetPlayer(func(path string) error {
+		played = append(played, path)
+		return nil
+	})()
+
+	// An in-process player cannot be handed to another process.
+	config := &persona.Config{Name: "fable", Voice: &persona.VoiceConfig{Playback: map[string]string{"Stop": persona.PlaybackDetach}}}
+	if err := playHookAudio(context.Background(), config, voice.DefaultConfig(), "Stop", audio); err != nil {
+		t.Fatalf("playHookAudio() error = %v", err)
+	}
+	if len(played) != 1 || played[0] != audio {
+		t.Errorf("played = %v, want the file played in this process", played)
+	}
+	if _, err := os.Stat(audio); !os.IsNotExist(err) {
+		t.Errorf("audio file should be removed after playback, stat error = %v", err)
+	}
+}
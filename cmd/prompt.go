@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/daikw/ccpersona/internal/prompt"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// handlePrompt prints the active persona for the current directory without a
+// trailing newline so it can be embedded directly in PS1 or starship. Errors
+// are logged and produce empty output: a prompt segment must never fail.
+func handlePrompt(ctx context.Context, c *cli.Command) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Debug().Err(err).Msg("prompt: failed to get working directory")
+		return nil
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	homeDir, _ := os.UserHomeDir()
+
+	var cache *prompt.Cache
+	if !c.Bool("no-cache") {
+		if path, err := prompt.DefaultCachePath(); err == nil {
+			cache = &prompt.Cache{Path: path}
+		}
+	}
+
+	active, err := prompt.Resolve(cache, dir, homeDir)
+	if err != nil {
+		log.Debug().Err(err).Msg("prompt: failed to resolve persona")
+		return nil
+	}
+
+	format := c.String("format")
+	if format == "" {
+		format = os.Getenv("CCPERSONA_PROMPT_FORMAT")
+	}
+	fmt.Print(prompt.Format(format, active))
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/notify"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/webpush"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+func handlePushServe(ctx context.Context, c *cli.Command) error {
+	store, err := webpush.DefaultStore()
+	if err != nil {
+		return err
+	}
+	key, err := store.Key()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", c.String("listen"))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	server := &http.Server{
+		Handler:           webpush.Handler(store, key),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	fmt.Printf("Push subscription page on %s (Ctrl-C to stop)\n", cliui.Label("http://localhost:"+port+"/"))
+	fmt.Printf("From another machine: ssh -L %s:localhost:%s <this host>, then open the same URL\n", port, port)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func handlePushTest(ctx context.Context, c *cli.Command) error {
+	text := strings.Join(c.Args().Slice(), " ")
+	if text == "" {
+		text = "Push notifications from ccpersona work."
+	}
+	config, err := persona.LoadConfigWithFallback()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load ccpersona config")
+	}
+	result, err := sendPush(ctx, pushConfig(config), notify.Event{Name: "test", Text: text}, c.String("urgency"), text)
+	if err != nil {
+		return err
+	}
+	for _, err := range result.Errors {
+		fmt.Printf("  %s %v\n", cliui.Failure("✗"), err)
+	}
+	if result.Removed > 0 {
+		fmt.Printf("Removed %d expired subscription(s)\n", result.Removed)
+	}
+	if result.Sent == 0 && len(result.Errors) == 0 {
+		return configError(fmt.Errorf("no browser is subscribed; open the page of: ccpersona runtime push serve"))
+	}
+	fmt.Printf("Sent to %d browser(s)\n", result.Sent)
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%d push(es) failed", len(result.Errors))
+	}
+	return nil
+}
+
+// pushConfig returns notifications.push, or an empty config so that
+// `runtime push test` works before anything is configured.
+func pushConfig(config *persona.Config) *notify.PushConfig {
+	if config != nil && config.Notifications != nil && config.Notifications.Push != nil {
+		return config.Notifications.Push
+	}
+	return &notify.PushConfig{}
+}
+
+// sendPush pushes message to every subscribed browser. Notifications from
+// one session share a tag, so a phone shows only the latest of them.
+func sendPush(ctx context.Context, push *notify.PushConfig, event notify.Event, urgency, message string) (webpush.Result, error) {
+	store, err := webpush.DefaultStore()
+	if err != nil {
+		return webpush.Result{}, err
+	}
+	title := "ccpersona"
+	if event.Project != "" {
+		title = filepath.Base(event.Project)
+	}
+	payload, err := webpush.Message{
+		Title:   fmt.Sprintf("%s: %s", title, event.Name),
+		Body:    message,
+		Urgency: urgency,
+		Tag:     event.SessionID,
+	}.Payload()
+	if err != nil {
+		return webpush.Result{}, err
+	}
+	return store.Broadcast(ctx, nil, payload, webpush.Options{
+		Subject: push.Subject,
+		TTL:     push.TTLDuration(),
+		Urgency: urgency,
+	})
+}
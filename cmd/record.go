@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/corpus"
+	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// unparsedEvent names the directory for payloads no parser accepted.
+const unparsedEvent = "unparsed"
+
+// recordCorpus archives a raw hook payload when recording is on. event is
+// nil when the payload could not be parsed; those are kept too, since they
+// are the ones a format change produces.
+func recordCorpus(data []byte, event *hook.UnifiedHookEvent) {
+	if len(data) == 0 {
+		return
+	}
+	dir, err := corpus.DefaultDir()
+	if err != nil {
+		return
+	}
+	settings, err := corpus.Load(dir)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read corpus recording settings")
+		return
+	}
+	if settings == nil {
+		return
+	}
+	source, eventType := corpus.UnknownSource, unparsedEvent
+	if event != nil {
+		source, eventType = event.Source, event.EventType
+	}
+	if _, err := corpus.Save(dir, settings, source, eventType, data); err != nil {
+		log.Warn().Err(err).Msg("Failed to record hook payload")
+	}
+}
+
+func handleRecordOn(ctx context.Context, c *cli.Command) error {
+	dir, err := corpus.DefaultDir()
+	if err != nil {
+		return err
+	}
+	settings := corpus.Settings{KeepText: c.Bool("keep-text"), MaxPerEvent: int(c.Int("max"))}
+	if settings.MaxPerEvent < 0 {
+		return usageError(fmt.Errorf("--max must be positive, got %d", settings.MaxPerEvent))
+	}
+	if err := corpus.Enable(dir, settings); err != nil {
+		return err
+	}
+	fmt.Printf("%s recording hook payloads to %s\n", cliui.Success("Started"), dir)
+	if settings.KeepText {
+		fmt.Println(cliui.Warn("Prompts, responses, and tool input are kept; only secrets, emails, and your home directory are scrubbed."))
+	} else {
+		fmt.Println(cliui.Muted("Prompts, responses, and tool input are replaced with their length."))
+	}
+	fmt.Println(cliui.Muted("Stop with: ccpersona runtime record off"))
+	return nil
+}
+
+func handleRecordOff(ctx context.Context, c *cli.Command) error {
+	dir, err := corpus.DefaultDir()
+	if err != nil {
+		return err
+	}
+	was, err := corpus.Disable(dir)
+	if err != nil {
+		return err
+	}
+	if !was {
+		fmt.Println(cliui.Muted("Recording was not on"))
+		return nil
+	}
+	fmt.Printf("%s recording %s\n", cliui.Success("Stopped"), cliui.Muted("(the corpus is kept; remove it with: ccpersona runtime record clear)"))
+	return nil
+}
+
+func handleRecordStatus(ctx context.Context, c *cli.Command) error {
+	dir, err := corpus.DefaultDir()
+	if err != nil {
+		return err
+	}
+	settings, err := corpus.Load(dir)
+	if err != nil {
+		return configError(err)
+	}
+	if settings == nil {
+		fmt.Printf("%s %s\n", cliui.Label("Recording:"), "off")
+	} else {
+		mode := "redacted"
+		if settings.KeepText {
+			mode = "text kept"
+		}
+		fmt.Printf("%s on since %s (%s, up to %d per event)\n", cliui.Label("Recording:"),
+			settings.EnabledAt.Local().Format("2006-01-02 15:04"), mode, settings.Limit())
+	}
+	fmt.Printf("%s %s\n", cliui.Label("Corpus:"), dir)
+
+	entries, err := corpus.List(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println(cliui.Muted("No payloads recorded"))
+		return nil
+	}
+	counts := map[[2]string]int{}
+	for _, e := range entries {
+		counts[[2]string{e.Source, e.Event}]++
+	}
+	keys := make([][2]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	fmt.Println()
+	fmt.Printf("%-14s %-24s %s\n", "SOURCE", "EVENT", "PAYLOADS")
+	for _, k := range keys {
+		fmt.Printf("%-14s %-24s %d\n", k[0], k[1], counts[k])
+	}
+	return nil
+}
+
+func handleRecordReplay(ctx context.Context, c *cli.Command) error {
+	dir := c.String("dir")
+	if dir == "" {
+		var err error
+		if dir, err = corpus.DefaultDir(); err != nil {
+			return err
+		}
+	}
+	entries, err := corpus.List(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println(cliui.Muted("No payloads in " + dir))
+		return nil
+	}
+
+	failed := 0
+	for _, e := range entries {
+		if problem := replayEntry(e); problem != "" {
+			failed++
+			fmt.Printf("%s %s: %s\n", cliui.Failure("FAIL"), e.Path, problem)
+		} else if c.Bool("verbose") {
+			fmt.Printf("%s %s\n", cliui.Success("ok"), e.Path)
+		}
+	}
+	summary := fmt.Sprintf("%d payloads replayed, %d failed", len(entries), failed)
+	if failed > 0 {
+		fmt.Println(cliui.Failure(summary))
+		return fmt.Errorf("%d payloads no longer parse as recorded", failed)
+	}
+	fmt.Println(cliui.Success(summary))
+	return nil
+}
+
+// replayEntry parses an archived payload again and describes how the result
+// differs from where it was filed, or returns "" when it matches. Payloads
+// filed as unparsed are expected to fail until support is added.
+func replayEntry(e corpus.Entry) string {
+	data, err := os.ReadFile(e.Path)
+	if err != nil {
+		return err.Error()
+	}
+	event, err := hook.ParseInput(data, e.Source)
+	if e.Source == corpus.UnknownSource && e.Event == unparsedEvent {
+		if err == nil {
+			return fmt.Sprintf("now parses as %s %s; move it to %s/%s", event.Source, event.EventType, event.Source, event.EventType)
+		}
+		return ""
+	}
+	if err != nil {
+		return err.Error()
+	}
+	if event.Source != e.Source || event.EventType != e.Event {
+		return fmt.Sprintf("parsed as %s %s", event.Source, event.EventType)
+	}
+	if event.Schema.Newer() {
+		return fmt.Sprintf("fields not understood: %v", append(event.Schema.UnknownFields, event.Schema.MismatchedFields...))
+	}
+	return ""
+}
+
+func handleRecordClear(ctx context.Context, c *cli.Command) error {
+	dir, err := corpus.DefaultDir()
+	if err != nil {
+		return err
+	}
+	n, err := corpus.Clear(dir)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		fmt.Println(cliui.Muted("No payloads recorded"))
+		return nil
+	}
+	fmt.Println(cliui.Success(fmt.Sprintf("Removed %d recorded payloads", n)))
+	return nil
+}
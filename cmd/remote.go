@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+)
+
+// remoteControl carries out what the tray and the Telegram bot offer, for
+// the project in dir. Like the terminal commands, it reads config and
+// transcripts relative to that directory.
+type remoteControl struct {
+	dir     string
+	home    string
+	manager *persona.Manager
+}
+
+// newRemoteControl changes to dir when it is set and controls the project
+// there.
+func newRemoteControl(dir string) (*remoteControl, error) {
+	if dir != "" {
+		if err := os.Chdir(dir); err != nil {
+			return nil, usageError(fmt.Errorf("--dir: %w", err))
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	manager, err := persona.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	return &remoteControl{dir: cwd, home: home, manager: manager}, nil
+}
+
+// active returns the persona hooks in the directory would use, or nil.
+func (r *remoteControl) active() *persona.ActivePersona {
+	active, err := persona.ResolveActive(r.dir, r.home)
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to resolve persona")
+	}
+	return persona.ApplyEnvToActive(active)
+}
+
+// configDir is the base directory of the config that is shown and edited:
+// the project's when it has one, else the global one.
+func (r *remoteControl) configDir() string {
+	if active, _ := persona.ResolveActive(r.dir, ""); active != nil {
+		return r.dir
+	}
+	return r.home
+}
+
+func (r *remoteControl) setMuted(muted bool, reason string) error {
+	if !muted {
+		return voice.Unmute()
+	}
+	_, err := voice.Mute(reason)
+	return err
+}
+
+// setPersona writes name to the config and syncs the Claude Code agent
+// files, as `config set-persona` does.
+func (r *remoteControl) setPersona(name string) error {
+	if !r.manager.PersonaExists(name) {
+		return &persona.NotExistError{Name: name}
+	}
+	target := r.configDir()
+	err := persona.UpdateConfig(target, func(config *persona.Config) (*persona.Config, error) {
+		if config == nil {
+			config = persona.GetDefaultConfig()
+		}
+		config.Name = name
+		return config, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if _, err := r.manager.SyncAgents(persona.ClaudeAgentsDir(target), name); err != nil {
+		log.Warn().Err(err).Msg("Agent files were not updated")
+	}
+	return nil
+}
+
+// lastMessage returns the speakable text of the project's latest assistant
+// message.
+func (r *remoteControl) lastMessage() (string, error) {
+	reader := voice.NewTranscriptReader(voice.DefaultConfig())
+	transcriptPath, err := reader.FindProjectTranscript(r.dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to find transcript: %w", err)
+	}
+	messages, err := reader.GetRecentAssistantMessages(transcriptPath, 1)
+	if err != nil {
+		return "", fmt.Errorf("failed to read assistant messages: %w", err)
+	}
+	if len(messages) == 0 {
+		return "", errors.New("no assistant message yet")
+	}
+	text := strings.TrimSpace(voice.StripMarkdown(messages[0]))
+	if text == "" {
+		return "", errors.New("the last assistant message has no speakable text")
+	}
+	return text, nil
+}
+
+// replayLast speaks the latest assistant message, like
+// `runtime last --speak`.
+func (r *remoteControl) replayLast(ctx context.Context) error {
+	text, err := r.lastMessage()
+	if err != nil {
+		return err
+	}
+	config, err := persona.LoadConfigWithFallback()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load ccpersona config")
+	}
+	return speakMessage(ctx, config, "", text)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/daikw/ccpersona/internal/voice/provider"
+	"github.com/urfave/cli/v3"
+)
+
+const replHelp = `Type text and press Enter to hear it. Commands:
+  /provider [name]   show or switch provider (default: back to the configured one)
+  /voice [id]        show or set the cloud provider voice
+  /speaker [id]      show or set the VOICEVOX/AivisSpeech speaker
+  /speed [x]         show or set speed (0.25-4.0)
+  /volume [x]        show or set volume (0.0-2.0)
+  /show              show the current settings
+  /reset             drop all changes made in this session
+  /quit              exit (or Ctrl-D)`
+
+// replSession holds the voice settings changed during a REPL session. They
+// are applied on top of the resolved persona voice for every line.
+type replSession struct {
+	config   *persona.Config
+	provider string
+	voice    string
+	speaker  int
+	speed    float64
+	volume   float64
+}
+
+func (s *replSession) options() voice.VoiceOptions {
+	opts := voice.Resolve(s.config.ToVoiceInput(), s.config.ToVoiceConfigFile(), s.provider)
+	if s.voice != "" {
+		opts.Voice = s.voice
+	}
+	if s.speaker > 0 {
+		opts.VoicevoxSpeaker = s.speaker
+		opts.AivisSpeechSpeaker = s.speaker
+	}
+	if s.speed > 0 {
+		opts.Speed = s.speed
+	}
+	if s.volume > 0 {
+		opts.Volume = s.volume
+	}
+	opts.OutputPath = ""
+	opts.PlayAudio = false
+	opts.ToStdout = false
+	return opts
+}
+
+func (s *replSession) describe() string {
+	opts := s.options()
+	name := opts.Provider
+	if name == "" {
+		name = "auto"
+	}
+	speed, volume := opts.Speed, opts.Volume
+	if speed == 0 {
+		speed = 1
+	}
+	if volume == 0 {
+		volume = 1
+	}
+	return fmt.Sprintf("%s, %s, speed %.2f, volume %.2f", name, describeVoice(opts), speed, volume)
+}
+
+// command runs a slash command and returns what to print. quit ends the
+// session.
+func (s *replSession) command(line string) (out string, quit bool, err error) {
+	fields := strings.Fields(line)
+	name, arg := fields[0], ""
+	if len(fields) > 1 {
+		arg = fields[1]
+	}
+
+	switch name {
+	case "/help", "/?":
+		return replHelp, false, nil
+	case "/quit", "/exit", "/q":
+		return "", true, nil
+	case "/show":
+		return s.describe(), false, nil
+	case "/reset":
+		*s = replSession{config: s.config}
+		return s.describe(), false, nil
+	case "/provider":
+		switch {
+		case arg == "":
+		case arg == "default":
+			s.provider = ""
+		case arg == voice.EngineVoicevox || arg == voice.EngineAivisSpeech || slices.Contains(provider.AllProviders, arg):
+			s.provider = arg
+		default:
+			return "", false, fmt.Errorf("unknown provider %q", arg)
+		}
+	case "/voice":
+		if arg != "" {
+			s.voice = arg
+		}
+	case "/speaker":
+		if arg != "" {
+			id, err := strconv.Atoi(arg)
+			if err != nil || id < 0 {
+				return "", false, fmt.Errorf("speaker must be a number")
+			}
+			s.speaker = id
+		}
+	case "/speed":
+		if arg != "" {
+			v, err := strconv.ParseFloat(arg, 64)
+			if err != nil || v < 0.25 || v > 4 {
+				return "", false, fmt.Errorf("speed must be between 0.25 and 4.0")
+			}
+			s.speed = v
+		}
+	case "/volume":
+		if arg != "" {
+			v, err := strconv.ParseFloat(arg, 64)
+			if err != nil || v <= 0 || v > 2 {
+				return "", false, fmt.Errorf("volume must be above 0 and at most 2.0")
+			}
+			s.volume = v
+		}
+	default:
+		return "", false, fmt.Errorf("unknown command %s (try /help)", name)
+	}
+	return s.describe(), false, nil
+}
+
+func handleRepl(ctx context.Context, c *cli.Command) error {
+	session := &replSession{config: loadUnifiedConfig(c, "")}
+	if c.IsSet("provider") {
+		session.provider = c.String("provider")
+	}
+
+	info, err := os.Stdin.Stat()
+	interactive := err == nil && info.Mode()&os.ModeCharDevice != 0
+	if interactive {
+		fmt.Println(cliui.Header("ccpersona repl") + " " + cliui.Muted("type text to hear it, /help for commands, Ctrl-D to exit"))
+		fmt.Println(cliui.Muted(session.describe()))
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		if interactive {
+			fmt.Print(cliui.Label("> "))
+		}
+		if !scanner.Scan() {
+			if interactive {
+				fmt.Println()
+			}
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "/") {
+			out, quit, err := session.command(line)
+			if err != nil {
+				fmt.Println(cliui.Failure(err.Error()))
+				continue
+			}
+			if quit {
+				return nil
+			}
+			fmt.Println(cliui.Muted(out))
+			continue
+		}
+
+		if err := replSpeak(ctx, session, line); err != nil {
+			fmt.Println(cliui.Failure(err.Error()))
+		}
+	}
+}
+
+// replSpeak synthesizes a line and plays it to the end, so lines never
+// overlap.
+func replSpeak(ctx context.Context, session *replSession, text string) error {
+	opts := session.options()
+	manager := voice.NewVoiceManager(opts.ToConfig(session.config.VoiceBaseConfig()))
+	start := time.Now()
+	audioFile, err := manager.Synthesize(ctx, text, opts)
+	if err != nil {
+		return fmt.Errorf("synthesis failed: %w", err)
+	}
+	fmt.Println(cliui.Muted(fmt.Sprintf("(%s)", time.Since(start).Round(10*time.Millisecond))))
+	if err := manager.PlayAudioBlocking(audioFile); err != nil {
+		return fmt.Errorf("playback failed: %w", err)
+	}
+	return nil
+}
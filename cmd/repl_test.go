@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplSessionCommands(t *testing.T) {
+	session := &replSession{config: &persona.Config{Voice: &persona.VoiceConfig{Provider: "openai", Voice: "alloy"}}}
+
+	out, quit, err := session.command("/show")
+	require.NoError(t, err)
+	assert.False(t, quit)
+	assert.Equal(t, "openai, voice alloy, speed 1.00, volume 1.00", out)
+
+	_, _, err = session.command("/voice nova")
+	require.NoError(t, err)
+	out, _, err = session.command("/speed 1.5")
+	require.NoError(t, err)
+	assert.Equal(t, "openai, voice nova, speed 1.50, volume 1.00", out)
+
+	_, _, err = session.command("/provider voicevox")
+	require.NoError(t, err)
+	_, _, err = session.command("/speaker 8")
+	require.NoError(t, err)
+	opts := session.options()
+	assert.Equal(t, "voicevox", opts.Provider)
+	assert.Equal(t, 8, opts.VoicevoxSpeaker)
+	assert.False(t, opts.PlayAudio)
+
+	for _, bad := range []string{"/speed 9", "/volume 0", "/speaker x", "/provider nope", "/dance"} {
+		_, _, err := session.command(bad)
+		assert.Error(t, err, bad)
+	}
+
+	out, _, err = session.command("/reset")
+	require.NoError(t, err)
+	assert.Equal(t, "openai, voice alloy, speed 1.00, volume 1.00", out)
+
+	_, quit, _ = session.command("/quit")
+	assert.True(t, quit)
+}
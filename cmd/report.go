@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/transcripts"
+	"github.com/daikw/ccpersona/internal/usage"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
+)
+
+func reportCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "report",
+		Usage:       "Summarize sessions, personas, spoken minutes, and provider costs",
+		Description: "Reads the local usage ledger (~/.agents/ccpersona/usage.jsonl), which records each\nagent session and spoken message, and prints a Markdown report for the period.\nSet CCPERSONA_USAGE_LEDGER=0 to stop recording.",
+		Action:      handleReport,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "since",
+				Usage: "Period to cover, such as 7d, 2w, or 36h",
+				Value: "7d",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Write the report to this file instead of stdout",
+			},
+		},
+	}
+}
+
+func handleReport(ctx context.Context, c *cli.Command) error {
+	period, err := transcripts.ParseAge(c.String("since"))
+	if err != nil {
+		return usageError(fmt.Errorf("--since: %w", err))
+	}
+	now := time.Now()
+	since := now.Add(-period)
+	entries, err := usage.Load(since)
+	if err != nil {
+		return err
+	}
+	home, _ := os.UserHomeDir()
+	for i := range entries {
+		entries[i].Project = displayProject(home, entries[i].Project)
+	}
+	report := usage.Summarize(entries, since, now, loadUnifiedConfig(c, "").PriceOverrides())
+	markdown := report.Markdown()
+
+	output := c.String("output")
+	if output == "" {
+		fmt.Print(markdown)
+		return nil
+	}
+	if err := fsutil.WriteFile(output, []byte(markdown), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	fmt.Printf("%s %s\n", cliui.Success("Wrote"), output)
+	return nil
+}
+
+// displayProject shortens a project path under the home directory to ~/...
+func displayProject(home, project string) string {
+	if home == "" || project == "" {
+		return project
+	}
+	if rel, err := filepath.Rel(home, project); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Join("~", rel)
+	}
+	return project
+}
+
+// recordSpeech adds a spoken message to the usage ledger, with the playing
+// time of audioFile where its format allows.
+func recordSpeech(config *persona.Config, opts voice.VoiceOptions, text, audioFile string) {
+	name := ""
+	if config != nil {
+		name = config.Name
+	}
+	project, _ := os.Getwd()
+	recordSpeechIn(project, name, opts, text, audioFile)
+}
+
+// recordSpeechIn is recordSpeech for a given project and persona, for the
+// daemon, which speaks for every project.
+func recordSpeechIn(project, personaName string, opts voice.VoiceOptions, text, audioFile string) {
+	if voice.TestMode() {
+		return
+	}
+	entry := usage.Entry{
+		Kind:     usage.KindSpeech,
+		Provider: opts.Provider,
+		Chars:    utf8.RuneCountInString(text),
+		Local:    voice.IsLocalProvider(opts.Provider) || opts.BaseURL != "",
+		Persona:  personaName,
+		Project:  project,
+	}
+	if d, err := voice.AudioDuration(audioFile); err == nil {
+		entry.Seconds = d.Seconds()
+	}
+	usage.Record(entry)
+}
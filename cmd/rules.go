@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/daikw/ccpersona/internal/notify"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
+)
+
+func rulesCommand(hidden bool) *cli.Command {
+	return &cli.Command{
+		Name:   "rules",
+		Usage:  "Inspect notification rules",
+		Hidden: hidden,
+		Commands: []*cli.Command{
+			{
+				Name:        "test",
+				Usage:       "Show which notification rules a message or recorded event would match",
+				Description: "Evaluates notifications.rules, triggers, keyword alerts, and question escalation\nagainst a sample message (or a recorded hook payload with --payload) and prints every\nrule's outcome, the selected channels, urgency, and voice. Nothing is delivered and no\ntrigger runs.",
+				ArgsUsage:   "[message]",
+				Action:      handleRulesTest,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "event",
+						Usage: "Event the message comes from (Notification, Stop, exec, ci, ...)",
+						Value: "Notification",
+					},
+					&cli.StringFlag{
+						Name:  "tool",
+						Usage: "Tool the event concerns, for rules that set tool",
+					},
+					&cli.StringFlag{
+						Name:  "model",
+						Usage: "Model the event comes from, for rules that set model",
+					},
+					&cli.BoolFlag{
+						Name:  "subagent",
+						Usage: "Treat the event as coming from a subagent",
+					},
+					&cli.StringFlag{
+						Name:  "payload",
+						Usage: "Recorded hook payload file to take the event and message from (- for stdin)",
+					},
+					&cli.StringFlag{
+						Name:  "urgency",
+						Usage: "Urgency before rules apply (default: derived from the message like the notify hook)",
+					},
+					&cli.BoolFlag{
+						Name:  "voice",
+						Usage: "Default voice channel when no rule matches, as for runtime notify",
+						Value: true,
+					},
+					&cli.BoolFlag{
+						Name:  "desktop",
+						Usage: "Default desktop channel when no rule matches, as for runtime notify",
+						Value: true,
+					},
+					&cli.StringFlag{
+						Name:  "config",
+						Usage: "Path to ccpersona config file",
+					},
+				},
+			},
+		},
+	}
+}
+
+func handleRulesTest(ctx context.Context, c *cli.Command) error {
+	event, source, err := rulesTestInput(c)
+	if err != nil {
+		return err
+	}
+	message := event.Text
+	config := loadUnifiedConfig(c, source)
+	var rules *notify.Config
+	if config != nil {
+		rules = config.Notifications
+	}
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+
+	urgency := c.String("urgency")
+	if urgency == "" {
+		urgency = "normal"
+		if strings.EqualFold(event.Name, "Notification") {
+			urgency = notificationUrgency(message)
+		}
+	}
+
+	fmt.Println(cliui.Header("Notification rules test"))
+	fmt.Printf("  %-8s %s\n", cliui.Label("config"), describeConfigSource(c))
+	fmt.Printf("  %-8s %s\n", cliui.Label("event"), event.Name)
+	fmt.Printf("  %-8s %q\n", cliui.Label("message"), message)
+	if event.Tool != "" {
+		fmt.Printf("  %-8s %s\n", cliui.Label("tool"), event.Tool)
+	}
+	if event.Model != "" {
+		fmt.Printf("  %-8s %s\n", cliui.Label("model"), event.Model)
+	}
+	if event.Subagent {
+		fmt.Printf("  %-8s %s\n", cliui.Label("subagent"), "yes")
+	}
+
+	fmt.Println()
+	fmt.Println(cliui.Header("Rules"))
+	results := rules.Explain(event)
+	if len(results) == 0 {
+		fmt.Println(cliui.Muted("  (no rules configured)"))
+	}
+	selected := -1
+	for _, r := range results {
+		label := fmt.Sprintf("[%d] %s", r.Index, describeRule(r.Rule))
+		switch {
+		case !r.Matched:
+			fmt.Printf("  %s %s %s\n", cliui.Failure("✗"), label, cliui.Muted("— "+r.Reason))
+		case selected < 0:
+			selected = r.Index
+			fmt.Printf("  %s %s %s\n", cliui.Success("✓"), label, cliui.Success("selected"))
+		default:
+			fmt.Printf("  %s %s %s\n", cliui.Muted("~"), label, cliui.Muted(fmt.Sprintf("— matches, but rule [%d] wins", selected)))
+		}
+	}
+
+	route := routeNotification(c, config, event, urgency)
+	fmt.Println()
+	fmt.Println(cliui.Header("Route"))
+	if route.Rule < 0 {
+		fmt.Printf("  %-9s %s\n", cliui.Label("rule"), cliui.Muted("none matched; using --voice/--desktop defaults"))
+	} else {
+		fmt.Printf("  %-9s [%d]\n", cliui.Label("rule"), route.Rule)
+	}
+	channels := strings.Join(route.Channels, ", ")
+	if channels == "" {
+		channels = cliui.Warn("none (the notification is dropped)")
+	}
+	fmt.Printf("  %-9s %s\n", cliui.Label("channels"), channels)
+	fmt.Printf("  %-9s %s\n", cliui.Label("urgency"), route.Urgency)
+	if route.Has(notify.ChannelVoice) {
+		opts := resolveVoiceFlag(config, "", route.Voice)
+		provider := opts.Provider
+		if provider == "" {
+			provider = "auto"
+		}
+		detail := fmt.Sprintf("%s (%s)", provider, describeVoice(opts))
+		if voice.IsMuted() {
+			detail += " " + cliui.Warn("— muted, would be skipped")
+		}
+		fmt.Printf("  %-9s %s\n", cliui.Label("voice"), detail)
+	}
+
+	if rules.HasTriggers() {
+		fmt.Println()
+		fmt.Println(cliui.Header("Triggers"))
+		matched := rules.MatchTriggers(message)
+		if len(matched) == 0 {
+			fmt.Println(cliui.Muted("  (none match)"))
+		}
+		for _, i := range matched {
+			var actions []string
+			for _, a := range rules.Triggers[i].Actions {
+				actions = append(actions, a.Kind())
+			}
+			fmt.Printf("  %s [%d] would run: %s\n", cliui.Success("✓"), i, strings.Join(actions, ", "))
+		}
+	}
+	if rules != nil && rules.Keywords.IsEnabled() {
+		fmt.Println()
+		fmt.Println(cliui.Header("Keyword alerts"))
+		if found := rules.Keywords.Match(message); len(found) > 0 {
+			route := rules.Keywords.Route()
+			fmt.Printf("  %s would alert on %s: %q\n", cliui.Success("✓"), strings.Join(route.Channels, ", "), notify.KeywordAlert(found))
+		} else {
+			fmt.Println(cliui.Muted("  (no watched keyword)"))
+		}
+	}
+	if rules != nil && rules.Questions.IsEnabled() {
+		fmt.Println()
+		fmt.Println(cliui.Header("Question escalation"))
+		if question, ok := notify.DetectQuestion(message); ok {
+			fmt.Printf("  %s would alert: %q\n", cliui.Success("✓"), question)
+		} else {
+			fmt.Println(cliui.Muted("  (message asks no question)"))
+		}
+	}
+	return nil
+}
+
+// rulesTestInput returns the event and hook source to test, from --payload
+// or from the arguments and --event. --tool, --model, and --subagent override
+// the metadata either way.
+func rulesTestInput(c *cli.Command) (notify.Event, string, error) {
+	event, source, err := rulesTestEvent(c)
+	if err != nil {
+		return notify.Event{}, "", err
+	}
+	if c.IsSet("tool") {
+		event.Tool = c.String("tool")
+	}
+	if c.IsSet("model") {
+		event.Model = c.String("model")
+	}
+	if c.IsSet("subagent") {
+		event.Subagent = c.Bool("subagent")
+	}
+	return event, source, nil
+}
+
+func rulesTestEvent(c *cli.Command) (notify.Event, string, error) {
+	path := c.String("payload")
+	if path == "" {
+		message := strings.Join(c.Args().Slice(), " ")
+		if message == "" {
+			return notify.Event{}, "", fmt.Errorf("message is required (usage: ccpersona config rules test <message> or --payload <file>)")
+		}
+		return notify.Event{Name: c.String("event"), Text: message}, "", nil
+	}
+
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return notify.Event{}, "", err
+		}
+		defer f.Close()
+		in = f
+	}
+	parsed, err := hook.DetectAndParse(in)
+	if err != nil {
+		return notify.Event{}, "", fmt.Errorf("failed to parse payload %s: %w", path, err)
+	}
+	message := parsed.AIResponse
+	if message == "" {
+		if transcript, _ := stopTranscriptPath(parsed); transcript != "" {
+			reader := voice.NewTranscriptReader(voice.DefaultConfig())
+			message, _ = reader.GetLatestAssistantMessage(transcript)
+		}
+	}
+	if args := c.Args().Slice(); len(args) > 0 {
+		message = strings.Join(args, " ")
+	}
+	if message == "" {
+		return notify.Event{}, "", fmt.Errorf("payload %s carries no message; pass one as an argument", path)
+	}
+	return notifyEvent(parsed, message), parsed.Source, nil
+}
+
+// describeRule summarizes a rule's match fields and outcome.
+func describeRule(r notify.Rule) string {
+	var parts []string
+	if r.Event != "" {
+		parts = append(parts, "event="+r.Event)
+	}
+	if r.Contains != "" {
+		parts = append(parts, fmt.Sprintf("contains=%q", r.Contains))
+	}
+	if r.Pattern != "" {
+		parts = append(parts, "pattern=/"+r.Pattern+"/")
+	}
+	if r.Tool != "" {
+		parts = append(parts, "tool="+r.Tool)
+	}
+	if r.Model != "" {
+		parts = append(parts, "model="+r.Model)
+	}
+	if r.Subagent != nil {
+		parts = append(parts, fmt.Sprintf("subagent=%t", *r.Subagent))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "(any)")
+	}
+	out := strings.Join(parts, " ") + " → " + strings.Join(r.Channels, ", ")
+	if r.Urgency != "" {
+		out += " (" + r.Urgency + ")"
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/hub"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+func handleServe(ctx context.Context, c *cli.Command) error {
+	configPath := c.String("config")
+	if configPath == "" {
+		var err error
+		if configPath, err = hub.DefaultConfigPath(); err != nil {
+			return err
+		}
+	}
+	config, err := hub.LoadConfig(configPath)
+	if err != nil {
+		return configError(err)
+	}
+	dataDir := c.String("data")
+	if dataDir == "" {
+		if dataDir, err = hub.DefaultDataDir(); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", c.String("listen"))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	srv := hub.NewServer(config, dataDir)
+	server := &http.Server{
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// Containers are stopped with SIGTERM.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Info().
+		Str("listen", listener.Addr().String()).
+		Str("config", configPath).
+		Int("users", len(config.Users)).
+		Msg("Notification hub started")
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	srv.Wait()
+	return nil
+}
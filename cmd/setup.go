@@ -4,12 +4,17 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/daikw/ccpersona/internal/cliui"
 	"github.com/daikw/ccpersona/internal/engine"
+	"github.com/daikw/ccpersona/internal/hook"
 	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/settings"
 	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
 )
 
@@ -38,6 +43,9 @@ func handleStatusWithDiagnose(ctx context.Context, c *cli.Command, forceDiagnose
 		fmt.Printf("%s %s\n", cliui.Label("Persona:"), cliui.Warn("(not configured)"))
 		warnings++
 	}
+	if name := persona.ProfileOverride(); name != "" {
+		fmt.Printf("%s %s\n", cliui.Label("Profile:"), name)
+	}
 
 	// Check voice engine status
 	voiceConfig := voice.DefaultConfig()
@@ -69,10 +77,20 @@ func handleStatusWithDiagnose(ctx context.Context, c *cli.Command, forceDiagnose
 
 	// Check Claude Code settings
 	homeDir, _ := os.UserHomeDir()
-	settingsPath := filepath.Join(homeDir, ".claude", "settings.json")
-	if _, err := os.Stat(settingsPath); err != nil {
+	settingsPath := settings.UserPath(homeDir)
+	_, settingsStatErr := os.Stat(settingsPath)
+	if settingsStatErr != nil {
 		warnings++
 	}
+	claudeSettings, settingsIssues, settingsErr := claudeSettingsIssues(settingsPath)
+	if settingsErr != nil {
+		issues++
+	}
+	issues += len(settingsIssues)
+
+	// Hook payloads with fields this build does not understand
+	schemaSightings := hookSchemaSightings(homeDir)
+	warnings += len(schemaSightings)
 
 	// Auto-diagnose if there are issues/warnings, or if forced
 	if forceDiagnose || issues > 0 || warnings > 0 {
@@ -133,10 +151,38 @@ func handleStatusWithDiagnose(ctx context.Context, c *cli.Command, forceDiagnose
 		}
 
 		// Claude Code settings
-		if _, err := os.Stat(settingsPath); err == nil {
-			fmt.Printf("  %s %s\n", cliui.Label("Claude Code settings:"), cliui.Success("found"))
-		} else {
+		switch {
+		case settingsErr != nil:
+			fmt.Printf("  %s %s\n", cliui.Label("Claude Code settings:"), cliui.Failure(settingsErr.Error()))
+		case settingsStatErr != nil:
 			fmt.Printf("  %s %s\n", cliui.Label("Claude Code settings:"), cliui.Warn("not found"))
+		case len(settingsIssues) > 0:
+			fmt.Printf("  %s %s\n", cliui.Label("Claude Code settings:"), cliui.Failure(fmt.Sprintf("%d problem(s)", len(settingsIssues))))
+			for _, issue := range settingsIssues {
+				fmt.Printf("      %s\n", issue)
+			}
+		default:
+			fmt.Printf("  %s %s\n", cliui.Label("Claude Code settings:"), cliui.Success("found"))
+		}
+		if claudeSettings != nil {
+			for _, line := range claudeSettings.Describe() {
+				fmt.Printf("      %s\n", cliui.Muted(line))
+			}
+		}
+
+		// Hook payload schemas
+		if len(schemaSightings) == 0 {
+			fmt.Printf("  %s %s\n", cliui.Label("Hook schema:"), cliui.Success("all recent payloads understood"))
+		}
+		for _, s := range schemaSightings {
+			fmt.Printf("  %s %s\n", cliui.Label("Hook schema:"), cliui.Warn(fmt.Sprintf("newer %s payload seen %s (%s)",
+				s.source, s.SeenAt.Local().Format("2006-01-02"), s.Event)))
+			if len(s.UnknownFields) > 0 {
+				fmt.Printf("      %s\n", cliui.Muted("unknown fields: "+strings.Join(s.UnknownFields, ", ")))
+			}
+			if len(s.MismatchedFields) > 0 {
+				fmt.Printf("      %s\n", cliui.Muted("fields with a new type: "+strings.Join(s.MismatchedFields, ", ")))
+			}
 		}
 
 		// Summary and recommendations
@@ -165,12 +211,20 @@ func handleStatusWithDiagnose(ctx context.Context, c *cli.Command, forceDiagnose
 			if projectConfig == nil {
 				fmt.Println("  - run 'ccpersona config init' to initialize project")
 			}
+			if settingsErr != nil || len(settingsIssues) > 0 {
+				fmt.Printf("  - fix the hooks in %s\n", settingsPath)
+			} else if settingsStatErr != nil {
+				fmt.Println("  - run 'ccpersona config integrate claude --global' to install Claude Code hooks")
+			}
 			if manager != nil {
 				personas, _ := manager.ListPersonas()
 				if len(personas) == 0 {
 					fmt.Println("  - run 'ccpersona persona edit <name>' to create a persona")
 				}
 			}
+			if len(schemaSightings) > 0 {
+				fmt.Println("  - update ccpersona: hooks send fields this version ignores (hooks keep working meanwhile)")
+			}
 		} else {
 			fmt.Println("")
 			fmt.Println(cliui.Success("All checks passed."))
@@ -179,3 +233,36 @@ func handleStatusWithDiagnose(ctx context.Context, c *cli.Command, forceDiagnose
 
 	return nil
 }
+
+// hookSchemaWindow is how long a newer hook payload is reported after it
+// was last seen.
+const hookSchemaWindow = 30 * 24 * time.Hour
+
+type hookSchemaSighting struct {
+	source string
+	hook.SchemaSighting
+}
+
+// hookSchemaSightings returns the recent hook payloads with fields this
+// build still does not understand, by source.
+func hookSchemaSightings(homeDir string) []hookSchemaSighting {
+	if homeDir == "" {
+		return nil
+	}
+	sightings, err := hook.LoadSchemaSightings(hook.SchemaLogPath(homeDir))
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to read hook schema sightings")
+		return nil
+	}
+	var out []hookSchemaSighting
+	for source, s := range sightings {
+		if time.Since(s.SeenAt) > hookSchemaWindow {
+			continue
+		}
+		if s, ok := s.Outstanding(); ok {
+			out = append(out, hookSchemaSighting{source: source, SchemaSighting: s})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].source < out[j].source })
+	return out
+}
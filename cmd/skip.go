@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// skipHotkeyID names the tray's shortcut to the desktop portal.
+const skipHotkeyID = "skip-speech"
+
+func handleSkip(ctx context.Context, c *cli.Command) error {
+	if err := voice.Skip(); err != nil {
+		return err
+	}
+	if !c.Bool("quiet") {
+		fmt.Println(cliui.Success("Skipped") + " the current message")
+	}
+	return nil
+}
+
+// watchSkipSignal skips speech whenever the process receives SIGUSR1, so a
+// window manager or script can reach a running tray with
+// `pkill -USR1 -f "ccpersona runtime tray"`. It does nothing on Windows.
+func watchSkipSignal(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	if !notifySkipSignal(signals) {
+		return
+	}
+	go func() {
+		defer stopSkipSignal(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				if err := voice.Skip(); err != nil {
+					log.Warn().Err(err).Msg("Failed to skip speech")
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// Windows has no SIGUSR1; use `ccpersona runtime skip` or the tray menu.
+func notifySkipSignal(c chan<- os.Signal) bool { return false }
+
+func stopSkipSignal(c chan<- os.Signal) {}
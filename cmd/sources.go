@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/urfave/cli/v3"
+)
+
+func sourcesCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "sources",
+		Usage:       "List user-defined hook event sources",
+		Description: "Source definitions in ~/.agents/ccpersona/sources/ (or $CCPERSONA_SOURCES_DIR) teach\nccpersona the hook payloads of other agent tools: a detect predicate plus a mapping of\nfield paths to unified event fields. Invalid definitions are listed with the reason.",
+		Action:      handleSourcesList,
+		Commands: []*cli.Command{
+			{
+				Name:      "test",
+				Usage:     "Show how a hook payload is detected and mapped",
+				ArgsUsage: "<payload.json|->",
+				Action:    handleSourcesTest,
+			},
+		},
+	}
+}
+
+func handleSourcesList(ctx context.Context, c *cli.Command) error {
+	dir, err := hook.SourcesDir()
+	if err != nil {
+		return err
+	}
+	defs, errs := hook.LoadSources(dir)
+	fmt.Printf("%s %s\n", cliui.Label("Sources directory:"), dir)
+	if len(defs) == 0 && len(errs) == 0 {
+		fmt.Println(cliui.Muted("  (no source definitions)"))
+	}
+	for _, def := range defs {
+		fmt.Printf("  %s %s %s\n", cliui.Success("✓"), def.Name, cliui.Muted("("+def.Path+")"))
+	}
+	for _, err := range errs {
+		fmt.Printf("  %s %v\n", cliui.Failure("✗"), err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d invalid source definition(s)", len(errs))
+	}
+	return nil
+}
+
+func handleSourcesTest(ctx context.Context, c *cli.Command) error {
+	path := c.Args().Get(0)
+	if path == "" {
+		return fmt.Errorf("payload is required (usage: ccpersona config sources test <payload.json|->)")
+	}
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	event, err := hook.DetectAndParse(in)
+	if err != nil {
+		return err
+	}
+	source := event.Source
+	if raw, ok := event.RawEvent.(*hook.PluginEvent); ok {
+		source += " " + cliui.Muted("("+raw.Definition+")")
+	} else {
+		source += " " + cliui.Muted("(built-in)")
+	}
+	transcript, _ := stopTranscriptPath(event)
+	rows := []struct{ label, value string }{
+		{"source", source},
+		{"event", event.EventType},
+		{"session", event.SessionID},
+		{"cwd", event.CWD},
+		{"roots", strings.Join(event.WorkspaceRoots, ", ")},
+		{"file", event.FilePath},
+		{"input", strings.Join(event.UserInput, " | ")},
+		{"response", event.AIResponse},
+		{"transcript", transcript},
+	}
+	for _, row := range rows {
+		if row.value == "" {
+			continue
+		}
+		fmt.Printf("  %-11s %s\n", cliui.Label(row.label), row.value)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// maxSpeakInput caps how much stdin or clipboard text is read at all; long
+// input is cut to --max-chars before synthesis anyway.
+const maxSpeakInput = 1 << 20
+
+func handleSpeak(ctx context.Context, c *cli.Command) error {
+	maxChars := int(c.Int("max-chars"))
+	if maxChars <= 0 {
+		return usageError(fmt.Errorf("--max-chars must be positive"))
+	}
+	config := loadUnifiedConfig(c, "")
+
+	if c.Bool("watch-clipboard") {
+		interval := c.Duration("interval")
+		if interval <= 0 {
+			return usageError(fmt.Errorf("--interval must be positive"))
+		}
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		return watchClipboard(ctx, config, interval, maxChars)
+	}
+
+	var text string
+	switch {
+	case c.Bool("clipboard"):
+		var err error
+		if text, err = readClipboard(ctx, runtime.GOOS); err != nil {
+			return err
+		}
+	case c.Args().Len() > 0:
+		text = strings.Join(c.Args().Slice(), " ")
+	default:
+		data, err := io.ReadAll(io.LimitReader(os.Stdin, maxSpeakInput))
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		text = string(data)
+	}
+
+	text, truncated := speakableText(text, maxChars)
+	if text == "" {
+		return fmt.Errorf("nothing to speak")
+	}
+	if truncated {
+		fmt.Fprintln(os.Stderr, cliui.Warn(fmt.Sprintf("Text is longer than %d characters; speaking the beginning only", maxChars)))
+	}
+	if !c.Bool("force") && voice.IsMuted() {
+		log.Debug().Msg("voice synthesis is globally muted, skipping")
+		return nil
+	}
+	return speakMessage(ctx, config, "", text)
+}
+
+// speakableText strips markdown and cuts text to max runes, preferring to
+// stop at the last sentence end in the second half of the allowance.
+func speakableText(text string, max int) (string, bool) {
+	text = strings.TrimSpace(voice.StripMarkdown(text))
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text, false
+	}
+	runes = runes[:max]
+	for i := len(runes) - 1; i >= max/2; i-- {
+		if strings.ContainsRune("。！？.!?", runes[i]) {
+			runes = runes[:i+1]
+			break
+		}
+	}
+	return strings.TrimSpace(string(runes)), true
+}
+
+// clipboardWatcher reports clipboard content that changed since the
+// previous poll. The content present when watching starts is not reported.
+type clipboardWatcher struct {
+	last    [sha256.Size]byte
+	started bool
+}
+
+func (w *clipboardWatcher) changed(content string) bool {
+	sum := sha256.Sum256([]byte(content))
+	if w.started && sum == w.last {
+		return false
+	}
+	first := !w.started
+	w.last, w.started = sum, true
+	return !first && strings.TrimSpace(content) != ""
+}
+
+// watchClipboard polls the clipboard and speaks each new copy until ctx is
+// done. Polling pauses while speaking, so copies made meanwhile collapse
+// into the latest one.
+func watchClipboard(ctx context.Context, config *persona.Config, interval time.Duration, maxChars int) error {
+	if _, err := pasteCommand(ctx, runtime.GOOS); err != nil {
+		return err
+	}
+	fmt.Println(cliui.Header("Watching clipboard") + " " + cliui.Muted("copy text to hear it, Ctrl-C to stop"))
+
+	var watcher clipboardWatcher
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		content, err := readClipboard(ctx, runtime.GOOS)
+		if err != nil {
+			// Non-text content such as images fails to read; keep watching.
+			log.Debug().Err(err).Msg("Failed to read clipboard")
+		} else if watcher.changed(content) {
+			if voice.IsMuted() {
+				log.Debug().Msg("voice synthesis is globally muted, skipping clipboard")
+			} else if text, _ := speakableText(content, maxChars); text != "" {
+				if err := speakMessage(ctx, config, "", text); err != nil && ctx.Err() == nil {
+					fmt.Fprintln(os.Stderr, cliui.Failure(err.Error()))
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
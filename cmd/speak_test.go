@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSpeakableText(t *testing.T) {
+	got, truncated := speakableText("  Build passed.\n", 100)
+	if got != "Build passed." || truncated {
+		t.Errorf("speakableText = %q, %v", got, truncated)
+	}
+
+	long := "First sentence here. Second sentence follows and runs on"
+	got, truncated = speakableText(long, 30)
+	if got != "First sentence here." || !truncated {
+		t.Errorf("speakableText(long) = %q, %v; want cut at the sentence end", got, truncated)
+	}
+
+	// Without a sentence end in the second half, cut at the limit.
+	got, _ = speakableText(strings.Repeat("あ", 50), 10)
+	if got != strings.Repeat("あ", 10) {
+		t.Errorf("speakableText(no sentence end) = %q", got)
+	}
+}
+
+func TestClipboardWatcher(t *testing.T) {
+	var w clipboardWatcher
+	steps := []struct {
+		content string
+		want    bool
+	}{
+		{"already there", false},
+		{"already there", false},
+		{"new copy", true},
+		{"new copy", false},
+		{"   ", false},
+		{"new copy", true},
+	}
+	for i, s := range steps {
+		if got := w.changed(s.content); got != s.want {
+			t.Errorf("step %d: changed(%q) = %v, want %v", i, s.content, got, s.want)
+		}
+	}
+}
+
+func TestPasteCommand(t *testing.T) {
+	for goos, want := range map[string]string{
+		"darwin":  "pbpaste",
+		"windows": "powershell -NoProfile -Command Get-Clipboard -Raw",
+	} {
+		cmd, err := pasteCommand(context.Background(), goos)
+		if err != nil {
+			t.Fatalf("pasteCommand(%s): %v", goos, err)
+		}
+		if got := strings.Join(cmd.Args, " "); got != want {
+			t.Errorf("pasteCommand(%s) = %q, want %q", goos, got, want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/analytics"
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/transcripts"
+	"github.com/daikw/ccpersona/internal/usage"
+	"github.com/urfave/cli/v3"
+)
+
+func statsCommand(hidden bool) *cli.Command {
+	return &cli.Command{
+		Name:        "stats",
+		Usage:       "Show opt-in local feature usage statistics",
+		Description: "Counts of used commands, TTS providers, and hook event sources are kept in\n~/.agents/ccpersona/analytics.json only after 'stats enable'. They never leave this\nmachine; share the --features report yourself if you want to.",
+		Action:      handleStats,
+		Hidden:      hidden,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "features",
+				Usage: "Show the feature usage report",
+			},
+			&cli.BoolFlag{
+				Name:  "by-persona",
+				Usage: "Compare personas by the feedback recorded with 'persona feedback'",
+			},
+			&cli.StringFlag{
+				Name:  "since",
+				Usage: "Period --by-persona covers, such as 30d, 2w, or 36h",
+				Value: "30d",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the report as JSON",
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:   "enable",
+				Usage:  "Start counting feature usage locally",
+				Action: handleStatsEnable,
+			},
+			{
+				Name:   "disable",
+				Usage:  "Stop counting and delete all recorded usage",
+				Action: handleStatsDisable,
+			},
+		},
+	}
+}
+
+func handleStats(ctx context.Context, c *cli.Command) error {
+	if c.Bool("by-persona") {
+		return handleStatsByPersona(c)
+	}
+	data, err := analytics.Load()
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		fmt.Println("Local usage statistics are off. Enable them with: ccpersona config stats enable")
+		return nil
+	}
+	if c.Bool("json") {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	}
+
+	fmt.Printf("%s since %s %s\n", cliui.Success("Recording locally"), data.EnabledAt.Local().Format("2006-01-02"),
+		cliui.Muted("(nothing leaves this machine)"))
+	if os.Getenv("DO_NOT_TRACK") == "1" {
+		fmt.Println(cliui.Warn("DO_NOT_TRACK=1 is set; nothing new is being recorded"))
+	}
+	if !c.Bool("features") {
+		fmt.Println("Show the report with: ccpersona config stats --features")
+		return nil
+	}
+
+	for _, kind := range analytics.Kinds {
+		fmt.Println()
+		fmt.Println(cliui.Header(strings.ToUpper(kind[:1]) + kind[1:] + "s"))
+		top := data.Top(kind)
+		if len(top) == 0 {
+			fmt.Println(cliui.Muted("  none recorded"))
+			continue
+		}
+		for _, count := range top {
+			fmt.Printf("  %6d  %s\n", count.Count, count.Name)
+		}
+	}
+	return nil
+}
+
+// handleStatsByPersona compares personas by the satisfaction recorded in
+// the usage ledger, which unlike the feature counts is always on.
+func handleStatsByPersona(c *cli.Command) error {
+	period, err := transcripts.ParseAge(c.String("since"))
+	if err != nil {
+		return usageError(fmt.Errorf("--since: %w", err))
+	}
+	since := time.Now().Add(-period)
+	entries, err := usage.Load(since)
+	if err != nil {
+		return err
+	}
+	feedback := usage.FeedbackByPersona(entries)
+	if c.Bool("json") {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(feedback)
+	}
+
+	fmt.Println(cliui.Header("Persona feedback since " + since.Local().Format("2006-01-02")))
+	if len(feedback) == 0 {
+		fmt.Println(cliui.Muted("  no sessions recorded"))
+		return nil
+	}
+	rated := false
+	fmt.Printf("  %-20s %8s %4s %4s %10s\n", "PERSONA", "SESSIONS", "UP", "DOWN", "SATISFIED")
+	for _, p := range feedback {
+		satisfied := "-"
+		if share, ok := p.Satisfaction(); ok {
+			satisfied = fmt.Sprintf("%.0f%%", share*100)
+			rated = true
+		}
+		fmt.Printf("  %-20s %8d %4d %4d %10s\n", p.Name, p.Sessions, p.Up, p.Down, satisfied)
+		for _, comment := range p.Comments {
+			fmt.Printf("    %s %s %s\n", usage.RatingSymbol(comment.Rating), comment.Text,
+				cliui.Muted(comment.Time.Local().Format("2006-01-02")))
+		}
+	}
+	if !rated {
+		fmt.Println(cliui.Muted("\nRate a session with: ccpersona persona feedback 👍|👎 [comment]"))
+	}
+	return nil
+}
+
+func handleStatsEnable(ctx context.Context, c *cli.Command) error {
+	if _, err := analytics.Enable(); err != nil {
+		return err
+	}
+	path, _ := analytics.Path()
+	fmt.Printf("%s local usage statistics %s\n", cliui.Success("Enabled"), cliui.Muted("("+path+")"))
+	fmt.Println("Only feature names and counts are stored, never prompts, text, or paths.")
+	return nil
+}
+
+func handleStatsDisable(ctx context.Context, c *cli.Command) error {
+	was, err := analytics.Disable()
+	if err != nil {
+		return err
+	}
+	if !was {
+		fmt.Println("Local usage statistics were already off.")
+		return nil
+	}
+	fmt.Printf("%s local usage statistics and deleted recorded data\n", cliui.Success("Disabled"))
+	return nil
+}
+
+// recordHookSource counts a hook event, e.g. "cursor stop", for the feature
+// report.
+func recordHookSource(source, eventType string) {
+	analytics.Record(analytics.KindSource, source+" "+eventType)
+}
+
+// commandName returns the space-separated path of the subcommand being run,
+// e.g. "runtime voice", derived from the root command's remaining arguments.
+// Only names from the command tree are returned, never user arguments.
+func commandName(root *cli.Command) string {
+	var names []string
+	cmd := root
+	for _, arg := range root.Args().Slice() {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		sub := cmd.Command(arg)
+		if sub == nil {
+			break
+		}
+		names = append(names, sub.Name)
+		cmd = sub
+	}
+	return strings.Join(names, " ")
+}
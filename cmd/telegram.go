@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/notify"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/telegram"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// telegramPollWait is how long each getUpdates call waits for a message.
+const telegramPollWait = 50 * time.Second
+
+const telegramHelp = `Commands:
+/status - persona and mute state
+/mute - mute voice
+/unmute - unmute voice
+/persona - list personas
+/persona <name> - switch persona
+/last - the latest assistant message`
+
+// telegramConfig returns notifications.telegram, which only the global
+// config sets, or a config error naming what is missing.
+func telegramConfig() (*notify.TelegramConfig, error) {
+	config, err := persona.LoadConfigWithFallback()
+	if err != nil {
+		return nil, configError(err)
+	}
+	if config == nil || config.Notifications == nil || config.Notifications.Telegram == nil {
+		return nil, configError(errors.New("notifications.telegram is not configured"))
+	}
+	tg := config.Notifications.Telegram
+	if tg.BotToken() == "" {
+		return nil, configError(errors.New("notifications.telegram has no bot token; set token or the variable named by token_env"))
+	}
+	return tg, nil
+}
+
+// sendTelegram sends a notification to the configured chat.
+func sendTelegram(ctx context.Context, tg *notify.TelegramConfig, event notify.Event, urgency string) error {
+	token := tg.BotToken()
+	if token == "" {
+		return errors.New("no bot token")
+	}
+	return telegram.NewBot(token).SendMessage(ctx, tg.ChatID, notify.TelegramText(event, urgency))
+}
+
+func handleTelegramTest(ctx context.Context, c *cli.Command) error {
+	tg, err := telegramConfig()
+	if err != nil {
+		return err
+	}
+	text := strings.Join(c.Args().Slice(), " ")
+	if text == "" {
+		text = "Telegram notifications from ccpersona work."
+	}
+	if err := sendTelegram(ctx, tg, notify.Event{Name: "test", Text: text}, ""); err != nil {
+		return err
+	}
+	fmt.Printf("%s Sent to chat %d\n", cliui.Success("✓"), tg.ChatID)
+	return nil
+}
+
+func handleTelegramServe(ctx context.Context, c *cli.Command) error {
+	remote, err := newRemoteControl(c.String("dir"))
+	if err != nil {
+		return err
+	}
+	tg, err := telegramConfig()
+	if err != nil {
+		return err
+	}
+	bot := telegram.NewBot(tg.BotToken())
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	// Skip commands sent while nothing was listening: a /mute from hours
+	// ago should not apply now.
+	offset := int64(0)
+	pending, err := bot.Updates(ctx, -1, 0)
+	if err != nil {
+		return err
+	}
+	for _, update := range pending {
+		offset = update.ID + 1
+	}
+
+	fmt.Printf("Listening for commands from chat %d (Ctrl-C to stop)\n", tg.ChatID)
+	for ctx.Err() == nil {
+		updates, err := bot.Updates(ctx, offset, telegramPollWait)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Warn().Err(err).Msg("Failed to poll Telegram")
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		for _, update := range updates {
+			offset = update.ID + 1
+			msg := update.Message
+			if msg == nil {
+				continue
+			}
+			if msg.Chat.ID != tg.ChatID {
+				log.Warn().Int64("chat_id", msg.Chat.ID).Msg("Ignoring message from a chat other than notifications.telegram.chat_id")
+				continue
+			}
+			cmd, ok := telegram.ParseCommand(msg.Text)
+			if !ok {
+				continue
+			}
+			reply := remote.telegramCommand(ctx, cmd)
+			if err := bot.SendMessage(ctx, msg.Chat.ID, reply); err != nil {
+				log.Warn().Err(err).Msg("Failed to reply on Telegram")
+			}
+		}
+	}
+	return nil
+}
+
+// telegramCommand applies a bot command and returns the reply.
+func (r *remoteControl) telegramCommand(ctx context.Context, cmd telegram.Command) string {
+	log.Info().Str("command", cmd.Name).Str("args", cmd.Args).Msg("Telegram command")
+	switch cmd.Name {
+	case "status":
+		return r.telegramStatus()
+	case "mute", "unmute":
+		muted := cmd.Name == "mute"
+		if err := r.setMuted(muted, "telegram"); err != nil {
+			return fmt.Sprintf("Failed to %s: %v", cmd.Name, err)
+		}
+		return r.telegramStatus()
+	case "persona":
+		if cmd.Args == "" {
+			names, err := r.manager.ListPersonas()
+			if err != nil {
+				return fmt.Sprintf("Failed to list personas: %v", err)
+			}
+			return fmt.Sprintf("%s\nAvailable: %s", r.telegramStatus(), strings.Join(names, ", "))
+		}
+		if err := r.setPersona(cmd.Args); err != nil {
+			return fmt.Sprintf("Failed to switch persona: %v", err)
+		}
+		return r.telegramStatus()
+	case "last":
+		text, err := r.lastMessage()
+		if err != nil {
+			return fmt.Sprintf("No message: %v", err)
+		}
+		return text
+	case "start", "help":
+		return telegramHelp
+	default:
+		return "Unknown command /" + cmd.Name + "\n\n" + telegramHelp
+	}
+}
+
+func (r *remoteControl) telegramStatus() string {
+	name := "no persona configured"
+	if active := r.active(); active != nil {
+		name = fmt.Sprintf("persona %s (%s)", active.Name, active.Scope)
+	}
+	state := "voice on"
+	if voice.IsMuted() {
+		state = "voice muted"
+	}
+	return fmt.Sprintf("%s, %s", name, state)
+}
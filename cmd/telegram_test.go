@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/telegram"
+	"github.com/daikw/ccpersona/internal/voice"
+)
+
+func TestTelegramCommand(t *testing.T) {
+	home, project := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(voice.EnvMute, "")
+	t.Setenv("CCPERSONA_PERSONA", "")
+	personas := filepath.Join(home, ".agents", "ccpersona", "personas")
+	if err := os.MkdirAll(personas, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"fable", "zundamon"} {
+		if err := os.WriteFile(filepath.Join(personas, name+".md"), []byte("# "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manager, err := persona.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := &remoteControl{dir: project, home: home, manager: manager}
+	run := func(text string) string {
+		cmd, ok := telegram.ParseCommand(text)
+		if !ok {
+			t.Fatalf("not a command: %q", text)
+		}
+		return remote.telegramCommand(context.Background(), cmd)
+	}
+
+	if got := run("/status"); got != "no persona configured, voice on" {
+		t.Errorf("/status = %q", got)
+	}
+	if got := run("/persona zundamon"); got != "persona zundamon (global), voice on" {
+		t.Errorf("/persona zundamon = %q", got)
+	}
+	if config, _ := persona.LoadConfig(home); config == nil || config.Name != "zundamon" {
+		t.Errorf("global config = %+v", config)
+	}
+	if got := run("/persona ../etc"); !strings.HasPrefix(got, "Failed to switch persona") {
+		t.Errorf("/persona ../etc = %q", got)
+	}
+	if got := run("/persona"); !strings.Contains(got, "Available: fable, zundamon") {
+		t.Errorf("/persona = %q", got)
+	}
+	if got := run("/mute"); !strings.HasSuffix(got, "voice muted") || !voice.IsMuted() {
+		t.Errorf("/mute = %q", got)
+	}
+	if got := run("/unmute"); !strings.HasSuffix(got, "voice on") {
+		t.Errorf("/unmute = %q", got)
+	}
+	if got := run("/reboot"); !strings.HasPrefix(got, "Unknown command /reboot") {
+		t.Errorf("/reboot = %q", got)
+	}
+}
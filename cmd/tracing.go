@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/daikw/ccpersona/internal/tracing"
+	"github.com/rs/zerolog/log"
+)
+
+// traceFlushTimeout bounds the export at the end of a hook, so an
+// unreachable collector delays the agent by at most this long.
+const traceFlushTimeout = 2 * time.Second
+
+// startTrace opens the root span of this process when trace context comes
+// from traceparent or TRACEPARENT and an OTLP endpoint is configured. The
+// returned function ends the span and exports the trace; without tracing
+// both are no-ops.
+func startTrace(ctx context.Context, traceparent, name string) (context.Context, *tracing.Span, func()) {
+	tracer, err := tracing.New(traceparent)
+	if err != nil {
+		log.Debug().Err(err).Msg("Tracing disabled")
+	}
+	ctx, span := tracer.Start(ctx, name)
+	return ctx, span, func() {
+		span.End()
+		flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), traceFlushTimeout)
+		defer cancel()
+		if err := tracer.Flush(flushCtx); err != nil {
+			log.Debug().Err(err).Msg("Failed to export trace")
+		}
+	}
+}
+
+// startHookTrace opens the root span for processing event in command.
+func startHookTrace(ctx context.Context, command string, event *hook.UnifiedHookEvent) (context.Context, func()) {
+	ctx, span, finish := startTrace(ctx, event.TraceParent, "ccpersona "+command+" "+event.EventType)
+	span.Set("ccpersona.source", event.Source)
+	span.Set("ccpersona.event", event.EventType)
+	span.Set("session.id", event.SessionID)
+	return ctx, finish
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/detach"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/transcripts"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+func handleTranscriptsPrune(ctx context.Context, c *cli.Command) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	if c.Bool("auto") {
+		return autoPruneTranscripts(homeDir, time.Now())
+	}
+
+	age, err := transcripts.ParseAge(c.String("older-than"))
+	if err != nil {
+		return err
+	}
+	dir := transcripts.ProjectsDir(homeDir)
+	files, err := transcripts.Find(dir, time.Now().Add(-age))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Println(cliui.Muted(fmt.Sprintf("No transcripts older than %s in %s", c.String("older-than"), dir)))
+		return nil
+	}
+
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	archive := c.String("archive")
+	if c.Bool("dry-run") {
+		for _, f := range files {
+			fmt.Printf("%s  %8s  %s\n", f.ModTime.Format("2006-01-02"), formatBytes(f.Size), f.Path)
+		}
+		action := "remove"
+		if archive != "" {
+			action = "archive to " + archive
+		}
+		fmt.Println(cliui.Muted(fmt.Sprintf("Would %s %d transcripts (%s)", action, len(files), formatBytes(total))))
+		return nil
+	}
+
+	result, err := transcripts.Prune(dir, files, archive)
+	verb := "Removed"
+	if archive != "" {
+		verb = "Archived"
+	}
+	if result.Files > 0 || err == nil {
+		fmt.Println(cliui.Success(fmt.Sprintf("%s %d transcripts (%s)", verb, result.Files, formatBytes(result.Bytes))))
+	}
+	return err
+}
+
+// autoPruneTranscripts applies the transcripts policy of the global config,
+// at most once per transcripts.AutoInterval.
+func autoPruneTranscripts(homeDir string, now time.Time) error {
+	policy := transcriptsPolicy(homeDir)
+	stamp := transcripts.StampPath(homeDir)
+	if !policy.AutoPrune() || !transcripts.AutoDue(stamp, now) {
+		return nil
+	}
+	// Stamp first so a failing run is not retried by every session.
+	if err := transcripts.MarkAutoRun(stamp, now); err != nil {
+		return err
+	}
+	age, err := transcripts.ParseAge(policy.PruneOlderThan)
+	if err != nil {
+		return err
+	}
+	dir := transcripts.ProjectsDir(homeDir)
+	files, err := transcripts.Find(dir, now.Add(-age))
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	result, err := transcripts.Prune(dir, files, policy.Archive)
+	log.Info().Int("files", result.Files).Int64("bytes", result.Bytes).Str("archive", policy.Archive).Msg("Pruned old transcripts")
+	return err
+}
+
+// transcriptsPolicy reads the transcripts settings from the global config.
+func transcriptsPolicy(homeDir string) *persona.TranscriptsConfig {
+	config, err := persona.LoadConfigFromPath(persona.ConfigPath(homeDir))
+	if err != nil || config == nil {
+		return nil
+	}
+	return config.Transcripts
+}
+
+// startTranscriptPrune runs the automatic prune in a detached process when
+// it is configured and due, so the session end hook returns at once.
+func startTranscriptPrune() {
+	homeDir, err := os.UserHomeDir()
+	if err != nil || !transcriptsPolicy(homeDir).AutoPrune() || !transcripts.AutoDue(transcripts.StampPath(homeDir), time.Now()) {
+		return
+	}
+	self, err := os.Executable()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to locate ccpersona for transcript pruning")
+		return
+	}
+	if err := detach.Start(exec.Command(self, "runtime", "transcripts", "prune", "--auto")); err != nil {
+		log.Warn().Err(err).Msg("Failed to start transcript pruning")
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/transcripts"
+)
+
+func TestAutoPruneTranscripts(t *testing.T) {
+	home := t.TempDir()
+	old := filepath.Join(transcripts.ProjectsDir(home), "-proj", "old.jsonl")
+	if err := os.MkdirAll(filepath.Dir(old), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(old, []byte("{}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-60 * 24 * time.Hour)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	// Without a policy nothing happens, not even the stamp.
+	if err := autoPruneTranscripts(home, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(transcripts.StampPath(home)); !os.IsNotExist(err) {
+		t.Fatal("no stamp should be written without a policy")
+	}
+
+	config := &persona.Config{Name: "default", Transcripts: &persona.TranscriptsConfig{PruneOlderThan: "30d", Archive: filepath.Join(home, "archive")}}
+	if err := persona.SaveConfig(home, config); err != nil {
+		t.Fatal(err)
+	}
+	if err := autoPruneTranscripts(home, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(home, "archive", "-proj", "old.jsonl")); err != nil {
+		t.Errorf("old transcript should be archived: %v", err)
+	}
+	if transcripts.AutoDue(transcripts.StampPath(home), now) {
+		t.Error("the run should be stamped")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 30: "5.0 GiB"}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/tray"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+func handleTray(ctx context.Context, c *cli.Command) error {
+	remote, err := newRemoteControl(c.String("dir"))
+	if err != nil {
+		return err
+	}
+
+	watchSkipSignal(ctx)
+	if trigger := c.String("skip-hotkey"); trigger != "" {
+		err := tray.BindHotkey(ctx, skipHotkeyID, "Skip the message ccpersona is reading", trigger, func() {
+			if err := voice.Skip(); err != nil {
+				log.Warn().Err(err).Msg("Failed to skip speech")
+			}
+		})
+		if err != nil {
+			log.Warn().Err(err).Msg("Cannot register the skip hotkey; bind `ccpersona runtime skip` in the desktop's keyboard settings instead")
+		}
+	}
+
+	err = tray.Run(ctx, tray.Actions{
+		State: func() tray.State {
+			state := tray.State{Muted: voice.IsMuted()}
+			if active := remote.active(); active != nil {
+				state.Persona, state.Scope = active.Name, active.Scope
+			}
+			return state
+		},
+		SetMuted: func(muted bool) error {
+			return remote.setMuted(muted, "tray")
+		},
+		Personas:   remote.manager.ListPersonas,
+		SetPersona: remote.setPersona,
+		ReplayLast: func() error {
+			return remote.replayLast(ctx)
+		},
+		Skip: voice.Skip,
+		OpenConfig: func() error {
+			path := persona.ConfigPath(remote.configDir())
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("no config at %s; create one with: ccpersona config init", path)
+			}
+			return openPath(ctx, runtime.GOOS, path)
+		},
+		Report: func(action string, err error) {
+			log.Warn().Err(err).Str("action", action).Msg("Tray action failed")
+			if err := showDesktopNotification(fmt.Sprintf("%s failed: %v", action, err), "normal"); err != nil {
+				log.Debug().Err(err).Msg("Failed to show tray error")
+			}
+		},
+	})
+	if errors.Is(err, tray.ErrUnsupported) && runtime.GOOS == "darwin" {
+		return fmt.Errorf("%w; build ccpersona with CGO_ENABLED=1 for the menu bar", err)
+	}
+	return err
+}
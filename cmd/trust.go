@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/trust"
+	"github.com/urfave/cli/v3"
+)
+
+func trustCommand(hidden bool) *cli.Command {
+	return &cli.Command{
+		Name:   "trust",
+		Usage:  "Manage minisign public keys trusted to sign persona bundles",
+		Hidden: hidden,
+		Commands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     "Trust a minisign public key (a .pub file or the base64 key)",
+				ArgsUsage: "<key|file>",
+				Action:    handleTrustAdd,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "comment",
+						Usage: "Label for the key, e.g. the organization that signs with it",
+					},
+				},
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"ls"},
+				Usage:   "List trusted keys",
+				Action:  handleTrustList,
+			},
+			{
+				Name:      "remove",
+				Aliases:   []string{"rm"},
+				Usage:     "Stop trusting a key",
+				ArgsUsage: "<key-id>",
+				Action:    handleTrustRemove,
+			},
+		},
+	}
+}
+
+func handleTrustAdd(ctx context.Context, c *cli.Command) error {
+	arg := c.Args().Get(0)
+	if arg == "" {
+		return fmt.Errorf("public key is required (usage: ccpersona trust add <key|file>)")
+	}
+	text := arg
+	if data, err := os.ReadFile(arg); err == nil {
+		text = string(data)
+	}
+	key, err := trust.ParsePublicKey(text)
+	if err != nil {
+		return fmt.Errorf("%s: %w", arg, err)
+	}
+	if comment := c.String("comment"); comment != "" {
+		key.Comment = comment
+	}
+
+	store, err := trust.DefaultStore()
+	if err != nil {
+		return err
+	}
+	path, err := store.Add(key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s key %s %s\n", cliui.Success("Trusted"), key.ID, cliui.Muted("("+path+")"))
+	return nil
+}
+
+func handleTrustList(ctx context.Context, c *cli.Command) error {
+	store, err := trust.DefaultStore()
+	if err != nil {
+		return err
+	}
+	keys, err := store.Keys()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		fmt.Printf("No trusted keys %s\n", cliui.Muted("("+store.Dir()+")"))
+		return nil
+	}
+	for _, key := range keys {
+		fmt.Printf("%s  %s\n", cliui.Label(key.ID), key.Comment)
+	}
+	return nil
+}
+
+func handleTrustRemove(ctx context.Context, c *cli.Command) error {
+	id := c.Args().Get(0)
+	if id == "" {
+		return fmt.Errorf("key ID is required (usage: ccpersona trust remove <key-id>)")
+	}
+	store, err := trust.DefaultStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Remove(id); err != nil {
+		return err
+	}
+	fmt.Printf("Removed trusted key %s\n", id)
+	return nil
+}
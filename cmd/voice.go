@@ -4,14 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/fsutil"
 	"github.com/daikw/ccpersona/internal/hook"
 	"github.com/daikw/ccpersona/internal/persona"
 	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/daikw/ccpersona/internal/voice/provider"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v3"
 )
@@ -23,8 +25,6 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 	}
 
 	personaConfig := loadUnifiedConfig(c, "")
-	fileConfig := personaConfig.ToVoiceConfigFile()
-	personaInput := personaConfig.ToVoiceInput()
 	if personaConfig != nil && personaConfig.Voice != nil {
 		log.Debug().
 			Str("persona", personaConfig.Name).
@@ -44,7 +44,7 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 	if c.IsSet("provider") {
 		cliProvider = c.String("provider")
 	}
-	baseOpts := voice.Resolve(personaInput, fileConfig, cliProvider)
+	baseOpts := resolveVoiceFlag(personaConfig, cliProvider, c.String("voice"))
 
 	// CLI speaker overrides everything (highest priority)
 	if cliSpeaker := int(c.Int("speaker")); cliSpeaker > 0 {
@@ -52,8 +52,13 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 		baseOpts.AivisSpeechSpeaker = cliSpeaker
 	}
 
-	voiceConfig := baseOpts.ToConfig(voice.DefaultConfig())
-	voiceConfig.ReadingMode = c.String("mode")
+	voiceConfig := baseOpts.ToConfig(personaConfig.VoiceBaseConfig())
+	if c.IsSet("mode") {
+		voiceConfig.ReadingMode = c.String("mode")
+	}
+	if c.IsSet("diff") {
+		voiceConfig.DiffMode = c.Bool("diff")
+	}
 
 	// Create voice manager
 	manager := voice.NewVoiceManager(voiceConfig)
@@ -73,6 +78,15 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 		}
 
 		fmt.Printf("Available voices for provider '%s':\n", provider)
+		voices, n := favoritesFirst(voices, provider, personaConfig.FavoriteVoices())
+		for _, v := range voices[:n] {
+			fmt.Printf("  %s %s (%s) - %s\n", cliui.Warn("★"), v.ID, v.Language, strings.TrimSpace(v.Name+" "+v.Description))
+		}
+		voices = voices[n:]
+		if provider == voice.EngineVoicevox || provider == voice.EngineAivisSpeech {
+			printSpeakerStyles(voices)
+			return nil
+		}
 		for _, v := range voices {
 			fmt.Printf("  - %s (%s) - %s\n", v.ID, v.Language, v.Description)
 		}
@@ -81,6 +95,9 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 
 	var text string
 	var dedupSessionID string // set when running as stop hook
+	var message string        // unprocessed assistant message, for triggers
+	var spokenID string       // transcript message to bookmark once spoken
+	var failed bool           // nothing was heard, so nothing is recorded as spoken
 
 	if c.Bool("transcript") {
 		// User explicitly wants to read from transcript
@@ -95,7 +112,8 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 		log.Debug().Str("path", transcriptPath).Msg("Using transcript file")
 
 		// Get latest assistant message
-		text, err = reader.GetLatestAssistantMessage(transcriptPath)
+		var messageID string
+		text, messageID, err = reader.GetLatestAssistantMessageWithID(transcriptPath)
 		if err != nil {
 			// If no text content found (e.g., tool_use only messages), skip voice synthesis
 			if strings.Contains(err.Error(), "no assistant message found") {
@@ -105,6 +123,19 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 			return fmt.Errorf("failed to get assistant message: %w", err)
 		}
 
+		// Never read the same message twice, even from another process.
+		bookmark := voice.NewTranscriptBookmark(transcriptPath)
+		if bookmark.Spoken(messageID) {
+			log.Info().Str("message_id", messageID).Msg("Latest message was already spoken, skipping voice synthesis")
+			return nil
+		}
+		spokenID = messageID
+		defer func() {
+			if !failed {
+				bookmark.Record(spokenID)
+			}
+		}()
+
 		// Process text according to reading mode
 		text = reader.ProcessText(text)
 
@@ -112,7 +143,11 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 		// Read plain text from stdin
 		log.Debug().Msg("Reading plain text from stdin")
 
-		textBytes, err := io.ReadAll(os.Stdin)
+		limits, err := hook.LimitsFromEnv()
+		if err != nil {
+			return err
+		}
+		textBytes, err := limits.ReadBytes(os.Stdin)
 		if err != nil {
 			return fmt.Errorf("failed to read from stdin: %w", err)
 		}
@@ -140,6 +175,8 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 			Str("transcript_path", event.TranscriptPath).
 			Bool("stop_hook_active", event.StopHookActive).
 			Msg("Received Stop hook event")
+		recordHookSource(persona.PlatformClaudeCode, "Stop")
+		voice.NewSessionRegistry().Touch(event.SessionID)
 
 		// Create transcript reader
 		reader := voice.NewTranscriptReader(voiceConfig)
@@ -156,6 +193,7 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 		}
 
 		// Process text according to reading mode
+		message = text
 		text = reader.ProcessText(text)
 		dedupSessionID = event.SessionID
 	}
@@ -176,38 +214,94 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 			log.Debug().Msg("Skipping duplicate voice synthesis")
 			return nil
 		}
-		defer func() {
-			dedup.Record(text)
+		defer func(spoken string) {
+			if failed {
+				return
+			}
+			dedup.Record(spoken)
 			go dedup.Cleanup()
+		}(text)
+	}
+
+	if message != "" {
+		onAssistantMessage(ctx, personaConfig, dedupSessionID, message)
+	}
+
+	// Diff mode reads only what changed since the last spoken message
+	if dedupSessionID != "" && voiceConfig.DiffMode {
+		history := voice.NewSpokenHistory(dedupSessionID)
+		spoken := text
+		text = voice.NewSentences(history.Last(), spoken)
+		defer func() {
+			if !failed {
+				history.Record(spoken)
+			}
 		}()
+		if text == "" {
+			log.Debug().Msg("No new sentences since the last spoken message, skipping")
+			return nil
+		}
+	}
+
+	if dedupSessionID != "" {
+		text = sessionPrefix(personaConfig, dedupSessionID, text)
 	}
 
 	fmt.Fprintf(os.Stderr, "📢 Reading text: %s\n", text)
 
 	// Overlay output-only CLI flags on top of resolved options.
-	options := buildVoiceOptions(c, baseOpts)
+	options := buildVoiceOptions(c, baseOpts, voiceConfig.Recent)
+	if options.Subtitles != "" {
+		if !voice.ValidSubtitleFormat(options.Subtitles) {
+			return usageError(fmt.Errorf("--subtitles must be srt or vtt"))
+		}
+		if options.OutputPath == "" {
+			return usageError(fmt.Errorf("--subtitles needs --output with a file path"))
+		}
+	}
+
+	// Hand playback to the daemon when one runs
+	if options.PlayAudio {
+		event := ""
+		if dedupSessionID != "" {
+			event = "Stop"
+		}
+		if forwarded, err := forwardSpeech(ctx, personaConfig, voiceConfig, options, event, text); forwarded {
+			if err != nil {
+				failed = true
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "✅ Voice synthesis complete\n")
+			return nil
+		}
+	}
 
 	// Synthesize voice
 	audioFile, err := manager.Synthesize(ctx, text, options)
 	if err != nil {
+		failed = true
 		return fmt.Errorf("failed to synthesize voice: %w", err)
 	}
 
-	// Handle output
-	if options.ToStdout {
-		// Audio was already streamed to stdout
+	// Audio already streamed to stdout; nothing was saved or is to be played
+	if audioFile == "" {
 		return nil
 	}
 
 	// Play audio if requested
 	if options.PlayAudio {
+		recordSpeech(personaConfig, options, text, audioFile)
 		if err := manager.PlayAudio(audioFile); err != nil {
+			failed = true
 			return fmt.Errorf("failed to play audio: %w", err)
 		}
 	}
 
-	if audioFile != "" {
+	if !options.ToStdout {
 		fmt.Fprintf(os.Stderr, "🎵 Audio saved to: %s\n", audioFile)
+		if options.Subtitles != "" {
+			fmt.Fprintf(os.Stderr, "💬 Subtitles saved to: %s\n", voice.SubtitlePath(audioFile, options.Subtitles))
+		}
 	}
 	fmt.Fprintf(os.Stderr, "✅ Voice synthesis complete\n")
 	return nil
@@ -215,26 +309,45 @@ func handleVoice(ctx context.Context, c *cli.Command) error {
 
 // buildVoiceOptions overlays output-destination CLI flags onto already-resolved options.
 // All provider/speed/volume settings are expected to be set by voice.Resolve() already.
-func buildVoiceOptions(c *cli.Command, base voice.VoiceOptions) voice.VoiceOptions {
+// --play and --recent add sinks to --output, so one synthesis can be played,
+// saved, and streamed at once.
+func buildVoiceOptions(c *cli.Command, base voice.VoiceOptions, recent *voice.RecentConfig) voice.VoiceOptions {
 	output := c.String("output")
 	toStdout := output == "-"
 
 	base.OutputPath = output
-	base.PlayAudio = output == "" // play if no output path specified
+	base.PlayAudio = output == "" || c.Bool("play") // play if no output path specified
 	base.ToStdout = toStdout
+	base.Subtitles = strings.ToLower(c.String("subtitles"))
+	if c.Bool("recent") {
+		base.Recent = recent
+		if base.Recent == nil {
+			base.Recent = &voice.RecentConfig{}
+		}
+	}
 
 	if toStdout {
 		base.OutputPath = ""
 	}
 
-	// --voice CLI flag overrides resolved voice name
-	if v := c.String("voice"); v != "" {
-		base.Voice = v
-	}
-
 	return base
 }
 
+// resolveVoiceFlag resolves the voice options with a command's --provider
+// and --voice flags applied. --voice takes a voice ID of the resolved
+// provider, or a voice alias or provider:voice reference, which also picks
+// the provider.
+func resolveVoiceFlag(config *persona.Config, cliProvider, name string) voice.VoiceOptions {
+	if ref, ok := config.LookupVoice(name); ok {
+		return voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), ref.Provider).WithVoice(ref)
+	}
+	opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), cliProvider)
+	if name != "" {
+		opts.Voice = name
+	}
+	return opts
+}
+
 // Voice config management handlers
 
 func handleVoiceConfigShow(ctx context.Context, c *cli.Command) error {
@@ -273,7 +386,7 @@ func handleVoiceConfigValidate(ctx context.Context, c *cli.Command) error {
 	} else {
 		fmt.Println("❌ Configuration has errors:")
 		fmt.Printf("  - %s\n", err)
-		return fmt.Errorf("configuration validation failed")
+		return configError(fmt.Errorf("configuration validation failed"))
 	}
 }
 
@@ -326,7 +439,7 @@ func handleVoiceConfigInit(ctx context.Context, c *cli.Command) error {
 	example = append(example, '\n')
 
 	// Write with secure permissions
-	if err := os.WriteFile(configPath, example, 0600); err != nil {
+	if err := fsutil.WriteFile(configPath, example, 0600); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
 
@@ -369,6 +482,15 @@ func handleVoiceUnmute(ctx context.Context, c *cli.Command) error {
 }
 
 func handleVoiceStatus(ctx context.Context, c *cli.Command) error {
+	if muted, ok := voice.MuteOverride(); ok {
+		if muted {
+			fmt.Printf("🔇 Voice synthesis: MUTED (%s=%s)\n", voice.EnvMute, os.Getenv(voice.EnvMute))
+		} else {
+			fmt.Printf("🔊 Voice synthesis: ACTIVE (%s=%s overrides the mute marker)\n", voice.EnvMute, os.Getenv(voice.EnvMute))
+		}
+		return nil
+	}
+
 	status, err := voice.LoadMuteStatus()
 	if err != nil {
 		return fmt.Errorf("failed to read mute status: %w", err)
@@ -397,7 +519,13 @@ func loadUnifiedConfig(c *cli.Command, platform string) *persona.Config {
 			fmt.Fprintf(os.Stderr, "ccpersona: failed to load %s; using built-in defaults: %v\n", configPath, err)
 			return nil
 		}
-		return config
+		config = persona.WithPersonaVoice(persona.ApplyEnvOverrides(persona.WithGlobalVoices(config)))
+		applied, err := persona.ApplyPlatform(config, platform)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ccpersona: %v; using the top-level settings\n", err)
+			return config
+		}
+		return applied
 	}
 
 	config, err := persona.LoadConfigWithFallbackForPlatform(platform)
@@ -413,12 +541,82 @@ func maskUnifiedConfig(config *persona.Config) *persona.Config {
 		return nil
 	}
 	masked := *config
-	if config.Voice != nil {
-		voiceCfg := *config.Voice
-		if voiceCfg.APIKey != "" {
-			voiceCfg.APIKey = fmt.Sprintf("[set, %d chars]", len(voiceCfg.APIKey))
+	masked.Voice = maskVoiceConfig(config.Voice)
+	if config.Profiles != nil {
+		masked.Profiles = make(map[string]*persona.Profile, len(config.Profiles))
+		for name, profile := range config.Profiles {
+			if profile != nil {
+				p := *profile
+				p.Voice = maskVoiceConfig(profile.Voice)
+				profile = &p
+			}
+			masked.Profiles[name] = profile
+		}
+	}
+	if config.Platforms != nil {
+		masked.Platforms = make(map[string]*persona.PlatformConfig, len(config.Platforms))
+		for name, entry := range config.Platforms {
+			if entry != nil {
+				e := *entry
+				e.Voice = maskVoiceConfig(entry.Voice)
+				entry = &e
+			}
+			masked.Platforms[name] = entry
+		}
+	}
+	if config.Personas != nil {
+		masked.Personas = make(map[string]*persona.PersonaConfig, len(config.Personas))
+		for name, entry := range config.Personas {
+			if entry != nil {
+				e := *entry
+				e.Voice = maskVoiceConfig(entry.Voice)
+				entry = &e
+			}
+			masked.Personas[name] = entry
 		}
-		masked.Voice = &voiceCfg
 	}
 	return &masked
 }
+
+func maskVoiceConfig(v *persona.VoiceConfig) *persona.VoiceConfig {
+	if v == nil || v.APIKey == "" {
+		return v
+	}
+	voiceCfg := *v
+	voiceCfg.APIKey = fmt.Sprintf("[set, %d chars]", len(voiceCfg.APIKey))
+	return &voiceCfg
+}
+
+// favoritesFirst moves the favorite voices of the provider (any provider
+// when it is "") to the front in the configured order, and returns how many
+// there are.
+func favoritesFirst(voices []provider.Voice, providerName string, favorites []voice.VoiceRef) ([]provider.Voice, int) {
+	var front, rest []provider.Voice
+	taken := make([]bool, len(voices))
+	for _, ref := range favorites {
+		for i, v := range voices {
+			if !taken[i] && ref.Matches(providerName, v) {
+				front = append(front, v)
+				taken[i] = true
+			}
+		}
+	}
+	for i, v := range voices {
+		if !taken[i] {
+			rest = append(rest, v)
+		}
+	}
+	return append(front, rest...), len(front)
+}
+
+// printSpeakerStyles lists local engine styles grouped by speaker. The ID is
+// what voice.speaker takes, and the style name what voice.speaker_style.name
+// takes.
+func printSpeakerStyles(voices []provider.Voice) {
+	for i, v := range voices {
+		if i == 0 || voices[i-1].Name != v.Name {
+			fmt.Printf("  %s\n", v.Name)
+		}
+		fmt.Printf("    %-12s %s\n", v.ID, v.Description)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/detach"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v3"
+)
+
+// handleVoiceAck plays a prompt acknowledgement phrase from the cache, or
+// with --warm synthesizes every phrase ahead of time.
+func handleVoiceAck(ctx context.Context, c *cli.Command) error {
+	config := loadUnifiedConfig(c, c.String("platform"))
+	var ack *persona.AckConfig
+	personaName := ""
+	if config != nil {
+		ack = config.Ack
+		personaName = config.Name
+	}
+
+	opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "")
+	voiceConfig := opts.ToConfig(config.VoiceBaseConfig())
+	manager := voice.NewVoiceManager(voiceConfig)
+	cache, err := voice.NewPhraseCache()
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("warm") {
+		for _, phrase := range ack.EffectivePhrases() {
+			path, err := cache.Get(ctx, manager, personaName, phrase, opts)
+			if err != nil {
+				return fmt.Errorf("failed to synthesize %q: %w", phrase, err)
+			}
+			fmt.Printf("%s %s %s\n", cliui.Success("✓"), phrase, cliui.Muted(path))
+		}
+		return nil
+	}
+
+	if voice.IsMuted() {
+		log.Debug().Msg("voice synthesis is globally muted, skipping ack")
+		return nil
+	}
+	phrase := c.String("phrase")
+	if phrase == "" {
+		phrases := ack.EffectivePhrases()
+		phrase = phrases[rand.IntN(len(phrases))]
+	}
+	path, err := cache.Get(ctx, manager, personaName, phrase, opts)
+	if err != nil {
+		return fmt.Errorf("failed to synthesize ack: %w", err)
+	}
+	return voice.NewVoiceEngine(voiceConfig).PlayFile(path)
+}
+
+// startAck plays the prompt acknowledgement in a detached process so the
+// prompt hook returns immediately.
+func startAck(config *persona.Config, platform string) {
+	if config == nil || !config.Ack.IsEnabled() {
+		return
+	}
+	startPhrase(platform, "")
+}
+
+// startResumeCue speaks the resume cue, such as "ずんだもんで再開します",
+// when a session resumed or was compacted. It goes through the ack cache, so
+// the cue is synthesized once per persona.
+func startResumeCue(config *persona.Config, platform string) {
+	if config == nil || !config.Resume.CueEnabled() {
+		return
+	}
+	startPhrase(platform, config.Resume.CuePhrase(config.Name))
+}
+
+// startPhrase runs `runtime voice ack` in a detached process; an empty
+// phrase plays a random ack phrase.
+func startPhrase(platform, phrase string) {
+	if voice.IsMuted() {
+		return
+	}
+	self, err := os.Executable()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to locate ccpersona for ack")
+		return
+	}
+	args := []string{"runtime", "voice", "ack"}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	if phrase != "" {
+		args = append(args, "--phrase", phrase)
+	}
+	if err := detach.Start(exec.Command(self, args...)); err != nil {
+		log.Warn().Err(err).Msg("Failed to start ack playback")
+	}
+}
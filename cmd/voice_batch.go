@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
+)
+
+func handleVoiceBatch(ctx context.Context, c *cli.Command) error {
+	input := c.String("input")
+	var content []byte
+	var err error
+	if input == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(input)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	sections, err := voice.SplitDocument(string(content), c.String("split"))
+	if err != nil {
+		return err
+	}
+	if len(sections) == 0 {
+		return fmt.Errorf("no text to synthesize in %s", input)
+	}
+
+	config := loadUnifiedConfig(c, "")
+	cliProvider := ""
+	if c.IsSet("provider") {
+		cliProvider = c.String("provider")
+	}
+	opts := resolveVoiceFlag(config, cliProvider, c.String("voice"))
+	manager := voice.NewVoiceManager(opts.ToConfig(config.VoiceBaseConfig()))
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	outDir := c.String("output")
+	fmt.Printf("Synthesizing %d sections into %s\n", len(sections), cliui.Label(outDir))
+	manifest, err := manager.SynthesizeBatch(ctx, sections, voice.BatchOptions{
+		OutDir:    outDir,
+		Source:    input,
+		Split:     c.String("split"),
+		Parallel:  int(c.Int("parallel")),
+		PerMinute: int(c.Int("rate")),
+		Force:     c.Bool("force"),
+		Voice:     opts,
+		OnSection: func(s voice.BatchSection) {
+			switch {
+			case s.Skipped:
+				fmt.Printf("  %s %s %s\n", cliui.Muted("="), s.File, cliui.Muted("(unchanged)"))
+			case s.Status == voice.BatchFailed:
+				fmt.Printf("  %s %s: %s\n", cliui.Failure("✗"), s.File, s.Error)
+			default:
+				fmt.Printf("  %s %s\n", cliui.Success("✓"), s.File)
+			}
+		},
+	})
+	if manifest != nil {
+		fmt.Printf("Manifest: %s\n", filepath.Join(outDir, voice.BatchManifestName))
+	}
+	return err
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
+)
+
+const sourceBuiltinDefault = "built-in default"
+
+// explainRow is one resolved setting and the layer it came from.
+type explainRow struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+func handleVoiceExplain(ctx context.Context, c *cli.Command) error {
+	platform := c.String("platform")
+	config := loadUnifiedConfig(c, platform)
+	configSource := describeConfigSource(c)
+	if config.HasPersonaVoice() {
+		configSource += " (persona " + config.Name + ")"
+	}
+	if config.PlatformOverride(platform) != nil {
+		configSource += " (platforms." + platform + ")"
+	}
+
+	cliProvider := ""
+	if c.IsSet("provider") {
+		cliProvider = c.String("provider")
+	}
+	opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), cliProvider)
+
+	fmt.Println(cliui.Header("Resolved voice settings"))
+	for _, row := range explainVoice(config, configSource, cliProvider, opts) {
+		fmt.Printf("  %-10s %-24s %s\n", cliui.Label(row.Key), row.Value, cliui.Muted(row.Source))
+	}
+	if opts.Auto != nil {
+		fmt.Println()
+		explainAuto(opts.Auto, voice.LoadProviderStats(), time.Now())
+	}
+	fmt.Println()
+	fmt.Println(cliui.Muted("Precedence: flags > CCPERSONA_* environment > project config > global config > built-in defaults"))
+	return nil
+}
+
+// explainAuto prints the measured stats of each auto candidate and the
+// order they would be tried in for short and long text.
+func explainAuto(sel *voice.AutoSelection, stats map[string]voice.ProviderStat, now time.Time) {
+	long := sel.LongChars
+	if long <= 0 {
+		long = voice.DefaultAutoLongChars
+	}
+	fmt.Println(cliui.Header("Auto provider"))
+	fmt.Printf("  %-12s %10s %8s %8s  %s\n", "PROVIDER", "LATENCY", "ERRORS", "SAMPLES", "STATUS")
+	for _, c := range sel.Candidates {
+		s := stats[c.Provider]
+		latency, status := "-", "healthy"
+		if s.Samples > 0 {
+			latency = fmt.Sprintf("%.0f ms", s.LatencyMS)
+		}
+		if !s.Healthy(now) {
+			status = cliui.Warn(fmt.Sprintf("backing off after %d failures", s.Failures))
+		}
+		if c.Provider == sel.Quality {
+			status += cliui.Muted(" (quality)")
+		}
+		fmt.Printf("  %-12s %10s %7.0f%% %8d  %s\n", c.Provider, latency, s.ErrorRate*100, s.Samples, status)
+	}
+	for _, sample := range []struct{ label, text string }{
+		{"Short text", "x"},
+		{fmt.Sprintf("Long text (%d+ chars)", long), strings.Repeat("x", long)},
+	} {
+		var order []string
+		for _, choice := range sel.Rank(sample.text, stats, now) {
+			order = append(order, choice.Options.Provider)
+		}
+		fmt.Printf("  %s %s\n", cliui.Label(sample.label+":"), strings.Join(order, " → "))
+	}
+}
+
+// describeConfigSource names the config file loadUnifiedConfig would read.
+func describeConfigSource(c *cli.Command) string {
+	if path := c.String("config"); path != "" {
+		return "--config " + path
+	}
+	if _, err := os.Stat(persona.ConfigPath(".")); err == nil {
+		return "project " + persona.ConfigPath(".")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if _, err := os.Stat(persona.ConfigPath(home)); err == nil {
+			return "global " + persona.ConfigPath(home)
+		}
+	}
+	return "no config file"
+}
+
+// explainVoice reports each resolved setting alongside the layer that
+// supplied it, mirroring the precedence implemented by voice.Resolve.
+func explainVoice(config *persona.Config, configSource, cliProvider string, opts voice.VoiceOptions) []explainRow {
+	defaults := voice.DefaultConfig()
+	var voiceCfg persona.VoiceConfig
+	if config != nil && config.Voice != nil {
+		voiceCfg = *config.Voice
+	}
+
+	var rows []explainRow
+
+	switch {
+	case persona.PersonaOverride() != "":
+		rows = append(rows, explainRow{"persona", persona.PersonaOverride(), "env " + persona.EnvPersona})
+	case config != nil:
+		rows = append(rows, explainRow{"persona", config.Name, configSource})
+	default:
+		rows = append(rows, explainRow{"persona", "none", configSource})
+	}
+
+	provider := opts.Provider
+	providerSource := sourceBuiltinDefault
+	switch {
+	case cliProvider != "":
+		providerSource = "--provider flag"
+	case voice.ProviderOverride() != "":
+		providerSource = "env " + voice.EnvProvider
+	case voiceCfg.Provider != "":
+		providerSource = configSource
+	}
+	if provider == "" {
+		provider = defaults.EnginePriority
+	}
+	rows = append(rows, explainRow{"provider", provider, providerSource})
+
+	if provider == voice.EngineVoicevox || provider == voice.EngineAivisSpeech {
+		speaker := int64(opts.VoicevoxSpeaker)
+		if provider == voice.EngineAivisSpeech {
+			speaker = int64(opts.AivisSpeechSpeaker)
+		}
+		speakerSource := configSource
+		if speaker == 0 {
+			speakerSource = sourceBuiltinDefault
+			speaker = int64(defaults.VoicevoxSpeaker)
+			if provider == voice.EngineAivisSpeech {
+				speaker = defaults.AivisSpeechSpeaker
+			}
+		}
+		rows = append(rows, explainRow{"speaker", strconv.FormatInt(speaker, 10), speakerSource})
+	} else if opts.Voice != "" {
+		rows = append(rows, explainRow{"voice", opts.Voice, configSource})
+	}
+
+	rows = append(rows,
+		explainRow{"volume", strconv.FormatFloat(opts.Volume, 'g', -1, 64), settingSource(voiceCfg.Volume > 0, configSource)},
+		explainRow{"speed", strconv.FormatFloat(opts.Speed, 'g', -1, 64), settingSource(voiceCfg.Speed > 0, configSource)},
+	)
+
+	if muted, ok := voice.MuteOverride(); ok {
+		rows = append(rows, explainRow{"muted", strconv.FormatBool(muted), "env " + voice.EnvMute})
+	} else if voice.IsMuted() {
+		path, _ := voice.MutePath()
+		rows = append(rows, explainRow{"muted", "true", "marker " + path})
+	} else {
+		rows = append(rows, explainRow{"muted", "false", sourceBuiltinDefault})
+	}
+	return rows
+}
+
+func settingSource(fromConfig bool, configSource string) string {
+	if fromConfig {
+		return configSource
+	}
+	return sourceBuiltinDefault
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+)
+
+func explainRowsByKey(rows []explainRow) map[string]explainRow {
+	out := make(map[string]explainRow, len(rows))
+	for _, row := range rows {
+		out[row.Key] = row
+	}
+	return out
+}
+
+func TestExplainVoice_Sources(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config := &persona.Config{
+		Name:  "fable",
+		Voice: &persona.VoiceConfig{Provider: "voicevox", Speaker: 8, Speed: 1.2},
+	}
+	source := "project .agents/ccpersona.json"
+
+	opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "")
+	rows := explainRowsByKey(explainVoice(config, source, "", opts))
+
+	if r := rows["persona"]; r.Value != "fable" || r.Source != source {
+		t.Errorf("persona row = %+v", r)
+	}
+	if r := rows["provider"]; r.Value != "voicevox" || r.Source != source {
+		t.Errorf("provider row = %+v", r)
+	}
+	if r := rows["speaker"]; r.Value != "8" || r.Source != source {
+		t.Errorf("speaker row = %+v", r)
+	}
+	if r := rows["speed"]; r.Value != "1.2" || r.Source != source {
+		t.Errorf("speed row = %+v", r)
+	}
+	if r := rows["volume"]; r.Source != sourceBuiltinDefault {
+		t.Errorf("volume row = %+v", r)
+	}
+	if r := rows["muted"]; r.Value != "false" {
+		t.Errorf("muted row = %+v", r)
+	}
+}
+
+func TestExplainVoice_EnvOverrides(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(persona.EnvPersona, "strict")
+	t.Setenv(voice.EnvProvider, "openai")
+	t.Setenv(voice.EnvMute, "1")
+
+	config := persona.ApplyEnvOverrides(&persona.Config{Name: "fable", Voice: &persona.VoiceConfig{Provider: "voicevox"}})
+	opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "")
+	rows := explainRowsByKey(explainVoice(config, "project x", "", opts))
+
+	if r := rows["persona"]; r.Value != "strict" || r.Source != "env CCPERSONA_PERSONA" {
+		t.Errorf("persona row = %+v", r)
+	}
+	if r := rows["provider"]; r.Value != "openai" || r.Source != "env CCPERSONA_PROVIDER" {
+		t.Errorf("provider row = %+v", r)
+	}
+	if r := rows["muted"]; r.Value != "true" || r.Source != "env CCPERSONA_MUTE" {
+		t.Errorf("muted row = %+v", r)
+	}
+
+	opts = voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "polly")
+	rows = explainRowsByKey(explainVoice(config, "project x", "polly", opts))
+	if r := rows["provider"]; r.Value != "polly" || r.Source != "--provider flag" {
+		t.Errorf("provider row with flag = %+v", r)
+	}
+}
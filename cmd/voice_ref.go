@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
+)
+
+func handleVoiceRefAdd(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 2 {
+		return fmt.Errorf("usage: ccpersona runtime voice ref add <name> <file.wav>")
+	}
+	ref, err := voice.AddReference(c.Args().Get(0), c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Added reference voice %s (%s)\n", cliui.Success("✓"), cliui.Label(ref.Name), cliui.Muted(ref.Path))
+	fmt.Printf("Use it with \"voice\": {\"provider\": \"xtts\", \"reference_wav\": %q}\n", ref.Name)
+	return nil
+}
+
+func handleVoiceRefList(ctx context.Context, c *cli.Command) error {
+	refs, err := voice.ListReferences()
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		fmt.Println("No reference voices. Add one with 'ccpersona runtime voice ref add <name> <file.wav>'.")
+		return nil
+	}
+	for _, ref := range refs {
+		fmt.Printf("  %-20s %8.1f KB  %s\n", ref.Name, float64(ref.Size)/1024, cliui.Muted(ref.Path))
+	}
+	return nil
+}
+
+func handleVoiceRefRemove(ctx context.Context, c *cli.Command) error {
+	name := c.Args().First()
+	if name == "" {
+		return fmt.Errorf("usage: ccpersona runtime voice ref remove <name>")
+	}
+	if err := voice.RemoveReference(name); err != nil {
+		return err
+	}
+	fmt.Printf("%s Removed reference voice %s\n", cliui.Success("✓"), name)
+	return nil
+}
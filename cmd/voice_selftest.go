@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
+)
+
+// voiceTestSentence is spoken by `voice test` unless --text is given.
+const voiceTestSentence = "これは ccpersona の音声テストです。"
+
+// voiceTestStage prints one stage of `voice test`. A failed stage ends the
+// test with an error, which makes the command exit non-zero.
+func voiceTestStage(name, detail string, err error) error {
+	if err != nil {
+		fmt.Printf("  %s %-10s %v\n", cliui.Failure("✗"), name, err)
+		return fmt.Errorf("voice test failed at %s: %w", name, err)
+	}
+	fmt.Printf("  %s %-10s %s\n", cliui.Success("✓"), name, detail)
+	return nil
+}
+
+func handleVoiceTest(ctx context.Context, c *cli.Command) error {
+	config := loadUnifiedConfig(c, "")
+	cliProvider := ""
+	if c.IsSet("provider") {
+		cliProvider = c.String("provider")
+	}
+	opts := resolveVoiceFlag(config, cliProvider, c.String("voice"))
+	opts.PlayAudio = false
+	opts.OutputPath = ""
+	opts.ToStdout = false
+
+	fmt.Println(cliui.Header("Voice pipeline test"))
+	_ = voiceTestStage("config", describeConfigSource(c), nil)
+	if voice.IsMuted() {
+		fmt.Printf("  %s %-10s %s\n", cliui.Warn("!"), "mute", "voice is muted globally; testing anyway")
+	}
+	if voice.TestMode() {
+		fmt.Printf("  %s %-10s %s\n", cliui.Warn("!"), "test mode", "synthesis and playback are simulated; see "+voice.TestRecordPath())
+	}
+
+	provider := opts.Provider
+	if provider == "" {
+		provider = "auto"
+	}
+	_ = voiceTestStage("provider", fmt.Sprintf("%s (%s)", provider, describeVoice(opts)), nil)
+
+	ctx, cancel := context.WithTimeout(ctx, c.Duration("timeout"))
+	defer cancel()
+	manager := voice.NewVoiceManager(opts.ToConfig(config.VoiceBaseConfig()))
+	text := c.String("text")
+	start := time.Now()
+	audioFile, err := manager.Synthesize(ctx, text, opts)
+	if err := voiceTestStage("synthesis", fmt.Sprintf("%d chars in %s", len([]rune(text)), time.Since(start).Round(time.Millisecond)), err); err != nil {
+		return err
+	}
+	defer os.Remove(audioFile)
+
+	info, err := os.Stat(audioFile)
+	if err == nil && info.Size() == 0 {
+		err = errors.New("provider returned empty audio")
+	}
+	if err != nil {
+		return voiceTestStage("audio", "", err)
+	}
+	format := strings.TrimPrefix(filepath.Ext(audioFile), ".")
+	detail := fmt.Sprintf("%s, %d bytes", format, info.Size())
+	if d, err := voice.AudioDuration(audioFile); err == nil {
+		detail += fmt.Sprintf(", %.1fs", d.Seconds())
+	}
+	_ = voiceTestStage("audio", detail, nil)
+
+	if c.Bool("no-play") {
+		fmt.Printf("  %s %-10s %s\n", cliui.Muted("-"), "playback", cliui.Muted("skipped (--no-play)"))
+		return nil
+	}
+	player := voice.PlayerName()
+	if player == "" {
+		return voiceTestStage("playback", "", errors.New("no audio player found (install afplay, aplay, paplay, or ffplay)"))
+	}
+	start = time.Now()
+	err = voice.NewVoiceEngine(voice.DefaultConfig()).PlayFile(audioFile)
+	return voiceTestStage("playback", fmt.Sprintf("%s in %s", player, time.Since(start).Round(time.Millisecond)), err)
+}
+
+// describeVoice names the voice or speaker opts selects.
+func describeVoice(opts voice.VoiceOptions) string {
+	if opts.Voice != "" {
+		return "voice " + opts.Voice
+	}
+	speaker := 0
+	switch opts.Provider {
+	case "voicevox":
+		speaker = opts.VoicevoxSpeaker
+	case "aivisspeech", "":
+		speaker = opts.AivisSpeechSpeaker
+	}
+	if speaker > 0 {
+		return fmt.Sprintf("speaker %d", speaker)
+	}
+	return "default voice"
+}
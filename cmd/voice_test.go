@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/harness"
+)
+
+func TestVoiceDiffModeRecordsOnlySpokenMessages(t *testing.T) {
+	env := harness.New(t)
+	env.WriteConfig(`{"name": "zundamon", "voice": {"provider": "aivisspeech", "speaker": 888753760, "diff_mode": true}}`)
+	env.WriteFile("transcript.jsonl", `{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"テストを修正しました。"}]}}`+"\n")
+	payload := env.Expand(`{"session_id":"diff","transcript_path":"{{project}}/transcript.jsonl","hook_event_name":"Stop"}`)
+	run := func(ctx context.Context, args []string) error {
+		return newApp().Run(ctx, args)
+	}
+
+	env.AivisSpeech.FailNext(10)
+	if _, err := env.Run(run, payload, "runtime", "voice", "--mode", "full"); err == nil {
+		t.Fatal("synthesis should fail")
+	}
+	env.AivisSpeech.FailNext(0)
+
+	// The failed message was never heard, so it is neither a duplicate nor
+	// the base of the diff.
+	if _, err := env.Run(run, payload, "runtime", "voice", "--mode", "full"); err != nil {
+		t.Fatal(err)
+	}
+	if plays := env.Player.Plays(); len(plays) != 1 {
+		t.Fatalf("plays = %v, want the message spoken once it succeeds", plays)
+	}
+	requests := env.AivisSpeech.Requests()
+	if last := requests[len(requests)-1]; last.Text != "テストを修正しました。" {
+		t.Errorf("spoken text = %q, want the whole message", last.Text)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/daikw/ccpersona/internal/cliui"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/urfave/cli/v3"
+)
+
+func voicesCommand() *cli.Command {
+	globalFlag := &cli.BoolFlag{
+		Name:    "global",
+		Aliases: []string{"g"},
+		Usage:   "Write to global config (~/.agents/ccpersona.json)",
+	}
+	return &cli.Command{
+		Name:        "voices",
+		Usage:       "List and edit voice aliases and favorites",
+		Description: "An alias names a voice as provider:voice, e.g. narrator → elevenlabs:pNInz6obpgDQGcFmaJgB\nor zunda → voicevox:3. Aliases work wherever a voice is accepted: --voice, voice.voice,\nand the voice of notification rules. Favorites are listed first by --list-voices.",
+		Action:      handleVoicesList,
+		Commands: []*cli.Command{
+			{
+				Name:      "alias",
+				Usage:     "Name a voice",
+				ArgsUsage: "<name> <provider:voice>",
+				Action:    handleVoicesAlias,
+				Flags:     []cli.Flag{globalFlag},
+			},
+			{
+				Name:      "unalias",
+				Usage:     "Remove a voice alias",
+				ArgsUsage: "<name>",
+				Action:    handleVoicesUnalias,
+				Flags:     []cli.Flag{globalFlag},
+			},
+			{
+				Name:      "favorite",
+				Usage:     "Add a voice to the favorites",
+				ArgsUsage: "<alias|provider:voice>",
+				Action:    handleVoicesFavorite,
+				Flags:     []cli.Flag{globalFlag},
+			},
+			{
+				Name:      "unfavorite",
+				Usage:     "Remove a voice from the favorites",
+				ArgsUsage: "<alias|provider:voice>",
+				Action:    handleVoicesUnfavorite,
+				Flags:     []cli.Flag{globalFlag},
+			},
+		},
+	}
+}
+
+func handleVoicesList(ctx context.Context, c *cli.Command) error {
+	config := loadUnifiedConfig(c, "")
+	var voices persona.VoicesConfig
+	if config != nil && config.Voices != nil {
+		voices = *config.Voices
+	}
+
+	fmt.Println(cliui.Header("Aliases"))
+	if len(voices.Aliases) == 0 {
+		fmt.Println(cliui.Muted("  (none; add one with 'ccpersona config voices alias <name> <provider:voice>')"))
+	}
+	for _, name := range slices.Sorted(maps.Keys(voices.Aliases)) {
+		fmt.Printf("  %-16s %s\n", cliui.Label(name), voices.Aliases[name])
+	}
+
+	fmt.Println()
+	fmt.Println(cliui.Header("Favorites"))
+	if len(voices.Favorites) == 0 {
+		fmt.Println(cliui.Muted("  (none)"))
+	}
+	for _, favorite := range voices.Favorites {
+		ref, ok := config.LookupVoice(favorite)
+		switch {
+		case !ok:
+			fmt.Printf("  %s %s %s\n", cliui.Failure("✗"), favorite, cliui.Muted("(no such alias)"))
+		case ref.String() == favorite:
+			fmt.Printf("  %s %s\n", cliui.Warn("★"), favorite)
+		default:
+			fmt.Printf("  %s %s %s\n", cliui.Warn("★"), favorite, cliui.Muted("("+ref.String()+")"))
+		}
+	}
+	return nil
+}
+
+func handleVoicesAlias(ctx context.Context, c *cli.Command) error {
+	name, target := c.Args().Get(0), c.Args().Get(1)
+	if name == "" || target == "" {
+		return usageError(fmt.Errorf("alias name and voice are required (usage: ccpersona config voices alias <name> <provider:voice>)"))
+	}
+	aliases := voice.VoiceAliases{name: target}
+	if err := aliases.Validate(); err != nil {
+		return usageError(err)
+	}
+	return updateVoices(c, func(v *persona.VoicesConfig) error {
+		if v.Aliases == nil {
+			v.Aliases = voice.VoiceAliases{}
+		}
+		v.Aliases[name] = target
+		return nil
+	}, fmt.Sprintf("Alias %s → %s", name, target))
+}
+
+func handleVoicesUnalias(ctx context.Context, c *cli.Command) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return usageError(fmt.Errorf("alias name is required (usage: ccpersona config voices unalias <name>)"))
+	}
+	return updateVoices(c, func(v *persona.VoicesConfig) error {
+		if _, ok := v.Aliases[name]; !ok {
+			return fmt.Errorf("no voice alias %q in this config", name)
+		}
+		delete(v.Aliases, name)
+		return nil
+	}, "Removed alias "+name)
+}
+
+func handleVoicesFavorite(ctx context.Context, c *cli.Command) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return usageError(fmt.Errorf("voice is required (usage: ccpersona config voices favorite <alias|provider:voice>)"))
+	}
+	if _, ok := loadUnifiedConfig(c, "").LookupVoice(name); !ok {
+		return usageError(fmt.Errorf("%q is neither a voice alias nor a provider:voice reference", name))
+	}
+	return updateVoices(c, func(v *persona.VoicesConfig) error {
+		if !slices.Contains(v.Favorites, name) {
+			v.Favorites = append(v.Favorites, name)
+		}
+		return nil
+	}, "Added favorite "+name)
+}
+
+func handleVoicesUnfavorite(ctx context.Context, c *cli.Command) error {
+	name := c.Args().Get(0)
+	if name == "" {
+		return usageError(fmt.Errorf("voice is required (usage: ccpersona config voices unfavorite <alias|provider:voice>)"))
+	}
+	return updateVoices(c, func(v *persona.VoicesConfig) error {
+		i := slices.Index(v.Favorites, name)
+		if i < 0 {
+			return fmt.Errorf("%q is not a favorite in this config", name)
+		}
+		v.Favorites = slices.Delete(v.Favorites, i, i+1)
+		return nil
+	}, "Removed favorite "+name)
+}
+
+// updateVoices edits the voices block of the project config, or the global
+// config with --global.
+func updateVoices(c *cli.Command, fn func(*persona.VoicesConfig) error, done string) error {
+	targetDir := "."
+	if c.Bool("global") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
+		}
+		targetDir = homeDir
+	}
+	err := persona.UpdateConfig(targetDir, func(config *persona.Config) (*persona.Config, error) {
+		if config == nil {
+			config = persona.GetDefaultConfig()
+		}
+		if config.Voices == nil {
+			config.Voices = &persona.VoicesConfig{}
+		}
+		if err := fn(config.Voices); err != nil {
+			return nil, err
+		}
+		if len(config.Voices.Aliases) == 0 && len(config.Voices.Favorites) == 0 {
+			config.Voices = nil
+		}
+		return config, nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s %s\n", cliui.Success("✓"), done, cliui.Muted("("+persona.ConfigPath(targetDir)+")"))
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/daikw/ccpersona/internal/voice/provider"
+)
+
+func TestResolveVoiceFlag(t *testing.T) {
+	config := &persona.Config{
+		Voice:  &persona.VoiceConfig{Provider: "openai", Voice: "nova"},
+		Voices: &persona.VoicesConfig{Aliases: voice.VoiceAliases{"zunda": "voicevox:3"}},
+	}
+	if opts := resolveVoiceFlag(config, "", "zunda"); opts.Provider != "voicevox" || opts.VoicevoxSpeaker != 3 {
+		t.Errorf("alias: %+v", opts)
+	}
+	if opts := resolveVoiceFlag(config, "openai", "elevenlabs:abc"); opts.Provider != "elevenlabs" || opts.Voice != "abc" {
+		t.Errorf("a reference should override --provider: %+v", opts)
+	}
+	if opts := resolveVoiceFlag(config, "", "shimmer"); opts.Provider != "openai" || opts.Voice != "shimmer" {
+		t.Errorf("plain voice ID: %+v", opts)
+	}
+	if opts := resolveVoiceFlag(config, "", ""); opts.Voice != "nova" {
+		t.Errorf("no flag should keep the configured voice: %+v", opts)
+	}
+}
+
+func TestFavoritesFirst(t *testing.T) {
+	voices := []provider.Voice{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}}
+	favorites := []voice.VoiceRef{
+		{Provider: "voicevox", Speaker: 3, HasSpeaker: true},
+		{Provider: "aivisspeech", Speaker: 1, HasSpeaker: true},
+		{Provider: "voicevox", Speaker: 2, HasSpeaker: true},
+	}
+	got, n := favoritesFirst(voices, "voicevox", favorites)
+	if n != 2 {
+		t.Fatalf("favorites = %d, want 2", n)
+	}
+	var ids []string
+	for _, v := range got {
+		ids = append(ids, v.ID)
+	}
+	if want := "3,2,1,4"; strings.Join(ids, ",") != want {
+		t.Errorf("order = %s, want %s", strings.Join(ids, ","), want)
+	}
+}
@@ -0,0 +1,179 @@
+// Package analytics keeps opt-in, local-only counts of which ccpersona
+// features are used: commands, TTS providers, and hook event sources. Nothing
+// is ever sent anywhere. The counts live in a single file under
+// ~/.agents/ccpersona and are only shown by `ccpersona config stats
+// --features`, so users decide whether to share the report.
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+// Kinds of recorded features.
+const (
+	KindCommand  = "command"
+	KindProvider = "provider"
+	KindSource   = "source"
+)
+
+// Kinds lists the feature kinds in report order.
+var Kinds = []string{KindCommand, KindProvider, KindSource}
+
+// maxNameLen keeps recorded names to identifiers; anything longer is not a
+// feature name and is dropped rather than stored.
+const maxNameLen = 64
+
+// Data is the on-disk analytics file.
+type Data struct {
+	EnabledAt time.Time                 `json:"enabled_at"`
+	UpdatedAt time.Time                 `json:"updated_at,omitempty"`
+	Counts    map[string]map[string]int `json:"counts"`
+}
+
+// Count is one feature and how often it was used.
+type Count struct {
+	Name  string
+	Count int
+}
+
+// Path returns the analytics file location. Its presence is the opt-in.
+func Path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".agents", "ccpersona", "analytics.json"), nil
+}
+
+// Enabled reports whether the user opted in. DO_NOT_TRACK=1 disables
+// recording even then.
+func Enabled() bool {
+	if os.Getenv("DO_NOT_TRACK") == "1" {
+		return false
+	}
+	path, err := Path()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Enable opts in and returns the current data. Enabling twice keeps the
+// existing counts.
+func Enable() (*Data, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	var data *Data
+	err = fsutil.WithLock(path, func() error {
+		if data, err = load(path); err != nil || data != nil {
+			return err
+		}
+		data = &Data{EnabledAt: time.Now().UTC(), Counts: map[string]map[string]int{}}
+		return save(path, data)
+	})
+	return data, err
+}
+
+// Disable opts out and deletes all recorded counts. It reports whether
+// analytics were enabled.
+func Disable() (bool, error) {
+	path, err := Path()
+	if err != nil {
+		return false, err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Load returns the recorded data, or nil when analytics are not enabled.
+func Load() (*Data, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return load(path)
+}
+
+// Record counts one use of a feature. It is a no-op unless the user opted
+// in, and failures are only logged: analytics must never break a command or
+// hook.
+func Record(kind, name string) {
+	if name == "" || len(name) > maxNameLen || !Enabled() {
+		return
+	}
+	path, err := Path()
+	if err != nil {
+		return
+	}
+	err = fsutil.WithLock(path, func() error {
+		data, err := load(path)
+		if err != nil || data == nil {
+			return err
+		}
+		if data.Counts[kind] == nil {
+			data.Counts[kind] = map[string]int{}
+		}
+		data.Counts[kind][name]++
+		data.UpdatedAt = time.Now().UTC()
+		return save(path, data)
+	})
+	if err != nil {
+		log.Debug().Err(err).Str("kind", kind).Str("name", name).Msg("Failed to record feature usage")
+	}
+}
+
+// Top returns the counts of one kind, most used first.
+func (d *Data) Top(kind string) []Count {
+	var counts []Count
+	for name, n := range d.Counts[kind] {
+		counts = append(counts, Count{Name: name, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+func load(path string) (*Data, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var data Data
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if data.Counts == nil {
+		data.Counts = map[string]map[string]int{}
+	}
+	return &data, nil
+}
+
+func save(path string, data *Data) error {
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(path, append(raw, '\n'), 0600)
+}
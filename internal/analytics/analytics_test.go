@@ -0,0 +1,64 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecordRequiresOptIn(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("DO_NOT_TRACK", "")
+
+	Record(KindCommand, "config status")
+	if data, err := Load(); err != nil || data != nil {
+		t.Fatalf("Load() before opt-in = %+v, %v", data, err)
+	}
+
+	if _, err := Enable(); err != nil {
+		t.Fatal(err)
+	}
+	Record(KindCommand, "config status")
+	Record(KindCommand, "config status")
+	Record(KindCommand, "runtime voice")
+	Record(KindProvider, "openai")
+	Record(KindSource, strings.Repeat("x", maxNameLen+1))
+
+	data, err := Load()
+	if err != nil || data == nil {
+		t.Fatalf("Load() = %+v, %v", data, err)
+	}
+	top := data.Top(KindCommand)
+	if len(top) != 2 || top[0] != (Count{"config status", 2}) || top[1] != (Count{"runtime voice", 1}) {
+		t.Errorf("Top(command) = %+v", top)
+	}
+	if got := data.Top(KindSource); len(got) != 0 {
+		t.Errorf("oversized name recorded: %+v", got)
+	}
+
+	// Enabling again keeps the counts.
+	if again, err := Enable(); err != nil || again.Counts[KindProvider]["openai"] != 1 {
+		t.Errorf("Enable() again = %+v, %v", again, err)
+	}
+
+	t.Setenv("DO_NOT_TRACK", "1")
+	Record(KindProvider, "openai")
+	if data, _ := Load(); data.Counts[KindProvider]["openai"] != 1 {
+		t.Error("DO_NOT_TRACK=1 should stop recording")
+	}
+}
+
+func TestDisableDeletesData(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if was, err := Disable(); err != nil || was {
+		t.Fatalf("Disable() when off = %v, %v", was, err)
+	}
+	if _, err := Enable(); err != nil {
+		t.Fatal(err)
+	}
+	if was, err := Disable(); err != nil || !was {
+		t.Fatalf("Disable() = %v, %v", was, err)
+	}
+	if Enabled() {
+		t.Error("still enabled after Disable")
+	}
+}
@@ -0,0 +1,184 @@
+package avatar
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// Example from RFC 6455, section 1.3.
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 5, 125, 126, 1000} {
+		payload := bytes.Repeat([]byte("x"), size)
+		var buf bytes.Buffer
+		require.NoError(t, writeFrame(&buf, opText, payload))
+		opcode, got, err := readFrame(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, byte(opText), opcode)
+		assert.Equal(t, payload, got, "size %d", size)
+	}
+}
+
+func TestReadFrameMasked(t *testing.T) {
+	mask := []byte{1, 2, 3, 4}
+	payload := []byte("ping")
+	frame := []byte{0x80 | opPing, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	opcode, got, err := readFrame(bytes.NewReader(frame))
+	require.NoError(t, err)
+	assert.Equal(t, byte(opPing), opcode)
+	assert.Equal(t, payload, got)
+
+	_, _, err = readFrame(bytes.NewReader([]byte{0x81, 127, 0, 0, 0, 0, 0, 1, 0, 0}))
+	assert.ErrorIs(t, err, errFrameTooLarge)
+}
+
+func TestVowelViseme(t *testing.T) {
+	assert.Equal(t, VisemeA, VowelViseme("a"))
+	assert.Equal(t, VisemeU, VowelViseme("U"), "devoiced")
+	assert.Equal(t, VisemeClosed, VowelViseme("N"))
+	assert.Equal(t, VisemeClosed, VowelViseme("cl"))
+	assert.Equal(t, VisemeSilence, VowelViseme("pau"))
+
+	assert.Equal(t, VisemeA, PollyViseme("@"))
+	assert.Equal(t, VisemeClosed, PollyViseme("p"))
+	assert.Equal(t, VisemeSilence, PollyViseme("sil"))
+}
+
+func TestEventsURL(t *testing.T) {
+	assert.Equal(t, "http://"+DefaultAddr+"/events", eventsURL(""))
+	assert.Equal(t, "http://localhost:9000/events", eventsURL("localhost:9000"))
+	assert.Equal(t, "https://avatar.lan/events", eventsURL("https://avatar.lan/"))
+}
+
+func TestIsLoopback(t *testing.T) {
+	assert.True(t, isLoopback("127.0.0.1:5000"))
+	assert.True(t, isLoopback("[::1]:5000"))
+	assert.False(t, isLoopback("192.168.1.10:5000"))
+}
+
+func TestAllowedHost(t *testing.T) {
+	assert.True(t, allowedHost("localhost:50090"))
+	assert.True(t, allowedHost("127.0.0.1:50090"))
+	assert.True(t, allowedHost("[::1]:50090"))
+	assert.True(t, allowedHost("192.168.1.10"))
+	assert.False(t, allowedHost("evil.example:50090"))
+	assert.False(t, allowedHost("localhost.evil.example"))
+}
+
+func TestHandlerRejectsOtherSites(t *testing.T) {
+	server := httptest.NewServer(NewHub().Handler())
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name, host, origin string
+		status             int
+	}{
+		{"hook", "", "", http.StatusOK},
+		{"own page", "", "self", http.StatusOK},
+		{"local frontend", "", "http://localhost:5173", http.StatusOK},
+		{"LAN viewer", "192.168.1.10:50090", "http://192.168.1.10:50090", http.StatusOK},
+		{"other site", "", "https://evil.example", http.StatusForbidden},
+		{"sandboxed page", "", "null", http.StatusForbidden},
+		{"DNS rebinding", "evil.example:50090", "http://evil.example:50090", http.StatusForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/", nil)
+			require.NoError(t, err)
+			if tc.host != "" {
+				req.Host = tc.host
+			}
+			if tc.origin == "self" {
+				tc.origin = server.URL
+			}
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+}
+
+// dial connects a WebSocket client to the test server.
+func dial(t *testing.T, server *httptest.Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	_, err = fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: 127.0.0.1\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	require.NoError(t, err)
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+	return conn, reader
+}
+
+func TestHubBroadcast(t *testing.T) {
+	hub := NewHub()
+	server := httptest.NewServer(hub.Handler())
+	defer server.Close()
+
+	conn, reader := dial(t, server)
+	require.Eventually(t, func() bool { return hub.Clients() == 1 }, time.Second, 10*time.Millisecond)
+
+	event := NewEvent(EventSpeechStart)
+	event.Persona = "zundamon"
+	event.Text = "こんにちは"
+	event.Marks = []Mark{{TimeMS: 100, DurationMS: 150, Type: MarkViseme, Value: VisemeO}}
+	require.NoError(t, Publish(context.Background(), server.URL, event))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	opcode, payload, err := readFrame(reader)
+	require.NoError(t, err)
+	assert.Equal(t, byte(opText), opcode)
+	var got Event
+	require.NoError(t, json.Unmarshal(payload, &got))
+	assert.Equal(t, event, got)
+
+	// Pings are answered with the same payload.
+	mask := []byte{9, 8, 7, 6}
+	ping := []byte{0x80 | opPing, 0x80 | 2}
+	ping = append(ping, mask...)
+	ping = append(ping, 'h'^mask[0], 'i'^mask[1])
+	_, err = conn.Write(ping)
+	require.NoError(t, err)
+	opcode, payload, err = readFrame(reader)
+	require.NoError(t, err)
+	assert.Equal(t, byte(opPong), opcode)
+	assert.Equal(t, []byte("hi"), payload)
+}
+
+func TestHubRejectsInvalidEvents(t *testing.T) {
+	server := httptest.NewServer(NewHub().Handler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/events", "application/json", strings.NewReader(`{"type":"dance"}`))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	assert.Error(t, Publish(context.Background(), "127.0.0.1:1", NewEvent(EventSpeechEnd)), "no daemon")
+}
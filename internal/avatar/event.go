@@ -0,0 +1,169 @@
+// Package avatar broadcasts speech events to avatar frontends such as VRM or
+// Live2D viewers, so they can lip-sync the persona while it speaks.
+//
+// `ccpersona runtime avatar serve` runs a small daemon. Hook processes post
+// events to it over HTTP when playback starts and ends, and it relays them to
+// every connected WebSocket client.
+package avatar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAddr is where the daemon listens unless configured otherwise.
+const DefaultAddr = "127.0.0.1:50090"
+
+// Event types.
+const (
+	EventSpeechStart = "speech_start"
+	EventSpeechEnd   = "speech_end"
+)
+
+// Mark types.
+const (
+	MarkSentence = "sentence"
+	MarkViseme   = "viseme"
+	MarkWord     = "word"
+)
+
+// Visemes, named after the VRM mouth blend shapes.
+const (
+	VisemeA       = "aa"
+	VisemeI       = "ih"
+	VisemeU       = "ou"
+	VisemeE       = "ee"
+	VisemeO       = "oh"
+	VisemeClosed  = "nn"
+	VisemeSilence = "sil"
+)
+
+// publishTimeout bounds how long speech waits for the daemon.
+const publishTimeout = 500 * time.Millisecond
+
+// Event is one message to avatar frontends. Marks are timed from the start
+// of playback and are empty when the provider supplies no timing; frontends
+// then fall back to audio amplitude or a generic mouth animation.
+type Event struct {
+	Type       string `json:"type"`
+	Persona    string `json:"persona,omitempty"`
+	Text       string `json:"text,omitempty"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+	Marks      []Mark `json:"marks,omitempty"`
+	Time       string `json:"time"`
+}
+
+// Mark is a timed viseme or word within the audio.
+type Mark struct {
+	TimeMS     int64  `json:"time_ms"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	// Phoneme is the provider's unit for the mark (a VOICEVOX mora, a Polly
+	// phoneme), for frontends with their own mapping.
+	Phoneme string `json:"phoneme,omitempty"`
+}
+
+// NewEvent returns an event of the given type stamped with the current time.
+func NewEvent(eventType string) Event {
+	return Event{Type: eventType, Time: time.Now().UTC().Format(time.RFC3339Nano)}
+}
+
+// Validate checks that an event posted to the daemon is well formed.
+func (e Event) Validate() error {
+	switch e.Type {
+	case EventSpeechStart, EventSpeechEnd:
+	default:
+		return fmt.Errorf("unknown event type %q", e.Type)
+	}
+	for _, mark := range e.Marks {
+		if mark.TimeMS < 0 || mark.DurationMS < 0 {
+			return fmt.Errorf("mark times must not be negative")
+		}
+	}
+	return nil
+}
+
+// VowelViseme maps a Japanese vowel, as used by VOICEVOX moras ("a", "I",
+// "N", "cl", "pau"), to a viseme. Devoiced vowels are uppercase.
+func VowelViseme(vowel string) string {
+	switch strings.ToLower(vowel) {
+	case "a":
+		return VisemeA
+	case "i":
+		return VisemeI
+	case "u":
+		return VisemeU
+	case "e":
+		return VisemeE
+	case "o":
+		return VisemeO
+	case "n", "cl":
+		return VisemeClosed
+	default:
+		return VisemeSilence
+	}
+}
+
+// PollyViseme maps an Amazon Polly viseme code to a viseme. Consonants take
+// the mouth shape they are usually animated with.
+func PollyViseme(code string) string {
+	switch code {
+	case "a", "@":
+		return VisemeA
+	case "i", "t", "T", "k", "s":
+		return VisemeI
+	case "u", "S", "r":
+		return VisemeU
+	case "e", "E":
+		return VisemeE
+	case "o", "O":
+		return VisemeO
+	case "p", "f":
+		return VisemeClosed
+	default:
+		return VisemeSilence
+	}
+}
+
+// Publish posts an event to the daemon at addr ("host:port" or an http URL).
+// It gives up quickly so speech is never held up by a missing daemon.
+func Publish(ctx context.Context, addr string, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, eventsURL(addr), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("avatar daemon unreachable: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("avatar daemon returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func eventsURL(addr string) string {
+	if addr == "" {
+		addr = DefaultAddr
+	}
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return strings.TrimSuffix(addr, "/") + "/events"
+}
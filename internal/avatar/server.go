@@ -0,0 +1,236 @@
+package avatar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// clientBuffer is how many messages may queue for a client before it is
+// dropped as too slow.
+const clientBuffer = 32
+
+// maxEventSize caps events posted by hook processes.
+const maxEventSize = 1 << 20
+
+// Hub relays events to connected WebSocket clients.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*client]struct{}
+}
+
+type client struct {
+	conn net.Conn
+	send chan frame
+	once sync.Once
+}
+
+type frame struct {
+	opcode  byte
+	payload []byte
+}
+
+func (c *client) close() {
+	c.once.Do(func() {
+		close(c.send)
+		_ = c.conn.Close()
+	})
+}
+
+// NewHub creates a hub without clients.
+func NewHub() *Hub {
+	return &Hub{clients: map[*client]struct{}{}}
+}
+
+// Clients returns the number of connected clients.
+func (h *Hub) Clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Broadcast sends an event to every client. Clients whose queue is full are
+// disconnected instead of delaying the others.
+func (h *Hub) Broadcast(event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		select {
+		case c.send <- frame{opText, data}:
+		default:
+			log.Debug().Msg("Dropping slow avatar client")
+			delete(h.clients, c)
+			c.close()
+		}
+	}
+}
+
+func (h *Hub) add(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = struct{}{}
+}
+
+func (h *Hub) remove(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		c.close()
+	}
+}
+
+// Handler serves WebSocket clients on any path and accepts events posted to
+// /events. Events are only accepted from the local machine, so binding the
+// daemon to a LAN address for a remote viewer does not let others drive it.
+// Requests from web pages of other sites, directly or through DNS rebinding,
+// are rejected (see allowedHost and allowedOrigin).
+func (h *Hub) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", h.handleEvent)
+	mux.HandleFunc("/", h.handleClient)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowedHost(r.Host) {
+			http.Error(w, "the Host must be localhost or an IP address", http.StatusForbidden)
+			return
+		}
+		if !allowedOrigin(r) {
+			http.Error(w, "cross-origin requests are not accepted", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (h *Hub) handleEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isLoopback(r.RemoteAddr) {
+		http.Error(w, "events are only accepted from localhost", http.StatusForbidden)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxEventSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		http.Error(w, fmt.Sprintf("invalid event: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := event.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.Broadcast(event)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Hub) handleClient(w http.ResponseWriter, r *http.Request) {
+	if !isWebSocketUpgrade(r) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprintf(w, "ccpersona avatar bridge: connect with a WebSocket client (%d connected)\n", h.Clients())
+		return
+	}
+	conn, rw, err := upgrade(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c := &client{conn: conn, send: make(chan frame, clientBuffer)}
+	h.add(c)
+	log.Debug().Str("remote", r.RemoteAddr).Msg("Avatar client connected")
+
+	go func() {
+		for f := range c.send {
+			if err := writeFrame(conn, f.opcode, f.payload); err != nil {
+				h.remove(c)
+				return
+			}
+		}
+	}()
+
+	// Read until the client leaves. Pongs go through the send queue so that
+	// only the writer goroutine writes to the connection.
+	defer h.remove(c)
+	for {
+		opcode, payload, err := readFrame(rw)
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case opClose:
+			return
+		case opPing:
+			h.enqueue(c, frame{opPong, payload})
+		}
+	}
+}
+
+// enqueue queues a frame for a client that is still connected.
+func (h *Hub) enqueue(c *client, f frame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	select {
+	case c.send <- f:
+	default:
+	}
+}
+
+// allowedHost reports whether a request was addressed to localhost or an IP
+// address. A page can point a DNS name of its own at this machine, but not
+// make the browser send a Host it does not own, so this defeats DNS rebinding
+// while a viewer on the LAN can still connect to the daemon's IP address.
+func allowedHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	return host == "localhost" || net.ParseIP(host) != nil
+}
+
+// allowedOrigin reports whether a request comes from a page served by this
+// daemon or by the local machine, such as a frontend's development server.
+// Browsers send Origin with every WebSocket handshake and cross-origin POST;
+// other clients, like the hooks posting events, send none.
+func allowedOrigin(r *http.Request) bool {
+	header := r.Header.Get("Origin")
+	if header == "" {
+		return true
+	}
+	origin, err := url.Parse(header)
+	if err != nil || origin.Host == "" {
+		return false
+	}
+	if origin.Host == r.Host {
+		return true
+	}
+	host := origin.Hostname()
+	ip := net.ParseIP(host)
+	return host == "localhost" || (ip != nil && ip.IsLoopback())
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,137 @@
+// Package ci watches GitHub check runs for a branch and reports status
+// transitions.
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the GitHub REST API endpoint.
+const DefaultBaseURL = "https://api.github.com"
+
+// CheckRun is the subset of the GitHub check run object used for status
+// aggregation.
+type CheckRun struct {
+	Name       string `json:"name"`
+	HeadSHA    string `json:"head_sha"`
+	Status     string `json:"status"`     // queued, in_progress, completed, ...
+	Conclusion string `json:"conclusion"` // success, failure, cancelled, ... (empty until completed)
+}
+
+// Client fetches check runs from the GitHub API.
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client for the public GitHub API.
+func NewClient(token string) *Client {
+	return &Client{
+		BaseURL:    DefaultBaseURL,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// CheckRuns returns the check runs for ref (a branch name or commit SHA) in
+// repo ("owner/name").
+func (c *Client) CheckRuns(ctx context.Context, repo, ref string) ([]CheckRun, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/commits/%s/check-runs?per_page=100",
+		strings.TrimRight(c.BaseURL, "/"), repo, url.PathEscape(ref))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch check runs: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API returned %s for %s@%s", resp.Status, repo, ref)
+	}
+
+	var body struct {
+		CheckRuns []CheckRun `json:"check_runs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode check runs: %w", err)
+	}
+	return body.CheckRuns, nil
+}
+
+// Token returns a GitHub token from GITHUB_TOKEN, GH_TOKEN, or the gh CLI.
+// An empty token is valid for public repositories but heavily rate limited.
+func Token(ctx context.Context) string {
+	for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		return ""
+	}
+	out, err := exec.CommandContext(ctx, "gh", "auth", "token").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+var remotePattern = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// ParseRemote extracts "owner/name" from a GitHub remote URL in HTTPS, SSH,
+// or scp-like form.
+func ParseRemote(remote string) (string, error) {
+	m := remotePattern.FindStringSubmatch(strings.TrimSpace(remote))
+	if m == nil {
+		return "", fmt.Errorf("not a GitHub remote: %s", remote)
+	}
+	return m[1] + "/" + m[2], nil
+}
+
+// DetectRepo returns the GitHub repository for the origin remote of the
+// repository at dir.
+func DetectRepo(ctx context.Context, dir string) (string, error) {
+	out, err := gitOutput(ctx, dir, "remote", "get-url", "origin")
+	if err != nil {
+		return "", fmt.Errorf("failed to read origin remote (use --repo owner/name): %w", err)
+	}
+	return ParseRemote(out)
+}
+
+// DetectBranch returns the current branch of the repository at dir.
+func DetectBranch(ctx context.Context, dir string) (string, error) {
+	out, err := gitOutput(ctx, dir, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to detect current branch (use --branch): %w", err)
+	}
+	return out, nil
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
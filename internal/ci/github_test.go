@@ -0,0 +1,57 @@
+package ci
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:daikw/ccpersona.git":       "daikw/ccpersona",
+		"https://github.com/daikw/ccpersona":       "daikw/ccpersona",
+		"https://github.com/daikw/ccpersona.git":   "daikw/ccpersona",
+		"ssh://git@github.com/daikw/ccpersona.git": "daikw/ccpersona",
+		"https://github.com/daikw/my.repo.git\n":   "daikw/my.repo",
+	}
+	for remote, want := range tests {
+		got, err := ParseRemote(remote)
+		require.NoError(t, err, remote)
+		assert.Equal(t, want, got, remote)
+	}
+
+	_, err := ParseRemote("git@gitlab.com:daikw/ccpersona.git")
+	assert.Error(t, err)
+}
+
+func TestClient_CheckRuns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/daikw/ccpersona/commits/feature%2Fx/check-runs", r.URL.EscapedPath())
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"total_count":1,"check_runs":[{"name":"test","head_sha":"abc","status":"completed","conclusion":"success"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("secret")
+	client.BaseURL = server.URL
+	runs, err := client.CheckRuns(context.Background(), "daikw/ccpersona", "feature/x")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, CheckRun{Name: "test", HeadSHA: "abc", Status: "completed", Conclusion: "success"}, runs[0])
+}
+
+func TestClient_CheckRunsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient("")
+	client.BaseURL = server.URL
+	_, err := client.CheckRuns(context.Background(), "daikw/missing", "main")
+	assert.ErrorContains(t, err, "404")
+}
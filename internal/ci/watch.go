@@ -0,0 +1,139 @@
+package ci
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// State is the aggregated status of all check runs on a commit.
+type State string
+
+const (
+	StateNone      State = "none" // no check runs reported yet
+	StatePending   State = "pending"
+	StateSucceeded State = "succeeded"
+	StateFailed    State = "failed"
+)
+
+// IsTerminal reports whether no further transitions are expected for the
+// commit.
+func (s State) IsTerminal() bool {
+	return s == StateSucceeded || s == StateFailed
+}
+
+// Status summarizes the check runs for one commit.
+type Status struct {
+	SHA    string
+	State  State
+	Total  int
+	Failed []string // names of failed check runs, sorted
+}
+
+// failingConclusions are check run conclusions that fail the commit.
+var failingConclusions = map[string]bool{
+	"failure":         true,
+	"cancelled":       true,
+	"timed_out":       true,
+	"action_required": true,
+	"startup_failure": true,
+}
+
+// Summarize aggregates check runs into a single status. Any failed run fails
+// the commit once every run has completed; until then the commit is pending.
+func Summarize(runs []CheckRun) Status {
+	status := Status{State: StateNone, Total: len(runs)}
+	if len(runs) == 0 {
+		return status
+	}
+	status.SHA = runs[0].HeadSHA
+
+	pending := false
+	for _, run := range runs {
+		if run.Status != "completed" {
+			pending = true
+			continue
+		}
+		if failingConclusions[run.Conclusion] {
+			status.Failed = append(status.Failed, run.Name)
+		}
+	}
+	sort.Strings(status.Failed)
+
+	switch {
+	case pending:
+		status.State = StatePending
+	case len(status.Failed) > 0:
+		status.State = StateFailed
+	default:
+		status.State = StateSucceeded
+	}
+	return status
+}
+
+// Message renders a short announcement for status on branch.
+func Message(branch string, status Status) string {
+	switch status.State {
+	case StatePending:
+		return fmt.Sprintf("CI started on %s", branch)
+	case StateSucceeded:
+		return fmt.Sprintf("CI succeeded on %s", branch)
+	case StateFailed:
+		return fmt.Sprintf("CI failed on %s: %s", branch, strings.Join(status.Failed, ", "))
+	default:
+		return fmt.Sprintf("No CI checks on %s", branch)
+	}
+}
+
+// FetchFunc returns the current check runs.
+type FetchFunc func(ctx context.Context) ([]CheckRun, error)
+
+// Watcher polls check runs and calls OnChange whenever the aggregated status
+// changes. A new head commit always counts as a change.
+type Watcher struct {
+	Fetch    FetchFunc
+	Interval time.Duration
+	// OnChange receives the previous and current status. prev is nil for the
+	// first successful poll.
+	OnChange func(prev *Status, cur Status)
+	// OnError is called for failed polls; polling continues afterwards.
+	OnError func(err error)
+	// UntilComplete stops the watcher after the first terminal status.
+	UntilComplete bool
+}
+
+// Run polls until ctx is cancelled or, with UntilComplete, a terminal status
+// is reached.
+func (w *Watcher) Run(ctx context.Context) error {
+	var last *Status
+	for {
+		runs, err := w.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if w.OnError != nil {
+				w.OnError(err)
+			}
+		} else {
+			cur := Summarize(runs)
+			if last == nil || last.SHA != cur.SHA || last.State != cur.State {
+				if w.OnChange != nil {
+					w.OnChange(last, cur)
+				}
+			}
+			last = &cur
+			if w.UntilComplete && cur.State.IsTerminal() {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.Interval):
+		}
+	}
+}
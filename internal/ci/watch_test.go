@@ -0,0 +1,84 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	assert.Equal(t, StateNone, Summarize(nil).State)
+
+	pending := Summarize([]CheckRun{
+		{Name: "lint", HeadSHA: "a", Status: "completed", Conclusion: "failure"},
+		{Name: "test", HeadSHA: "a", Status: "in_progress"},
+	})
+	assert.Equal(t, StatePending, pending.State)
+	assert.Equal(t, "a", pending.SHA)
+
+	failed := Summarize([]CheckRun{
+		{Name: "test", HeadSHA: "a", Status: "completed", Conclusion: "timed_out"},
+		{Name: "lint", HeadSHA: "a", Status: "completed", Conclusion: "failure"},
+		{Name: "docs", HeadSHA: "a", Status: "completed", Conclusion: "skipped"},
+	})
+	assert.Equal(t, StateFailed, failed.State)
+	assert.Equal(t, []string{"lint", "test"}, failed.Failed)
+	assert.Equal(t, "CI failed on main: lint, test", Message("main", failed))
+
+	ok := Summarize([]CheckRun{{Name: "test", HeadSHA: "a", Status: "completed", Conclusion: "success"}})
+	assert.Equal(t, StateSucceeded, ok.State)
+	assert.Equal(t, "CI succeeded on main", Message("main", ok))
+}
+
+func TestWatcher_ReportsTransitions(t *testing.T) {
+	polls := [][]CheckRun{
+		{{Name: "test", HeadSHA: "a", Status: "queued"}},
+		{{Name: "test", HeadSHA: "a", Status: "in_progress"}}, // still pending: no change
+		nil, // error poll
+		{{Name: "test", HeadSHA: "a", Status: "completed", Conclusion: "failure"}},
+	}
+	i := 0
+	var states []State
+	var errs int
+
+	w := &Watcher{
+		Interval: time.Millisecond,
+		Fetch: func(ctx context.Context) ([]CheckRun, error) {
+			runs := polls[i]
+			i++
+			if runs == nil {
+				return nil, errors.New("boom")
+			}
+			return runs, nil
+		},
+		OnChange: func(prev *Status, cur Status) {
+			if len(states) == 0 {
+				assert.Nil(t, prev)
+			}
+			states = append(states, cur.State)
+		},
+		OnError:       func(error) { errs++ },
+		UntilComplete: true,
+	}
+
+	require.NoError(t, w.Run(context.Background()))
+	assert.Equal(t, []State{StatePending, StateFailed}, states)
+	assert.Equal(t, 1, errs)
+	assert.Equal(t, 4, i)
+}
+
+func TestWatcher_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{
+		Interval: time.Hour,
+		Fetch: func(context.Context) ([]CheckRun, error) {
+			cancel()
+			return nil, nil
+		},
+	}
+	require.NoError(t, w.Run(ctx))
+}
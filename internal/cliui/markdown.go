@@ -0,0 +1,114 @@
+package cliui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var (
+	mdTitle   = color.New(color.FgMagenta, color.Bold).SprintFunc()
+	mdSection = color.New(color.FgCyan, color.Bold).SprintFunc()
+	mdBold    = color.New(color.Bold).SprintFunc()
+	mdItalic  = color.New(color.Italic).SprintFunc()
+	mdCode    = color.New(color.FgYellow).SprintFunc()
+	mdString  = color.New(color.FgGreen).SprintFunc()
+
+	mdInline     = regexp.MustCompile("`[^`]+`|\\*\\*[^*]+\\*\\*|__[^_]+__|\\*[^*\\s][^*]*\\*")
+	mdHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	mdListItem   = regexp.MustCompile(`^(\s*)[-*+][ \t]+(.*)$`)
+	mdOrdered    = regexp.MustCompile(`^(\s*)(\d+[.)])[ \t]+(.*)$`)
+	mdRule       = regexp.MustCompile(`^ {0,3}([-*_])([ \t]*[-*_]){2,}[ \t]*$`)
+	mdCodeString = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'`)
+)
+
+// RenderMarkdown formats markdown for reading in a terminal: styled headings,
+// bullets, emphasis and inline code, quoted blocks, and lightly highlighted
+// code blocks. Leading YAML front matter is shown de-emphasized. Colors
+// follow the usual fatih/color rules, so piped output stays plain.
+func RenderMarkdown(md string) string {
+	var b strings.Builder
+	inFence, inFrontMatter := false, false
+	for i, line := range strings.Split(strings.TrimRight(md, "\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case i == 0 && trimmed == "---":
+			inFrontMatter = true
+			b.WriteString(Muted(line))
+		case inFrontMatter:
+			if trimmed == "---" || trimmed == "..." {
+				inFrontMatter = false
+			}
+			b.WriteString(Muted(line))
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			inFence = !inFence
+			if !inFence {
+				b.WriteString(Muted("  └"))
+			} else if lang := strings.Trim(trimmed, "`~ "); lang != "" {
+				b.WriteString(Muted("  ┌ " + lang))
+			} else {
+				b.WriteString(Muted("  ┌"))
+			}
+		case inFence:
+			b.WriteString(Muted("  │ ") + highlightCode(line))
+		default:
+			b.WriteString(renderLine(line))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func renderLine(line string) string {
+	if m := mdHeading.FindStringSubmatch(line); m != nil {
+		title := renderInline(m[2])
+		switch len(m[1]) {
+		case 1:
+			return mdTitle(title)
+		case 2:
+			return mdSection("▍" + title)
+		default:
+			return Header(title)
+		}
+	}
+	if mdRule.MatchString(line) {
+		return Muted(strings.Repeat("─", 40))
+	}
+	if m := mdListItem.FindStringSubmatch(line); m != nil {
+		return m[1] + "  • " + renderInline(m[2])
+	}
+	if m := mdOrdered.FindStringSubmatch(line); m != nil {
+		return m[1] + "  " + m[2] + " " + renderInline(m[3])
+	}
+	if trimmed := strings.TrimLeft(line, " "); strings.HasPrefix(trimmed, ">") {
+		return Muted("  │ ") + mdItalic(renderInline(strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))))
+	}
+	return renderInline(line)
+}
+
+func renderInline(text string) string {
+	return mdInline.ReplaceAllStringFunc(text, func(m string) string {
+		switch {
+		case strings.HasPrefix(m, "`"):
+			return mdCode(strings.Trim(m, "`"))
+		case strings.HasPrefix(m, "**"), strings.HasPrefix(m, "__"):
+			return mdBold(m[2 : len(m)-2])
+		default:
+			return mdItalic(m[1 : len(m)-1])
+		}
+	})
+}
+
+// highlightCode dims line comments and colors string literals, which covers
+// the shell, JSON, YAML, and Go snippets personas usually contain.
+func highlightCode(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "#") {
+		return Muted(line)
+	}
+	return mdCodeString.ReplaceAllStringFunc(line, func(s string) string {
+		return mdString(s)
+	})
+}
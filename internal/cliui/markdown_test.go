@@ -0,0 +1,48 @@
+package cliui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestRenderMarkdownPlain(t *testing.T) {
+	old := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = old })
+
+	md := "---\ndescription: test\n---\n# 人格\n\n## 口調 ##\n- **大切**な `code`\n1. first\n> quoted *note*\n***\n```go\nx := \"s\" // c\n```\n"
+	want := strings.Join([]string{
+		"---",
+		"description: test",
+		"---",
+		"人格",
+		"",
+		"▍口調",
+		"  • 大切な code",
+		"  1. first",
+		"  │ quoted note",
+		strings.Repeat("─", 40),
+		"  ┌ go",
+		"  │ x := \"s\" // c",
+		"  └",
+	}, "\n") + "\n"
+	if got := RenderMarkdown(md); got != want {
+		t.Errorf("RenderMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderMarkdownColor(t *testing.T) {
+	old := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = old })
+
+	got := RenderMarkdown("## 口調\n```\n# comment\n```\n")
+	if !strings.Contains(got, "\x1b[") {
+		t.Errorf("expected ANSI styling, got %q", got)
+	}
+	if strings.Contains(got, "##") {
+		t.Errorf("heading markers should be rendered away: %q", got)
+	}
+}
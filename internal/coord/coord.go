@@ -0,0 +1,133 @@
+// Package coord coordinates ccpersona processes working on the same project,
+// such as Claude Code and Cursor firing hooks at once, or a hook speaking
+// while the user switches persona.
+//
+// A claim holds one resource of a project (its audio or its persona files)
+// across processes. Interactive commands take precedence over hooks: a hook
+// waits only briefly and gives way while a command is waiting or working,
+// whereas a command waits for the hook in progress to finish.
+package coord
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// Priority orders claims on the same resource.
+type Priority int
+
+const (
+	// Hook is a hook invocation; it waits HookWait, then the caller skips
+	// or proceeds uncoordinated.
+	Hook Priority = iota
+	// Interactive is a command the user ran; hooks give way to it.
+	Interactive
+)
+
+// Resource is what a claim holds within a project.
+type Resource string
+
+const (
+	// Audio is the project's speech: one voice at a time.
+	Audio Resource = "audio"
+	// Persona is the project's persona config and generated agent files.
+	Persona Resource = "persona"
+)
+
+var (
+	// HookWait bounds how long a hook waits for a resource.
+	HookWait = 1500 * time.Millisecond
+	// InteractiveWait bounds how long a command waits for a hook to finish.
+	InteractiveWait = 15 * time.Second
+)
+
+// ErrBusy is returned when the resource stayed claimed for the whole wait.
+var ErrBusy = errors.New("another ccpersona process is busy with this project")
+
+// pollInterval is how often a hook checks a busy resource again.
+const pollInterval = 50 * time.Millisecond
+
+// Claim is a held resource; Release it when done.
+type Claim struct {
+	release []func()
+}
+
+// Release gives the resource up. It is safe to call more than once and on a
+// nil claim.
+func (c *Claim) Release() {
+	if c == nil {
+		return
+	}
+	for i := len(c.release) - 1; i >= 0; i-- {
+		c.release[i]()
+	}
+	c.release = nil
+}
+
+// Acquire claims resource of the project directory with the given priority.
+// It returns ErrBusy when the resource did not become free in time.
+func Acquire(project string, resource Resource, priority Priority) (*Claim, error) {
+	abs, err := filepath.Abs(project)
+	if err != nil {
+		return nil, err
+	}
+	// The keys name no real file; fsutil keeps lock files in the temp
+	// directory, keyed by path.
+	key := filepath.Join(abs, ".ccpersona", "coord", string(resource))
+	if priority == Interactive {
+		return acquireInteractive(key)
+	}
+	return acquireHook(key)
+}
+
+// acquireInteractive announces the command on the priority lock, so hooks
+// stop competing, then waits for the resource. Commands queue on the
+// priority lock among themselves.
+func acquireInteractive(key string) (*Claim, error) {
+	deadline := time.Now().Add(InteractiveWait)
+	priority, err := fsutil.Lock(key+".priority", InteractiveWait)
+	if err != nil {
+		return nil, busy(err)
+	}
+	held, err := fsutil.Lock(key, time.Until(deadline))
+	if err != nil {
+		priority()
+		return nil, busy(err)
+	}
+	return &Claim{release: []func(){priority, held}}, nil
+}
+
+// acquireHook takes the resource once it is free and no command is waiting
+// for it, checking until HookWait has passed.
+func acquireHook(key string) (*Claim, error) {
+	deadline := time.Now().Add(HookWait)
+	for {
+		if free, err := fsutil.Lock(key+".priority", 0); err == nil {
+			free()
+			held, err := fsutil.Lock(key, 0)
+			if err == nil {
+				return &Claim{release: []func(){held}}, nil
+			}
+			if !errors.Is(err, fsutil.ErrLockTimeout) {
+				return nil, err
+			}
+		} else if !errors.Is(err, fsutil.ErrLockTimeout) {
+			return nil, err
+		}
+		if !time.Now().Before(deadline) {
+			return nil, ErrBusy
+		}
+		time.Sleep(min(pollInterval, time.Until(deadline)))
+	}
+}
+
+func busy(err error) error {
+	if errors.Is(err, fsutil.ErrLockTimeout) {
+		return ErrBusy
+	}
+	return fmt.Errorf("failed to claim the project: %w", err)
+}
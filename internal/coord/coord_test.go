@@ -0,0 +1,170 @@
+package coord
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setWaits(t *testing.T, hook, interactive time.Duration) {
+	t.Helper()
+	oldHook, oldInteractive := HookWait, InteractiveWait
+	HookWait, InteractiveWait = hook, interactive
+	t.Cleanup(func() { HookWait, InteractiveWait = oldHook, oldInteractive })
+}
+
+func mustAcquire(t *testing.T, project string, resource Resource, priority Priority) *Claim {
+	t.Helper()
+	claim, err := Acquire(project, resource, priority)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	return claim
+}
+
+func TestHookSkipsWhileResourceBusy(t *testing.T) {
+	setWaits(t, 100*time.Millisecond, time.Second)
+	project := t.TempDir()
+
+	held := mustAcquire(t, project, Audio, Hook)
+	start := time.Now()
+	if _, err := Acquire(project, Audio, Hook); !errors.Is(err, ErrBusy) {
+		t.Fatalf("err = %v, want ErrBusy while another hook speaks", err)
+	}
+	if waited := time.Since(start); waited < HookWait {
+		t.Errorf("gave up after %v, want a wait of %v", waited, HookWait)
+	}
+
+	other := mustAcquire(t, project, Persona, Hook)
+	other.Release()
+	held.Release()
+	held.Release()
+	mustAcquire(t, project, Audio, Hook).Release()
+}
+
+func TestInteractiveWaitsForHook(t *testing.T) {
+	setWaits(t, 100*time.Millisecond, 5*time.Second)
+	project := t.TempDir()
+
+	hook := mustAcquire(t, project, Audio, Hook)
+	time.AfterFunc(150*time.Millisecond, hook.Release)
+	start := time.Now()
+	claim := mustAcquire(t, project, Audio, Interactive)
+	defer claim.Release()
+	if waited := time.Since(start); waited < 100*time.Millisecond {
+		t.Errorf("interactive claim taken after %v while the hook still held it", waited)
+	}
+}
+
+func TestHookGivesWayToWaitingCommand(t *testing.T) {
+	setWaits(t, 300*time.Millisecond, 5*time.Second)
+	project := t.TempDir()
+
+	first := mustAcquire(t, project, Audio, Hook)
+	acquired := make(chan *Claim)
+	go func() {
+		claim, err := Acquire(project, Audio, Interactive)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- claim
+	}()
+	// Let the command queue up, then finish the first hook while a second
+	// hook competes: the command must win.
+	time.Sleep(50 * time.Millisecond)
+	time.AfterFunc(50*time.Millisecond, first.Release)
+	if _, err := Acquire(project, Audio, Hook); !errors.Is(err, ErrBusy) {
+		t.Fatalf("err = %v, want the hook to skip for the waiting command", err)
+	}
+	(<-acquired).Release()
+}
+
+func TestConcurrentHooksNeverOverlap(t *testing.T) {
+	setWaits(t, 5*time.Second, 5*time.Second)
+	project := t.TempDir()
+
+	var active, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			priority := Hook
+			if i%4 == 0 {
+				priority = Interactive
+			}
+			claim, err := Acquire(project, Audio, priority)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer claim.Release()
+			n := active.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			active.Add(-1)
+		}()
+	}
+	wg.Wait()
+	if peak.Load() != 1 {
+		t.Errorf("%d claims were held at once, want 1", peak.Load())
+	}
+}
+
+// TestHelperProcess holds an interactive claim as a separate ccpersona
+// process would, for TestClaimAcrossProcesses.
+func TestHelperProcess(t *testing.T) {
+	project := os.Getenv("COORD_TEST_PROJECT")
+	if project == "" {
+		return
+	}
+	claim, err := Acquire(project, Audio, Interactive)
+	if err != nil {
+		os.Exit(1)
+	}
+	_ = os.WriteFile(filepath.Join(project, "held"), nil, 0o644)
+	time.Sleep(300 * time.Millisecond)
+	claim.Release()
+	os.Exit(0)
+}
+
+func TestClaimAcrossProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts a child process")
+	}
+	setWaits(t, 100*time.Millisecond, 10*time.Second)
+	project := t.TempDir()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), "COORD_TEST_PROJECT="+project)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cmd.Wait() }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(project, "held")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("helper process never claimed the project")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := Acquire(project, Audio, Hook); !errors.Is(err, ErrBusy) {
+		t.Fatalf("err = %v, want the hook to skip while the command speaks", err)
+	}
+	mustAcquire(t, project, Audio, Interactive).Release()
+}
@@ -0,0 +1,192 @@
+// Package corpus archives raw hook payloads, redacted, in a directory per
+// source and event, so format changes in supported tools can be replayed
+// against the parser and turned into regression tests. Recording is opt-in.
+package corpus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// DefaultMaxPerEvent is how many payloads are kept per source and event;
+// the oldest are dropped first.
+const DefaultMaxPerEvent = 50
+
+// UnknownSource is the directory for payloads no parser recognized.
+const UnknownSource = "unknown"
+
+// settingsFile marks the corpus as recording; its presence is the consent.
+const settingsFile = "recording.json"
+
+// Settings are chosen when recording is turned on.
+type Settings struct {
+	EnabledAt time.Time `json:"enabled_at"`
+	// KeepText keeps prompts, responses, and tool input instead of replacing
+	// them with their length. Secrets and home paths are scrubbed either way.
+	KeepText    bool `json:"keep_text,omitempty"`
+	MaxPerEvent int  `json:"max_per_event,omitempty"`
+}
+
+// Limit returns MaxPerEvent or the default.
+func (s *Settings) Limit() int {
+	if s.MaxPerEvent > 0 {
+		return s.MaxPerEvent
+	}
+	return DefaultMaxPerEvent
+}
+
+// DefaultDir returns ~/.agents/ccpersona/corpus.
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".agents", "ccpersona", "corpus"), nil
+}
+
+// Load returns the recording settings, or nil when recording is off.
+func Load(dir string) (*Settings, error) {
+	data, err := os.ReadFile(filepath.Join(dir, settingsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", settingsFile, err)
+	}
+	return &s, nil
+}
+
+// Enable turns recording on with settings.
+func Enable(dir string, s Settings) error {
+	if s.EnabledAt.IsZero() {
+		s.EnabledAt = time.Now().UTC()
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create corpus directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(filepath.Join(dir, settingsFile), append(data, '\n'), 0600)
+}
+
+// Disable turns recording off and keeps the corpus. It reports whether
+// recording was on.
+func Disable(dir string) (bool, error) {
+	err := os.Remove(filepath.Join(dir, settingsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Entry is one archived payload.
+type Entry struct {
+	Source string
+	Event  string
+	Path   string
+}
+
+// Save redacts payload and archives it under dir/<source>/<event>/. An
+// identical redacted payload is stored once. It returns the file path, or ""
+// when the payload was a duplicate.
+func Save(dir string, s *Settings, source, event string, payload []byte) (string, error) {
+	redacted, err := Redact(payload, s.KeepText)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(redacted)
+	hash := hex.EncodeToString(sum[:])[:12]
+	eventDir := filepath.Join(dir, safeName(source, UnknownSource), safeName(event, "unknown"))
+	if err := os.MkdirAll(eventDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create corpus directory: %w", err)
+	}
+
+	var path string
+	err = fsutil.WithLock(eventDir, func() error {
+		existing, err := filepath.Glob(filepath.Join(eventDir, "*.json"))
+		if err != nil {
+			return err
+		}
+		for _, f := range existing {
+			if strings.HasSuffix(f, "-"+hash+".json") {
+				return nil
+			}
+		}
+		// Names sort by time, so the oldest come first.
+		sort.Strings(existing)
+		for len(existing) >= s.Limit() {
+			if err := os.Remove(existing[0]); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			existing = existing[1:]
+		}
+		path = filepath.Join(eventDir, time.Now().UTC().Format("20060102T150405.000000000")+"-"+hash+".json")
+		return fsutil.WriteFile(path, redacted, 0600)
+	})
+	return path, err
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// safeName turns a source or event name into a directory name.
+func safeName(name, fallback string) string {
+	name = strings.Trim(unsafeChars.ReplaceAllString(name, "_"), "._")
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// List returns the archived payloads in dir, by source, event, and time.
+func List(dir string) ([]Entry, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	entries := make([]Entry, 0, len(files))
+	for _, f := range files {
+		event := filepath.Dir(f)
+		entries = append(entries, Entry{
+			Source: filepath.Base(filepath.Dir(event)),
+			Event:  filepath.Base(event),
+			Path:   f,
+		})
+	}
+	return entries, nil
+}
+
+// Clear deletes the archived payloads and keeps the recording settings.
+func Clear(dir string) (int, error) {
+	entries, err := List(dir)
+	if err != nil {
+		return 0, err
+	}
+	sources := map[string]bool{}
+	for _, e := range entries {
+		sources[filepath.Join(dir, e.Source)] = true
+	}
+	for source := range sources {
+		if err := os.RemoveAll(source); err != nil {
+			return 0, err
+		}
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,129 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/scrub"
+)
+
+// structuralFields are the top-level fields holding identifiers, event and
+// tool names, and the working directory: what a parser needs to recognize a
+// payload. Every other string, including everything nested under another
+// field, is replaced with its length unless text is kept, so fields a tool
+// adds later are redacted too.
+// Field names from every supported source are listed together since their
+// shapes do not collide.
+var structuralFields = map[string]bool{
+	"session_id":        true,
+	"conversation_id":   true,
+	"generation_id":     true,
+	"execution_id":      true,
+	"trajectory_id":     true,
+	"thread-id":         true,
+	"turn-id":           true,
+	"turn_id":           true,
+	"tool_use_id":       true,
+	"hook_event_name":   true,
+	"agent_action_name": true,
+	"event":             true,
+	"event_type":        true,
+	"type":              true,
+	"source":            true,
+	"status":            true,
+	"agent_type":        true,
+	"permission_mode":   true,
+	"tool_name":         true,
+	"model":             true,
+	"cursor_version":    true,
+	"version":           true,
+	"timestamp":         true,
+	"cwd":               true,
+	"transcript_path":   true,
+	"workspace_roots":   true,
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// placeholderHome replaces the user's home directory in paths.
+const placeholderHome = "/home/user"
+
+// Redact returns payload as indented JSON with secrets, email addresses,
+// and the home directory replaced. Unless keepText is set, every string
+// outside structuralFields is replaced with its length so the shape survives
+// without the content. A payload that is not JSON is rejected rather than
+// stored verbatim.
+func Redact(payload []byte, keepText bool) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("payload is not JSON: %w", err)
+	}
+	home, _ := os.UserHomeDir()
+	r := redactor{home: home, keepText: keepText}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.payload(v)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+type redactor struct {
+	home     string
+	keepText bool
+}
+
+// payload redacts a whole payload, keeping the text of its structural
+// fields.
+func (r redactor) payload(v interface{}) interface{} {
+	fields, ok := v.(map[string]interface{})
+	if !ok {
+		return r.value(v, true)
+	}
+	out := make(map[string]interface{}, len(fields))
+	for k, child := range fields {
+		out[k] = r.value(child, !structuralFields[k])
+	}
+	return out
+}
+
+// value redacts v; inText is set outside structural fields.
+func (r redactor) value(v interface{}, inText bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			out[k] = r.value(child, inText)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = r.value(child, inText)
+		}
+		return out
+	case string:
+		if inText && !r.keepText {
+			return fmt.Sprintf("<redacted %d chars>", len([]rune(v)))
+		}
+		return r.scrub(v)
+	}
+	return v
+}
+
+func (r redactor) scrub(s string) string {
+	s = scrub.Secrets(s)
+	s = emailPattern.ReplaceAllString(s, "user@example.com")
+	if r.home != "" && r.home != "/" {
+		s = strings.ReplaceAll(s, r.home, placeholderHome)
+	}
+	return s
+}
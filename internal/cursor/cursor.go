@@ -0,0 +1,251 @@
+// Package cursor reads Cursor's local conversation storage, so hooks whose
+// payload carries no response text (such as stop) can still find the last
+// agent message of a conversation.
+package cursor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// UserDirEnv overrides Cursor's user data directory, the one that holds
+// globalStorage/state.vscdb.
+const UserDirEnv = "CCPERSONA_CURSOR_USER_DIR"
+
+// ErrNoMessage is returned when the latest turn of a conversation has no
+// agent text, for example because it only ran tools or is still streaming.
+var ErrNoMessage = errors.New("no assistant message found")
+
+// Cursor's bubble types in composer data.
+const (
+	bubbleUser      = 1
+	bubbleAssistant = 2
+)
+
+// UserDir returns Cursor's user data directory for this platform.
+func UserDir() (string, error) {
+	if dir := os.Getenv(UserDirEnv); dir != "" {
+		return dir, nil
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		return filepath.Join(homeDir, "Library", "Application Support", "Cursor", "User"), nil
+	case "windows":
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			return "", fmt.Errorf("APPDATA is not set")
+		}
+		return filepath.Join(appData, "Cursor", "User"), nil
+	default:
+		config, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(config, "Cursor", "User"), nil
+	}
+}
+
+// LastAssistantMessage returns the final agent text of the latest turn of a
+// conversation. A JSON or JSONL transcript at transcriptPath is preferred;
+// otherwise the conversation is read from Cursor's global state database.
+func LastAssistantMessage(conversationID, transcriptPath string) (string, error) {
+	if transcriptPath != "" {
+		message, err := transcriptMessage(transcriptPath)
+		if err == nil || conversationID == "" {
+			return message, err
+		}
+	}
+	if conversationID == "" {
+		return "", fmt.Errorf("no conversation ID or transcript path")
+	}
+	dir, err := UserDir()
+	if err != nil {
+		return "", err
+	}
+	return storedMessage(filepath.Join(dir, "globalStorage", "state.vscdb"), conversationID)
+}
+
+// bubble is one message of a conversation.
+type bubble struct {
+	Type     int    `json:"type"`
+	BubbleID string `json:"bubbleId"`
+	Text     string `json:"text"`
+}
+
+// composerData is the `composerData:<id>` entry of a conversation. Older
+// Cursor versions inline the messages; newer ones list headers and store each
+// message under `bubbleId:<id>:<bubbleId>`.
+type composerData struct {
+	Conversation []bubble `json:"conversation"`
+	Headers      []bubble `json:"fullConversationHeadersOnly"`
+}
+
+// storedMessage reads the conversation from the state database at path.
+func storedMessage(path, conversationID string) (string, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	value, ok, err := db.lookup("cursorDiskKV", "composerData:"+conversationID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	if !ok {
+		return "", fmt.Errorf("conversation %s not found in %s", conversationID, path)
+	}
+	var data composerData
+	if err := json.Unmarshal(value, &data); err != nil {
+		return "", fmt.Errorf("conversation %s: %w", conversationID, err)
+	}
+
+	if len(data.Conversation) > 0 {
+		if text := lastAssistantText(data.Conversation); text != "" {
+			return text, nil
+		}
+		return "", ErrNoMessage
+	}
+	for i := len(data.Headers) - 1; i >= 0; i-- {
+		header := data.Headers[i]
+		if header.Type == bubbleUser {
+			break
+		}
+		if header.Type != bubbleAssistant {
+			continue
+		}
+		value, ok, err := db.lookup("cursorDiskKV", "bubbleId:"+conversationID+":"+header.BubbleID)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		var b bubble
+		if !ok || json.Unmarshal(value, &b) != nil {
+			continue
+		}
+		if text := strings.TrimSpace(b.Text); text != "" {
+			return text, nil
+		}
+	}
+	return "", ErrNoMessage
+}
+
+// lastAssistantText returns the last agent text after the final user
+// message, or "" when there is none.
+func lastAssistantText(bubbles []bubble) string {
+	for i := len(bubbles) - 1; i >= 0; i-- {
+		switch bubbles[i].Type {
+		case bubbleUser:
+			return ""
+		case bubbleAssistant:
+			if text := strings.TrimSpace(bubbles[i].Text); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// transcriptMessage reads a chat transcript: JSONL with one message per line,
+// or a JSON document holding composer data, a messages array, or a bare
+// array of messages.
+func transcriptMessage(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var messages []interface{}
+	if strings.HasSuffix(path, ".jsonl") {
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			var message interface{}
+			if json.Unmarshal(line, &message) == nil {
+				messages = append(messages, message)
+			}
+		}
+	} else {
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		switch doc := doc.(type) {
+		case []interface{}:
+			messages = doc
+		case map[string]interface{}:
+			for _, key := range []string{"conversation", "messages", "bubbles"} {
+				if list, ok := doc[key].([]interface{}); ok {
+					messages = list
+					break
+				}
+			}
+		}
+	}
+
+	bubbles := make([]bubble, 0, len(messages))
+	for _, m := range messages {
+		if obj, ok := m.(map[string]interface{}); ok {
+			bubbles = append(bubbles, bubble{Type: messageType(obj), Text: messageText(obj)})
+		}
+	}
+	if text := lastAssistantText(bubbles); text != "" {
+		return text, nil
+	}
+	return "", ErrNoMessage
+}
+
+// messageType maps a message's role or bubble type onto the bubble types.
+func messageType(m map[string]interface{}) int {
+	for _, key := range []string{"role", "type"} {
+		switch v := m[key].(type) {
+		case float64:
+			return int(v)
+		case string:
+			switch strings.ToLower(v) {
+			case "user", "human":
+				return bubbleUser
+			case "assistant", "ai", "agent":
+				return bubbleAssistant
+			}
+		}
+	}
+	if inner, ok := m["message"].(map[string]interface{}); ok {
+		return messageType(inner)
+	}
+	return 0
+}
+
+// messageText returns the text of a message: a text field, string content,
+// or the text parts of content blocks, looking inside a nested message.
+func messageText(m map[string]interface{}) string {
+	if text, ok := m["text"].(string); ok {
+		return text
+	}
+	switch content := m["content"].(type) {
+	case string:
+		return content
+	case []interface{}:
+		var parts []string
+		for _, block := range content {
+			b, ok := block.(map[string]interface{})
+			if !ok || (b["type"] != nil && b["type"] != "text") {
+				continue
+			}
+			if text, ok := b["text"].(string); ok {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	if inner, ok := m["message"].(map[string]interface{}); ok {
+		return messageText(inner)
+	}
+	return ""
+}
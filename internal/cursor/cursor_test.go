@@ -0,0 +1,219 @@
+package cursor
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The fixtures were written by SQLite 3.40 with a 512-byte page size, so the
+// lookups cross interior pages and overflow chains. wal/ holds a database
+// whose latest rows are only in its uncheckpointed write-ahead log.
+
+func TestStoredMessage(t *testing.T) {
+	path := filepath.Join("testdata", "state.vscdb")
+	tests := []struct {
+		id      string
+		want    string
+		wantErr error
+	}{
+		{id: "inline", want: "All tests pass."},
+		{id: "headers", want: "Refactored the parser. Every case is covered now."},
+		{id: "toolonly", wantErr: ErrNoMessage},
+	}
+	for _, tt := range tests {
+		got, err := storedMessage(path, tt.id)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("storedMessage(%s) error = %v, want %v", tt.id, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !strings.HasPrefix(got, tt.want) {
+			t.Errorf("storedMessage(%s) = %.60q, %v, want prefix %q", tt.id, got, err, tt.want)
+		}
+	}
+	if got, _ := storedMessage(path, "headers"); len(got) < 3000 {
+		t.Errorf("overflowing bubble truncated to %d bytes", len(got))
+	}
+	if _, err := storedMessage(path, "missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing conversation error = %v", err)
+	}
+}
+
+func TestStoredMessageWAL(t *testing.T) {
+	path := filepath.Join("testdata", "wal", "state.vscdb")
+	for id, want := range map[string]string{
+		"wal": "Answer still in the log.",
+		"old": "Rewritten in the log.",
+	} {
+		if got, err := storedMessage(path, id); got != want || err != nil {
+			t.Errorf("storedMessage(%s) = %q, %v, want %q", id, got, err, want)
+		}
+	}
+}
+
+func TestSQLiteScanAndLookup(t *testing.T) {
+	db, err := openSQLite(filepath.Join("testdata", "state.vscdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	entries, err := db.schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root uint32
+	for _, e := range entries {
+		if e.kind == "table" && e.name == "cursorDiskKV" {
+			root = e.root
+		}
+	}
+	rows := 0
+	if err := db.scanTable(root, 0, func([]interface{}) bool { rows++; return true }); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 127 {
+		t.Errorf("scanned %d rows, want 127", rows)
+	}
+	for _, key := range []string{"filler:000", "filler:077", "filler:119"} {
+		value, ok, err := db.lookup("cursorDiskKV", key)
+		if err != nil || !ok || string(value) != strings.Repeat("v", 60) {
+			t.Errorf("lookup(%s) = %q, %v, %v", key, value, ok, err)
+		}
+	}
+	if _, ok, err := db.lookup("cursorDiskKV", "filler:0775"); ok || err != nil {
+		t.Errorf("lookup of an absent key = %v, %v", ok, err)
+	}
+	if _, _, err := db.lookup("nope", "x"); err == nil {
+		t.Error("lookup in a missing table should fail")
+	}
+
+	if _, err := openSQLite(filepath.Join("cursor.go")); err == nil {
+		t.Error("openSQLite should reject a non-database file")
+	}
+}
+
+func TestLastAssistantMessage(t *testing.T) {
+	dir := t.TempDir()
+	jsonl := filepath.Join(dir, "agent.jsonl")
+	writeFile(t, jsonl, `{"role":"user","message":{"content":[{"type":"text","text":"fix it"}]}}
+{"role":"assistant","message":{"content":[{"type":"text","text":"Fixed."},{"type":"tool_use","name":"edit"}]}}
+`)
+	if got, err := LastAssistantMessage("", jsonl); got != "Fixed." || err != nil {
+		t.Errorf("JSONL transcript = %q, %v", got, err)
+	}
+
+	chat := filepath.Join(dir, "chat.json")
+	writeFile(t, chat, `{"messages":[{"role":"assistant","content":"Old."},{"role":"user","content":"again"},{"role":"assistant","content":"New."}]}`)
+	if got, err := LastAssistantMessage("", chat); got != "New." || err != nil {
+		t.Errorf("chat JSON = %q, %v", got, err)
+	}
+
+	// An unreadable transcript falls back to the state database.
+	userDir := filepath.Join(dir, "User")
+	data, err := os.ReadFile(filepath.Join("testdata", "state.vscdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(userDir, "globalStorage", "state.vscdb"), string(data))
+	t.Setenv(UserDirEnv, userDir)
+	if got, err := LastAssistantMessage("inline", filepath.Join(dir, "missing.jsonl")); got != "All tests pass." || err != nil {
+		t.Errorf("database fallback = %q, %v", got, err)
+	}
+	if _, err := LastAssistantMessage("", ""); err == nil {
+		t.Error("no conversation ID or transcript should fail")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readCorrupt looks up every message of a database file holding data, which
+// must fail or succeed without panicking.
+func readCorrupt(t *testing.T, data []byte) {
+	path := filepath.Join(t.TempDir(), "state.vscdb")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"inline", "headers", "toolonly", "missing"} {
+		_, _ = storedMessage(path, id)
+	}
+}
+
+func TestStoredMessageCorruptFile(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "state.vscdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Truncated at every page boundary and in the middle of each page.
+	for size := 100; size < len(data); size += 256 {
+		readCorrupt(t, data[:size])
+	}
+	// Every header and cell pointer byte of each page set to 0xff.
+	for page := 0; page < len(data); page += 512 {
+		hdr := page
+		if page == 0 {
+			hdr = 100
+		}
+		for i := hdr; i < hdr+40 && i < len(data); i++ {
+			corrupt := append([]byte(nil), data...)
+			corrupt[i] = 0xff
+			readCorrupt(t, corrupt)
+		}
+	}
+}
+
+func TestSQLiteScanCycle(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "state.vscdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Page 4 is the interior root of cursorDiskKV: point every child at
+	// itself.
+	page := data[3*512 : 4*512]
+	cells := int(binary.BigEndian.Uint16(page[3:]))
+	for i := 0; i < cells; i++ {
+		off := int(binary.BigEndian.Uint16(page[12+2*i:]))
+		binary.BigEndian.PutUint32(page[off:], 4)
+	}
+	path := filepath.Join(t.TempDir(), "state.vscdb")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := openSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.scanTable(4, 0, func([]interface{}) bool { return true }); !errors.Is(err, errCorrupt) {
+		t.Errorf("scan of a cyclic tree = %v, want %v", err, errCorrupt)
+	}
+}
+
+func FuzzStoredMessage(f *testing.F) {
+	data, err := os.ReadFile(filepath.Join("testdata", "state.vscdb"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data, 0, byte(0))
+	f.Add(data, 1024+3, byte(0xff))
+	f.Fuzz(func(t *testing.T, data []byte, at int, b byte) {
+		if len(data) > 0 && at >= 0 {
+			data = append([]byte(nil), data...)
+			data[at%len(data)] ^= b
+		}
+		readCorrupt(t, data)
+	})
+}
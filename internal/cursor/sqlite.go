@@ -0,0 +1,578 @@
+package cursor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// sqliteDB is a minimal read-only SQLite reader: just enough to look up rows
+// of Cursor's key-value tables without cgo. Pages still in the write-ahead
+// log are read from it, so data Cursor has not checkpointed yet is visible.
+type sqliteDB struct {
+	file     *os.File
+	wal      *os.File
+	pageSize int
+	usable   int
+	// size is the size of the database and its log, which no payload can
+	// exceed.
+	size int64
+	// walPages maps a page number to the offset of its latest committed
+	// copy in the WAL.
+	walPages map[uint32]int64
+}
+
+const (
+	pageIndexInterior = 2
+	pageTableInterior = 5
+	pageIndexLeaf     = 10
+	pageTableLeaf     = 13
+
+	// maxTreeDepth bounds b-tree descent so a corrupt file cannot loop.
+	maxTreeDepth = 64
+
+	// minUsable is the smallest usable page size SQLite allows; the payload
+	// limits are computed from it.
+	minUsable = 480
+)
+
+var errCorrupt = errors.New("malformed database")
+
+func openSQLite(path string) (*sqliteDB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 100)
+	if _, err := io.ReadFull(f, header); err != nil || string(header[:16]) != "SQLite format 3\x00" {
+		f.Close()
+		return nil, fmt.Errorf("%s: not a SQLite database", path)
+	}
+	pageSize := int(binary.BigEndian.Uint16(header[16:]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w: page size %d", path, errCorrupt, pageSize)
+	}
+	if enc := binary.BigEndian.Uint32(header[56:]); enc > 1 {
+		f.Close()
+		return nil, fmt.Errorf("%s: unsupported text encoding %d", path, enc)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	db := &sqliteDB{
+		file:     f,
+		pageSize: pageSize,
+		usable:   pageSize - int(header[20]),
+		size:     info.Size(),
+	}
+	if db.usable < minUsable {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w: reserved space %d", path, errCorrupt, header[20])
+	}
+	if err := db.readWAL(path + "-wal"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// readWAL indexes the committed frames of the write-ahead log. Frames after
+// the last commit, or left over from an earlier log generation (their salts
+// differ from the header's), are ignored.
+func (db *sqliteDB) readWAL(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	header := make([]byte, 32)
+	if _, err := io.ReadFull(f, header); err != nil {
+		f.Close()
+		return nil // an empty log holds no frames
+	}
+	magic := binary.BigEndian.Uint32(header)
+	if (magic != 0x377f0682 && magic != 0x377f0683) || int(binary.BigEndian.Uint32(header[8:])) != db.pageSize {
+		f.Close()
+		return nil
+	}
+	salt := header[16:24]
+	if info, err := f.Stat(); err == nil {
+		db.size += info.Size()
+	}
+
+	db.wal = f
+	db.walPages = make(map[uint32]int64)
+	pending := make(map[uint32]int64)
+	frame := make([]byte, 24)
+	for off := int64(32); ; off += int64(24 + db.pageSize) {
+		if _, err := f.ReadAt(frame, off); err != nil {
+			break
+		}
+		if !bytes.Equal(frame[8:16], salt) {
+			break
+		}
+		pending[binary.BigEndian.Uint32(frame)] = off + 24
+		if binary.BigEndian.Uint32(frame[4:]) != 0 {
+			for page, at := range pending {
+				db.walPages[page] = at
+			}
+			clear(pending)
+		}
+	}
+	return nil
+}
+
+func (db *sqliteDB) Close() error {
+	if db.wal != nil {
+		db.wal.Close()
+	}
+	return db.file.Close()
+}
+
+func (db *sqliteDB) page(n uint32) ([]byte, error) {
+	if n == 0 {
+		return nil, errCorrupt
+	}
+	buf := make([]byte, db.pageSize)
+	var err error
+	if at, ok := db.walPages[n]; ok {
+		_, err = db.wal.ReadAt(buf, at)
+	} else {
+		_, err = db.file.ReadAt(buf, int64(n-1)*int64(db.pageSize))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read page %d: %w", n, err)
+	}
+	return buf, nil
+}
+
+// btreePage is a parsed b-tree page header.
+type btreePage struct {
+	data  []byte
+	kind  byte
+	cells int
+	// ptrs is the offset of the cell pointer array.
+	ptrs  int
+	right uint32
+}
+
+func (db *sqliteDB) btree(n uint32) (*btreePage, error) {
+	data, err := db.page(n)
+	if err != nil {
+		return nil, err
+	}
+	hdr := 0
+	if n == 1 {
+		hdr = 100
+	}
+	p := &btreePage{
+		data:  data,
+		kind:  data[hdr],
+		cells: int(binary.BigEndian.Uint16(data[hdr+3:])),
+		ptrs:  hdr + 8,
+	}
+	switch p.kind {
+	case pageIndexInterior, pageTableInterior:
+		p.right = binary.BigEndian.Uint32(data[hdr+8:])
+		p.ptrs += 4
+	case pageIndexLeaf, pageTableLeaf:
+	default:
+		return nil, fmt.Errorf("page %d: %w: page type %d", n, errCorrupt, p.kind)
+	}
+	if p.ptrs+2*p.cells > len(data) {
+		return nil, fmt.Errorf("page %d: %w: cell count", n, errCorrupt)
+	}
+	return p, nil
+}
+
+// cell returns the offset of cell i. Every cell is at least 4 bytes long,
+// so the child page number of an interior cell can be read at the offset.
+func (p *btreePage) cell(i int) (int, error) {
+	off := int(binary.BigEndian.Uint16(p.data[p.ptrs+2*i:]))
+	if off < p.ptrs || off+4 > len(p.data) {
+		return 0, errCorrupt
+	}
+	return off, nil
+}
+
+// payload returns the cell payload of size bytes starting at off, following
+// overflow pages when it does not fit on the page.
+func (db *sqliteDB) payload(p *btreePage, off int, size uint64) ([]byte, error) {
+	if size > uint64(db.size) {
+		return nil, fmt.Errorf("%w: payload of %d bytes", errCorrupt, size)
+	}
+	u := uint64(db.usable)
+	maxLocal := u - 35
+	if p.kind == pageIndexInterior || p.kind == pageIndexLeaf {
+		maxLocal = (u-12)*64/255 - 23
+	}
+	minLocal := (u-12)*32/255 - 23
+	local := size
+	if size > maxLocal {
+		local = minLocal + (size-minLocal)%(u-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	end := off + int(local)
+	if off > len(p.data) || end > len(p.data) || (local < size && end+4 > len(p.data)) {
+		return nil, errCorrupt
+	}
+	out := make([]byte, 0, size)
+	out = append(out, p.data[off:end]...)
+	if local == size {
+		return out, nil
+	}
+	next := binary.BigEndian.Uint32(p.data[end:])
+	for pages := uint64(0); uint64(len(out)) < size; pages++ {
+		if pages > size/(u-4)+1 {
+			return nil, errCorrupt
+		}
+		data, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		n := min(size-uint64(len(out)), u-4)
+		out = append(out, data[4:4+n]...)
+		next = binary.BigEndian.Uint32(data)
+	}
+	return out, nil
+}
+
+// schemaEntry is a row of sqlite_schema.
+type schemaEntry struct {
+	kind, name, table string
+	root              uint32
+}
+
+func (db *sqliteDB) schema() ([]schemaEntry, error) {
+	var entries []schemaEntry
+	err := db.scanTable(1, 0, func(rec []interface{}) bool {
+		if len(rec) >= 4 {
+			kind, _ := rec[0].(string)
+			name, _ := rec[1].(string)
+			table, _ := rec[2].(string)
+			root, _ := rec[3].(int64)
+			entries = append(entries, schemaEntry{kind, name, table, uint32(root)})
+		}
+		return true
+	})
+	return entries, err
+}
+
+// lookup returns the second column of the row of table whose first column
+// is key, as in Cursor's `key TEXT UNIQUE, value BLOB` tables. The unique
+// index is used when present; otherwise the table is scanned.
+func (db *sqliteDB) lookup(table, key string) ([]byte, bool, error) {
+	entries, err := db.schema()
+	if err != nil {
+		return nil, false, err
+	}
+	var root, index uint32
+	for _, e := range entries {
+		switch {
+		case e.kind == "table" && e.name == table:
+			root = e.root
+		case e.kind == "index" && e.table == table && strings.HasPrefix(e.name, "sqlite_autoindex_"):
+			index = e.root
+		}
+	}
+	if root == 0 {
+		return nil, false, fmt.Errorf("no table %s", table)
+	}
+
+	var row []interface{}
+	if index != 0 {
+		rowid, ok, err := db.indexLookup(index, key, 0)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		if row, err = db.tableRow(root, rowid, 0); err != nil {
+			return nil, false, err
+		}
+	} else {
+		err = db.scanTable(root, 0, func(rec []interface{}) bool {
+			if len(rec) > 0 && rec[0] == key {
+				row = rec
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	if len(row) < 2 {
+		return nil, row != nil, nil
+	}
+	switch v := row[1].(type) {
+	case string:
+		return []byte(v), true, nil
+	case []byte:
+		return v, true, nil
+	default:
+		return nil, true, nil
+	}
+}
+
+// scanTable calls fn with every row of the table b-tree rooted at n until fn
+// returns false.
+func (db *sqliteDB) scanTable(n uint32, depth int, fn func([]interface{}) bool) error {
+	_, err := db.walkTable(n, depth, map[uint32]bool{}, fn)
+	return err
+}
+
+// walkTable is scanTable with the pages seen so far, since a corrupt file
+// whose interior pages point back at each other would otherwise be walked
+// an exponential number of times before the depth limit stops it.
+func (db *sqliteDB) walkTable(n uint32, depth int, seen map[uint32]bool, fn func([]interface{}) bool) (bool, error) {
+	if depth > maxTreeDepth || seen[n] {
+		return false, errCorrupt
+	}
+	seen[n] = true
+	p, err := db.btree(n)
+	if err != nil {
+		return false, err
+	}
+	for i := 0; i < p.cells; i++ {
+		off, err := p.cell(i)
+		if err != nil {
+			return false, err
+		}
+		if p.kind == pageTableInterior {
+			more, err := db.walkTable(binary.BigEndian.Uint32(p.data[off:]), depth+1, seen, fn)
+			if err != nil || !more {
+				return more, err
+			}
+			continue
+		}
+		if p.kind != pageTableLeaf {
+			return false, errCorrupt
+		}
+		rec, _, err := db.tableCell(p, off)
+		if err != nil {
+			return false, err
+		}
+		if !fn(rec) {
+			return false, nil
+		}
+	}
+	if p.kind == pageTableInterior {
+		return db.walkTable(p.right, depth+1, seen, fn)
+	}
+	return true, nil
+}
+
+// tableCell decodes a table leaf cell into its record and rowid.
+func (db *sqliteDB) tableCell(p *btreePage, off int) ([]interface{}, int64, error) {
+	size, n := readVarint(p.data[off:])
+	rowid, m := readVarint(p.data[off+n:])
+	if n == 0 || m == 0 {
+		return nil, 0, errCorrupt
+	}
+	data, err := db.payload(p, off+n+m, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	rec, err := parseRecord(data)
+	return rec, int64(rowid), err
+}
+
+// tableRow finds the row with rowid in the table b-tree rooted at n.
+func (db *sqliteDB) tableRow(n uint32, rowid int64, depth int) ([]interface{}, error) {
+	if depth > maxTreeDepth {
+		return nil, errCorrupt
+	}
+	p, err := db.btree(n)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < p.cells; i++ {
+		off, err := p.cell(i)
+		if err != nil {
+			return nil, err
+		}
+		switch p.kind {
+		case pageTableInterior:
+			key, m := readVarint(p.data[off+4:])
+			if m == 0 {
+				return nil, errCorrupt
+			}
+			if rowid <= int64(key) {
+				return db.tableRow(binary.BigEndian.Uint32(p.data[off:]), rowid, depth+1)
+			}
+		case pageTableLeaf:
+			rec, id, err := db.tableCell(p, off)
+			if err != nil || id == rowid {
+				return rec, err
+			}
+		default:
+			return nil, errCorrupt
+		}
+	}
+	if p.kind == pageTableInterior {
+		return db.tableRow(p.right, rowid, depth+1)
+	}
+	return nil, fmt.Errorf("%w: rowid %d is indexed but missing", errCorrupt, rowid)
+}
+
+// indexLookup finds key in the index b-tree rooted at n and returns the rowid
+// stored with it.
+func (db *sqliteDB) indexLookup(n uint32, key string, depth int) (int64, bool, error) {
+	if depth > maxTreeDepth {
+		return 0, false, errCorrupt
+	}
+	p, err := db.btree(n)
+	if err != nil {
+		return 0, false, err
+	}
+	interior := p.kind == pageIndexInterior
+	if !interior && p.kind != pageIndexLeaf {
+		return 0, false, errCorrupt
+	}
+	for i := 0; i < p.cells; i++ {
+		off, err := p.cell(i)
+		if err != nil {
+			return 0, false, err
+		}
+		start := off
+		if interior {
+			start += 4
+		}
+		size, m := readVarint(p.data[start:])
+		if m == 0 {
+			return 0, false, errCorrupt
+		}
+		data, err := db.payload(p, start+m, size)
+		if err != nil {
+			return 0, false, err
+		}
+		rec, err := parseRecord(data)
+		if err != nil || len(rec) < 2 {
+			return 0, false, errCorrupt
+		}
+		switch c := compareKey(key, rec[0]); {
+		case c == 0:
+			rowid, ok := rec[len(rec)-1].(int64)
+			return rowid, ok, nil
+		case c < 0 && interior:
+			return db.indexLookup(binary.BigEndian.Uint32(p.data[off:]), key, depth+1)
+		case c < 0:
+			return 0, false, nil
+		}
+	}
+	if interior {
+		return db.indexLookup(p.right, key, depth+1)
+	}
+	return 0, false, nil
+}
+
+// compareKey orders a text key against an index value the way SQLite's
+// BINARY collation does: NULL and numbers sort before text, blobs after.
+func compareKey(key string, v interface{}) int {
+	switch v := v.(type) {
+	case string:
+		return strings.Compare(key, v)
+	case []byte:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// parseRecord decodes a record into nil, int64, float64, string, and []byte
+// values.
+func parseRecord(data []byte) ([]interface{}, error) {
+	hdrLen, n := readVarint(data)
+	if n == 0 || hdrLen > uint64(len(data)) {
+		return nil, errCorrupt
+	}
+	var types []uint64
+	for pos := n; pos < int(hdrLen); {
+		t, m := readVarint(data[pos:int(hdrLen)])
+		if m == 0 {
+			return nil, errCorrupt
+		}
+		types = append(types, t)
+		pos += m
+	}
+
+	values := make([]interface{}, 0, len(types))
+	body := data[hdrLen:]
+	for _, t := range types {
+		size := serialSize(t)
+		if size > uint64(len(body)) {
+			return nil, errCorrupt
+		}
+		raw := body[:size]
+		body = body[size:]
+		switch {
+		case t == 0:
+			values = append(values, nil)
+		case t <= 6:
+			v := int64(int8(raw[0]))
+			for _, b := range raw[1:] {
+				v = v<<8 | int64(b)
+			}
+			values = append(values, v)
+		case t == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(raw)))
+		case t == 8, t == 9:
+			values = append(values, int64(t-8))
+		case t >= 12 && t%2 == 0:
+			values = append(values, append([]byte(nil), raw...))
+		case t >= 13:
+			values = append(values, string(raw))
+		default:
+			return nil, errCorrupt
+		}
+	}
+	return values, nil
+}
+
+func serialSize(t uint64) uint64 {
+	switch {
+	case t <= 4:
+		return t
+	case t == 5:
+		return 6
+	case t == 6, t == 7:
+		return 8
+	case t < 12:
+		return 0
+	default:
+		return (t - 12) / 2
+	}
+}
+
+// readVarint decodes a SQLite varint and returns it with its length, or a
+// length of 0 when b is too short.
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, 9
+}
@@ -0,0 +1,262 @@
+// Package daemon hands speech from hook processes to one long-running
+// process, `ccpersona runtime daemon`. Hooks otherwise resolve the engine,
+// check that it answers, and start a player for every message; the daemon
+// keeps its connections to engines and providers open and plays messages
+// in order from one queue.
+//
+// The daemon writes its address and a random token to a state file that
+// only the user can read. Hooks find it there and post each message with
+// the token; when no daemon answers, they speak as before.
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/daikw/ccpersona/internal/voice"
+)
+
+// DefaultAddr is where the daemon listens unless configured otherwise.
+const DefaultAddr = "127.0.0.1:50093"
+
+// DisableEnv set to any value makes hooks speak by themselves even when a
+// daemon runs.
+const DisableEnv = "CCPERSONA_NO_DAEMON"
+
+// forwardTimeout bounds handing a message over when the hook does not wait
+// for playback, so a stuck daemon delays speech only briefly.
+const forwardTimeout = 2 * time.Second
+
+// ErrNotRunning is returned by Forward when no daemon takes the message;
+// the caller then speaks it itself.
+var ErrNotRunning = errors.New("speech daemon is not running")
+
+// Request is one message for the daemon to speak.
+type Request struct {
+	Text    string             `json:"text"`
+	Options voice.VoiceOptions `json:"options"`
+	Config  *voice.Config      `json:"config"`
+	// Persona is carried separately because Config does not serialize it.
+	Persona string `json:"persona,omitempty"`
+	// Event is the hook event ("" for commands the user ran), which decides
+	// who gives way when another process is speaking in Dir.
+	Event string `json:"event,omitempty"`
+	Dir   string `json:"dir"`
+	// Wait holds the response until the message was played.
+	Wait bool `json:"wait,omitempty"`
+}
+
+// Validate checks that a request posted to the daemon is complete.
+func (r Request) Validate() error {
+	switch {
+	case r.Text == "":
+		return errors.New("text is required")
+	case r.Config == nil:
+		return errors.New("config is required")
+	case r.Dir == "":
+		return errors.New("dir is required")
+	}
+	return nil
+}
+
+// State is what a running daemon writes for hooks to find it.
+type State struct {
+	Addr    string    `json:"addr"`
+	Token   string    `json:"token"`
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+}
+
+// Status is the daemon's answer to a health check.
+type Status struct {
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+	Queued  int       `json:"queued"`
+	Spoken  int       `json:"spoken"`
+	Failed  int       `json:"failed"`
+}
+
+// StatePath returns the state file, in the user's cache directory.
+func StatePath() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "ccpersona", "daemon.json"), nil
+}
+
+// NewState returns the state of a daemon of this process listening on addr,
+// with a fresh token.
+func NewState(addr string) (*State, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	return &State{Addr: addr, Token: hex.EncodeToString(token), PID: os.Getpid(), Started: time.Now()}, nil
+}
+
+// Save writes the state to path, readable only by the user.
+func (s *State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(path, data, 0o600)
+}
+
+// Remove deletes the state file at path if it still belongs to s, so a
+// daemon that exits late does not hide one started after it.
+func (s *State) Remove(path string) {
+	if current, err := LoadState(path); err == nil && current != nil && current.Token == s.Token {
+		_ = os.Remove(path)
+	}
+}
+
+// LoadState reads the state file at path; nil without error when no daemon
+// has written one.
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// running returns the state of the daemon hooks should talk to, or
+// ErrNotRunning.
+func running() (*State, error) {
+	if os.Getenv(DisableEnv) != "" || voice.TestMode() {
+		return nil, ErrNotRunning
+	}
+	path, err := StatePath()
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	state, err := LoadState(path)
+	if err != nil || state == nil {
+		return nil, ErrNotRunning
+	}
+	return state, nil
+}
+
+// Forward hands req to the running daemon. It returns ErrNotRunning when no
+// daemon takes it, and the daemon's error when req.Wait is set and speaking
+// failed.
+func Forward(ctx context.Context, req Request) error {
+	state, err := running()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if !req.Wait {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, forwardTimeout)
+		defer cancel()
+	}
+	resp, err := state.do(ctx, http.MethodPost, "/speak", data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted:
+		return nil
+	case http.StatusUnprocessableEntity:
+		return errors.New(readError(resp))
+	default:
+		// Full queue, stale token, or another server on the port.
+		return fmt.Errorf("%w: %s", ErrNotRunning, readError(resp))
+	}
+}
+
+// Query asks the running daemon for its status.
+func Query(ctx context.Context) (*Status, error) {
+	state, err := running()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, forwardTimeout)
+	defer cancel()
+	resp, err := state.do(ctx, http.MethodGet, "/status", nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrNotRunning, readError(resp))
+	}
+	var status Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Stop asks the running daemon to exit once the message playing is done.
+func Stop(ctx context.Context) error {
+	state, err := running()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, forwardTimeout)
+	defer cancel()
+	resp, err := state.do(ctx, http.MethodPost, "/stop", nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("daemon refused to stop: %s", readError(resp))
+	}
+	return nil
+}
+
+func (s *State) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, "http://"+s.Addr+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func readError(resp *http.Response) string {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if msg := bytes.TrimSpace(data); len(msg) > 0 {
+		return string(msg)
+	}
+	return resp.Status
+}
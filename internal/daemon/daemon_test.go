@@ -0,0 +1,180 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSpeaker records what it was asked to speak.
+type fakeSpeaker struct {
+	mu     sync.Mutex
+	played []string
+	fail   string
+}
+
+func (f *fakeSpeaker) Synthesize(ctx context.Context, req Request) (string, error) {
+	if req.Text == f.fail {
+		return "", errors.New("engine down")
+	}
+	return "audio:" + req.Text, nil
+}
+
+func (f *fakeSpeaker) Play(ctx context.Context, req Request, audioFile string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.played = append(f.played, audioFile)
+	return nil
+}
+
+func (f *fakeSpeaker) Played() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.played...)
+}
+
+// startDaemon runs a daemon with speaker and writes its state where
+// Forward looks.
+func startDaemon(t *testing.T, speaker Speaker) *Server {
+	t.Helper()
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("HOME", cache)
+	t.Setenv("LocalAppData", cache)
+	t.Setenv(DisableEnv, "")
+	t.Setenv(voice.EnvTestMode, "")
+	t.Setenv(voice.EnvMute, "")
+
+	state, err := NewState("")
+	require.NoError(t, err)
+	srv := NewServer(state, speaker)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	state.Addr = strings.TrimPrefix(ts.URL, "http://")
+
+	path, err := StatePath()
+	require.NoError(t, err)
+	require.NoError(t, state.Save(path))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go srv.Run(ctx)
+	return srv
+}
+
+func request(text string) Request {
+	return Request{Text: text, Config: voice.DefaultConfig(), Dir: "/project", Wait: true}
+}
+
+func TestForwardSpeaksInOrder(t *testing.T) {
+	speaker := &fakeSpeaker{}
+	startDaemon(t, speaker)
+
+	require.NoError(t, Forward(context.Background(), request("one")))
+	queued := request("two")
+	queued.Wait = false
+	require.NoError(t, Forward(context.Background(), queued))
+	require.NoError(t, Forward(context.Background(), request("three")))
+
+	assert.Equal(t, []string{"audio:one", "audio:two", "audio:three"}, speaker.Played())
+	status, err := Query(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Spoken)
+	assert.Equal(t, 0, status.Queued)
+}
+
+func TestForwardReportsFailure(t *testing.T) {
+	startDaemon(t, &fakeSpeaker{fail: "broken"})
+
+	err := Forward(context.Background(), request("broken"))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotRunning)
+	assert.Contains(t, err.Error(), "engine down")
+}
+
+func TestForwardWithoutDaemon(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	assert.ErrorIs(t, Forward(context.Background(), request("hello")), ErrNotRunning)
+
+	// A state file left by a daemon that died.
+	path, err := StatePath()
+	require.NoError(t, err)
+	require.NoError(t, (&State{Addr: "127.0.0.1:1", Token: "x"}).Save(path))
+	assert.ErrorIs(t, Forward(context.Background(), request("hello")), ErrNotRunning)
+}
+
+func TestForwardDisabled(t *testing.T) {
+	speaker := &fakeSpeaker{}
+	startDaemon(t, speaker)
+	t.Setenv(DisableEnv, "1")
+
+	assert.ErrorIs(t, Forward(context.Background(), request("hello")), ErrNotRunning)
+	assert.Empty(t, speaker.Played())
+}
+
+func TestServerRejectsWrongToken(t *testing.T) {
+	state, err := NewState("")
+	require.NoError(t, err)
+	srv := NewServer(state, &fakeSpeaker{})
+
+	for _, header := range []string{"", "Bearer wrong"} {
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		req.RemoteAddr = "127.0.0.1:4000"
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "header %q", header)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.RemoteAddr = "192.168.1.5:4000"
+	req.Header.Set("Authorization", "Bearer "+state.Token)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestStop(t *testing.T) {
+	srv := startDaemon(t, &fakeSpeaker{})
+	require.NoError(t, Stop(context.Background()))
+	select {
+	case <-srv.Stopped():
+	case <-time.After(time.Second):
+		t.Fatal("daemon was not asked to stop")
+	}
+}
+
+func TestStateRemoveKeepsNewerDaemon(t *testing.T) {
+	path := t.TempDir() + "/daemon.json"
+	old, err := NewState("127.0.0.1:1")
+	require.NoError(t, err)
+	current, err := NewState("127.0.0.1:2")
+	require.NoError(t, err)
+	require.NoError(t, current.Save(path))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	old.Remove(path)
+	loaded, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Equal(t, current.Token, loaded.Token)
+
+	current.Remove(path)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,240 @@
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+)
+
+// maxRequestSize caps messages posted by hooks.
+const maxRequestSize = 1 << 20
+
+// queueSize is how many messages may wait for synthesis. Hooks speak by
+// themselves when the queue is full rather than wait behind it.
+const queueSize = 16
+
+// Speaker synthesizes and plays messages for the daemon.
+type Speaker interface {
+	Synthesize(ctx context.Context, req Request) (audioFile string, err error)
+	Play(ctx context.Context, req Request, audioFile string) error
+}
+
+// Server queues messages from hooks. One goroutine synthesizes them in
+// order while another plays the previous one, so the next message is
+// usually ready when playback ends.
+type Server struct {
+	state   *State
+	speaker Speaker
+	synth   chan *job
+	play    chan *job
+	stop    chan struct{}
+	once    sync.Once
+
+	mu     sync.Mutex
+	queued int
+	spoken int
+	failed int
+}
+
+type job struct {
+	req      Request
+	received time.Time
+	audio    string
+	done     chan error
+}
+
+// NewServer returns a daemon that accepts requests carrying the token of
+// state and speaks them with speaker. Call Run to start speaking.
+func NewServer(state *State, speaker Speaker) *Server {
+	return &Server{
+		state:   state,
+		speaker: speaker,
+		synth:   make(chan *job, queueSize),
+		play:    make(chan *job, 1),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Run speaks queued messages until ctx is done. Messages still queued then
+// are dropped.
+func (s *Server) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.synthesizeLoop(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		s.playLoop(ctx)
+	}()
+	wg.Wait()
+}
+
+// Stopped is closed when a client asked the daemon to exit.
+func (s *Server) Stopped() <-chan struct{} {
+	return s.stop
+}
+
+// Status reports the queue and what was spoken so far.
+func (s *Server) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Status{PID: s.state.PID, Started: s.state.Started, Queued: s.queued, Spoken: s.spoken, Failed: s.failed}
+}
+
+func (s *Server) synthesizeLoop(ctx context.Context) {
+	defer close(s.play)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-s.synth:
+			audio, err := s.speaker.Synthesize(ctx, j.req)
+			if err != nil {
+				s.finish(j, fmt.Errorf("failed to synthesize voice: %w", err))
+				continue
+			}
+			j.audio = audio
+			select {
+			case s.play <- j:
+			case <-ctx.Done():
+				voice.DiscardAudio(audio)
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) playLoop(ctx context.Context) {
+	for j := range s.play {
+		if ctx.Err() != nil {
+			voice.DiscardAudio(j.audio)
+			continue
+		}
+		if voice.IsMuted() || voice.SkippedAt().After(j.received) {
+			// Muted or skipped while the message waited.
+			voice.DiscardAudio(j.audio)
+			s.finish(j, nil)
+			continue
+		}
+		if err := s.speaker.Play(ctx, j.req, j.audio); err != nil {
+			s.finish(j, fmt.Errorf("failed to play audio: %w", err))
+			continue
+		}
+		s.finish(j, nil)
+	}
+}
+
+func (s *Server) finish(j *job, err error) {
+	s.mu.Lock()
+	s.queued--
+	if err != nil {
+		s.failed++
+		log.Warn().Err(err).Str("event", j.req.Event).Msg("Daemon failed to speak")
+	} else {
+		s.spoken++
+	}
+	s.mu.Unlock()
+	j.done <- err
+}
+
+// Handler serves the daemon API. Requests are only accepted from the local
+// machine and with the token from the state file.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("POST /speak", s.handleSpeak)
+	mux.HandleFunc("POST /stop", s.handleStop)
+	return s.authorize(mux)
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopback(r.RemoteAddr) {
+			http.Error(w, "requests are only accepted from localhost", http.StatusForbidden)
+			return
+		}
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.state.Token)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.Status())
+}
+
+func (s *Server) handleSpeak(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Buffered, so the loops never block on a hook that stopped waiting.
+	j := &job{req: req, received: time.Now(), done: make(chan error, 1)}
+	s.mu.Lock()
+	select {
+	case s.synth <- j:
+		s.queued++
+	default:
+		s.mu.Unlock()
+		http.Error(w, "queue is full", http.StatusServiceUnavailable)
+		return
+	}
+	s.mu.Unlock()
+
+	if !req.Wait {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	select {
+	case err := <-j.done:
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case <-r.Context().Done():
+	}
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
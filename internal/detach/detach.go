@@ -0,0 +1,18 @@
+// Package detach starts processes that outlive the hook that launched them.
+package detach
+
+import (
+	"os/exec"
+)
+
+// Start launches cmd in its own session, detached from the caller's terminal
+// and process group, so it keeps running after the caller exits. Standard
+// streams default to the null device. The caller must not Wait on cmd.
+func Start(cmd *exec.Cmd) error {
+	setDetached(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Release the handle; the child is reparented when the caller exits.
+	return cmd.Process.Release()
+}
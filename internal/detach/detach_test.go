@@ -0,0 +1,40 @@
+package detach
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess is run as the detached child by TestStart.
+func TestHelperProcess(t *testing.T) {
+	path := os.Getenv("DETACH_TEST_MARKER")
+	if path == "" {
+		return
+	}
+	_ = os.WriteFile(path, []byte("ok"), 0o644)
+	os.Exit(0)
+}
+
+func TestStart(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), "DETACH_TEST_MARKER="+marker)
+	if err := Start(cmd); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if cmd.SysProcAttr == nil {
+		t.Fatal("SysProcAttr should be set")
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(marker); err == nil {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("detached process did not run")
+}
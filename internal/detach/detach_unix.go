@@ -0,0 +1,14 @@
+//go:build !windows
+
+package detach
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setDetached starts the child in a new session so terminal hangups and the
+// hook runner's process-group kill do not reach it.
+func setDetached(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package detach
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const (
+	detachedProcess       = 0x00000008
+	createNewProcessGroup = 0x00000200
+)
+
+// setDetached starts the child without a console and in its own process
+// group so Ctrl+C in the caller's console does not reach it.
+func setDetached(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: detachedProcess | createNewProcessGroup}
+}
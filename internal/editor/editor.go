@@ -0,0 +1,127 @@
+// Package editor opens files in the user's editor. It honours $VISUAL and
+// $EDITOR with arguments (e.g. "code --wait") and falls back to a minimal
+// line editor when no editor is installed, as in slim containers.
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNoEditor is returned by Command when no editor is configured or found.
+var ErrNoEditor = errors.New("no editor found")
+
+// fallbacks are tried in order when neither the config nor the environment
+// names an editor.
+func fallbacks() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi", "nano"}
+}
+
+// Command returns the editor command line: configured (the global config's
+// editor), then $VISUAL, then $EDITOR, then the first fallback editor found
+// on PATH. It returns ErrNoEditor when none is available.
+func Command(configured string) ([]string, error) {
+	for _, source := range []struct{ name, value string }{
+		{"config editor", configured},
+		{"$VISUAL", os.Getenv("VISUAL")},
+		{"$EDITOR", os.Getenv("EDITOR")},
+	} {
+		if strings.TrimSpace(source.value) == "" {
+			continue
+		}
+		args, err := Split(source.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", source.name, source.value, err)
+		}
+		return args, nil
+	}
+	for _, name := range fallbacks() {
+		if _, err := exec.LookPath(name); err == nil {
+			return []string{name}, nil
+		}
+	}
+	return nil, ErrNoEditor
+}
+
+// Split splits an editor command line into arguments. Whitespace separates
+// arguments; single and double quotes group them, and a backslash escapes the
+// next character outside single quotes, except on Windows where it is a path
+// separator.
+func Split(s string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'' && runtime.GOOS != "windows":
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
+
+// Open edits path in the editor chosen by Command, attached to the terminal.
+// Without any editor it runs the inline line editor on stdin and stdout.
+func Open(path, configured string) error {
+	args, err := Command(configured)
+	if errors.Is(err, ErrNoEditor) {
+		return Inline(path, os.Stdin, os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to open editor %s: %w", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package editor
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"vim", []string{"vim"}},
+		{"code --wait", []string{"code", "--wait"}},
+		{`  subl  -w  `, []string{"subl", "-w"}},
+		{`"/Applications/My Editor.app/bin/edit" --wait`, []string{"/Applications/My Editor.app/bin/edit", "--wait"}},
+		{`emacsclient -a '' -t`, []string{"emacsclient", "-a", "", "-t"}},
+	}
+	for _, tt := range tests {
+		got, err := Split(tt.in)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "   ", `code "--wait`} {
+		if _, err := Split(bad); err == nil {
+			t.Errorf("Split(%q) should fail", bad)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		if got, _ := Split(`my\ editor -f`); !reflect.DeepEqual(got, []string{"my editor", "-f"}) {
+			t.Errorf("escaped space = %q", got)
+		}
+	}
+}
+
+func TestCommandPrecedence(t *testing.T) {
+	t.Setenv("VISUAL", "code --wait")
+	t.Setenv("EDITOR", "nano")
+
+	if got, _ := Command("hx"); !reflect.DeepEqual(got, []string{"hx"}) {
+		t.Errorf("config editor should win, got %q", got)
+	}
+	if got, _ := Command(""); !reflect.DeepEqual(got, []string{"code", "--wait"}) {
+		t.Errorf("$VISUAL should win over $EDITOR, got %q", got)
+	}
+	t.Setenv("VISUAL", "")
+	if got, _ := Command(""); !reflect.DeepEqual(got, []string{"nano"}) {
+		t.Errorf("$EDITOR = %q", got)
+	}
+
+	t.Setenv("EDITOR", "")
+	t.Setenv("PATH", t.TempDir())
+	if _, err := Command(""); err != ErrNoEditor {
+		t.Errorf("empty PATH error = %v, want ErrNoEditor", err)
+	}
+}
@@ -0,0 +1,136 @@
+package editor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+const inlineHelp = `Commands:
+  p          print the buffer with line numbers
+  a          append lines at the end
+  i N        insert lines before line N
+  c N        replace line N
+  d N[-M]    delete line N (or lines N to M)
+  w          save and quit
+  q          quit without saving
+Lines entered after a, i, or c end with a line containing only ".".`
+
+// Inline edits path with a minimal line-oriented editor reading commands
+// from in, for environments without a terminal editor. A missing file starts
+// empty. End of input quits without saving.
+func Inline(path string, in io.Reader, out io.Writer) error {
+	var lines []string
+	perm := os.FileMode(0600)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if text := strings.TrimSuffix(string(data), "\n"); text != "" {
+			lines = strings.Split(text, "\n")
+		}
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	fmt.Fprintf(out, "No editor found; editing %s inline. Set $VISUAL or $EDITOR to use your own.\n%s\n", path, inlineHelp)
+	scanner := bufio.NewScanner(in)
+	readBlock := func() []string {
+		var block []string
+		for scanner.Scan() {
+			if scanner.Text() == "." {
+				break
+			}
+			block = append(block, scanner.Text())
+		}
+		return block
+	}
+
+	modified := false
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			if modified {
+				return errors.New("input ended before saving; changes discarded")
+			}
+			return nil
+		}
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch cmd {
+		case "":
+		case "p":
+			for i, line := range lines {
+				fmt.Fprintf(out, "%4d  %s\n", i+1, line)
+			}
+		case "a":
+			lines = append(lines, readBlock()...)
+			modified = true
+		case "i", "c":
+			n, err := lineNumber(arg, len(lines))
+			if err != nil {
+				fmt.Fprintln(out, err)
+				continue
+			}
+			block := readBlock()
+			tail := lines[n-1:]
+			if cmd == "c" {
+				tail = tail[1:]
+			}
+			lines = append(append(append([]string{}, lines[:n-1]...), block...), tail...)
+			modified = true
+		case "d":
+			from, to, _ := strings.Cut(arg, "-")
+			if to == "" {
+				to = from
+			}
+			start, err := lineNumber(from, len(lines))
+			if err == nil {
+				var end int
+				if end, err = lineNumber(to, len(lines)); err == nil && end < start {
+					err = fmt.Errorf("invalid range %s", arg)
+				}
+				if err == nil {
+					lines = append(lines[:start-1], lines[end:]...)
+					modified = true
+				}
+			}
+			if err != nil {
+				fmt.Fprintln(out, err)
+			}
+		case "w":
+			text := strings.Join(lines, "\n")
+			if len(lines) > 0 {
+				text += "\n"
+			}
+			if err := fsutil.WriteFile(path, []byte(text), perm); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Saved %d line(s)\n", len(lines))
+			return nil
+		case "q":
+			return nil
+		case "h", "help", "?":
+			fmt.Fprintln(out, inlineHelp)
+		default:
+			fmt.Fprintf(out, "unknown command %q (h for help)\n", cmd)
+		}
+	}
+}
+
+func lineNumber(arg string, count int) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > count {
+		return 0, fmt.Errorf("line number must be between 1 and %d", count)
+	}
+	return n, nil
+}
@@ -0,0 +1,57 @@
+package editor
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "persona.md")
+	if err := os.WriteFile(path, []byte("# Persona\nold line\nkeep\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	script := strings.Join([]string{
+		"c 2", "new line", ".",
+		"a", "appended", "more", ".",
+		"i 1", "---", ".",
+		"d 5",
+		"d 9",
+		"p",
+		"w",
+	}, "\n")
+	var out strings.Builder
+	if err := Inline(path, strings.NewReader(script), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := "---\n# Persona\nnew line\nkeep\nmore\n"
+	if string(data) != want {
+		t.Errorf("saved content = %q, want %q", data, want)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640 preserved", info.Mode().Perm())
+	}
+	if !strings.Contains(out.String(), "line number must be between 1 and 5") {
+		t.Errorf("out-of-range delete not reported:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "   2  # Persona") {
+		t.Errorf("p should print numbered lines:\n%s", out.String())
+	}
+}
+
+func TestInlineDiscardsWithoutSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new.md")
+	if err := Inline(path, strings.NewReader("a\nhello\n.\nq\n"), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("q must not write the file")
+	}
+	if err := Inline(path, strings.NewReader("a\nhello\n.\n"), io.Discard); err == nil {
+		t.Error("end of input with unsaved changes should be reported")
+	}
+}
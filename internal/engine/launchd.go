@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/daikw/ccpersona/internal/fsutil"
 	"github.com/rs/zerolog/log"
 )
 
@@ -56,7 +57,7 @@ func (m *launchdManager) Install(def *EngineDef) error {
 	}
 
 	// Write plist
-	if err := os.WriteFile(plistPath, []byte(contents), 0644); err != nil {
+	if err := fsutil.WriteFile(plistPath, []byte(contents), 0644); err != nil {
 		return fmt.Errorf("failed to write plist: %w", err)
 	}
 
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/daikw/ccpersona/internal/fsutil"
 	"github.com/rs/zerolog/log"
 )
 
@@ -47,7 +48,7 @@ func (m *systemdManager) Install(def *EngineDef) error {
 		return fmt.Errorf("failed to create systemd user directory: %w", err)
 	}
 
-	if err := os.WriteFile(unitPath, []byte(contents), 0644); err != nil {
+	if err := fsutil.WriteFile(unitPath, []byte(contents), 0644); err != nil {
 		return fmt.Errorf("failed to write unit file: %w", err)
 	}
 
@@ -0,0 +1,210 @@
+// Package experiment alternates personas across sessions in a project and
+// records which persona each session used, so users can compare them.
+package experiment
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/daikw/ccpersona/internal/projectpath"
+)
+
+// Record event kinds.
+const (
+	EventStart = "start"
+	EventEnd   = "end"
+)
+
+// Record is one line of the experiment log.
+type Record struct {
+	Event     string    `json:"event"`
+	SessionID string    `json:"session_id"`
+	Persona   string    `json:"persona,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Log is an append-only JSONL file of session records for one project.
+type Log struct {
+	Path string
+}
+
+// LogPath returns the experiment log for projectDir.
+func LogPath(homeDir, projectDir string) string {
+	return filepath.Join(homeDir, ".agents", "ccpersona", "experiments", projectpath.Encode(projectDir)+".jsonl")
+}
+
+// Records reads all records. A missing log yields no records.
+func (l *Log) Records() ([]Record, error) {
+	f, err := os.Open(l.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read experiment log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue // tolerate a torn final line
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+func (l *Log) append(r Record) error {
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create experiment directory: %w", err)
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open experiment log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write experiment log: %w", err)
+	}
+	return nil
+}
+
+// Assign returns the persona for sessionID. A session that already started
+// keeps its persona (SessionStart fires again on resume); a new session gets
+// the next persona in rotation and is logged. Without a session ID, as from
+// legacy hooks that fire on every prompt, nothing rotates: the persona of
+// the latest session is kept, or "" when none started yet.
+func (l *Log) Assign(sessionID string, personas []string, now time.Time) (string, error) {
+	if len(personas) == 0 {
+		return "", fmt.Errorf("experiment has no personas")
+	}
+	// Sessions starting together must see each other's records, or they
+	// would get the same persona.
+	var persona string
+	err := fsutil.WithLock(l.Path, func() error {
+		records, err := l.Records()
+		if err != nil {
+			return err
+		}
+
+		started, latest := 0, ""
+		for _, r := range records {
+			if r.Event != EventStart {
+				continue
+			}
+			if sessionID != "" && r.SessionID == sessionID {
+				persona = r.Persona
+				return nil
+			}
+			started++
+			latest = r.Persona
+		}
+		if sessionID == "" {
+			persona = latest
+			return nil
+		}
+
+		persona = personas[started%len(personas)]
+		return l.append(Record{Event: EventStart, SessionID: sessionID, Persona: persona, Time: now})
+	})
+	if err != nil {
+		return "", err
+	}
+	return persona, nil
+}
+
+// End records that sessionID ended. Sessions not started under the
+// experiment are ignored.
+func (l *Log) End(sessionID string, now time.Time) error {
+	if sessionID == "" {
+		return nil
+	}
+	return fsutil.WithLock(l.Path, func() error {
+		records, err := l.Records()
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			if r.Event == EventStart && r.SessionID == sessionID {
+				return l.append(Record{Event: EventEnd, SessionID: sessionID, Time: now})
+			}
+		}
+		return nil
+	})
+}
+
+// Summary aggregates sessions for one persona.
+type Summary struct {
+	Persona  string
+	Sessions int
+	// Ended counts sessions with a recorded end; durations cover only those.
+	Ended         int
+	TotalDuration time.Duration
+}
+
+// AverageDuration returns the mean duration of ended sessions.
+func (s Summary) AverageDuration() time.Duration {
+	if s.Ended == 0 {
+		return 0
+	}
+	return s.TotalDuration / time.Duration(s.Ended)
+}
+
+// Report summarizes records per persona, sorted by persona name. The last end
+// record of a session wins, so resumed sessions count their full span.
+func Report(records []Record) []Summary {
+	type session struct {
+		persona string
+		start   time.Time
+		end     time.Time
+	}
+	sessions := make(map[string]*session)
+	var order []string
+	for _, r := range records {
+		switch r.Event {
+		case EventStart:
+			if _, ok := sessions[r.SessionID]; !ok {
+				sessions[r.SessionID] = &session{persona: r.Persona, start: r.Time}
+				order = append(order, r.SessionID)
+			}
+		case EventEnd:
+			if s, ok := sessions[r.SessionID]; ok && r.Time.After(s.end) {
+				s.end = r.Time
+			}
+		}
+	}
+
+	byPersona := make(map[string]*Summary)
+	for _, id := range order {
+		s := sessions[id]
+		sum, ok := byPersona[s.persona]
+		if !ok {
+			sum = &Summary{Persona: s.persona}
+			byPersona[s.persona] = sum
+		}
+		sum.Sessions++
+		if !s.end.IsZero() && s.end.After(s.start) {
+			sum.Ended++
+			sum.TotalDuration += s.end.Sub(s.start)
+		}
+	}
+
+	summaries := make([]Summary, 0, len(byPersona))
+	for _, sum := range byPersona {
+		summaries = append(summaries, *sum)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Persona < summaries[j].Persona })
+	return summaries
+}
@@ -0,0 +1,135 @@
+package experiment
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAssign_RotatesAndKeepsSessionPersona(t *testing.T) {
+	log := &Log{Path: filepath.Join(t.TempDir(), "exp.jsonl")}
+	personas := []string{"fable", "strict"}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	got := make([]string, 0, 3)
+	for _, id := range []string{"s1", "s2", "s3"} {
+		p, err := log.Assign(id, personas, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, p)
+	}
+	if got[0] != "fable" || got[1] != "strict" || got[2] != "fable" {
+		t.Errorf("rotation = %v", got)
+	}
+
+	// Resuming s2 keeps its persona and does not advance the rotation.
+	if p, _ := log.Assign("s2", personas, now); p != "strict" {
+		t.Errorf("resumed session persona = %q, want strict", p)
+	}
+	if p, _ := log.Assign("s4", personas, now); p != "strict" {
+		t.Errorf("next session persona = %q, want strict", p)
+	}
+}
+
+func TestAssign_ConcurrentSessionsSplitEvenly(t *testing.T) {
+	log := &Log{Path: filepath.Join(t.TempDir(), "exp.jsonl")}
+	personas := []string{"fable", "strict"}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	const sessions = 20
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, err := log.Assign(fmt.Sprintf("s%d", i), personas, now); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	records, err := log.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, r := range records {
+		counts[r.Persona]++
+	}
+	if len(records) != sessions || counts["fable"] != sessions/2 || counts["strict"] != sessions/2 {
+		t.Errorf("split = %v over %d records, want %d each", counts, len(records), sessions/2)
+	}
+}
+
+func TestAssign_WithoutSessionKeepsCurrentPersona(t *testing.T) {
+	log := &Log{Path: filepath.Join(t.TempDir(), "exp.jsonl")}
+	personas := []string{"fable", "strict"}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if p, err := log.Assign("", personas, now); err != nil || p != "" {
+		t.Errorf("Assign(\"\") before any session = %q, %v; want the configured persona", p, err)
+	}
+	if _, err := log.Assign("s1", personas, now); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if p, _ := log.Assign("", personas, now); p != "fable" {
+			t.Errorf("Assign(\"\") #%d = %q, want the current fable", i, p)
+		}
+	}
+	if p, _ := log.Assign("s2", personas, now); p != "strict" {
+		t.Errorf("next session persona = %q, want strict; anonymous calls must not rotate", p)
+	}
+	if records, _ := log.Records(); len(records) != 2 {
+		t.Errorf("records = %+v, want only the two sessions", records)
+	}
+}
+
+func TestAssign_NoPersonas(t *testing.T) {
+	log := &Log{Path: filepath.Join(t.TempDir(), "exp.jsonl")}
+	if _, err := log.Assign("s1", nil, time.Now()); err == nil {
+		t.Error("expected error without personas")
+	}
+}
+
+func TestEndAndReport(t *testing.T) {
+	log := &Log{Path: filepath.Join(t.TempDir(), "exp.jsonl")}
+	personas := []string{"fable", "strict"}
+	t0 := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"s1", "s2", "s3"} {
+		if _, err := log.Assign(id, personas, t0.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = log.End("s1", t0.Add(30*time.Minute))
+	_ = log.End("s2", t0.Add(time.Hour+10*time.Minute))
+	_ = log.End("unknown", t0)
+
+	records, err := log.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("got %d records, want 5", len(records))
+	}
+
+	summaries := Report(records)
+	if len(summaries) != 2 {
+		t.Fatalf("got %d summaries", len(summaries))
+	}
+	fable, strict := summaries[0], summaries[1]
+	if fable.Persona != "fable" || fable.Sessions != 2 || fable.Ended != 1 || fable.AverageDuration() != 30*time.Minute {
+		t.Errorf("fable summary = %+v", fable)
+	}
+	if strict.Persona != "strict" || strict.Sessions != 1 || strict.AverageDuration() != 10*time.Minute {
+		t.Errorf("strict summary = %+v", strict)
+	}
+}
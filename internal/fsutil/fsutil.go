@@ -0,0 +1,147 @@
+// Package fsutil writes files so that concurrent ccpersona processes (hooks
+// from parallel sessions, shell prompts, CLI commands) never observe or
+// produce a partial file.
+//
+// WriteFile replaces a file atomically. WithLock serializes read-modify-write
+// sequences across processes with an advisory lock; WriteFileLocked combines
+// the two.
+package fsutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LockTimeout bounds how long WithLock waits for another process to release
+// a lock before giving up.
+var LockTimeout = 5 * time.Second
+
+// ErrLockTimeout is returned when a lock could not be acquired in time.
+var ErrLockTimeout = errors.New("timed out waiting for file lock")
+
+// renameAttempts covers transient failures replacing a file that another
+// process has open, which Windows reports as access denied.
+const renameAttempts = 5
+
+// WriteFile atomically replaces path with data: it writes a temp file in the
+// same directory, syncs it, and renames it over path. Readers see either the
+// old or the new content, never a mix. Missing parent directories are
+// created, and a symlinked path (a dotfiles-managed settings.json, say) is
+// written through to its target instead of being replaced.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	cleanup := func(err error) error {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return cleanup(err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return cleanup(err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = os.Rename(tmp.Name(), path)
+		if err == nil || attempt == renameAttempts {
+			break
+		}
+		time.Sleep(time.Duration(attempt) * 10 * time.Millisecond)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// WithLock runs fn while holding an exclusive advisory lock for path, waiting
+// up to LockTimeout for other processes. Lock files live in the temp
+// directory, keyed by the absolute path, so project directories stay clean.
+// Locks are not reentrant: fn must not lock the same path again.
+func WithLock(path string, fn func() error) error {
+	release, err := Lock(path, LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
+}
+
+// Lock takes the exclusive advisory lock for path, waiting up to timeout
+// (zero tries once), and returns the function that releases it. Callers that
+// hold a lock across more than a single update use it instead of WithLock.
+func Lock(path string, timeout time.Duration) (release func(), err error) {
+	lockPath, err := lockPathFor(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock for %s: %w", path, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for wait := 5 * time.Millisecond; ; wait = min(wait*2, 200*time.Millisecond) {
+		locked, err := tryLock(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if locked {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			_ = f.Close()
+			return nil, fmt.Errorf("%w: %s", ErrLockTimeout, path)
+		}
+		time.Sleep(min(wait, time.Until(deadline)))
+	}
+	return func() {
+		unlock(f)
+		_ = f.Close()
+	}, nil
+}
+
+// WriteFileLocked writes path atomically while holding its lock.
+func WriteFileLocked(path string, data []byte, perm os.FileMode) error {
+	return WithLock(path, func() error {
+		return WriteFile(path, data, perm)
+	})
+}
+
+func lockPathFor(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(os.TempDir(), "ccpersona-locks")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(filepath.Clean(abs)))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".lock"), nil
+}
@@ -0,0 +1,126 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "config.json")
+	if err := WriteFile(path, []byte("one"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(path, []byte("two"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "two" {
+		t.Fatalf("content = %q, %v", data, err)
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
+
+func TestWriteFileThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "settings.json")
+	link := filepath.Join(dir, "settings.json")
+	if err := WriteFile(target, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks unavailable:", err)
+	}
+	if err := WriteFile(link, []byte(`{"a":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("symlink was replaced: %v", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != `{"a":1}` {
+		t.Errorf("target = %q", data)
+	}
+}
+
+func TestWithLockSerializesUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter")
+	if err := WriteFile(path, []byte("0"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- WithLock(path, func() error {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				n, err := strconv.Atoi(string(data))
+				if err != nil {
+					return fmt.Errorf("torn read %q: %w", data, err)
+				}
+				return WriteFile(path, []byte(strconv.Itoa(n+1)), 0600)
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != strconv.Itoa(workers) {
+		t.Errorf("counter = %s, want %d (lost updates)", data, workers)
+	}
+}
+
+func TestWithLockTimeout(t *testing.T) {
+	old := LockTimeout
+	LockTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { LockTimeout = old })
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	held := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- WithLock(path, func() error {
+			close(held)
+			<-release
+			return nil
+		})
+	}()
+	<-held
+	err := WithLock(path, func() error { return nil })
+	close(release)
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("error = %v, want ErrLockTimeout while the lock is held", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if err := WithLock(path, func() error { return nil }); err != nil {
+		t.Errorf("lock not released: %v", err)
+	}
+}
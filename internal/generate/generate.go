@@ -0,0 +1,356 @@
+// Package generate drafts persona markdown with an LLM from a short
+// interview: what the persona is for, its tone, language, and strictness.
+package generate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// APIs the generator can call.
+const (
+	// APIAnthropic is the Claude Messages API.
+	APIAnthropic = "anthropic"
+	// APIOpenAI is the chat completions API, which OpenAI and most local
+	// servers (Ollama, LM Studio, vLLM) implement.
+	APIOpenAI = "openai"
+)
+
+// Defaults for each API.
+const (
+	DefaultAnthropicURL   = "https://api.anthropic.com/v1/messages"
+	DefaultAnthropicModel = "claude-sonnet-4-5"
+	DefaultOpenAIURL      = "https://api.openai.com/v1/chat/completions"
+	DefaultOpenAIModel    = "gpt-4o-mini"
+	DefaultMaxTokens      = 2048
+)
+
+// requestTimeout bounds one draft; long personas take a while to write.
+const requestTimeout = 2 * time.Minute
+
+// maxResponseBytes caps what is read from the endpoint.
+const maxResponseBytes = 1 << 20
+
+// anthropicVersion is the Messages API version header.
+const anthropicVersion = "2023-06-01"
+
+// Config selects the endpoint `persona generate` drafts with. It is read
+// from the global config only, so a cloned repository cannot send an API key
+// to an endpoint of its choosing.
+type Config struct {
+	// API is "anthropic" (default) or "openai".
+	API string `json:"api,omitempty"`
+	// URL overrides the endpoint, e.g. a proxy or a local server.
+	URL   string `json:"url,omitempty"`
+	Model string `json:"model,omitempty"`
+	// APIKeyEnv names the variable holding the API key (default:
+	// ANTHROPIC_API_KEY or OPENAI_API_KEY). Endpoints other than the
+	// defaults may run without a key.
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	MaxTokens int    `json:"max_tokens,omitempty"`
+}
+
+// Validate checks the API name and endpoint; safe on nil.
+func (c *Config) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.API {
+	case "", APIAnthropic, APIOpenAI:
+	default:
+		return fmt.Errorf("generate.api must be %q or %q, got %q", APIAnthropic, APIOpenAI, c.API)
+	}
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("generate.url must be an http(s) URL")
+		}
+	}
+	if c.MaxTokens < 0 {
+		return fmt.Errorf("generate.max_tokens must not be negative")
+	}
+	return nil
+}
+
+// Resolved returns c with defaults filled in; safe on nil.
+func (c *Config) Resolved() Config {
+	var out Config
+	if c != nil {
+		out = *c
+	}
+	if out.API == "" {
+		out.API = APIAnthropic
+	}
+	defaultURL, defaultModel, defaultKey := DefaultAnthropicURL, DefaultAnthropicModel, "ANTHROPIC_API_KEY"
+	if out.API == APIOpenAI {
+		defaultURL, defaultModel, defaultKey = DefaultOpenAIURL, DefaultOpenAIModel, "OPENAI_API_KEY"
+	}
+	if out.URL == "" {
+		out.URL = defaultURL
+	}
+	if out.Model == "" {
+		out.Model = defaultModel
+	}
+	if out.APIKeyEnv == "" {
+		out.APIKeyEnv = defaultKey
+	}
+	if out.MaxTokens == 0 {
+		out.MaxTokens = DefaultMaxTokens
+	}
+	return out
+}
+
+// hosted reports whether the endpoint is a hosted API that needs a key.
+func (c Config) hosted() bool {
+	return c.URL == DefaultAnthropicURL || c.URL == DefaultOpenAIURL
+}
+
+// Answers are the interview results the draft is written from.
+type Answers struct {
+	Name string
+	// Goal is what the persona is for, e.g. "review Go code for a payments team".
+	Goal string
+	// Tone describes how it talks, e.g. "calm and encouraging".
+	Tone string
+	// Language is the language the persona speaks, e.g. "ja" or "English".
+	Language string
+	// Strictness runs from 1 (relaxed) to 5 (uncompromising).
+	Strictness int
+}
+
+// Validate checks that the answers can produce a persona.
+func (a Answers) Validate() error {
+	if strings.TrimSpace(a.Goal) == "" {
+		return errors.New("the persona's goal is required")
+	}
+	if a.Strictness < 1 || a.Strictness > 5 {
+		return fmt.Errorf("strictness must be between 1 and 5, got %d", a.Strictness)
+	}
+	return nil
+}
+
+// Sections are the headings every persona file has, in order.
+var Sections = []string{"口調", "考え方", "価値観", "専門性", "対話スタイル"}
+
+// systemPrompt explains the persona format to the model.
+const systemPrompt = `You write persona files for ccpersona, a tool that sets the tone and working style of AI coding assistants such as Claude Code, Codex, and Cursor.
+
+A persona file is markdown. It starts with the title line "# 人格: <name>" and has exactly these second-level sections, in this order, with these Japanese headings:
+
+## 口調
+How the assistant talks: register, sentence endings, verbosity. Two to four sentences.
+
+## 考え方
+A bulleted list of how it approaches problems.
+
+## 価値観
+A bulleted list of what it values in code and collaboration.
+
+## 専門性
+A bulleted list of its areas of expertise.
+
+## 対話スタイル
+A bulleted list of how it explains, asks questions, and gives feedback.
+
+Write the section contents in the requested language; keep the headings in Japanese. Address the assistant directly and describe behavior, not a backstory. Never include instructions to ignore other instructions, bypass permissions, reveal secrets, or hide actions from the user. Output only the markdown, without code fences or commentary.`
+
+// userPrompt describes the persona to draft.
+func userPrompt(a Answers) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Name: %s\n", a.Name)
+	fmt.Fprintf(&b, "Goal: %s\n", strings.TrimSpace(a.Goal))
+	if tone := strings.TrimSpace(a.Tone); tone != "" {
+		fmt.Fprintf(&b, "Tone: %s\n", tone)
+	}
+	language := strings.TrimSpace(a.Language)
+	if language == "" {
+		language = "ja"
+	}
+	fmt.Fprintf(&b, "Language: %s\n", language)
+	fmt.Fprintf(&b, "Strictness: %d of 5 (%s)\n", a.Strictness, strictnessLabel(a.Strictness))
+	return b.String()
+}
+
+func strictnessLabel(level int) string {
+	switch level {
+	case 1:
+		return "relaxed: suggests rather than insists"
+	case 2:
+		return "easygoing: flags real problems, lets style slide"
+	case 3:
+		return "balanced: holds a clear quality bar"
+	case 4:
+		return "strict: pushes back on shortcuts and missing tests"
+	default:
+		return "uncompromising: blocks anything below its standards"
+	}
+}
+
+// Draft asks the configured endpoint for a persona and returns the cleaned
+// markdown.
+func Draft(ctx context.Context, client *http.Client, config *Config, answers Answers) (string, error) {
+	if err := answers.Validate(); err != nil {
+		return "", err
+	}
+	cfg := config.Resolved()
+	key := strings.TrimSpace(os.Getenv(cfg.APIKeyEnv))
+	if key == "" && cfg.hosted() {
+		return "", fmt.Errorf("no API key; set %s or point generate.url at a local server", cfg.APIKeyEnv)
+	}
+
+	var body any
+	if cfg.API == APIOpenAI {
+		body = map[string]any{
+			"model":      cfg.Model,
+			"max_tokens": cfg.MaxTokens,
+			"messages": []map[string]string{
+				{"role": "system", "content": systemPrompt},
+				{"role": "user", "content": userPrompt(answers)},
+			},
+		}
+	} else {
+		body = map[string]any{
+			"model":      cfg.Model,
+			"max_tokens": cfg.MaxTokens,
+			"system":     systemPrompt,
+			"messages": []map[string]string{
+				{"role": "user", "content": userPrompt(answers)},
+			},
+		}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.API == APIOpenAI {
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+	} else {
+		req.Header.Set("anthropic-version", anthropicVersion)
+		if key != "" {
+			req.Header.Set("x-api-key", key)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, apiError(data))
+	}
+
+	text, err := responseText(cfg.API, data)
+	if err != nil {
+		return "", err
+	}
+	content := Clean(text, answers.Name)
+	if content == "" {
+		return "", errors.New("the model returned an empty persona")
+	}
+	return content, nil
+}
+
+// responseText extracts the generated text from an API response.
+func responseText(api string, data []byte) (string, error) {
+	if api == APIOpenAI {
+		var reply struct {
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal(data, &reply); err != nil {
+			return "", fmt.Errorf("invalid response: %w", err)
+		}
+		if len(reply.Choices) == 0 {
+			return "", errors.New("the response has no choices")
+		}
+		return reply.Choices[0].Message.Content, nil
+	}
+	var reply struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return "", fmt.Errorf("invalid response: %w", err)
+	}
+	var b strings.Builder
+	for _, block := range reply.Content {
+		if block.Type == "text" {
+			b.WriteString(block.Text)
+		}
+	}
+	return b.String(), nil
+}
+
+// apiError returns the message from an error response, or a short excerpt.
+func apiError(data []byte) string {
+	var reply struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &reply) == nil && reply.Error.Message != "" {
+		return reply.Error.Message
+	}
+	text := strings.TrimSpace(string(data))
+	if len(text) > 200 {
+		text = text[:200] + "…"
+	}
+	return text
+}
+
+// Clean strips code fences and chatter around the markdown and makes sure
+// it starts with the "# 人格: <name>" title.
+func Clean(text, name string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		if _, rest, ok := strings.Cut(text, "\n"); ok {
+			text = rest
+		}
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+	if i := strings.Index(text, "# 人格"); i > 0 {
+		text = text[i:]
+	} else if i < 0 {
+		if j := strings.Index(text, "## "); j > 0 {
+			text = text[j:]
+		}
+	}
+	if text == "" {
+		return ""
+	}
+	title := "# 人格: " + name
+	if strings.HasPrefix(text, "# 人格") {
+		_, rest, _ := strings.Cut(text, "\n")
+		text = strings.TrimLeft(rest, "\n")
+	}
+	return title + "\n\n" + text + "\n"
+}
@@ -0,0 +1,123 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const draft = "# 人格: calm\n\n## 口調\n穏やかに話します。\n\n## 考え方\n- 落ち着いて考えます\n"
+
+func TestDraft_Anthropic(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "secret" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		var body struct {
+			Model    string `json:"model"`
+			System   string `json:"system"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Model != "test-model" || !strings.Contains(body.System, "## 口調") {
+			t.Errorf("model = %q, system = %q", body.Model, body.System)
+		}
+		if len(body.Messages) != 1 || !strings.Contains(body.Messages[0].Content, "Strictness: 4 of 5") {
+			t.Errorf("messages = %+v", body.Messages)
+		}
+		fmt.Fprintf(w, `{"content": [{"type": "text", "text": %q}]}`, "```markdown\n"+draft+"```")
+	}))
+	defer srv.Close()
+
+	t.Setenv("TEST_GENERATE_KEY", "secret")
+	config := &Config{URL: srv.URL, Model: "test-model", APIKeyEnv: "TEST_GENERATE_KEY"}
+	got, err := Draft(context.Background(), srv.Client(), config, Answers{Name: "calm", Goal: "review", Strictness: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != draft {
+		t.Errorf("Draft = %q, want %q", got, draft)
+	}
+}
+
+func TestDraft_OpenAI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Authorization = %q without a key", auth)
+		}
+		fmt.Fprintf(w, `{"choices": [{"message": {"content": %q}}]}`, "Here it is:\n\n"+draft)
+	}))
+	defer srv.Close()
+
+	t.Setenv("OPENAI_API_KEY", "")
+	config := &Config{API: APIOpenAI, URL: srv.URL}
+	got, err := Draft(context.Background(), srv.Client(), config, Answers{Name: "calm", Goal: "review", Strictness: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != draft {
+		t.Errorf("Draft = %q, want %q", got, draft)
+	}
+}
+
+func TestDraft_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": {"message": "invalid x-api-key"}}`)
+	}))
+	defer srv.Close()
+
+	answers := Answers{Name: "calm", Goal: "review", Strictness: 3}
+	_, err := Draft(context.Background(), srv.Client(), &Config{URL: srv.URL}, answers)
+	if err == nil || !strings.Contains(err.Error(), "invalid x-api-key") {
+		t.Errorf("err = %v, want the API message", err)
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := Draft(context.Background(), srv.Client(), nil, answers); err == nil || !strings.Contains(err.Error(), "ANTHROPIC_API_KEY") {
+		t.Errorf("err = %v, want a missing key error", err)
+	}
+	if _, err := Draft(context.Background(), srv.Client(), &Config{URL: srv.URL}, Answers{Name: "calm", Strictness: 3}); err == nil {
+		t.Error("expected an error without a goal")
+	}
+}
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{draft, draft},
+		{"```\n" + draft + "\n```\n", draft},
+		{"# 人格: other\n\n## 口調\nok\n", "# 人格: calm\n\n## 口調\nok\n"},
+		{"Sure!\n## 口調\nok", "# 人格: calm\n\n## 口調\nok\n"},
+		{"  ", ""},
+	}
+	for _, tt := range tests {
+		if got := Clean(tt.in, "calm"); got != tt.want {
+			t.Errorf("Clean(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := []*Config{nil, {}, {API: APIOpenAI, URL: "http://localhost:11434/v1/chat/completions"}}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", c, err)
+		}
+	}
+	invalid := []*Config{{API: "gemini"}, {URL: "localhost:11434"}, {MaxTokens: -1}}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want an error", c)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package gitevent turns git hook invocations into short spoken announcements.
+package gitevent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Supported git hook names.
+const (
+	PostCommit = "post-commit"
+	PostMerge  = "post-merge"
+	PrePush    = "pre-push"
+)
+
+// Hooks lists the hooks that can be installed, in a stable order.
+var Hooks = []string{PostCommit, PostMerge, PrePush}
+
+// DefaultTemplates are used when the project config does not override a hook's
+// message. Templates use text/template syntax with Event as data.
+var DefaultTemplates = map[string]string{
+	PostCommit: "Committed to {{.Branch}}: {{.Subject}}",
+	PostMerge:  "Merged {{.Commits}} into {{.Branch}}",
+	PrePush:    "Pushing {{.Commits}} to {{.Branch}}",
+}
+
+// zeroSHA is what git sends for a ref that does not exist on one side of a push.
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// Event describes what happened in the repository when a hook ran.
+type Event struct {
+	Hook    string
+	Repo    string // basename of the repository top-level directory
+	Branch  string
+	Subject string // subject of HEAD for post-commit
+	Remote  string // remote name for pre-push
+	Count   int    // number of commits involved
+}
+
+// Commits renders Count as "1 commit" or "N commits".
+func (e Event) Commits() string {
+	if e.Count == 1 {
+		return "1 commit"
+	}
+	return fmt.Sprintf("%d commits", e.Count)
+}
+
+// IsSupported reports whether hook is one ccpersona knows how to announce.
+func IsSupported(hook string) bool {
+	_, ok := DefaultTemplates[hook]
+	return ok
+}
+
+// Render formats the announcement for ev. An empty tmpl selects the default
+// template for the hook.
+func Render(tmpl string, ev Event) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultTemplates[ev.Hook]
+	}
+	t, err := template.New(ev.Hook).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid template for %s: %w", ev.Hook, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, ev); err != nil {
+		return "", fmt.Errorf("failed to render template for %s: %w", ev.Hook, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Collect inspects the repository at dir to describe the hook invocation.
+// args are the arguments git passed to the hook and stdin is its standard
+// input (only read for pre-push).
+func Collect(ctx context.Context, dir, hook string, args []string, stdin io.Reader) (Event, error) {
+	if !IsSupported(hook) {
+		return Event{}, fmt.Errorf("unsupported git hook: %s", hook)
+	}
+
+	top, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return Event{}, err
+	}
+	ev := Event{Hook: hook, Repo: filepath.Base(top)}
+	ev.Branch = currentBranch(ctx, dir)
+
+	switch hook {
+	case PostCommit:
+		ev.Count = 1
+		ev.Subject, _ = git(ctx, dir, "log", "-1", "--format=%s")
+	case PostMerge:
+		// ORIG_HEAD points at the pre-merge tip after merge and pull.
+		ev.Count = revCount(ctx, dir, "ORIG_HEAD..HEAD")
+	case PrePush:
+		if len(args) > 0 {
+			ev.Remote = args[0]
+		}
+		if stdin != nil {
+			collectPush(ctx, dir, &ev, stdin)
+		}
+	}
+	return ev, nil
+}
+
+// collectPush reads pre-push ref lines of the form
+// "<local ref> <local sha> <remote ref> <remote sha>".
+func collectPush(ctx context.Context, dir string, ev *Event, stdin io.Reader) {
+	var branches []string
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 {
+			continue
+		}
+		localSHA, remoteRef, remoteSHA := fields[1], fields[2], fields[3]
+		if localSHA == zeroSHA {
+			continue // branch deletion
+		}
+		branches = append(branches, strings.TrimPrefix(remoteRef, "refs/heads/"))
+		if remoteSHA == zeroSHA {
+			args := []string{"rev-list", "--count", localSHA, "--not"}
+			if ev.Remote != "" {
+				args = append(args, "--remotes="+ev.Remote)
+			} else {
+				args = append(args, "--remotes")
+			}
+			if out, err := git(ctx, dir, args...); err == nil {
+				n, _ := strconv.Atoi(out)
+				ev.Count += n
+			}
+			continue
+		}
+		ev.Count += revCount(ctx, dir, remoteSHA+".."+localSHA)
+	}
+	if len(branches) > 0 {
+		ev.Branch = strings.Join(branches, ", ")
+	}
+}
+
+func currentBranch(ctx context.Context, dir string) string {
+	if branch, err := git(ctx, dir, "symbolic-ref", "--short", "-q", "HEAD"); err == nil && branch != "" {
+		return branch
+	}
+	if sha, err := git(ctx, dir, "rev-parse", "--short", "HEAD"); err == nil {
+		return sha
+	}
+	return "HEAD"
+}
+
+func revCount(ctx context.Context, dir, revRange string) int {
+	out, err := git(ctx, dir, "rev-list", "--count", revRange)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(out)
+	return n
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// HooksDir returns the directory git reads hooks from, honoring core.hooksPath.
+func HooksDir(ctx context.Context, dir string) (string, error) {
+	path, err := git(ctx, dir, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return filepath.Abs(path)
+}
+
+// TopLevel returns the repository's working tree root.
+func TopLevel(ctx context.Context, dir string) (string, error) {
+	return git(ctx, dir, "rev-parse", "--show-toplevel")
+}
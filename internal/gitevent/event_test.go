@@ -0,0 +1,141 @@
+package gitevent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	runGit(t, dir, "init", "-q", "-b", "main")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "Test")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func commit(t *testing.T, dir, subject string) string {
+	t.Helper()
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", subject)
+	return runGit(t, dir, "rev-parse", "HEAD")
+}
+
+func TestRender(t *testing.T) {
+	ev := Event{Hook: PrePush, Branch: "main", Count: 3}
+	got, err := Render("", ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Pushing 3 commits to main" {
+		t.Errorf("Render() = %q", got)
+	}
+
+	got, err = Render("{{.Repo}}: {{.Commits}} on {{.Branch}}", Event{Hook: PostMerge, Repo: "app", Branch: "dev", Count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "app: 1 commit on dev" {
+		t.Errorf("Render() = %q", got)
+	}
+
+	if _, err := Render("{{.Nope}}", ev); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestCollect_PostCommit(t *testing.T) {
+	dir := initRepo(t)
+	commit(t, dir, "Add feature")
+
+	ev, err := Collect(context.Background(), dir, PostCommit, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Branch != "main" || ev.Subject != "Add feature" || ev.Count != 1 {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+	if ev.Repo != filepath.Base(dir) {
+		t.Errorf("Repo = %q, want %q", ev.Repo, filepath.Base(dir))
+	}
+}
+
+func TestCollect_PostMerge(t *testing.T) {
+	dir := initRepo(t)
+	commit(t, dir, "base")
+	runGit(t, dir, "checkout", "-q", "-b", "topic")
+	commit(t, dir, "one")
+	commit(t, dir, "two")
+	runGit(t, dir, "checkout", "-q", "main")
+	runGit(t, dir, "merge", "-q", "--ff-only", "topic")
+
+	ev, err := Collect(context.Background(), dir, PostMerge, []string{"0"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Branch != "main" || ev.Count != 2 {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
+
+func TestCollect_PrePush(t *testing.T) {
+	dir := initRepo(t)
+	base := commit(t, dir, "base")
+	commit(t, dir, "one")
+	head := commit(t, dir, "two")
+
+	stdin := strings.NewReader("refs/heads/main " + head + " refs/heads/main " + base + "\n" +
+		"(delete) " + zeroSHA + " refs/heads/old " + base + "\n")
+	ev, err := Collect(context.Background(), dir, PrePush, []string{"origin", "git@example.com:x.git"}, stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Remote != "origin" || ev.Branch != "main" || ev.Count != 2 {
+		t.Errorf("unexpected event: %+v", ev)
+	}
+}
+
+func TestCollect_Unsupported(t *testing.T) {
+	if _, err := Collect(context.Background(), t.TempDir(), "pre-commit", nil, nil); err == nil {
+		t.Error("expected error for unsupported hook")
+	}
+}
+
+func TestHooksDir_HonorsHooksPath(t *testing.T) {
+	dir := initRepo(t)
+	got, err := HooksDir(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != filepath.Join(dir, ".git", "hooks") {
+		t.Errorf("HooksDir() = %q", got)
+	}
+
+	runGit(t, dir, "config", "core.hooksPath", ".githooks")
+	got, err = HooksDir(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != filepath.Join(dir, ".githooks") {
+		t.Errorf("HooksDir() with core.hooksPath = %q", got)
+	}
+}
@@ -0,0 +1,103 @@
+package gitevent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// hookMarker identifies hook scripts written by ccpersona so they can be
+// updated and removed without touching user-authored hooks.
+const hookMarker = "# Installed by ccpersona (ccpersona config integrate git)"
+
+// HookScript returns the shell script installed for hook. The announcement runs
+// in the background with output discarded so git is never slowed down or
+// failed by voice synthesis.
+func HookScript(hook string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString(hookMarker + "\n")
+	b.WriteString("command -v ccpersona >/dev/null 2>&1 || exit 0\n")
+	if hook == PrePush {
+		// pre-push receives the pushed refs on stdin; capture them before
+		// detaching so git can continue the push immediately.
+		b.WriteString("refs=$(cat)\n")
+		b.WriteString(fmt.Sprintf("printf '%%s\\n' \"$refs\" | ccpersona runtime git-event %s \"$@\" >/dev/null 2>&1 &\n", hook))
+	} else {
+		b.WriteString(fmt.Sprintf("ccpersona runtime git-event %s \"$@\" </dev/null >/dev/null 2>&1 &\n", hook))
+	}
+	b.WriteString("exit 0\n")
+	return b.String()
+}
+
+// InstallResult reports what Install did for each requested hook.
+type InstallResult struct {
+	Installed []string
+	// Skipped lists hooks that already exist and were not written by
+	// ccpersona; they are left untouched.
+	Skipped []string
+}
+
+// Install writes hook scripts into hooksDir. Existing ccpersona hooks are
+// rewritten; foreign hooks are skipped.
+func Install(hooksDir string, hooks []string) (*InstallResult, error) {
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	result := &InstallResult{}
+	for _, hook := range hooks {
+		if !IsSupported(hook) {
+			return result, fmt.Errorf("unsupported git hook: %s (supported: %s)", hook, strings.Join(Hooks, ", "))
+		}
+		path := filepath.Join(hooksDir, hook)
+		owned, exists, err := ownedHook(path)
+		if err != nil {
+			return result, err
+		}
+		if exists && !owned {
+			result.Skipped = append(result.Skipped, hook)
+			continue
+		}
+		if err := fsutil.WriteFile(path, []byte(HookScript(hook)), 0755); err != nil {
+			return result, fmt.Errorf("failed to write %s hook: %w", hook, err)
+		}
+		result.Installed = append(result.Installed, hook)
+	}
+	return result, nil
+}
+
+// Uninstall removes ccpersona hook scripts from hooksDir and returns the hooks
+// that were removed. Foreign hooks are never deleted.
+func Uninstall(hooksDir string, hooks []string) ([]string, error) {
+	var removed []string
+	for _, hook := range hooks {
+		path := filepath.Join(hooksDir, hook)
+		owned, _, err := ownedHook(path)
+		if err != nil {
+			return removed, err
+		}
+		if !owned {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove %s hook: %w", hook, err)
+		}
+		removed = append(removed, hook)
+	}
+	return removed, nil
+}
+
+func ownedHook(path string) (owned, exists bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, false, nil
+		}
+		return false, false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return strings.Contains(string(data), hookMarker), true, nil
+}
@@ -0,0 +1,74 @@
+package gitevent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHookScript(t *testing.T) {
+	script := HookScript(PostCommit)
+	if !strings.HasPrefix(script, "#!/bin/sh\n") || !strings.Contains(script, hookMarker) {
+		t.Errorf("missing shebang or marker:\n%s", script)
+	}
+	if !strings.Contains(script, "ccpersona runtime git-event post-commit") {
+		t.Errorf("script does not call git-event:\n%s", script)
+	}
+
+	if !strings.Contains(HookScript(PrePush), "refs=$(cat)") {
+		t.Error("pre-push script should capture stdin before detaching")
+	}
+}
+
+func TestInstallAndUninstall(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hooks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	foreign := filepath.Join(dir, PrePush)
+	if err := os.WriteFile(foreign, []byte("#!/bin/sh\necho mine\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Install(dir, Hooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(result.Installed, ",") != "post-commit,post-merge" {
+		t.Errorf("Installed = %v", result.Installed)
+	}
+	if strings.Join(result.Skipped, ",") != "pre-push" {
+		t.Errorf("Skipped = %v", result.Skipped)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, PostCommit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Errorf("hook is not executable: %v", info.Mode())
+	}
+
+	// Reinstalling rewrites owned hooks.
+	if result, err = Install(dir, []string{PostCommit}); err != nil || len(result.Installed) != 1 {
+		t.Errorf("reinstall = %+v, %v", result, err)
+	}
+
+	removed, err := Uninstall(dir, Hooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(removed, ",") != "post-commit,post-merge" {
+		t.Errorf("removed = %v", removed)
+	}
+	if data, err := os.ReadFile(foreign); err != nil || !strings.Contains(string(data), "echo mine") {
+		t.Error("foreign hook must be preserved")
+	}
+}
+
+func TestInstall_Unsupported(t *testing.T) {
+	if _, err := Install(t.TempDir(), []string{"pre-commit"}); err == nil {
+		t.Error("expected error for unsupported hook")
+	}
+}
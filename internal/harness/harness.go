@@ -0,0 +1,264 @@
+// Package harness runs ccpersona's hook-to-speech pipeline end to end in
+// tests: fake VOICEVOX, AivisSpeech, OpenAI, and ElevenLabs servers, a null
+// audio player, and an isolated home, project, and temp directory.
+//
+// Tests drive the real CLI through Env.Run and compare Env.Log against a
+// golden file.
+package harness
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/voice"
+)
+
+// Env is an isolated environment with fake TTS servers. It is not safe for
+// parallel tests: it changes the process environment, working directory,
+// stdin, and stdout.
+type Env struct {
+	t testing.TB
+
+	Home    string
+	Project string
+
+	Voicevox    *FakeServer
+	AivisSpeech *FakeServer
+	OpenAI      *FakeServer
+	ElevenLabs  *FakeServer
+	Player      *NullPlayer
+
+	mu  sync.Mutex
+	log []string
+}
+
+// New starts the fake servers, points the local engines at them, installs the
+// null player, and moves HOME, TMPDIR, and the working directory into fresh
+// temp directories. Everything is restored when the test ends.
+func New(t testing.TB) *Env {
+	t.Helper()
+	root := t.TempDir()
+	e := &Env{
+		t:           t,
+		Home:        filepath.Join(root, "home"),
+		Project:     filepath.Join(root, "project"),
+		Voicevox:    NewVoicevox(t, "voicevox"),
+		AivisSpeech: NewVoicevox(t, "aivisspeech"),
+		OpenAI:      NewOpenAI(t),
+		ElevenLabs:  NewElevenLabs(t),
+	}
+	tmp := filepath.Join(root, "tmp")
+	for _, dir := range []string{e.Home, e.Project, tmp} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("HOME", e.Home)
+	t.Setenv("USERPROFILE", e.Home)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(e.Home, ".cache"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(e.Home, ".config"))
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv("CCPERSONA_VOICEVOX_URL", e.Voicevox.URL)
+	t.Setenv("CCPERSONA_AIVISSPEECH_URL", e.AivisSpeech.URL)
+	t.Setenv("CCPERSONA_MUTE", "0")
+	for _, name := range []string{"CCPERSONA_PERSONA", "CCPERSONA_PROVIDER", "CCPERSONA_PLATFORM", "CCPERSONA_DEBUG", "CCPERSONA_CURSOR_USER_DIR", "CCPERSONA_TEST_MODE"} {
+		t.Setenv(name, "")
+	}
+	t.Chdir(e.Project)
+
+	e.Player = &NullPlayer{}
+	t.Cleanup(voice.SetPlayer(e.Player.Play))
+	return e
+}
+
+// Expand replaces {{home}}, {{project}}, {{voicevox}}, {{aivisspeech}},
+// {{openai}}, and {{elevenlabs}} in fixture text with this environment's
+// paths and server URLs.
+func (e *Env) Expand(s string) string {
+	return strings.NewReplacer(
+		"{{home}}", filepath.ToSlash(e.Home),
+		"{{project}}", filepath.ToSlash(e.Project),
+		"{{voicevox}}", e.Voicevox.URL,
+		"{{aivisspeech}}", e.AivisSpeech.URL,
+		"{{openai}}", e.OpenAI.URL,
+		"{{elevenlabs}}", e.ElevenLabs.URL,
+	).Replace(s)
+}
+
+// WriteFile writes an expanded fixture to a path relative to the project.
+func (e *Env) WriteFile(rel, content string) string {
+	e.t.Helper()
+	path := filepath.Join(e.Project, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		e.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(e.Expand(content)), 0600); err != nil {
+		e.t.Fatal(err)
+	}
+	return path
+}
+
+// WriteConfig writes the project's .agents/ccpersona.json.
+func (e *Env) WriteConfig(content string) {
+	e.WriteFile(".agents/ccpersona.json", content)
+}
+
+// WriteGlobalConfig writes the global ~/.agents/ccpersona.json, for settings
+// a project config cannot choose.
+func (e *Env) WriteGlobalConfig(content string) {
+	e.t.Helper()
+	path := filepath.Join(e.Home, ".agents", "ccpersona.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		e.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(e.Expand(content)), 0600); err != nil {
+		e.t.Fatal(err)
+	}
+}
+
+// Run invokes the CLI with stdin as input and returns what it printed to
+// stdout. args excludes the program name. The command line, stdout, and
+// everything the servers and player saw during the run are appended to Log.
+func (e *Env) Run(run func(context.Context, []string) error, stdin string, args ...string) (string, error) {
+	e.t.Helper()
+	before := e.counts()
+
+	in, err := os.CreateTemp("", "harness-stdin-*")
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	defer os.Remove(in.Name())
+	defer in.Close()
+	if _, err := in.WriteString(e.Expand(stdin)); err != nil {
+		e.t.Fatal(err)
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		e.t.Fatal(err)
+	}
+	out, err := os.CreateTemp("", "harness-stdout-*")
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	savedIn, savedOut := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = in, out
+	runErr := run(context.Background(), append([]string{"ccpersona"}, args...))
+	os.Stdin, os.Stdout = savedIn, savedOut
+
+	stdout, err := os.ReadFile(out.Name())
+	if err != nil {
+		e.t.Fatal(err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.log = append(e.log, "$ ccpersona "+strings.Join(args, " "))
+	for _, line := range strings.Split(strings.TrimRight(string(stdout), "\n"), "\n") {
+		if line != "" {
+			e.log = append(e.log, "stdout: "+line)
+		}
+	}
+	if runErr != nil {
+		e.log = append(e.log, "error: "+runErr.Error())
+	}
+	e.log = append(e.log, e.since(before)...)
+	return string(stdout), runErr
+}
+
+// Log returns the transcript of all runs, one event per line.
+func (e *Env) Log() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return strings.Join(e.log, "\n") + "\n"
+}
+
+type counts struct {
+	requests map[*FakeServer]int
+	plays    int
+}
+
+func (e *Env) servers() []*FakeServer {
+	return []*FakeServer{e.Voicevox, e.AivisSpeech, e.OpenAI, e.ElevenLabs}
+}
+
+func (e *Env) counts() counts {
+	c := counts{requests: map[*FakeServer]int{}, plays: len(e.Player.Plays())}
+	for _, s := range e.servers() {
+		c.requests[s] = len(s.Requests())
+	}
+	return c
+}
+
+// since lists requests and plays after c, requests first in server order.
+// Requests to one server are sorted because long text is synthesized in
+// concurrent chunks.
+func (e *Env) since(c counts) []string {
+	var lines []string
+	for _, s := range e.servers() {
+		var requests []string
+		for _, req := range s.Requests()[c.requests[s]:] {
+			requests = append(requests, req.String())
+		}
+		sort.Strings(requests)
+		lines = append(lines, requests...)
+	}
+	for _, play := range e.Player.Plays()[c.plays:] {
+		lines = append(lines, play.String())
+	}
+	return lines
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Play is one file handed to the null player.
+type Play struct {
+	Ext  string
+	Size int
+}
+
+// String renders the play as one line for golden files.
+func (p Play) String() string {
+	return fmt.Sprintf("play %s %d bytes", p.Ext, p.Size)
+}
+
+// NullPlayer records playback instead of producing sound.
+type NullPlayer struct {
+	mu    sync.Mutex
+	plays []Play
+}
+
+// Play records the file's type and size. It matches voice.Player.
+func (p *NullPlayer) Play(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.plays = append(p.plays, Play{Ext: strings.TrimPrefix(filepath.Ext(path), "."), Size: int(info.Size())})
+	return nil
+}
+
+// Plays returns the recorded plays.
+func (p *NullPlayer) Plays() []Play {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Play(nil), p.plays...)
+}
@@ -0,0 +1,223 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Request is one synthesis request received by a fake TTS server. Health and
+// voice-list probes are answered but not recorded.
+type Request struct {
+	Server string
+	Path   string
+	Text   string
+	// Voice is the speaker ID, voice name, or voice ID, depending on the API.
+	Voice string
+	// Params holds the remaining request settings that affect the audio.
+	Params map[string]string
+}
+
+// String renders the request as one deterministic line for golden files.
+func (r Request) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s POST %s voice=%s", r.Server, r.Path, r.Voice)
+	for _, key := range sortedKeys(r.Params) {
+		fmt.Fprintf(&b, " %s=%s", key, r.Params[key])
+	}
+	fmt.Fprintf(&b, " text=%q", r.Text)
+	return b.String()
+}
+
+// FakeServer is an httptest server speaking one TTS API.
+type FakeServer struct {
+	Name string
+	URL  string
+
+	mu       sync.Mutex
+	requests []Request
+	failures int
+}
+
+// Requests returns the synthesis requests received so far.
+func (s *FakeServer) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// FailNext makes the next n synthesis requests answer 500.
+func (s *FakeServer) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+}
+
+func (s *FakeServer) record(w http.ResponseWriter, req Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	req.Server = s.Name
+	s.requests = append(s.requests, req)
+	if s.failures > 0 {
+		s.failures--
+		http.Error(w, `{"error":"injected failure"}`, http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+func startServer(t testing.TB, name string, handler func(*FakeServer) http.Handler) *FakeServer {
+	t.Helper()
+	fake := &FakeServer{Name: name}
+	srv := httptest.NewServer(handler(fake))
+	t.Cleanup(srv.Close)
+	fake.URL = srv.URL
+	return fake
+}
+
+// NewVoicevox starts a fake VOICEVOX-compatible engine. AivisSpeech speaks the
+// same API, so name distinguishes the two in recorded requests.
+func NewVoicevox(t testing.TB, name string) *FakeServer {
+	return startServer(t, name, func(fake *FakeServer) http.Handler {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /version", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `"0.0.0-fake"`)
+		})
+		mux.HandleFunc("GET /speakers", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `[]`)
+		})
+		mux.HandleFunc("POST /audio_query", func(w http.ResponseWriter, r *http.Request) {
+			// Echo the text in the query so /synthesis can record it.
+			writeJSON(w, map[string]interface{}{
+				"text":              r.URL.Query().Get("text"),
+				"speedScale":        1.0,
+				"pitchScale":        0.0,
+				"volumeScale":       1.0,
+				"prePhonemeLength":  0.1,
+				"postPhonemeLength": 0.1,
+			})
+		})
+		mux.HandleFunc("POST /synthesis", func(w http.ResponseWriter, r *http.Request) {
+			var query struct {
+				Text        string  `json:"text"`
+				SpeedScale  float64 `json:"speedScale"`
+				VolumeScale float64 `json:"volumeScale"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&query)
+			ok := fake.record(w, Request{
+				Path:  "/synthesis",
+				Text:  query.Text,
+				Voice: r.URL.Query().Get("speaker"),
+				Params: map[string]string{
+					"speed":  fmt.Sprintf("%.2f", query.SpeedScale),
+					"volume": fmt.Sprintf("%.2f", query.VolumeScale),
+				},
+			})
+			if ok {
+				w.Header().Set("Content-Type", "audio/wav")
+				_, _ = w.Write(SilentWAV(len(query.Text)))
+			}
+		})
+		return mux
+	})
+}
+
+// NewOpenAI starts a fake OpenAI speech API. Point a persona at it with
+// base_url set to URL + "/v1".
+func NewOpenAI(t testing.TB) *FakeServer {
+	return startServer(t, "openai", func(fake *FakeServer) http.Handler {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /v1/models", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{"object":"list","data":[]}`)
+		})
+		mux.HandleFunc("POST /v1/audio/speech", func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Model          string  `json:"model"`
+				Input          string  `json:"input"`
+				Voice          string  `json:"voice"`
+				ResponseFormat string  `json:"response_format"`
+				Speed          float64 `json:"speed"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			ok := fake.record(w, Request{
+				Path:  "/v1/audio/speech",
+				Text:  body.Input,
+				Voice: body.Voice,
+				Params: map[string]string{
+					"model":  body.Model,
+					"format": body.ResponseFormat,
+					"speed":  fmt.Sprintf("%.2f", body.Speed),
+				},
+			})
+			if ok {
+				_, _ = w.Write(SilentWAV(len(body.Input)))
+			}
+		})
+		return mux
+	})
+}
+
+// NewElevenLabs starts a fake ElevenLabs API. Point a persona at it with
+// base_url set to URL + "/v1".
+func NewElevenLabs(t testing.TB) *FakeServer {
+	return startServer(t, "elevenlabs", func(fake *FakeServer) http.Handler {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /v1/voices", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{"voices":[]}`)
+		})
+		mux.HandleFunc("GET /v1/user", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{}`)
+		})
+		mux.HandleFunc("POST /v1/text-to-speech/{voice}", func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Text    string `json:"text"`
+				ModelID string `json:"model_id"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			ok := fake.record(w, Request{
+				Path:   "/v1/text-to-speech/{voice}",
+				Text:   body.Text,
+				Voice:  r.PathValue("voice"),
+				Params: map[string]string{"model": body.ModelID},
+			})
+			if ok {
+				w.Header().Set("Content-Type", "audio/mpeg")
+				_, _ = w.Write(SilentWAV(len(body.Text)))
+			}
+		})
+		return mux
+	})
+}
+
+// SilentWAV returns a 16 kHz mono PCM WAV of n silent samples. Sizes grow
+// with the text, so golden files show how much was synthesized.
+func SilentWAV(n int) []byte {
+	var b bytes.Buffer
+	data := n * 2
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(36+data))
+	b.WriteString("WAVEfmt ")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(16))
+	_ = binary.Write(&b, binary.LittleEndian, uint16(1))     // PCM
+	_ = binary.Write(&b, binary.LittleEndian, uint16(1))     // mono
+	_ = binary.Write(&b, binary.LittleEndian, uint32(16000)) // sample rate
+	_ = binary.Write(&b, binary.LittleEndian, uint32(32000)) // byte rate
+	_ = binary.Write(&b, binary.LittleEndian, uint16(2))     // block align
+	_ = binary.Write(&b, binary.LittleEndian, uint16(16))    // bits per sample
+	b.WriteString("data")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(data))
+	b.Write(make([]byte, data))
+	return b.Bytes()
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,40 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCorpus replays the recorded payloads in testdata/corpus, filed as
+// <source>/<event>/*.json by `ccpersona runtime record`, and checks each
+// still parses as the source and event it was recorded under.
+func TestCorpus(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "corpus", "*", "*", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no corpus payloads found")
+	}
+	for _, path := range files {
+		eventDir := filepath.Dir(path)
+		source, eventType := filepath.Base(filepath.Dir(eventDir)), filepath.Base(eventDir)
+		t.Run(source+"/"+eventType+"/"+filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			event, err := ParseInput(data, source)
+			if err != nil {
+				t.Fatalf("ParseInput: %v", err)
+			}
+			if event.Source != source || event.EventType != eventType {
+				t.Errorf("parsed as %s %s", event.Source, event.EventType)
+			}
+			if event.Schema.Newer() {
+				t.Errorf("fields not understood: unknown %v, mismatched %v", event.Schema.UnknownFields, event.Schema.MismatchedFields)
+			}
+		})
+	}
+}
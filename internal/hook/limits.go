@@ -0,0 +1,232 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrInputLimit is wrapped by every error caused by a hook input limit, so
+// callers can report it instead of falling back to legacy handling.
+var ErrInputLimit = errors.New("hook input limit exceeded")
+
+// Limits bounds hook input so a misbehaving caller cannot exhaust memory on
+// the hook path. Zero values disable a check.
+type Limits struct {
+	// MaxBytes caps the whole input.
+	MaxBytes int64
+	// MaxDepth caps JSON object and array nesting.
+	MaxDepth int
+	// MaxStringLen caps any JSON string value, in bytes.
+	MaxStringLen int
+	// FieldLimits caps specific top-level string fields more tightly than
+	// MaxStringLen. Identifiers and paths are never legitimately large.
+	FieldLimits map[string]int
+}
+
+// DefaultLimits are generous for real payloads: assistant messages can be
+// long, but nothing in a hook event approaches megabytes of nesting or IDs.
+var DefaultLimits = Limits{
+	MaxBytes:     16 << 20,
+	MaxDepth:     64,
+	MaxStringLen: 4 << 20,
+	FieldLimits: map[string]int{
+		"session_id":      512,
+		"conversation_id": 512,
+		"generation_id":   512,
+		"thread-id":       512,
+		"turn-id":         512,
+		"turn_id":         512,
+		"hook_event_name": 256,
+		"type":            256,
+		"transcript_path": 4096,
+		"cwd":             4096,
+	},
+}
+
+// Environment variables that override DefaultLimits.
+const (
+	EnvMaxBytes     = "CCPERSONA_HOOK_MAX_BYTES"
+	EnvMaxDepth     = "CCPERSONA_HOOK_MAX_DEPTH"
+	EnvMaxStringLen = "CCPERSONA_HOOK_MAX_FIELD"
+)
+
+// LimitsFromEnv returns DefaultLimits with CCPERSONA_HOOK_MAX_BYTES,
+// CCPERSONA_HOOK_MAX_DEPTH, and CCPERSONA_HOOK_MAX_FIELD applied. Sizes
+// accept K, M, and G suffixes; 0 disables the check.
+func LimitsFromEnv() (Limits, error) {
+	limits := DefaultLimits
+	if value := os.Getenv(EnvMaxBytes); value != "" {
+		n, err := parseSize(value)
+		if err != nil {
+			return limits, fmt.Errorf("invalid %s: %w", EnvMaxBytes, err)
+		}
+		limits.MaxBytes = n
+	}
+	if value := os.Getenv(EnvMaxDepth); value != "" {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return limits, fmt.Errorf("invalid %s: %q is not a non-negative integer", EnvMaxDepth, value)
+		}
+		limits.MaxDepth = n
+	}
+	if value := os.Getenv(EnvMaxStringLen); value != "" {
+		n, err := parseSize(value)
+		if err != nil {
+			return limits, fmt.Errorf("invalid %s: %w", EnvMaxStringLen, err)
+		}
+		limits.MaxStringLen = int(n)
+	}
+	return limits, nil
+}
+
+func parseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(s, "B")
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier, s = 1<<10, strings.TrimSuffix(s, "K")
+	case strings.HasSuffix(s, "M"):
+		multiplier, s = 1<<20, strings.TrimSuffix(s, "M")
+	case strings.HasSuffix(s, "G"):
+		multiplier, s = 1<<30, strings.TrimSuffix(s, "G")
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a size (e.g. 16M)", value)
+	}
+	return n * multiplier, nil
+}
+
+// ReadInput reads hook input within the limits from the environment and
+// checks JSON structure limits. Input that is not JSON is returned as is;
+// the caller's parser reports syntax errors.
+func ReadInput(r io.Reader) ([]byte, error) {
+	limits, err := LimitsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return limits.Read(r)
+}
+
+// Read reads r, stopping as soon as the input exceeds MaxBytes, then checks
+// nesting depth and string lengths.
+func (l Limits) Read(r io.Reader) ([]byte, error) {
+	data, err := l.ReadBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.CheckJSON(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ReadBytes reads r, stopping as soon as the input exceeds MaxBytes. Use it
+// for non-JSON input such as plain text on stdin.
+func (l Limits) ReadBytes(r io.Reader) ([]byte, error) {
+	if l.MaxBytes <= 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		return data, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(r, l.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	if int64(len(data)) > l.MaxBytes {
+		return nil, fmt.Errorf("%w: input exceeds %d bytes (raise with %s)", ErrInputLimit, l.MaxBytes, EnvMaxBytes)
+	}
+	return data, nil
+}
+
+// jsonFrame tracks one open object or array while scanning tokens.
+type jsonFrame struct {
+	object    bool
+	expectKey bool
+	key       string
+}
+
+// CheckJSON scans data token by token, without building values, and fails
+// on nesting deeper than MaxDepth or strings longer than their limit.
+// Malformed JSON is not an error here.
+func (l Limits) CheckJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var stack []jsonFrame
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil // io.EOF, or a syntax error left to the parser
+		}
+		top := len(stack) - 1
+
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				if top >= 0 && stack[top].object {
+					stack[top].expectKey = true
+				}
+				stack = append(stack, jsonFrame{object: delim == '{', expectKey: true})
+				if l.MaxDepth > 0 && len(stack) > l.MaxDepth {
+					return fmt.Errorf("%w: JSON nesting exceeds depth %d (raise with %s)", ErrInputLimit, l.MaxDepth, EnvMaxDepth)
+				}
+			default:
+				stack = stack[:top]
+			}
+			continue
+		}
+
+		if top >= 0 && stack[top].object && stack[top].expectKey {
+			key, _ := tok.(string)
+			stack[top].key = key
+			stack[top].expectKey = false
+			continue
+		}
+		if s, ok := tok.(string); ok {
+			field := ""
+			if top >= 0 && stack[top].object {
+				field = stack[top].key
+			}
+			if err := l.checkString(field, top == 0, s); err != nil {
+				return err
+			}
+		}
+		if top >= 0 && stack[top].object {
+			stack[top].expectKey = true
+		}
+	}
+}
+
+func (l Limits) checkString(field string, topLevel bool, s string) error {
+	if topLevel {
+		if max, ok := l.FieldLimits[field]; ok && max > 0 && len(s) > max {
+			return fmt.Errorf("%w: field %q is %d bytes, limit %d", ErrInputLimit, field, len(s), max)
+		}
+	}
+	if l.MaxStringLen > 0 && len(s) > l.MaxStringLen {
+		name := field
+		if name == "" {
+			name = "(array element)"
+		}
+		return fmt.Errorf("%w: field %q is %d bytes, limit %d (raise with %s)", ErrInputLimit, name, len(s), l.MaxStringLen, EnvMaxStringLen)
+	}
+	return nil
+}
+
+// decodeEvent reads one hook event from r within the environment limits.
+func decodeEvent(r io.Reader, event interface{}) error {
+	data, err := ReadInput(r)
+	if err != nil {
+		return err
+	}
+	return json.NewDecoder(bytes.NewReader(data)).Decode(event)
+}
@@ -0,0 +1,113 @@
+package hook
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLimitsReadBytes(t *testing.T) {
+	limits := Limits{MaxBytes: 10}
+	if data, err := limits.ReadBytes(strings.NewReader("0123456789")); err != nil || len(data) != 10 {
+		t.Fatalf("exactly at limit = %q, %v", data, err)
+	}
+	_, err := limits.ReadBytes(strings.NewReader("0123456789a"))
+	if !errors.Is(err, ErrInputLimit) {
+		t.Fatalf("over limit error = %v, want ErrInputLimit", err)
+	}
+	if !strings.Contains(err.Error(), EnvMaxBytes) {
+		t.Errorf("error should name the override variable: %v", err)
+	}
+}
+
+// endless never ends, like a tool piping unbounded output into the hook.
+type endless struct{}
+
+func (endless) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = ' '
+	}
+	return len(p), nil
+}
+
+func TestLimitsReadBytesStopsEarly(t *testing.T) {
+	_, err := Limits{MaxBytes: 1 << 20}.ReadBytes(io.LimitReader(endless{}, 1<<40))
+	if !errors.Is(err, ErrInputLimit) {
+		t.Fatalf("error = %v, want ErrInputLimit", err)
+	}
+}
+
+func TestLimitsCheckJSON(t *testing.T) {
+	limits := Limits{
+		MaxDepth:     3,
+		MaxStringLen: 20,
+		FieldLimits:  map[string]int{"session_id": 4},
+	}
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"within limits", `{"session_id":"abcd","a":{"b":["short",1,true,null]}}`, false},
+		{"depth at limit", `{"a":{"b":[1]}}`, false},
+		{"depth exceeded", `{"a":{"b":[[1]]}}`, true},
+		{"field limit", `{"session_id":"abcde"}`, true},
+		{"field limit only at top level", `{"nested":{"session_id":"abcdefgh"}}`, false},
+		{"long value", `{"text":"` + strings.Repeat("x", 21) + `"}`, true},
+		{"long array element", `{"roots":["` + strings.Repeat("x", 21) + `"]}`, true},
+		{"long key is not a value", `{"` + strings.Repeat("k", 21) + `":1}`, false},
+		{"value after nested object", `{"a":{"b":1},"session_id":"toolong"}`, true},
+		{"malformed is left to the parser", `{"a":`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.CheckJSON([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInputLimit) {
+				t.Fatalf("error %v does not wrap ErrInputLimit", err)
+			}
+		})
+	}
+}
+
+func TestLimitsFromEnv(t *testing.T) {
+	t.Setenv(EnvMaxBytes, "2M")
+	t.Setenv(EnvMaxDepth, "8")
+	t.Setenv(EnvMaxStringLen, "512k")
+	limits, err := LimitsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.MaxBytes != 2<<20 || limits.MaxDepth != 8 || limits.MaxStringLen != 512<<10 {
+		t.Fatalf("limits = %+v", limits)
+	}
+	if limits.FieldLimits["session_id"] != DefaultLimits.FieldLimits["session_id"] {
+		t.Fatal("field limits should keep their defaults")
+	}
+
+	t.Setenv(EnvMaxBytes, "lots")
+	if _, err := LimitsFromEnv(); err == nil || !strings.Contains(err.Error(), EnvMaxBytes) {
+		t.Fatalf("invalid size error = %v", err)
+	}
+}
+
+func TestDetectAndParseRejectsOversizedInput(t *testing.T) {
+	t.Setenv(EnvMaxBytes, "1K")
+	payload := `{"session_id":"s","transcript_path":"/t","hook_event_name":"Stop","pad":"` + strings.Repeat("x", 2048) + `"}`
+	if _, err := DetectAndParse(strings.NewReader(payload)); !errors.Is(err, ErrInputLimit) {
+		t.Fatalf("DetectAndParse error = %v, want ErrInputLimit", err)
+	}
+	if _, err := ParseStopEvent(strings.NewReader(payload)); !errors.Is(err, ErrInputLimit) {
+		t.Fatalf("ParseStopEvent error = %v, want ErrInputLimit", err)
+	}
+
+	t.Setenv(EnvMaxBytes, "")
+	t.Setenv(EnvMaxDepth, "")
+	deep := `{"session_id":"s","hook_event_name":"Stop","x":` + strings.Repeat("[", 100) + strings.Repeat("]", 100) + `}`
+	if _, err := DetectAndParse(strings.NewReader(deep)); !errors.Is(err, ErrInputLimit) {
+		t.Fatalf("deep input error = %v, want ErrInputLimit", err)
+	}
+}
@@ -0,0 +1,308 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// legacyCommands maps hidden top-level commands and their old aliases to the
+// current runtime command.
+var legacyCommands = map[string]string{
+	"user_prompt_submit_hook": "runtime hook",
+	"stop_hook":               "runtime voice",
+	"notification_hook":       "runtime notify",
+	"hook":                    "runtime hook",
+	"voice":                   "runtime voice",
+	"notify":                  "runtime notify",
+	"mcp":                     "runtime mcp",
+	"engine":                  "runtime engine",
+}
+
+// legacyModes maps old --mode values to the two current reading modes.
+var legacyModes = map[string]string{
+	"first_line":  "short",
+	"full_text":   "full",
+	"line_limit":  "full",
+	"after_first": "full",
+	"char_limit":  "full",
+}
+
+var (
+	// ccpersonaInvocation finds "ccpersona <subcommand>", also when the binary
+	// is given by path or quoted.
+	ccpersonaInvocation = regexp.MustCompile(`(^|[\s/\\"'])ccpersona(\.exe)?(["']?)(\s+)(\S+)`)
+	// legacyEngineFlag is the removed voice flag that selected the provider.
+	legacyEngineFlag = regexp.MustCompile(`(^|\s)--engine([=\s])`)
+	legacyModeFlag   = regexp.MustCompile(`(^|\s)--mode([=\s]+)(first_line|full_text|line_limit|after_first|char_limit)\b`)
+	// codexNotify matches the notify array in Codex's config.toml.
+	codexNotify = regexp.MustCompile(`(?m)^(\s*notify\s*=\s*)(\[.*\])`)
+)
+
+// SettingsFiles returns the agent settings files under baseDir that can carry
+// ccpersona hook commands. baseDir is a project root or the home directory.
+func SettingsFiles(baseDir string) []string {
+	return []string{
+		filepath.Join(baseDir, ".claude", "settings.json"),
+		filepath.Join(baseDir, ".claude", "settings.local.json"),
+		filepath.Join(baseDir, ".cursor", "hooks.json"),
+		filepath.Join(baseDir, ".codex", "hooks.json"),
+		filepath.Join(baseDir, ".codex", "config.toml"),
+	}
+}
+
+// MigrateCommand rewrites a hook command line that invokes ccpersona through
+// legacy command names or flags. It returns the new command and whether
+// anything changed; commands that do not run ccpersona are left alone.
+func MigrateCommand(command string) (string, bool) {
+	loc := ccpersonaInvocation.FindStringSubmatchIndex(command)
+	if loc == nil {
+		return command, false
+	}
+	subStart, subEnd := loc[10], loc[11]
+	sub := command[subStart:subEnd]
+
+	// Only this invocation is rewritten: stop at the next shell separator.
+	rest := command[subEnd:]
+	tail := ""
+	if i := strings.IndexAny(rest, ";&|"); i >= 0 {
+		rest, tail = rest[:i], rest[i:]
+	}
+
+	if replacement, ok := legacyCommands[sub]; ok {
+		sub = replacement
+	}
+	rest = legacyEngineFlag.ReplaceAllString(rest, "${1}--provider${2}")
+	rest = legacyModeFlag.ReplaceAllStringFunc(rest, func(match string) string {
+		parts := legacyModeFlag.FindStringSubmatch(match)
+		return parts[1] + "--mode" + parts[2] + legacyModes[parts[3]]
+	})
+
+	migrated := command[:subStart] + sub + rest + tail
+	return migrated, migrated != command
+}
+
+// MigrateSettingsFile rewrites legacy ccpersona commands in one settings file
+// and returns "old -> new" for each rewritten command. With dryRun the file is
+// left untouched. A missing file is not an error.
+func MigrateSettingsFile(path string, dryRun bool) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var out []byte
+	var changes []string
+	if filepath.Ext(path) == ".toml" {
+		out, changes = migrateCodexNotify(data)
+	} else {
+		out, changes, err = migrateJSONCommands(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+	if len(changes) == 0 || dryRun {
+		return changes, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := fsutil.WriteFileLocked(path, out, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return changes, nil
+}
+
+// Commands returns the ccpersona commands a settings file runs, sorted: the
+// "command" strings of a JSON file and the notify array of Codex's
+// config.toml, joined by spaces. A missing file has none.
+func Commands(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var commands []string
+	if filepath.Ext(path) == ".toml" {
+		for _, parts := range codexNotify.FindAllSubmatch(data, -1) {
+			var argv []string
+			if err := json.Unmarshal(parts[2], &argv); err != nil || len(argv) < 2 {
+				continue
+			}
+			if strings.TrimSuffix(filepath.Base(argv[0]), ".exe") == "ccpersona" {
+				commands = append(commands, strings.Join(argv, " "))
+			}
+		}
+	} else {
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		found := map[string]bool{}
+		collectInvocations(doc, found)
+		for command := range found {
+			commands = append(commands, command)
+		}
+	}
+	sort.Strings(commands)
+	return commands, nil
+}
+
+// collectInvocations adds every "command" string that runs ccpersona.
+func collectInvocations(node interface{}, commands map[string]bool) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if command, ok := value.(string); ok && key == "command" {
+				if ccpersonaInvocation.MatchString(command) {
+					commands[command] = true
+				}
+				continue
+			}
+			collectInvocations(value, commands)
+		}
+	case []interface{}:
+		for _, value := range v {
+			collectInvocations(value, commands)
+		}
+	}
+}
+
+// migrateJSONCommands rewrites every "command" string in a JSON document.
+// Claude Code nests them under hooks.<Event>[].hooks[], Cursor and Codex
+// under hooks.<event>[]. Rewrites are applied to the original text so the
+// file keeps its formatting and key order.
+func migrateJSONCommands(data []byte) ([]byte, []string, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	rewrites := map[string]string{}
+	collectCommands(doc, rewrites)
+	if len(rewrites) == 0 {
+		return data, nil, nil
+	}
+
+	olds := make([]string, 0, len(rewrites))
+	for old := range rewrites {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+
+	out := data
+	textual := true
+	changes := make([]string, 0, len(olds))
+	for _, old := range olds {
+		changes = append(changes, old+" -> "+rewrites[old])
+		oldLiteral, newLiteral := jsonString(old), jsonString(rewrites[old])
+		if !bytes.Contains(out, oldLiteral) {
+			textual = false
+			continue
+		}
+		out = bytes.ReplaceAll(out, oldLiteral, newLiteral)
+	}
+	if textual {
+		return out, changes, nil
+	}
+
+	// The file escapes strings differently than encoding/json; re-encode it.
+	replaceCommands(doc, rewrites)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), changes, nil
+}
+
+func collectCommands(node interface{}, rewrites map[string]string) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if command, ok := value.(string); ok && key == "command" {
+				if migrated, changed := MigrateCommand(command); changed {
+					rewrites[command] = migrated
+				}
+				continue
+			}
+			collectCommands(value, rewrites)
+		}
+	case []interface{}:
+		for _, value := range v {
+			collectCommands(value, rewrites)
+		}
+	}
+}
+
+func replaceCommands(node interface{}, rewrites map[string]string) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if command, ok := value.(string); ok && key == "command" {
+				if migrated, ok := rewrites[command]; ok {
+					v[key] = migrated
+				}
+				continue
+			}
+			replaceCommands(value, rewrites)
+		}
+	case []interface{}:
+		for _, value := range v {
+			replaceCommands(value, rewrites)
+		}
+	}
+}
+
+func jsonString(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+// migrateCodexNotify rewrites notify = ["ccpersona", "notify", ...] in
+// Codex's config.toml to the runtime command.
+func migrateCodexNotify(data []byte) ([]byte, []string) {
+	var changes []string
+	out := codexNotify.ReplaceAllFunc(data, func(line []byte) []byte {
+		parts := codexNotify.FindSubmatch(line)
+		var argv []string
+		// Simple TOML string arrays are valid JSON.
+		if err := json.Unmarshal(parts[2], &argv); err != nil || len(argv) < 2 {
+			return line
+		}
+		base := strings.TrimSuffix(filepath.Base(argv[0]), ".exe")
+		replacement, ok := legacyCommands[argv[1]]
+		if base != "ccpersona" || !ok {
+			return line
+		}
+		migrated := append([]string{argv[0]}, strings.Fields(replacement)...)
+		migrated = append(migrated, argv[2:]...)
+		quoted := make([]string, len(migrated))
+		for i, arg := range migrated {
+			quoted[i] = string(jsonString(arg))
+		}
+		newArray := "[" + strings.Join(quoted, ", ") + "]"
+		changes = append(changes, string(parts[2])+" -> "+newArray)
+		return append(append([]byte{}, parts[1]...), newArray...)
+	})
+	return out, changes
+}
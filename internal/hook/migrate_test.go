@@ -0,0 +1,149 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateCommand(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"ccpersona stop_hook", "ccpersona runtime voice"},
+		{"ccpersona user_prompt_submit_hook", "ccpersona runtime hook"},
+		{"ccpersona notification_hook --voice", "ccpersona runtime notify --voice"},
+		{"ccpersona hook --platform codex", "ccpersona runtime hook --platform codex"},
+		{"/usr/local/bin/ccpersona voice --engine openai", "/usr/local/bin/ccpersona runtime voice --provider openai"},
+		{"ccpersona voice --engine=polly --mode first_line", "ccpersona runtime voice --provider=polly --mode short"},
+		{"ccpersona runtime voice --mode=char_limit", "ccpersona runtime voice --mode=full"},
+		{`"C:\tools\ccpersona.exe" notify`, `"C:\tools\ccpersona.exe" runtime notify`},
+		{"ccpersona stop_hook && say --engine x", "ccpersona runtime voice && say --engine x"},
+	}
+	for _, tt := range tests {
+		got, changed := MigrateCommand(tt.in)
+		if got != tt.want || !changed {
+			t.Errorf("MigrateCommand(%q) = %q, %v; want %q", tt.in, got, changed, tt.want)
+		}
+	}
+
+	for _, unchanged := range []string{
+		"ccpersona runtime hook",
+		"ccpersona runtime voice --mode full",
+		"echo voice",
+		"myccpersona voice",
+		"ccpersona config migrate",
+	} {
+		if got, changed := MigrateCommand(unchanged); changed {
+			t.Errorf("MigrateCommand(%q) = %q, want unchanged", unchanged, got)
+		}
+	}
+}
+
+func TestMigrateSettingsFile_PreservesFormatting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	original := `{
+  "model": "opus",
+  "hooks": {
+    "Stop": [
+      { "hooks": [ { "type": "command", "command": "ccpersona stop_hook" } ] }
+    ],
+    "SessionStart": [
+      { "hooks": [ { "type": "command", "command": "ccpersona runtime hook" } ] }
+    ]
+  }
+}
+`
+	if err := os.WriteFile(path, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := MigrateSettingsFile(path, true)
+	if err != nil || len(changes) != 1 {
+		t.Fatalf("dry run = %q, %v", changes, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Fatal("dry run modified the file")
+	}
+
+	changes, err = MigrateSettingsFile(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0] != "ccpersona stop_hook -> ccpersona runtime voice" {
+		t.Fatalf("changes = %q", changes)
+	}
+	data, _ := os.ReadFile(path)
+	want := strings.Replace(original, "ccpersona stop_hook", "ccpersona runtime voice", 1)
+	if string(data) != want {
+		t.Fatalf("file =\n%s\nwant\n%s", data, want)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Fatalf("mode = %v, want 0640", info.Mode().Perm())
+	}
+}
+
+func TestMigrateSettingsFile_CursorAndCodex(t *testing.T) {
+	dir := t.TempDir()
+	cursor := filepath.Join(dir, "hooks.json")
+	if err := os.WriteFile(cursor, []byte(`{"version":1,"hooks":{"afterAgentResponse":[{"command":"ccpersona notify --voice"}]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := MigrateSettingsFile(cursor, false)
+	if err != nil || len(changes) != 1 {
+		t.Fatalf("cursor changes = %q, %v", changes, err)
+	}
+	if data, _ := os.ReadFile(cursor); !strings.Contains(string(data), `"ccpersona runtime notify --voice"`) {
+		t.Fatalf("cursor hooks = %s", data)
+	}
+
+	codex := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(codex, []byte("model = \"o3\"\nnotify = [\"ccpersona\", \"notify\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = MigrateSettingsFile(codex, false)
+	if err != nil || len(changes) != 1 {
+		t.Fatalf("codex changes = %q, %v", changes, err)
+	}
+	want := "model = \"o3\"\nnotify = [\"ccpersona\", \"runtime\", \"notify\"]\n"
+	if data, _ := os.ReadFile(codex); string(data) != want {
+		t.Fatalf("config.toml = %q, want %q", data, want)
+	}
+
+	if changes, err := MigrateSettingsFile(filepath.Join(dir, "missing.json"), false); err != nil || changes != nil {
+		t.Fatalf("missing file = %q, %v", changes, err)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	claude := filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(claude, []byte(`{"hooks":{"Stop":[{"hooks":[{"type":"command","command":"ccpersona runtime voice"},{"type":"command","command":"say done"}]}],"SessionStart":[{"hooks":[{"type":"command","command":"/usr/local/bin/ccpersona runtime hook"}]}]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commands, err := Commands(claude)
+	want := []string{"/usr/local/bin/ccpersona runtime hook", "ccpersona runtime voice"}
+	if err != nil || strings.Join(commands, "|") != strings.Join(want, "|") {
+		t.Fatalf("Commands = %q, %v; want %q", commands, err, want)
+	}
+
+	codex := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(codex, []byte("notify = [\"ccpersona\", \"runtime\", \"notify\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if commands, err := Commands(codex); err != nil || len(commands) != 1 || commands[0] != "ccpersona runtime notify" {
+		t.Fatalf("Commands(config.toml) = %q, %v", commands, err)
+	}
+
+	broken := filepath.Join(dir, "hooks.json")
+	if err := os.WriteFile(broken, []byte(`{"hooks":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Commands(broken); err == nil {
+		t.Fatal("Commands accepted malformed JSON")
+	}
+	if commands, err := Commands(filepath.Join(dir, "missing.json")); err != nil || commands != nil {
+		t.Fatalf("missing file = %q, %v", commands, err)
+	}
+}
@@ -0,0 +1,389 @@
+package hook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+// Capability is a set of optional payload features an event carries, so
+// handlers can adopt newer fields without breaking on older releases.
+type Capability uint32
+
+// Capabilities detected from Claude Code and Codex payloads.
+const (
+	// CapTranscript: transcript_path points at the session transcript.
+	CapTranscript Capability = 1 << iota
+	// CapPermissionMode: permission_mode carries the session's mode, e.g.
+	// "plan" or "acceptEdits".
+	CapPermissionMode
+	// CapAgentType: agent_type or agent_id names the agent raising the event.
+	CapAgentType
+	// CapModel: the payload names the model.
+	CapModel
+	// CapLastMessage: the final assistant message is in the payload, so the
+	// transcript need not be read.
+	CapLastMessage
+	// CapToolUseID: tool events carry tool_use_id to pair Pre and Post.
+	CapToolUseID
+)
+
+var capabilityNames = []struct {
+	cap  Capability
+	name string
+}{
+	{CapTranscript, "transcript"},
+	{CapPermissionMode, "permission_mode"},
+	{CapAgentType, "agent_type"},
+	{CapModel, "model"},
+	{CapLastMessage, "last_message"},
+	{CapToolUseID, "tool_use_id"},
+}
+
+// Has reports whether all of want are present.
+func (c Capability) Has(want Capability) bool {
+	return c&want == want
+}
+
+// String lists the capability names, e.g. "transcript,permission_mode".
+func (c Capability) String() string {
+	var names []string
+	for _, n := range capabilityNames {
+		if c.Has(n.cap) {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Claude Code payload generations, told apart by the fields they added.
+const (
+	// SchemaV1 has session_id, transcript_path, and hook_event_name.
+	SchemaV1 = 1
+	// SchemaV2 adds permission_mode.
+	SchemaV2 = 2
+	// SchemaV3 adds agent_type and agent_id for agents and subagents.
+	SchemaV3 = 3
+)
+
+// Schema describes how well this build understands a payload.
+type Schema struct {
+	// Version is the detected payload generation, 0 for sources without
+	// versioned schemas.
+	Version      int
+	Capabilities Capability
+	// UnknownFields are top-level fields this build does not know, a sign
+	// the payload comes from a newer release. They are ignored.
+	UnknownFields []string
+	// MismatchedFields have a JSON type other than the expected one; they
+	// are ignored rather than failing the hook.
+	MismatchedFields []string
+}
+
+// Newer reports whether the payload has fields this build does not
+// understand.
+func (s Schema) Newer() bool {
+	return len(s.UnknownFields) > 0 || len(s.MismatchedFields) > 0
+}
+
+// Has reports whether the event carries all of want.
+func (e *UnifiedHookEvent) Has(want Capability) bool {
+	return e.Schema.Capabilities.Has(want)
+}
+
+// JSON value kinds for expected field types.
+const (
+	kindAny = iota
+	kindString
+	kindBool
+	kindObject
+	kindArray
+)
+
+// claudeCodeFields are the top-level fields Claude Code hooks send, with
+// their JSON types. Keys ccpersona reads from every source (tool details,
+// trace context) are included so they are not reported as unknown.
+var claudeCodeFields = map[string]int{
+	"session_id":             kindString,
+	"transcript_path":        kindString,
+	"cwd":                    kindString,
+	"hook_event_name":        kindString,
+	"permission_mode":        kindString,
+	"prompt":                 kindString,
+	"stop_hook_active":       kindBool,
+	"message":                kindString,
+	"title":                  kindString,
+	"notification_type":      kindString,
+	"tool_name":              kindString,
+	"tool_input":             kindObject,
+	"tool_response":          kindAny,
+	"tool_use_id":            kindString,
+	"tool_params":            kindObject,
+	"tool_result":            kindObject,
+	"trigger":                kindString,
+	"compact_mode":           kindString,
+	"custom_instructions":    kindString,
+	"source":                 kindString,
+	"reason":                 kindString,
+	"model":                  kindAny,
+	"agent_type":             kindString,
+	"agent_id":               kindString,
+	"agent_transcript_path":  kindString,
+	"last_assistant_message": kindString,
+	"is_subagent":            kindBool,
+	"turn_id":                kindAny, // Codex lifecycle hooks
+	"traceparent":            kindString,
+	"trace_context":          kindObject,
+	"_meta":                  kindObject,
+}
+
+// codexNotifyFields are the fields of Codex's notify payload.
+var codexNotifyFields = map[string]int{
+	"type":                   kindString,
+	"thread-id":              kindString,
+	"turn-id":                kindAny,
+	"cwd":                    kindString,
+	"input-messages":         kindArray,
+	"last-assistant-message": kindString,
+	"traceparent":            kindString,
+	"trace_context":          kindObject,
+	"_meta":                  kindObject,
+}
+
+// knownSchemas are the payload shapes whose fields are checked, by name.
+var knownSchemas = map[string]map[string]int{
+	"claude-code":  claudeCodeFields,
+	"codex-notify": codexNotifyFields,
+}
+
+// schemaName returns the payload shape of a built-in event, or "" when the
+// source's payloads vary too much per hook to flag unknown fields. Codex
+// lifecycle hooks share Claude Code's shape.
+func schemaName(event *UnifiedHookEvent) string {
+	switch {
+	case event.IsClaudeCode():
+		return "claude-code"
+	case event.IsCodex():
+		if _, ok := event.RawEvent.(*CodexNotifyEvent); ok {
+			return "codex-notify"
+		}
+		return "claude-code"
+	}
+	return ""
+}
+
+// detectSchema fills in event.Schema from the raw payload.
+func detectSchema(event *UnifiedHookEvent, generic map[string]interface{}) {
+	var s Schema
+	has := func(keys ...string) bool { return hasAnyKey(generic, keys...) }
+	if has("transcript_path") {
+		s.Capabilities |= CapTranscript
+	}
+	if has("permission_mode") {
+		s.Capabilities |= CapPermissionMode
+	}
+	if has("agent_type", "agent_id") {
+		s.Capabilities |= CapAgentType
+	}
+	if event.Model != "" {
+		s.Capabilities |= CapModel
+	}
+	if has("last_assistant_message", "last-assistant-message") {
+		s.Capabilities |= CapLastMessage
+	}
+	if has("tool_use_id") {
+		s.Capabilities |= CapToolUseID
+	}
+
+	if event.IsClaudeCode() {
+		switch {
+		case s.Capabilities.Has(CapAgentType):
+			s.Version = SchemaV3
+		case s.Capabilities.Has(CapPermissionMode):
+			s.Version = SchemaV2
+		default:
+			s.Version = SchemaV1
+		}
+	}
+
+	if known := knownSchemas[schemaName(event)]; known != nil {
+		for key, value := range generic {
+			kind, ok := known[key]
+			if !ok {
+				s.UnknownFields = append(s.UnknownFields, key)
+				continue
+			}
+			if !kindMatches(kind, value) {
+				s.MismatchedFields = append(s.MismatchedFields, key)
+			}
+		}
+		sort.Strings(s.UnknownFields)
+		sort.Strings(s.MismatchedFields)
+	}
+	event.Schema = s
+}
+
+func kindMatches(kind int, value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch kind {
+	case kindString:
+		_, ok := value.(string)
+		return ok
+	case kindBool:
+		_, ok := value.(bool)
+		return ok
+	case kindObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case kindArray:
+		_, ok := value.([]interface{})
+		return ok
+	}
+	return true
+}
+
+// decodeTolerant unmarshals a payload into a typed event. A field whose
+// JSON type changed is left empty instead of failing the whole event;
+// detectSchema reports it.
+func decodeTolerant(data []byte, v interface{}) error {
+	err := json.Unmarshal(data, v)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		log.Debug().Str("field", typeErr.Field).Str("type", typeErr.Value).Msg("Ignoring hook field with an unexpected type")
+		return nil
+	}
+	return err
+}
+
+// maxSightingFields bounds the fields kept per sighting.
+const maxSightingFields = 32
+
+// schemaRecordInterval limits rewriting a sighting that has nothing new to
+// once an hour, since every hook of a newer release would otherwise write.
+const schemaRecordInterval = time.Hour
+
+// SchemaSighting records a payload with fields this build did not
+// understand, for `config status` to report.
+type SchemaSighting struct {
+	SeenAt time.Time `json:"seen_at"`
+	Event  string    `json:"event"`
+	// Schema is the payload shape the fields were checked against.
+	Schema           string   `json:"schema"`
+	Version          int      `json:"version,omitempty"`
+	UnknownFields    []string `json:"unknown_fields,omitempty"`
+	MismatchedFields []string `json:"mismatched_fields,omitempty"`
+}
+
+// SchemaLogPath returns where sightings are kept.
+func SchemaLogPath(homeDir string) string {
+	return filepath.Join(homeDir, ".agents", "ccpersona", "hook-schema.json")
+}
+
+// RecordSchema remembers that event's source sent fields this build did not
+// understand, merged with earlier sightings from the same source. It does
+// nothing for payloads that are fully understood.
+func RecordSchema(path string, event *UnifiedHookEvent) error {
+	if !event.Schema.Newer() {
+		return nil
+	}
+	return fsutil.WithLock(path, func() error {
+		sightings, err := LoadSchemaSightings(path)
+		if err != nil {
+			sightings = nil
+		}
+		if sightings == nil {
+			sightings = map[string]SchemaSighting{}
+		}
+		prev := sightings[event.Source]
+		if time.Since(prev.SeenAt) < schemaRecordInterval &&
+			covers(prev.UnknownFields, event.Schema.UnknownFields) &&
+			covers(prev.MismatchedFields, event.Schema.MismatchedFields) {
+			return nil
+		}
+		sightings[event.Source] = SchemaSighting{
+			SeenAt:           time.Now().UTC(),
+			Event:            event.EventType,
+			Schema:           schemaName(event),
+			Version:          event.Schema.Version,
+			UnknownFields:    mergeFields(prev.UnknownFields, event.Schema.UnknownFields),
+			MismatchedFields: mergeFields(prev.MismatchedFields, event.Schema.MismatchedFields),
+		}
+		data, err := json.MarshalIndent(sightings, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return fsutil.WriteFile(path, append(data, '\n'), 0600)
+	})
+}
+
+// covers reports whether every field in b is in a.
+func covers(a, b []string) bool {
+	for _, f := range b {
+		if !slices.Contains(a, f) {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeFields(a, b []string) []string {
+	set := map[string]bool{}
+	for _, f := range append(append([]string{}, a...), b...) {
+		set[f] = true
+	}
+	out := make([]string, 0, len(set))
+	for f := range set {
+		out = append(out, f)
+	}
+	sort.Strings(out)
+	if len(out) > maxSightingFields {
+		out = out[:maxSightingFields]
+	}
+	return out
+}
+
+// LoadSchemaSightings reads the recorded sightings by source. A missing
+// file yields none.
+func LoadSchemaSightings(path string) (map[string]SchemaSighting, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sightings map[string]SchemaSighting
+	if err := json.Unmarshal(data, &sightings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return sightings, nil
+}
+
+// Outstanding drops the unknown fields this build has learned since the
+// sighting was recorded, e.g. after an upgrade, and reports whether any
+// remain.
+func (s SchemaSighting) Outstanding() (SchemaSighting, bool) {
+	known := knownSchemas[s.Schema]
+	var unknown []string
+	for _, f := range s.UnknownFields {
+		if _, ok := known[f]; !ok {
+			unknown = append(unknown, f)
+		}
+	}
+	s.UnknownFields = unknown
+	return s, len(s.UnknownFields) > 0 || len(s.MismatchedFields) > 0
+}
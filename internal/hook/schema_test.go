@@ -0,0 +1,141 @@
+package hook
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectSchema_ClaudeCodeVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		version int
+		caps    Capability
+	}{
+		{
+			name:    "v1",
+			payload: `{"session_id":"s","transcript_path":"/t.jsonl","hook_event_name":"Stop","stop_hook_active":false}`,
+			version: SchemaV1,
+			caps:    CapTranscript,
+		},
+		{
+			name:    "v2",
+			payload: `{"session_id":"s","transcript_path":"/t.jsonl","cwd":"/w","hook_event_name":"Stop","permission_mode":"plan"}`,
+			version: SchemaV2,
+			caps:    CapTranscript | CapPermissionMode,
+		},
+		{
+			name:    "v3",
+			payload: `{"session_id":"s","transcript_path":"/t.jsonl","hook_event_name":"SubagentStop","permission_mode":"default","agent_id":"a1","agent_type":"reviewer","last_assistant_message":"done"}`,
+			version: SchemaV3,
+			caps:    CapTranscript | CapPermissionMode | CapAgentType | CapLastMessage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := DetectAndParse(strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Schema.Version != tt.version || event.Schema.Capabilities != tt.caps {
+				t.Errorf("schema = v%d %s, want v%d %s", event.Schema.Version, event.Schema.Capabilities, tt.version, tt.caps)
+			}
+			if event.Schema.Newer() {
+				t.Errorf("known fields reported as newer: %+v", event.Schema)
+			}
+		})
+	}
+}
+
+func TestDetectSchema_NewFields(t *testing.T) {
+	payload := `{"session_id":"s","transcript_path":"/t.jsonl","hook_event_name":"Stop","permission_mode":"plan","agent_id":"a1","agent_type":"reviewer","effort":"high","context_window":{"used":10}}`
+	event, err := DetectAndParse(strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.PermissionMode != "plan" || event.AgentType != "reviewer" || !event.IsSubagent {
+		t.Errorf("event = %+v", event)
+	}
+	if !event.Has(CapPermissionMode | CapAgentType) {
+		t.Errorf("capabilities = %s", event.Schema.Capabilities)
+	}
+	if got := strings.Join(event.Schema.UnknownFields, ","); got != "context_window,effort" {
+		t.Errorf("unknown fields = %q", got)
+	}
+}
+
+func TestDetectSchema_TolerantTypes(t *testing.T) {
+	// A field changing type must not turn the event into a parse error.
+	payload := `{"session_id":"s","transcript_path":"/t.jsonl","hook_event_name":"UserPromptSubmit","prompt":{"text":"hi"},"cwd":"/w"}`
+	event, err := DetectAndParse(strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("DetectAndParse: %v", err)
+	}
+	if event.SessionID != "s" || event.CWD != "/w" {
+		t.Errorf("other fields lost: %+v", event)
+	}
+	if got := strings.Join(event.Schema.MismatchedFields, ","); got != "prompt" {
+		t.Errorf("mismatched fields = %q", got)
+	}
+}
+
+func TestDetectSchema_CursorUnchecked(t *testing.T) {
+	payload := `{"conversation_id":"c","generation_id":"g","hook_event_name":"stop","cursor_version":"1.7","workspace_roots":["/w"],"brand_new":1}`
+	event, err := DetectAndParse(strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Schema.Newer() || event.Schema.Version != 0 {
+		t.Errorf("Cursor schema = %+v", event.Schema)
+	}
+}
+
+func TestRecordSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hook-schema.json")
+	parse := func(payload string) *UnifiedHookEvent {
+		t.Helper()
+		event, err := DetectAndParse(strings.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return event
+	}
+
+	if err := RecordSchema(path, parse(`{"session_id":"s","hook_event_name":"Stop"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if sightings, _ := LoadSchemaSightings(path); sightings != nil {
+		t.Fatalf("understood payload recorded: %+v", sightings)
+	}
+
+	for _, payload := range []string{
+		`{"session_id":"s","hook_event_name":"Stop","effort":"high"}`,
+		`{"session_id":"s","hook_event_name":"Notification","context_window":{}}`,
+	} {
+		if err := RecordSchema(path, parse(payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sightings, err := LoadSchemaSightings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := sightings["claude-code"]
+	if s.Schema != "claude-code" || s.Event != "Notification" || strings.Join(s.UnknownFields, ",") != "context_window,effort" {
+		t.Errorf("sighting = %+v", s)
+	}
+	if time.Since(s.SeenAt) > time.Minute {
+		t.Errorf("SeenAt = %v", s.SeenAt)
+	}
+
+	// Fields a later build learned are no longer outstanding.
+	s.UnknownFields = append(s.UnknownFields, "permission_mode")
+	if got, ok := s.Outstanding(); !ok || strings.Join(got.UnknownFields, ",") != "context_window,effort" {
+		t.Errorf("Outstanding = %+v, %v", got, ok)
+	}
+	if _, ok := (SchemaSighting{Schema: "claude-code", UnknownFields: []string{"agent_type"}}).Outstanding(); ok {
+		t.Error("a sighting of only known fields should not be outstanding")
+	}
+}
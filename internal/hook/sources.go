@@ -0,0 +1,372 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourcesDirEnv overrides the directory source definitions are loaded from.
+const SourcesDirEnv = "CCPERSONA_SOURCES_DIR"
+
+// builtinSources are the sources with compiled-in parsers; definitions may
+// not reuse their names.
+var builtinSources = []string{SourceClaudeCode, SourceCodex, SourceCursor, SourceWindsurf}
+
+var sourceNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// SourceDef declares how to recognise and read the hook payloads of an agent
+// tool without a compiled-in parser. It is loaded from a JSON or YAML file in
+// the sources directory.
+type SourceDef struct {
+	// Name becomes the event's Source and the platform used for
+	// platform-specific configuration.
+	Name   string       `json:"name" yaml:"name"`
+	Detect SourceDetect `json:"detect" yaml:"detect"`
+	Fields SourceFields `json:"fields" yaml:"fields"`
+	// Events renames the tool's event names to the ones ccpersona acts on:
+	// SessionStart, UserPromptSubmit, Stop, Notification, and SessionEnd.
+	Events map[string]string `json:"events,omitempty" yaml:"events,omitempty"`
+	// DefaultEvent is the event name when fields.event_type is unset or
+	// absent from a payload.
+	DefaultEvent string `json:"default_event,omitempty" yaml:"default_event,omitempty"`
+
+	// Path is the file the definition was loaded from.
+	Path string `json:"-" yaml:"-"`
+}
+
+// SourceDetect is the predicate that recognises a source's payloads. Every
+// listed condition must hold, and at least one is required.
+type SourceDetect struct {
+	// Has lists field paths that must be present.
+	Has []string `json:"has,omitempty" yaml:"has,omitempty"`
+	// Equals maps field paths to the exact value they must have.
+	Equals map[string]string `json:"equals,omitempty" yaml:"equals,omitempty"`
+}
+
+// SourceFields maps unified event fields to field paths in the payload. A
+// path is dot-separated; numeric segments index arrays, and negative ones
+// count from the end ("messages.-1.text").
+type SourceFields struct {
+	SessionID  string `json:"session_id,omitempty" yaml:"session_id,omitempty"`
+	CWD        string `json:"cwd,omitempty" yaml:"cwd,omitempty"`
+	EventType  string `json:"event_type,omitempty" yaml:"event_type,omitempty"`
+	UserInput  string `json:"user_input,omitempty" yaml:"user_input,omitempty"`
+	AIResponse string `json:"ai_response,omitempty" yaml:"ai_response,omitempty"`
+	// TranscriptPath is read for Stop events without an ai_response.
+	TranscriptPath string `json:"transcript_path,omitempty" yaml:"transcript_path,omitempty"`
+	FilePath       string `json:"file_path,omitempty" yaml:"file_path,omitempty"`
+	WorkspaceRoots string `json:"workspace_roots,omitempty" yaml:"workspace_roots,omitempty"`
+	ToolName       string `json:"tool_name,omitempty" yaml:"tool_name,omitempty"`
+	// ToolInput must point at an object.
+	ToolInput string `json:"tool_input,omitempty" yaml:"tool_input,omitempty"`
+	Model     string `json:"model,omitempty" yaml:"model,omitempty"`
+	Title     string `json:"title,omitempty" yaml:"title,omitempty"`
+	// Subagent is true when the value at the path is true, "true", or a
+	// non-empty string other than "false".
+	Subagent string `json:"subagent,omitempty" yaml:"subagent,omitempty"`
+}
+
+// PluginEvent is the RawEvent of events parsed by a source definition.
+type PluginEvent struct {
+	// Definition is the file of the definition that matched.
+	Definition     string
+	TranscriptPath string
+	Data           map[string]interface{}
+}
+
+// SourcesDir returns the directory source definitions are loaded from:
+// $CCPERSONA_SOURCES_DIR, else ~/.agents/ccpersona/sources, falling back to
+// ~/.claude/ccpersona/sources when only that one exists.
+func SourcesDir() (string, error) {
+	if dir := os.Getenv(SourcesDirEnv); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".agents", "ccpersona", "sources")
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		legacy := filepath.Join(home, ".claude", "ccpersona", "sources")
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy, nil
+		}
+	}
+	return dir, nil
+}
+
+// LoadSources reads every .json, .yaml, and .yml definition in dir, in file
+// name order. Invalid definitions are skipped and returned as errors; a
+// missing directory has no definitions.
+func LoadSources(dir string) ([]SourceDef, []error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var defs []SourceDef
+	var errs []error
+	seen := map[string]string{}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		def, err := loadSource(path)
+		if err == nil {
+			if prev, dup := seen[def.Name]; dup {
+				err = fmt.Errorf("source %q is already defined in %s", def.Name, prev)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		seen[def.Name] = path
+		defs = append(defs, *def)
+	}
+	return defs, errs
+}
+
+func loadSource(path string) (*SourceDef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var def SourceDef
+	if filepath.Ext(path) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&def)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&def)
+	}
+	if err != nil {
+		return nil, err
+	}
+	def.Path = path
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// Validate checks the name, that the detection predicate is not empty, and
+// that every path is well formed.
+func (d *SourceDef) Validate() error {
+	if !sourceNamePattern.MatchString(d.Name) {
+		return fmt.Errorf("name %q must be lowercase letters, digits, '-', or '_'", d.Name)
+	}
+	for _, builtin := range builtinSources {
+		if d.Name == builtin {
+			return fmt.Errorf("name %q is a built-in source", d.Name)
+		}
+	}
+	if len(d.Detect.Has) == 0 && len(d.Detect.Equals) == 0 {
+		return errors.New("detect needs at least one has or equals condition")
+	}
+	paths := append([]string{}, d.Detect.Has...)
+	for path := range d.Detect.Equals {
+		paths = append(paths, path)
+	}
+	f := d.Fields
+	paths = append(paths, f.SessionID, f.CWD, f.EventType, f.UserInput, f.AIResponse, f.TranscriptPath, f.FilePath, f.WorkspaceRoots)
+	paths = append(paths, f.ToolName, f.ToolInput, f.Model, f.Title, f.Subagent)
+	for _, path := range paths {
+		if path != "" && strings.Contains("."+path+".", "..") {
+			return fmt.Errorf("invalid field path %q", path)
+		}
+	}
+	if f.EventType == "" && d.DefaultEvent == "" {
+		return errors.New("set fields.event_type or default_event")
+	}
+	return nil
+}
+
+// Matches reports whether payload satisfies the detection predicate.
+func (d *SourceDef) Matches(payload map[string]interface{}) bool {
+	for _, path := range d.Detect.Has {
+		if _, ok := lookupPath(payload, path); !ok {
+			return false
+		}
+	}
+	for path, want := range d.Detect.Equals {
+		v, ok := lookupPath(payload, path)
+		if s, isScalar := scalarString(v); !ok || !isScalar || s != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse maps payload to a unified event.
+func (d *SourceDef) Parse(payload map[string]interface{}) *UnifiedHookEvent {
+	str := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		v, _ := lookupPath(payload, path)
+		s, _ := scalarString(v)
+		return s
+	}
+	eventType := str(d.Fields.EventType)
+	if eventType == "" {
+		eventType = d.DefaultEvent
+	}
+	if renamed, ok := d.Events[eventType]; ok {
+		eventType = renamed
+	}
+	event := &UnifiedHookEvent{
+		Source:     d.Name,
+		SessionID:  str(d.Fields.SessionID),
+		CWD:        str(d.Fields.CWD),
+		EventType:  eventType,
+		UserInput:  stringList(payload, d.Fields.UserInput),
+		AIResponse: str(d.Fields.AIResponse),
+		FilePath:   str(d.Fields.FilePath),
+		RawEvent: &PluginEvent{
+			Definition:     d.Path,
+			TranscriptPath: str(d.Fields.TranscriptPath),
+			Data:           payload,
+		},
+		WorkspaceRoots: stringList(payload, d.Fields.WorkspaceRoots),
+	}
+	if event.CWD == "" && len(event.WorkspaceRoots) > 0 {
+		event.CWD = event.WorkspaceRoots[0]
+	}
+	event.ToolName = str(d.Fields.ToolName)
+	if v, ok := lookupPath(payload, d.Fields.ToolInput); ok {
+		event.ToolInput, _ = v.(map[string]interface{})
+	}
+	if v, ok := lookupPath(payload, d.Fields.Model); ok {
+		event.Model = modelName(v)
+	}
+	event.Title = str(d.Fields.Title)
+	if subagent := str(d.Fields.Subagent); subagent != "" && subagent != "false" {
+		event.IsSubagent = true
+	}
+	return event
+}
+
+// IsPlugin reports whether the event was parsed by a source definition.
+func (e *UnifiedHookEvent) IsPlugin() bool {
+	_, ok := e.RawEvent.(*PluginEvent)
+	return ok
+}
+
+// IsPluginSource reports whether name is defined in the sources directory.
+func IsPluginSource(name string) bool {
+	dir, err := SourcesDir()
+	if err != nil {
+		return false
+	}
+	defs, _ := LoadSources(dir)
+	for _, def := range defs {
+		if def.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// detectPlugin returns the event parsed by the first definition in the
+// sources directory that matches payload, or that sourceHint names.
+func detectPlugin(payload map[string]interface{}, sourceHint string) *UnifiedHookEvent {
+	dir, err := SourcesDir()
+	if err != nil {
+		return nil
+	}
+	defs, _ := LoadSources(dir)
+	for i := range defs {
+		if defs[i].Name == sourceHint || defs[i].Matches(payload) {
+			return defs[i].Parse(payload)
+		}
+	}
+	return nil
+}
+
+func lookupPath(v interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, false
+			}
+			if i < 0 {
+				i += len(node)
+			}
+			if i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// scalarString formats strings, numbers, and booleans; other values report
+// false.
+func scalarString(v interface{}) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(s), true
+	default:
+		return "", false
+	}
+}
+
+// stringList reads a string or an array of strings at path.
+func stringList(payload map[string]interface{}, path string) []string {
+	v, ok := lookupPath(payload, path)
+	if !ok {
+		return []string{}
+	}
+	if s, ok := scalarString(v); ok {
+		if s == "" {
+			return []string{}
+		}
+		return []string{s}
+	}
+	list := []string{}
+	if items, ok := v.([]interface{}); ok {
+		for _, item := range items {
+			if s, ok := scalarString(item); ok && s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
@@ -0,0 +1,181 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const aiderSource = `name: aider
+detect:
+  has: [aider_version]
+  equals:
+    kind: hook
+fields:
+  session_id: chat.id
+  cwd: root
+  event_type: event
+  user_input: messages.0.text
+  ai_response: messages.-1.text
+events:
+  reply_done: Stop
+`
+
+func writeSources(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv(SourcesDirEnv, dir)
+	return dir
+}
+
+func TestLoadSources(t *testing.T) {
+	dir := writeSources(t, map[string]string{
+		"aider.yaml":     aiderSource,
+		"notes.txt":      "ignored",
+		"bad-name.json":  `{"name": "Cursor", "detect": {"has": ["x"]}, "default_event": "Stop"}`,
+		"builtin.json":   `{"name": "cursor", "detect": {"has": ["x"]}, "default_event": "Stop"}`,
+		"no-detect.json": `{"name": "nodetect", "default_event": "Stop"}`,
+		"typo.yml":       "name: typo\ndetect: {has: [x]}\ndefualt_event: Stop\n",
+		"zz-dup.json":    `{"name": "aider", "detect": {"has": ["x"]}, "default_event": "Stop"}`,
+	})
+	defs, errs := LoadSources(dir)
+	if len(defs) != 1 || defs[0].Name != "aider" || defs[0].Events["reply_done"] != "Stop" {
+		t.Fatalf("LoadSources defs = %+v", defs)
+	}
+	if len(errs) != 5 {
+		t.Fatalf("LoadSources errs = %v, want 5", errs)
+	}
+	for _, want := range []string{"bad-name.json", "builtin.json", "no-detect.json", "typo.yml", "already defined"} {
+		found := false
+		for _, err := range errs {
+			found = found || strings.Contains(err.Error(), want)
+		}
+		if !found {
+			t.Errorf("no error mentions %q: %v", want, errs)
+		}
+	}
+
+	if defs, errs := LoadSources(filepath.Join(dir, "missing")); defs != nil || errs != nil {
+		t.Errorf("missing dir = %v, %v", defs, errs)
+	}
+}
+
+func TestSourcesDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(SourcesDirEnv, "")
+	canonical := filepath.Join(home, ".agents", "ccpersona", "sources")
+	legacy := filepath.Join(home, ".claude", "ccpersona", "sources")
+	if dir, _ := SourcesDir(); dir != canonical {
+		t.Errorf("SourcesDir() = %s, want %s", dir, canonical)
+	}
+	if err := os.MkdirAll(legacy, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if dir, _ := SourcesDir(); dir != legacy {
+		t.Errorf("SourcesDir() with only the legacy dir = %s, want %s", dir, legacy)
+	}
+	if err := os.MkdirAll(canonical, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if dir, _ := SourcesDir(); dir != canonical {
+		t.Errorf("SourcesDir() with both = %s, want %s", dir, canonical)
+	}
+}
+
+func TestDetectAndParsePluginSource(t *testing.T) {
+	writeSources(t, map[string]string{"aider.yaml": aiderSource})
+
+	payload := `{
+		"aider_version": "0.80",
+		"kind": "hook",
+		"event": "reply_done",
+		"root": "/work/app",
+		"chat": {"id": "c-42"},
+		"messages": [{"text": "add tests"}, {"text": "thinking"}, {"text": "Tests added."}]
+	}`
+	event, err := DetectAndParse(strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Source != "aider" || event.EventType != "Stop" || event.SessionID != "c-42" || event.CWD != "/work/app" {
+		t.Errorf("event = %+v", event)
+	}
+	if !reflect.DeepEqual(event.UserInput, []string{"add tests"}) || event.AIResponse != "Tests added." {
+		t.Errorf("input/response = %v / %q", event.UserInput, event.AIResponse)
+	}
+	if !event.IsPlugin() || event.IsClaudeCode() {
+		t.Errorf("IsPlugin = %v, IsClaudeCode = %v", event.IsPlugin(), event.IsClaudeCode())
+	}
+
+	// The predicate must hold entirely; otherwise built-in detection applies.
+	claude := `{"hook_event_name": "Stop", "session_id": "s", "transcript_path": "/t", "kind": "hook"}`
+	if event, err := DetectAndParse(strings.NewReader(claude)); err != nil || event.Source != "claude-code" {
+		t.Errorf("claude payload = %+v, %v", event, err)
+	}
+	// A source hint selects the definition without the predicate.
+	event, err = DetectAndParseForSource(strings.NewReader(`{"event": "x"}`), "aider")
+	if err != nil || event.Source != "aider" || event.EventType != "x" {
+		t.Errorf("hinted payload = %+v, %v", event, err)
+	}
+	if !IsPluginSource("aider") || IsPluginSource("codex") {
+		t.Error("IsPluginSource mismatch")
+	}
+}
+
+func TestSourceDefParseDefaults(t *testing.T) {
+	def := SourceDef{
+		Name:   "tool",
+		Detect: SourceDetect{Equals: map[string]string{"v": "2", "ok": "true"}},
+		Fields: SourceFields{
+			WorkspaceRoots: "roots", UserInput: "prompts", TranscriptPath: "log",
+			ToolName: "tool.name", ToolInput: "tool.args", Model: "model", Title: "task", Subagent: "sub",
+		},
+		DefaultEvent: "Notification",
+	}
+	if err := def.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	payload := map[string]interface{}{
+		"v": 2.0, "ok": true,
+		"roots":   []interface{}{"/a", "/b"},
+		"prompts": []interface{}{"one", "", "two"},
+		"log":     "/tmp/t.jsonl",
+		"tool":    map[string]interface{}{"name": "write_file", "args": map[string]interface{}{"path": "a.go"}},
+		"model":   map[string]interface{}{"name": "sonnet"},
+		"task":    "Fix the build",
+		"sub":     true,
+	}
+	if !def.Matches(payload) {
+		t.Fatal("numeric and boolean equals should match")
+	}
+	event := def.Parse(payload)
+	if event.EventType != "Notification" || event.CWD != "/a" || !reflect.DeepEqual(event.WorkspaceRoots, []string{"/a", "/b"}) {
+		t.Errorf("event = %+v", event)
+	}
+	if !reflect.DeepEqual(event.UserInput, []string{"one", "two"}) {
+		t.Errorf("UserInput = %v", event.UserInput)
+	}
+	if event.ToolName != "write_file" || event.ToolInput["path"] != "a.go" || event.Model != "sonnet" || event.Title != "Fix the build" || !event.IsSubagent {
+		t.Errorf("metadata = %q %v %q %q %v", event.ToolName, event.ToolInput, event.Model, event.Title, event.IsSubagent)
+	}
+	if raw := event.RawEvent.(*PluginEvent); raw.TranscriptPath != "/tmp/t.jsonl" {
+		t.Errorf("TranscriptPath = %q", raw.TranscriptPath)
+	}
+
+	payload["v"] = "3"
+	if def.Matches(payload) {
+		t.Error("mismatched equals should not match")
+	}
+	def.Fields.CWD = "a..b"
+	if err := def.Validate(); err == nil {
+		t.Error("empty path segment should be invalid")
+	}
+}
@@ -4,7 +4,6 @@
 package hook
 
 import (
-	"encoding/json"
 	"io"
 	"os"
 )
@@ -59,8 +58,19 @@ type PreCompactEvent struct {
 // Triggered when a Claude Code session starts or resumes
 type SessionStartEvent struct {
 	HookEvent
+	// Source tells why the session started: "startup", "resume", "clear",
+	// or "compact".
+	Source string `json:"source,omitempty"`
 }
 
+// Values of SessionStartEvent.Source.
+const (
+	StartSourceStartup = "startup"
+	StartSourceResume  = "resume"
+	StartSourceClear   = "clear"
+	StartSourceCompact = "compact"
+)
+
 // SessionEndEvent represents the SessionEnd hook event
 // Triggered when a Claude Code session ends
 type SessionEndEvent struct {
@@ -134,11 +144,57 @@ type CursorAfterAgentResponseEvent struct {
 	Text string `json:"text"` // The final AI response text
 }
 
+// WindsurfHookEvent represents the common hook event data from Windsurf's
+// Cascade agent. See: https://docs.windsurf.com/windsurf/cascade/hooks
+// Note: Cascade names the hook in agent_action_name (snake_case, e.g.
+// "pre_user_prompt") and the conversation in trajectory_id; the hook's
+// details are in tool_info.
+type WindsurfHookEvent struct {
+	AgentActionName string `json:"agent_action_name"`
+	TrajectoryID    string `json:"trajectory_id"`
+	ExecutionID     string `json:"execution_id"`
+	Timestamp       string `json:"timestamp,omitempty"`
+}
+
+// WindsurfUserPromptEvent represents Windsurf's pre_user_prompt hook event
+type WindsurfUserPromptEvent struct {
+	WindsurfHookEvent
+	ToolInfo struct {
+		UserPrompt string `json:"user_prompt"`
+	} `json:"tool_info"`
+}
+
+// WindsurfCascadeResponseEvent represents Windsurf's post_cascade_response
+// hook event, which carries the agent's response like Cursor's
+// afterAgentResponse
+type WindsurfCascadeResponseEvent struct {
+	WindsurfHookEvent
+	ToolInfo struct {
+		Response string `json:"response"`
+	} `json:"tool_info"`
+}
+
+// WindsurfRunCommandEvent represents Windsurf's pre_run_command and
+// post_run_command hook events
+type WindsurfRunCommandEvent struct {
+	WindsurfHookEvent
+	ToolInfo struct {
+		CommandLine string `json:"command_line"`
+		CWD         string `json:"cwd"`
+	} `json:"tool_info"`
+}
+
+// WindsurfActionEvent represents Windsurf's other hook events (code reads
+// and writes, MCP tool use) with their tool_info as is
+type WindsurfActionEvent struct {
+	WindsurfHookEvent
+	ToolInfo map[string]interface{} `json:"tool_info,omitempty"`
+}
+
 // ParseHookEvent reads and parses the hook event from stdin
 func ParseHookEvent(r io.Reader) (*HookEvent, error) {
 	var event HookEvent
-	decoder := json.NewDecoder(r)
-	if err := decoder.Decode(&event); err != nil {
+	if err := decodeEvent(r, &event); err != nil {
 		return nil, err
 	}
 	return &event, nil
@@ -147,8 +203,7 @@ func ParseHookEvent(r io.Reader) (*HookEvent, error) {
 // ParseUserPromptSubmitEvent reads and parses UserPromptSubmit event from stdin
 func ParseUserPromptSubmitEvent(r io.Reader) (*UserPromptSubmitEvent, error) {
 	var event UserPromptSubmitEvent
-	decoder := json.NewDecoder(r)
-	if err := decoder.Decode(&event); err != nil {
+	if err := decodeEvent(r, &event); err != nil {
 		return nil, err
 	}
 	return &event, nil
@@ -157,8 +212,7 @@ func ParseUserPromptSubmitEvent(r io.Reader) (*UserPromptSubmitEvent, error) {
 // ParseStopEvent reads and parses Stop event from stdin
 func ParseStopEvent(r io.Reader) (*StopEvent, error) {
 	var event StopEvent
-	decoder := json.NewDecoder(r)
-	if err := decoder.Decode(&event); err != nil {
+	if err := decodeEvent(r, &event); err != nil {
 		return nil, err
 	}
 	return &event, nil
@@ -167,8 +221,7 @@ func ParseStopEvent(r io.Reader) (*StopEvent, error) {
 // ParseNotificationEvent reads and parses Notification event from stdin
 func ParseNotificationEvent(r io.Reader) (*NotificationEvent, error) {
 	var event NotificationEvent
-	decoder := json.NewDecoder(r)
-	if err := decoder.Decode(&event); err != nil {
+	if err := decodeEvent(r, &event); err != nil {
 		return nil, err
 	}
 	return &event, nil
@@ -197,8 +250,7 @@ func ReadNotificationEvent() (*NotificationEvent, error) {
 // ParseSessionStartEvent reads and parses SessionStart event from stdin
 func ParseSessionStartEvent(r io.Reader) (*SessionStartEvent, error) {
 	var event SessionStartEvent
-	decoder := json.NewDecoder(r)
-	if err := decoder.Decode(&event); err != nil {
+	if err := decodeEvent(r, &event); err != nil {
 		return nil, err
 	}
 	return &event, nil
@@ -212,8 +264,7 @@ func ReadSessionStartEvent() (*SessionStartEvent, error) {
 // ParseSessionEndEvent reads and parses SessionEnd event from stdin
 func ParseSessionEndEvent(r io.Reader) (*SessionEndEvent, error) {
 	var event SessionEndEvent
-	decoder := json.NewDecoder(r)
-	if err := decoder.Decode(&event); err != nil {
+	if err := decodeEvent(r, &event); err != nil {
 		return nil, err
 	}
 	return &event, nil
@@ -227,8 +278,7 @@ func ReadSessionEndEvent() (*SessionEndEvent, error) {
 // ParseCodexNotifyEvent reads and parses Codex notify event from stdin
 func ParseCodexNotifyEvent(r io.Reader) (*CodexNotifyEvent, error) {
 	var event CodexNotifyEvent
-	decoder := json.NewDecoder(r)
-	if err := decoder.Decode(&event); err != nil {
+	if err := decodeEvent(r, &event); err != nil {
 		return nil, err
 	}
 	return &event, nil
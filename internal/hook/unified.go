@@ -5,17 +5,63 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 )
 
-// UnifiedHookEvent represents a normalized hook event that works for Claude Code, Codex, and Cursor
+// Sources of the built-in hook parsers, as set in UnifiedHookEvent.Source.
+const (
+	SourceClaudeCode = "claude-code"
+	SourceCodex      = "codex"
+	SourceCursor     = "cursor"
+	SourceWindsurf   = "windsurf"
+)
+
+// UnifiedHookEvent represents a normalized hook event that works for Claude Code, Codex, Cursor, and Windsurf
 type UnifiedHookEvent struct {
-	Source     string      // "claude-code", "codex", or "cursor"
+	Source     string      // "claude-code", "codex", "cursor", "windsurf", or a plugin source
 	SessionID  string      // Session/Thread/Conversation identifier
 	CWD        string      // Current working directory
 	EventType  string      // Event type (e.g., "UserPromptSubmit", "agent-turn-complete", "sessionStart")
 	UserInput  []string    // User's input messages
 	AIResponse string      // AI's response message
 	RawEvent   interface{} // Original event for type-specific handling
+
+	// WorkspaceRoots lists every root of a Cursor multi-root workspace; CWD
+	// is the first of them.
+	WorkspaceRoots []string
+	// FilePath is the file a Cursor file event refers to, when present.
+	FilePath string
+
+	// The fields below are filled where the source provides them, so rules
+	// and logging can filter on them the same way for every source.
+
+	// ToolName is the tool a tool-use event refers to, e.g. "Bash".
+	ToolName string
+	// ToolInput holds the tool's arguments.
+	ToolInput map[string]interface{}
+	// Model is the model serving the session.
+	Model string
+	// Title is the notification title or task description.
+	Title string
+	// IsSubagent marks events raised by a subagent rather than the main
+	// agent.
+	IsSubagent bool
+	// TraceParent is the W3C trace context the payload carries, if any.
+	TraceParent string
+	// PermissionMode is the session's permission mode, e.g. "plan", where
+	// the payload has it (CapPermissionMode).
+	PermissionMode string
+	// AgentType names the agent raising the event (CapAgentType).
+	AgentType string
+	// StartSource is why a SessionStart fired, e.g. "resume" or "compact",
+	// where the payload has it.
+	StartSource string
+	// TranscriptPath is the session transcript, where the payload has it.
+	TranscriptPath string
+
+	// Schema tells which payload generation was detected, which optional
+	// fields it carries, and which fields were not understood.
+	Schema Schema
 }
 
 // DetectAndParse automatically detects the hook source and parses the event
@@ -27,33 +73,76 @@ func DetectAndParse(r io.Reader) (*UnifiedHookEvent, error) {
 // Some lifecycle payloads intentionally share the same wire shape across
 // assistants, so callers that know the invoking platform can pass it here.
 func DetectAndParseForSource(r io.Reader, sourceHint string) (*UnifiedHookEvent, error) {
-	// Read all data from reader
-	data, err := io.ReadAll(r)
+	// Read within the size, depth, and field limits before decoding anything
+	data, err := ReadInput(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read input: %w", err)
+		return nil, err
 	}
+	return ParseInput(data, sourceHint)
+}
 
+// ParseInput detects the source of a payload already read with ReadInput
+// and parses it, for callers that also keep the raw bytes.
+func ParseInput(data []byte, sourceHint string) (*UnifiedHookEvent, error) {
 	// Try to parse as a generic JSON first to detect the source
 	var generic map[string]interface{}
 	if err := json.Unmarshal(data, &generic); err != nil {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
+	// User-defined sources come first: their detect predicates are explicit,
+	// and a tool they describe would otherwise be misread by the heuristics
+	// below or rejected as unknown.
+	if event := detectPlugin(generic, sourceHint); event != nil {
+		event.TraceParent = traceParent(generic)
+		return event, nil
+	}
+
 	// Source detection is decoupled from event-type interpretation.
 	// Codex is identified by its source-level shape (a "type" field corroborated
 	// by Codex-specific fields, or the known type value alone). The concrete
 	// value of "type" is interpreted downstream in parseCodexEvent, so a future
 	// Codex event type beyond "agent-turn-complete" is still recognized as Codex
 	// as long as it carries Codex-specific fields.
+	event, err := parseBuiltinEvent(data, generic, sourceHint)
+	if err != nil {
+		return nil, err
+	}
+	fillMetadata(event, generic)
+	event.TraceParent = traceParent(generic)
+	detectSchema(event, generic)
+	return event, nil
+}
+
+// traceParent reads the W3C traceparent a payload carries at the top level,
+// in a "trace_context" object, or in an MCP-style "_meta" object.
+func traceParent(generic map[string]interface{}) string {
+	if s := firstString(generic, "traceparent"); s != "" {
+		return s
+	}
+	for _, key := range []string{"trace_context", "traceContext", "_meta"} {
+		if m, ok := generic[key].(map[string]interface{}); ok {
+			if s := firstString(m, "traceparent"); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func parseBuiltinEvent(data []byte, generic map[string]interface{}, sourceHint string) (*UnifiedHookEvent, error) {
 	if isCodexEvent(generic) {
 		return parseCodexEvent(data)
 	}
+	if isWindsurfEvent(generic, sourceHint) {
+		return parseWindsurfEvent(data, generic)
+	}
 
 	// Both Claude Code and Cursor carry hook_event_name; disambiguate by scoring
 	// multiple signals rather than trusting a single field, so one schema change
 	// cannot silently misroute a Cursor event into the Claude Code parser.
 	if _, hasHookEventName := generic["hook_event_name"]; hasHookEventName {
-		if sourceHint == "codex" {
+		if sourceHint == SourceCodex {
 			return parseCodexLifecycleEvent(data)
 		}
 		if isCursorEvent(generic) {
@@ -65,6 +154,64 @@ func DetectAndParseForSource(r io.Reader, sourceHint string) (*UnifiedHookEvent,
 	return nil, fmt.Errorf("unknown hook event format")
 }
 
+// fillMetadata copies the optional tool, model, title, and subagent details
+// from the keys the built-in sources use for them.
+func fillMetadata(event *UnifiedHookEvent, generic map[string]interface{}) {
+	// Windsurf names the tool in its parser; other sources use these keys.
+	if name := firstString(generic, "tool_name", "toolName"); name != "" {
+		event.ToolName = name
+	}
+	for _, key := range []string{"tool_input", "tool_params", "toolInput"} {
+		if input, ok := generic[key].(map[string]interface{}); ok {
+			event.ToolInput = input
+			break
+		}
+	}
+	// Cursor's shell and MCP hooks carry the command instead of tool input.
+	if command, ok := generic["command"].(string); ok && event.ToolInput == nil && event.IsCursor() {
+		event.ToolInput = map[string]interface{}{"command": command}
+		if event.ToolName == "" {
+			event.ToolName = "Shell"
+		}
+	}
+	event.Model = modelName(generic["model"])
+	event.Title = firstString(generic, "title", "task_description", "taskDescription")
+	switch event.EventType {
+	case "SubagentStop", "SubagentStart", "subagentStop", "subagentStart":
+		event.IsSubagent = true
+	}
+	if v, ok := generic["is_subagent"].(bool); ok && v {
+		event.IsSubagent = true
+	}
+	event.PermissionMode = firstString(generic, "permission_mode")
+	event.AgentType = firstString(generic, "agent_type")
+	// Claude Code sets agent_id on events raised inside a subagent.
+	if firstString(generic, "agent_id") != "" {
+		event.IsSubagent = true
+	}
+}
+
+// modelName reads a model given as a string or as an object with an id or
+// display name.
+func modelName(v interface{}) string {
+	switch m := v.(type) {
+	case string:
+		return m
+	case map[string]interface{}:
+		return firstString(m, "id", "display_name", "name")
+	}
+	return ""
+}
+
+func firstString(m map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := m[k].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
 // isCodexEvent reports whether the generic payload looks like a Codex notify
 // event. A "type" field is necessary but not sufficient: another source could
 // add a "type" field someday, and parseCodexEvent does not validate required
@@ -135,12 +282,12 @@ func isCamelCase(s string) bool {
 
 func parseCodexEvent(data []byte) (*UnifiedHookEvent, error) {
 	var event CodexNotifyEvent
-	if err := json.Unmarshal(data, &event); err != nil {
+	if err := decodeTolerant(data, &event); err != nil {
 		return nil, fmt.Errorf("failed to parse Codex event: %w", err)
 	}
 
 	return &UnifiedHookEvent{
-		Source:     "codex",
+		Source:     SourceCodex,
 		SessionID:  event.ThreadID,
 		CWD:        event.CWD,
 		EventType:  event.Type,
@@ -152,7 +299,7 @@ func parseCodexEvent(data []byte) (*UnifiedHookEvent, error) {
 
 func parseCodexLifecycleEvent(data []byte) (*UnifiedHookEvent, error) {
 	var event CodexLifecycleEvent
-	if err := json.Unmarshal(data, &event); err != nil {
+	if err := decodeTolerant(data, &event); err != nil {
 		return nil, fmt.Errorf("failed to parse Codex lifecycle event: %w", err)
 	}
 
@@ -162,7 +309,7 @@ func parseCodexLifecycleEvent(data []byte) (*UnifiedHookEvent, error) {
 	}
 
 	return &UnifiedHookEvent{
-		Source:     "codex",
+		Source:     SourceCodex,
 		SessionID:  event.SessionID,
 		CWD:        event.CWD,
 		EventType:  event.HookEventName,
@@ -178,11 +325,11 @@ func parseClaudeCodeEvent(data []byte, generic map[string]interface{}) (*Unified
 	switch hookEventName {
 	case "UserPromptSubmit":
 		var event UserPromptSubmitEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := decodeTolerant(data, &event); err != nil {
 			return nil, fmt.Errorf("failed to parse UserPromptSubmit event: %w", err)
 		}
 		return &UnifiedHookEvent{
-			Source:     "claude-code",
+			Source:     SourceClaudeCode,
 			SessionID:  event.SessionID,
 			CWD:        event.CWD,
 			EventType:  hookEventName,
@@ -193,11 +340,11 @@ func parseClaudeCodeEvent(data []byte, generic map[string]interface{}) (*Unified
 
 	case "Stop", "SubagentStop":
 		var event StopEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := decodeTolerant(data, &event); err != nil {
 			return nil, fmt.Errorf("failed to parse Stop event: %w", err)
 		}
 		return &UnifiedHookEvent{
-			Source:     "claude-code",
+			Source:     SourceClaudeCode,
 			SessionID:  event.SessionID,
 			CWD:        event.CWD,
 			EventType:  hookEventName,
@@ -208,11 +355,11 @@ func parseClaudeCodeEvent(data []byte, generic map[string]interface{}) (*Unified
 
 	case "Notification":
 		var event NotificationEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := decodeTolerant(data, &event); err != nil {
 			return nil, fmt.Errorf("failed to parse Notification event: %w", err)
 		}
 		return &UnifiedHookEvent{
-			Source:     "claude-code",
+			Source:     SourceClaudeCode,
 			SessionID:  event.SessionID,
 			CWD:        event.CWD,
 			EventType:  hookEventName,
@@ -223,26 +370,28 @@ func parseClaudeCodeEvent(data []byte, generic map[string]interface{}) (*Unified
 
 	case "SessionStart":
 		var event SessionStartEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := decodeTolerant(data, &event); err != nil {
 			return nil, fmt.Errorf("failed to parse SessionStart event: %w", err)
 		}
 		return &UnifiedHookEvent{
-			Source:     "claude-code",
-			SessionID:  event.SessionID,
-			CWD:        event.CWD,
-			EventType:  hookEventName,
-			UserInput:  []string{},
-			AIResponse: "",
-			RawEvent:   &event,
+			Source:         SourceClaudeCode,
+			SessionID:      event.SessionID,
+			CWD:            event.CWD,
+			EventType:      hookEventName,
+			UserInput:      []string{},
+			AIResponse:     "",
+			RawEvent:       &event,
+			StartSource:    event.Source,
+			TranscriptPath: event.TranscriptPath,
 		}, nil
 
 	case "SessionEnd":
 		var event SessionEndEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := decodeTolerant(data, &event); err != nil {
 			return nil, fmt.Errorf("failed to parse SessionEnd event: %w", err)
 		}
 		return &UnifiedHookEvent{
-			Source:     "claude-code",
+			Source:     SourceClaudeCode,
 			SessionID:  event.SessionID,
 			CWD:        event.CWD,
 			EventType:  hookEventName,
@@ -254,11 +403,11 @@ func parseClaudeCodeEvent(data []byte, generic map[string]interface{}) (*Unified
 	default:
 		// For other Claude Code events, just parse as generic HookEvent
 		var event HookEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := decodeTolerant(data, &event); err != nil {
 			return nil, fmt.Errorf("failed to parse Claude Code event: %w", err)
 		}
 		return &UnifiedHookEvent{
-			Source:     "claude-code",
+			Source:     SourceClaudeCode,
 			SessionID:  event.SessionID,
 			CWD:        event.CWD,
 			EventType:  hookEventName,
@@ -270,6 +419,22 @@ func parseClaudeCodeEvent(data []byte, generic map[string]interface{}) (*Unified
 }
 
 func parseCursorEvent(data []byte, generic map[string]interface{}) (*UnifiedHookEvent, error) {
+	event, err := parseCursorEventType(data, generic)
+	if err != nil {
+		return nil, err
+	}
+	if roots, ok := generic["workspace_roots"].([]interface{}); ok {
+		for _, root := range roots {
+			if root, ok := root.(string); ok && root != "" {
+				event.WorkspaceRoots = append(event.WorkspaceRoots, root)
+			}
+		}
+	}
+	event.FilePath, _ = generic["file_path"].(string)
+	return event, nil
+}
+
+func parseCursorEventType(data []byte, generic map[string]interface{}) (*UnifiedHookEvent, error) {
 	hookEventName, _ := generic["hook_event_name"].(string)
 
 	// Get CWD from workspace_roots if available
@@ -283,11 +448,11 @@ func parseCursorEvent(data []byte, generic map[string]interface{}) (*UnifiedHook
 	switch hookEventName {
 	case "sessionStart":
 		var event CursorSessionStartEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := decodeTolerant(data, &event); err != nil {
 			return nil, fmt.Errorf("failed to parse Cursor sessionStart event: %w", err)
 		}
 		return &UnifiedHookEvent{
-			Source:     "cursor",
+			Source:     SourceCursor,
 			SessionID:  event.ConversationID,
 			CWD:        cwd,
 			EventType:  hookEventName,
@@ -298,11 +463,11 @@ func parseCursorEvent(data []byte, generic map[string]interface{}) (*UnifiedHook
 
 	case "beforeSubmitPrompt":
 		var event CursorBeforeSubmitPromptEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := decodeTolerant(data, &event); err != nil {
 			return nil, fmt.Errorf("failed to parse Cursor beforeSubmitPrompt event: %w", err)
 		}
 		return &UnifiedHookEvent{
-			Source:     "cursor",
+			Source:     SourceCursor,
 			SessionID:  event.ConversationID,
 			CWD:        cwd,
 			EventType:  hookEventName,
@@ -313,11 +478,11 @@ func parseCursorEvent(data []byte, generic map[string]interface{}) (*UnifiedHook
 
 	case "stop":
 		var event CursorStopEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := decodeTolerant(data, &event); err != nil {
 			return nil, fmt.Errorf("failed to parse Cursor stop event: %w", err)
 		}
 		return &UnifiedHookEvent{
-			Source:     "cursor",
+			Source:     SourceCursor,
 			SessionID:  event.ConversationID,
 			CWD:        cwd,
 			EventType:  hookEventName,
@@ -328,11 +493,11 @@ func parseCursorEvent(data []byte, generic map[string]interface{}) (*UnifiedHook
 
 	case "afterAgentResponse":
 		var event CursorAfterAgentResponseEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := decodeTolerant(data, &event); err != nil {
 			return nil, fmt.Errorf("failed to parse Cursor afterAgentResponse event: %w", err)
 		}
 		return &UnifiedHookEvent{
-			Source:     "cursor",
+			Source:     SourceCursor,
 			SessionID:  event.ConversationID,
 			CWD:        cwd,
 			EventType:  hookEventName,
@@ -344,11 +509,11 @@ func parseCursorEvent(data []byte, generic map[string]interface{}) (*UnifiedHook
 	default:
 		// For other Cursor events, parse as generic CursorHookEvent
 		var event CursorHookEvent
-		if err := json.Unmarshal(data, &event); err != nil {
+		if err := decodeTolerant(data, &event); err != nil {
 			return nil, fmt.Errorf("failed to parse Cursor event: %w", err)
 		}
 		return &UnifiedHookEvent{
-			Source:     "cursor",
+			Source:     SourceCursor,
 			SessionID:  event.ConversationID,
 			CWD:        cwd,
 			EventType:  hookEventName,
@@ -359,19 +524,94 @@ func parseCursorEvent(data []byte, generic map[string]interface{}) (*UnifiedHook
 	}
 }
 
+// isWindsurfEvent reports whether the payload is a Windsurf Cascade hook.
+// Cascade payloads name the hook in agent_action_name rather than
+// hook_event_name and identify the conversation by trajectory_id.
+func isWindsurfEvent(generic map[string]interface{}, sourceHint string) bool {
+	if _, ok := generic["agent_action_name"].(string); !ok {
+		return false
+	}
+	return sourceHint == SourceWindsurf || hasAnyKey(generic, "trajectory_id", "execution_id", "tool_info")
+}
+
+func parseWindsurfEvent(data []byte, generic map[string]interface{}) (*UnifiedHookEvent, error) {
+	action, _ := generic["agent_action_name"].(string)
+	unified := &UnifiedHookEvent{
+		Source:    SourceWindsurf,
+		EventType: action,
+		UserInput: []string{},
+	}
+
+	switch action {
+	case "pre_user_prompt":
+		var event WindsurfUserPromptEvent
+		if err := decodeTolerant(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse Windsurf pre_user_prompt event: %w", err)
+		}
+		unified.SessionID = event.TrajectoryID
+		unified.UserInput = []string{event.ToolInfo.UserPrompt}
+		unified.RawEvent = &event
+
+	case "post_cascade_response":
+		var event WindsurfCascadeResponseEvent
+		if err := decodeTolerant(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse Windsurf post_cascade_response event: %w", err)
+		}
+		unified.SessionID = event.TrajectoryID
+		unified.AIResponse = event.ToolInfo.Response
+		unified.RawEvent = &event
+
+	case "pre_run_command", "post_run_command":
+		var event WindsurfRunCommandEvent
+		if err := decodeTolerant(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse Windsurf %s event: %w", action, err)
+		}
+		unified.SessionID = event.TrajectoryID
+		unified.CWD = event.ToolInfo.CWD
+		unified.ToolName = "run_command"
+		unified.ToolInput = map[string]interface{}{"command": event.ToolInfo.CommandLine}
+		unified.RawEvent = &event
+
+	default:
+		// File and MCP hooks share the generic shape; pick out what the
+		// unified event has room for.
+		var event WindsurfActionEvent
+		if err := decodeTolerant(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse Windsurf event: %w", err)
+		}
+		unified.SessionID = event.TrajectoryID
+		unified.FilePath = firstString(event.ToolInfo, "file_path")
+		unified.CWD = firstString(event.ToolInfo, "cwd")
+		if name := firstString(event.ToolInfo, "mcp_tool_name"); name != "" {
+			unified.ToolName = name
+			unified.ToolInput, _ = event.ToolInfo["mcp_tool_arguments"].(map[string]interface{})
+		} else {
+			// pre_write_code and post_write_code both become "write_code"
+			unified.ToolName = strings.TrimPrefix(strings.TrimPrefix(action, "pre_"), "post_")
+		}
+		unified.RawEvent = &event
+	}
+	return unified, nil
+}
+
 // IsCodex returns true if the event is from Codex
 func (e *UnifiedHookEvent) IsCodex() bool {
-	return e.Source == "codex"
+	return e.Source == SourceCodex
 }
 
 // IsClaudeCode returns true if the event is from Claude Code
 func (e *UnifiedHookEvent) IsClaudeCode() bool {
-	return e.Source == "claude-code"
+	return e.Source == SourceClaudeCode
 }
 
 // IsCursor returns true if the event is from Cursor
 func (e *UnifiedHookEvent) IsCursor() bool {
-	return e.Source == "cursor"
+	return e.Source == SourceCursor
+}
+
+// IsWindsurf returns true if the event is from Windsurf's Cascade agent
+func (e *UnifiedHookEvent) IsWindsurf() bool {
+	return e.Source == SourceWindsurf
 }
 
 // GetCodexEvent returns the underlying Codex event if available
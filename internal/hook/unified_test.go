@@ -187,6 +187,10 @@ func TestDetectAndParseClaudeCodeSessionStartWithModelAndPermissionMode(t *testi
 		t.Errorf("Expected session ID 'session-start-123', got '%s'", event.SessionID)
 	}
 
+	if event.StartSource != StartSourceStartup || event.TranscriptPath != "/path/to/transcript.jsonl" {
+		t.Errorf("Expected start source 'startup' and the transcript path, got %q and %q", event.StartSource, event.TranscriptPath)
+	}
+
 	if !event.IsClaudeCode() {
 		t.Error("Expected IsClaudeCode() to return true")
 	}
@@ -332,6 +336,30 @@ func TestGetClaudeCodeEvent(t *testing.T) {
 	})
 }
 
+func TestDetectAndParseCursorMultiRoot(t *testing.T) {
+	jsonData := `{
+		"conversation_id": "cursor-conv-123",
+		"hook_event_name": "afterFileEdit",
+		"cursor_version": "1.7.0",
+		"workspace_roots": ["/work/api", "", "/work/web"],
+		"file_path": "/work/web/src/app.ts"
+	}`
+
+	event, err := DetectAndParse(strings.NewReader(jsonData))
+	if err != nil {
+		t.Fatalf("Failed to parse Cursor event: %v", err)
+	}
+	if len(event.WorkspaceRoots) != 2 || event.WorkspaceRoots[0] != "/work/api" || event.WorkspaceRoots[1] != "/work/web" {
+		t.Errorf("WorkspaceRoots = %v", event.WorkspaceRoots)
+	}
+	if event.CWD != "/work/api" {
+		t.Errorf("CWD = %q, want the first root", event.CWD)
+	}
+	if event.FilePath != "/work/web/src/app.ts" {
+		t.Errorf("FilePath = %q", event.FilePath)
+	}
+}
+
 func TestDetectAndParseCursorSessionStart(t *testing.T) {
 	jsonData := `{
 		"conversation_id": "cursor-conv-123",
@@ -600,6 +628,22 @@ func TestDetectAndParseSourceBoundaries(t *testing.T) {
 			}`,
 			wantSource: "",
 		},
+		{
+			name: "agent_action_name with trajectory_id is Windsurf",
+			jsonData: `{
+				"agent_action_name": "post_cascade_response",
+				"trajectory_id": "traj-1",
+				"execution_id": "exec-1",
+				"tool_info": {"response": "Done"}
+			}`,
+			wantSource: "windsurf",
+			wantType:   "post_cascade_response",
+		},
+		{
+			name:       "agent_action_name alone is unknown",
+			jsonData:   `{"agent_action_name": "pre_user_prompt"}`,
+			wantSource: "",
+		},
 		{
 			name:       "no type and no hook_event_name is unknown",
 			jsonData:   `{"some_field": "value"}`,
@@ -631,6 +675,90 @@ func TestDetectAndParseSourceBoundaries(t *testing.T) {
 	}
 }
 
+func TestDetectAndParseWindsurfEvents(t *testing.T) {
+	prompt, err := DetectAndParse(strings.NewReader(`{
+		"agent_action_name": "pre_user_prompt",
+		"trajectory_id": "traj-1",
+		"execution_id": "exec-1",
+		"timestamp": "2025-11-20T10:00:00Z",
+		"tool_info": {"user_prompt": "Add a test"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !prompt.IsWindsurf() || prompt.SessionID != "traj-1" || prompt.EventType != "pre_user_prompt" {
+		t.Errorf("unexpected event: %+v", prompt)
+	}
+	if len(prompt.UserInput) != 1 || prompt.UserInput[0] != "Add a test" {
+		t.Errorf("user input = %v", prompt.UserInput)
+	}
+	if _, ok := prompt.RawEvent.(*WindsurfUserPromptEvent); !ok {
+		t.Errorf("raw event = %T", prompt.RawEvent)
+	}
+
+	response, err := DetectAndParse(strings.NewReader(`{
+		"agent_action_name": "post_cascade_response",
+		"trajectory_id": "traj-1",
+		"execution_id": "exec-2",
+		"tool_info": {"response": "I added the test."}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.AIResponse != "I added the test." {
+		t.Errorf("AI response = %q", response.AIResponse)
+	}
+
+	command, err := DetectAndParse(strings.NewReader(`{
+		"agent_action_name": "pre_run_command",
+		"trajectory_id": "traj-1",
+		"execution_id": "exec-3",
+		"tool_info": {"command_line": "go test ./...", "cwd": "/project"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if command.ToolName != "run_command" || command.ToolInput["command"] != "go test ./..." || command.CWD != "/project" {
+		t.Errorf("unexpected command event: %+v", command)
+	}
+
+	write, err := DetectAndParse(strings.NewReader(`{
+		"agent_action_name": "post_write_code",
+		"trajectory_id": "traj-1",
+		"execution_id": "exec-4",
+		"tool_info": {"file_path": "/project/main.go", "edits": []}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if write.ToolName != "write_code" || write.FilePath != "/project/main.go" {
+		t.Errorf("unexpected write event: %+v", write)
+	}
+
+	mcp, err := DetectAndParse(strings.NewReader(`{
+		"agent_action_name": "pre_mcp_tool_use",
+		"trajectory_id": "traj-1",
+		"execution_id": "exec-5",
+		"tool_info": {"mcp_server_name": "github", "mcp_tool_name": "create_issue", "mcp_tool_arguments": {"title": "x"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mcp.ToolName != "create_issue" || mcp.ToolInput["title"] != "x" {
+		t.Errorf("unexpected MCP event: %+v", mcp)
+	}
+}
+
+func TestDetectAndParseWindsurfSourceHint(t *testing.T) {
+	event, err := DetectAndParseForSource(strings.NewReader(`{"agent_action_name": "pre_user_prompt"}`), SourceWindsurf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !event.IsWindsurf() {
+		t.Errorf("source = %q, want windsurf", event.Source)
+	}
+}
+
 func TestParseClaudeCodeEventTypes(t *testing.T) {
 	t.Run("parse PreToolUse event", func(t *testing.T) {
 		jsonData := `{
@@ -688,3 +816,88 @@ func TestParseClaudeCodeEventTypes(t *testing.T) {
 		}
 	})
 }
+
+func TestDetectAndParseMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		tool     string
+		input    string
+		model    string
+		title    string
+		subagent bool
+	}{
+		{
+			name:    "claude pre tool use",
+			payload: `{"hook_event_name": "PreToolUse", "session_id": "s", "transcript_path": "/t", "tool_name": "Bash", "tool_input": {"command": "go test"}}`,
+			tool:    "Bash", input: "go test",
+		},
+		{
+			name:    "claude notification",
+			payload: `{"hook_event_name": "Notification", "session_id": "s", "transcript_path": "/t", "message": "m", "title": "Permission needed", "model": {"id": "claude-x", "display_name": "X"}}`,
+			title:   "Permission needed", model: "claude-x",
+		},
+		{
+			name:     "claude subagent stop",
+			payload:  `{"hook_event_name": "SubagentStop", "session_id": "s", "transcript_path": "/t"}`,
+			subagent: true,
+		},
+		{
+			name:    "cursor shell",
+			payload: `{"hook_event_name": "beforeShellExecution", "conversation_id": "c", "generation_id": "g", "model": "gpt-5", "command": "ls -la", "workspace_roots": ["/w"]}`,
+			tool:    "Shell", input: "ls -la", model: "gpt-5",
+		},
+		{
+			name:    "codex notify",
+			payload: `{"type": "agent-turn-complete", "thread-id": "t", "turn-id": "1", "cwd": "/w", "input-messages": [], "last-assistant-message": "done"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := DetectAndParse(strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			input, _ := event.ToolInput["command"].(string)
+			if event.ToolName != tt.tool || input != tt.input || event.Model != tt.model || event.Title != tt.title || event.IsSubagent != tt.subagent {
+				t.Errorf("metadata = tool %q input %q model %q title %q subagent %v", event.ToolName, input, event.Model, event.Title, event.IsSubagent)
+			}
+		})
+	}
+}
+
+func TestDetectAndParseCodexLifecycleModel(t *testing.T) {
+	payload := `{"hook_event_name": "Stop", "session_id": "s", "transcript_path": "/t", "model": "gpt-5-codex", "permission_mode": "default"}`
+	event, err := DetectAndParseForSource(strings.NewReader(payload), "codex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Source != "codex" || event.Model != "gpt-5-codex" {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestDetectAndParseTraceParent(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{"top level", `{"hook_event_name": "Stop", "session_id": "s", "transcript_path": "/t", "traceparent": "` + tp + `"}`, tp},
+		{"trace context", `{"hook_event_name": "Stop", "session_id": "s", "transcript_path": "/t", "trace_context": {"traceparent": "` + tp + `"}}`, tp},
+		{"meta", `{"type": "agent-turn-complete", "thread-id": "t", "turn-id": "1", "_meta": {"traceparent": "` + tp + `"}}`, tp},
+		{"absent", `{"hook_event_name": "Stop", "session_id": "s", "transcript_path": "/t"}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := DetectAndParse(strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.TraceParent != tt.want {
+				t.Errorf("TraceParent = %q, want %q", event.TraceParent, tt.want)
+			}
+		})
+	}
+}
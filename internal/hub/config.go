@@ -0,0 +1,229 @@
+// Package hub runs a shared notification hub: the agent hooks of several
+// developers post events to it with their own API token, and it fans them
+// out to the team's chat and to each developer's own push channels.
+package hub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/notify"
+)
+
+// DefaultAddr is where the hub listens unless configured otherwise. The
+// container image listens on all interfaces instead.
+const DefaultAddr = "127.0.0.1:50092"
+
+// minTokenLength rejects tokens short enough to guess.
+const minTokenLength = 16
+
+// defaultUrgencies are sent to the team and to users' own channels when no
+// urgencies are configured.
+var defaultUrgencies = []string{"high", "critical"}
+
+var userNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Config is the hub's own config file, separate from the ccpersona config
+// of any one developer.
+type Config struct {
+	Team TeamConfig `json:"team"`
+	// Users are the developers allowed to post, each keyed by API token.
+	Users []User `json:"users"`
+	// PushSubject is the VAPID contact push services use to reach the hub,
+	// a mailto: or https: URL.
+	PushSubject string `json:"push_subject,omitempty"`
+}
+
+// TeamConfig sends events from every user to the team's chat through
+// incoming webhooks. The URLs are credentials; the _env fields name
+// environment variables holding them instead.
+type TeamConfig struct {
+	Slack      string `json:"slack,omitempty"`
+	SlackEnv   string `json:"slack_env,omitempty"`
+	Discord    string `json:"discord,omitempty"`
+	DiscordEnv string `json:"discord_env,omitempty"`
+	Filter
+}
+
+// User is a developer whose hooks post to the hub.
+type User struct {
+	// Name labels the user's events in team chat and names their push
+	// subscriptions.
+	Name string `json:"name"`
+	// Token is the API token the user's hooks send; TokenEnv names an
+	// environment variable holding it instead.
+	Token    string `json:"token,omitempty"`
+	TokenEnv string `json:"token_env,omitempty"`
+	// Team set to false keeps the user's events out of team chat.
+	Team *bool `json:"team,omitempty"`
+	// Push sends to the browsers the user subscribed at /push/.
+	Push bool `json:"push,omitempty"`
+	// Telegram sends to the user's own chat. Its events list is not used;
+	// Filter decides instead.
+	Telegram *notify.TelegramConfig `json:"telegram,omitempty"`
+	Filter
+}
+
+// Filter selects events by urgency or name. An event passes when either
+// matches.
+type Filter struct {
+	// Urgencies lists the urgencies sent (default: high and critical). An
+	// empty list sends only what Events selects.
+	Urgencies []string `json:"urgencies,omitzero"`
+	// Events lists event names (globs, "*" for all) sent at any urgency.
+	Events []string `json:"events,omitempty"`
+}
+
+// Passes reports whether an event named name with urgency is sent.
+func (f Filter) Passes(name, urgency string) bool {
+	urgencies := f.Urgencies
+	if urgencies == nil {
+		urgencies = defaultUrgencies
+	}
+	for _, u := range urgencies {
+		if u == urgency {
+			return true
+		}
+	}
+	for _, glob := range f.Events {
+		if ok, _ := path.Match(strings.ToLower(glob), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) validate(field string) error {
+	for _, glob := range f.Events {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("%s.events: invalid pattern %q: %w", field, glob, err)
+		}
+	}
+	return nil
+}
+
+// SlackURL returns the team's Slack webhook, or "".
+func (t TeamConfig) SlackURL() string { return fromEnv(t.Slack, t.SlackEnv) }
+
+// DiscordURL returns the team's Discord webhook, or "".
+func (t TeamConfig) DiscordURL() string { return fromEnv(t.Discord, t.DiscordEnv) }
+
+// APIToken returns the user's token.
+func (u User) APIToken() string { return fromEnv(u.Token, u.TokenEnv) }
+
+// SharesWithTeam reports whether the user's events go to team chat.
+func (u User) SharesWithTeam() bool { return u.Team == nil || *u.Team }
+
+func fromEnv(value, env string) string {
+	if env != "" {
+		return os.Getenv(env)
+	}
+	return value
+}
+
+// DefaultConfigPath returns ~/.agents/ccpersona/hub.json.
+func DefaultConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".agents", "ccpersona", "hub.json"), nil
+}
+
+// DefaultDataDir returns ~/.agents/ccpersona/hub, where push subscriptions
+// are kept.
+func DefaultDataDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".agents", "ccpersona", "hub"), nil
+}
+
+// LoadConfig reads and validates the hub config at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Validate checks users, tokens, and webhook URLs. Tokens are resolved
+// from the environment here, so a missing variable fails at startup rather
+// than locking its user out.
+func (c *Config) Validate() error {
+	if len(c.Users) == 0 {
+		return errors.New("users must list at least one user")
+	}
+	names := map[string]bool{}
+	tokens := map[string]string{}
+	for i, u := range c.Users {
+		field := fmt.Sprintf("users[%d]", i)
+		if !userNamePattern.MatchString(u.Name) {
+			return fmt.Errorf("%s.name %q must be letters, digits, '.', '_', or '-'", field, u.Name)
+		}
+		if names[u.Name] {
+			return fmt.Errorf("%s: duplicate name %q", field, u.Name)
+		}
+		names[u.Name] = true
+		token := u.APIToken()
+		switch {
+		case u.Token == "" && u.TokenEnv == "":
+			return fmt.Errorf("%s: token or token_env is required", field)
+		case token == "":
+			return fmt.Errorf("%s: %s is not set", field, u.TokenEnv)
+		case len(token) < minTokenLength:
+			return fmt.Errorf("%s: the token must be at least %d characters", field, minTokenLength)
+		}
+		if other, ok := tokens[token]; ok {
+			return fmt.Errorf("%s: %q uses the same token", field, other)
+		}
+		tokens[token] = u.Name
+		if u.Telegram != nil && (u.Telegram.BotToken() == "" || u.Telegram.ChatID == 0) {
+			return fmt.Errorf("%s.telegram: a bot token and chat_id are required", field)
+		}
+		if err := u.Filter.validate(field); err != nil {
+			return err
+		}
+	}
+	for _, w := range []struct{ field, env, url string }{
+		{"team.slack", c.Team.SlackEnv, c.Team.SlackURL()},
+		{"team.discord", c.Team.DiscordEnv, c.Team.DiscordURL()},
+	} {
+		if w.url == "" {
+			if w.env != "" {
+				return fmt.Errorf("%s: %s is not set", w.field, w.env)
+			}
+			continue
+		}
+		if u, err := url.Parse(w.url); err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("%s must be an https:// webhook URL", w.field)
+		}
+	}
+	return c.Team.Filter.validate("team")
+}
+
+// userByToken returns the user token belongs to.
+func (c *Config) userByToken(token string) (User, bool) {
+	for _, u := range c.Users {
+		if tokenEqual(u.APIToken(), token) {
+			return u, true
+		}
+	}
+	return User{}, false
+}
@@ -0,0 +1,179 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/notify"
+)
+
+const (
+	aliceToken = "alice-token-0123456789"
+	bobToken   = "bob-token-0123456789ab"
+)
+
+// webhookRecorder collects the JSON bodies posted to it.
+type webhookRecorder struct {
+	mu     sync.Mutex
+	bodies []map[string]string
+}
+
+func (w *webhookRecorder) server(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("webhook body: %v", err)
+		}
+		w.mu.Lock()
+		w.bodies = append(w.bodies, body)
+		w.mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (w *webhookRecorder) posted() []map[string]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]map[string]string(nil), w.bodies...)
+}
+
+func testConfig(slack, discord string) *Config {
+	off := false
+	return &Config{
+		Team: TeamConfig{Slack: slack, Discord: discord},
+		Users: []User{
+			{Name: "alice", Token: aliceToken, Push: true},
+			{Name: "bob", Token: bobToken, Team: &off},
+		},
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := testConfig("https://hooks.slack.com/services/x", "").Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+	t.Setenv("HUB_TEST_TOKEN", "")
+	for name, mutate := range map[string]func(*Config){
+		"no users":        func(c *Config) { c.Users = nil },
+		"bad name":        func(c *Config) { c.Users[0].Name = "../alice" },
+		"duplicate name":  func(c *Config) { c.Users[1].Name = "alice" },
+		"no token":        func(c *Config) { c.Users[0].Token = "" },
+		"short token":     func(c *Config) { c.Users[0].Token = "short" },
+		"shared token":    func(c *Config) { c.Users[1].Token = aliceToken },
+		"unset token env": func(c *Config) { c.Users[0].Token, c.Users[0].TokenEnv = "", "HUB_TEST_TOKEN" },
+		"http webhook":    func(c *Config) { c.Team.Slack = "http://hooks.slack.com/x" },
+		"unset webhook":   func(c *Config) { c.Team.DiscordEnv = "HUB_TEST_TOKEN" },
+		"bad glob":        func(c *Config) { c.Team.Events = []string{"["} },
+		"telegram chat":   func(c *Config) { c.Users[0].Telegram = &notify.TelegramConfig{Token: "t"} },
+	} {
+		c := testConfig("", "")
+		mutate(c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestFilterPasses(t *testing.T) {
+	var defaults Filter
+	if !defaults.Passes("Notification", "critical") || defaults.Passes("Stop", "normal") {
+		t.Error("default filter should pass high and critical only")
+	}
+	f := Filter{Urgencies: []string{}, Events: []string{"stop"}}
+	if !f.Passes("Stop", "low") || f.Passes("Notification", "critical") {
+		t.Error("an empty urgency list should pass only the listed events")
+	}
+}
+
+func TestHandleEvent(t *testing.T) {
+	var slack, discord webhookRecorder
+	srv := NewServer(testConfig(slack.server(t).URL, discord.server(t).URL), t.TempDir())
+	handler := srv.Handler()
+
+	post := func(token, body string) int {
+		req := httptest.NewRequest(http.MethodPost, notify.HubEventsPath, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post("", `{"event":"Notification"}`); code != http.StatusUnauthorized {
+		t.Errorf("no token: %d", code)
+	}
+	if code := post("wrong-token-0123456789", `{"event":"Notification"}`); code != http.StatusUnauthorized {
+		t.Errorf("unknown token: %d", code)
+	}
+	if code := post(aliceToken, `{"text":"no event"}`); code != http.StatusBadRequest {
+		t.Errorf("missing event: %d", code)
+	}
+	if code := post(aliceToken, `{"event":"Notification","text":"Claude needs your permission","urgency":"critical","project":"/src/api"}`); code != http.StatusAccepted {
+		t.Fatalf("alice: %d", code)
+	}
+	if code := post(aliceToken, `{"event":"Stop","text":"done"}`); code != http.StatusAccepted {
+		t.Fatalf("alice normal: %d", code)
+	}
+	if code := post(bobToken, `{"event":"Notification","urgency":"critical"}`); code != http.StatusAccepted {
+		t.Fatalf("bob: %d", code)
+	}
+	srv.Wait()
+
+	got := slack.posted()
+	if len(got) != 1 {
+		t.Fatalf("slack got %d posts, want alice's critical event only: %v", len(got), got)
+	}
+	if want := "alice · ❗ api · Notification\nClaude needs your permission"; got[0]["text"] != want {
+		t.Errorf("slack text = %q, want %q", got[0]["text"], want)
+	}
+	if d := discord.posted(); len(d) != 1 || d[0]["content"] != got[0]["text"] {
+		t.Errorf("discord = %v", d)
+	}
+}
+
+func TestHandlePush(t *testing.T) {
+	handler := NewServer(testConfig("", ""), t.TempDir()).Handler()
+	get := func(user, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/push/", nil)
+		if token != "" {
+			req.SetBasicAuth(user, token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("", ""); rec.Code != http.StatusUnauthorized || !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Basic") {
+		t.Errorf("anonymous: %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+	if rec := get("bob", bobToken); rec.Code != http.StatusForbidden {
+		t.Errorf("bob without push: %d", rec.Code)
+	}
+	rec := get("alice", aliceToken)
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != http.StatusOK || !strings.Contains(string(body), "push notifications") {
+		t.Errorf("alice: %d %s", rec.Code, body)
+	}
+}
+
+func TestDeliverTruncatesDiscord(t *testing.T) {
+	var discord webhookRecorder
+	srv := NewServer(testConfig("", discord.server(t).URL), t.TempDir())
+	srv.Deliver(context.Background(), srv.config.Users[0], notify.MQTTMessage{
+		Event:   "Stop",
+		Text:    strings.Repeat("あ", 3000),
+		Urgency: "high",
+	})
+	got := discord.posted()
+	if len(got) != 1 || len([]rune(got[0]["content"])) != discordLimit {
+		t.Errorf("discord content should be cut to %d runes", discordLimit)
+	}
+}
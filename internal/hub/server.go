@@ -0,0 +1,202 @@
+package hub
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/notify"
+	"github.com/daikw/ccpersona/internal/webpush"
+	"github.com/rs/zerolog/log"
+)
+
+// maxEventSize caps event bodies; hooks send one message at a time.
+const maxEventSize = 64 << 10
+
+// deliveryTimeout bounds fanning out one event. Hooks get their answer
+// before delivery starts, so it never holds them up.
+const deliveryTimeout = 30 * time.Second
+
+// Server accepts events from users' hooks and delivers them.
+type Server struct {
+	config  *Config
+	dataDir string
+	client  *http.Client
+	// pending tracks deliveries still running, so Wait can let them finish
+	// on shutdown.
+	pending sync.WaitGroup
+}
+
+// NewServer returns a hub for config that keeps push subscriptions under
+// dataDir.
+func NewServer(config *Config, dataDir string) *Server {
+	return &Server{config: config, dataDir: dataDir, client: &http.Client{Timeout: deliveryTimeout}}
+}
+
+// Handler serves the event API, each user's push subscription page under
+// /push/, and a health check for container orchestrators.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok\n")
+	})
+	mux.HandleFunc("POST "+notify.HubEventsPath, s.handleEvent)
+	mux.Handle("/push/", http.StripPrefix("/push", http.HandlerFunc(s.handlePush)))
+	return mux
+}
+
+// Wait blocks until deliveries in flight are done.
+func (s *Server) Wait() {
+	s.pending.Wait()
+}
+
+func (s *Server) handleEvent(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	user, known := s.config.userByToken(token)
+	if !ok || !known {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ccpersona"`)
+		http.Error(w, "unknown API token", http.StatusUnauthorized)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxEventSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	var msg notify.MQTTMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		http.Error(w, fmt.Sprintf("invalid event: %v", err), http.StatusBadRequest)
+		return
+	}
+	if msg.Event == "" {
+		http.Error(w, "invalid event: event is required", http.StatusBadRequest)
+		return
+	}
+	if msg.Urgency == "" {
+		msg.Urgency = "normal"
+	}
+	w.WriteHeader(http.StatusAccepted)
+
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+		s.Deliver(ctx, user, msg)
+	}()
+}
+
+// Deliver fans msg from user out to the team's chat and the user's own
+// channels that its urgency and name pass. Failures are logged.
+func (s *Server) Deliver(ctx context.Context, user User, msg notify.MQTTMessage) {
+	event := notify.Event{
+		Name:      msg.Event,
+		Text:      msg.Text,
+		Tool:      msg.Tool,
+		Model:     msg.Model,
+		Subagent:  msg.Subagent,
+		Source:    msg.Source,
+		SessionID: msg.SessionID,
+		Project:   msg.Project,
+	}
+	logger := log.With().Str("user", user.Name).Str("event", msg.Event).Str("urgency", msg.Urgency).Logger()
+
+	if user.SharesWithTeam() && s.config.Team.Passes(msg.Event, msg.Urgency) {
+		text := user.Name + " · " + notify.TelegramText(event, msg.Urgency)
+		if webhook := s.config.Team.SlackURL(); webhook != "" {
+			if err := postWebhook(ctx, s.client, webhook, map[string]string{"text": text}); err != nil {
+				logger.Warn().Err(err).Msg("Failed to post to Slack")
+			}
+		}
+		if webhook := s.config.Team.DiscordURL(); webhook != "" {
+			if err := postWebhook(ctx, s.client, webhook, map[string]string{"content": truncate(text, discordLimit)}); err != nil {
+				logger.Warn().Err(err).Msg("Failed to post to Discord")
+			}
+		}
+	}
+	if !user.Filter.Passes(msg.Event, msg.Urgency) {
+		return
+	}
+	if user.Telegram != nil {
+		if err := sendTelegram(ctx, user.Telegram, notify.TelegramText(event, msg.Urgency)); err != nil {
+			logger.Warn().Err(err).Msg("Failed to send to Telegram")
+		}
+	}
+	if user.Push {
+		if err := s.push(ctx, user, event, msg.Urgency); err != nil {
+			logger.Warn().Err(err).Msg("Failed to push")
+		}
+	}
+}
+
+// push sends to every browser user subscribed. Notifications from one
+// session share a tag, so a phone shows only the latest of them.
+func (s *Server) push(ctx context.Context, user User, event notify.Event, urgency string) error {
+	title := user.Name
+	if event.Project != "" {
+		title = filepath.Base(event.Project)
+	}
+	payload, err := webpush.Message{
+		Title:   fmt.Sprintf("%s: %s", title, event.Name),
+		Body:    event.Text,
+		Urgency: urgency,
+		Tag:     event.SessionID,
+	}.Payload()
+	if err != nil {
+		return err
+	}
+	result, err := s.pushStore(user).Broadcast(ctx, s.client, payload, webpush.Options{
+		Subject: s.config.PushSubject,
+		Urgency: urgency,
+	})
+	if err == nil && len(result.Errors) > 0 {
+		err = result.Errors[0]
+	}
+	return err
+}
+
+func (s *Server) pushStore(user User) *webpush.Store {
+	return webpush.NewStore(filepath.Join(s.dataDir, "push", user.Name))
+}
+
+// handlePush serves the subscription page of the user who signs in with
+// HTTP basic auth, their API token as the password. Browsers ask once and
+// send it along with the page's own requests.
+func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) {
+	_, token, ok := r.BasicAuth()
+	user, known := s.config.userByToken(token)
+	if !ok || !known {
+		w.Header().Set("WWW-Authenticate", `Basic realm="ccpersona push (password: your API token)", charset="UTF-8"`)
+		http.Error(w, "sign in with your API token as the password", http.StatusUnauthorized)
+		return
+	}
+	if !user.Push {
+		http.Error(w, fmt.Sprintf("push is not enabled for %s in the hub config", user.Name), http.StatusForbidden)
+		return
+	}
+	store := s.pushStore(user)
+	key, err := store.Key()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	webpush.AuthenticatedHandler(store, key).ServeHTTP(w, r)
+}
+
+// tokenEqual compares tokens in constant time. Hashing first keeps the
+// comparison from leaking their length.
+func tokenEqual(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	ha, hb := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
@@ -0,0 +1,61 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/notify"
+	"github.com/daikw/ccpersona/internal/telegram"
+)
+
+// discordLimit is the longest message content Discord accepts.
+const discordLimit = 2000
+
+// postWebhook posts body as JSON to a Slack or Discord incoming webhook.
+// Errors never include the URL, which is a credential.
+func postWebhook(ctx context.Context, client *http.Client, webhook string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(data))
+	if err != nil {
+		return errors.New("invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// sendTelegram sends text to the user's chat.
+func sendTelegram(ctx context.Context, tg *notify.TelegramConfig, text string) error {
+	return telegram.NewBot(tg.BotToken()).SendMessage(ctx, tg.ChatID, text)
+}
+
+// truncate shortens s to limit runes, marking the cut.
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,208 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/daikw/ccpersona/internal/projectpath"
+)
+
+// Extraction limits keep SessionStart fast even for large transcript
+// histories.
+const (
+	// maxTranscripts is how many of the project's most recent transcripts
+	// are scanned.
+	maxTranscripts = 10
+	// maxLineBytes skips JSONL lines larger than this (pasted files, images).
+	maxLineBytes = 256 * 1024
+	minFactRunes = 8
+	maxFactRunes = 200
+)
+
+// factPatterns match sentences that state a lasting preference or project
+// convention rather than a one-off request.
+var factPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^(please )?(always|never)\b`),
+	regexp.MustCompile(`(?i)^(i|we) (prefer|like|want|always|never|use)\b`),
+	regexp.MustCompile(`(?i)^(please )?(don't|do not|avoid)\b`),
+	regexp.MustCompile(`(?i)^prefer\b`),
+	regexp.MustCompile(`(?i)\binstead of\b`),
+	regexp.MustCompile(`(?i)^remember( that)?\b`),
+	regexp.MustCompile(`(常に|必ず|いつも|しないで|禁止|優先して|を使って)`),
+}
+
+var sentenceSplit = regexp.MustCompile(`[\n.!?。！？]+`)
+
+// ProjectTranscriptDir returns the Claude Code transcript directory for
+// projectDir.
+func ProjectTranscriptDir(homeDir, projectDir string) string {
+	return filepath.Join(homeDir, ".claude", "projects", projectpath.Encode(projectDir))
+}
+
+// ExtractFromProject scans the most recent transcripts of projectDir for user
+// messages newer than since and returns candidate facts, oldest first, along
+// with the newest message timestamp seen (or since when nothing is newer).
+func ExtractFromProject(homeDir, projectDir string, since time.Time) ([]string, time.Time, error) {
+	dir := ProjectTranscriptDir(homeDir, projectDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, since, nil
+		}
+		return nil, since, err
+	}
+
+	type transcript struct {
+		path    string
+		modTime int64
+	}
+	var transcripts []transcript
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().After(since) {
+			continue
+		}
+		transcripts = append(transcripts, transcript{filepath.Join(dir, entry.Name()), info.ModTime().UnixNano()})
+	}
+	sort.Slice(transcripts, func(i, j int) bool { return transcripts[i].modTime < transcripts[j].modTime })
+	if len(transcripts) > maxTranscripts {
+		transcripts = transcripts[len(transcripts)-maxTranscripts:]
+	}
+
+	var facts []string
+	newest := since
+	for _, t := range transcripts {
+		f, err := os.Open(t.path)
+		if err != nil {
+			continue
+		}
+		found, latest := ExtractFacts(f, since)
+		_ = f.Close()
+		facts = append(facts, found...)
+		if latest.After(newest) {
+			newest = latest
+		}
+	}
+	return facts, newest, nil
+}
+
+// ExtractFacts returns preference-like sentences written by the user in a
+// Claude Code transcript after since, and the newest user message timestamp
+// seen. Messages without a timestamp are always considered.
+func ExtractFacts(r io.Reader, since time.Time) ([]string, time.Time) {
+	var facts []string
+	newest := since
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, err := readLine(reader)
+		if len(line) > 0 && bytes.Contains(line, []byte(`"user"`)) {
+			texts, ts := userTexts(line)
+			if ts.IsZero() || ts.After(since) {
+				for _, text := range texts {
+					facts = append(facts, factsFromText(text)...)
+				}
+				if ts.After(newest) {
+					newest = ts
+				}
+			}
+		}
+		if err != nil {
+			return facts, newest
+		}
+	}
+}
+
+// readLine reads one line, discarding (but consuming) lines longer than
+// maxLineBytes.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if !tooLong {
+			line = append(line, chunk...)
+			if len(line) > maxLineBytes {
+				tooLong = true
+				line = nil
+			}
+		}
+		if err != nil || !isPrefix {
+			return line, err
+		}
+	}
+}
+
+func userTexts(line []byte) ([]string, time.Time) {
+	var entry struct {
+		Type      string    `json:"type"`
+		IsMeta    bool      `json:"isMeta"`
+		Timestamp time.Time `json:"timestamp"`
+		Message   struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil, time.Time{}
+	}
+	if entry.Type != "user" || entry.IsMeta || entry.Message.Role != "user" {
+		return nil, time.Time{}
+	}
+
+	var text string
+	if err := json.Unmarshal(entry.Message.Content, &text); err == nil {
+		return []string{text}, entry.Timestamp
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(entry.Message.Content, &blocks); err != nil {
+		return nil, entry.Timestamp
+	}
+	var texts []string
+	for _, block := range blocks {
+		// tool_result blocks are tool output, not user statements.
+		if block.Type == "text" {
+			texts = append(texts, block.Text)
+		}
+	}
+	return texts, entry.Timestamp
+}
+
+func factsFromText(text string) []string {
+	text = strings.TrimSpace(text)
+	// Slash commands, command output wrappers, and pasted code are not
+	// statements of preference.
+	if text == "" || strings.HasPrefix(text, "<") || strings.HasPrefix(text, "/") || strings.Contains(text, "```") {
+		return nil
+	}
+
+	var facts []string
+	for _, sentence := range sentenceSplit.Split(text, -1) {
+		sentence = strings.TrimSpace(strings.TrimLeft(sentence, "-*• "))
+		n := utf8.RuneCountInString(sentence)
+		if n < minFactRunes || n > maxFactRunes {
+			continue
+		}
+		for _, pattern := range factPatterns {
+			if pattern.MatchString(sentence) {
+				facts = append(facts, sentence)
+				break
+			}
+		}
+	}
+	return facts
+}
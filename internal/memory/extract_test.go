@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractFacts(t *testing.T) {
+	transcript := strings.Join([]string{
+		`{"type":"user","timestamp":"2026-01-01T00:00:00Z","message":{"role":"user","content":"We use pnpm instead of npm. Can you fix the failing test?"}}`,
+		`{"type":"user","timestamp":"2026-01-01T00:01:00Z","message":{"role":"user","content":[{"type":"text","text":"Never commit generated files"},{"type":"tool_result","content":"Always ignore this"}]}}`,
+		`{"type":"user","timestamp":"2026-01-01T00:02:00Z","isMeta":true,"message":{"role":"user","content":"Always skip meta messages"}}`,
+		`{"type":"user","timestamp":"2026-01-01T00:03:00Z","message":{"role":"user","content":"<command-name>/clear</command-name> always"}}`,
+		`{"type":"assistant","timestamp":"2026-01-01T00:04:00Z","message":{"role":"assistant","content":[{"type":"text","text":"I will always do that."}]}}`,
+		`{"type":"user","timestamp":"2026-01-01T00:05:00Z","message":{"role":"user","content":"コメントは必ず日本語で書いてください。"}}`,
+		`not json`,
+	}, "\n")
+
+	facts, newest := ExtractFacts(strings.NewReader(transcript), time.Time{})
+	want := []string{
+		"We use pnpm instead of npm",
+		"Never commit generated files",
+		"コメントは必ず日本語で書いてください",
+	}
+	if strings.Join(facts, "|") != strings.Join(want, "|") {
+		t.Errorf("ExtractFacts() = %q, want %q", facts, want)
+	}
+	if !newest.Equal(time.Date(2026, 1, 1, 0, 5, 0, 0, time.UTC)) {
+		t.Errorf("newest = %v", newest)
+	}
+
+	since := time.Date(2026, 1, 1, 0, 0, 30, 0, time.UTC)
+	facts, _ = ExtractFacts(strings.NewReader(transcript), since)
+	if len(facts) != 2 || facts[0] != "Never commit generated files" {
+		t.Errorf("ExtractFacts(since) = %q", facts)
+	}
+}
+
+func TestExtractFacts_SkipsOversizedLines(t *testing.T) {
+	huge := `{"type":"user","message":{"role":"user","content":"Always ` + strings.Repeat("x", maxLineBytes) + `"}}`
+	ok := `{"type":"user","message":{"role":"user","content":"Always write tests first"}}`
+	facts, _ := ExtractFacts(strings.NewReader(huge+"\n"+ok+"\n"), time.Time{})
+	if len(facts) != 1 || facts[0] != "Always write tests first" {
+		t.Errorf("ExtractFacts() = %q", facts)
+	}
+}
+
+func TestExtractFromProject(t *testing.T) {
+	home := t.TempDir()
+	dir := ProjectTranscriptDir(home, "/work/app")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	line := `{"type":"user","timestamp":"2026-01-01T00:00:00Z","message":{"role":"user","content":"Prefer table-driven tests"}}`
+	if err := os.WriteFile(filepath.Join(dir, "a.jsonl"), []byte(line+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	facts, _, err := ExtractFromProject(home, "/work/app", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(facts) != 1 || facts[0] != "Prefer table-driven tests" {
+		t.Errorf("ExtractFromProject() = %q", facts)
+	}
+
+	facts, _, err = ExtractFromProject(home, "/work/other", time.Time{})
+	if err != nil || facts != nil {
+		t.Errorf("missing project should yield no facts, got %q, %v", facts, err)
+	}
+}
@@ -0,0 +1,196 @@
+// Package memory keeps a small per-project list of stable facts and
+// preferences extracted from prior transcripts, appended to the persona at
+// session start.
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/daikw/ccpersona/internal/projectpath"
+)
+
+// Size limits. MaxChars in config is clamped to HardMaxChars so a memory file
+// can never crowd out the persona itself.
+const (
+	DefaultMaxChars = 1500
+	HardMaxChars    = 4000
+	maxFacts        = 30
+)
+
+// SectionTitle heads the memory section appended to the persona output.
+const SectionTitle = "## Memory"
+
+// sincePrefix marks the watermark line recording the newest transcript
+// message already processed. Facts removed by the user or by Clear therefore
+// do not come back unless they are restated in a later session.
+const sincePrefix = "<!-- ccpersona:since="
+
+// File is the parsed content of a memory file.
+type File struct {
+	Facts []string
+	Since time.Time
+}
+
+// Dir returns the directory holding per-project memory files.
+func Dir(homeDir string) string {
+	return filepath.Join(homeDir, ".agents", "ccpersona", "memory")
+}
+
+// Path returns the memory file for projectDir.
+func Path(homeDir, projectDir string) string {
+	return filepath.Join(Dir(homeDir), projectpath.Encode(projectDir)+".md")
+}
+
+// EffectiveMaxChars clamps a configured limit to (0, HardMaxChars].
+func EffectiveMaxChars(configured int) int {
+	if configured <= 0 {
+		return DefaultMaxChars
+	}
+	if configured > HardMaxChars {
+		return HardMaxChars
+	}
+	return configured
+}
+
+// Load reads a memory file. Facts are markdown bullet lines; any other lines
+// (headers, notes added while editing) are ignored. A missing file yields an
+// empty File.
+func Load(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &File{}, nil
+		}
+		return nil, fmt.Errorf("failed to read memory: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	out := &File{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if value, ok := strings.CutPrefix(line, sincePrefix); ok {
+			if since, err := time.Parse(time.RFC3339Nano, strings.TrimSuffix(value, " -->")); err == nil {
+				out.Since = since
+			}
+			continue
+		}
+		if fact, ok := strings.CutPrefix(line, "- "); ok && strings.TrimSpace(fact) != "" {
+			out.Facts = append(out.Facts, strings.TrimSpace(fact))
+		}
+	}
+	return out, scanner.Err()
+}
+
+// Save writes a memory file as a markdown list.
+func Save(path, projectDir string, file *File) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create memory directory: %w", err)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# ccpersona memory for %s\n\n", projectDir)
+	b.WriteString("<!-- One fact per \"- \" line. Oldest facts are dropped first when the size cap is reached. -->\n")
+	if !file.Since.IsZero() {
+		fmt.Fprintf(&b, "%s%s -->\n", sincePrefix, file.Since.UTC().Format(time.RFC3339Nano))
+	}
+	b.WriteString("\n")
+	for _, fact := range file.Facts {
+		fmt.Fprintf(&b, "- %s\n", fact)
+	}
+	// Concurrent SessionStart hooks in one project may refresh memory at the
+	// same time; readers must never see a partial or interleaved file.
+	if err := fsutil.WriteFileLocked(path, []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write memory: %w", err)
+	}
+	return nil
+}
+
+// Merge appends new facts that are not already known and enforces the size
+// cap by dropping the oldest facts first.
+func Merge(existing, extracted []string, maxChars int) []string {
+	seen := make(map[string]bool, len(existing)+len(extracted))
+	var merged []string
+	for _, fact := range append(append([]string{}, existing...), extracted...) {
+		key := normalize(fact)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, fact)
+	}
+	return capFacts(merged, maxChars)
+}
+
+// capFacts keeps the newest facts whose rendered size fits maxChars.
+func capFacts(facts []string, maxChars int) []string {
+	if len(facts) > maxFacts {
+		facts = facts[len(facts)-maxFacts:]
+	}
+	total := 0
+	start := len(facts)
+	for i := len(facts) - 1; i >= 0; i-- {
+		size := utf8.RuneCountInString(facts[i]) + 3 // "- " and newline
+		if total+size > maxChars {
+			break
+		}
+		total += size
+		start = i
+	}
+	return facts[start:]
+}
+
+func normalize(fact string) string {
+	fact = strings.ToLower(strings.Join(strings.Fields(fact), " "))
+	return strings.TrimRight(fact, ".!?。！？ ")
+}
+
+// Render formats facts as the section appended to persona output, or "" when
+// there is nothing to add.
+func Render(facts []string, maxChars int) string {
+	facts = capFacts(facts, maxChars)
+	if len(facts) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(SectionTitle + "\n")
+	b.WriteString("Stable preferences from earlier sessions in this project:\n")
+	for _, fact := range facts {
+		fmt.Fprintf(&b, "- %s\n", fact)
+	}
+	return b.String()
+}
+
+// Refresh extracts facts from transcript messages newer than the stored
+// watermark, merges them into the project's memory, and returns the result.
+func Refresh(homeDir, projectDir string, maxChars int) (*File, error) {
+	path := Path(homeDir, projectDir)
+	file, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	extracted, newest, err := ExtractFromProject(homeDir, projectDir, file.Since)
+	if err != nil {
+		return file, fmt.Errorf("failed to read transcripts: %w", err)
+	}
+	if !newest.After(file.Since) && len(extracted) == 0 {
+		return file, nil
+	}
+	updated := &File{Facts: Merge(file.Facts, extracted, maxChars), Since: newest}
+	if err := Save(path, projectDir, updated); err != nil {
+		return updated, err
+	}
+	return updated, nil
+}
+
+// Clear drops all facts for projectDir. The watermark is kept at now so facts
+// from transcripts already on disk are not extracted again.
+func Clear(homeDir, projectDir string) error {
+	return Save(Path(homeDir, projectDir), projectDir, &File{Since: time.Now().UTC()})
+}
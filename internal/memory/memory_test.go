@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEffectiveMaxChars(t *testing.T) {
+	if got := EffectiveMaxChars(0); got != DefaultMaxChars {
+		t.Errorf("EffectiveMaxChars(0) = %d", got)
+	}
+	if got := EffectiveMaxChars(100000); got != HardMaxChars {
+		t.Errorf("EffectiveMaxChars(100000) = %d", got)
+	}
+	if got := EffectiveMaxChars(500); got != 500 {
+		t.Errorf("EffectiveMaxChars(500) = %d", got)
+	}
+}
+
+func TestMerge_DedupesAndCaps(t *testing.T) {
+	merged := Merge([]string{"Always use tabs", "Never push to main"}, []string{"always use  tabs.", "Prefer small PRs"}, 1000)
+	if strings.Join(merged, "|") != "Always use tabs|Never push to main|Prefer small PRs" {
+		t.Errorf("Merge() = %q", merged)
+	}
+
+	// Only the newest facts that fit are kept.
+	capped := Merge([]string{strings.Repeat("a", 40), strings.Repeat("b", 40)}, []string{strings.Repeat("c", 40)}, 90)
+	if len(capped) != 2 || capped[0][0] != 'b' || capped[1][0] != 'c' {
+		t.Errorf("Merge() capped = %q", capped)
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory", "p.md")
+	since := time.Date(2026, 2, 3, 4, 5, 6, 0, time.UTC)
+	if err := Save(path, "/work/app", &File{Facts: []string{"Always use tabs"}, Since: since}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hand-added bullets are picked up; other lines are ignored.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("Some note\n- Prefer small PRs\n")
+	_ = f.Close()
+
+	file, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(file.Facts, "|") != "Always use tabs|Prefer small PRs" {
+		t.Errorf("Load() facts = %q", file.Facts)
+	}
+	if !file.Since.Equal(since) {
+		t.Errorf("Load() since = %v, want %v", file.Since, since)
+	}
+}
+
+func TestSaveConcurrent(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "memory")
+	path := filepath.Join(dir, "p.md")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			facts := make([]string, 50)
+			for j := range facts {
+				facts[j] = fmt.Sprintf("writer %d fact %d", i, j)
+			}
+			if err := Save(path, "/work/app", &File{Facts: facts}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	file, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Facts) != 50 {
+		t.Fatalf("got %d facts, want one writer's complete list", len(file.Facts))
+	}
+	writer := strings.Fields(file.Facts[0])[1]
+	for _, fact := range file.Facts {
+		if strings.Fields(fact)[1] != writer {
+			t.Fatalf("facts from several writers interleaved: %q", file.Facts)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temp files left behind: %d entries", len(entries))
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 && runtime.GOOS != "windows" {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestRender(t *testing.T) {
+	if Render(nil, 100) != "" {
+		t.Error("empty memory should render nothing")
+	}
+	out := Render([]string{"Always use tabs"}, 100)
+	if !strings.HasPrefix(out, SectionTitle+"\n") || !strings.Contains(out, "- Always use tabs\n") {
+		t.Errorf("Render() = %q", out)
+	}
+}
+
+func TestRefreshAndClear(t *testing.T) {
+	home := t.TempDir()
+	project := "/work/app"
+	dir := ProjectTranscriptDir(home, project)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	line := `{"type":"user","timestamp":"2026-01-01T00:00:00Z","message":{"role":"user","content":"Always run the linter"}}`
+	transcript := filepath.Join(dir, "a.jsonl")
+	if err := os.WriteFile(transcript, []byte(line+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(transcript, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Refresh(home, project, DefaultMaxChars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Facts) != 1 {
+		t.Fatalf("Refresh() facts = %q", file.Facts)
+	}
+
+	if err := Clear(home, project); err != nil {
+		t.Fatal(err)
+	}
+	file, err = Refresh(home, project, DefaultMaxChars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Facts) != 0 {
+		t.Errorf("cleared facts came back: %q", file.Facts)
+	}
+}
@@ -0,0 +1,176 @@
+// Package models downloads and tracks local TTS model files used by offline
+// providers (sherpa-onnx).
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// Model is a downloadable model archive.
+type Model struct {
+	Name        string
+	URL         string
+	SHA256      string // expected archive hash; empty when not pinned
+	Description string
+}
+
+const sherpaReleaseURL = "https://github.com/k2-fsa/sherpa-onnx/releases/download/tts-models/"
+
+// Catalog lists well-known sherpa-onnx VITS models that `models pull` accepts
+// by name.
+var Catalog = []Model{
+	{Name: "vits-piper-en_US-amy-low", URL: sherpaReleaseURL + "vits-piper-en_US-amy-low.tar.bz2", Description: "English (US), female, small"},
+	{Name: "vits-piper-en_US-lessac-medium", URL: sherpaReleaseURL + "vits-piper-en_US-lessac-medium.tar.bz2", Description: "English (US), female, medium"},
+	{Name: "vits-piper-de_DE-thorsten-medium", URL: sherpaReleaseURL + "vits-piper-de_DE-thorsten-medium.tar.bz2", Description: "German, male, medium"},
+}
+
+// Lookup finds a catalog entry by name.
+func Lookup(name string) (Model, bool) {
+	for _, m := range Catalog {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Model{}, false
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// ValidName reports whether name is usable as a model directory name.
+func ValidName(name string) bool {
+	return namePattern.MatchString(name) && name != "." && name != ".."
+}
+
+// Installed records a pulled model. Files maps paths relative to Path to
+// their SHA-256, so Verify can detect corruption later.
+type Installed struct {
+	Name     string            `json:"name"`
+	Path     string            `json:"path"`
+	URL      string            `json:"url"`
+	SHA256   string            `json:"sha256"` // archive hash
+	Files    map[string]string `json:"files"`
+	Size     int64             `json:"size"`
+	PulledAt time.Time         `json:"pulled_at"`
+}
+
+// Dir returns the directory holding pulled models.
+func Dir(homeDir string) string {
+	return filepath.Join(homeDir, ".agents", "ccpersona", "models")
+}
+
+func manifestPath(homeDir string) string {
+	return filepath.Join(Dir(homeDir), "manifest.json")
+}
+
+func loadManifest(homeDir string) (map[string]Installed, error) {
+	data, err := os.ReadFile(manifestPath(homeDir))
+	if os.IsNotExist(err) {
+		return map[string]Installed{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read model manifest: %w", err)
+	}
+	manifest := map[string]Installed{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse model manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+func saveManifest(homeDir string, manifest map[string]Installed) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteFile(manifestPath(homeDir), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write model manifest: %w", err)
+	}
+	return nil
+}
+
+// List returns installed models sorted by name.
+func List(homeDir string) ([]Installed, error) {
+	manifest, err := loadManifest(homeDir)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Installed, 0, len(manifest))
+	for _, m := range manifest {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// Get returns an installed model by name.
+func Get(homeDir, name string) (*Installed, error) {
+	manifest, err := loadManifest(homeDir)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := manifest[name]
+	if !ok {
+		return nil, fmt.Errorf("model %q is not installed (run 'ccpersona runtime models pull %s')", name, name)
+	}
+	return &m, nil
+}
+
+// Remove deletes an installed model and its manifest entry.
+func Remove(homeDir, name string) error {
+	manifest, err := loadManifest(homeDir)
+	if err != nil {
+		return err
+	}
+	m, ok := manifest[name]
+	if !ok {
+		return fmt.Errorf("model %q is not installed", name)
+	}
+	if err := os.RemoveAll(m.Path); err != nil {
+		return fmt.Errorf("remove model files: %w", err)
+	}
+	delete(manifest, name)
+	return saveManifest(homeDir, manifest)
+}
+
+// Verify re-hashes an installed model's files against the manifest and
+// returns the relative paths that are missing or modified.
+func Verify(homeDir, name string) ([]string, error) {
+	m, err := Get(homeDir, name)
+	if err != nil {
+		return nil, err
+	}
+	var bad []string
+	for rel, want := range m.Files {
+		got, err := fileSHA256(filepath.Join(m.Path, rel))
+		if err != nil || got != want {
+			bad = append(bad, rel)
+		}
+	}
+	sort.Strings(bad)
+	return bad, nil
+}
+
+// ResolveDir maps a configured model value to a directory: an installed
+// model name, or a path to a model directory.
+func ResolveDir(homeDir, value string) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("no model configured")
+	}
+	if ValidName(value) && !filepath.IsAbs(value) {
+		if m, err := Get(homeDir, value); err == nil {
+			return m.Path, nil
+		}
+	}
+	if info, err := os.Stat(value); err == nil && info.IsDir() {
+		return value, nil
+	}
+	return "", fmt.Errorf("model %q is neither an installed model nor a directory (run 'ccpersona runtime models pull %s')", value, value)
+}
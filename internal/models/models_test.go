@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	seen := map[string]bool{}
+	for _, m := range Catalog {
+		if !ValidName(m.Name) {
+			t.Errorf("invalid catalog name %q", m.Name)
+		}
+		if seen[m.Name] {
+			t.Errorf("duplicate catalog entry %q", m.Name)
+		}
+		seen[m.Name] = true
+		if !strings.HasPrefix(m.URL, "https://") {
+			t.Errorf("%s: catalog URLs must use https: %s", m.Name, m.URL)
+		}
+		if got, ok := Lookup(m.Name); !ok || got.URL != m.URL {
+			t.Errorf("Lookup(%q) = %+v, %v", m.Name, got, ok)
+		}
+	}
+	if _, ok := Lookup("missing"); ok {
+		t.Error("Lookup should fail for unknown names")
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"vits-piper-en_US-amy-low": true,
+		"model.v2":                 true,
+		"":                         false,
+		"..":                       false,
+		"../x":                     false,
+		"a/b":                      false,
+		".hidden":                  false,
+	} {
+		if got := ValidName(name); got != want {
+			t.Errorf("ValidName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestResolveDir(t *testing.T) {
+	home := t.TempDir()
+	dir := t.TempDir()
+
+	if got, err := ResolveDir(home, dir); err != nil || got != dir {
+		t.Errorf("directory path = %q, %v", got, err)
+	}
+	if _, err := ResolveDir(home, "not-installed"); err == nil {
+		t.Error("expected error for unknown model")
+	}
+	if _, err := ResolveDir(home, ""); err == nil {
+		t.Error("expected error for empty value")
+	}
+}
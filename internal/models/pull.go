@@ -0,0 +1,228 @@
+package models
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxExtractBytes bounds the unpacked size of a model archive.
+const maxExtractBytes = 4 << 30
+
+// Pull downloads m into the models directory, verifying the archive hash when
+// m.SHA256 is set, and records the result in the manifest. An existing model
+// with the same name is replaced only after the new one is fully unpacked.
+func Pull(ctx context.Context, client *http.Client, homeDir string, m Model) (*Installed, error) {
+	if !ValidName(m.Name) {
+		return nil, fmt.Errorf("invalid model name %q", m.Name)
+	}
+	dir := Dir(homeDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create models dir: %w", err)
+	}
+
+	archive, sum, err := download(ctx, client, dir, m.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(archive)
+
+	if m.SHA256 != "" && !strings.EqualFold(sum, m.SHA256) {
+		return nil, fmt.Errorf("checksum mismatch for %s: got %s, want %s", m.Name, sum, m.SHA256)
+	}
+
+	staging, err := os.MkdirTemp(dir, "."+m.Name+"-")
+	if err != nil {
+		return nil, fmt.Errorf("create staging dir: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	files, size, err := extract(archive, m.URL, staging)
+	if err != nil {
+		return nil, err
+	}
+
+	// Release archives wrap everything in a single top-level directory named
+	// after the model; flatten it so the model dir holds the files directly.
+	root := staging
+	if entries, err := os.ReadDir(staging); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		root = filepath.Join(staging, entries[0].Name())
+		prefix := entries[0].Name() + "/"
+		flattened := make(map[string]string, len(files))
+		for rel, hash := range files {
+			flattened[strings.TrimPrefix(rel, prefix)] = hash
+		}
+		files = flattened
+	}
+
+	target := filepath.Join(dir, m.Name)
+	if err := os.RemoveAll(target); err != nil {
+		return nil, fmt.Errorf("replace existing model: %w", err)
+	}
+	if err := os.Rename(root, target); err != nil {
+		return nil, fmt.Errorf("install model: %w", err)
+	}
+
+	installed := Installed{
+		Name:     m.Name,
+		Path:     target,
+		URL:      m.URL,
+		SHA256:   sum,
+		Files:    files,
+		Size:     size,
+		PulledAt: time.Now().UTC(),
+	}
+	manifest, err := loadManifest(homeDir)
+	if err != nil {
+		return nil, err
+	}
+	manifest[m.Name] = installed
+	if err := saveManifest(homeDir, manifest); err != nil {
+		return nil, err
+	}
+	return &installed, nil
+}
+
+// download streams url to a temp file in dir and returns its path and SHA-256.
+func download(ctx context.Context, client *http.Client, dir, url string) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("download %s: status %d", url, resp.StatusCode)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".download-*")
+	if err != nil {
+		return "", "", fmt.Errorf("create download file: %w", err)
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), resp.Body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", "", fmt.Errorf("download %s: %w", url, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", "", fmt.Errorf("download %s: %w", url, err)
+	}
+	return tmp.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// extract unpacks a .tar.bz2 / .tar.gz / .tar archive into dest and returns
+// the SHA-256 of every regular file. Entries that would escape dest, links,
+// and device files are rejected.
+func extract(archive, name, dest string) (map[string]string, int64, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch {
+	case strings.HasSuffix(name, ".tar.bz2"), strings.HasSuffix(name, ".tbz2"):
+		r = bzip2.NewReader(f)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, 0, fmt.Errorf("open archive: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	case strings.HasSuffix(name, ".tar"):
+	default:
+		return nil, 0, fmt.Errorf("unsupported archive format: %s", filepath.Base(name))
+	}
+
+	files := map[string]string{}
+	var total int64
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("read archive: %w", err)
+		}
+
+		rel := filepath.ToSlash(filepath.Clean(hdr.Name))
+		if rel == "." {
+			continue
+		}
+		if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, "../") {
+			return nil, 0, fmt.Errorf("archive entry %q escapes the model directory", hdr.Name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(rel))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return nil, 0, err
+			}
+		case tar.TypeReg:
+			total += hdr.Size
+			if total > maxExtractBytes {
+				return nil, 0, fmt.Errorf("archive expands beyond %d GB", maxExtractBytes>>30)
+			}
+			sum, err := writeFile(target, tr)
+			if err != nil {
+				return nil, 0, err
+			}
+			files[rel] = sum
+		default:
+			// Links and special files are not needed by any model layout.
+			continue
+		}
+	}
+	return files, total, nil
+}
+
+func writeFile(path string, r io.Reader) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), r); err != nil {
+		out.Close()
+		return "", fmt.Errorf("extract %s: %w", filepath.Base(path), err)
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type tarEntry struct {
+	name string
+	body string
+	dir  bool
+}
+
+func tarGz(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		if e.dir {
+			hdr = &tar.Header{Name: e.name, Mode: 0o755, Typeflag: tar.TypeDir}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if !e.dir {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func serve(t *testing.T, data []byte) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPullInstallsAndVerifies(t *testing.T) {
+	home := t.TempDir()
+	archive := tarGz(t, []tarEntry{
+		{name: "vits-test/", dir: true},
+		{name: "vits-test/model.onnx", body: "onnx"},
+		{name: "vits-test/tokens.txt", body: "a 1"},
+		{name: "vits-test/espeak-ng-data/phontab", body: "ph"},
+	})
+	sum := sha256.Sum256(archive)
+	server := serve(t, archive)
+
+	m := Model{Name: "vits-test", URL: server.URL + "/vits-test.tar.gz", SHA256: hex.EncodeToString(sum[:])}
+	installed, err := Pull(context.Background(), server.Client(), home, m)
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	if installed.Path != filepath.Join(Dir(home), "vits-test") {
+		t.Errorf("path = %s", installed.Path)
+	}
+	for _, rel := range []string{"model.onnx", "tokens.txt", "espeak-ng-data/phontab"} {
+		if _, ok := installed.Files[rel]; !ok {
+			t.Errorf("manifest missing %s: %v", rel, installed.Files)
+		}
+		if _, err := os.Stat(filepath.Join(installed.Path, rel)); err != nil {
+			t.Errorf("file %s not installed: %v", rel, err)
+		}
+	}
+
+	list, err := List(home)
+	if err != nil || len(list) != 1 || list[0].Name != "vits-test" {
+		t.Fatalf("List() = %+v, %v", list, err)
+	}
+	if dir, err := ResolveDir(home, "vits-test"); err != nil || dir != installed.Path {
+		t.Errorf("ResolveDir() = %q, %v", dir, err)
+	}
+
+	if bad, err := Verify(home, "vits-test"); err != nil || len(bad) != 0 {
+		t.Fatalf("Verify() = %v, %v", bad, err)
+	}
+	if err := os.WriteFile(filepath.Join(installed.Path, "tokens.txt"), []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if bad, _ := Verify(home, "vits-test"); len(bad) != 1 || bad[0] != "tokens.txt" {
+		t.Errorf("Verify() after tamper = %v", bad)
+	}
+
+	if err := Remove(home, "vits-test"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := os.Stat(installed.Path); !os.IsNotExist(err) {
+		t.Error("model dir should be removed")
+	}
+	if list, _ := List(home); len(list) != 0 {
+		t.Errorf("manifest not updated: %+v", list)
+	}
+}
+
+func TestPullChecksumMismatch(t *testing.T) {
+	home := t.TempDir()
+	server := serve(t, tarGz(t, []tarEntry{{name: "model.onnx", body: "x"}}))
+
+	m := Model{Name: "bad", URL: server.URL + "/bad.tar.gz", SHA256: strings.Repeat("0", 64)}
+	_, err := Pull(context.Background(), server.Client(), home, m)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(Dir(home), "bad")); !os.IsNotExist(err) {
+		t.Error("nothing should be installed on mismatch")
+	}
+}
+
+func TestPullRejectsPathTraversal(t *testing.T) {
+	home := t.TempDir()
+	server := serve(t, tarGz(t, []tarEntry{{name: "../../escape.txt", body: "x"}}))
+
+	_, err := Pull(context.Background(), server.Client(), home, Model{Name: "evil", URL: server.URL + "/evil.tar.gz"})
+	if err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("expected traversal error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".agents", "escape.txt")); !os.IsNotExist(err) {
+		t.Error("entry escaped the staging directory")
+	}
+}
+
+func TestPullUnsupportedArchive(t *testing.T) {
+	server := serve(t, []byte("zip"))
+	_, err := Pull(context.Background(), server.Client(), t.TempDir(), Model{Name: "z", URL: server.URL + "/z.zip"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported archive") {
+		t.Fatalf("expected unsupported archive error, got %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// DigestConfig collects spoken announcements below high urgency and reads
+// them as one summary per interval, such as "In the last 10 minutes: 3 turns
+// completed, 1 needs review." High and critical announcements are still
+// spoken at once.
+type DigestConfig struct {
+	// Interval is the time from the first collected event to the summary,
+	// such as "10m".
+	Interval string `json:"interval"`
+	// Events limits the digest to these event names (case-insensitive);
+	// empty collects every event.
+	Events []string `json:"events,omitempty"`
+}
+
+// MinDigestInterval is the shortest interval accepted.
+const MinDigestInterval = 30 * time.Second
+
+// Enabled reports whether a digest is configured; safe on nil.
+func (d *DigestConfig) Enabled() bool {
+	return d != nil && d.Interval != ""
+}
+
+// Period returns the parsed interval.
+func (d *DigestConfig) Period() time.Duration {
+	period, _ := time.ParseDuration(d.Interval)
+	return period
+}
+
+// Collects reports whether an event spoken with urgency goes to the digest.
+func (d *DigestConfig) Collects(event, urgency string) bool {
+	if !d.Enabled() || urgency == "high" || urgency == "critical" {
+		return false
+	}
+	if len(d.Events) == 0 {
+		return true
+	}
+	for _, name := range d.Events {
+		if strings.EqualFold(name, event) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *DigestConfig) validate() error {
+	if d == nil {
+		return nil
+	}
+	period, err := time.ParseDuration(d.Interval)
+	if err != nil {
+		return fmt.Errorf("notifications.digest.interval: %w", err)
+	}
+	if period < MinDigestInterval {
+		return fmt.Errorf("notifications.digest.interval must be at least %s", MinDigestInterval)
+	}
+	return nil
+}
+
+// DigestEntry is an announcement waiting for the next summary.
+type DigestEntry struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Text    string    `json:"text,omitempty"`
+	Project string    `json:"project,omitempty"`
+}
+
+// Digest is the queue of collected announcements, shared by every hook
+// process. It lives in the OS temp directory, like pending questions.
+type Digest struct {
+	path string
+}
+
+// NewDigest returns the shared digest queue.
+func NewDigest() *Digest {
+	return &Digest{path: filepath.Join(os.TempDir(), questionDir, "digest.jsonl")}
+}
+
+// Add queues entry and reports whether the queue was empty before, in which
+// case the caller schedules the summary.
+func (d *Digest) Add(entry DigestEntry) (first bool, err error) {
+	entry.Text = truncateRunes(entry.Text, 200)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return false, err
+	}
+	err = fsutil.WithLock(d.path, func() error {
+		data, err := os.ReadFile(d.path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		first = len(bytes.TrimSpace(data)) == 0
+		return fsutil.WriteFile(d.path, append(append(data, line...), '\n'), 0600)
+	})
+	return first, err
+}
+
+// Take returns the queued entries and empties the queue.
+func (d *Digest) Take() ([]DigestEntry, error) {
+	return d.take(func([]DigestEntry) bool { return true })
+}
+
+// TakeOverdue is Take when the oldest entry was queued at least period
+// before now, and returns nothing otherwise. Hooks call it so a summary
+// whose scheduled process never ran is still spoken.
+func (d *Digest) TakeOverdue(period time.Duration, now time.Time) ([]DigestEntry, error) {
+	return d.take(func(entries []DigestEntry) bool {
+		for _, entry := range entries {
+			if now.Sub(entry.Time) >= period {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// take empties the queue and returns its entries when due accepts them.
+func (d *Digest) take(due func([]DigestEntry) bool) ([]DigestEntry, error) {
+	var entries []DigestEntry
+	err := fsutil.WithLock(d.path, func() error {
+		data, err := os.ReadFile(d.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			var entry DigestEntry
+			if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+				entries = append(entries, entry)
+			}
+		}
+		if !due(entries) {
+			entries = nil
+			return nil
+		}
+		return os.Remove(d.path)
+	})
+	return entries, err
+}
+
+// digestKinds names the common events in a summary, as singular and plural
+// phrases.
+var digestKinds = []struct {
+	events           []string
+	singular, plural string
+}{
+	{[]string{"Stop", "agent-turn-complete", "afterAgentResponse"}, "turn completed", "turns completed"},
+	{[]string{"Notification"}, "needs review", "need review"},
+	{[]string{"tool_error"}, "tool error", "tool errors"},
+	{[]string{"ci"}, "CI update", "CI updates"},
+	{[]string{"exec"}, "command finished", "commands finished"},
+}
+
+// DigestSummary renders entries as one sentence covering the time since the
+// oldest of them, such as "In the last 10 minutes: 3 turns completed, 1
+// needs review." It returns "" for no entries.
+func DigestSummary(entries []DigestEntry, now time.Time) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	oldest := entries[0].Time
+	counts := map[string]int{}
+	var order []string
+	for _, entry := range entries {
+		if entry.Time.Before(oldest) {
+			oldest = entry.Time
+		}
+		kind := digestKind(entry.Event)
+		if counts[kind] == 0 {
+			order = append(order, kind)
+		}
+		counts[kind]++
+	}
+
+	parts := make([]string, 0, len(order))
+	for _, kind := range order {
+		parts = append(parts, digestPhrase(kind, counts[kind]))
+	}
+	minutes := int((now.Sub(oldest) + time.Minute - 1) / time.Minute)
+	span := "minute"
+	if minutes > 1 {
+		span = fmt.Sprintf("%d minutes", minutes)
+	}
+	return fmt.Sprintf("In the last %s: %s.", span, strings.Join(parts, ", "))
+}
+
+// digestKind groups an event name with the events counted together.
+func digestKind(event string) string {
+	for _, kind := range digestKinds {
+		for _, name := range kind.events {
+			if strings.EqualFold(name, event) {
+				return kind.events[0]
+			}
+		}
+	}
+	return event
+}
+
+func digestPhrase(kind string, n int) string {
+	for _, k := range digestKinds {
+		if k.events[0] == kind {
+			if n == 1 {
+				return "1 " + k.singular
+			}
+			return fmt.Sprintf("%d %s", n, k.plural)
+		}
+	}
+	if n == 1 {
+		return fmt.Sprintf("1 %s event", kind)
+	}
+	return fmt.Sprintf("%d %s events", n, kind)
+}
+
+// CollectsDigest reports whether the digest takes an event spoken with
+// urgency; safe on nil.
+func (c *Config) CollectsDigest(event, urgency string) bool {
+	return c != nil && c.Digest.Collects(event, urgency)
+}
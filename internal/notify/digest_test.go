@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDigestConfig(t *testing.T) {
+	d := &DigestConfig{Interval: "10m"}
+	if !d.Collects("Stop", "normal") || !d.Collects("ci", "low") || d.Collects("Notification", "high") || d.Collects("Stop", "critical") {
+		t.Error("digest should collect every event below high urgency")
+	}
+	if d.Period() != 10*time.Minute {
+		t.Errorf("Period() = %v", d.Period())
+	}
+	d.Events = []string{"stop"}
+	if !d.Collects("Stop", "normal") || d.Collects("ci", "normal") {
+		t.Error("digest with events should collect only those events")
+	}
+	if (*DigestConfig)(nil).Collects("Stop", "normal") || (*Config)(nil).CollectsDigest("Stop", "normal") {
+		t.Error("no digest should collect nothing")
+	}
+
+	for _, interval := range []string{"", "soon", "10s"} {
+		if err := (&Config{Digest: &DigestConfig{Interval: interval}}).Validate(); err == nil {
+			t.Errorf("Validate() should reject interval %q", interval)
+		}
+	}
+	if err := (&Config{Digest: d}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestDigestQueue(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	digest := NewDigest()
+	now := time.Now()
+
+	if entries, err := digest.Take(); err != nil || len(entries) != 0 {
+		t.Fatalf("Take() on an empty queue = %v, %v", entries, err)
+	}
+	for i, event := range []string{"Stop", "Notification", "Stop"} {
+		first, err := digest.Add(DigestEntry{Time: now.Add(time.Duration(i) * time.Minute), Event: event})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != (i == 0) {
+			t.Errorf("Add() #%d first = %v", i, first)
+		}
+	}
+	entries, err := digest.Take()
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("Take() = %v, %v", entries, err)
+	}
+	if first, _ := digest.Add(DigestEntry{Time: now, Event: "Stop"}); !first {
+		t.Error("the first entry after Take should schedule a new summary")
+	}
+}
+
+func TestDigestSummary(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	entries := []DigestEntry{
+		{Time: now.Add(-10 * time.Minute), Event: "Stop"},
+		{Time: now.Add(-8 * time.Minute), Event: "Notification"},
+		{Time: now.Add(-5 * time.Minute), Event: "agent-turn-complete"},
+		{Time: now.Add(-4 * time.Minute), Event: "stop"},
+		{Time: now.Add(-1 * time.Minute), Event: "deploy"},
+	}
+	want := "In the last 10 minutes: 3 turns completed, 1 needs review, 1 deploy event."
+	if got := DigestSummary(entries, now); got != want {
+		t.Errorf("DigestSummary() = %q, want %q", got, want)
+	}
+	if got := DigestSummary(entries[1:2], now.Add(-7*time.Minute-30*time.Second)); got != "In the last minute: 1 needs review." {
+		t.Errorf("DigestSummary() = %q", got)
+	}
+	if DigestSummary(nil, now) != "" {
+		t.Error("DigestSummary() of nothing should be empty")
+	}
+}
+
+func TestDigestTakeOverdue(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	digest := NewDigest()
+	start := time.Now()
+
+	// The process scheduled by the first entry never runs; later hooks
+	// find the queue and flush it once it is overdue.
+	for i, event := range []string{"Stop", "Notification"} {
+		if _, err := digest.Add(DigestEntry{Time: start.Add(time.Duration(i) * time.Minute), Event: event}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entries, err := digest.TakeOverdue(10*time.Minute, start.Add(9*time.Minute)); err != nil || entries != nil {
+		t.Fatalf("TakeOverdue() before the interval = %v, %v", entries, err)
+	}
+	entries, err := digest.TakeOverdue(10*time.Minute, start.Add(10*time.Minute))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("TakeOverdue() after the interval = %v, %v", entries, err)
+	}
+	if entries, _ := digest.TakeOverdue(10*time.Minute, start.Add(time.Hour)); len(entries) != 0 {
+		t.Errorf("TakeOverdue() should have emptied the queue, got %v", entries)
+	}
+	if first, _ := digest.Add(DigestEntry{Time: start.Add(time.Hour), Event: "Stop"}); !first {
+		t.Error("the first entry after a flush should schedule a new summary")
+	}
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DND is a do-not-disturb schedule. While one of its windows is active,
+// hooks and runtime announcements stay silent and skip desktop
+// notifications; MQTT and screen reader output are unaffected.
+type DND struct {
+	Windows []DNDWindow `json:"windows"`
+}
+
+// DNDWindow is a daily time range in local time, such as 22:00-07:00. A
+// window that ends before it starts runs past midnight, and Days refer to
+// the day it starts. Days are mon..sun, "weekdays", or "weekends"; empty
+// means every day.
+type DNDWindow struct {
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+var dndDays = map[string][]time.Weekday{
+	"sun":      {time.Sunday},
+	"mon":      {time.Monday},
+	"tue":      {time.Tuesday},
+	"wed":      {time.Wednesday},
+	"thu":      {time.Thursday},
+	"fri":      {time.Friday},
+	"sat":      {time.Saturday},
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekends": {time.Saturday, time.Sunday},
+}
+
+// Active reports whether now falls in any window; safe on nil.
+func (d *DND) Active(now time.Time) bool {
+	if d == nil {
+		return false
+	}
+	for _, w := range d.Windows {
+		if w.active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w DNDWindow) active(now time.Time) bool {
+	start, err1 := parseClock(w.Start)
+	end, err2 := parseClock(w.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	today := now.Weekday()
+	switch {
+	case start < end:
+		return minute >= start && minute < end && w.onDay(today)
+	case start == end:
+		return w.onDay(today)
+	default:
+		yesterday := (today + 6) % 7
+		return (minute >= start && w.onDay(today)) || (minute < end && w.onDay(yesterday))
+	}
+}
+
+func (w DNDWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		for _, d := range dndDays[strings.ToLower(name)] {
+			if d == day {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseClock returns minutes since midnight for an "HH:MM" time.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (d *DND) validate() error {
+	if d == nil {
+		return nil
+	}
+	for i, w := range d.Windows {
+		for _, s := range []string{w.Start, w.End} {
+			if _, err := parseClock(s); err != nil {
+				return fmt.Errorf("notifications.dnd.windows[%d]: %w", i, err)
+			}
+		}
+		for _, name := range w.Days {
+			if _, ok := dndDays[strings.ToLower(name)]; !ok {
+				return fmt.Errorf("notifications.dnd.windows[%d]: unknown day %q (valid: mon..sun, weekdays, weekends)", i, name)
+			}
+		}
+	}
+	return nil
+}
+
+// DNDActive reports whether the do-not-disturb schedule is active at now;
+// safe on nil.
+func (c *Config) DNDActive(now time.Time) bool {
+	return c != nil && c.DND.Active(now)
+}
+
+// Quiet returns the route without the voice and desktop channels, which a
+// do-not-disturb schedule silences.
+func (r Route) Quiet() Route {
+	channels := make([]string, 0, len(r.Channels))
+	for _, ch := range r.Channels {
+		if ch != ChannelVoice && ch != ChannelDesktop {
+			channels = append(channels, ch)
+		}
+	}
+	r.Channels = channels
+	return r
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDNDActive(t *testing.T) {
+	dnd := &DND{Windows: []DNDWindow{
+		{Days: []string{"weekdays"}, Start: "22:00", End: "07:00"},
+		{Days: []string{"Sat"}, Start: "12:00", End: "13:00"},
+	}}
+	// 2026-10-16 is a Friday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"friday evening", at(16, 23, 30), true},
+		{"friday before start", at(16, 21, 59), false},
+		{"saturday after a friday night", at(17, 6, 59), true},
+		{"saturday at the end", at(17, 7, 0), false},
+		{"saturday lunch", at(17, 12, 30), true},
+		{"saturday night", at(17, 23, 0), false},
+		{"monday after a sunday night", at(19, 3, 0), false},
+	}
+	for _, tt := range tests {
+		if got := dnd.Active(tt.now); got != tt.want {
+			t.Errorf("%s: Active(%v) = %v, want %v", tt.name, tt.now, got, tt.want)
+		}
+	}
+
+	var nilDND *DND
+	if nilDND.Active(time.Now()) {
+		t.Error("nil schedule must not be active")
+	}
+	allDay := &DND{Windows: []DNDWindow{{Start: "00:00", End: "00:00"}}}
+	if !allDay.Active(time.Now()) {
+		t.Error("a window with equal start and end covers the whole day")
+	}
+}
+
+func TestDNDValidate(t *testing.T) {
+	for _, w := range []DNDWindow{
+		{Start: "25:00", End: "07:00"},
+		{Start: "22:00"},
+		{Days: []string{"someday"}, Start: "22:00", End: "07:00"},
+	} {
+		c := &Config{DND: &DND{Windows: []DNDWindow{w}}}
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", w)
+		}
+	}
+	c := &Config{DND: &DND{Windows: []DNDWindow{{Days: []string{"weekends", "mon"}, Start: "09:00", End: "17:30"}}}}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
+
+func TestRouteQuiet(t *testing.T) {
+	route := Route{Channels: []string{ChannelVoice, ChannelMQTT, ChannelDesktop, ChannelScreenReader}, Urgency: "critical"}
+	got := route.Quiet()
+	if !slices.Equal(got.Channels, []string{ChannelMQTT, ChannelScreenReader}) || got.Urgency != "critical" {
+		t.Errorf("Quiet() = %+v", got)
+	}
+	if len(route.Channels) != 4 {
+		t.Error("Quiet must not modify the original route")
+	}
+}
@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// HubEventsPath is where a team hub started with `ccpersona runtime serve`
+// accepts events.
+const HubEventsPath = "/v1/events"
+
+// hubTimeout bounds posting to the hub when the context has no deadline, so
+// an unreachable hub never stalls a hook for long.
+const hubTimeout = 5 * time.Second
+
+// HubConfig forwards notifications to a shared team hub, which fans them out
+// to the team's chat and to the user's own push channels.
+type HubConfig struct {
+	// URL is the hub's base URL, such as https://ccpersona.example.com.
+	URL string `json:"url"`
+	// Token is the API token the hub knows this user by; TokenEnv names an
+	// environment variable holding it instead, which keeps it out of config
+	// files.
+	Token    string `json:"token,omitempty"`
+	TokenEnv string `json:"token_env,omitempty"`
+	// Urgencies lists the urgencies forwarded even when no rule routes the
+	// notification to the hub channel (default: high and critical). An empty
+	// list forwards only what rules select.
+	Urgencies []string `json:"urgencies,omitzero"`
+	// Events lists hook event names (globs, "*" for all) that are forwarded
+	// as they arrive, independently of the notification rules.
+	Events []string `json:"events,omitempty"`
+}
+
+func (h *HubConfig) validate() error {
+	if h == nil {
+		return nil
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("notifications.hub.url must be an http:// or https:// URL")
+	}
+	if h.Token == "" && h.TokenEnv == "" {
+		return errors.New("notifications.hub: token or token_env is required")
+	}
+	for _, glob := range h.Events {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("notifications.hub.events: invalid pattern %q: %w", glob, err)
+		}
+	}
+	return nil
+}
+
+// APIToken returns the configured token.
+func (h *HubConfig) APIToken() string {
+	if h.TokenEnv != "" {
+		return os.Getenv(h.TokenEnv)
+	}
+	return h.Token
+}
+
+// Sends reports whether a notification with route is forwarded; safe on nil.
+func (h *HubConfig) Sends(route Route) bool {
+	if h == nil {
+		return false
+	}
+	if route.Has(ChannelHub) {
+		return true
+	}
+	urgencies := h.Urgencies
+	if urgencies == nil {
+		urgencies = []string{"high", "critical"}
+	}
+	for _, urgency := range urgencies {
+		if urgency == route.Urgency {
+			return true
+		}
+	}
+	return false
+}
+
+// ForwardsEvent reports whether hook events named name are forwarded as they
+// arrive; safe on nil.
+func (h *HubConfig) ForwardsEvent(name string) bool {
+	if h == nil {
+		return false
+	}
+	for _, glob := range h.Events {
+		if globMatch(glob, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// PostHub sends msg to the hub, in the same JSON shape as MQTT messages.
+func PostHub(ctx context.Context, h *HubConfig, msg MQTTMessage) error {
+	token := h.APIToken()
+	if token == "" {
+		return errors.New("notifications.hub: the API token is empty")
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hubTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(h.URL, "/")+HubEventsPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notifications.hub.url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post to hub: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("hub returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
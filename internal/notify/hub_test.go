@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHubSends(t *testing.T) {
+	var unset *HubConfig
+	if unset.Sends(Route{Channels: []string{ChannelHub}}) {
+		t.Error("nil config should never forward")
+	}
+	defaults := &HubConfig{}
+	if !defaults.Sends(Route{Urgency: "high"}) || !defaults.Sends(Route{Channels: []string{ChannelHub}, Urgency: "low"}) || defaults.Sends(Route{Urgency: "normal"}) {
+		t.Error("default config should forward high, critical, and routed notifications")
+	}
+	rulesOnly := &HubConfig{Urgencies: []string{}}
+	if rulesOnly.Sends(Route{Urgency: "critical"}) {
+		t.Error("an empty urgency list should forward routed notifications only")
+	}
+}
+
+func TestHubConfigValidate(t *testing.T) {
+	valid := &HubConfig{URL: "https://hub.example.com", TokenEnv: "CCPERSONA_HUB_TOKEN"}
+	if err := valid.validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+	for name, h := range map[string]*HubConfig{
+		"no url":   {Token: "t"},
+		"bad url":  {URL: "hub.example.com", Token: "t"},
+		"no token": {URL: "https://hub.example.com"},
+		"bad glob": {URL: "https://hub.example.com", Token: "t", Events: []string{"["}},
+	} {
+		if err := h.validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := (&Config{Rules: []Rule{{Channels: []string{ChannelHub}}}}).Validate(); err == nil {
+		t.Error("a hub rule without notifications.hub should fail")
+	}
+}
+
+func TestPostHub(t *testing.T) {
+	var got MQTTMessage
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != HubEventsPath {
+			http.NotFound(w, r)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	t.Setenv("HUB_TEST_TOKEN", "secret-token")
+	h := &HubConfig{URL: srv.URL + "/", TokenEnv: "HUB_TEST_TOKEN"}
+	err := PostHub(context.Background(), h, NewMQTTMessage(Event{Name: "Notification", Text: "needs permission"}, "critical"))
+	if err != nil {
+		t.Fatalf("PostHub: %v", err)
+	}
+	if auth != "Bearer secret-token" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if got.Event != "Notification" || got.Urgency != "critical" || got.Text != "needs permission" {
+		t.Errorf("posted %+v", got)
+	}
+
+	h.URL = srv.URL + "/elsewhere"
+	if err := PostHub(context.Background(), h, NewMQTTMessage(Event{Name: "Stop"}, "")); err == nil {
+		t.Error("expected an error for a non-2xx response")
+	}
+}
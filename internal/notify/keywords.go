@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// EventKeyword is the event name of keyword alerts, as matched by digest
+// events and sent to MQTT and the hub.
+const EventKeyword = "keyword"
+
+// KeywordConfig watches assistant messages for risky phrases such as
+// "DROP TABLE" or "force push". Only the message text is scanned; tool
+// calls and tool output are not. A match is alerted at critical urgency,
+// bypassing do-not-disturb and the digest, as a safety net while agents run
+// unattended.
+type KeywordConfig struct {
+	// Words are phrases matched case-insensitively anywhere in the message.
+	Words []string `json:"words,omitempty"`
+	// Patterns are regular expressions, for phrases with variants such as
+	// `git push .*(-f|--force)`.
+	Patterns []string `json:"patterns,omitempty"`
+	// Channels receive the alert (default voice and desktop).
+	Channels []string `json:"channels,omitempty"`
+	// Sound starts playing as the alert is sent, unless voice is muted.
+	Sound string `json:"sound,omitempty"`
+}
+
+// DefaultKeywordChannels apply when Channels is not set.
+var DefaultKeywordChannels = []string{ChannelVoice, ChannelDesktop}
+
+// IsEnabled reports whether any keyword is watched; safe on nil.
+func (k *KeywordConfig) IsEnabled() bool {
+	return k != nil && (len(k.Words) > 0 || len(k.Patterns) > 0)
+}
+
+// Match returns the watched words and patterns found in message, in the
+// configured order.
+func (k *KeywordConfig) Match(message string) []string {
+	if !k.IsEnabled() {
+		return nil
+	}
+	lower := strings.ToLower(message)
+	var found []string
+	for _, word := range k.Words {
+		if word != "" && strings.Contains(lower, strings.ToLower(word)) {
+			found = append(found, word)
+		}
+	}
+	for _, pattern := range k.Patterns {
+		if re, err := regexp.Compile(pattern); err == nil && re.MatchString(message) {
+			found = append(found, pattern)
+		}
+	}
+	return found
+}
+
+// Route returns the critical route keyword alerts are delivered on.
+func (k *KeywordConfig) Route() Route {
+	channels := DefaultKeywordChannels
+	if len(k.Channels) > 0 {
+		channels = k.Channels
+	}
+	return Route{Channels: channels, Urgency: "critical", Rule: -1}
+}
+
+// SoundPath returns Sound with a leading ~ expanded.
+func (k *KeywordConfig) SoundPath() string {
+	return expandHome(k.Sound)
+}
+
+// KeywordAlert is the text of the alert for the found keywords.
+func KeywordAlert(found []string) string {
+	return "Keyword alert: the agent wrote " + strings.Join(found, ", ")
+}
+
+func (k *KeywordConfig) validate(c *Config) error {
+	if k == nil {
+		return nil
+	}
+	for i, word := range k.Words {
+		if strings.TrimSpace(word) == "" {
+			return fmt.Errorf("notifications.keywords.words[%d] is empty", i)
+		}
+	}
+	for i, pattern := range k.Patterns {
+		if pattern == "" {
+			return fmt.Errorf("notifications.keywords.patterns[%d] is empty", i)
+		}
+		if err := validatePattern(pattern); err != nil {
+			return fmt.Errorf("notifications.keywords.patterns[%d]: invalid pattern: %w", i, err)
+		}
+	}
+	for _, ch := range k.Channels {
+		if err := c.checkChannel(ch); err != nil {
+			return fmt.Errorf("notifications.keywords: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeywordMatch(t *testing.T) {
+	k := &KeywordConfig{
+		Words:    []string{"DROP TABLE", "rm -rf"},
+		Patterns: []string{`git push .*(-f|--force)`},
+	}
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{"I ran `drop table users;` to reset the schema.", []string{"DROP TABLE"}},
+		{"Cleaned up with rm -rf build/ and git push origin main --force.", []string{"rm -rf", `git push .*(-f|--force)`}},
+		{"All tests passed.", nil},
+	}
+	for _, tt := range tests {
+		if got := k.Match(tt.message); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Match(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+
+	var none *KeywordConfig
+	if none.IsEnabled() || none.Match("DROP TABLE") != nil {
+		t.Error("nil keyword config matched")
+	}
+}
+
+func TestKeywordRoute(t *testing.T) {
+	route := (&KeywordConfig{Words: []string{"x"}}).Route()
+	if !reflect.DeepEqual(route.Channels, DefaultKeywordChannels) || route.Urgency != "critical" {
+		t.Fatalf("default route = %+v", route)
+	}
+	route = (&KeywordConfig{Words: []string{"x"}, Channels: []string{ChannelTelegram}}).Route()
+	if !reflect.DeepEqual(route.Channels, []string{ChannelTelegram}) {
+		t.Fatalf("route = %+v", route)
+	}
+}
+
+func TestValidateKeywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		keywords KeywordConfig
+		wantErr  string
+	}{
+		{"valid", KeywordConfig{Words: []string{"force push"}, Patterns: []string{`rm\s+-rf`}, Channels: []string{ChannelDesktop}}, ""},
+		{"empty word", KeywordConfig{Words: []string{" "}}, "words[0] is empty"},
+		{"bad pattern", KeywordConfig{Patterns: []string{"("}}, "invalid pattern"},
+		{"unknown channel", KeywordConfig{Words: []string{"x"}, Channels: []string{"pager"}}, "unknown channel"},
+		{"unconfigured channel", KeywordConfig{Words: []string{"x"}, Channels: []string{ChannelMQTT}}, "needs notifications.mqtt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keywords := tt.keywords
+			err := (&Config{Keywords: &keywords}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
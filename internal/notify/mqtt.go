@@ -0,0 +1,421 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultMQTTTopic is the topic template used when none is configured.
+const DefaultMQTTTopic = "ccpersona/{source}/{event}"
+
+// mqttTimeout bounds connecting and publishing when the context has no
+// deadline, so an unreachable broker never stalls a hook for long.
+const mqttTimeout = 5 * time.Second
+
+// mqttKeepAlive is the keep-alive announced to the broker. Connections last
+// one publish, so it only has to outlive that.
+const mqttKeepAlive = 30
+
+// MQTTConfig publishes notifications and hook events to an MQTT broker, for
+// home-automation setups such as flashing a light on permission requests.
+type MQTTConfig struct {
+	// Broker is the broker URL: mqtt:// or tcp:// (default port 1883), or
+	// mqtts://, ssl://, or tls:// (default port 8883).
+	Broker string `json:"broker"`
+	// Topic is the topic template. {event}, {source}, {session}, {project},
+	// and {urgency} are replaced by the message's values (default
+	// "ccpersona/{source}/{event}").
+	Topic string `json:"topic,omitempty"`
+	// Events lists hook event names (globs, "*" for all) that are published
+	// as they arrive, independently of the notification rules.
+	Events   []string `json:"events,omitempty"`
+	ClientID string   `json:"client_id,omitempty"`
+	Username string   `json:"username,omitempty"`
+	// Password is the broker password; PasswordEnv names an environment
+	// variable holding it instead, which keeps it out of config files.
+	Password    string `json:"password,omitempty"`
+	PasswordEnv string `json:"password_env,omitempty"`
+	// QoS is 0 (default, fire and forget) or 1 (wait for the broker's ack).
+	QoS    int  `json:"qos,omitempty"`
+	Retain bool `json:"retain,omitempty"`
+	// CAFile, CertFile, and KeyFile configure TLS: a CA bundle for brokers
+	// with a private CA, and a client certificate for mutual TLS.
+	CAFile             string `json:"ca_file,omitempty"`
+	CertFile           string `json:"cert_file,omitempty"`
+	KeyFile            string `json:"key_file,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// MQTTMessage is the JSON payload of a published message.
+type MQTTMessage struct {
+	Event     string `json:"event"`
+	Text      string `json:"text,omitempty"`
+	Urgency   string `json:"urgency,omitempty"`
+	Source    string `json:"source,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Project   string `json:"project,omitempty"`
+	Tool      string `json:"tool,omitempty"`
+	Model     string `json:"model,omitempty"`
+	Subagent  bool   `json:"subagent,omitempty"`
+	Time      string `json:"time"`
+}
+
+// NewMQTTMessage builds the payload for an event.
+func NewMQTTMessage(event Event, urgency string) MQTTMessage {
+	return MQTTMessage{
+		Event:     event.Name,
+		Text:      event.Text,
+		Urgency:   urgency,
+		Source:    event.Source,
+		SessionID: event.SessionID,
+		Project:   event.Project,
+		Tool:      event.Tool,
+		Model:     event.Model,
+		Subagent:  event.Subagent,
+		Time:      time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func (m *MQTTConfig) validate() error {
+	if m == nil {
+		return nil
+	}
+	if _, _, err := m.address(); err != nil {
+		return fmt.Errorf("notifications.mqtt.broker: %w", err)
+	}
+	if m.QoS < 0 || m.QoS > 1 {
+		return fmt.Errorf("notifications.mqtt.qos must be 0 or 1")
+	}
+	if (m.CertFile == "") != (m.KeyFile == "") {
+		return fmt.Errorf("notifications.mqtt: cert_file and key_file must be set together")
+	}
+	for _, glob := range m.Events {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("notifications.mqtt.events: invalid pattern %q: %w", glob, err)
+		}
+	}
+	if strings.ContainsAny(m.Topic, "+#") {
+		return fmt.Errorf("notifications.mqtt.topic must not contain wildcards")
+	}
+	return nil
+}
+
+// ForwardsEvent reports whether hook events named name are published as they
+// arrive; safe on nil.
+func (m *MQTTConfig) ForwardsEvent(name string) bool {
+	if m == nil {
+		return false
+	}
+	for _, glob := range m.Events {
+		if globMatch(glob, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// TopicFor expands the topic template for msg. Values are reduced to one
+// topic level: slashes, wildcards, and control characters become '_'.
+func (m *MQTTConfig) TopicFor(msg MQTTMessage) string {
+	template := m.Topic
+	if template == "" {
+		template = DefaultMQTTTopic
+	}
+	project := ""
+	if msg.Project != "" {
+		project = filepath.Base(msg.Project)
+	}
+	return strings.NewReplacer(
+		"{event}", topicLevel(msg.Event),
+		"{source}", topicLevel(msg.Source),
+		"{session}", topicLevel(msg.SessionID),
+		"{project}", topicLevel(project),
+		"{urgency}", topicLevel(msg.Urgency),
+	).Replace(template)
+}
+
+func topicLevel(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '+' || r == '#' || r < 0x20 || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+// address returns the broker's host:port and whether it uses TLS.
+func (m *MQTTConfig) address() (string, bool, error) {
+	if m.Broker == "" {
+		return "", false, errors.New("broker is required")
+	}
+	u, err := url.Parse(m.Broker)
+	if err != nil {
+		return "", false, err
+	}
+	var useTLS bool
+	port := "1883"
+	switch u.Scheme {
+	case "mqtt", "tcp":
+	case "mqtts", "ssl", "tls":
+		useTLS, port = true, "8883"
+	default:
+		return "", false, fmt.Errorf("unsupported scheme %q (use mqtt:// or mqtts://)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", false, errors.New("broker host is missing")
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	return net.JoinHostPort(u.Hostname(), port), useTLS, nil
+}
+
+func (m *MQTTConfig) password() string {
+	if m.PasswordEnv != "" {
+		return os.Getenv(m.PasswordEnv)
+	}
+	return m.Password
+}
+
+func (m *MQTTConfig) tlsConfig(host string) (*tls.Config, error) {
+	config := &tls.Config{ServerName: host, InsecureSkipVerify: m.InsecureSkipVerify}
+	if m.CAFile != "" {
+		pem, err := os.ReadFile(expandHome(m.CAFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", m.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if m.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(expandHome(m.CertFile), expandHome(m.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// PublishMQTT publishes msg as JSON to the topic the template yields. Each
+// call opens its own connection: hooks are short-lived processes, so there is
+// no session to keep.
+func PublishMQTT(ctx context.Context, m *MQTTConfig, msg MQTTMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return m.publish(ctx, m.TopicFor(msg), payload)
+}
+
+func (m *MQTTConfig) publish(ctx context.Context, topic string, payload []byte) error {
+	addr, useTLS, err := m.address()
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, mqttTimeout)
+		defer cancel()
+	}
+
+	var conn net.Conn
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		config, err := m.tlsConfig(host)
+		if err != nil {
+			return err
+		}
+		conn, err = (&tls.Dialer{Config: config}).DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to connect to MQTT broker: %w", err)
+		}
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to connect to MQTT broker: %w", err)
+		}
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	r := bufio.NewReader(conn)
+	if _, err := conn.Write(m.connectPacket()); err != nil {
+		return fmt.Errorf("failed to send MQTT connect: %w", err)
+	}
+	if err := readConnack(r); err != nil {
+		return err
+	}
+
+	const packetID = 1
+	if _, err := conn.Write(publishPacket(topic, payload, m.QoS, m.Retain, packetID)); err != nil {
+		return fmt.Errorf("failed to publish MQTT message: %w", err)
+	}
+	if m.QoS == 1 {
+		if err := readPuback(r, packetID); err != nil {
+			return err
+		}
+	}
+	_, _ = conn.Write([]byte{0xE0, 0x00}) // DISCONNECT
+	return nil
+}
+
+// MQTT 3.1.1 control packet types, in the high nibble of the first byte.
+const (
+	mqttConnect = 1
+	mqttConnack = 2
+	mqttPublish = 3
+	mqttPuback  = 4
+)
+
+func (m *MQTTConfig) connectPacket() []byte {
+	clientID := m.ClientID
+	if clientID == "" {
+		suffix := make([]byte, 4)
+		_, _ = rand.Read(suffix)
+		clientID = "ccpersona-" + hex.EncodeToString(suffix)
+	}
+	flags := byte(0x02) // clean session
+	var payload []byte
+	payload = appendString(payload, clientID)
+	if m.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, m.Username)
+		if password := m.password(); password != "" {
+			flags |= 0x40
+			payload = appendString(payload, password)
+		}
+	}
+
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, 4, flags) // protocol level 4 (3.1.1)
+	body = binary.BigEndian.AppendUint16(body, mqttKeepAlive)
+	body = append(body, payload...)
+	return packet(mqttConnect<<4, body)
+}
+
+func publishPacket(topic string, payload []byte, qos int, retain bool, packetID uint16) []byte {
+	header := byte(mqttPublish<<4) | byte(qos<<1)
+	if retain {
+		header |= 0x01
+	}
+	body := appendString(nil, topic)
+	if qos > 0 {
+		body = binary.BigEndian.AppendUint16(body, packetID)
+	}
+	return packet(header, append(body, payload...))
+}
+
+// packet prefixes body with the fixed header: the type byte and the
+// variable-length remaining length.
+func packet(header byte, body []byte) []byte {
+	out := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// readPacket reads one control packet and returns its type and body.
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed MQTT remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+func readConnack(r *bufio.Reader) error {
+	kind, body, err := readPacket(r)
+	if err != nil {
+		return fmt.Errorf("failed to read MQTT connack: %w", err)
+	}
+	if kind != mqttConnack || len(body) != 2 {
+		return fmt.Errorf("unexpected MQTT packet type %d instead of connack", kind)
+	}
+	if code := body[1]; code != 0 {
+		reason, ok := connackErrors[code]
+		if !ok {
+			reason = fmt.Sprintf("code %d", code)
+		}
+		return fmt.Errorf("MQTT broker refused the connection: %s", reason)
+	}
+	return nil
+}
+
+func readPuback(r *bufio.Reader, packetID uint16) error {
+	kind, body, err := readPacket(r)
+	if err != nil {
+		return fmt.Errorf("failed to read MQTT puback: %w", err)
+	}
+	if kind != mqttPuback || len(body) != 2 || binary.BigEndian.Uint16(body) != packetID {
+		return fmt.Errorf("unexpected MQTT packet type %d instead of puback", kind)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeBroker accepts one connection, checks CONNECT, and records the
+// PUBLISH that follows.
+type fakeBroker struct {
+	addr    string
+	connect chan []byte
+	publish chan []byte
+	header  chan byte
+}
+
+func startFakeBroker(t *testing.T, returnCode byte) *fakeBroker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	b := &fakeBroker{
+		addr:    ln.Addr().String(),
+		connect: make(chan []byte, 1),
+		publish: make(chan []byte, 1),
+		header:  make(chan byte, 1),
+	}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		kind, body, err := readPacket(r)
+		if err != nil || kind != mqttConnect {
+			return
+		}
+		b.connect <- body
+		_, _ = conn.Write([]byte{mqttConnack << 4, 2, 0, returnCode})
+		if returnCode != 0 {
+			return
+		}
+		header, err := r.Peek(1)
+		if err != nil {
+			return
+		}
+		b.header <- header[0]
+		kind, body, err = readPacket(r)
+		if err != nil || kind != mqttPublish {
+			return
+		}
+		b.publish <- body
+		if header[0]&0x06 != 0 {
+			topicLen := int(binary.BigEndian.Uint16(body))
+			id := body[2+topicLen : 4+topicLen]
+			_, _ = conn.Write([]byte{mqttPuback << 4, 2, id[0], id[1]})
+		}
+	}()
+	return b
+}
+
+func TestPublishMQTT(t *testing.T) {
+	broker := startFakeBroker(t, 0)
+	cfg := &MQTTConfig{
+		Broker:   "mqtt://" + broker.addr,
+		Topic:    "home/{project}/{event}",
+		Username: "user",
+		Password: "secret",
+		ClientID: "test-client",
+		QoS:      1,
+	}
+	msg := NewMQTTMessage(Event{Name: "Notification", Text: "Claude needs your permission", Project: "/work/my/app", Source: "claude-code"}, "critical")
+	if err := PublishMQTT(context.Background(), cfg, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	connect := <-broker.connect
+	if !strings.Contains(string(connect), "MQTT") || connect[7]&0xC2 != 0xC2 {
+		t.Errorf("connect flags = %08b", connect[7])
+	}
+	for _, want := range []string{"test-client", "user", "secret"} {
+		if !strings.Contains(string(connect), want) {
+			t.Errorf("connect payload lacks %q", want)
+		}
+	}
+
+	if header := <-broker.header; header != mqttPublish<<4|0x02 {
+		t.Errorf("publish header = %#x", header)
+	}
+	body := <-broker.publish
+	topicLen := int(binary.BigEndian.Uint16(body))
+	if topic := string(body[2 : 2+topicLen]); topic != "home/app/Notification" {
+		t.Errorf("topic = %q", topic)
+	}
+	var got MQTTMessage
+	if err := json.Unmarshal(body[4+topicLen:], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Event != "Notification" || got.Urgency != "critical" || got.Text != "Claude needs your permission" || got.Source != "claude-code" {
+		t.Errorf("payload = %+v", got)
+	}
+}
+
+func TestPublishMQTTRefused(t *testing.T) {
+	broker := startFakeBroker(t, 4)
+	err := PublishMQTT(context.Background(), &MQTTConfig{Broker: "tcp://" + broker.addr}, MQTTMessage{Event: "Stop"})
+	if err == nil || !strings.Contains(err.Error(), "bad user name or password") {
+		t.Errorf("PublishMQTT error = %v", err)
+	}
+}
+
+func TestMQTTTopicFor(t *testing.T) {
+	cfg := &MQTTConfig{}
+	if got := cfg.TopicFor(MQTTMessage{Event: "Stop", Source: "claude-code"}); got != "ccpersona/claude-code/Stop" {
+		t.Errorf("default topic = %q", got)
+	}
+	cfg.Topic = "a/{session}/{urgency}/{event}"
+	if got := cfg.TopicFor(MQTTMessage{Event: "x/+#", SessionID: "s1"}); got != "a/s1/unknown/x___" {
+		t.Errorf("sanitized topic = %q", got)
+	}
+}
+
+func TestMQTTForwardsEvent(t *testing.T) {
+	var none *MQTTConfig
+	if none.ForwardsEvent("Stop") {
+		t.Error("nil config forwards events")
+	}
+	cfg := &MQTTConfig{Events: []string{"Notification", "Pre*"}}
+	for name, want := range map[string]bool{"notification": true, "PreToolUse": true, "Stop": false} {
+		if got := cfg.ForwardsEvent(name); got != want {
+			t.Errorf("ForwardsEvent(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestMQTTPacketLength(t *testing.T) {
+	p := packet(0x30, make([]byte, 321))
+	if p[1] != 0xC1 || p[2] != 0x02 || len(p) != 324 {
+		t.Errorf("remaining length bytes = %x %x, len %d", p[1], p[2], len(p))
+	}
+}
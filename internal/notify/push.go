@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PushConfig sends Web Push notifications to the browsers subscribed with
+// `ccpersona runtime push serve`, so an agent on a headless server can still
+// reach the user's phone or laptop.
+type PushConfig struct {
+	// Subject is the VAPID contact push services use to reach the sender, a
+	// mailto: or https: URL.
+	Subject string `json:"subject,omitempty"`
+	// Urgencies lists the urgencies pushed even when no rule routes the
+	// notification to the push channel (default: critical). An empty list
+	// pushes only what rules select; omitzero keeps it empty when the config
+	// is rewritten.
+	Urgencies []string `json:"urgencies,omitzero"`
+	// TTL is how long, in seconds, a push service keeps a message for a
+	// browser that is offline (default 3600).
+	TTL int `json:"ttl,omitempty"`
+}
+
+// Sends reports whether a notification with route is pushed; safe on nil.
+func (p *PushConfig) Sends(route Route) bool {
+	if p == nil {
+		return false
+	}
+	if route.Has(ChannelPush) {
+		return true
+	}
+	urgencies := p.Urgencies
+	if urgencies == nil {
+		urgencies = []string{"critical"}
+	}
+	for _, urgency := range urgencies {
+		if urgency == route.Urgency {
+			return true
+		}
+	}
+	return false
+}
+
+// TTLDuration returns TTL as a duration; zero means the default.
+func (p *PushConfig) TTLDuration() time.Duration {
+	return time.Duration(p.TTL) * time.Second
+}
+
+func (p *PushConfig) validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Subject != "" && !strings.HasPrefix(p.Subject, "mailto:") && !strings.HasPrefix(p.Subject, "https://") {
+		return fmt.Errorf("notifications.push.subject must be a mailto: or https:// URL")
+	}
+	if p.TTL < 0 {
+		return fmt.Errorf("notifications.push.ttl must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPushSends(t *testing.T) {
+	critical := Route{Channels: []string{ChannelVoice}, Urgency: "critical"}
+	routed := Route{Channels: []string{ChannelPush}, Urgency: "low"}
+	normal := Route{Channels: []string{ChannelVoice}, Urgency: "normal"}
+
+	var unset *PushConfig
+	if unset.Sends(routed) {
+		t.Error("nil config should never push")
+	}
+	defaults := &PushConfig{}
+	if !defaults.Sends(critical) || !defaults.Sends(routed) || defaults.Sends(normal) {
+		t.Error("default config should push critical and routed notifications only")
+	}
+	rulesOnly := &PushConfig{Urgencies: []string{}}
+	if rulesOnly.Sends(critical) || !rulesOnly.Sends(routed) {
+		t.Error("an empty urgency list should push routed notifications only")
+	}
+	high := &PushConfig{Urgencies: []string{"high", "critical"}}
+	if !high.Sends(Route{Urgency: "high"}) {
+		t.Error("listed urgencies should be pushed")
+	}
+}
+
+func TestPushConfigKeepsEmptyUrgencies(t *testing.T) {
+	data, err := json.Marshal(&PushConfig{Urgencies: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back PushConfig
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Urgencies == nil {
+		t.Errorf("%s lost the empty urgency list", data)
+	}
+}
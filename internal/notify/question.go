@@ -0,0 +1,214 @@
+package notify
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// QuestionConfig escalates assistant messages that ask the user something:
+// an earcon, a sticky desktop notification, and optional reminders while the
+// question stays unanswered.
+type QuestionConfig struct {
+	Enabled bool `json:"enabled"`
+	// Sound is played before the message is read (the "question earcon").
+	Sound string `json:"sound,omitempty"`
+	// Sticky keeps the desktop notification until dismissed (default true).
+	Sticky *bool `json:"sticky,omitempty"`
+	// RepeatMinutes re-alerts after this many minutes without an answer
+	// (0 disables reminders).
+	RepeatMinutes int `json:"repeat_minutes,omitempty"`
+	// MaxRepeats caps the reminders per question (default 3).
+	MaxRepeats int `json:"max_repeats,omitempty"`
+}
+
+// DefaultMaxRepeats applies when MaxRepeats is not set.
+const DefaultMaxRepeats = 3
+
+// IsEnabled reports whether question escalation is on; safe on nil.
+func (q *QuestionConfig) IsEnabled() bool {
+	return q != nil && q.Enabled
+}
+
+// IsSticky reports whether the desktop notification should persist.
+func (q *QuestionConfig) IsSticky() bool {
+	return q.Sticky == nil || *q.Sticky
+}
+
+// SoundPath returns Sound with a leading ~ expanded.
+func (q *QuestionConfig) SoundPath() string {
+	return expandHome(q.Sound)
+}
+
+// EffectiveMaxRepeats returns MaxRepeats or its default.
+func (q *QuestionConfig) EffectiveMaxRepeats() int {
+	if q.MaxRepeats > 0 {
+		return q.MaxRepeats
+	}
+	return DefaultMaxRepeats
+}
+
+func (q *QuestionConfig) validate() error {
+	if q == nil {
+		return nil
+	}
+	if q.RepeatMinutes < 0 {
+		return fmt.Errorf("notifications.questions.repeat_minutes must not be negative")
+	}
+	if q.MaxRepeats < 0 {
+		return fmt.Errorf("notifications.questions.max_repeats must not be negative")
+	}
+	return nil
+}
+
+// inputRequests are phrases that ask for the user's input even without a
+// question mark. They are matched case-insensitively in the final line.
+var inputRequests = []string{
+	"let me know",
+	"please confirm",
+	"please choose",
+	"please review",
+	"should i ",
+	"would you like",
+	"do you want",
+	"waiting for your",
+	"need your input",
+	"needs your input",
+	"教えてください",
+	"確認してください",
+	"選んでください",
+	"指示してください",
+	"ご確認ください",
+	"どうしますか",
+	"よろしいですか",
+}
+
+// questionEndings are Japanese sentence endings that form a question even
+// when written with 。 instead of ？.
+var questionEndings = []string{"ですか", "ますか", "でしょうか", "ませんか"}
+
+// DetectQuestion reports whether message ends by asking the user something,
+// and returns the final line that does so. Only the last non-empty line is
+// considered: a question in the middle of a report is usually rhetorical.
+func DetectQuestion(message string) (string, bool) {
+	line := lastLine(message)
+	if line == "" {
+		return "", false
+	}
+	trimmed := strings.TrimRight(line, " \t*_`\"'）)」』")
+	if strings.HasSuffix(trimmed, "?") || strings.HasSuffix(trimmed, "？") {
+		return line, true
+	}
+	bare := strings.TrimRight(trimmed, "。.！!")
+	for _, ending := range questionEndings {
+		if strings.HasSuffix(bare, ending) {
+			return line, true
+		}
+	}
+	lower := strings.ToLower(line)
+	for _, phrase := range inputRequests {
+		if strings.Contains(lower, phrase) {
+			return line, true
+		}
+	}
+	return "", false
+}
+
+func lastLine(message string) string {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+const questionDir = "ccpersona-notify"
+
+// PendingQuestion is an unanswered question recorded for a session.
+type PendingQuestion struct {
+	SessionID string    `json:"session_id"`
+	Question  string    `json:"question"`
+	AskedAt   time.Time `json:"asked_at"`
+}
+
+// Token identifies this question so a reminder can tell it apart from a
+// later question in the same session.
+func (p *PendingQuestion) Token() string {
+	return fmt.Sprintf("%d", p.AskedAt.UnixNano())
+}
+
+// QuestionTracker records the unanswered question per session. State lives in
+// the OS temp directory, like the voice dedup markers.
+type QuestionTracker struct {
+	sessionID string
+	dir       string
+}
+
+// NewQuestionTracker creates a tracker for the given session.
+func NewQuestionTracker(sessionID string) *QuestionTracker {
+	return &QuestionTracker{
+		sessionID: sessionID,
+		dir:       filepath.Join(os.TempDir(), questionDir),
+	}
+}
+
+// Mark records question as pending and returns the stored entry.
+func (qt *QuestionTracker) Mark(question string) (*PendingQuestion, error) {
+	if err := os.MkdirAll(qt.dir, 0700); err != nil {
+		return nil, fmt.Errorf("create question directory: %w", err)
+	}
+	pending := &PendingQuestion{
+		SessionID: qt.sessionID,
+		Question:  truncateRunes(question, 300),
+		AskedAt:   time.Now().UTC(),
+	}
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return nil, err
+	}
+	if err := fsutil.WriteFile(qt.path(), data, 0600); err != nil {
+		return nil, fmt.Errorf("write pending question: %w", err)
+	}
+	return pending, nil
+}
+
+// Pending returns the unanswered question, or nil when there is none.
+func (qt *QuestionTracker) Pending() *PendingQuestion {
+	data, err := os.ReadFile(qt.path())
+	if err != nil {
+		return nil
+	}
+	var pending PendingQuestion
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil
+	}
+	return &pending
+}
+
+// Clear marks the session's question as answered.
+func (qt *QuestionTracker) Clear() {
+	_ = os.Remove(qt.path())
+}
+
+// path hashes the session ID, which comes from untrusted hook JSON, so it can
+// never escape the directory.
+func (qt *QuestionTracker) path() string {
+	sum := sha256.Sum256([]byte(qt.sessionID))
+	return filepath.Join(qt.dir, fmt.Sprintf("%x.question", sum[:16]))
+}
+
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max]) + "…"
+}
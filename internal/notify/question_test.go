@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectQuestion(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    bool
+	}{
+		{"question mark", "I updated the parser.\n\nShould this also cover YAML?", true},
+		{"fullwidth question mark", "実装しました。\nこの方針で進めてよいですか？", true},
+		{"markdown wrapped", "Done.\n**Which option do you prefer?**", true},
+		{"japanese ending without mark", "修正案を用意しました。適用してもよろしいですか。", true},
+		{"input request phrase", "Here are two approaches.\nLet me know which one to use.", true},
+		{"japanese request", "差分を確認してください。", true},
+		{"statement", "All tests pass.\nThe change is ready.", false},
+		{"question in the middle", "Why did it fail? The cache was stale.\nFixed it.", false},
+		{"empty", "  \n ", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			question, got := DetectQuestion(tt.message)
+			if got != tt.want {
+				t.Fatalf("DetectQuestion() = %v, want %v", got, tt.want)
+			}
+			if got && !strings.Contains(tt.message, question) {
+				t.Fatalf("question %q not taken from the message", question)
+			}
+		})
+	}
+}
+
+func TestQuestionTracker(t *testing.T) {
+	dir := t.TempDir()
+	qt := NewQuestionTracker("session-1")
+	qt.dir = dir
+
+	if qt.Pending() != nil {
+		t.Fatal("no question should be pending initially")
+	}
+	first, err := qt.Mark("Proceed?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := qt.Pending()
+	if pending == nil || pending.Question != "Proceed?" || pending.Token() != first.Token() {
+		t.Fatalf("Pending() = %+v", pending)
+	}
+
+	other := NewQuestionTracker("session-2")
+	other.dir = dir
+	if other.Pending() != nil {
+		t.Fatal("questions are per session")
+	}
+
+	qt.Clear()
+	if qt.Pending() != nil {
+		t.Fatal("Clear() should remove the pending question")
+	}
+}
+
+func TestQuestionTrackerPathStaysInDir(t *testing.T) {
+	dir := t.TempDir()
+	qt := NewQuestionTracker("../../etc/passwd")
+	qt.dir = dir
+	if _, err := qt.Mark("x?"); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || filepath.Dir(qt.path()) != dir {
+		t.Fatalf("question file escaped the directory: %s", qt.path())
+	}
+}
+
+func TestQuestionConfigDefaults(t *testing.T) {
+	var nilCfg *QuestionConfig
+	if nilCfg.IsEnabled() {
+		t.Fatal("nil config is disabled")
+	}
+	q := &QuestionConfig{Enabled: true}
+	if !q.IsSticky() || q.EffectiveMaxRepeats() != DefaultMaxRepeats {
+		t.Fatalf("defaults: sticky=%v max=%d", q.IsSticky(), q.EffectiveMaxRepeats())
+	}
+	if err := (&Config{Questions: &QuestionConfig{RepeatMinutes: -1}}).Validate(); err == nil {
+		t.Fatal("negative repeat_minutes should be rejected")
+	}
+}
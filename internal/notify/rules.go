@@ -0,0 +1,290 @@
+// Package notify routes notification text to output channels according to
+// user-configured rules, and runs actions triggered by assistant messages.
+package notify
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Output channel names accepted in rules.
+const (
+	ChannelVoice        = "voice"
+	ChannelDesktop      = "desktop"
+	ChannelScreenReader = "screen_reader"
+	ChannelMQTT         = "mqtt"
+	ChannelPush         = "push"
+	ChannelTelegram     = "telegram"
+	ChannelHub          = "hub"
+)
+
+// Channels lists every known channel in a stable order.
+var Channels = []string{ChannelVoice, ChannelDesktop, ChannelScreenReader, ChannelMQTT, ChannelPush, ChannelTelegram, ChannelHub}
+
+// Rule selects channels (and optionally an urgency) for matching
+// notifications. Empty match fields match everything.
+type Rule struct {
+	// Event is the notification origin: a hook event name such as
+	// "Notification", or "exec" / "ci" for runtime commands. "*" matches all.
+	Event string `json:"event,omitempty"`
+	// Contains matches a case-insensitive substring of the text.
+	Contains string `json:"contains,omitempty"`
+	// Pattern matches a regular expression against the text.
+	Pattern string `json:"pattern,omitempty"`
+	// Tool and Model are case-insensitive glob patterns (path.Match syntax)
+	// for the tool and model of the event; an event without one never
+	// matches a rule that sets it.
+	Tool  string `json:"tool,omitempty"`
+	Model string `json:"model,omitempty"`
+	// Subagent, when set, matches only subagent (true) or main agent
+	// (false) events.
+	Subagent *bool    `json:"subagent,omitempty"`
+	Channels []string `json:"channels"`
+	Urgency  string   `json:"urgency,omitempty"`
+	// Voice speaks matching notifications with another voice: a voice
+	// alias, a provider:voice reference, or a voice ID of the provider.
+	Voice string `json:"voice,omitempty"`
+}
+
+// Event is a notification as seen by the rules. Name and Text are always
+// set; the metadata is filled where the hook source provides it.
+type Event struct {
+	// Name is the notification origin, as matched by Rule.Event.
+	Name     string
+	Text     string
+	Tool     string
+	Model    string
+	Subagent bool
+	// Source, SessionID, and Project identify where the event came from.
+	// Rules do not match on them; they are passed on to the MQTT channel.
+	Source    string
+	SessionID string
+	Project   string
+}
+
+// Config holds the notification rules and message triggers. The first
+// matching rule wins; every matching trigger fires.
+type Config struct {
+	Rules     []Rule          `json:"rules,omitempty"`
+	Triggers  []Trigger       `json:"triggers,omitempty"`
+	Questions *QuestionConfig `json:"questions,omitempty"`
+	Keywords  *KeywordConfig  `json:"keywords,omitempty"`
+	MQTT      *MQTTConfig     `json:"mqtt,omitempty"`
+	DND       *DND            `json:"dnd,omitempty"`
+	Digest    *DigestConfig   `json:"digest,omitempty"`
+	Push      *PushConfig     `json:"push,omitempty"`
+	Telegram  *TelegramConfig `json:"telegram,omitempty"`
+	Hub       *HubConfig      `json:"hub,omitempty"`
+}
+
+// Route is the routing decision for a single notification.
+type Route struct {
+	Channels []string
+	Urgency  string
+	// Voice is the matching rule's voice, or "" for the persona's.
+	Voice string
+	// Rule is the index of the matching rule, or -1 when defaults were used.
+	Rule int
+}
+
+// Has reports whether the route includes channel.
+func (r Route) Has(channel string) bool {
+	for _, ch := range r.Channels {
+		if ch == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks channel names, patterns, and trigger actions.
+func (c *Config) Validate() error {
+	if c == nil {
+		return nil
+	}
+	for i, rule := range c.Rules {
+		if len(rule.Channels) == 0 {
+			return fmt.Errorf("notifications.rules[%d]: channels is required", i)
+		}
+		for _, ch := range rule.Channels {
+			if err := c.checkChannel(ch); err != nil {
+				return fmt.Errorf("notifications.rules[%d]: %w", i, err)
+			}
+		}
+		if err := validatePattern(rule.Pattern); err != nil {
+			return fmt.Errorf("notifications.rules[%d]: invalid pattern: %w", i, err)
+		}
+		for _, glob := range []string{rule.Tool, rule.Model} {
+			if _, err := path.Match(glob, ""); err != nil {
+				return fmt.Errorf("notifications.rules[%d]: invalid pattern %q: %w", i, glob, err)
+			}
+		}
+	}
+	if err := validateTriggers(c.Triggers); err != nil {
+		return err
+	}
+	if err := c.Questions.validate(); err != nil {
+		return err
+	}
+	if err := c.Keywords.validate(c); err != nil {
+		return err
+	}
+	if err := c.DND.validate(); err != nil {
+		return err
+	}
+	if err := c.Digest.validate(); err != nil {
+		return err
+	}
+	if err := c.Push.validate(); err != nil {
+		return err
+	}
+	if err := c.Telegram.validate(); err != nil {
+		return err
+	}
+	if err := c.Hub.validate(); err != nil {
+		return err
+	}
+	return c.MQTT.validate()
+}
+
+// checkChannel checks that ch is a known channel and that the channels
+// that need their own settings have them.
+func (c *Config) checkChannel(ch string) error {
+	if !isKnownChannel(ch) {
+		return fmt.Errorf("unknown channel %q (valid: %s)", ch, strings.Join(Channels, ", "))
+	}
+	needs := map[string]bool{
+		ChannelMQTT:     c.MQTT == nil,
+		ChannelPush:     c.Push == nil,
+		ChannelTelegram: c.Telegram == nil,
+		ChannelHub:      c.Hub == nil,
+	}
+	if needs[ch] {
+		return fmt.Errorf("channel %q needs notifications.%s", ch, ch)
+	}
+	return nil
+}
+
+// Route picks the channels for a notification. When no rule matches, the
+// given default channels and urgency are returned unchanged.
+func (c *Config) Route(event, text string, defaults []string, urgency string) Route {
+	return c.RouteEvent(Event{Name: event, Text: text}, defaults, urgency)
+}
+
+// RouteEvent is Route for an event with metadata, so rules that filter on
+// tool, model, or subagent can match.
+func (c *Config) RouteEvent(event Event, defaults []string, urgency string) Route {
+	if c != nil {
+		for i, rule := range c.Rules {
+			if !rule.matches(event) {
+				continue
+			}
+			route := Route{Channels: rule.Channels, Urgency: urgency, Voice: rule.Voice, Rule: i}
+			if rule.Urgency != "" {
+				route.Urgency = rule.Urgency
+			}
+			return route
+		}
+	}
+	return Route{Channels: defaults, Urgency: urgency, Rule: -1}
+}
+
+// RuleMatch is the outcome of one rule for a notification, as reported by
+// Explain.
+type RuleMatch struct {
+	Index   int
+	Rule    Rule
+	Matched bool
+	// Reason says why the rule did not match; it is empty for a match.
+	Reason string
+}
+
+// Explain evaluates every rule against a notification in order, without
+// stopping at the first match as Route does, so callers can show which rule
+// wins and which later ones it shadows.
+func (c *Config) Explain(event Event) []RuleMatch {
+	if c == nil {
+		return nil
+	}
+	results := make([]RuleMatch, 0, len(c.Rules))
+	for i, rule := range c.Rules {
+		reason := rule.mismatch(event)
+		results = append(results, RuleMatch{Index: i, Rule: rule, Matched: reason == "", Reason: reason})
+	}
+	return results
+}
+
+func (r Rule) matches(event Event) bool {
+	return r.mismatch(event) == ""
+}
+
+// mismatch returns why the rule does not match, or "" when it does.
+func (r Rule) mismatch(event Event) string {
+	if r.Event != "" && r.Event != "*" && !strings.EqualFold(r.Event, event.Name) {
+		return fmt.Sprintf("event %q is not %q", event.Name, r.Event)
+	}
+	if !globMatch(r.Tool, event.Tool) {
+		return fmt.Sprintf("tool %q does not match %q", event.Tool, r.Tool)
+	}
+	if !globMatch(r.Model, event.Model) {
+		return fmt.Sprintf("model %q does not match %q", event.Model, r.Model)
+	}
+	if r.Subagent != nil && *r.Subagent != event.Subagent {
+		if event.Subagent {
+			return "event is from a subagent"
+		}
+		return "event is not from a subagent"
+	}
+	return textMismatch(r.Contains, r.Pattern, event.Text)
+}
+
+// globMatch matches value against a case-insensitive glob. An empty pattern
+// matches everything; an empty value matches only "*".
+func globMatch(pattern, value string) bool {
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+	ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return ok
+}
+
+// matchText applies the shared contains/pattern matching of rules and
+// triggers. An empty field matches everything.
+func matchText(contains, pattern, text string) bool {
+	return textMismatch(contains, pattern, text) == ""
+}
+
+func textMismatch(contains, pattern, text string) string {
+	if contains != "" && !strings.Contains(strings.ToLower(text), strings.ToLower(contains)) {
+		return fmt.Sprintf("text does not contain %q", contains)
+	}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Sprintf("invalid pattern: %v", err)
+		}
+		if !re.MatchString(text) {
+			return fmt.Sprintf("text does not match /%s/", pattern)
+		}
+	}
+	return ""
+}
+
+func validatePattern(pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	_, err := regexp.Compile(pattern)
+	return err
+}
+
+func isKnownChannel(name string) bool {
+	for _, ch := range Channels {
+		if ch == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRouteDefaultsWhenNoRuleMatches(t *testing.T) {
+	defaults := []string{ChannelVoice, ChannelDesktop}
+
+	var nilCfg *Config
+	got := nilCfg.Route("Notification", "hello", defaults, "normal")
+	if got.Rule != -1 || !reflect.DeepEqual(got.Channels, defaults) || got.Urgency != "normal" {
+		t.Fatalf("nil config route = %+v", got)
+	}
+
+	cfg := &Config{Rules: []Rule{{Event: "ci", Channels: []string{ChannelScreenReader}}}}
+	got = cfg.Route("exec", "build failed", defaults, "critical")
+	if got.Rule != -1 || !reflect.DeepEqual(got.Channels, defaults) {
+		t.Fatalf("unmatched route = %+v", got)
+	}
+}
+
+func TestRouteFirstMatchWins(t *testing.T) {
+	cfg := &Config{Rules: []Rule{
+		{Event: "Notification", Contains: "PERMISSION", Channels: []string{ChannelScreenReader, ChannelVoice}, Urgency: "critical"},
+		{Event: "*", Pattern: `^build (failed|broke)`, Channels: []string{ChannelDesktop}},
+		{Channels: []string{ChannelScreenReader}},
+	}}
+
+	tests := []struct {
+		name     string
+		event    string
+		text     string
+		rule     int
+		urgency  string
+		channels []string
+	}{
+		{"contains is case-insensitive", "notification", "Claude needs your permission", 0, "critical", []string{ChannelScreenReader, ChannelVoice}},
+		{"pattern", "exec", "build failed after 3s", 1, "normal", []string{ChannelDesktop}},
+		{"catch-all", "ci", "all checks passed", 2, "normal", []string{ChannelScreenReader}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cfg.Route(tt.event, tt.text, []string{ChannelVoice}, "normal")
+			if got.Rule != tt.rule || got.Urgency != tt.urgency || !reflect.DeepEqual(got.Channels, tt.channels) {
+				t.Errorf("Route() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	cfg := &Config{Rules: []Rule{
+		{Event: "ci", Channels: []string{ChannelDesktop}},
+		{Contains: "permission", Channels: []string{ChannelVoice}},
+		{Pattern: `^build`, Channels: []string{ChannelDesktop}},
+		{Event: "*", Channels: []string{ChannelScreenReader}},
+	}}
+	got := cfg.Explain(Event{Name: "Notification", Text: "Claude needs your permission"})
+	want := []struct {
+		matched bool
+		reason  string
+	}{
+		{false, `event "Notification" is not "ci"`},
+		{true, ""},
+		{false, "text does not match /^build/"},
+		{true, ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Explain() = %+v", got)
+	}
+	for i, w := range want {
+		if got[i].Index != i || got[i].Matched != w.matched || got[i].Reason != w.reason {
+			t.Errorf("Explain()[%d] = %+v, want matched=%v reason=%q", i, got[i], w.matched, w.reason)
+		}
+	}
+	if reason := cfg.Explain(Event{Name: "ci", Text: "deploy done"})[1].Reason; reason != `text does not contain "permission"` {
+		t.Errorf("contains reason = %q", reason)
+	}
+	if route := cfg.Route("Notification", "Claude needs your permission", nil, "normal"); route.Rule != 1 {
+		t.Errorf("Route should pick the first match Explain reports, got rule %d", route.Rule)
+	}
+	var nilCfg *Config
+	if nilCfg.Explain(Event{Name: "Notification", Text: "x"}) != nil {
+		t.Error("nil config should explain nothing")
+	}
+}
+
+func TestRouteEventMetadata(t *testing.T) {
+	yes, no := true, false
+	cfg := &Config{Rules: []Rule{
+		{Subagent: &yes, Channels: []string{}},
+		{Tool: "mcp__*", Channels: []string{ChannelDesktop}},
+		{Model: "GPT-*", Subagent: &no, Channels: []string{ChannelScreenReader}},
+	}}
+	tests := []struct {
+		event Event
+		rule  int
+	}{
+		{Event{Name: "Stop", Subagent: true}, 0},
+		{Event{Name: "PreToolUse", Tool: "mcp__github__create_issue"}, 1},
+		{Event{Name: "PreToolUse", Tool: "Bash"}, -1},
+		{Event{Name: "Stop", Model: "gpt-5"}, 2},
+		{Event{Name: "Stop"}, -1},
+	}
+	for _, tt := range tests {
+		if got := cfg.RouteEvent(tt.event, []string{ChannelVoice}, "normal"); got.Rule != tt.rule {
+			t.Errorf("RouteEvent(%+v) rule = %d, want %d", tt.event, got.Rule, tt.rule)
+		}
+	}
+	reasons := cfg.Explain(Event{Name: "Stop", Tool: "Bash"})
+	if reasons[0].Reason != "event is not from a subagent" || reasons[1].Reason != `tool "Bash" does not match "mcp__*"` || reasons[2].Reason != `model "" does not match "GPT-*"` {
+		t.Errorf("Explain reasons = %+v", reasons)
+	}
+	if err := (&Config{Rules: []Rule{{Tool: "[", Channels: []string{ChannelVoice}}}}).Validate(); err == nil {
+		t.Error("invalid tool glob should fail validation")
+	}
+}
+
+func TestRouteHas(t *testing.T) {
+	r := Route{Channels: []string{ChannelScreenReader}}
+	if !r.Has(ChannelScreenReader) || r.Has(ChannelVoice) {
+		t.Errorf("Has() mismatch for %+v", r)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"valid", &Config{Rules: []Rule{{Pattern: "a+", Channels: Channels}}, MQTT: &MQTTConfig{Broker: "mqtt://localhost"}, Push: &PushConfig{}, Telegram: &TelegramConfig{Token: "t", ChatID: 1}, Hub: &HubConfig{URL: "https://hub.example.com", Token: "t"}}, false},
+		{"mqtt without broker config", &Config{Rules: []Rule{{Channels: []string{ChannelMQTT}}}}, true},
+		{"bad mqtt broker", &Config{MQTT: &MQTTConfig{Broker: "http://localhost"}}, true},
+		{"bad mqtt qos", &Config{MQTT: &MQTTConfig{Broker: "mqtts://localhost", QoS: 2}}, true},
+		{"mqtt topic wildcard", &Config{MQTT: &MQTTConfig{Broker: "mqtt://localhost", Topic: "home/#"}}, true},
+		{"push without push config", &Config{Rules: []Rule{{Channels: []string{ChannelPush}}}}, true},
+		{"bad push subject", &Config{Push: &PushConfig{Subject: "dev@example.com"}}, true},
+		{"telegram without chat", &Config{Telegram: &TelegramConfig{Token: "t"}}, true},
+		{"telegram without token", &Config{Telegram: &TelegramConfig{ChatID: 1}}, true},
+		{"telegram events", &Config{Telegram: &TelegramConfig{TokenEnv: "TG", ChatID: 1, Events: []string{"["}}}, true},
+		{"missing channels", &Config{Rules: []Rule{{Event: "ci"}}}, true},
+		{"unknown channel", &Config{Rules: []Rule{{Channels: []string{"braille"}}}}, true},
+		{"bad pattern", &Config{Rules: []Rule{{Pattern: "(", Channels: []string{ChannelVoice}}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Orca's remote controller D-Bus service (Orca 47+).
+const (
+	orcaBusName    = "org.gnome.Orca.Service"
+	orcaObjectPath = "/org/gnome/Orca/Service"
+	orcaMethod     = "org.gnome.Orca.Service.PresentMessage"
+)
+
+// AnnounceScreenReader forwards text to the active screen reader so it is
+// spoken (or shown on a braille display) with the user's own screen reader
+// settings. Candidates are tried in order until one succeeds.
+func AnnounceScreenReader(ctx context.Context, text string) error {
+	cmds, err := screenReaderCommands(ctx, runtime.GOOS, text)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, cmd := range cmds {
+		if cmd.Err != nil {
+			// Binary not installed.
+			errs = append(errs, cmd.Err)
+			continue
+		}
+		if runErr := cmd.Run(); runErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", cmd.Args[0], runErr))
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("no screen reader accepted the announcement: %w", errors.Join(errs...))
+}
+
+// screenReaderCommands builds the platform-specific announcement commands.
+// Text is always passed as a separate argument, never spliced into a script.
+func screenReaderCommands(ctx context.Context, goos, text string) ([]*exec.Cmd, error) {
+	switch goos {
+	case "linux":
+		return []*exec.Cmd{
+			// Orca presents the message through AT-SPI, including braille.
+			exec.CommandContext(ctx, "gdbus", "call", "--session",
+				"--dest", orcaBusName,
+				"--object-path", orcaObjectPath,
+				"--method", orcaMethod,
+				"--", text),
+			// speech-dispatcher is the backend Orca speaks through.
+			exec.CommandContext(ctx, "spd-say", "--wait", "--", text),
+		}, nil
+
+	case "darwin":
+		return []*exec.Cmd{
+			exec.CommandContext(ctx, "osascript", macAnnouncementArgs(text)...),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("screen reader output is not supported on %s", goos)
+	}
+}
+
+// macAnnouncementArgs posts an NSAccessibility announcement via JXA, which
+// VoiceOver reads at high priority. The text arrives through argv.
+func macAnnouncementArgs(text string) []string {
+	script := `ObjC.import('AppKit');
+function run(argv) {
+	var info = $.NSDictionary.dictionaryWithObjectsForKeys(
+		$([$(argv[0]), $.NSAccessibilityPriorityHigh]),
+		$([$.NSAccessibilityAnnouncementKey, $.NSAccessibilityPriorityKey]));
+	$.NSAccessibilityPostNotificationWithUserInfo(
+		$.NSApplication.sharedApplication,
+		$.NSAccessibilityAnnouncementRequestedNotification,
+		info);
+	delay(0.5);
+}`
+	return []string{"-l", "JavaScript", "-e", script, "--", text}
+}
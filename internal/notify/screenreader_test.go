@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"testing"
+)
+
+func TestScreenReaderCommandsLinux(t *testing.T) {
+	cmds, err := screenReaderCommands(context.Background(), "linux", "-e hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds) != 2 {
+		t.Fatalf("expected Orca and speech-dispatcher candidates, got %d", len(cmds))
+	}
+	orca := cmds[0].Args
+	if orca[0] != "gdbus" || orca[len(orca)-1] != "-e hello" || orca[len(orca)-2] != "--" {
+		t.Errorf("unexpected gdbus args: %v", orca)
+	}
+	spd := cmds[1].Args
+	if spd[0] != "spd-say" || spd[len(spd)-1] != "-e hello" || spd[len(spd)-2] != "--" {
+		t.Errorf("unexpected spd-say args: %v", spd)
+	}
+}
+
+func TestScreenReaderCommandsDarwin(t *testing.T) {
+	cmds, err := screenReaderCommands(context.Background(), "darwin", `say "hi"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := cmds[0].Args
+	if args[0] != "osascript" || args[len(args)-1] != `say "hi"` {
+		t.Errorf("text must be passed as argv: %v", args)
+	}
+}
+
+func TestScreenReaderCommandsUnsupported(t *testing.T) {
+	if _, err := screenReaderCommands(context.Background(), "plan9", "x"); err == nil {
+		t.Error("expected error for unsupported platform")
+	}
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// TelegramConfig sends notifications to a Telegram chat through a bot, and
+// names the chat whose commands `ccpersona runtime telegram serve` accepts.
+type TelegramConfig struct {
+	// Token is the bot token from @BotFather; TokenEnv names an environment
+	// variable holding it instead, which keeps it out of config files.
+	Token    string `json:"token,omitempty"`
+	TokenEnv string `json:"token_env,omitempty"`
+	// ChatID is the chat notifications go to. Commands from other chats
+	// are ignored.
+	ChatID int64 `json:"chat_id"`
+	// Events lists hook event names (globs, "*" for all) that are sent as
+	// they arrive, independently of the notification rules.
+	Events []string `json:"events,omitempty"`
+}
+
+func (t *TelegramConfig) validate() error {
+	if t == nil {
+		return nil
+	}
+	if t.Token == "" && t.TokenEnv == "" {
+		return errors.New("notifications.telegram: token or token_env is required")
+	}
+	if t.ChatID == 0 {
+		return errors.New("notifications.telegram.chat_id is required")
+	}
+	for _, glob := range t.Events {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("notifications.telegram.events: invalid pattern %q: %w", glob, err)
+		}
+	}
+	return nil
+}
+
+// BotToken returns the configured token.
+func (t *TelegramConfig) BotToken() string {
+	if t.TokenEnv != "" {
+		return os.Getenv(t.TokenEnv)
+	}
+	return t.Token
+}
+
+// ForwardsEvent reports whether hook events named name are sent as they
+// arrive; safe on nil.
+func (t *TelegramConfig) ForwardsEvent(name string) bool {
+	if t == nil {
+		return false
+	}
+	for _, glob := range t.Events {
+		if globMatch(glob, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// TelegramText formats a notification for a chat: a header naming the
+// project and event, marked when critical, then the text.
+func TelegramText(event Event, urgency string) string {
+	header := event.Name
+	if event.Project != "" {
+		header = filepath.Base(event.Project) + " · " + header
+	}
+	if urgency == "critical" {
+		header = "❗ " + header
+	}
+	if event.Text == "" {
+		return header
+	}
+	return header + "\n" + event.Text
+}
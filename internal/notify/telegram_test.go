@@ -0,0 +1,28 @@
+package notify
+
+import "testing"
+
+func TestTelegramText(t *testing.T) {
+	event := Event{Name: "Notification", Text: "Claude needs your permission", Project: "/home/me/api"}
+	if got, want := TelegramText(event, "critical"), "❗ api · Notification\nClaude needs your permission"; got != want {
+		t.Errorf("TelegramText() = %q, want %q", got, want)
+	}
+	if got := TelegramText(Event{Name: "Stop"}, ""); got != "Stop" {
+		t.Errorf("TelegramText() = %q", got)
+	}
+}
+
+func TestTelegramConfig(t *testing.T) {
+	t.Setenv("CCPERSONA_TEST_TG", "123:abc")
+	tg := &TelegramConfig{Token: "ignored", TokenEnv: "CCPERSONA_TEST_TG", ChatID: 1, Events: []string{"Stop", "Session*"}}
+	if tg.BotToken() != "123:abc" {
+		t.Errorf("BotToken() = %q", tg.BotToken())
+	}
+	if !tg.ForwardsEvent("SessionStart") || tg.ForwardsEvent("Notification") {
+		t.Error("ForwardsEvent should follow the event globs")
+	}
+	var unset *TelegramConfig
+	if unset.ForwardsEvent("Stop") {
+		t.Error("nil config should not forward")
+	}
+}
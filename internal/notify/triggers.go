@@ -0,0 +1,218 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Trigger runs actions when the assistant's final message matches. Unlike
+// rules, every matching trigger fires. Empty match fields match everything.
+type Trigger struct {
+	// Contains matches a case-insensitive substring of the message.
+	Contains string `json:"contains,omitempty"`
+	// Pattern matches a regular expression against the message.
+	Pattern string   `json:"pattern,omitempty"`
+	Actions []Action `json:"actions"`
+}
+
+// Action is one side effect of a trigger. Exactly one field is set.
+type Action struct {
+	// Sound is an audio file played to completion.
+	Sound string `json:"sound,omitempty"`
+	// Webhook is a URL that receives a JSON POST with the message.
+	Webhook string `json:"webhook,omitempty"`
+	// Open is a URL or file opened with the desktop's default handler.
+	Open string `json:"open,omitempty"`
+}
+
+// Kind names the action type, or "" when no field or several are set.
+func (a Action) Kind() string {
+	var kinds []string
+	if a.Sound != "" {
+		kinds = append(kinds, "sound")
+	}
+	if a.Webhook != "" {
+		kinds = append(kinds, "webhook")
+	}
+	if a.Open != "" {
+		kinds = append(kinds, "open")
+	}
+	if len(kinds) != 1 {
+		return ""
+	}
+	return kinds[0]
+}
+
+// WebhookPayload is the JSON body posted by webhook actions.
+type WebhookPayload struct {
+	Event   string `json:"event"`
+	Message string `json:"message"`
+	Trigger int    `json:"trigger"`
+}
+
+// webhookTimeout bounds webhook calls so a slow endpoint cannot stall a hook.
+const webhookTimeout = 10 * time.Second
+
+// HasTriggers reports whether any trigger is configured.
+func (c *Config) HasTriggers() bool {
+	return c != nil && len(c.Triggers) > 0
+}
+
+// MatchTriggers returns the indexes of all triggers matching message.
+func (c *Config) MatchTriggers(message string) []int {
+	if c == nil {
+		return nil
+	}
+	var matched []int
+	for i, trigger := range c.Triggers {
+		if matchText(trigger.Contains, trigger.Pattern, message) {
+			matched = append(matched, i)
+		}
+	}
+	return matched
+}
+
+func validateTriggers(triggers []Trigger) error {
+	for i, trigger := range triggers {
+		if err := validatePattern(trigger.Pattern); err != nil {
+			return fmt.Errorf("notifications.triggers[%d]: invalid pattern: %w", i, err)
+		}
+		if len(trigger.Actions) == 0 {
+			return fmt.Errorf("notifications.triggers[%d]: actions is required", i)
+		}
+		for j, action := range trigger.Actions {
+			if err := action.validate(); err != nil {
+				return fmt.Errorf("notifications.triggers[%d].actions[%d]: %w", i, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (a Action) validate() error {
+	switch a.Kind() {
+	case "sound":
+		return nil
+	case "webhook":
+		u, err := url.Parse(a.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook must be an http(s) URL")
+		}
+		return nil
+	case "open":
+		if _, err := openTarget(a.Open); err != nil {
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("set exactly one of sound, webhook, or open")
+	}
+}
+
+// RunAction performs a single action for a trigger. play is used for sound
+// actions so this package stays independent of the audio stack.
+func RunAction(ctx context.Context, a Action, trigger int, message string, play func(path string) error) error {
+	switch a.Kind() {
+	case "sound":
+		return play(expandHome(a.Sound))
+	case "webhook":
+		return postWebhook(ctx, http.DefaultClient, a.Webhook, WebhookPayload{
+			Event:   "assistant_message",
+			Message: message,
+			Trigger: trigger,
+		})
+	case "open":
+		target, err := openTarget(a.Open)
+		if err != nil {
+			return err
+		}
+		cmd, err := openCommand(runtime.GOOS, target)
+		if err != nil {
+			return err
+		}
+		// The handler may keep running (a browser, an editor); don't wait.
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("open %s: %w", target, err)
+		}
+		go func() { _ = cmd.Wait() }()
+		return nil
+	default:
+		return fmt.Errorf("invalid action: set exactly one of sound, webhook, or open")
+	}
+}
+
+func postWebhook(ctx context.Context, client *http.Client, endpoint string, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s: status %d", endpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+// openTarget accepts http(s) URLs and file paths. Other schemes are refused
+// so a config cannot launch arbitrary URL handlers.
+func openTarget(value string) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("open target is empty")
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		if (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+			return value, nil
+		}
+		return "", fmt.Errorf("open supports http(s) URLs and file paths, not %q", u.Scheme)
+	}
+	path := expandHome(value)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path, nil
+}
+
+// openCommand builds the platform command that opens target. The target is
+// passed as a single argument and never interpreted by a shell.
+func openCommand(goos, target string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		return exec.Command("open", target), nil
+	case "linux":
+		return exec.Command("xdg-open", target), nil
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", target), nil
+	default:
+		return nil, fmt.Errorf("unsupported platform: %s", goos)
+	}
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
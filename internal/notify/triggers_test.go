@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestActionKind(t *testing.T) {
+	tests := []struct {
+		action Action
+		want   string
+	}{
+		{Action{Sound: "a.wav"}, "sound"},
+		{Action{Webhook: "https://example.com"}, "webhook"},
+		{Action{Open: "https://example.com"}, "open"},
+		{Action{}, ""},
+		{Action{Sound: "a.wav", Open: "b"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.action.Kind(); got != tt.want {
+			t.Errorf("%+v.Kind() = %q, want %q", tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTriggers(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger Trigger
+		wantErr string
+	}{
+		{"valid", Trigger{Contains: "tests passed", Actions: []Action{{Sound: "~/ok.wav"}, {Webhook: "https://hooks.example.com/x"}}}, ""},
+		{"no actions", Trigger{Contains: "x"}, "actions is required"},
+		{"bad pattern", Trigger{Pattern: "(", Actions: []Action{{Sound: "a"}}}, "invalid pattern"},
+		{"empty action", Trigger{Actions: []Action{{}}}, "exactly one"},
+		{"webhook scheme", Trigger{Actions: []Action{{Webhook: "ftp://example.com"}}}, "http(s) URL"},
+		{"open scheme", Trigger{Actions: []Action{{Open: "javascript:alert(1)"}}}, "not \"javascript\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Config{Triggers: []Trigger{tt.trigger}}).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchTriggersFiresAll(t *testing.T) {
+	cfg := &Config{Triggers: []Trigger{
+		{Contains: "TESTS PASSED", Actions: []Action{{Sound: "ok.wav"}}},
+		{Pattern: `deploy(ed)? to \w+`, Actions: []Action{{Sound: "ship.wav"}}},
+		{Contains: "needs your input", Actions: []Action{{Sound: "ask.wav"}}},
+	}}
+	got := cfg.MatchTriggers("All tests passed and deployed to staging.")
+	if !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Fatalf("MatchTriggers() = %v, want [0 1]", got)
+	}
+	var nilCfg *Config
+	if nilCfg.MatchTriggers("anything") != nil || nilCfg.HasTriggers() {
+		t.Fatal("nil config should match nothing")
+	}
+}
+
+func TestRunActionWebhook(t *testing.T) {
+	var got WebhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	err := RunAction(context.Background(), Action{Webhook: srv.URL}, 2, "deployed", nil)
+	if err != nil {
+		t.Fatalf("RunAction() error = %v", err)
+	}
+	want := WebhookPayload{Event: "assistant_message", Message: "deployed", Trigger: 2}
+	if got != want {
+		t.Fatalf("payload = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunActionWebhookStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	if err := RunAction(context.Background(), Action{Webhook: srv.URL}, 0, "x", nil); err == nil {
+		t.Fatal("expected error for 500 response")
+	}
+}
+
+func TestRunActionSound(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	var played string
+	play := func(path string) error { played = path; return nil }
+	if err := RunAction(context.Background(), Action{Sound: "~/sounds/ok.wav"}, 0, "x", play); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, "sounds", "ok.wav"); played != want {
+		t.Fatalf("played %q, want %q", played, want)
+	}
+}
+
+func TestOpenTarget(t *testing.T) {
+	if got, err := openTarget("https://ci.example.com/run/1"); err != nil || got != "https://ci.example.com/run/1" {
+		t.Fatalf("url: %q, %v", got, err)
+	}
+	got, err := openTarget("report.html")
+	if err != nil || !filepath.IsAbs(got) {
+		t.Fatalf("relative path should become absolute: %q, %v", got, err)
+	}
+	if _, err := openTarget("file:///etc/passwd"); err == nil {
+		t.Fatal("file: scheme should be refused")
+	}
+}
+
+func TestOpenCommand(t *testing.T) {
+	target := "https://example.com/?q=a&b=c"
+	for goos, want := range map[string][]string{
+		"darwin":  {"open", target},
+		"linux":   {"xdg-open", target},
+		"windows": {"rundll32", "url.dll,FileProtocolHandler", target},
+	} {
+		cmd, err := openCommand(goos, target)
+		if err != nil {
+			t.Fatalf("%s: %v", goos, err)
+		}
+		if !reflect.DeepEqual(cmd.Args, want) {
+			t.Errorf("%s args = %v, want %v", goos, cmd.Args, want)
+		}
+	}
+	if _, err := openCommand("plan9", target); err == nil {
+		t.Error("expected error for unsupported platform")
+	}
+}
@@ -0,0 +1,202 @@
+package persona
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"gopkg.in/yaml.v3"
+)
+
+// agentManifestName records the agent files generated for the current
+// persona, so switching personas removes exactly those files.
+const agentManifestName = ".ccpersona-agents.json"
+
+var agentName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// AgentSpec is a Claude Code subagent declared under `agents:` in a persona's
+// front matter.
+type AgentSpec struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Tools       []string `yaml:"tools,omitempty"`
+	Model       string   `yaml:"model,omitempty"`
+	Prompt      string   `yaml:"prompt"`
+}
+
+// agentManifest maps generated file names to the SHA-256 of the content
+// written, so files edited since are left alone.
+type agentManifest struct {
+	Persona string            `json:"persona"`
+	Files   map[string]string `json:"files"`
+}
+
+// AgentSyncResult lists what SyncAgents changed. Kept files were generated
+// earlier but edited since, or belong to the user, and were not touched.
+type AgentSyncResult struct {
+	Written []string
+	Removed []string
+	Kept    []string
+}
+
+// ParseAgents returns the subagents declared in the front matter of persona
+// content.
+func ParseAgents(content string) ([]AgentSpec, error) {
+	fm, err := parseFrontMatter(content)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, agent := range fm.Agents {
+		if !agentName.MatchString(agent.Name) {
+			return nil, fmt.Errorf("invalid agent name %q (use lowercase letters, digits, and hyphens)", agent.Name)
+		}
+		if seen[agent.Name] {
+			return nil, fmt.Errorf("agent %q is declared twice", agent.Name)
+		}
+		seen[agent.Name] = true
+		if strings.TrimSpace(agent.Description) == "" {
+			return nil, fmt.Errorf("agent %q has no description", agent.Name)
+		}
+	}
+	return fm.Agents, nil
+}
+
+// renderAgent formats an agent as a Claude Code agent definition file.
+func renderAgent(agent AgentSpec) (string, error) {
+	header := struct {
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+		Tools       string `yaml:"tools,omitempty"`
+		Model       string `yaml:"model,omitempty"`
+	}{agent.Name, agent.Description, strings.Join(agent.Tools, ", "), agent.Model}
+	front, err := yaml.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("---\n%s---\n\n%s\n", front, strings.TrimSpace(agent.Prompt)), nil
+}
+
+// ClaudeAgentsDir returns the Claude Code agent directory under baseDir: the
+// project directory, or the home directory for global personas.
+func ClaudeAgentsDir(baseDir string) string {
+	return filepath.Join(baseDir, ClaudeDir, "agents")
+}
+
+// SyncAgents replaces the agent files generated for the previous persona in
+// agentsDir with those the persona name declares. Only files ccpersona wrote
+// and nobody edited since are removed or overwritten.
+func (m *Manager) SyncAgents(agentsDir, name string) (*AgentSyncResult, error) {
+	raw, err := m.ReadPersona(name)
+	if err != nil {
+		return nil, err
+	}
+	agents, err := ParseAgents(raw)
+	if err != nil {
+		return nil, fmt.Errorf("persona '%s': %w", name, err)
+	}
+
+	manifestPath := filepath.Join(agentsDir, agentManifestName)
+	result := &AgentSyncResult{}
+	err = fsutil.WithLock(manifestPath, func() error {
+		manifest, err := loadAgentManifest(manifestPath)
+		if err != nil {
+			return err
+		}
+
+		next := &agentManifest{Persona: name, Files: map[string]string{}}
+		wanted := map[string]string{}
+		for _, agent := range agents {
+			content, err := renderAgent(agent)
+			if err != nil {
+				return fmt.Errorf("failed to render agent %q: %w", agent.Name, err)
+			}
+			wanted[agent.Name+".md"] = content
+		}
+
+		// Drop the previous persona's files, unless edited since.
+		for _, file := range slices.Sorted(maps.Keys(manifest.Files)) {
+			path := filepath.Join(agentsDir, file)
+			if !fileHasHash(path, manifest.Files[file]) {
+				if _, statErr := os.Stat(path); statErr == nil {
+					result.Kept = append(result.Kept, file)
+				}
+				continue
+			}
+			if _, ok := wanted[file]; ok {
+				continue // overwritten below
+			}
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+			result.Removed = append(result.Removed, file)
+		}
+
+		for _, file := range slices.Sorted(maps.Keys(wanted)) {
+			path := filepath.Join(agentsDir, file)
+			if _, err := os.Stat(path); err == nil && !fileHasHash(path, manifest.Files[file]) {
+				// A user's own agent, or one edited since generation.
+				if !slices.Contains(result.Kept, file) {
+					result.Kept = append(result.Kept, file)
+				}
+				continue
+			}
+			if err := fsutil.WriteFile(path, []byte(wanted[file]), 0644); err != nil {
+				return fmt.Errorf("failed to write agent file: %w", err)
+			}
+			next.Files[file] = contentHash(wanted[file])
+			result.Written = append(result.Written, file)
+		}
+
+		if len(next.Files) == 0 {
+			if err := os.Remove(manifestPath); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		}
+		data, err := json.MarshalIndent(next, "", "  ")
+		if err != nil {
+			return err
+		}
+		return fsutil.WriteFile(manifestPath, append(data, '\n'), 0644)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func loadAgentManifest(path string) (*agentManifest, error) {
+	manifest := &agentManifest{Files: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid agent manifest %s: %w", path, err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = map[string]string{}
+	}
+	return manifest, nil
+}
+
+// fileHasHash reports whether the file exists with the given content hash.
+func fileHasHash(path, hash string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && hash != "" && contentHash(string(data)) == hash
+}
+
+func contentHash(content string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
@@ -0,0 +1,127 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAgents(t *testing.T) {
+	content := "---\n" +
+		"agents:\n" +
+		"  - name: reviewer\n" +
+		"    description: Reviews diffs in the persona's voice\n" +
+		"    tools: [Read, Grep]\n" +
+		"    model: sonnet\n" +
+		"    prompt: |\n" +
+		"      Review the change.\n" +
+		"---\n" +
+		"# 人格\n"
+	agents, err := ParseAgents(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AgentSpec{{
+		Name:        "reviewer",
+		Description: "Reviews diffs in the persona's voice",
+		Tools:       []string{"Read", "Grep"},
+		Model:       "sonnet",
+		Prompt:      "Review the change.\n",
+	}}
+	if !reflect.DeepEqual(agents, want) {
+		t.Errorf("ParseAgents = %+v, want %+v", agents, want)
+	}
+
+	for _, bad := range []string{
+		"---\nagents:\n  - name: ../evil\n    description: x\n---\n",
+		"---\nagents:\n  - name: a\n---\n",
+		"---\nagents:\n  - name: a\n    description: x\n  - name: a\n    description: y\n---\n",
+	} {
+		if _, err := ParseAgents(bad); err == nil {
+			t.Errorf("ParseAgents(%q) accepted invalid agents", bad)
+		}
+	}
+}
+
+func TestRenderAgent(t *testing.T) {
+	got, err := renderAgent(AgentSpec{Name: "reviewer", Description: "Reviews: diffs", Tools: []string{"Read", "Grep"}, Prompt: "Be brief.\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "---\nname: reviewer\ndescription: 'Reviews: diffs'\ntools: Read, Grep\n---\n\nBe brief.\n"
+	if got != want {
+		t.Errorf("renderAgent = %q, want %q", got, want)
+	}
+}
+
+func TestSyncAgents(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	personasDir := filepath.Join(home, ".claude", "personas")
+	if err := os.MkdirAll(personasDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	personas := map[string]string{
+		"strict": "---\nagents:\n  - name: reviewer\n    description: Reviews\n    prompt: Review.\n  - name: tester\n    description: Tests\n    prompt: Test.\n---\n# strict\n",
+		"calm":   "---\nagents:\n  - name: helper\n    description: Helps\n    prompt: Help.\n---\n# calm\n",
+		"plain":  "# plain\n",
+	}
+	for name, content := range personas {
+		if err := os.WriteFile(filepath.Join(personasDir, name+".md"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manager, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	agentsDir := ClaudeAgentsDir(t.TempDir())
+
+	result, err := manager.SyncAgents(agentsDir, "strict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Written, []string{"reviewer.md", "tester.md"}) {
+		t.Errorf("written = %v", result.Written)
+	}
+
+	// An edited generated file and a user's own agent survive the switch.
+	if err := os.WriteFile(filepath.Join(agentsDir, "tester.md"), []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(agentsDir, "helper.md"), []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = manager.SyncAgents(agentsDir, "calm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Written) != 0 || !reflect.DeepEqual(result.Removed, []string{"reviewer.md"}) ||
+		!reflect.DeepEqual(result.Kept, []string{"tester.md", "helper.md"}) {
+		t.Errorf("switch result = %+v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(agentsDir, "helper.md")); string(data) != "mine" {
+		t.Errorf("user agent overwritten: %q", data)
+	}
+
+	// Without the user's file the agent is generated, and a persona without
+	// agents removes it and the manifest again.
+	if err := os.Remove(filepath.Join(agentsDir, "helper.md")); err != nil {
+		t.Fatal(err)
+	}
+	if result, err = manager.SyncAgents(agentsDir, "calm"); err != nil || !reflect.DeepEqual(result.Written, []string{"helper.md"}) {
+		t.Fatalf("calm again = %+v, %v", result, err)
+	}
+	data, err := os.ReadFile(filepath.Join(agentsDir, "helper.md"))
+	if err != nil || !strings.Contains(string(data), "name: helper") {
+		t.Errorf("helper.md = %q, %v", data, err)
+	}
+	if result, err = manager.SyncAgents(agentsDir, "plain"); err != nil || !reflect.DeepEqual(result.Removed, []string{"helper.md"}) {
+		t.Fatalf("plain = %+v, %v", result, err)
+	}
+	if _, err := os.Stat(filepath.Join(agentsDir, agentManifestName)); !os.IsNotExist(err) {
+		t.Errorf("manifest left behind: %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package persona
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// ApplyTarget is an instruction file an assistant reads from the project,
+// which `persona apply` writes the persona into.
+type ApplyTarget struct {
+	// Name selects the target on the command line.
+	Name string
+	// Platform picks the persona's platform sections.
+	Platform string
+	// Path is relative to the project root.
+	Path string
+	// Owned files are written whole. Others are shared with the user, and
+	// only the block between the ccpersona markers is replaced.
+	Owned bool
+}
+
+// ApplyTargets are the targets `persona apply --all-platforms` writes.
+var ApplyTargets = []ApplyTarget{
+	{Name: "claude", Platform: PlatformClaudeCode, Path: "CLAUDE.md"},
+	{Name: "codex", Platform: PlatformCodex, Path: "AGENTS.md"},
+	{Name: "cursor", Platform: PlatformCursor, Path: filepath.Join(".cursor", "rules", "ccpersona.mdc"), Owned: true},
+	{Name: "gemini", Platform: "gemini", Path: "GEMINI.md"},
+}
+
+// FindApplyTarget returns the target called name.
+func FindApplyTarget(name string) (ApplyTarget, bool) {
+	for _, target := range ApplyTargets {
+		if strings.EqualFold(target.Name, name) {
+			return target, true
+		}
+	}
+	return ApplyTarget{}, false
+}
+
+const (
+	applyBegin = "<!-- ccpersona:begin (managed by `ccpersona persona apply`; edits are replaced) -->"
+	applyEnd   = "<!-- ccpersona:end -->"
+)
+
+// ApplyChange is the planned content of one target file.
+type ApplyChange struct {
+	Target ApplyTarget
+	// Path is the absolute file path.
+	Path    string
+	Content []byte
+	// Changed is false when the file already has Content.
+	Changed bool
+
+	previous []byte
+	existed  bool
+	perm     fs.FileMode
+}
+
+// PlanApply renders persona name for each target under root, without
+// writing anything. Each target gets the persona with its platform sections
+// and templates resolved, followed by the config's custom instructions.
+func (m *Manager) PlanApply(root, name string, config *Config, targets []ApplyTarget) ([]ApplyChange, error) {
+	changes := make([]ApplyChange, 0, len(targets))
+	for _, target := range targets {
+		body, err := readPersonaForRoot(m, name, target.Platform, config, root)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.TrimSpace(body) + "\n"
+		if config != nil && config.CustomInstructions != "" {
+			body += "\n" + strings.TrimSpace(config.CustomInstructions) + "\n"
+		}
+
+		change := ApplyChange{Target: target, Path: filepath.Join(root, target.Path), perm: 0644}
+		previous, err := os.ReadFile(change.Path)
+		switch {
+		case err == nil:
+			change.previous, change.existed = previous, true
+			if info, err := os.Stat(change.Path); err == nil {
+				change.perm = info.Mode().Perm()
+			}
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("failed to read %s: %w", change.Path, err)
+		}
+
+		if target.Owned {
+			change.Content = []byte(ownedRule(name, body))
+		} else {
+			content, err := replaceManagedBlock(string(previous), body)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", change.Path, err)
+			}
+			change.Content = []byte(content)
+		}
+		change.Changed = !change.existed || !bytes.Equal(previous, change.Content)
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// CommitApply writes the changed files. If any write fails, files already
+// written are restored, and files that did not exist are removed, so the
+// targets are updated together or not at all.
+func CommitApply(changes []ApplyChange) error {
+	for i, change := range changes {
+		if !change.Changed {
+			continue
+		}
+		if err := fsutil.WriteFile(change.Path, change.Content, change.perm); err != nil {
+			err = fmt.Errorf("failed to write %s: %w", change.Path, err)
+			if rollbackErr := rollbackApply(changes[:i]); rollbackErr != nil {
+				return errors.Join(err, rollbackErr)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func rollbackApply(written []ApplyChange) error {
+	var errs []error
+	for _, change := range written {
+		if !change.Changed {
+			continue
+		}
+		var err error
+		if change.existed {
+			err = fsutil.WriteFile(change.Path, change.previous, change.perm)
+		} else {
+			err = os.Remove(change.Path)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", change.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ownedRule is the Cursor project rule holding the persona.
+func ownedRule(name, body string) string {
+	return fmt.Sprintf("---\ndescription: Persona %s, applied by ccpersona\nalwaysApply: true\n---\n\n%s", name, body)
+}
+
+// replaceManagedBlock puts body between the ccpersona markers in content,
+// replacing the previous block or appending one.
+func replaceManagedBlock(content, body string) (string, error) {
+	block := applyBegin + "\n" + body + applyEnd + "\n"
+	start := strings.Index(content, applyBegin)
+	if start < 0 {
+		if content == "" {
+			return block, nil
+		}
+		return strings.TrimRight(content, "\n") + "\n\n" + block, nil
+	}
+	end := strings.Index(content[start:], applyEnd)
+	if end < 0 {
+		return "", errors.New("ccpersona block has no end marker")
+	}
+	end += start + len(applyEnd)
+	rest := strings.TrimPrefix(content[end:], "\n")
+	return content[:start] + block + rest, nil
+}
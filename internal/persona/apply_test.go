@@ -0,0 +1,112 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func applyFixture(t *testing.T) (*Manager, string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	personasDir := filepath.Join(home, ".agents", "ccpersona", "personas")
+	if err := os.MkdirAll(personasDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nenv:\n  A: b\n---\n# 人格: fable\n<!-- if: cursor -->\nCursor だけ。\n<!-- endif -->\n{{.Project}} を担当するのだ。\n"
+	if err := os.WriteFile(filepath.Join(personasDir, "fable.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manager, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(t.TempDir(), "app")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return manager, root
+}
+
+func TestApply(t *testing.T) {
+	manager, root := applyFixture(t)
+	claude := filepath.Join(root, "CLAUDE.md")
+	if err := os.WriteFile(claude, []byte("# Project notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{Name: "fable", CustomInstructions: "テストを書くのだ。"}
+
+	changes, err := manager.PlanApply(root, "fable", config, ApplyTargets)
+	if err != nil {
+		t.Fatalf("PlanApply() error = %v", err)
+	}
+	if err := CommitApply(changes); err != nil {
+		t.Fatalf("CommitApply() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(claude)
+	want := "# Project notes\n\n" + applyBegin + "\n# 人格: fable\napp を担当するのだ。\n\nテストを書くのだ。\n" + applyEnd + "\n"
+	if string(data) != want {
+		t.Errorf("CLAUDE.md = %q, want %q", data, want)
+	}
+	rule, _ := os.ReadFile(filepath.Join(root, ".cursor", "rules", "ccpersona.mdc"))
+	if !strings.HasPrefix(string(rule), "---\ndescription: Persona fable") || !strings.Contains(string(rule), "Cursor だけ。") {
+		t.Errorf("cursor rule = %q", rule)
+	}
+	for _, path := range []string{"AGENTS.md", "GEMINI.md"} {
+		if data, err := os.ReadFile(filepath.Join(root, path)); err != nil || strings.Contains(string(data), "Cursor だけ。") {
+			t.Errorf("%s = %q, %v", path, data, err)
+		}
+	}
+
+	// Applying again replaces the block instead of appending another.
+	changes, err = manager.PlanApply(root, "fable", &Config{Name: "fable"}, ApplyTargets[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CommitApply(changes); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(claude)
+	if strings.Count(string(data), applyBegin) != 1 || strings.Contains(string(data), "テストを書く") {
+		t.Errorf("CLAUDE.md after reapplying = %q", data)
+	}
+	changes, _ = manager.PlanApply(root, "fable", &Config{Name: "fable"}, ApplyTargets[:1])
+	if changes[0].Changed {
+		t.Error("an applied target should be up to date")
+	}
+}
+
+func TestCommitApply_RollsBack(t *testing.T) {
+	manager, root := applyFixture(t)
+	claude := filepath.Join(root, "CLAUDE.md")
+	if err := os.WriteFile(claude, []byte("# Project notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := manager.PlanApply(root, "fable", &Config{Name: "fable"}, ApplyTargets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// .cursor is a file by the time the cursor rule is written.
+	if err := os.WriteFile(filepath.Join(root, ".cursor"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CommitApply(changes); err == nil {
+		t.Fatal("CommitApply() should fail")
+	}
+	if data, _ := os.ReadFile(claude); string(data) != "# Project notes\n" {
+		t.Errorf("CLAUDE.md was not restored: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "AGENTS.md")); !os.IsNotExist(err) {
+		t.Errorf("AGENTS.md created before the failure should be removed, stat error = %v", err)
+	}
+}
+
+func TestReplaceManagedBlock_MissingEnd(t *testing.T) {
+	if _, err := replaceManagedBlock("x\n"+applyBegin+"\nold\n", "new\n"); err == nil {
+		t.Error("replaceManagedBlock() should reject a block without an end marker")
+	}
+}
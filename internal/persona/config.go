@@ -9,6 +9,8 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/daikw/ccpersona/internal/transcripts"
 	"github.com/daikw/ccpersona/internal/voice"
 	"github.com/rs/zerolog/log"
 )
@@ -55,6 +57,8 @@ func LoadConfig(projectPath string) (*Config, error) {
 // LoadConfigForPlatform loads unified configuration from .agents/ccpersona.json.
 // Platform is accepted for call-site compatibility, but no longer changes the
 // path: the unified config is shared across Claude Code, Codex, Cursor, and MCP.
+// Outside the home directory, settings a cloned repository must not choose
+// are taken from the global config instead (see restrictProjectConfig).
 func LoadConfigForPlatform(projectPath, platform string) (*Config, error) {
 	path := ConfigPath(projectPath)
 	config, err := loadConfigFile(path)
@@ -67,7 +71,7 @@ func LoadConfigForPlatform(projectPath, platform string) (*Config, error) {
 		return nil, nil
 	}
 	log.Debug().Str("persona", config.Name).Str("path", path).Msg("Loaded ccpersona config")
-	return config, nil
+	return restrictProjectConfig(config, projectPath), nil
 }
 
 // LoadConfigWithFallback loads configuration from the current directory,
@@ -79,13 +83,16 @@ func LoadConfigWithFallback() (*Config, error) {
 // LoadConfigWithFallbackForPlatform loads unified project config first, then
 // unified global config. Broken files are reported to stderr and ignored so
 // runtime paths such as voice synthesis can continue with built-in defaults.
+// Worktree rules for the current checkout, then CCPERSONA_PERSONA, override
+// the persona name of the result. The voice of that persona, then the
+// platforms entry for platform, overlay its voice settings.
 func LoadConfigWithFallbackForPlatform(platform string) (*Config, error) {
 	config, err := LoadConfigForPlatform(".", platform)
 	if err != nil {
 		return nil, err
 	}
 	if config != nil {
-		return config, nil
+		return applyPlatformOverride(WithPersonaVoice(ApplyEnvOverrides(ApplyWorktreeRules(WithGlobalVoices(config), "."))), platform), nil
 	}
 
 	homeDir, err := os.UserHomeDir()
@@ -97,7 +104,7 @@ func LoadConfigWithFallbackForPlatform(platform string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	return config, nil
+	return applyPlatformOverride(WithPersonaVoice(ApplyEnvOverrides(ApplyWorktreeRules(config, "."))), platform), nil
 }
 
 // LoadConfigFromPath loads a specific unified config file strictly.
@@ -189,8 +196,36 @@ func legacyConfigPaths(baseDir, platform string) []string {
 	return paths
 }
 
-// SaveConfig saves unified configuration to .agents/ccpersona.json.
+// SaveConfig saves unified configuration to .agents/ccpersona.json. The file
+// is replaced atomically under a lock, so concurrent hooks and commands never
+// leave a partial config behind.
 func SaveConfig(projectPath string, config *Config) error {
+	configPath := ConfigPath(projectPath)
+	return fsutil.WithLock(configPath, func() error {
+		return writeConfig(projectPath, config)
+	})
+}
+
+// UpdateConfig applies a read-modify-write to .agents/ccpersona.json while
+// holding its lock, so concurrent updates are not lost. fn receives the
+// current config (nil when there is none) and returns the config to save, or
+// nil to leave the file untouched.
+func UpdateConfig(projectPath string, fn func(*Config) (*Config, error)) error {
+	configPath := ConfigPath(projectPath)
+	return fsutil.WithLock(configPath, func() error {
+		config, err := LoadConfigFromPath(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load existing config: %w", err)
+		}
+		config, err = fn(config)
+		if err != nil || config == nil {
+			return err
+		}
+		return writeConfig(projectPath, config)
+	})
+}
+
+func writeConfig(projectPath string, config *Config) error {
 	agentsDir := filepath.Join(projectPath, AgentsDir)
 	if err := os.MkdirAll(agentsDir, DirPermission); err != nil {
 		return fmt.Errorf("failed to create %s directory: %w", AgentsDir, err)
@@ -203,7 +238,7 @@ func SaveConfig(projectPath string, config *Config) error {
 	}
 	data = append(data, '\n')
 
-	if err := os.WriteFile(configPath, data, FilePermission); err != nil {
+	if err := fsutil.WriteFile(configPath, data, FilePermission); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
@@ -231,77 +266,188 @@ func ValidateConfig(config *Config) error {
 		if config.Voice.Speed < 0 || config.Voice.Speed > 4.0 {
 			return fmt.Errorf("voice speed must be between 0.0 and 4.0")
 		}
+		if err := config.Voice.Caption.Validate(); err != nil {
+			return err
+		}
+		if err := config.Voice.Output.Validate(); err != nil {
+			return err
+		}
+		if err := config.Voice.Recent.Validate(); err != nil {
+			return err
+		}
+		if err := config.Voice.Cache.Validate(); err != nil {
+			return err
+		}
+		if ladder := config.Voice.RetryLadder; ladder != nil {
+			if err := voice.ValidateRetryLadder(*ladder); err != nil {
+				return err
+			}
+		}
+		if err := config.Voice.Prosody.Validate(); err != nil {
+			return fmt.Errorf("voice.%w", err)
+		}
+		if err := voice.ValidateStyles(config.Voice.SpeakerStyle, config.Voice.SpeakerStyles); err != nil {
+			return fmt.Errorf("voice.%w", err)
+		}
+		if config.Voice.Provider == voice.ProviderAuto && config.Voice.Auto == nil {
+			return fmt.Errorf("voice.provider %q needs voice.auto.providers", voice.ProviderAuto)
+		}
+		if err := config.Voice.Auto.Validate(); err != nil {
+			return err
+		}
+		for event, mode := range config.Voice.Playback {
+			if mode != PlaybackWait && mode != PlaybackDetach {
+				return fmt.Errorf("voice.playback.%s must be %q or %q, got %q", event, PlaybackWait, PlaybackDetach, mode)
+			}
+		}
 	}
-	return nil
+	if err := config.Notifications.Validate(); err != nil {
+		return err
+	}
+	if err := config.Generate.Validate(); err != nil {
+		return err
+	}
+	if err := config.Voices.Validate(); err != nil {
+		return err
+	}
+	if err := config.Apply.Validate(); err != nil {
+		return err
+	}
+	if config.Transcripts.AutoPrune() {
+		if _, err := transcripts.ParseAge(config.Transcripts.PruneOlderThan); err != nil {
+			return fmt.Errorf("transcripts.prune_older_than: %w", err)
+		}
+	}
+	for provider, price := range config.PriceOverrides() {
+		if price < 0 {
+			return fmt.Errorf("usage.prices.%s must not be negative", provider)
+		}
+	}
+	for _, name := range config.ProfileNames() {
+		applied, err := ApplyProfile(config, name)
+		if err != nil {
+			return err
+		}
+		applied.Profiles = nil
+		if err := ValidateConfig(applied); err != nil {
+			return fmt.Errorf("profiles.%s: %w", name, err)
+		}
+	}
+	seen := map[string]string{}
+	for name := range config.Platforms {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("platforms: empty platform name")
+		}
+		if other, dup := seen[normalizePlatform(name)]; dup {
+			return fmt.Errorf("platforms: %q and %q name the same platform", other, name)
+		}
+		seen[normalizePlatform(name)] = name
+		applied, err := ApplyPlatform(config, name)
+		if err != nil {
+			return err
+		}
+		applied.Profiles, applied.Platforms = nil, nil
+		if err := ValidateConfig(applied); err != nil {
+			return fmt.Errorf("platforms.%s: %w", name, err)
+		}
+	}
+	for _, name := range config.PersonaNames() {
+		if err := validatePersonaName(name); err != nil {
+			return fmt.Errorf("personas: %w", err)
+		}
+		named := *config
+		named.Name = name
+		applied, err := ApplyPersonaVoice(&named, nil)
+		if err != nil {
+			return err
+		}
+		applied.Profiles, applied.Platforms, applied.Personas = nil, nil, nil
+		if err := ValidateConfig(applied); err != nil {
+			return fmt.Errorf("personas.%s: %w", name, err)
+		}
+	}
+	return validateWorktreeRules(config.Worktrees)
 }
 
 // MigrateConfig merges legacy persona and voice config files into
-// .agents/ccpersona.json under baseDir.
-func MigrateConfig(baseDir string, force bool) (string, error) {
+// .agents/ccpersona.json under baseDir. It returns the written path and a
+// description of each source file and renamed field.
+func MigrateConfig(baseDir string, force bool) (string, []string, error) {
 	target := ConfigPath(baseDir)
 	if _, err := os.Stat(target); err == nil && !force {
-		return "", fmt.Errorf("config already exists: %s (use --force to overwrite)", target)
+		return "", nil, fmt.Errorf("config already exists: %s (use --force to overwrite)", target)
 	} else if err != nil && !os.IsNotExist(err) {
-		return "", fmt.Errorf("cannot access target config %s: %w", target, err)
+		return "", nil, fmt.Errorf("cannot access target config %s: %w", target, err)
 	}
 
 	out := GetDefaultConfig()
-	found := false
+	var notes []string
 
-	if legacyPersona, err := loadFirstLegacyPersona(baseDir); err != nil {
-		return "", err
+	if legacyPersona, path, changes, err := loadFirstLegacyPersona(baseDir); err != nil {
+		return "", nil, err
 	} else if legacyPersona != nil {
 		out = legacyPersona
-		found = true
+		notes = append(notes, "merged "+path)
+		notes = append(notes, changes...)
 	}
 
 	if legacyVoice, err := loadLegacyVoiceConfig(baseDir); err != nil {
-		return "", err
+		return "", nil, err
 	} else if legacyVoice != nil {
 		mergeLegacyVoice(out, legacyVoice)
-		found = true
+		notes = append(notes, "merged "+legacyVoiceConfigPath(baseDir))
 	}
 
-	if !found {
-		return "", fmt.Errorf("no legacy configuration found under %s", baseDir)
+	if len(notes) == 0 {
+		return "", nil, fmt.Errorf("no legacy configuration found under %s", baseDir)
 	}
 	if out.Name == "" {
 		out.Name = "default"
 	}
 
 	if err := SaveConfig(baseDir, out); err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return target, nil
+	return target, notes, nil
 }
 
-func loadFirstLegacyPersona(baseDir string) (*Config, error) {
-	for _, path := range []string{
+func legacyPersonaPaths(baseDir string) []string {
+	return []string{
 		filepath.Join(baseDir, AgentsDir, LegacyPersonaFileName),
 		filepath.Join(baseDir, ClaudeDir, LegacyPersonaFileName),
 		filepath.Join(baseDir, CodexDir, LegacyPersonaFileName),
 		filepath.Join(baseDir, CursorDir, LegacyPersonaFileName),
 		filepath.Join(baseDir, ClaudeDir, PlatformCodex, LegacyPersonaFileName),
 		filepath.Join(baseDir, ClaudeDir, PlatformCursor, LegacyPersonaFileName),
-	} {
+	}
+}
+
+// loadFirstLegacyPersona returns the first legacy persona config found, its
+// path, and the legacy field renames applied while reading it.
+func loadFirstLegacyPersona(baseDir string) (*Config, string, []string, error) {
+	for _, path := range legacyPersonaPaths(baseDir) {
 		data, err := os.ReadFile(path)
 		if err != nil {
 			if os.IsNotExist(err) {
 				continue
 			}
-			return nil, fmt.Errorf("failed to read legacy persona config %s: %w", path, err)
+			return nil, "", nil, fmt.Errorf("failed to read legacy persona config %s: %w", path, err)
+		}
+		data, changes, err := migrateLegacyFields(data)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to parse legacy persona config %s: %w", path, err)
 		}
 		var cfg Config
 		if err := json.Unmarshal(data, &cfg); err != nil {
-			return nil, fmt.Errorf("failed to parse legacy persona config %s: %w", path, err)
+			return nil, "", nil, fmt.Errorf("failed to parse legacy persona config %s: %w", path, err)
 		}
-		return &cfg, nil
+		return &cfg, path, changes, nil
 	}
-	return nil, nil
+	return nil, "", nil, nil
 }
 
 func loadLegacyVoiceConfig(baseDir string) (*voice.ConfigFile, error) {
-	path := filepath.Join(baseDir, ClaudeDir, LegacyVoiceConfigName)
+	path := legacyVoiceConfigPath(baseDir)
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -398,3 +544,67 @@ func mergeProviderConfig(dst *VoiceConfig, src *voice.ProviderConfig) {
 		dst.Volume = src.Volume
 	}
 }
+
+// Persona resolution scopes reported by ResolveActive and ApplyEnvToActive.
+const (
+	ScopeProject = "project"
+	ScopeGlobal  = "global"
+	ScopeEnv     = "env"
+)
+
+// ActivePersona describes which persona applies in a directory and where that
+// decision came from.
+type ActivePersona struct {
+	Name     string `json:"name"`
+	Scope    string `json:"scope"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// ResolveActive reports the persona that hooks started in dir would apply from
+// config files, using the same project -> global order as
+// LoadConfigWithFallback but without printing legacy or broken-config
+// warnings. It returns nil when neither config exists. Environment overrides
+// are not applied; see ApplyEnvToActive.
+func ResolveActive(dir, homeDir string) (*ActivePersona, error) {
+	for _, candidate := range []struct {
+		base  string
+		scope string
+	}{
+		{dir, ScopeProject},
+		{homeDir, ScopeGlobal},
+	} {
+		if candidate.base == "" {
+			continue
+		}
+		config, err := loadConfigFile(ConfigPath(candidate.base))
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			continue
+		}
+		active := &ActivePersona{Name: config.Name, Scope: candidate.scope}
+		if name := WorktreePersona(config.Worktrees, dir); name != "" {
+			active.Name, active.Scope = name, ScopeWorktree
+		}
+		if config.Voice != nil {
+			active.Provider = config.Voice.Provider
+		}
+		return active, nil
+	}
+	return nil, nil
+}
+
+// ApplyEnvToActive applies CCPERSONA_PERSONA to a resolved persona. It is kept
+// separate from ResolveActive so file-based results can be cached.
+func ApplyEnvToActive(active *ActivePersona) *ActivePersona {
+	name := PersonaOverride()
+	if name == "" {
+		return active
+	}
+	out := ActivePersona{Name: name, Scope: ScopeEnv}
+	if active != nil {
+		out.Provider = active.Provider
+	}
+	return &out
+}
@@ -2,13 +2,21 @@ package persona
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/daikw/ccpersona/internal/voice"
 )
 
 func TestLoadConfig_UsesUnifiedAgentsConfig(t *testing.T) {
 	tmpDir := t.TempDir()
+	// The global config, since a project config cannot set base_url.
+	t.Setenv("HOME", tmpDir)
 	cfg := &Config{
 		Name: "fable",
 		Voice: &VoiceConfig{
@@ -97,6 +105,81 @@ func TestSaveConfig_WritesUnifiedAgentsConfig(t *testing.T) {
 	}
 }
 
+func TestUpdateConfig_ConcurrentUpdatesAreNotLost(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := SaveConfig(tmpDir, &Config{Name: "default"}); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 10
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := UpdateConfig(tmpDir, func(config *Config) (*Config, error) {
+				config.Worktrees = append(config.Worktrees, WorktreeRule{Branch: fmt.Sprintf("b%d", i), Persona: "p"})
+				return config, nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := LoadConfig(tmpDir)
+	if err != nil || got == nil {
+		t.Fatalf("LoadConfig() = %v, %v", got, err)
+	}
+	if len(got.Worktrees) != workers {
+		t.Errorf("got %d rules, want %d (lost updates)", len(got.Worktrees), workers)
+	}
+
+	// Returning nil leaves the file untouched, and a missing config is nil.
+	missing := t.TempDir()
+	if err := UpdateConfig(missing, func(config *Config) (*Config, error) {
+		if config != nil {
+			t.Errorf("config = %+v, want nil", config)
+		}
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ConfigPath(missing)); !os.IsNotExist(err) {
+		t.Errorf("UpdateConfig wrote a config: %v", err)
+	}
+}
+
+func TestUpdateConfig_KeepsEmptyRetryLadder(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := SaveConfig(tmpDir, &Config{Name: "default", Voice: &VoiceConfig{Provider: "openai", RetryLadder: &[]string{}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateConfig(tmpDir, func(config *Config) (*Config, error) {
+		config.Name = "other"
+		return config, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadConfig(tmpDir)
+	if err != nil || got == nil {
+		t.Fatalf("LoadConfig() = %v, %v", got, err)
+	}
+	if got.Voice.RetryLadder == nil || len(*got.Voice.RetryLadder) != 0 {
+		t.Fatalf("retry_ladder = %v, want an explicit empty list", got.Voice.RetryLadder)
+	}
+	if ladder := got.VoiceBaseConfig().RetryLadder; ladder == nil || len(ladder) != 0 {
+		t.Errorf("voice config ladder = %#v, want empty (retries off)", ladder)
+	}
+
+	got.Voice.RetryLadder = nil
+	if ladder := got.VoiceBaseConfig().RetryLadder; ladder != nil {
+		t.Errorf("voice config ladder = %#v, want nil (default ladder)", ladder)
+	}
+}
+
 func TestValidateConfig_AllowsOpenAICompatibleVoice(t *testing.T) {
 	err := ValidateConfig(&Config{
 		Name: "valid",
@@ -111,8 +194,54 @@ func TestValidateConfig_AllowsOpenAICompatibleVoice(t *testing.T) {
 	}
 }
 
+func TestVoiceProsody(t *testing.T) {
+	pitch := 0.1
+	config := &Config{Name: "metan", Voice: &VoiceConfig{Provider: "voicevox", Speaker: 2, Prosody: &voice.Prosody{PitchScale: &pitch}}}
+	if err := ValidateConfig(config); err != nil {
+		t.Fatalf("ValidateConfig() error = %v", err)
+	}
+	opts := voice.Resolve(config.ToVoiceInput(), config.ToVoiceConfigFile(), "")
+	if opts.Prosody == nil || *opts.Prosody.PitchScale != pitch {
+		t.Errorf("persona prosody should reach the voice options, got %+v", opts.Prosody)
+	}
+
+	pitch = 1
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "voice.prosody.pitch_scale") {
+		t.Errorf("ValidateConfig() should reject an out-of-range pitch, got %v", err)
+	}
+}
+
+func TestPlaybackMode(t *testing.T) {
+	config := &Config{Name: "fable", Voice: &VoiceConfig{Playback: map[string]string{
+		"*":            PlaybackDetach,
+		"Notification": PlaybackWait,
+	}}}
+	tests := []struct {
+		event string
+		want  string
+	}{
+		{"Stop", PlaybackDetach},
+		{"notification", PlaybackWait},
+		{"", PlaybackWait},
+	}
+	for _, tt := range tests {
+		if got := config.PlaybackMode(tt.event); got != tt.want {
+			t.Errorf("PlaybackMode(%q) = %q, want %q", tt.event, got, tt.want)
+		}
+	}
+	if got := (&Config{}).PlaybackMode("Stop"); got != PlaybackWait {
+		t.Errorf("PlaybackMode() without voice config = %q, want wait", got)
+	}
+
+	config.Voice.Playback["Stop"] = "background"
+	if err := ValidateConfig(config); err == nil {
+		t.Error("ValidateConfig() should reject an unknown playback mode")
+	}
+}
+
 func TestMigrateConfig_MergesLegacyPersonaAndVoice(t *testing.T) {
 	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
 	claudeDir := filepath.Join(tmpDir, ClaudeDir)
 	if err := os.MkdirAll(claudeDir, 0755); err != nil {
 		t.Fatal(err)
@@ -142,13 +271,16 @@ func TestMigrateConfig_MergesLegacyPersonaAndVoice(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	path, err := MigrateConfig(tmpDir, false)
+	path, notes, err := MigrateConfig(tmpDir, false)
 	if err != nil {
 		t.Fatalf("MigrateConfig() error = %v", err)
 	}
 	if path != ConfigPath(tmpDir) {
 		t.Fatalf("path = %s, want %s", path, ConfigPath(tmpDir))
 	}
+	if len(notes) != 2 {
+		t.Fatalf("notes = %q, want one per merged file", notes)
+	}
 
 	got, err := LoadConfig(tmpDir)
 	if err != nil {
@@ -164,3 +296,73 @@ func TestMigrateConfig_MergesLegacyPersonaAndVoice(t *testing.T) {
 		t.Fatalf("engines = %#v, want irodori", got.Engines)
 	}
 }
+
+func TestToVoiceInput_AccessibilitySpeed(t *testing.T) {
+	cfg := &Config{Accessibility: &voice.AccessibilityOptions{Enabled: true}}
+	if got := cfg.ToVoiceInput().Speed; got != voice.DefaultAccessibilitySpeed {
+		t.Fatalf("speed = %v, want accessibility default", got)
+	}
+
+	cfg.Voice = &VoiceConfig{Provider: "voicevox", Speed: 1.2}
+	if got := cfg.ToVoiceInput().Speed; got != 1.2 {
+		t.Fatalf("explicit voice speed should win, got %v", got)
+	}
+
+	cfg.Voice.Speed = 0
+	cfg.Accessibility.Speed = 0.6
+	if got := cfg.ToVoiceInput(); got.Speed != 0.6 || got.Provider != "voicevox" {
+		t.Fatalf("unexpected input %+v", got)
+	}
+}
+
+func TestVoiceBaseConfig(t *testing.T) {
+	var nilCfg *Config
+	if nilCfg.VoiceBaseConfig().Accessibility != nil {
+		t.Fatal("nil config should not enable accessibility")
+	}
+	cfg := &Config{Accessibility: &voice.AccessibilityOptions{Enabled: false}}
+	if cfg.VoiceBaseConfig().Accessibility != nil {
+		t.Fatal("disabled profile should not be attached")
+	}
+	cfg.Accessibility.Enabled = true
+	if cfg.VoiceBaseConfig().Accessibility != cfg.Accessibility {
+		t.Fatal("enabled profile should be attached")
+	}
+	if cfg.VoiceBaseConfig().DiffMode {
+		t.Fatal("diff mode should be off without a voice block")
+	}
+	cfg.Voice = &VoiceConfig{DiffMode: true}
+	if !cfg.VoiceBaseConfig().DiffMode {
+		t.Fatal("voice.diff_mode should enable diff mode")
+	}
+	if mode := cfg.VoiceBaseConfig().ReadingMode; mode != voice.ModeShort {
+		t.Fatalf("default reading mode = %q, want short", mode)
+	}
+	cfg.Voice.ReadingMode = voice.ModeAdaptive
+	cfg.Voice.Adaptive = &voice.AdaptiveOptions{ShortChars: 50}
+	if base := cfg.VoiceBaseConfig(); base.ReadingMode != voice.ModeAdaptive || base.Adaptive != cfg.Voice.Adaptive {
+		t.Fatalf("voice.reading_mode/adaptive not applied: %+v", base)
+	}
+	cfg.Voice.UUIDMode = true
+	if !cfg.VoiceBaseConfig().UUIDMode {
+		t.Fatal("voice.uuid_mode not applied")
+	}
+}
+
+func TestAckConfigEffectivePhrases(t *testing.T) {
+	var nilAck *AckConfig
+	if nilAck.IsEnabled() {
+		t.Fatal("nil ack config should be disabled")
+	}
+	if got := nilAck.EffectivePhrases(); !reflect.DeepEqual(got, DefaultAckPhrases) {
+		t.Fatalf("nil phrases = %v", got)
+	}
+	ack := &AckConfig{Enabled: true, Phrases: []string{"  ", "了解、やってみるのだ"}}
+	if got := ack.EffectivePhrases(); !reflect.DeepEqual(got, []string{"了解、やってみるのだ"}) {
+		t.Fatalf("blank phrases should be dropped, got %v", got)
+	}
+	ack.Phrases = []string{""}
+	if got := ack.EffectivePhrases(); !reflect.DeepEqual(got, DefaultAckPhrases) {
+		t.Fatalf("all-blank phrases should fall back to defaults, got %v", got)
+	}
+}
@@ -0,0 +1,35 @@
+package persona
+
+import (
+	"os"
+	"strings"
+)
+
+// EnvPersona names the environment variable that forces the persona for the
+// current process (for example one terminal or one CI job) without touching
+// config files.
+const EnvPersona = "CCPERSONA_PERSONA"
+
+// PersonaOverride returns the persona name forced by CCPERSONA_PERSONA, or ""
+// when unset.
+func PersonaOverride() string {
+	return strings.TrimSpace(os.Getenv(EnvPersona))
+}
+
+// ApplyEnvOverrides returns config with the CCPERSONA_PROFILE profile and
+// CCPERSONA_PERSONA applied. When no config file exists the persona override
+// still selects a persona on top of the default config. The input config is
+// never modified, so callers that later save it do not persist the override.
+func ApplyEnvOverrides(config *Config) *Config {
+	config = applyProfileOverride(config)
+	name := PersonaOverride()
+	if name == "" {
+		return config
+	}
+	var out Config
+	if config != nil {
+		out = *config
+	}
+	out.Name = name
+	return &out
+}
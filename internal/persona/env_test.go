@@ -0,0 +1,48 @@
+package persona
+
+import "testing"
+
+func TestApplyEnvOverrides(t *testing.T) {
+	original := &Config{Name: "fable", CustomInstructions: "keep"}
+
+	t.Setenv(EnvPersona, "")
+	if got := ApplyEnvOverrides(original); got != original {
+		t.Error("config should be returned unchanged without an override")
+	}
+
+	t.Setenv(EnvPersona, "strict")
+	got := ApplyEnvOverrides(original)
+	if got.Name != "strict" || got.CustomInstructions != "keep" {
+		t.Errorf("unexpected override result: %+v", got)
+	}
+	if original.Name != "fable" {
+		t.Error("input config must not be modified")
+	}
+
+	if got := ApplyEnvOverrides(nil); got == nil || got.Name != "strict" {
+		t.Errorf("override should apply without a config file, got %+v", got)
+	}
+}
+
+func TestApplyEnvToActive(t *testing.T) {
+	t.Setenv(EnvPersona, "strict")
+	got := ApplyEnvToActive(&ActivePersona{Name: "fable", Scope: ScopeProject, Provider: "openai"})
+	if got.Name != "strict" || got.Scope != ScopeEnv || got.Provider != "openai" {
+		t.Errorf("unexpected active persona: %+v", got)
+	}
+}
+
+func TestLoadConfigWithFallback_EnvOverride(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(t.TempDir())
+	t.Setenv(EnvPersona, "from-env")
+
+	config, err := LoadConfigWithFallback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config == nil || config.Name != "from-env" {
+		t.Errorf("LoadConfigWithFallback() = %+v, want persona from env", config)
+	}
+}
@@ -0,0 +1,20 @@
+package persona
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// NotExistError reports a persona name that has no persona file. It matches
+// fs.ErrNotExist.
+type NotExistError struct {
+	Name string
+}
+
+func (e *NotExistError) Error() string {
+	return fmt.Sprintf("persona '%s' does not exist", e.Name)
+}
+
+func (e *NotExistError) Is(target error) bool {
+	return target == fs.ErrNotExist
+}
@@ -0,0 +1,125 @@
+package persona
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EnvClaudeEnvFile names the file Claude Code passes to SessionStart hooks.
+// Export statements appended to it are sourced before every Bash command of
+// the session.
+const EnvClaudeEnvFile = "CLAUDE_ENV_FILE"
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// allowedEnvNames are the variables a persona may set: identity and locale
+// settings that change how tools label and format their output, never which
+// programs run or what code they load. Anything else could be used by an
+// imported persona to run code in the session.
+var allowedEnvNames = map[string]bool{
+	"GIT_AUTHOR_NAME":     true,
+	"GIT_AUTHOR_EMAIL":    true,
+	"GIT_COMMITTER_NAME":  true,
+	"GIT_COMMITTER_EMAIL": true,
+	"LANG":                true,
+	"LANGUAGE":            true,
+	"TZ":                  true,
+}
+
+// allowedEnvPrefixes are prefixes of allowed variables: locale categories,
+// and PERSONA_ for values of the persona's own that no tool reads by default.
+var allowedEnvPrefixes = []string{"LC_", "PERSONA_"}
+
+// ParseEnv returns the environment variables declared under `env:` in the
+// front matter of persona content. Invalid names and names outside the
+// allowlist are skipped with a warning.
+func ParseEnv(content string) (map[string]string, error) {
+	fm, err := parseFrontMatter(content)
+	if err != nil {
+		return nil, err
+	}
+	env := map[string]string{}
+	for name, value := range fm.Env {
+		if !envName.MatchString(name) || !envAllowed(name) {
+			log.Warn().Str("name", name).Msg("Ignoring persona environment variable")
+			continue
+		}
+		env[name] = value
+	}
+	return env, nil
+}
+
+func envAllowed(name string) bool {
+	if allowedEnvNames[name] {
+		return true
+	}
+	for _, prefix := range allowedEnvPrefixes {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadPersonaEnv returns the environment variables the persona declares.
+func (m *Manager) ReadPersonaEnv(name string) (map[string]string, error) {
+	raw, err := m.ReadPersona(name)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEnv(raw)
+}
+
+// FormatEnvExports renders env as POSIX shell export statements, sorted by
+// name, with every value single-quoted.
+func FormatEnvExports(env map[string]string) string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "export %s=%s\n", name, shellQuote(env[name]))
+	}
+	return b.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// exportPersonaEnv appends the persona's environment variables to the
+// session env file Claude Code passes in CLAUDE_ENV_FILE. Without that file
+// nothing is exported; `ccpersona persona env` prints the same statements for
+// other agents and shells.
+func exportPersonaEnv(manager *Manager, name string) {
+	envFile := os.Getenv(EnvClaudeEnvFile)
+	if envFile == "" {
+		return
+	}
+	env, err := manager.ReadPersonaEnv(name)
+	if err != nil {
+		log.Warn().Err(err).Str("persona", name).Msg("Failed to read persona environment")
+		return
+	}
+	if len(env) == 0 {
+		return
+	}
+	f, err := os.OpenFile(envFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to open session env file")
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := f.WriteString(FormatEnvExports(env)); err != nil {
+		log.Warn().Err(err).Msg("Failed to write session env file")
+	}
+}
@@ -0,0 +1,81 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	content := "---\n" +
+		"description: reviewer\n" +
+		"env:\n" +
+		"  GIT_AUTHOR_NAME: Zundamon\n" +
+		"  LANG: ja_JP.UTF-8\n" +
+		"  LC_TIME: C\n" +
+		"  PERSONA_RETRIES: 3\n" +
+		"  PATH: /tmp/evil\n" +
+		"  LD_PRELOAD: /tmp/evil.so\n" +
+		"  GIT_CONFIG_PARAMETERS: \"'core.pager=sh -c id'\"\n" +
+		"  PAGER: /tmp/evil\n" +
+		"  lang: C\n" +
+		"  bad-name: x\n" +
+		"---\n" +
+		"# 人格\n"
+	env, err := ParseEnv(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"GIT_AUTHOR_NAME": "Zundamon", "LANG": "ja_JP.UTF-8", "LC_TIME": "C", "PERSONA_RETRIES": "3"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("ParseEnv = %v, want %v", env, want)
+	}
+
+	if env, err := ParseEnv("# 人格\nno front matter\n"); err != nil || len(env) != 0 {
+		t.Errorf("ParseEnv without front matter = %v, %v", env, err)
+	}
+	if _, err := ParseEnv("---\nenv: [\n---\n"); err == nil {
+		t.Error("expected an error for invalid front matter")
+	}
+}
+
+func TestFormatEnvExports(t *testing.T) {
+	got := FormatEnvExports(map[string]string{"B": "it's", "A": "$HOME `x`"})
+	want := "export A='$HOME `x`'\nexport B='it'\\''s'\n"
+	if got != want {
+		t.Errorf("FormatEnvExports = %q, want %q", got, want)
+	}
+}
+
+func TestExportPersonaEnv(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	personasDir := filepath.Join(home, ".claude", "personas")
+	if err := os.MkdirAll(personasDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nenv:\n  LANG: ja_JP.UTF-8\n---\n# 人格: test\n"
+	if err := os.WriteFile(filepath.Join(personasDir, "test.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manager, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envFile := filepath.Join(home, "session.env")
+	if err := os.WriteFile(envFile, []byte("export EXISTING=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvClaudeEnvFile, envFile)
+	exportPersonaEnv(manager, "test")
+
+	data, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "export EXISTING=1\nexport LANG='ja_JP.UTF-8'\n"; got != want {
+		t.Errorf("env file = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,209 @@
+package persona
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+// Policies for how often UserPromptSubmit hooks print the persona again.
+const (
+	// ApplyEveryPrompt prints it on every prompt, as before the setting.
+	ApplyEveryPrompt = "every_prompt"
+	// ApplySessionStart prints it once per session: at SessionStart, or on
+	// the first prompt when no SessionStart hook is installed.
+	ApplySessionStart = "session_start"
+	// ApplyEveryNPrompts prints it on the first prompt and then every Nth.
+	ApplyEveryNPrompts = "every_n_prompts"
+	// ApplyOnChange prints it when it differs from what the session last
+	// got, such as after `config set-persona` or a persona edit.
+	ApplyOnChange = "on_change"
+)
+
+// DefaultApplyEvery is N for every_n_prompts when none is configured.
+const DefaultApplyEvery = 5
+
+// sessionStateTTL is how long an idle session's state is kept.
+const sessionStateTTL = 24 * time.Hour
+
+// ApplyConfig sets how often prompt hooks print the persona. SessionStart
+// always prints it.
+type ApplyConfig struct {
+	Policy string `json:"policy,omitempty"`
+	// Every is N for every_n_prompts (default 5).
+	Every int `json:"every,omitempty"`
+}
+
+// EffectivePolicy returns the configured policy or every_prompt; safe on nil.
+func (a *ApplyConfig) EffectivePolicy() string {
+	if a == nil || a.Policy == "" {
+		return ApplyEveryPrompt
+	}
+	return a.Policy
+}
+
+func (a *ApplyConfig) every() int {
+	if a == nil || a.Every <= 0 {
+		return DefaultApplyEvery
+	}
+	return a.Every
+}
+
+// Validate checks the policy name.
+func (a *ApplyConfig) Validate() error {
+	if a == nil {
+		return nil
+	}
+	switch a.EffectivePolicy() {
+	case ApplyEveryPrompt, ApplySessionStart, ApplyEveryNPrompts, ApplyOnChange:
+	default:
+		return fmt.Errorf("apply.policy must be %q, %q, %q, or %q, got %q",
+			ApplyEveryPrompt, ApplySessionStart, ApplyEveryNPrompts, ApplyOnChange, a.Policy)
+	}
+	if a.Every < 0 {
+		return fmt.Errorf("apply.every must not be negative")
+	}
+	return nil
+}
+
+// SessionState is what the session state store remembers about a session.
+type SessionState struct {
+	// Hash is the SHA-256 of the persona output the session last got.
+	Hash string `json:"hash,omitempty"`
+	// Persona is the persona last applied.
+	Persona string `json:"persona,omitempty"`
+	// Prompts counts prompts since the persona was last applied.
+	Prompts int       `json:"prompts"`
+	Applied time.Time `json:"applied"`
+}
+
+// SessionStore keeps per-session state in the temp directory, where hooks
+// from every process of a session find it. Entries idle for a day expire.
+type SessionStore struct {
+	dir string
+	now func() time.Time
+}
+
+// NewSessionStore returns the store at $TMPDIR/ccpersona-sessions.
+func NewSessionStore() *SessionStore {
+	return &SessionStore{dir: filepath.Join(os.TempDir(), "ccpersona-sessions"), now: time.Now}
+}
+
+func (s *SessionStore) path(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:12])+".json")
+}
+
+// Load returns the state of a session, or nil when it has none.
+func (s *SessionStore) Load(sessionID string) *SessionState {
+	data, err := os.ReadFile(s.path(sessionID))
+	if err != nil {
+		return nil
+	}
+	var state SessionState
+	if json.Unmarshal(data, &state) != nil {
+		return nil
+	}
+	return &state
+}
+
+// Save writes the state of a session and expires idle ones.
+func (s *SessionStore) Save(sessionID string, state *SessionState) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	s.expire()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(s.path(sessionID), data, 0o600)
+}
+
+func (s *SessionStore) expire() {
+	entries, _ := os.ReadDir(s.dir)
+	now := s.now()
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err == nil && !entry.IsDir() && now.Sub(info.ModTime()) > sessionStateTTL {
+			_ = os.Remove(filepath.Join(s.dir, entry.Name()))
+		}
+	}
+}
+
+// applyDecision settles whether a prompt hook prints the persona, in two
+// steps: before rendering for policies that do not need the content, so a
+// skipped prompt costs no memory refresh, and after it for on_change.
+type applyDecision struct {
+	store     *SessionStore
+	sessionID string
+	policy    *ApplyConfig
+	// prompt is set for prompt hooks; SessionStart always applies.
+	prompt bool
+	state  *SessionState
+}
+
+// newApplyDecision loads the session's state. Without a session ID there is
+// nothing to remember, so every call applies.
+func newApplyDecision(store *SessionStore, sessionID string, policy *ApplyConfig, prompt bool) *applyDecision {
+	d := &applyDecision{store: store, sessionID: sessionID, policy: policy, prompt: prompt}
+	if sessionID != "" {
+		d.state = store.Load(sessionID)
+	}
+	return d
+}
+
+// skipBeforeRender reports whether the prompt is skipped without looking at
+// the content.
+func (d *applyDecision) skipBeforeRender() bool {
+	if !d.prompt || d.sessionID == "" || d.state == nil {
+		return false
+	}
+	switch d.policy.EffectivePolicy() {
+	case ApplySessionStart:
+		return true
+	case ApplyEveryNPrompts:
+		return d.state.Prompts+1 < d.policy.every()
+	}
+	return false
+}
+
+// skipAfterRender reports whether a prompt with this content is skipped.
+func (d *applyDecision) skipAfterRender(hash string) bool {
+	return d.prompt && d.state != nil && d.policy.EffectivePolicy() == ApplyOnChange && d.state.Hash == hash
+}
+
+// record saves the outcome: applied content resets the prompt count, and
+// a skipped prompt adds to it. Only what a policy reads later is written, so
+// prompt hooks under every_prompt and skipped prompts that are not counted
+// leave the store alone.
+func (d *applyDecision) record(applied bool, name, hash string) {
+	policy := d.policy.EffectivePolicy()
+	switch {
+	case d.sessionID == "":
+		return
+	case d.prompt && policy == ApplyEveryPrompt:
+		return
+	case !applied && policy != ApplyEveryNPrompts:
+		return
+	}
+	state := d.state
+	if state == nil {
+		state = &SessionState{}
+	}
+	if applied {
+		*state = SessionState{Hash: hash, Persona: name, Applied: d.store.now().UTC()}
+	} else {
+		state.Prompts++
+	}
+	if err := d.store.Save(d.sessionID, state); err != nil {
+		log.Debug().Err(err).Msg("Failed to save session state")
+	}
+}
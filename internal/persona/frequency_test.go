@@ -0,0 +1,103 @@
+package persona
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// simulateApply runs one hook through the decision as applyPersona does and
+// reports whether the persona was printed.
+func simulateApply(store *SessionStore, policy *ApplyConfig, prompt bool, content string) bool {
+	d := newApplyDecision(store, "session-1", policy, prompt)
+	if d.skipBeforeRender() {
+		d.record(false, "", "")
+		return false
+	}
+	hash := contentHash(content)
+	if d.skipAfterRender(hash) {
+		d.record(false, "zundamon", hash)
+		return false
+	}
+	d.record(true, "zundamon", hash)
+	return true
+}
+
+func TestApplyPolicies(t *testing.T) {
+	type step struct {
+		prompt  bool
+		content string
+		want    bool
+	}
+	start := step{false, "a", true}
+	tests := []struct {
+		name   string
+		policy *ApplyConfig
+		steps  []step
+	}{
+		{"default every prompt", nil, []step{start, {true, "a", true}, {true, "a", true}}},
+		{"session start", &ApplyConfig{Policy: ApplySessionStart}, []step{start, {true, "a", false}, {true, "b", false}, start}},
+		{"session start without hook", &ApplyConfig{Policy: ApplySessionStart}, []step{{true, "a", true}, {true, "a", false}}},
+		{"every 3 prompts", &ApplyConfig{Policy: ApplyEveryNPrompts, Every: 3}, []step{
+			{true, "a", true}, {true, "a", false}, {true, "a", false}, {true, "a", true}, {true, "a", false},
+		}},
+		{"on change", &ApplyConfig{Policy: ApplyOnChange}, []step{start, {true, "a", false}, {true, "b", true}, {true, "b", false}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &SessionStore{dir: t.TempDir(), now: time.Now}
+			for i, s := range tt.steps {
+				if got := simulateApply(store, tt.policy, s.prompt, s.content); got != s.want {
+					t.Fatalf("step %d (prompt=%v, content=%q): applied = %v, want %v", i, s.prompt, s.content, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyWithoutSessionID(t *testing.T) {
+	store := &SessionStore{dir: t.TempDir(), now: time.Now}
+	d := newApplyDecision(store, "", &ApplyConfig{Policy: ApplySessionStart}, true)
+	if d.skipBeforeRender() || d.skipAfterRender("x") {
+		t.Error("hooks without a session ID should always apply")
+	}
+	d.record(true, "zundamon", "x")
+	if entries, _ := os.ReadDir(store.dir); len(entries) != 0 {
+		t.Errorf("nothing should be stored without a session ID, got %d files", len(entries))
+	}
+}
+
+func TestSessionStoreExpires(t *testing.T) {
+	now := time.Now()
+	store := &SessionStore{dir: t.TempDir(), now: func() time.Time { return now }}
+	if err := store.Save("old", &SessionState{Hash: "h"}); err != nil {
+		t.Fatal(err)
+	}
+	past := now.Add(-2 * sessionStateTTL)
+	if err := os.Chtimes(store.path("old"), past, past); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save("new", &SessionState{Hash: "h"}); err != nil {
+		t.Fatal(err)
+	}
+	if store.Load("old") != nil {
+		t.Error("idle session state should expire")
+	}
+	if store.Load("new") == nil {
+		t.Error("fresh session state should be kept")
+	}
+}
+
+func TestApplyConfigValidate(t *testing.T) {
+	for _, policy := range []string{"", ApplyEveryPrompt, ApplySessionStart, ApplyEveryNPrompts, ApplyOnChange} {
+		if err := (&ApplyConfig{Policy: policy}).Validate(); err != nil {
+			t.Errorf("%q: %v", policy, err)
+		}
+	}
+	if err := (&ApplyConfig{Policy: "sometimes"}).Validate(); err == nil {
+		t.Error("unknown policy should fail")
+	}
+	if err := ValidateConfig(&Config{Name: "x", Apply: &ApplyConfig{Policy: ApplyEveryNPrompts, Every: -1}}); err == nil {
+		t.Error("negative every should fail config validation")
+	}
+}
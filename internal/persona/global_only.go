@@ -0,0 +1,310 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/daikw/ccpersona/internal/notify"
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+)
+
+var warnedGlobalOnly sync.Map
+
+// globalOnlyVoice is a voice setting read from the global config only,
+// because it runs a program, writes files, or sends data to a host of its
+// choosing, and a project config comes with any cloned repository.
+type globalOnlyVoice struct {
+	key string
+	// take replaces the setting in v with the one in global (nil when the
+	// global config has none) and reports whether v set a different value.
+	take func(v, global *VoiceConfig) bool
+}
+
+var globalOnlyVoiceSettings = []globalOnlyVoice{
+	{"voice.adaptive.summary_command", func(v, global *VoiceConfig) bool {
+		var own, want string
+		if v.Adaptive != nil {
+			own = v.Adaptive.SummaryCommand
+		}
+		if global != nil && global.Adaptive != nil {
+			want = global.Adaptive.SummaryCommand
+		}
+		if own == want {
+			return false
+		}
+		adaptive := voice.AdaptiveOptions{}
+		if v.Adaptive != nil {
+			adaptive = *v.Adaptive
+		}
+		adaptive.SummaryCommand = want
+		v.Adaptive = &adaptive
+		return own != ""
+	}},
+	{"voice.recent.dir", func(v, global *VoiceConfig) bool {
+		var own, want string
+		if v.Recent != nil {
+			own = v.Recent.Dir
+		}
+		if global != nil && global.Recent != nil {
+			want = global.Recent.Dir
+		}
+		if own == want || v.Recent == nil {
+			return false
+		}
+		recent := *v.Recent
+		recent.Dir = want
+		v.Recent = &recent
+		return own != ""
+	}},
+	// The caption file is replaced on every update. Without a global path
+	// the project's caption is dropped, since a caption needs one.
+	{"voice.caption.path", func(v, global *VoiceConfig) bool {
+		if v.Caption == nil {
+			return false
+		}
+		own, want := v.Caption.Path, ""
+		if global != nil && global.Caption != nil {
+			want = global.Caption.Path
+		}
+		if own == want {
+			return false
+		}
+		if want == "" {
+			v.Caption = nil
+			return true
+		}
+		caption := *v.Caption
+		caption.Path = want
+		v.Caption = &caption
+		return own != ""
+	}},
+	// Confirming the output device lets others hear everything spoken.
+	{"voice.output.confirm", func(v, global *VoiceConfig) bool {
+		if v.Output == nil {
+			return false
+		}
+		own := v.Output.Confirm
+		want := global != nil && global.Output != nil && global.Output.Confirm
+		if own == want {
+			return false
+		}
+		output := *v.Output
+		output.Confirm = want
+		v.Output = &output
+		return own
+	}},
+	// The avatar daemon receives everything spoken.
+	{"voice.avatar.addr", func(v, global *VoiceConfig) bool {
+		if v.Avatar == nil {
+			return false
+		}
+		own, want := v.Avatar.Addr, ""
+		if global != nil && global.Avatar != nil {
+			want = global.Avatar.Addr
+		}
+		if own == want {
+			return false
+		}
+		avatar := *v.Avatar
+		avatar.Addr = want
+		v.Avatar = &avatar
+		return own != ""
+	}},
+	// Cloud providers send their API key to the endpoint.
+	{"voice.base_url", func(v, global *VoiceConfig) bool {
+		want := ""
+		if global != nil {
+			want = global.BaseURL
+		}
+		return takeGlobal(&v.BaseURL, want)
+	}},
+}
+
+// globalOnlyNotify is a notification setting read from the global config
+// only; see globalOnlyVoice.
+type globalOnlyNotify struct {
+	key  string
+	take func(n, global *notify.Config) bool
+}
+
+var globalOnlyNotifySettings = []globalOnlyNotify{
+	// Trigger actions open URLs and post messages to webhooks.
+	{"notifications.triggers", func(n, global *notify.Config) bool {
+		var want []notify.Trigger
+		if global != nil {
+			want = global.Triggers
+		}
+		return takeGlobal(&n.Triggers, want)
+	}},
+	// The broker receives hook text, and password_env can name any
+	// environment variable.
+	{"notifications.mqtt", func(n, global *notify.Config) bool {
+		var want *notify.MQTTConfig
+		if global != nil {
+			want = global.MQTT
+		}
+		return takeGlobal(&n.MQTT, want)
+	}},
+	// The hub receives hook text, with token_env naming any environment
+	// variable as its bearer token.
+	{"notifications.hub", func(n, global *notify.Config) bool {
+		var want *notify.HubConfig
+		if global != nil {
+			want = global.Hub
+		}
+		return takeGlobal(&n.Hub, want)
+	}},
+	// The bot receives hook text, token_env can name any environment
+	// variable, and chat_id picks who may send commands.
+	{"notifications.telegram", func(n, global *notify.Config) bool {
+		var want *notify.TelegramConfig
+		if global != nil {
+			want = global.Telegram
+		}
+		return takeGlobal(&n.Telegram, want)
+	}},
+}
+
+// takeGlobal sets *own to want and reports whether own held a value of its
+// own that was dropped.
+func takeGlobal[T any](own *T, want T) bool {
+	if reflect.DeepEqual(*own, want) {
+		return false
+	}
+	v := reflect.ValueOf(own).Elem()
+	ignored := !v.IsZero()
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Map {
+		ignored = v.Len() > 0
+	}
+	*own = want
+	return ignored
+}
+
+// restrictProjectConfig replaces the global-only settings of the project
+// config under baseDir, including those in its profiles, platforms, and
+// personas entries, with the global config's, and logs each value it
+// ignored. The global config itself is returned unchanged.
+func restrictProjectConfig(config *Config, baseDir string) *Config {
+	if config == nil {
+		return nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return restrictConfig(config, nil, ConfigPath(baseDir))
+	}
+	if sameFile(ConfigPath(baseDir), ConfigPath(homeDir)) {
+		return config
+	}
+	global, err := loadConfigFile(ConfigPath(homeDir))
+	if err != nil {
+		global = nil
+	}
+	return restrictConfig(config, global, ConfigPath(baseDir))
+}
+
+func restrictConfig(config, global *Config, path string) *Config {
+	out := *config
+	var globalVoice *VoiceConfig
+	var globalNotify *notify.Config
+	if global != nil {
+		globalVoice, globalNotify = global.Voice, global.Notifications
+	}
+	out.Voice = restrictVoice(config.Voice, globalVoice, path, "")
+	out.Notifications = restrictNotifications(config.Notifications, globalNotify, path, "")
+	if config.Profiles != nil {
+		out.Profiles = make(map[string]*Profile, len(config.Profiles))
+		for name, profile := range config.Profiles {
+			if profile != nil {
+				p := *profile
+				p.Voice = restrictVoice(profile.Voice, nil, path, "profiles."+name+".")
+				p.Notifications = restrictNotifications(profile.Notifications, nil, path, "profiles."+name+".")
+				profile = &p
+			}
+			out.Profiles[name] = profile
+		}
+	}
+	if config.Platforms != nil {
+		out.Platforms = make(map[string]*PlatformConfig, len(config.Platforms))
+		for name, entry := range config.Platforms {
+			if entry != nil {
+				e := *entry
+				e.Voice = restrictVoice(entry.Voice, nil, path, "platforms."+name+".")
+				entry = &e
+			}
+			out.Platforms[name] = entry
+		}
+	}
+	if config.Personas != nil {
+		out.Personas = make(map[string]*PersonaConfig, len(config.Personas))
+		for name, entry := range config.Personas {
+			if entry != nil {
+				e := *entry
+				e.Voice = restrictVoice(entry.Voice, nil, path, "personas."+name+".")
+				entry = &e
+			}
+			out.Personas[name] = entry
+		}
+	}
+	return &out
+}
+
+// restrictVoice applies the global-only voice settings to a copy of v.
+// Overlays pass a nil global, which drops their own values so the
+// top-level setting applies.
+func restrictVoice(v, global *VoiceConfig, path, prefix string) *VoiceConfig {
+	if v == nil {
+		return nil
+	}
+	out := *v
+	for _, setting := range globalOnlyVoiceSettings {
+		if setting.take(&out, global) {
+			warnGlobalOnly(path, prefix+setting.key)
+		}
+	}
+	return &out
+}
+
+// restrictNotifications is restrictVoice for notification settings. A
+// project without notification settings gets the global-only ones of the
+// global config, so they keep working inside the project.
+func restrictNotifications(n, global *notify.Config, path, prefix string) *notify.Config {
+	if n == nil && global == nil {
+		return nil
+	}
+	out := &notify.Config{}
+	if n != nil {
+		copied := *n
+		out = &copied
+	}
+	for _, setting := range globalOnlyNotifySettings {
+		if setting.take(out, global) {
+			warnGlobalOnly(path, prefix+setting.key)
+		}
+	}
+	if n == nil && reflect.DeepEqual(*out, notify.Config{}) {
+		return nil
+	}
+	return out
+}
+
+func warnGlobalOnly(path, key string) {
+	if _, loaded := warnedGlobalOnly.LoadOrStore(path+"\x00"+key, true); loaded {
+		return
+	}
+	log.Warn().Str("config", path).Str("setting", key).Msg("Ignoring a setting the project config cannot choose; set it in the global config")
+}
+
+func sameFile(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
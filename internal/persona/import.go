@@ -0,0 +1,212 @@
+package persona
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/daikw/ccpersona/internal/trust"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxImportBytes caps what an import downloads or reads. Content between
+// MaxPersonaBytes and this limit is flagged by ScanPersona; content beyond it
+// is refused outright.
+const MaxImportBytes = 1 << 20
+
+// IsRemoteSource reports whether source is an http(s) URL rather than a path.
+func IsRemoteSource(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// MaxSignatureBytes caps a detached signature download; minisign
+// signatures are a few hundred bytes.
+const MaxSignatureBytes = 4 << 10
+
+// SignatureSuffix is appended to a persona source or file to locate its
+// detached minisign signature.
+const SignatureSuffix = ".minisig"
+
+// FetchPersona reads persona markdown from an http(s) URL or a local file.
+func FetchPersona(ctx context.Context, source string) ([]byte, error) {
+	data, err := fetch(ctx, source, MaxImportBytes)
+	if errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("%s not found", source)
+	}
+	return data, err
+}
+
+// SignatureSource returns where the detached signature for source lives:
+// the same file or URL path with SignatureSuffix appended.
+func SignatureSource(source string) string {
+	if IsRemoteSource(source) {
+		if u, err := url.Parse(source); err == nil {
+			u.Path += SignatureSuffix
+			u.RawPath = ""
+			return u.String()
+		}
+	}
+	return source + SignatureSuffix
+}
+
+// FetchSignature reads the detached signature for a persona source. It
+// returns nil without error when the signature does not exist.
+func FetchSignature(ctx context.Context, source string) ([]byte, error) {
+	data, err := fetch(ctx, source, MaxSignatureBytes)
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	return data, err
+}
+
+var errNotFound = errors.New("not found")
+
+func fetch(ctx context.Context, source string, limit int64) ([]byte, error) {
+	var r io.Reader
+	if IsRemoteSource(source) {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("download %s: %w", source, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, errNotFound
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("download %s: status %d", source, resp.StatusCode)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, errNotFound
+			}
+			return nil, fmt.Errorf("failed to open %s: %w", source, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is larger than %d bytes; refusing to import", source, limit)
+	}
+	return data, nil
+}
+
+// ImportName derives a persona name from a URL or path: the last path
+// element without its .md extension.
+func ImportName(source string) string {
+	base := filepath.Base(source)
+	if IsRemoteSource(source) {
+		if u, err := url.Parse(source); err == nil {
+			base = path.Base(u.Path)
+		}
+	}
+	name := strings.TrimSuffix(base, ".md")
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// ImportPersona writes content as the named persona in the personas
+// directory and returns its path. A non-nil signature is kept next to it as
+// <name>.md.minisig so the persona can be verified again later; a stale
+// signature from an earlier import is removed. An existing persona is
+// replaced only with force. Callers are expected to have run ScanPersona
+// first.
+func (m *Manager) ImportPersona(name string, content, signature []byte, force bool) (string, error) {
+	if err := validatePersonaName(name); err != nil {
+		return "", err
+	}
+	if m.PersonaExists(name) && !force {
+		return "", fmt.Errorf("persona '%s' already exists (use --force to replace it)", name)
+	}
+	if err := os.MkdirAll(m.personasDir, DirPermission); err != nil {
+		return "", fmt.Errorf("failed to create personas directory: %w", err)
+	}
+
+	path := m.GetPersonaPath(name)
+	if err := fsutil.WriteFile(path, content, FilePermission); err != nil {
+		return "", fmt.Errorf("failed to write persona file: %w", err)
+	}
+	sigPath := path + SignatureSuffix
+	if signature != nil {
+		if err := fsutil.WriteFile(sigPath, signature, FilePermission); err != nil {
+			return "", fmt.Errorf("failed to write persona signature: %w", err)
+		}
+	} else if err := os.Remove(sigPath); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to remove stale persona signature: %w", err)
+	}
+	log.Debug().Str("persona", name).Str("path", path).Msg("Imported persona")
+	return path, nil
+}
+
+// ReadPersonaSignature returns the detached signature stored next to a
+// persona, or nil if the persona is unsigned.
+func (m *Manager) ReadPersonaSignature(name string) ([]byte, error) {
+	if err := validatePersonaName(name); err != nil {
+		return nil, err
+	}
+	path, ok := m.resolvePersonaPath(name)
+	if !ok {
+		return nil, &NotExistError{Name: name}
+	}
+	data, err := os.ReadFile(path + SignatureSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read persona signature: %w", err)
+	}
+	return data, nil
+}
+
+// checkSignature verifies raw, the content of an installed persona, against
+// the signature stored next to it before the persona is applied. A persona
+// that no longer matches its signature was changed after signing and is
+// refused. A signature from a key no longer trusted is logged, as is an
+// unsigned persona once the user trusts any key.
+func (m *Manager) checkSignature(store *trust.Store, name, raw string) error {
+	signature, err := m.ReadPersonaSignature(name)
+	if err != nil {
+		return err
+	}
+	if signature == nil {
+		if keys, err := store.Keys(); err == nil && len(keys) > 0 {
+			log.Warn().Str("persona", name).Msg("Applying an unsigned persona; import it from a signed bundle to verify it")
+		}
+		return nil
+	}
+	_, _, err = store.Verify([]byte(raw), signature)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, trust.ErrUntrustedKey):
+		log.Warn().Err(err).Str("persona", name).Msg("Applying a persona signed by an untrusted key")
+		return nil
+	default:
+		return fmt.Errorf("persona %q does not match its signature, so it was changed after signing; re-import it, or delete %s%s to use it unsigned: %w",
+			name, name+".md", SignatureSuffix, err)
+	}
+}
@@ -0,0 +1,177 @@
+package persona
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/trust"
+)
+
+func TestImportName(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/personas/zundamon.md?raw=1": "zundamon",
+		"./examples/personas/strict_engineer.md":         "strict_engineer",
+		"calm":                                           "calm",
+		"https://example.com/":                           "",
+	}
+	for source, want := range tests {
+		if got := ImportName(source); got != want {
+			t.Errorf("ImportName(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestSignatureSource(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/p/calm.md?ref=main": "https://example.com/p/calm.md.minisig?ref=main",
+		"personas/calm.md":                       "personas/calm.md.minisig",
+	}
+	for source, want := range tests {
+		if got := SignatureSource(source); got != want {
+			t.Errorf("SignatureSource(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestFetchPersona(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok.md":
+			fmt.Fprint(w, "# 人格: ok\n")
+		case "/ok.md.minisig":
+			fmt.Fprint(w, "signature")
+		case "/huge.md":
+			w.Write(make([]byte, MaxImportBytes+1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	data, err := FetchPersona(context.Background(), srv.URL+"/ok.md")
+	if err != nil || string(data) != "# 人格: ok\n" {
+		t.Fatalf("FetchPersona = %q, %v", data, err)
+	}
+	if _, err := FetchPersona(context.Background(), srv.URL+"/huge.md"); err == nil {
+		t.Error("expected oversized download to fail")
+	}
+	if _, err := FetchPersona(context.Background(), srv.URL+"/missing.md"); err == nil {
+		t.Error("expected 404 to fail")
+	}
+
+	if sig, err := FetchSignature(context.Background(), srv.URL+"/ok.md"+SignatureSuffix); err != nil || string(sig) != "signature" {
+		t.Fatalf("FetchSignature = %q, %v", sig, err)
+	}
+	if sig, err := FetchSignature(context.Background(), srv.URL+"/missing.md"+SignatureSuffix); err != nil || sig != nil {
+		t.Fatalf("missing signature = %q, %v; want nil, nil", sig, err)
+	}
+
+	local := filepath.Join(t.TempDir(), "local.md")
+	if err := os.WriteFile(local, []byte("# local\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := FetchPersona(context.Background(), local); err != nil || string(data) != "# local\n" {
+		t.Fatalf("FetchPersona(local) = %q, %v", data, err)
+	}
+}
+
+func TestImportPersona(t *testing.T) {
+	dir := t.TempDir()
+	m := &Manager{personasDir: filepath.Join(dir, "personas")}
+
+	path, err := m.ImportPersona("calm", []byte("# calm\n"), []byte("sig"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# calm\n" {
+		t.Fatalf("written content = %q", data)
+	}
+	if _, err := m.ImportPersona("calm", []byte("# other\n"), nil, false); err == nil {
+		t.Fatal("expected existing persona to be kept without force")
+	}
+	if sig, err := m.ReadPersonaSignature("calm"); err != nil || string(sig) != "sig" {
+		t.Fatalf("ReadPersonaSignature = %q, %v", sig, err)
+	}
+	if _, err := m.ImportPersona("calm", []byte("# other\n"), nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if sig, err := m.ReadPersonaSignature("calm"); err != nil || sig != nil {
+		t.Fatalf("stale signature kept after unsigned re-import: %q, %v", sig, err)
+	}
+	if _, err := m.ImportPersona("../escape", []byte("x"), nil, true); err == nil {
+		t.Fatal("expected invalid name to be rejected")
+	}
+}
+
+// testKey signs content in minisign's legacy format.
+type testKey struct {
+	keyNum []byte
+	priv   ed25519.PrivateKey
+}
+
+func newTestKey(seed byte) *testKey {
+	seedBytes := make([]byte, ed25519.SeedSize)
+	seedBytes[0] = seed
+	return &testKey{keyNum: []byte{seed, 2, 3, 4, 5, 6, 7, 8}, priv: ed25519.NewKeyFromSeed(seedBytes)}
+}
+
+func (k *testKey) public(t *testing.T) *trust.Key {
+	t.Helper()
+	raw := append(append([]byte("Ed"), k.keyNum...), k.priv.Public().(ed25519.PublicKey)...)
+	key, err := trust.ParsePublicKey("untrusted comment: test\n" + base64.StdEncoding.EncodeToString(raw) + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func (k *testKey) sign(data string) []byte {
+	sig := ed25519.Sign(k.priv, []byte(data))
+	global := ed25519.Sign(k.priv, append(append([]byte{}, sig...), "ok"...))
+	raw := append(append([]byte("Ed"), k.keyNum...), sig...)
+	return []byte("untrusted comment: sig\n" + base64.StdEncoding.EncodeToString(raw) + "\ntrusted comment: ok\n" + base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func TestCheckSignature(t *testing.T) {
+	dir := t.TempDir()
+	m := &Manager{personasDir: filepath.Join(dir, "personas")}
+	store := trust.NewStore(filepath.Join(dir, "trust"))
+	key := newTestKey(1)
+	if _, err := store.Add(key.public(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	const content = "# calm\n"
+	if _, err := m.ImportPersona("calm", []byte(content), key.sign(content), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.checkSignature(store, "calm", content); err != nil {
+		t.Errorf("checkSignature() on the signed content = %v", err)
+	}
+	err := m.checkSignature(store, "calm", "# calm, edited\n")
+	if err == nil || !strings.Contains(err.Error(), "changed after signing") {
+		t.Errorf("checkSignature() on edited content = %v, want a refusal", err)
+	}
+
+	// Keys no longer trusted and unsigned personas are applied.
+	if _, err := m.ImportPersona("other", []byte(content), newTestKey(2).sign(content), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.checkSignature(store, "other", content); err != nil {
+		t.Errorf("checkSignature() with an untrusted key = %v, want a warning only", err)
+	}
+	if _, err := m.ImportPersona("plain", []byte(content), nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.checkSignature(store, "plain", content); err != nil {
+		t.Errorf("checkSignature() on an unsigned persona = %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package persona
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultIndexURL is the community index of shared personas that
+// `persona search` queries when no other index is configured.
+const DefaultIndexURL = "https://raw.githubusercontent.com/daikw/ccpersona/main/examples/personas/index.json"
+
+// EnvIndex names the environment variable that points `persona search` at
+// another index, such as an organization's internal one.
+const EnvIndex = "CCPERSONA_INDEX"
+
+// MaxIndexBytes caps an index download.
+const MaxIndexBytes = 4 << 20
+
+// Index is a static JSON list of shared personas:
+//
+//	{"personas": [{"name": "calm", "description": "...", "languages": ["en"],
+//	  "rating": 4.5, "votes": 12, "url": "calm.md"}]}
+type Index struct {
+	Personas []IndexEntry `json:"personas"`
+}
+
+// IndexEntry describes one shared persona. URL may be relative to the index.
+type IndexEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Languages   []string `json:"languages,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	// Rating is the average community rating from 0 to 5, from Votes votes.
+	Rating float64 `json:"rating,omitempty"`
+	Votes  int     `json:"votes,omitempty"`
+	URL    string  `json:"url"`
+}
+
+// IndexSource returns the index to query: flag when set, then
+// CCPERSONA_INDEX, then configured (the global config's persona_index),
+// then DefaultIndexURL.
+func IndexSource(flag, configured string) string {
+	for _, source := range []string{flag, os.Getenv(EnvIndex), configured} {
+		if source = strings.TrimSpace(source); source != "" {
+			return source
+		}
+	}
+	return DefaultIndexURL
+}
+
+// FetchIndex reads an index from an http(s) URL or a local file and
+// resolves entry URLs against it. Entries without a name or URL are dropped.
+func FetchIndex(ctx context.Context, source string) (*Index, error) {
+	data, err := fetch(ctx, source, MaxIndexBytes)
+	if errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("persona index %s not found", source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid persona index %s: %w", source, err)
+	}
+	entries := index.Personas[:0]
+	for _, entry := range index.Personas {
+		if entry.Name == "" || entry.URL == "" {
+			continue
+		}
+		resolved, err := resolveIndexURL(source, entry.URL)
+		if err != nil {
+			continue
+		}
+		entry.URL = resolved
+		entries = append(entries, entry)
+	}
+	index.Personas = entries
+	return &index, nil
+}
+
+// resolveIndexURL resolves ref relative to the index it was listed in.
+func resolveIndexURL(source, ref string) (string, error) {
+	if IsRemoteSource(ref) {
+		return ref, nil
+	}
+	if IsRemoteSource(source) {
+		base, err := url.Parse(source)
+		if err != nil {
+			return "", err
+		}
+		rel, err := url.Parse(ref)
+		if err != nil {
+			return "", err
+		}
+		return base.ResolveReference(rel).String(), nil
+	}
+	if strings.Contains(ref, "://") {
+		return "", fmt.Errorf("unsupported URL %s", ref)
+	}
+	if filepath.IsAbs(ref) {
+		return ref, nil
+	}
+	return filepath.Join(filepath.Dir(source), filepath.FromSlash(ref)), nil
+}
+
+// Search returns the entries whose name, description, tags, or author
+// contain keyword, ignoring case, and that support lang when it is set.
+// An empty keyword matches every entry. Results are ordered by rating,
+// best first, then by name.
+func (x *Index) Search(keyword, lang string) []IndexEntry {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	var matches []IndexEntry
+	for _, entry := range x.Personas {
+		if lang != "" && !entry.HasLanguage(lang) {
+			continue
+		}
+		if keyword != "" && !entry.matches(keyword) {
+			continue
+		}
+		matches = append(matches, entry)
+	}
+	slices.SortStableFunc(matches, func(a, b IndexEntry) int {
+		if c := cmp.Compare(b.Rating, a.Rating); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return matches
+}
+
+// HasLanguage reports whether the persona is written for lang, matching
+// the primary subtag so "ja" matches "ja-JP".
+func (e IndexEntry) HasLanguage(lang string) bool {
+	want, _, _ := strings.Cut(strings.ToLower(lang), "-")
+	for _, l := range e.Languages {
+		have, _, _ := strings.Cut(strings.ToLower(l), "-")
+		if have == want {
+			return true
+		}
+	}
+	return false
+}
+
+func (e IndexEntry) matches(keyword string) bool {
+	fields := append([]string{e.Name, e.Description, e.Author}, e.Tags...)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), keyword) {
+			return true
+		}
+	}
+	return false
+}
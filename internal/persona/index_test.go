@@ -0,0 +1,134 @@
+package persona
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testIndex = `{"personas": [
+  {"name": "calm", "description": "Soft-spoken reviewer", "languages": ["en"], "rating": 4.2, "votes": 10, "url": "calm.md"},
+  {"name": "zundamon", "description": "Cheerful", "languages": ["ja-JP"], "tags": ["voicevox"], "rating": 4.8, "votes": 30, "url": "https://example.com/zundamon.md"},
+  {"name": "strict", "description": "Strict reviewer", "languages": ["ja", "en"], "url": "sub/strict.md"},
+  {"name": "", "url": "nameless.md"},
+  {"name": "nowhere"}
+]}`
+
+func TestFetchIndex(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/personas/index.json":
+			fmt.Fprint(w, testIndex)
+		case "/broken.json":
+			fmt.Fprint(w, "{")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	index, err := FetchIndex(context.Background(), srv.URL+"/personas/index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		srv.URL + "/personas/calm.md",
+		"https://example.com/zundamon.md",
+		srv.URL + "/personas/sub/strict.md",
+	}
+	if len(index.Personas) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(index.Personas), len(want), index.Personas)
+	}
+	for i, entry := range index.Personas {
+		if entry.URL != want[i] {
+			t.Errorf("entry %s URL = %q, want %q", entry.Name, entry.URL, want[i])
+		}
+	}
+
+	if _, err := FetchIndex(context.Background(), srv.URL+"/broken.json"); err == nil {
+		t.Error("expected an invalid index to fail")
+	}
+	if _, err := FetchIndex(context.Background(), srv.URL+"/missing.json"); err == nil {
+		t.Error("expected a missing index to fail")
+	}
+}
+
+func TestFetchIndex_LocalFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.json")
+	if err := os.WriteFile(path, []byte(`{"personas": [{"name": "calm", "url": "calm.md"}, {"name": "bad", "url": "ftp://example.com/bad.md"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	index, err := FetchIndex(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Personas) != 1 || index.Personas[0].URL != filepath.Join(dir, "calm.md") {
+		t.Errorf("entries = %+v", index.Personas)
+	}
+}
+
+func TestFetchIndex_Shipped(t *testing.T) {
+	index, err := FetchIndex(context.Background(), filepath.Join("..", "..", "examples", "personas", "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Personas) == 0 {
+		t.Fatal("shipped index is empty")
+	}
+	for _, entry := range index.Personas {
+		if _, err := os.Stat(entry.URL); err != nil {
+			t.Errorf("index entry %s: %v", entry.Name, err)
+		}
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	index := &Index{Personas: []IndexEntry{
+		{Name: "calm", Description: "Soft-spoken reviewer", Languages: []string{"en"}, Rating: 4.2},
+		{Name: "zundamon", Description: "Cheerful", Languages: []string{"ja-JP"}, Tags: []string{"VOICEVOX"}, Rating: 4.8},
+		{Name: "strict", Description: "Strict Reviewer", Languages: []string{"ja", "en"}},
+		{Name: "alpha", Description: "Reviewer too", Languages: []string{"en"}},
+	}}
+	tests := []struct {
+		keyword, lang string
+		want          []string
+	}{
+		{"", "", []string{"zundamon", "calm", "alpha", "strict"}},
+		{"reviewer", "", []string{"calm", "alpha", "strict"}},
+		{"voicevox", "", []string{"zundamon"}},
+		{"", "ja", []string{"zundamon", "strict"}},
+		{"reviewer", "JA", []string{"strict"}},
+		{"nothing", "", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, entry := range index.Search(tt.keyword, tt.lang) {
+			got = append(got, entry.Name)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Search(%q, %q) = %v, want %v", tt.keyword, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestIndexSource(t *testing.T) {
+	t.Setenv(EnvIndex, "")
+	if got := IndexSource("", ""); got != DefaultIndexURL {
+		t.Errorf("default = %q", got)
+	}
+	if got := IndexSource("", "https://config.example/index.json"); got != "https://config.example/index.json" {
+		t.Errorf("configured = %q", got)
+	}
+	t.Setenv(EnvIndex, "https://env.example/index.json")
+	if got := IndexSource("", "https://config.example/index.json"); got != "https://env.example/index.json" {
+		t.Errorf("env = %q", got)
+	}
+	if got := IndexSource("./index.json", "https://config.example/index.json"); got != "./index.json" {
+		t.Errorf("flag = %q", got)
+	}
+}
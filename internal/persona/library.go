@@ -0,0 +1,48 @@
+package persona
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+)
+
+// DefaultTemplate is the template new personas start from.
+const DefaultTemplate = "default"
+
+//go:embed templates/*.md
+var templateFS embed.FS
+
+// PersonaTemplate is a persona that ships with ccpersona as a starting point.
+type PersonaTemplate struct {
+	Name        string
+	Description string
+}
+
+// personaTemplates lists the embedded templates in the order `persona
+// template list` shows them; each has a templates/<name>.md file.
+var personaTemplates = []PersonaTemplate{
+	{DefaultTemplate, "Plain, neutral assistant; the skeleton `persona edit` used to start from"},
+	{"zundamon", "ずんだもん: cheerful, ends sentences with 〜のだ"},
+	{"strict-reviewer", "Direct code reviewer that ranks findings and cites lines"},
+	{"pair-programmer", "Collaborative partner working in small, verified steps"},
+	{"teacher", "Patient explainer that checks understanding and gives hints"},
+}
+
+// Templates returns the embedded persona templates.
+func Templates() []PersonaTemplate {
+	return append([]PersonaTemplate(nil), personaTemplates...)
+}
+
+// TemplateContent returns the markdown of the named template, with its
+// title naming the persona name instead of the template.
+func TemplateContent(template, name string) (string, error) {
+	data, err := templateFS.ReadFile("templates/" + template + ".md")
+	if err != nil {
+		return "", fmt.Errorf("no persona template '%s' (see 'ccpersona persona template list')", template)
+	}
+	content := string(data)
+	if first, rest, ok := strings.Cut(content, "\n"); ok && strings.HasPrefix(first, "# 人格:") {
+		content = "# 人格: " + name + "\n" + rest
+	}
+	return content, nil
+}
@@ -0,0 +1,67 @@
+package persona
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplatesMatchEmbeddedFiles(t *testing.T) {
+	files, err := fs.Glob(templateFS, "templates/*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(Templates()) {
+		t.Errorf("%d template files but %d listed templates", len(files), len(Templates()))
+	}
+	for _, tmpl := range Templates() {
+		content, err := TemplateContent(tmpl.Name, "mine")
+		if err != nil {
+			t.Errorf("%s: %v", tmpl.Name, err)
+			continue
+		}
+		if !strings.HasPrefix(content, "# 人格: mine\n") {
+			t.Errorf("%s: title not renamed: %q", tmpl.Name, strings.SplitN(content, "\n", 2)[0])
+		}
+		if _, err := RenderPersona(content, TemplateVars(nil, "/project")); err != nil {
+			t.Errorf("%s does not render: %v", tmpl.Name, err)
+		}
+	}
+}
+
+func TestCreatePersonaFromTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := &Manager{
+		homeDir:     tmpDir,
+		personasDir: filepath.Join(tmpDir, ".agents", "ccpersona", "personas"),
+	}
+
+	if err := manager.CreatePersonaFromTemplate("zunda", "zundamon", false); err != nil {
+		t.Fatal(err)
+	}
+	content, err := manager.ReadPersona("zunda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(content, "# 人格: zunda\n") || !strings.Contains(content, "のだ") {
+		t.Errorf("unexpected content: %q", content)
+	}
+
+	if err := manager.CreatePersonaFromTemplate("zunda", "teacher", false); err == nil {
+		t.Error("an existing persona should not be replaced without force")
+	}
+	if err := manager.CreatePersonaFromTemplate("zunda", "teacher", true); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := manager.ReadPersona("zunda"); !strings.Contains(content, "先生") {
+		t.Error("force should replace the persona with the template")
+	}
+
+	if err := manager.CreatePersonaFromTemplate("other", "no-such-template", false); err == nil {
+		t.Error("an unknown template should be an error")
+	}
+	if manager.PersonaExists("other") {
+		t.Error("an unknown template should not create a persona")
+	}
+}
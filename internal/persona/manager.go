@@ -6,7 +6,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/daikw/ccpersona/internal/fsutil"
 	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
 )
 
 // Manager handles persona operations
@@ -118,47 +120,39 @@ func (m *Manager) PersonaExists(name string) bool {
 	return ok
 }
 
-// CreatePersona creates a new persona from a template
+// CreatePersona creates a new persona from the default template
 func (m *Manager) CreatePersona(name string) error {
+	return m.CreatePersonaFromTemplate(name, DefaultTemplate, false)
+}
+
+// CreatePersonaFromTemplate creates persona name from an embedded template.
+// An existing persona is only replaced with force.
+func (m *Manager) CreatePersonaFromTemplate(name, template string, force bool) error {
 	if err := validatePersonaName(name); err != nil {
 		return err
 	}
-	if m.PersonaExists(name) {
+	if m.PersonaExists(name) && !force {
 		return fmt.Errorf("persona '%s' already exists", name)
 	}
+	content, err := TemplateContent(template, name)
+	if err != nil {
+		return err
+	}
 
 	// Ensure personas directory exists
 	if err := os.MkdirAll(m.personasDir, DirPermission); err != nil {
 		return fmt.Errorf("failed to create personas directory: %w", err)
 	}
 
-	template := fmt.Sprintf(`# 人格: %s
-
-## 口調
-標準的な口調で話します。
-
-## 考え方
-- 論理的に問題を解決します
-- 効率性を重視します
-
-## 価値観
-- コードの品質を大切にします
-- テストの重要性を理解しています
-
-## 専門性
-- 一般的なプログラミング知識
-
-## 対話スタイル
-- 明確で簡潔な説明
-- 必要に応じて詳細を提供
-`, name)
-
 	path := m.GetPersonaPath(name)
-	if err := os.WriteFile(path, []byte(template), FilePermission); err != nil {
+	if err := fsutil.WriteFile(path, []byte(content), FilePermission); err != nil {
 		return fmt.Errorf("failed to create persona file: %w", err)
 	}
+	if err := removeIfExists(path + SignatureSuffix); err != nil {
+		return err
+	}
 
-	log.Info().Str("persona", name).Str("path", path).Msg("Created new persona")
+	log.Info().Str("persona", name).Str("template", template).Str("path", path).Msg("Created new persona")
 	return nil
 }
 
@@ -168,12 +162,12 @@ func (m *Manager) ReadPersona(name string) (string, error) {
 		return "", err
 	}
 	if !m.PersonaExists(name) {
-		return "", fmt.Errorf("persona '%s' does not exist", name)
+		return "", &NotExistError{Name: name}
 	}
 
 	path, ok := m.resolvePersonaPath(name)
 	if !ok {
-		return "", fmt.Errorf("persona '%s' does not exist", name)
+		return "", &NotExistError{Name: name}
 	}
 	content, err := os.ReadFile(path)
 	if err != nil {
@@ -195,23 +189,52 @@ func (m *Manager) ReadPersonaForContext(name string) (string, error) {
 
 // stripYAMLFrontMatter removes YAML front matter (--- delimited) from markdown content.
 func stripYAMLFrontMatter(content string) string {
+	_, body := splitYAMLFrontMatter(content)
+	return body
+}
+
+// personaFrontMatter is the part of a persona's YAML front matter ccpersona
+// reads.
+type personaFrontMatter struct {
+	Env    map[string]string `yaml:"env"`
+	Agents []AgentSpec       `yaml:"agents"`
+	Voice  *VoiceProfile     `yaml:"voice"`
+}
+
+// parseFrontMatter decodes the front matter of persona content. Content
+// without front matter yields an empty value.
+func parseFrontMatter(content string) (personaFrontMatter, error) {
+	var fm personaFrontMatter
+	front, _ := splitYAMLFrontMatter(content)
+	if front == "" {
+		return fm, nil
+	}
+	if err := yaml.Unmarshal([]byte(front), &fm); err != nil {
+		return fm, fmt.Errorf("invalid front matter: %w", err)
+	}
+	return fm, nil
+}
+
+// splitYAMLFrontMatter splits markdown content into its YAML front matter
+// (without delimiters) and body. front is empty when there is none.
+func splitYAMLFrontMatter(content string) (front, body string) {
 	// UTF-8 BOM 対応
 	content = strings.TrimPrefix(content, "\uFEFF")
 
 	lines := strings.SplitAfter(content, "\n")
 	if len(lines) == 0 || strings.TrimRight(lines[0], "\r\n") != "---" {
-		return content
+		return "", content
 	}
 
 	for i := 1; i < len(lines); i++ {
 		line := strings.TrimRight(lines[i], "\r\n")
 		if line == "---" || line == "..." {
-			return strings.Join(lines[i+1:], "")
+			return strings.Join(lines[1:i], ""), strings.Join(lines[i+1:], "")
 		}
 	}
 
 	// 閉じ区切りがない場合は通常の Markdown として扱う
-	return content
+	return "", content
 }
 
 // GetCurrentPersona returns the currently configured persona name from persona.json
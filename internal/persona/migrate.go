@@ -0,0 +1,142 @@
+package persona
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// legacyVoiceField is a voice key from the old runtime voice schema and its
+// replacement in the unified config.
+type legacyVoiceField struct {
+	Old string
+	New string
+}
+
+// legacyVoiceFields are tried in order, so when several legacy keys map to the
+// same field the first one present wins. The speaker order is adjusted for
+// the configured provider in voiceFieldOrder.
+var legacyVoiceFields = []legacyVoiceField{
+	{"engine_priority", "provider"},
+	{"aivisspeech_speaker", "speaker"},
+	{"voicevox_speaker", "speaker"},
+	{"volume_scale", "volume"},
+	{"speed_scale", "speed"},
+}
+
+// MigrateConfigFields rewrites legacy field names in the unified config under
+// baseDir and returns a description of each change. With dryRun the file is
+// left untouched. A missing config is not an error.
+func MigrateConfigFields(baseDir string, dryRun bool) ([]string, error) {
+	path := ConfigPath(baseDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+	migrated, changes, err := migrateLegacyFields(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if len(changes) == 0 || dryRun {
+		return changes, nil
+	}
+
+	var config Config
+	if err := json.Unmarshal(migrated, &config); err != nil {
+		return nil, fmt.Errorf("migrated config %s is invalid: %w", path, err)
+	}
+	if err := SaveConfig(baseDir, &config); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// migrateLegacyFields renames legacy voice keys in a config document. The
+// document is returned unchanged when there is nothing to rename.
+func migrateLegacyFields(data []byte) ([]byte, []string, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	rawVoice, ok := doc["voice"]
+	if !ok {
+		return data, nil, nil
+	}
+	var voiceDoc map[string]json.RawMessage
+	if err := json.Unmarshal(rawVoice, &voiceDoc); err != nil || voiceDoc == nil {
+		// Not an object; the regular loader reports the type error.
+		return data, nil, nil
+	}
+
+	var changes []string
+	for _, field := range voiceFieldOrder(voiceDoc) {
+		value, ok := voiceDoc[field.Old]
+		if !ok {
+			continue
+		}
+		delete(voiceDoc, field.Old)
+		if _, exists := voiceDoc[field.New]; exists {
+			changes = append(changes, fmt.Sprintf("removed voice.%s (voice.%s is already set)", field.Old, field.New))
+			continue
+		}
+		voiceDoc[field.New] = value
+		changes = append(changes, fmt.Sprintf("renamed voice.%s -> voice.%s", field.Old, field.New))
+	}
+	if len(changes) == 0 {
+		return data, nil, nil
+	}
+
+	encoded, err := json.Marshal(voiceDoc)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc["voice"] = encoded
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, changes, nil
+}
+
+// voiceFieldOrder returns legacyVoiceFields with the VOICEVOX speaker first
+// when the document selects VOICEVOX, so the matching speaker ID survives.
+func voiceFieldOrder(voiceDoc map[string]json.RawMessage) []legacyVoiceField {
+	var provider string
+	if raw, ok := voiceDoc["provider"]; ok {
+		_ = json.Unmarshal(raw, &provider)
+	} else if raw, ok := voiceDoc["engine_priority"]; ok {
+		_ = json.Unmarshal(raw, &provider)
+	}
+	if provider != "voicevox" {
+		return legacyVoiceFields
+	}
+	order := make([]legacyVoiceField, 0, len(legacyVoiceFields))
+	for _, field := range legacyVoiceFields {
+		if field.Old == "voicevox_speaker" {
+			order = append([]legacyVoiceField{field}, order...)
+			continue
+		}
+		order = append(order, field)
+	}
+	return order
+}
+
+// LegacyConfigFiles returns the legacy persona and voice config files present
+// under baseDir, in the order MigrateConfig reads them.
+func LegacyConfigFiles(baseDir string) []string {
+	var files []string
+	for _, path := range append(legacyPersonaPaths(baseDir), legacyVoiceConfigPath(baseDir)) {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+func legacyVoiceConfigPath(baseDir string) string {
+	return filepath.Join(baseDir, ClaudeDir, LegacyVoiceConfigName)
+}
@@ -0,0 +1,110 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, baseDir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(baseDir, AgentsDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ConfigPath(baseDir), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateConfigFields(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeConfigFile(t, tmpDir, `{
+		"name": "zundamon",
+		"voice": {
+			"engine_priority": "voicevox",
+			"aivisspeech_speaker": 1512153248,
+			"voicevox_speaker": 3,
+			"speed_scale": 1.2,
+			"volume": 0.8,
+			"volume_scale": 1.5
+		}
+	}`)
+
+	changes, err := MigrateConfigFields(tmpDir, false)
+	if err != nil {
+		t.Fatalf("MigrateConfigFields() error = %v", err)
+	}
+	want := []string{
+		"renamed voice.voicevox_speaker -> voice.speaker",
+		"renamed voice.engine_priority -> voice.provider",
+		"removed voice.aivisspeech_speaker (voice.speaker is already set)",
+		"removed voice.volume_scale (voice.volume is already set)",
+		"renamed voice.speed_scale -> voice.speed",
+	}
+	if strings.Join(changes, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("changes =\n%s\nwant\n%s", strings.Join(changes, "\n"), strings.Join(want, "\n"))
+	}
+
+	got, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := got.Voice
+	if v.Provider != "voicevox" || v.Speaker != 3 || v.Speed != 1.2 || v.Volume != 0.8 {
+		t.Fatalf("voice = %#v, want migrated voicevox settings", v)
+	}
+
+	again, err := MigrateConfigFields(tmpDir, false)
+	if err != nil || len(again) != 0 {
+		t.Fatalf("second run = %q, %v; want no changes", again, err)
+	}
+}
+
+func TestMigrateConfigFields_DryRunAndMissing(t *testing.T) {
+	tmpDir := t.TempDir()
+	if changes, err := MigrateConfigFields(tmpDir, false); err != nil || changes != nil {
+		t.Fatalf("missing config = %q, %v; want nothing", changes, err)
+	}
+
+	original := `{"name": "x", "voice": {"voicevox_speaker": 8}}`
+	writeConfigFile(t, tmpDir, original)
+	changes, err := MigrateConfigFields(tmpDir, true)
+	if err != nil || len(changes) != 1 {
+		t.Fatalf("dry run = %q, %v; want one change", changes, err)
+	}
+	data, _ := os.ReadFile(ConfigPath(tmpDir))
+	if string(data) != original {
+		t.Fatalf("dry run modified the file: %s", data)
+	}
+}
+
+func TestMigrateConfig_RenamesLegacyPersonaFields(t *testing.T) {
+	tmpDir := t.TempDir()
+	claudeDir := filepath.Join(tmpDir, ClaudeDir)
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(claudeDir, LegacyPersonaFileName),
+		[]byte(`{"name": "fable", "voice": {"provider": "aivisspeech", "aivisspeech_speaker": 42}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if files := LegacyConfigFiles(tmpDir); len(files) != 1 {
+		t.Fatalf("LegacyConfigFiles() = %q, want the persona file", files)
+	}
+	_, notes, err := MigrateConfig(tmpDir, false)
+	if err != nil {
+		t.Fatalf("MigrateConfig() error = %v", err)
+	}
+	if len(notes) != 2 || notes[1] != "renamed voice.aivisspeech_speaker -> voice.speaker" {
+		t.Fatalf("notes = %q", notes)
+	}
+	got, err := LoadConfig(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Voice == nil || got.Voice.Speaker != 42 {
+		t.Fatalf("voice = %#v, want speaker 42", got.Voice)
+	}
+}
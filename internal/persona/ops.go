@@ -0,0 +1,117 @@
+package persona
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+// CopyPersona copies persona src to dst in the personas directory. An
+// existing dst is only replaced with force. The signature is not copied: it
+// signs the original name's file, and a copy is meant to be edited.
+func (m *Manager) CopyPersona(src, dst string, force bool) (string, error) {
+	srcPath, err := m.checkTransfer(src, dst, force)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read persona file: %w", err)
+	}
+	dstPath := m.GetPersonaPath(dst)
+	if err := fsutil.WriteFile(dstPath, data, FilePermission); err != nil {
+		return "", fmt.Errorf("failed to write persona file: %w", err)
+	}
+	if err := removeIfExists(dstPath + SignatureSuffix); err != nil {
+		return "", err
+	}
+	log.Debug().Str("from", src).Str("to", dst).Msg("Copied persona")
+	return dstPath, nil
+}
+
+// RenamePersona moves persona oldName to newName in the personas directory,
+// together with its signature. A persona found in a legacy directory moves
+// to the canonical one.
+func (m *Manager) RenamePersona(oldName, newName string, force bool) (string, error) {
+	oldPath, err := m.checkTransfer(oldName, newName, force)
+	if err != nil {
+		return "", err
+	}
+	newPath := m.GetPersonaPath(newName)
+	if err := os.MkdirAll(m.personasDir, DirPermission); err != nil {
+		return "", fmt.Errorf("failed to create personas directory: %w", err)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return "", fmt.Errorf("failed to rename persona: %w", err)
+	}
+	if err := os.Rename(oldPath+SignatureSuffix, newPath+SignatureSuffix); errors.Is(err, fs.ErrNotExist) {
+		err = removeIfExists(newPath + SignatureSuffix)
+		if err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", fmt.Errorf("failed to rename persona signature: %w", err)
+	}
+	log.Debug().Str("from", oldName).Str("to", newName).Msg("Renamed persona")
+	return newPath, nil
+}
+
+// DeletePersona removes every copy of a persona (the canonical file and any
+// legacy-directory copies it shadows) with their signatures, and returns the
+// removed persona files.
+func (m *Manager) DeletePersona(name string) ([]string, error) {
+	if err := validatePersonaName(name); err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, dir := range m.personaDirs() {
+		path := filepath.Join(dir, name+".md")
+		if err := os.Remove(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return removed, fmt.Errorf("failed to delete persona: %w", err)
+		}
+		removed = append(removed, path)
+		if err := removeIfExists(path + SignatureSuffix); err != nil {
+			return removed, err
+		}
+	}
+	if len(removed) == 0 {
+		return nil, &NotExistError{Name: name}
+	}
+	log.Debug().Str("persona", name).Strs("paths", removed).Msg("Deleted persona")
+	return removed, nil
+}
+
+// checkTransfer validates a copy or rename and returns the source file.
+func (m *Manager) checkTransfer(src, dst string, force bool) (string, error) {
+	if err := validatePersonaName(src); err != nil {
+		return "", err
+	}
+	if err := validatePersonaName(dst); err != nil {
+		return "", err
+	}
+	if src == dst {
+		return "", fmt.Errorf("source and destination are both '%s'", src)
+	}
+	srcPath, ok := m.resolvePersonaPath(src)
+	if !ok {
+		return "", &NotExistError{Name: src}
+	}
+	if m.PersonaExists(dst) && !force {
+		return "", fmt.Errorf("persona '%s' already exists (use --force to replace it)", dst)
+	}
+	return srcPath, nil
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
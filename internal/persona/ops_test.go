@@ -0,0 +1,99 @@
+package persona
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testManager(t *testing.T) *Manager {
+	t.Helper()
+	dir := t.TempDir()
+	m := &Manager{
+		homeDir:                 dir,
+		personasDir:             filepath.Join(dir, ".agents", "ccpersona", "personas"),
+		agentsLegacyPersonasDir: filepath.Join(dir, ".agents", "personas"),
+	}
+	for _, d := range m.personaDirs() {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func writePersona(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopyPersona(t *testing.T) {
+	m := testManager(t)
+	writePersona(t, m.GetPersonaPath("a"), "# A\n")
+	writePersona(t, m.GetPersonaPath("a")+SignatureSuffix, "sig")
+
+	if _, err := m.CopyPersona("a", "b", false); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.ReadPersona("b"); got != "# A\n" {
+		t.Errorf("copy content = %q", got)
+	}
+	if _, err := os.Stat(m.GetPersonaPath("b") + SignatureSuffix); !os.IsNotExist(err) {
+		t.Error("signature must not be copied")
+	}
+	if _, err := m.CopyPersona("a", "b", false); err == nil {
+		t.Error("copying over an existing persona requires force")
+	}
+	if _, err := m.CopyPersona("missing", "c", false); !errors.Is(err, fs.ErrNotExist) || err.Error() != "persona 'missing' does not exist" {
+		t.Errorf("copying a missing persona = %v, want a NotExistError", err)
+	}
+	if _, err := m.CopyPersona("a", "../escape", true); err == nil {
+		t.Error("invalid destination name should fail")
+	}
+}
+
+func TestRenamePersonaFromLegacyDir(t *testing.T) {
+	m := testManager(t)
+	legacy := filepath.Join(m.agentsLegacyPersonasDir, "old.md")
+	writePersona(t, legacy, "# Old\n")
+	writePersona(t, legacy+SignatureSuffix, "sig")
+
+	path, err := m.RenamePersona("old", "new", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != m.GetPersonaPath("new") || m.PersonaExists("old") {
+		t.Errorf("rename left old persona or wrong path %s", path)
+	}
+	if sig, _ := m.ReadPersonaSignature("new"); string(sig) != "sig" {
+		t.Errorf("signature not moved: %q", sig)
+	}
+	if _, err := m.RenamePersona("new", "new", true); err == nil {
+		t.Error("renaming to the same name should fail")
+	}
+}
+
+func TestDeletePersonaRemovesShadowedCopies(t *testing.T) {
+	m := testManager(t)
+	writePersona(t, m.GetPersonaPath("dup"), "canonical")
+	writePersona(t, m.GetPersonaPath("dup")+SignatureSuffix, "sig")
+	writePersona(t, filepath.Join(m.agentsLegacyPersonasDir, "dup.md"), "legacy")
+
+	removed, err := m.DeletePersona("dup")
+	if err != nil || len(removed) != 2 {
+		t.Fatalf("DeletePersona() = %v, %v", removed, err)
+	}
+	if m.PersonaExists("dup") {
+		t.Error("persona still exists")
+	}
+	if _, err := os.Stat(m.GetPersonaPath("dup") + SignatureSuffix); !os.IsNotExist(err) {
+		t.Error("signature not removed")
+	}
+	if _, err := m.DeletePersona("dup"); err == nil {
+		t.Error("deleting a missing persona should fail")
+	}
+}
@@ -0,0 +1,192 @@
+package persona
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/daikw/ccpersona/internal/voice"
+)
+
+var warnedPersonaVoice sync.Map
+
+// VoiceProfile is the voice a persona declares under `voice:` in its front
+// matter, so the persona speaks in its own voice whatever the config's
+// top-level voice is. It only picks and shapes the voice: endpoints and
+// credentials stay in the config, so an imported persona cannot send an API
+// key elsewhere.
+type VoiceProfile struct {
+	Provider string `yaml:"provider"`
+	// Speaker is the VOICEVOX or AivisSpeech speaker ID.
+	Speaker int `yaml:"speaker"`
+	// Voice is a voice ID, an alias, or a provider:voice reference.
+	Voice  string  `yaml:"voice"`
+	Speed  float64 `yaml:"speed"`
+	Volume float64 `yaml:"volume"`
+	// Pitch is the VOICEVOX and AivisSpeech pitch scale, -0.15 to 0.15.
+	Pitch    *float64 `yaml:"pitch"`
+	Language string   `yaml:"language"`
+}
+
+// PersonaConfig overrides settings while one persona is active. Fields set
+// here replace the same voice fields after the persona's own front matter.
+type PersonaConfig struct {
+	Voice *VoiceConfig `json:"voice,omitempty"`
+}
+
+// voiceConfig returns the profile as a voice block; nil for an empty one.
+func (p *VoiceProfile) voiceConfig() *VoiceConfig {
+	if p == nil || *p == (VoiceProfile{}) {
+		return nil
+	}
+	v := &VoiceConfig{
+		Provider: p.Provider,
+		Speaker:  p.Speaker,
+		Voice:    p.Voice,
+		Speed:    p.Speed,
+		Volume:   p.Volume,
+		Language: p.Language,
+	}
+	if p.Pitch != nil {
+		pitch := *p.Pitch
+		v.Prosody = &voice.Prosody{PitchScale: &pitch}
+	}
+	return v
+}
+
+// ParseVoiceProfile returns the voice declared under `voice:` in the front
+// matter of persona content, or nil when there is none.
+func ParseVoiceProfile(content string) (*VoiceProfile, error) {
+	fm, err := parseFrontMatter(content)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateVoiceProfile(fm.Voice); err != nil {
+		return nil, err
+	}
+	return fm.Voice, nil
+}
+
+func validateVoiceProfile(p *VoiceProfile) error {
+	v := p.voiceConfig()
+	if v == nil {
+		return nil
+	}
+	if v.Provider == voice.ProviderAuto {
+		return fmt.Errorf("voice.provider %q needs voice.auto in the config", voice.ProviderAuto)
+	}
+	return ValidateConfig(&Config{Name: "persona", Voice: v})
+}
+
+// ReadPersonaVoice returns the voice the persona declares, or nil.
+func (m *Manager) ReadPersonaVoice(name string) (*VoiceProfile, error) {
+	raw, err := m.ReadPersona(name)
+	if err != nil {
+		return nil, err
+	}
+	return ParseVoiceProfile(raw)
+}
+
+// PersonaNames returns the names with a personas entry in sorted order.
+func (c *Config) PersonaNames() []string {
+	if c == nil {
+		return nil
+	}
+	names := make([]string, 0, len(c.Personas))
+	for name := range c.Personas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasPersonaVoice reports whether the active persona declares a voice, in
+// its front matter or a personas entry; safe on nil.
+func (c *Config) HasPersonaVoice() bool {
+	if c == nil {
+		return false
+	}
+	if p := c.Personas[c.Name]; p != nil && p.Voice != nil {
+		return true
+	}
+	profile, err := readActivePersonaVoice(c.Name)
+	return err == nil && profile.voiceConfig() != nil
+}
+
+// ApplyPersonaVoice returns a copy of config with the voice of the active
+// persona overlaid: first the profile from its front matter, then its
+// personas entry. A persona that switches providers drops the previous
+// voice, speaker, and model, which only mean something to the old provider.
+// Config is returned unchanged when neither declares a voice.
+func ApplyPersonaVoice(config *Config, profile *VoiceProfile) (*Config, error) {
+	if config == nil {
+		return nil, nil
+	}
+	var entry *VoiceConfig
+	if p := config.Personas[config.Name]; p != nil {
+		entry = p.Voice
+	}
+	front := profile.voiceConfig()
+	if front == nil && entry == nil {
+		return config, nil
+	}
+	out := *config
+	for _, top := range []*VoiceConfig{front, entry} {
+		if top == nil {
+			continue
+		}
+		base := out.Voice
+		if base != nil && top.Provider != "" && top.Provider != base.Provider {
+			switched := *base
+			switched.Voice, switched.Speaker, switched.Model = "", 0, ""
+			base = &switched
+		}
+		var err error
+		if out.Voice, err = overlay(base, top); err != nil {
+			return nil, fmt.Errorf("persona %q: voice: %w", config.Name, err)
+		}
+	}
+	return &out, nil
+}
+
+// WithPersonaVoice is ApplyPersonaVoice with the front matter of the active
+// persona's file. A persona that cannot be read or declares an invalid voice
+// is reported once on stderr, and the config's own settings are kept.
+func WithPersonaVoice(config *Config) *Config {
+	if config == nil || config.Name == "" {
+		return config
+	}
+	profile, err := readActivePersonaVoice(config.Name)
+	if err != nil {
+		if _, loaded := warnedPersonaVoice.LoadOrStore(config.Name, true); !loaded {
+			fmt.Fprintf(os.Stderr, "ccpersona: persona %q: %v; ignoring its voice\n", config.Name, err)
+		}
+		profile = nil
+	}
+	out, err := ApplyPersonaVoice(config, profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ccpersona: %v; using the top-level settings\n", err)
+		return config
+	}
+	return out
+}
+
+// readActivePersonaVoice reads the front matter voice of persona name; a
+// persona without a file ("none", or one not installed yet) has none.
+func readActivePersonaVoice(name string) (*VoiceProfile, error) {
+	if name == "none" || validatePersonaName(name) != nil {
+		return nil, nil
+	}
+	m, err := NewManager()
+	if err != nil {
+		return nil, nil
+	}
+	profile, err := m.ReadPersonaVoice(name)
+	var notExist *NotExistError
+	if errors.As(err, &notExist) {
+		return nil, nil
+	}
+	return profile, err
+}
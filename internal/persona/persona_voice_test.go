@@ -0,0 +1,125 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseVoiceProfile(t *testing.T) {
+	content := "---\nvoice:\n  provider: openai\n  voice: nova\n  speed: 1.1\n  pitch: 0.05\n  language: en-US\n---\n# 人格: narrator\n"
+	profile, err := ParseVoiceProfile(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Provider != "openai" || profile.Voice != "nova" || profile.Speed != 1.1 || profile.Language != "en-US" {
+		t.Errorf("profile = %+v", profile)
+	}
+	if v := profile.voiceConfig(); v.Prosody == nil || *v.Prosody.PitchScale != 0.05 {
+		t.Errorf("pitch should become the prosody pitch scale: %+v", v.Prosody)
+	}
+
+	if profile, err := ParseVoiceProfile("# 人格: plain\n"); err != nil || profile != nil {
+		t.Errorf("a persona without front matter has no voice: %+v, %v", profile, err)
+	}
+
+	for _, bad := range []string{
+		"---\nvoice:\n  speed: 9\n---\n",
+		"---\nvoice:\n  pitch: 0.5\n---\n",
+		"---\nvoice:\n  provider: auto\n---\n",
+	} {
+		if _, err := ParseVoiceProfile(bad); err == nil {
+			t.Errorf("ParseVoiceProfile(%q) should fail", bad)
+		}
+	}
+}
+
+func TestApplyPersonaVoice(t *testing.T) {
+	config := &Config{
+		Name:  "zundamon",
+		Voice: &VoiceConfig{Provider: "openai", Voice: "alloy", Model: "tts-1", Volume: 0.8, APIKey: "sk-test"},
+	}
+
+	zunda, err := ApplyPersonaVoice(config, &VoiceProfile{Provider: "voicevox", Speaker: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := zunda.Voice; v.Provider != "voicevox" || v.Speaker != 3 || v.Voice != "" || v.Model != "" || v.Volume != 0.8 {
+		t.Errorf("voice = %+v, want the persona's provider and speaker without the previous provider's voice", v)
+	}
+
+	english, err := ApplyPersonaVoice(config, &VoiceProfile{Voice: "nova", Speed: 1.2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := english.Voice; v.Provider != "openai" || v.Voice != "nova" || v.Model != "tts-1" || v.APIKey != "sk-test" || v.Speed != 1.2 {
+		t.Errorf("voice = %+v, want the persona's voice over the same provider's settings", v)
+	}
+
+	if config.Voice.Provider != "openai" || config.Voice.Voice != "alloy" {
+		t.Error("input config must not be modified")
+	}
+	if got, _ := ApplyPersonaVoice(config, nil); got != config {
+		t.Error("a persona without a voice keeps the config as is")
+	}
+}
+
+func TestApplyPersonaVoice_ConfigEntryWins(t *testing.T) {
+	config := &Config{
+		Name:     "narrator",
+		Voice:    &VoiceConfig{Provider: "voicevox", Speaker: 1},
+		Personas: map[string]*PersonaConfig{"narrator": {Voice: &VoiceConfig{Voice: "shimmer", Speed: 0.9}}},
+	}
+	applied, err := ApplyPersonaVoice(config, &VoiceProfile{Provider: "openai", Voice: "nova", Speed: 1.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := applied.Voice; v.Provider != "openai" || v.Voice != "shimmer" || v.Speed != 0.9 || v.Speaker != 0 {
+		t.Errorf("voice = %+v, want the personas entry over the front matter", v)
+	}
+}
+
+func TestWithPersonaVoice(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	personasDir := filepath.Join(home, ".claude", "personas")
+	if err := os.MkdirAll(personasDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nvoice:\n  provider: voicevox\n  speaker: 3\n---\n# 人格: zundamon\n"
+	if err := os.WriteFile(filepath.Join(personasDir, "zundamon.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &Config{Name: "zundamon", Voice: &VoiceConfig{Provider: "openai", Voice: "nova"}}
+	if !config.HasPersonaVoice() {
+		t.Error("HasPersonaVoice should see the front matter voice")
+	}
+	if v := WithPersonaVoice(config).Voice; v.Provider != "voicevox" || v.Speaker != 3 {
+		t.Errorf("voice = %+v, want the persona's VOICEVOX speaker", v)
+	}
+
+	missing := &Config{Name: "not-installed", Voice: config.Voice}
+	if got := WithPersonaVoice(missing); got != missing || got.HasPersonaVoice() {
+		t.Error("a persona without a file keeps the config's voice")
+	}
+}
+
+func TestValidateConfig_Personas(t *testing.T) {
+	config := &Config{
+		Name:     "default",
+		Personas: map[string]*PersonaConfig{"narrator": {Voice: &VoiceConfig{Speed: 9}}},
+	}
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "personas.narrator") {
+		t.Errorf("err = %v, want the personas entry named", err)
+	}
+	config.Personas = map[string]*PersonaConfig{"../escape": {Voice: &VoiceConfig{Speed: 1}}}
+	if err := ValidateConfig(config); err == nil {
+		t.Error("a personas entry must name a valid persona")
+	}
+	config.Personas = map[string]*PersonaConfig{"narrator": {Voice: &VoiceConfig{Provider: "openai", Voice: "nova"}}}
+	if err := ValidateConfig(config); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,99 @@
+package persona
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// platformDirective matches a line holding only <!-- if: cursor -->,
+// <!-- else -->, or <!-- endif -->.
+var platformDirective = regexp.MustCompile(`^\s*<!--\s*(if:([^>]*?)|else|endif)\s*-->\s*$`)
+
+// FilterPlatformSections keeps the parts of persona content gated by
+// <!-- if: cursor --> ... <!-- endif --> whose condition names platform and
+// drops the others, together with the directive lines. A condition is a
+// comma-separated list of platforms (claude-code or claude, codex, cursor,
+// or a plugin source); "!cursor" names every platform but cursor. Gates
+// nest, and <!-- else --> inverts the innermost one.
+func FilterPlatformSections(content, platform string) (string, error) {
+	if !strings.Contains(content, "<!--") {
+		return content, nil
+	}
+	platform = normalizePlatform(platform)
+
+	type gate struct{ parent, cond, inElse bool }
+	var (
+		out   strings.Builder
+		gates []gate
+	)
+	keep := true
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		m := platformDirective.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		switch {
+		case m == nil:
+			if keep {
+				out.WriteString(line)
+			}
+		case m[1] == "endif":
+			if len(gates) == 0 {
+				return "", fmt.Errorf("line %d: endif without if", i+1)
+			}
+			keep = gates[len(gates)-1].parent
+			gates = gates[:len(gates)-1]
+		case m[1] == "else":
+			if len(gates) == 0 || gates[len(gates)-1].inElse {
+				return "", fmt.Errorf("line %d: else without if", i+1)
+			}
+			g := &gates[len(gates)-1]
+			g.inElse = true
+			keep = g.parent && !g.cond
+		default:
+			cond, err := platformCondition(m[2], platform)
+			if err != nil {
+				return "", fmt.Errorf("line %d: %w", i+1, err)
+			}
+			gates = append(gates, gate{parent: keep, cond: cond})
+			keep = keep && cond
+		}
+	}
+	if len(gates) > 0 {
+		return "", fmt.Errorf("if without endif")
+	}
+	return out.String(), nil
+}
+
+// platformCondition reports whether a comma-separated condition names
+// platform.
+func platformCondition(cond, platform string) (bool, error) {
+	match := false
+	empty := true
+	for _, term := range strings.Split(cond, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		empty = false
+		if name, negated := strings.CutPrefix(term, "!"); negated {
+			match = match || normalizePlatform(name) != platform
+		} else {
+			match = match || normalizePlatform(term) == platform
+		}
+	}
+	if empty {
+		return false, fmt.Errorf("if needs a platform, e.g. <!-- if: cursor -->")
+	}
+	return match, nil
+}
+
+// normalizePlatform maps a platform to its identifier; hooks that do not
+// name one are Claude Code's.
+func normalizePlatform(platform string) string {
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	switch platform {
+	case "", "claude":
+		return PlatformClaudeCode
+	}
+	return platform
+}
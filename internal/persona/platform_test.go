@@ -0,0 +1,100 @@
+package persona
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const gatedPersona = `# 人格: fable
+共通の指示。
+<!-- if: claude -->
+Task ツールを使うのだ。
+<!-- else -->
+ツールは控えめに。
+<!-- endif -->
+<!-- if: codex, cursor -->
+エディタ向け。
+  <!-- if: !cursor -->
+  Codex だけ。
+  <!-- endif -->
+<!-- endif -->
+終わり。
+`
+
+func TestFilterPlatformSections(t *testing.T) {
+	tests := []struct {
+		platform string
+		want     string
+	}{
+		{"", "# 人格: fable\n共通の指示。\nTask ツールを使うのだ。\n終わり。\n"},
+		{"claude-code", "# 人格: fable\n共通の指示。\nTask ツールを使うのだ。\n終わり。\n"},
+		{"codex", "# 人格: fable\n共通の指示。\nツールは控えめに。\nエディタ向け。\n  Codex だけ。\n終わり。\n"},
+		{"Cursor", "# 人格: fable\n共通の指示。\nツールは控えめに。\nエディタ向け。\n終わり。\n"},
+	}
+	for _, tt := range tests {
+		got, err := FilterPlatformSections(gatedPersona, tt.platform)
+		if err != nil || got != tt.want {
+			t.Errorf("FilterPlatformSections(%q) = %q, %v; want %q", tt.platform, got, err, tt.want)
+		}
+	}
+
+	plain := "# 人格\n<!-- a note -->\n"
+	if got, err := FilterPlatformSections(plain, "cursor"); err != nil || got != plain {
+		t.Errorf("FilterPlatformSections() changed content without directives: %q, %v", got, err)
+	}
+}
+
+func TestFilterPlatformSections_Errors(t *testing.T) {
+	for _, content := range []string{
+		"<!-- if: cursor -->\nno end\n",
+		"<!-- endif -->\n",
+		"<!-- else -->\n",
+		"<!-- if: cursor -->\n<!-- else -->\n<!-- else -->\n<!-- endif -->\n",
+		"<!-- if: -->\n<!-- endif -->\n",
+	} {
+		if _, err := FilterPlatformSections(content, "cursor"); err == nil {
+			t.Errorf("FilterPlatformSections(%q) should fail", content)
+		}
+	}
+}
+
+func TestScanPersona_IgnoresPlatformDirectives(t *testing.T) {
+	if findings := ScanPersona([]byte(gatedPersona)); len(findings) != 0 {
+		t.Errorf("ScanPersona() = %v, want no findings for platform directives", findings)
+	}
+}
+
+func TestHandleSessionStartForPlatform_FiltersSections(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvPersona, "")
+	t.Setenv(EnvProfile, "")
+	t.Chdir(t.TempDir())
+	personasDir := filepath.Join(home, ".agents", "ccpersona", "personas")
+	if err := os.MkdirAll(personasDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(personasDir, "fable.md"), []byte(gatedPersona), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveConfig(".", &Config{Name: "fable"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, w, _ := os.Pipe()
+	stdout := os.Stdout
+	os.Stdout = w
+	err := HandleSessionStartForPlatform(PlatformCursor)
+	_ = w.Close()
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := io.ReadAll(r)
+	if strings.Contains(string(out), "Task ツール") || strings.Contains(string(out), "<!--") || !strings.Contains(string(out), "エディタ向け。") {
+		t.Errorf("applied persona for cursor = %q", out)
+	}
+}
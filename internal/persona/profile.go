@@ -0,0 +1,196 @@
+package persona
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/daikw/ccpersona/internal/notify"
+	"github.com/rs/zerolog/log"
+)
+
+// EnvProfile names the environment variable that selects a config profile
+// for the current process and the hooks it starts.
+const EnvProfile = "CCPERSONA_PROFILE"
+
+var warnedMissingProfile sync.Map
+
+// Profile overrides part of the config in one context, such as work or
+// home. Fields set in a profile replace the same fields of the top-level
+// voice and notifications settings; lists such as notification rules are
+// replaced as a whole.
+type Profile struct {
+	Voice         *VoiceConfig   `json:"voice,omitempty"`
+	Notifications *notify.Config `json:"notifications,omitempty"`
+}
+
+// PlatformConfig overrides settings for the hooks of one platform, such as
+// a quick local voice for Codex turns and a cloud voice for Claude Code.
+// Fields set here replace the same voice fields after any profile applies.
+type PlatformConfig struct {
+	Voice *VoiceConfig `json:"voice,omitempty"`
+}
+
+// ProfileOverride returns the profile selected by CCPERSONA_PROFILE, or ""
+// when unset.
+func ProfileOverride() string {
+	return strings.TrimSpace(os.Getenv(EnvProfile))
+}
+
+// ProfileNames returns the configured profile names in sorted order.
+func (c *Config) ProfileNames() []string {
+	if c == nil {
+		return nil
+	}
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile returns a copy of config with the named profile overlaid. The
+// input config is never modified. An unknown name is an error.
+func ApplyProfile(config *Config, name string) (*Config, error) {
+	if config == nil {
+		return nil, fmt.Errorf("profile %q: no config file", name)
+	}
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (configured: %s)", name, strings.Join(config.ProfileNames(), ", "))
+	}
+	out := *config
+	if profile == nil {
+		return &out, nil
+	}
+	var err error
+	if out.Voice, err = overlay(config.Voice, profile.Voice); err != nil {
+		return nil, fmt.Errorf("profile %q: voice: %w", name, err)
+	}
+	if out.Notifications, err = overlay(config.Notifications, profile.Notifications); err != nil {
+		return nil, fmt.Errorf("profile %q: notifications: %w", name, err)
+	}
+	return &out, nil
+}
+
+// WithGlobalProfiles adds the global config's profiles to a project config;
+// a project profile of the same name wins.
+func WithGlobalProfiles(config *Config) *Config {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return config
+	}
+	global, err := loadConfigFile(ConfigPath(homeDir))
+	if err != nil {
+		log.Debug().Err(err).Msg("Ignoring profiles of unreadable global config")
+		return config
+	}
+	return mergeProfiles(config, global)
+}
+
+func mergeProfiles(config, global *Config) *Config {
+	if config == nil || global == nil || len(global.Profiles) == 0 {
+		return config
+	}
+	merged := make(map[string]*Profile, len(global.Profiles)+len(config.Profiles))
+	for name, profile := range global.Profiles {
+		merged[name] = profile
+	}
+	for name, profile := range config.Profiles {
+		merged[name] = profile
+	}
+	out := *config
+	out.Profiles = merged
+	return &out
+}
+
+// overlay returns a deep copy of base with the fields set in top written
+// over it, using JSON so that omitted fields keep their base value.
+func overlay[T any](base, top *T) (*T, error) {
+	if top == nil {
+		return base, nil
+	}
+	out := new(T)
+	if base != nil {
+		data, err := json.Marshal(base)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, out); err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.Marshal(top)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// applyProfileOverride applies the CCPERSONA_PROFILE profile, which may be
+// defined in the loaded config or the global one. An unknown profile is
+// reported once on stderr and ignored, so hooks keep working.
+func applyProfileOverride(config *Config) *Config {
+	name := ProfileOverride()
+	if name == "" || config == nil {
+		return config
+	}
+	out, err := ApplyProfile(WithGlobalProfiles(config), name)
+	if err != nil {
+		if _, loaded := warnedMissingProfile.LoadOrStore(name, true); !loaded {
+			fmt.Fprintf(os.Stderr, "ccpersona: %v; using the top-level settings\n", err)
+		}
+		return config
+	}
+	return out
+}
+
+// ApplyPlatform returns a copy of config with the platforms entry for the
+// hook platform overlaid; "claude" is an alias of "claude-code". Config is
+// returned unchanged for "" (commands not run by a hook) or a platform
+// without an entry.
+func ApplyPlatform(config *Config, platform string) (*Config, error) {
+	entry := config.PlatformOverride(platform)
+	if entry == nil {
+		return config, nil
+	}
+	out := *config
+	var err error
+	if out.Voice, err = overlay(config.Voice, entry.Voice); err != nil {
+		return nil, fmt.Errorf("platforms.%s: voice: %w", normalizePlatform(platform), err)
+	}
+	return &out, nil
+}
+
+// PlatformOverride returns the platforms entry for a hook platform, or nil;
+// safe on nil.
+func (c *Config) PlatformOverride(platform string) *PlatformConfig {
+	if c == nil || platform == "" {
+		return nil
+	}
+	platform = normalizePlatform(platform)
+	for name, entry := range c.Platforms {
+		if normalizePlatform(name) == platform {
+			return entry
+		}
+	}
+	return nil
+}
+
+// applyPlatformOverride is ApplyPlatform for hooks, which keep the
+// top-level settings when the overlay cannot be applied.
+func applyPlatformOverride(config *Config, platform string) *Config {
+	out, err := ApplyPlatform(config, platform)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ccpersona: %v; using the top-level settings\n", err)
+		return config
+	}
+	return out
+}
@@ -0,0 +1,207 @@
+package persona
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/notify"
+)
+
+func profileConfig() *Config {
+	return &Config{
+		Name:  "fable",
+		Voice: &VoiceConfig{Provider: "aivisspeech", Speaker: 3, Volume: 1.0},
+		Notifications: &notify.Config{
+			Rules: []notify.Rule{{Event: "*", Channels: []string{notify.ChannelVoice}}},
+		},
+		Profiles: map[string]*Profile{
+			"work": {
+				Voice: &VoiceConfig{Provider: "openai", Voice: "nova"},
+				Notifications: &notify.Config{
+					DND: &notify.DND{Windows: []notify.DNDWindow{{Start: "12:00", End: "13:00"}}},
+				},
+			},
+			"home": {
+				Notifications: &notify.Config{
+					Rules: []notify.Rule{{Event: "*", Channels: []string{notify.ChannelDesktop}}},
+				},
+			},
+		},
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	config := profileConfig()
+
+	work, err := ApplyProfile(config, "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work.Voice.Provider != "openai" || work.Voice.Voice != "nova" || work.Voice.Speaker != 3 || work.Voice.Volume != 1.0 {
+		t.Errorf("work voice = %+v, want profile fields over the top-level voice", work.Voice)
+	}
+	if len(work.Notifications.Rules) != 1 || work.Notifications.DND == nil {
+		t.Errorf("work notifications = %+v, want top-level rules plus the profile schedule", work.Notifications)
+	}
+
+	home, err := ApplyProfile(config, "home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if home.Voice != config.Voice {
+		t.Error("a profile without voice settings keeps the top-level voice")
+	}
+	if got := home.Notifications.Rules[0].Channels[0]; got != notify.ChannelDesktop {
+		t.Errorf("home rules channel = %q, want the profile rules to replace the list", got)
+	}
+
+	if config.Voice.Provider != "aivisspeech" || config.Notifications.DND != nil || config.Notifications.Rules[0].Channels[0] != notify.ChannelVoice {
+		t.Error("input config must not be modified")
+	}
+
+	if _, err := ApplyProfile(config, "travel"); err == nil || !strings.Contains(err.Error(), "home, work") {
+		t.Errorf("ApplyProfile(unknown) error = %v, want the configured names", err)
+	}
+}
+
+func TestApplyEnvOverrides_Profile(t *testing.T) {
+	config := profileConfig()
+
+	t.Setenv(EnvProfile, "work")
+	t.Setenv(EnvPersona, "strict")
+	got := ApplyEnvOverrides(config)
+	if got.Name != "strict" || got.Voice.Provider != "openai" {
+		t.Errorf("ApplyEnvOverrides = name %q provider %q, want both overrides", got.Name, got.Voice.Provider)
+	}
+
+	t.Setenv(EnvProfile, "missing")
+	t.Setenv(EnvPersona, "")
+	if got := ApplyEnvOverrides(config); got != config {
+		t.Error("an unknown profile must leave the config unchanged")
+	}
+}
+
+func TestValidateConfig_Profiles(t *testing.T) {
+	config := profileConfig()
+	if err := ValidateConfig(config); err != nil {
+		t.Fatalf("ValidateConfig = %v", err)
+	}
+	config.Profiles["work"].Voice.Speed = 9
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "profiles.work") {
+		t.Errorf("ValidateConfig = %v, want an error naming the profile", err)
+	}
+}
+
+func TestApplyPlatform(t *testing.T) {
+	config := profileConfig()
+	config.Platforms = map[string]*PlatformConfig{
+		"codex":  {Voice: &VoiceConfig{Provider: "voicevox", Speaker: 1}},
+		"claude": {Voice: &VoiceConfig{Provider: "elevenlabs", Voice: "premium"}},
+	}
+
+	codex, err := ApplyPlatform(config, "codex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codex.Voice.Provider != "voicevox" || codex.Voice.Speaker != 1 || codex.Voice.Volume != 1.0 {
+		t.Errorf("codex voice = %+v, want the platform fields over the top-level voice", codex.Voice)
+	}
+	claude, err := ApplyPlatform(config, "claude-code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claude.Voice.Provider != "elevenlabs" {
+		t.Errorf("claude-code voice = %+v, want the claude alias to apply", claude.Voice)
+	}
+	for _, platform := range []string{"", "cursor"} {
+		if got, _ := ApplyPlatform(config, platform); got != config {
+			t.Errorf("ApplyPlatform(%q) should return the config unchanged", platform)
+		}
+	}
+	if config.Voice.Provider != "aivisspeech" {
+		t.Error("input config must not be modified")
+	}
+
+	// The platform overlay applies after the profile.
+	t.Setenv(EnvProfile, "work")
+	got := applyPlatformOverride(ApplyEnvOverrides(config), "codex")
+	if got.Voice.Provider != "voicevox" || got.Voice.Voice != "nova" {
+		t.Errorf("profile + platform voice = %+v", got.Voice)
+	}
+}
+
+func TestValidateConfig_Platforms(t *testing.T) {
+	config := profileConfig()
+	config.Platforms = map[string]*PlatformConfig{"codex": {Voice: &VoiceConfig{Speed: 9}}}
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "platforms.codex") {
+		t.Errorf("ValidateConfig = %v, want an error naming the platform", err)
+	}
+	config.Platforms = map[string]*PlatformConfig{"claude": {}, "claude-code": {}}
+	if err := ValidateConfig(config); err == nil || !strings.Contains(err.Error(), "same platform") {
+		t.Errorf("ValidateConfig = %v, want duplicate platforms rejected", err)
+	}
+}
+
+func TestLoadConfigWithFallbackForPlatform(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvProfile, "")
+	t.Chdir(t.TempDir())
+	writeConfigFile(t, home, `{
+  "name": "fable",
+  "voice": {"provider": "openai", "voice": "nova"},
+  "platforms": {"codex": {"voice": {"provider": "voicevox", "speaker": 3}}}
+}`)
+
+	codex, err := LoadConfigWithFallbackForPlatform(PlatformCodex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codex.Voice.Provider != "voicevox" || codex.Voice.Speaker != 3 {
+		t.Errorf("codex voice = %+v", codex.Voice)
+	}
+	claude, err := LoadConfigWithFallbackForPlatform(PlatformClaudeCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claude.Voice.Provider != "openai" {
+		t.Errorf("claude-code voice = %+v, want the top-level voice", claude.Voice)
+	}
+}
+
+func TestLoadConfigWithFallback_GlobalProfiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := t.TempDir()
+	t.Chdir(project)
+	writeConfigFile(t, home, `{
+  "name": "default",
+  "profiles": {
+    "meeting": {"voice": {"speed": 0.8}},
+    "work": {"voice": {"provider": "openai", "voice": "nova"}}
+  }
+}`)
+	writeConfigFile(t, project, `{
+  "name": "fable",
+  "voice": {"provider": "voicevox", "speaker": 3},
+  "profiles": {"work": {"voice": {"speaker": 8}}}
+}`)
+
+	t.Setenv(EnvProfile, "meeting")
+	config, err := LoadConfigWithFallback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Voice.Speed != 0.8 || config.Voice.Speaker != 3 {
+		t.Errorf("meeting voice = %+v, want the global profile over the project voice", config.Voice)
+	}
+
+	t.Setenv(EnvProfile, "work")
+	config, err = LoadConfigWithFallback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Voice.Provider != "voicevox" || config.Voice.Speaker != 8 {
+		t.Errorf("work voice = %+v, want the project's own profile", config.Voice)
+	}
+}
@@ -0,0 +1,105 @@
+package persona
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxRefsDepth bounds how deep FindConfigFiles descends below a search root.
+const maxRefsDepth = 6
+
+// skipRefsDirs are never searched for project configs.
+var skipRefsDirs = map[string]bool{".git": true, "node_modules": true, "vendor": true, ".venv": true}
+
+// PersonaRefs lists the fields of config that name persona: the active
+// persona, experiment rotations, worktree rules, and its personas entry.
+func PersonaRefs(config *Config, persona string) []string {
+	var refs []string
+	if config == nil {
+		return nil
+	}
+	if config.Name == persona {
+		refs = append(refs, "name")
+	}
+	if config.Experiment != nil {
+		for i, name := range config.Experiment.Personas {
+			if name == persona {
+				refs = append(refs, fmt.Sprintf("experiment.personas[%d]", i))
+			}
+		}
+	}
+	for i, rule := range config.Worktrees {
+		if rule.Persona == persona {
+			refs = append(refs, fmt.Sprintf("worktrees[%d].persona", i))
+		}
+	}
+	if _, ok := config.Personas[persona]; ok {
+		refs = append(refs, "personas."+persona)
+	}
+	return refs
+}
+
+// RenamePersonaRefs points every reference to oldName in config at newName
+// and returns how many it changed.
+func RenamePersonaRefs(config *Config, oldName, newName string) int {
+	changed := 0
+	if config.Name == oldName {
+		config.Name = newName
+		changed++
+	}
+	if config.Experiment != nil {
+		for i, name := range config.Experiment.Personas {
+			if name == oldName {
+				config.Experiment.Personas[i] = newName
+				changed++
+			}
+		}
+	}
+	for i := range config.Worktrees {
+		if config.Worktrees[i].Persona == oldName {
+			config.Worktrees[i].Persona = newName
+			changed++
+		}
+	}
+	// The entry moves with the persona, replacing any left under newName.
+	if entry, ok := config.Personas[oldName]; ok {
+		delete(config.Personas, oldName)
+		config.Personas[newName] = entry
+		changed++
+	}
+	return changed
+}
+
+// FindProjectConfigs returns the project directories below root that have
+// an .agents/ccpersona.json, skipping VCS and dependency directories and
+// anything deeper than maxRefsDepth.
+func FindProjectConfigs(root string) ([]string, error) {
+	var dirs []string
+	root = filepath.Clean(root)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return fs.SkipDir
+		}
+		if !d.IsDir() || path == root {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		if skipRefsDirs[d.Name()] || strings.Count(rel, string(filepath.Separator)) >= maxRefsDepth {
+			return fs.SkipDir
+		}
+		if d.Name() == AgentsDir {
+			if _, err := os.Stat(filepath.Join(path, ConfigFileName)); err == nil {
+				dirs = append(dirs, filepath.Dir(path))
+			}
+			return fs.SkipDir
+		}
+		return nil
+	})
+	return dirs, err
+}
@@ -0,0 +1,57 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPersonaRefs(t *testing.T) {
+	config := &Config{
+		Name:       "old",
+		Experiment: &ExperimentConfig{Personas: []string{"a", "old"}},
+		Worktrees:  []WorktreeRule{{Branch: "main", Persona: "b"}, {Branch: "exp/*", Persona: "old"}},
+		Personas: map[string]*PersonaConfig{
+			"old": {Voice: &VoiceConfig{Provider: "voicevox", Speaker: 3}},
+			"b":   {},
+		},
+	}
+	want := []string{"name", "experiment.personas[1]", "worktrees[1].persona", "personas.old"}
+	if got := PersonaRefs(config, "old"); !reflect.DeepEqual(got, want) {
+		t.Errorf("PersonaRefs() = %q, want %q", got, want)
+	}
+	if n := RenamePersonaRefs(config, "old", "new"); n != 4 {
+		t.Errorf("RenamePersonaRefs() = %d, want 4", n)
+	}
+	if refs := PersonaRefs(config, "old"); refs != nil {
+		t.Errorf("references left: %q", refs)
+	}
+	if config.Name != "new" || config.Experiment.Personas[1] != "new" || config.Worktrees[1].Persona != "new" {
+		t.Errorf("config not updated: %+v", config)
+	}
+	if entry := config.Personas["new"]; entry == nil || entry.Voice.Speaker != 3 || len(config.Personas) != 2 {
+		t.Errorf("personas = %+v, want the old entry under the new name", config.Personas)
+	}
+}
+
+func TestFindProjectConfigs(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{".", "a", filepath.Join("b", "c"), filepath.Join("node_modules", "x")} {
+		if err := SaveConfig(filepath.Join(root, dir), &Config{Name: "p"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "empty", AgentsDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindProjectConfigs(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{root, filepath.Join(root, "a"), filepath.Join(root, "b", "c")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindProjectConfigs() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,163 @@
+package persona
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/hook"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultResumePhrase is spoken when a session resumes and no phrase is
+// configured.
+const DefaultResumePhrase = "{persona}で再開します"
+
+// ResumeConfig sets what happens when a session is resumed or compacted.
+type ResumeConfig struct {
+	// Cue speaks Phrase when a session resumes or is compacted.
+	Cue bool `json:"cue"`
+	// Phrase is the cue; {persona} is replaced with the persona name.
+	Phrase string `json:"phrase,omitempty"`
+}
+
+// CueEnabled reports whether the resume cue is on; safe on nil.
+func (r *ResumeConfig) CueEnabled() bool {
+	return r != nil && r.Cue
+}
+
+// CuePhrase returns the cue for a persona.
+func (r *ResumeConfig) CuePhrase(name string) string {
+	phrase := DefaultResumePhrase
+	if r != nil && r.Phrase != "" {
+		phrase = r.Phrase
+	}
+	return strings.ReplaceAll(phrase, "{persona}", name)
+}
+
+// IsResumeSource reports whether a SessionStart source continues an earlier
+// context rather than starting a fresh one.
+func IsResumeSource(source string) bool {
+	return source == hook.StartSourceResume || source == hook.StartSourceCompact
+}
+
+// HandleSessionResume applies the persona for a SessionStart hook that
+// resumed or compacted a session. The transcript is checked first: when the
+// persona the session should have is still in its context, nothing is
+// printed. Otherwise the drift is logged and the persona printed again.
+// Other sources apply the persona as on startup.
+func HandleSessionResume(platform, sessionID, source, transcriptPath string) error {
+	req := applyRequest{platform: platform, sessionID: sessionID}
+	if IsResumeSource(source) {
+		req.source = source
+		req.transcript = transcriptPath
+	}
+	return applyPersona(req)
+}
+
+// verifyResumed reports whether content, the persona output the session
+// should have, is still in its context. A transcript that cannot be read
+// counts as missing, so the persona is printed again.
+func verifyResumed(req applyRequest, name, content string, state *SessionState) bool {
+	present, err := transcriptHasPersona(req.transcript, content)
+	if err != nil {
+		log.Debug().Err(err).Str("transcript", req.transcript).Msg("Cannot verify the resumed session; applying the persona")
+		return false
+	}
+	if present {
+		log.Debug().Str("source", req.source).Str("persona", name).Msg("Persona still in the session context")
+		return true
+	}
+	if req.source == hook.StartSourceCompact {
+		log.Debug().Str("persona", name).Msg("Applying the persona again after compaction")
+		return false
+	}
+	event := log.Warn().Str("persona", name).Str("session_id", req.sessionID)
+	if state != nil && state.Persona != "" && state.Persona != name {
+		event = event.Str("previous", state.Persona)
+	}
+	event.Msg("Persona drift: the resumed session does not have the expected persona; applying it again")
+	return false
+}
+
+// compactBoundary marks where Claude Code compacted a transcript; entries
+// before it are no longer in the context.
+var compactBoundary = []byte(`"compact_boundary"`)
+
+// transcriptHasPersona reports whether a Claude Code transcript carries
+// content after its last compaction.
+func transcriptHasPersona(path, content string) (bool, error) {
+	content = strings.TrimSpace(content)
+	if path == "" {
+		return false, errors.New("no transcript path")
+	}
+	if content == "" {
+		return false, errors.New("no persona content")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	// Lines are JSON, so match the escaped first line before decoding.
+	first, _, _ := strings.Cut(content, "\n")
+	var escaped bytes.Buffer
+	enc := json.NewEncoder(&escaped)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(first)
+	needle := bytes.Trim(bytes.TrimSpace(escaped.Bytes()), `"`)
+
+	found := false
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if bytes.Contains(line, compactBoundary) && isCompactBoundary(line) {
+			found = false
+		} else if !found && bytes.Contains(line, needle) {
+			var entry any
+			if json.Unmarshal(line, &entry) == nil && containsString(entry, content) {
+				found = true
+			}
+		}
+		if err == io.EOF {
+			return found, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+func isCompactBoundary(line []byte) bool {
+	var entry struct {
+		Subtype string `json:"subtype"`
+	}
+	return json.Unmarshal(line, &entry) == nil && entry.Subtype == "compact_boundary"
+}
+
+// containsString reports whether any string in a decoded JSON value
+// contains s.
+func containsString(v any, s string) bool {
+	switch v := v.(type) {
+	case string:
+		return strings.Contains(v, s)
+	case []any:
+		for _, item := range v {
+			if containsString(item, s) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, item := range v {
+			if containsString(item, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
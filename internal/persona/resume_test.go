@@ -0,0 +1,84 @@
+package persona
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTranscript(t *testing.T, entries ...any) string {
+	t.Helper()
+	var b strings.Builder
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTranscriptHasPersona(t *testing.T) {
+	const content = "# 人格: ずんだもん\n語尾は「なのだ」。<重要>\n"
+	hookOutput := map[string]any{
+		"type":       "attachment",
+		"attachment": map[string]any{"type": "hook_additional_context", "content": []any{content}},
+	}
+	user := map[string]any{"type": "user", "message": map[string]any{"content": "hello"}}
+	boundary := map[string]any{"type": "system", "subtype": "compact_boundary"}
+
+	tests := []struct {
+		name    string
+		entries []any
+		want    bool
+	}{
+		{"applied", []any{hookOutput, user}, true},
+		{"never applied", []any{user}, false},
+		{"compacted away", []any{hookOutput, user, boundary, user}, false},
+		{"applied after compaction", []any{hookOutput, boundary, hookOutput}, true},
+		{"edited since", []any{map[string]any{"content": "# 人格: ずんだもん\n語尾は「のだ」。"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transcriptHasPersona(writeTranscript(t, tt.entries...), content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("transcriptHasPersona = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := transcriptHasPersona("", content); err == nil {
+		t.Error("a missing transcript path should be an error")
+	}
+	if _, err := transcriptHasPersona(filepath.Join(t.TempDir(), "gone.jsonl"), content); err == nil {
+		t.Error("a missing transcript should be an error")
+	}
+}
+
+func TestResumeCue(t *testing.T) {
+	var unset *ResumeConfig
+	if unset.CueEnabled() {
+		t.Error("nil config should not speak a cue")
+	}
+	if got := unset.CuePhrase("zundamon"); got != "zundamonで再開します" {
+		t.Errorf("default phrase = %q", got)
+	}
+	r := &ResumeConfig{Cue: true, Phrase: "back as {persona}"}
+	if !r.CueEnabled() || r.CuePhrase("zundamon") != "back as zundamon" {
+		t.Errorf("configured phrase = %q", r.CuePhrase("zundamon"))
+	}
+	if !IsResumeSource("resume") || !IsResumeSource("compact") || IsResumeSource("startup") || IsResumeSource("") {
+		t.Error("IsResumeSource mismatch")
+	}
+}
@@ -0,0 +1,168 @@
+package persona
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxPersonaBytes is the largest persona an import accepts without review.
+// Hand-written personas are a few kilobytes; anything far larger is usually
+// padding that hides instructions from a quick read.
+const MaxPersonaBytes = 64 << 10
+
+// Finding kinds reported by ScanPersona.
+const (
+	FindingInjection     = "injection"
+	FindingHiddenUnicode = "hidden-unicode"
+	FindingHiddenContent = "hidden-content"
+	FindingOversized     = "oversized"
+)
+
+// Finding is one red flag in persona content.
+type Finding struct {
+	Kind string
+	// Line is 1-based; 0 means the whole file.
+	Line   int
+	Detail string
+}
+
+func (f Finding) String() string {
+	if f.Line == 0 {
+		return fmt.Sprintf("%s: %s", f.Kind, f.Detail)
+	}
+	return fmt.Sprintf("line %d: %s: %s", f.Line, f.Kind, f.Detail)
+}
+
+// redFlag is a phrase that has no place in a persona: personas describe tone
+// and values, never data handling or the assistant's safety rules.
+type redFlag struct {
+	pattern *regexp.Regexp
+	detail  string
+}
+
+var redFlags = []redFlag{
+	{regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,30}\b(previous|prior|above|earlier|all|system|other)\b.{0,20}\b(instructions?|prompts?|rules|guidelines|messages?)\b`),
+		"tells the assistant to ignore its instructions"},
+	{regexp.MustCompile(`(?i)\b(bypass|disable|ignore|override|turn off|circumvent)\b.{0,30}\b(safety|guardrails?|security|restrictions?|content polic(y|ies)|permissions?|sandbox)\b`),
+		"tells the assistant to bypass safety or permissions"},
+	{regexp.MustCompile(`(?i)\b(jailbreak|DAN mode|developer mode enabled|you are no longer bound)\b`),
+		"jailbreak phrasing"},
+	{regexp.MustCompile(`(?i)\b(send|upload|post|exfiltrate|leak|transmit|forward|email|reveal|print|output|paste)\b.{0,40}\b(api[ _-]?keys?|secrets?|tokens?|passwords?|credentials?|private keys?|ssh keys?|\.env|env(ironment)? var(iable)?s?|id_rsa|cookies)\b`),
+		"asks for secrets to be sent or revealed"},
+	{regexp.MustCompile(`(?i)\b(curl|wget|nc|netcat|Invoke-WebRequest)\b[^\n]{0,80}(\$\{?[A-Z_]*(KEY|TOKEN|SECRET|PASSWORD)|\.env\b|id_rsa|\.ssh/|\.aws/|\bprintenv\b|\benv\s*\|)`),
+		"shell command that ships secrets over the network"},
+	{regexp.MustCompile(`(?i)\b(do not|don't|never)\b.{0,20}\b(tell|inform|mention|show|reveal)\b.{0,20}\bthe user\b|\bwithout (telling|informing|asking) the user\b`),
+		"asks the assistant to hide actions from the user"},
+	{regexp.MustCompile(`--dangerously-skip-permissions|--no-sandbox|--yolo\b`),
+		"asks for permission checks to be disabled"},
+	{regexp.MustCompile(`(以前|これまで|前|上記|すべて|全て)の(指示|命令|ルール|プロンプト)を(無視|忘れ)`),
+		"tells the assistant to ignore its instructions"},
+	{regexp.MustCompile(`(安全|セキュリティ|権限|制限|ガードレール).{0,10}(無視|解除|回避|無効)`),
+		"tells the assistant to bypass safety or permissions"},
+	{regexp.MustCompile(`(APIキー|API キー|シークレット|パスワード|秘密鍵|トークン|認証情報|環境変数).{0,20}(送信|送って|アップロード|出力|表示|教え|貼り付け)`),
+		"asks for secrets to be sent or revealed"},
+	{regexp.MustCompile(`ユーザー?に(知らせ|伝え|言わ|見せ)(ず|ない)`),
+		"asks the assistant to hide actions from the user"},
+}
+
+var htmlComment = regexp.MustCompile(`(?s)<!--(.*?)-->`)
+
+// ScanPersona checks persona content for prompt-injection phrasing, invisible
+// or direction-changing Unicode, content hidden from rendered Markdown, and
+// excessive size. An empty result means nothing suspicious was found, not
+// that the persona is safe.
+func ScanPersona(content []byte) []Finding {
+	var findings []Finding
+	if len(content) > MaxPersonaBytes {
+		findings = append(findings, Finding{
+			Kind:   FindingOversized,
+			Detail: fmt.Sprintf("%d bytes exceeds %d", len(content), MaxPersonaBytes),
+		})
+	}
+	if !utf8.Valid(content) {
+		findings = append(findings, Finding{Kind: FindingHiddenUnicode, Detail: "content is not valid UTF-8"})
+	}
+
+	text := strings.TrimPrefix(string(content), "\uFEFF")
+	for i, line := range strings.Split(text, "\n") {
+		lineNo := i + 1
+		if r, ok := hiddenRune(line); ok {
+			findings = append(findings, Finding{
+				Kind:   FindingHiddenUnicode,
+				Line:   lineNo,
+				Detail: fmt.Sprintf("invisible character %U", r),
+			})
+		}
+		// Match on the visible text too, so zero-width characters cannot
+		// split a phrase past the patterns.
+		visible := stripHidden(line)
+		for _, flag := range redFlags {
+			if match := flag.pattern.FindString(visible); match != "" {
+				findings = append(findings, Finding{
+					Kind:   FindingInjection,
+					Line:   lineNo,
+					Detail: fmt.Sprintf("%s: %q", flag.detail, truncateRunes(match, 60)),
+				})
+			}
+		}
+	}
+
+	for _, loc := range htmlComment.FindAllStringSubmatchIndex(text, -1) {
+		// Platform directives are removed before the persona is applied.
+		if strings.TrimSpace(text[loc[2]:loc[3]]) == "" || platformDirective.MatchString(text[loc[0]:loc[1]]) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Kind:   FindingHiddenContent,
+			Line:   strings.Count(text[:loc[0]], "\n") + 1,
+			Detail: "HTML comment is invisible in rendered Markdown but still sent to the assistant",
+		})
+	}
+	return findings
+}
+
+// isHiddenRune reports characters that render as nothing or reorder text:
+// zero-width and other format characters (including bidi controls and tag
+// characters) and control characters other than tab and line endings.
+func isHiddenRune(r rune) bool {
+	switch r {
+	case '\t', '\n', '\r':
+		return false
+	case '\u00AD', '\u034F', '\u115F', '\u1160', '\u3164', '\uFFA0': // soft hyphen, grapheme joiner, Hangul fillers
+		return true
+	}
+	return unicode.Is(unicode.Cf, r) || unicode.IsControl(r) || (r >= 0xE0000 && r <= 0xE007F)
+}
+
+// hiddenRune returns the first hidden character in s. A zero-width joiner
+// after a symbol is part of an emoji sequence and is allowed.
+func hiddenRune(s string) (rune, bool) {
+	var prev rune
+	for _, r := range s {
+		if isHiddenRune(r) && !(r == '\u200D' && unicode.In(prev, unicode.So, unicode.Sk, unicode.Mn)) {
+			return r, true
+		}
+		prev = r
+	}
+	return 0, false
+}
+
+func stripHidden(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isHiddenRune(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,86 @@
+package persona
+
+import (
+	"strings"
+)
+
+// Heading is a markdown ATX heading ("## 口調") in a persona file.
+type Heading struct {
+	Level int
+	Title string
+	// Line is the zero-based line index of the heading.
+	Line int
+}
+
+// ParseHeading reports whether line is an ATX heading and returns it.
+// Closing hashes ("## 口調 ##") are dropped from the title.
+func ParseHeading(line string) (level int, title string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return 0, "", false
+	}
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false
+	}
+	title = strings.TrimSpace(rest)
+	if stripped := strings.TrimRight(title, "#"); stripped != title && (stripped == "" || strings.HasSuffix(stripped, " ")) {
+		title = strings.TrimSpace(stripped)
+	}
+	return level, title, true
+}
+
+// isFence reports whether line opens or closes a fenced code block.
+func isFence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// Headings lists the headings of a markdown document, skipping anything
+// inside fenced code blocks.
+func Headings(content string) []Heading {
+	var headings []Heading
+	inFence := false
+	for i, line := range strings.Split(content, "\n") {
+		if isFence(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if level, title, ok := ParseHeading(line); ok {
+			headings = append(headings, Heading{Level: level, Title: title, Line: i})
+		}
+	}
+	return headings
+}
+
+// Section returns the section whose heading matches title (case-insensitive,
+// surrounding space ignored): the heading line and everything up to the next
+// heading of the same or a higher level. ok is false when no heading matches.
+func Section(content, title string) (section string, ok bool) {
+	lines := strings.Split(content, "\n")
+	headings := Headings(content)
+	want := strings.TrimSpace(title)
+	for i, h := range headings {
+		if !strings.EqualFold(h.Title, want) {
+			continue
+		}
+		end := len(lines)
+		for _, next := range headings[i+1:] {
+			if next.Level <= h.Level {
+				end = next.Line
+				break
+			}
+		}
+		return strings.TrimRight(strings.Join(lines[h.Line:end], "\n"), "\n") + "\n", true
+	}
+	return "", false
+}
@@ -0,0 +1,62 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const sectionDoc = `# 人格: テスト
+
+## 口調
+丁寧に話す。
+
+### 語尾
+- です・ます
+
+## 考え方 ##
+` + "```md\n## not a heading\n```" + `
+- 慎重に
+`
+
+func TestHeadings(t *testing.T) {
+	var titles []string
+	for _, h := range Headings(sectionDoc) {
+		titles = append(titles, h.Title)
+	}
+	want := []string{"人格: テスト", "口調", "語尾", "考え方"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("Headings() = %q, want %q", titles, want)
+	}
+	for _, line := range []string{"#hashtag", "####### seven", "    # indented code"} {
+		if _, _, ok := ParseHeading(line); ok {
+			t.Errorf("ParseHeading(%q) should not be a heading", line)
+		}
+	}
+}
+
+func TestSection(t *testing.T) {
+	got, ok := Section(sectionDoc, " 口調 ")
+	want := "## 口調\n丁寧に話す。\n\n### 語尾\n- です・ます\n"
+	if !ok || got != want {
+		t.Errorf("Section(口調) = %q, %v; want %q", got, ok, want)
+	}
+	got, ok = Section(sectionDoc, "考え方")
+	if !ok || got != "## 考え方 ##\n```md\n## not a heading\n```\n- 慎重に\n" {
+		t.Errorf("Section(考え方) = %q, %v", got, ok)
+	}
+	if _, ok := Section(sectionDoc, "missing"); ok {
+		t.Error("Section(missing) should not match")
+	}
+}
+
+func TestSectionExamplePersona(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "examples", "personas", "zundamon.md"))
+	if err != nil {
+		t.Skip(err)
+	}
+	if _, ok := Section(string(data), "口調"); !ok {
+		t.Error("example persona should have a 口調 section")
+	}
+}
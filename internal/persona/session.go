@@ -2,7 +2,15 @@ package persona
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/daikw/ccpersona/internal/experiment"
+	"github.com/daikw/ccpersona/internal/memory"
+	"github.com/daikw/ccpersona/internal/trust"
+	"github.com/daikw/ccpersona/internal/usage"
 	"github.com/rs/zerolog/log"
 )
 
@@ -14,18 +22,87 @@ func HandleSessionStart() error {
 
 // HandleSessionStartForPlatform is the main entry point for hook functionality with platform support
 func HandleSessionStartForPlatform(platform string) error {
-	// Load persona configuration (project or global fallback, platform-aware)
-	config, err := LoadConfigWithFallbackForPlatform(platform)
+	return HandleSessionStartForSession(platform, "")
+}
+
+// HandleSessionStartForSession applies the persona for a hook event that
+// carries a session ID. Experiment mode uses the ID to keep one persona per
+// session across resumes.
+func HandleSessionStartForSession(platform, sessionID string) error {
+	return HandleSessionStartForWorkspace(platform, sessionID, nil, "")
+}
+
+// HandleSessionStartForWorkspace applies personas for a workspace with one or
+// more roots, such as a Cursor multi-root workspace. The root containing
+// filePath wins when the event names a file. Otherwise the first root with a
+// configuration supplies the persona, voice, and memory, and other roots
+// with a different persona or custom instructions are appended as sections
+// scoped to their root.
+func HandleSessionStartForWorkspace(platform, sessionID string, roots []string, filePath string) error {
+	return applyPersona(applyRequest{platform: platform, sessionID: sessionID, roots: roots, filePath: filePath})
+}
+
+// HandlePromptSubmit applies the persona for a UserPromptSubmit hook, or
+// prints nothing when the apply policy of the config says the session
+// already has it.
+func HandlePromptSubmit(platform, sessionID string) error {
+	return applyPersona(applyRequest{platform: platform, sessionID: sessionID, prompt: true})
+}
+
+// applyRequest describes the hook event a persona is applied for.
+type applyRequest struct {
+	platform  string
+	sessionID string
+	roots     []string
+	filePath  string
+	// prompt is set for prompt hooks, which follow the apply policy.
+	prompt bool
+	// source and transcript are set when a session resumed or was
+	// compacted, so the transcript is checked before printing.
+	source     string
+	transcript string
+}
+
+// applyPersona prints the persona of the workspace. Prompt hooks consult
+// the apply policy through the session state store; SessionStart prints
+// and resets the state unless a resumed session still has the persona.
+func applyPersona(req applyRequest) error {
+	platform, sessionID := req.platform, req.sessionID
+	configs, err := ResolveWorkspaceConfigs(platform, req.roots, req.filePath)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	if config == nil {
+	if len(configs) == 0 {
 		log.Debug().Msg("No persona configuration found")
 		return nil
 	}
+	config := configs[0].Config
+
+	decision := newApplyDecision(NewSessionStore(), sessionID, config.Apply, req.prompt)
+	if decision.skipBeforeRender() {
+		log.Debug().Str("policy", config.Apply.EffectivePolicy()).Msg("Persona already applied in this session")
+		decision.record(false, "", "")
+		return nil
+	}
 
-	log.Info().Str("persona", config.Name).Msg("Found persona configuration")
+	name := config.Name
+	if config.Experiment.Active() && PersonaOverride() == "" {
+		if assigned, err := assignExperimentPersona(config.Experiment, sessionID); err != nil {
+			log.Warn().Err(err).Msg("Failed to assign experiment persona; using configured persona")
+		} else if assigned != "" {
+			name = assigned
+		}
+	}
+
+	log.Info().Str("persona", name).Str("root", configs[0].Root).Msg("Found persona configuration")
+	if sessionID != "" {
+		project := configs[0].Root
+		if project == "" {
+			project, _ = os.Getwd()
+		}
+		usage.Record(usage.Entry{Kind: usage.KindSession, Project: project, Persona: name, Platform: platform, Session: sessionID})
+	}
 
 	// Read persona content
 	manager, err := NewManager()
@@ -33,23 +110,164 @@ func HandleSessionStartForPlatform(platform string) error {
 		return fmt.Errorf("failed to create manager: %w", err)
 	}
 
-	content, err := manager.ReadPersonaForContext(config.Name)
+	content, err := readPersonaForRoot(manager, name, platform, config, configs[0].Root)
 	if err != nil {
 		return fmt.Errorf("failed to read persona: %w", err)
 	}
+	exportPersonaEnv(manager, name)
 
-	// Output persona content to stdout
-	fmt.Print(content)
+	var out strings.Builder
+	out.WriteString(content)
 
 	// Append custom instructions if configured
 	if config.CustomInstructions != "" {
-		fmt.Printf("\n%s\n", config.CustomInstructions)
+		fmt.Fprintf(&out, "\n%s\n", config.CustomInstructions)
+	}
+
+	// Append project memory if enabled
+	if section := memorySection(config); section != "" {
+		fmt.Fprintf(&out, "\n%s", section)
+	}
+
+	// Append the other workspace roots
+	for _, rc := range configs[1:] {
+		out.WriteString(rootSection(manager, rc, name, platform))
 	}
 
 	// Append speak instruction if voice is configured
 	if config.Voice != nil {
-		fmt.Print("\n## speak ツールの利用\nユーザーへの確認・許可を求める際、作業完了の報告、または自発的に話しかけたい場面では、\nspeak MCP ツールを使って発話してください。\n")
+		out.WriteString("\n## speak ツールの利用\nユーザーへの確認・許可を求める際、作業完了の報告、または自発的に話しかけたい場面では、\nspeak MCP ツールを使って発話してください。\n")
+	}
+
+	hash := contentHash(out.String())
+	if req.source != "" && verifyResumed(req, name, out.String(), decision.state) {
+		decision.record(true, name, hash)
+		return nil
+	}
+	if decision.skipAfterRender(hash) {
+		log.Debug().Str("persona", name).Msg("Persona unchanged since it was last applied")
+		decision.record(false, name, hash)
+		return nil
 	}
 
+	// Output persona content to stdout
+	fmt.Print(out.String())
+	decision.record(true, name, hash)
 	return nil
 }
+
+// readPersonaForRoot reads a persona as AI context with the sections for
+// other platforms dropped and its template placeholders resolved for root.
+// A signed persona that no longer matches its signature is refused; content
+// that cannot be processed is logged and used as written.
+func readPersonaForRoot(manager *Manager, name, platform string, config *Config, root string) (string, error) {
+	raw, err := manager.ReadPersona(name)
+	if err != nil {
+		return "", err
+	}
+	store, err := trust.DefaultStore()
+	if err != nil {
+		return "", err
+	}
+	if err := manager.checkSignature(store, name, raw); err != nil {
+		return "", err
+	}
+	content := stripYAMLFrontMatter(raw)
+	if filtered, err := FilterPlatformSections(content, platform); err != nil {
+		log.Warn().Err(err).Str("persona", name).Msg("Failed to filter platform sections; using the persona as written")
+	} else {
+		content = filtered
+	}
+	rendered, err := RenderPersona(content, TemplateVars(config, root))
+	if err != nil {
+		log.Warn().Err(err).Str("persona", name).Msg("Failed to resolve persona template; using it as written")
+		return content, nil
+	}
+	return rendered, nil
+}
+
+// rootSection renders an additional workspace root. A root that shares the
+// primary persona only contributes its custom instructions; one with neither
+// a different persona nor instructions renders nothing.
+func rootSection(manager *Manager, rc RootConfig, primary, platform string) string {
+	var body strings.Builder
+	if rc.Config.Name != "" && rc.Config.Name != primary {
+		content, err := readPersonaForRoot(manager, rc.Config.Name, platform, rc.Config, rc.Root)
+		if err != nil {
+			log.Warn().Err(err).Str("root", rc.Root).Msg("Skipping workspace root persona")
+		} else {
+			body.WriteString(strings.TrimRight(content, "\n") + "\n")
+		}
+	}
+	if rc.Config.CustomInstructions != "" {
+		fmt.Fprintf(&body, "\n%s\n", rc.Config.CustomInstructions)
+	}
+	if body.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n## ワークスペース: %s\n%s 配下のファイルを扱うときは、以下の指示に従ってください。\n\n%s",
+		filepath.Base(rc.Root), rc.Root, body.String())
+}
+
+// memorySection refreshes the current project's memory from its transcripts
+// and renders it. Failures are logged and yield no section so a broken
+// transcript never blocks persona application.
+func memorySection(config *Config) string {
+	if config.Memory == nil || !config.Memory.Enabled {
+		return ""
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Warn().Err(err).Msg("Skipping memory: no home directory")
+		return ""
+	}
+	projectDir, err := os.Getwd()
+	if err != nil {
+		log.Warn().Err(err).Msg("Skipping memory: no working directory")
+		return ""
+	}
+
+	maxChars := memory.EffectiveMaxChars(config.Memory.MaxChars)
+	file, err := memory.Refresh(homeDir, projectDir, maxChars)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to refresh memory")
+	}
+	if file == nil {
+		return ""
+	}
+	return memory.Render(file.Facts, maxChars)
+}
+
+// HandleSessionEnd records the end of a session for experiment reports.
+func HandleSessionEnd(sessionID string) error {
+	config, err := LoadConfigWithFallback()
+	if err != nil || config == nil || !config.Experiment.Active() {
+		return err
+	}
+	experimentLog, err := projectExperimentLog()
+	if err != nil {
+		return err
+	}
+	return experimentLog.End(sessionID, time.Now().UTC())
+}
+
+func assignExperimentPersona(exp *ExperimentConfig, sessionID string) (string, error) {
+	experimentLog, err := projectExperimentLog()
+	if err != nil {
+		return "", err
+	}
+	return experimentLog.Assign(sessionID, exp.Personas, time.Now().UTC())
+}
+
+// projectExperimentLog returns the experiment log for the current project.
+func projectExperimentLog() (*experiment.Log, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return &experiment.Log{Path: experiment.LogPath(homeDir, projectDir)}, nil
+}
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/daikw/ccpersona/internal/memory"
 )
 
 func TestHandleSessionStart(t *testing.T) {
@@ -33,6 +35,8 @@ func TestHandleSessionStart(t *testing.T) {
 	defer func() {
 		_ = os.Setenv("HOME", originalHome)
 	}()
+	// Keep the session state store inside the test.
+	t.Setenv("TMPDIR", tmpDir)
 
 	t.Run("NoPersonaConfig", func(t *testing.T) {
 		if err := HandleSessionStart(); err != nil {
@@ -169,4 +173,121 @@ func TestHandleSessionStart(t *testing.T) {
 			t.Errorf("Expected speak instruction in stdout, got: %q", output)
 		}
 	})
+
+	t.Run("WithMemory", func(t *testing.T) {
+		personasDir := filepath.Join(tmpDir, ".claude", "personas")
+		if err := os.WriteFile(filepath.Join(personasDir, "memory-test.md"), []byte("# 人格: memory-test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := SaveConfig(projectDir, &Config{Name: "memory-test", Memory: &MemoryConfig{Enabled: true}}); err != nil {
+			t.Fatal(err)
+		}
+
+		cwd, _ := os.Getwd()
+		transcriptDir := memory.ProjectTranscriptDir(tmpDir, cwd)
+		if err := os.MkdirAll(transcriptDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		transcript := `{"type":"user","timestamp":"2026-01-01T00:00:00Z","message":{"role":"user","content":"Always run make lint before committing. Fix the bug."}}` + "\n"
+		if err := os.WriteFile(filepath.Join(transcriptDir, "s.jsonl"), []byte(transcript), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		origStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := HandleSessionStart()
+
+		_ = w.Close()
+		os.Stdout = origStdout
+
+		outputBytes, _ := io.ReadAll(r)
+		output := string(outputBytes)
+
+		if err != nil {
+			t.Errorf("Failed to handle session start: %v", err)
+		}
+		if !strings.Contains(output, memory.SectionTitle) || !strings.Contains(output, "- Always run make lint before committing") {
+			t.Errorf("Expected memory section in stdout, got: %q", output)
+		}
+		if strings.Contains(output, "Fix the bug") {
+			t.Errorf("One-off requests should not be remembered, got: %q", output)
+		}
+	})
+
+	t.Run("WithExperiment", func(t *testing.T) {
+		personasDir := filepath.Join(tmpDir, ".claude", "personas")
+		for _, name := range []string{"exp-a", "exp-b"} {
+			if err := os.WriteFile(filepath.Join(personasDir, name+".md"), []byte("# 人格: "+name), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		config := &Config{Name: "test", Experiment: &ExperimentConfig{Personas: []string{"exp-a", "exp-b"}}}
+		if err := SaveConfig(projectDir, config); err != nil {
+			t.Fatal(err)
+		}
+
+		capture := func(sessionID string) string {
+			origStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			err := HandleSessionStartForSession("", sessionID)
+			_ = w.Close()
+			os.Stdout = origStdout
+			if err != nil {
+				t.Fatalf("Failed to handle session start: %v", err)
+			}
+			out, _ := io.ReadAll(r)
+			return string(out)
+		}
+
+		if out := capture("s1"); !strings.Contains(out, "# 人格: exp-a") {
+			t.Errorf("first session should use exp-a, got: %q", out)
+		}
+		if out := capture("s2"); !strings.Contains(out, "# 人格: exp-b") {
+			t.Errorf("second session should use exp-b, got: %q", out)
+		}
+		if out := capture("s1"); !strings.Contains(out, "# 人格: exp-a") {
+			t.Errorf("resumed session should keep exp-a, got: %q", out)
+		}
+		if err := HandleSessionEnd("s1"); err != nil {
+			t.Errorf("HandleSessionEnd() error = %v", err)
+		}
+	})
+
+	t.Run("WithResume", func(t *testing.T) {
+		if err := SaveConfig(projectDir, &Config{Name: "test"}); err != nil {
+			t.Fatal(err)
+		}
+		capture := func(source, transcript string) string {
+			origStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			err := HandleSessionResume("", "resume-1", source, transcript)
+			_ = w.Close()
+			os.Stdout = origStdout
+			if err != nil {
+				t.Fatalf("Failed to handle session resume: %v", err)
+			}
+			out, _ := io.ReadAll(r)
+			return string(out)
+		}
+
+		applied := capture("startup", "")
+		if !strings.Contains(applied, "# 人格: test") {
+			t.Fatalf("startup should print the persona, got: %q", applied)
+		}
+		withPersona := writeTranscript(t, map[string]any{"type": "system", "content": applied})
+		if out := capture("resume", withPersona); out != "" {
+			t.Errorf("a resumed session that has the persona should print nothing, got: %q", out)
+		}
+		compacted := writeTranscript(t, map[string]any{"type": "system", "content": applied}, map[string]any{"type": "system", "subtype": "compact_boundary"})
+		if out := capture("compact", compacted); out != applied {
+			t.Errorf("a compacted session should get the persona again, got: %q", out)
+		}
+		if out := capture("resume", ""); out != applied {
+			t.Errorf("a session without a transcript should get the persona again, got: %q", out)
+		}
+	})
 }
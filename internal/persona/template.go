@@ -0,0 +1,86 @@
+package persona
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// EnvUserName sets the {{.UserName}} persona template value.
+const EnvUserName = "CCPERSONA_USER_NAME"
+
+// TemplateVars returns the values persona markdown can reference when it is
+// applied in root: UserName, Project, Date, and Language, overridden and
+// extended by the config's variables.
+func TemplateVars(config *Config, root string) map[string]string {
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	vars := map[string]string{
+		"UserName": templateUserName(),
+		"Project":  filepath.Base(root),
+		"Date":     time.Now().Format("2006-01-02"),
+		"Language": templateLanguage(config),
+	}
+	if config != nil {
+		for name, value := range config.Variables {
+			vars[name] = value
+		}
+	}
+	return vars
+}
+
+// RenderPersona resolves Go template placeholders such as {{.Project}} in
+// persona content. Content without placeholders is returned unchanged. An
+// unknown variable or a malformed template is an error, so callers can fall
+// back to the raw content.
+func RenderPersona(content string, vars map[string]string) (string, error) {
+	if !strings.Contains(content, "{{") {
+		return content, nil
+	}
+	tmpl, err := template.New("persona").Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func templateUserName() string {
+	if name := strings.TrimSpace(os.Getenv(EnvUserName)); name != "" {
+		return name
+	}
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	if u.Name != "" {
+		return u.Name
+	}
+	return u.Username
+}
+
+// templateLanguage is the voice language from config, else the language of
+// the locale (LC_ALL, LC_MESSAGES, LANG), such as "ja" for ja_JP.UTF-8.
+func templateLanguage(config *Config) string {
+	if config != nil && config.Voice != nil && config.Voice.Language != "" {
+		return config.Voice.Language
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" || locale == "C" || locale == "POSIX" {
+			continue
+		}
+		if i := strings.IndexAny(locale, "_.@"); i >= 0 {
+			locale = locale[:i]
+		}
+		return locale
+	}
+	return ""
+}
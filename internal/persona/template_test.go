@@ -0,0 +1,51 @@
+package persona
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateVars(t *testing.T) {
+	t.Setenv(EnvUserName, "Daiki")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "ja_JP.UTF-8")
+
+	vars := TemplateVars(&Config{Variables: map[string]string{"Team": "infra", "Project": "ccp"}}, "/work/ccpersona")
+	want := map[string]string{
+		"UserName": "Daiki",
+		"Project":  "ccp",
+		"Date":     time.Now().Format("2006-01-02"),
+		"Language": "ja",
+		"Team":     "infra",
+	}
+	for name, value := range want {
+		if vars[name] != value {
+			t.Errorf("vars[%q] = %q, want %q", name, vars[name], value)
+		}
+	}
+
+	vars = TemplateVars(&Config{Voice: &VoiceConfig{Language: "en"}}, "/work/ccpersona")
+	if vars["Project"] != "ccpersona" || vars["Language"] != "en" {
+		t.Errorf("vars = %v, want the root's base name and the voice language", vars)
+	}
+}
+
+func TestRenderPersona(t *testing.T) {
+	vars := map[string]string{"UserName": "Daiki", "Project": "ccpersona"}
+
+	got, err := RenderPersona("# {{.Project}}\n{{.UserName}}さんと話すのだ。", vars)
+	if err != nil || got != "# ccpersona\nDaikiさんと話すのだ。" {
+		t.Errorf("RenderPersona() = %q, %v", got, err)
+	}
+	if got, err := RenderPersona("no placeholders", vars); err != nil || got != "no placeholders" {
+		t.Errorf("RenderPersona() without placeholders = %q, %v", got, err)
+	}
+	if _, err := RenderPersona("{{.Unknown}}", vars); err == nil || !strings.Contains(err.Error(), "Unknown") {
+		t.Errorf("RenderPersona() with an unknown variable error = %v", err)
+	}
+	if _, err := RenderPersona("{{.Project", vars); err == nil {
+		t.Error("RenderPersona() should reject a malformed template")
+	}
+}
@@ -1,6 +1,12 @@
 package persona
 
-import "github.com/daikw/ccpersona/internal/voice"
+import (
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/generate"
+	"github.com/daikw/ccpersona/internal/notify"
+	"github.com/daikw/ccpersona/internal/voice"
+)
 
 // Config represents the persona configuration for a project
 type Config struct {
@@ -8,6 +14,140 @@ type Config struct {
 	Voice              *VoiceConfig                      `json:"voice,omitempty"`
 	CustomInstructions string                            `json:"custom_instructions,omitempty"`
 	Engines            map[string]voice.EngineUserConfig `json:"engines,omitempty"`
+	Git                *GitConfig                        `json:"git,omitempty"`
+	Memory             *MemoryConfig                     `json:"memory,omitempty"`
+	Experiment         *ExperimentConfig                 `json:"experiment,omitempty"`
+	Accessibility      *voice.AccessibilityOptions       `json:"accessibility,omitempty"`
+	Notifications      *notify.Config                    `json:"notifications,omitempty"`
+	Ack                *AckConfig                        `json:"ack,omitempty"`
+	// Apply sets how often UserPromptSubmit hooks print the persona again.
+	Apply *ApplyConfig `json:"apply,omitempty"`
+	// Resume sets the cue spoken when a session resumes or is compacted.
+	Resume    *ResumeConfig  `json:"resume,omitempty"`
+	Worktrees []WorktreeRule `json:"worktrees,omitempty"`
+	// Variables are template values persona markdown can reference, such as
+	// {{.Team}}; they override the built-in values of the same name.
+	Variables map[string]string `json:"variables,omitempty"`
+	// Profiles are named overlays of voice and notification settings,
+	// selected with --profile or CCPERSONA_PROFILE.
+	Profiles map[string]*Profile `json:"profiles,omitempty"`
+	// Platforms overlay the voice settings for hooks from one assistant,
+	// keyed by platform: "claude-code", "codex", "cursor", or a custom
+	// source name.
+	Platforms map[string]*PlatformConfig `json:"platforms,omitempty"`
+	// Editor is the command the edit commands run, e.g. "code --wait". It is
+	// read from the global config only, so a cloned repository cannot choose
+	// a program to execute.
+	Editor string `json:"editor,omitempty"`
+	// PersonaIndex is the index URL or path `persona search` queries. It is
+	// read from the global config only, so a cloned repository cannot steer
+	// installs to its own index.
+	PersonaIndex string `json:"persona_index,omitempty"`
+	// Transcripts prunes old Claude Code transcripts automatically. It is
+	// read from the global config only, so a cloned repository cannot delete
+	// files outside it.
+	Transcripts *TranscriptsConfig `json:"transcripts,omitempty"`
+	// Generate selects the LLM endpoint `persona generate` drafts with. It
+	// is read from the global config only, so a cloned repository cannot
+	// send an API key to an endpoint of its choosing.
+	Generate *generate.Config `json:"generate,omitempty"`
+	// Usage sets how `config report` estimates provider costs.
+	Usage *UsageConfig `json:"usage,omitempty"`
+	// Voices names voices and lists favorites. A project config also gets
+	// the global config's entries.
+	Voices *VoicesConfig `json:"voices,omitempty"`
+	// Personas override the voice while one persona is active, keyed by
+	// persona name, after the voice in the persona's own front matter.
+	Personas map[string]*PersonaConfig `json:"personas,omitempty"`
+}
+
+// UsageConfig overrides the list prices, in USD per million characters,
+// that the usage report estimates provider costs with, e.g. for premium
+// voices or a negotiated rate: {"prices": {"elevenlabs": 165}}.
+type UsageConfig struct {
+	Prices map[string]float64 `json:"prices,omitempty"`
+}
+
+// PriceOverrides returns the configured prices; safe on nil.
+func (c *Config) PriceOverrides() map[string]float64 {
+	if c == nil || c.Usage == nil {
+		return nil
+	}
+	return c.Usage.Prices
+}
+
+// TranscriptsConfig prunes transcripts older than PruneOlderThan (such as
+// "30d") at session end, at most once a day, moving them to Archive when
+// set instead of deleting them.
+type TranscriptsConfig struct {
+	PruneOlderThan string `json:"prune_older_than,omitempty"`
+	Archive        string `json:"archive,omitempty"`
+}
+
+// AutoPrune reports whether automatic pruning is configured; safe on nil.
+func (t *TranscriptsConfig) AutoPrune() bool {
+	return t != nil && t.PruneOlderThan != ""
+}
+
+// AckConfig plays a short spoken acknowledgement when a prompt is submitted,
+// before the agent's turn starts. Phrases are synthesized once per persona
+// and voice setting, then played from the cache.
+type AckConfig struct {
+	Enabled bool     `json:"enabled"`
+	Phrases []string `json:"phrases,omitempty"`
+}
+
+// DefaultAckPhrases are used when no phrases are configured.
+var DefaultAckPhrases = []string{"了解です", "承知しました"}
+
+// IsEnabled reports whether prompt acknowledgements are on; safe on nil.
+func (a *AckConfig) IsEnabled() bool {
+	return a != nil && a.Enabled
+}
+
+// EffectivePhrases returns the configured phrases, or the defaults.
+func (a *AckConfig) EffectivePhrases() []string {
+	if a != nil {
+		var phrases []string
+		for _, p := range a.Phrases {
+			if strings.TrimSpace(p) != "" {
+				phrases = append(phrases, p)
+			}
+		}
+		if len(phrases) > 0 {
+			return phrases
+		}
+	}
+	return DefaultAckPhrases
+}
+
+// ExperimentConfig alternates personas across sessions in a project. It is
+// active when at least two personas are listed; the name field is then ignored
+// at session start.
+type ExperimentConfig struct {
+	Personas []string `json:"personas"`
+}
+
+// Active reports whether the experiment should pick the persona.
+func (e *ExperimentConfig) Active() bool {
+	return e != nil && len(e.Personas) >= 2
+}
+
+// MemoryConfig enables the per-project memory section appended to the persona
+// at session start.
+type MemoryConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxChars caps the memory section (default 1500, at most 4000).
+	MaxChars int `json:"max_chars,omitempty"`
+}
+
+// GitConfig opts a repository into spoken git hook announcements installed by
+// `ccpersona config integrate git`.
+type GitConfig struct {
+	Enabled bool `json:"enabled"`
+	// Templates overrides the announcement per hook name (post-commit,
+	// post-merge, pre-push) using text/template syntax.
+	Templates map[string]string `json:"templates,omitempty"`
 }
 
 // VoiceConfig represents the active voice synthesis settings for a persona.
@@ -35,20 +175,168 @@ type VoiceConfig struct {
 	Region     string `json:"region,omitempty"`
 	Engine     string `json:"engine,omitempty"`
 	SampleRate string `json:"sample_rate,omitempty"`
+
+	ReferenceWAV string `json:"reference_wav,omitempty"`
+	Language     string `json:"language,omitempty"`
+	SSML         bool   `json:"ssml,omitempty"`
+
+	ChunkChars     int `json:"chunk_chars,omitempty"`
+	ParallelChunks int `json:"parallel_chunks,omitempty"`
+
+	// SpeedRamp speeds up long readouts after the first sentence.
+	SpeedRamp *voice.SpeedRamp `json:"speed_ramp,omitempty"`
+
+	// Prosody gives VOICEVOX and AivisSpeech speakers the persona's own
+	// intonation, pitch, and silence.
+	Prosody *voice.Prosody `json:"prosody,omitempty"`
+
+	// SpeakerStyle picks a style of the speaker by name, such as "あまあま",
+	// with its emotional intensity. SpeakerStyles override it per
+	// notification urgency.
+	SpeakerStyle  *voice.SpeakerStyle            `json:"speaker_style,omitempty"`
+	SpeakerStyles map[string]*voice.SpeakerStyle `json:"speaker_styles,omitempty"`
+
+	// DiffMode reads only sentences that were not in the previous message
+	// spoken in the same session.
+	DiffMode bool `json:"diff_mode,omitempty"`
+
+	// ReadingMode is short (first line, default), full, or adaptive;
+	// MaxChars caps full and adaptive reading.
+	ReadingMode string                 `json:"reading_mode,omitempty"`
+	MaxChars    int                    `json:"max_chars,omitempty"`
+	Adaptive    *voice.AdaptiveOptions `json:"adaptive,omitempty"`
+
+	// UUIDMode reads the whole final turn in short mode too.
+	UUIDMode bool `json:"uuid_mode,omitempty"`
+
+	// Japanese normalizes text for VOICEVOX and AivisSpeech: character
+	// width, spoken symbols, pauses, and a reading dictionary.
+	Japanese *voice.JapaneseOptions `json:"japanese,omitempty"`
+
+	// Caption writes the text being spoken to a file or named pipe.
+	Caption *voice.CaptionConfig `json:"caption,omitempty"`
+
+	// Avatar sends speech events with lip-sync timing to the avatar bridge.
+	Avatar *voice.AvatarConfig `json:"avatar,omitempty"`
+
+	// Output plays speech on an audio device such as a virtual microphone.
+	Output *voice.OutputConfig `json:"output,omitempty"`
+
+	// Recent also saves everything spoken to a directory that keeps the
+	// newest files.
+	Recent *voice.RecentConfig `json:"recent,omitempty"`
+
+	// Cache keeps cloud provider audio of short messages, so repeated
+	// phrases are synthesized once. On by default.
+	Cache *voice.CacheConfig `json:"cache,omitempty"`
+
+	// RetryLadder shortens text a provider rejected for its length or
+	// content, one step per retry: "50%", a character count, "summary", or
+	// "first-sentence". An empty list turns retries off; a pointer keeps
+	// it apart from an unset ladder when the config is rewritten.
+	RetryLadder *[]string `json:"retry_ladder,omitempty"`
+
+	// SessionNames prefixes spoken hook output with the session's name
+	// ("apple session: ...") while other sessions are active. Default on.
+	SessionNames *bool `json:"session_names,omitempty"`
+
+	// Playback maps hook event names, or "*" for any event, to "wait"
+	// (default) or "detach", which lets the hook return while audio plays.
+	Playback map[string]string `json:"playback,omitempty"`
+
+	// Auto lists the candidates picked from per message when Provider is
+	// "auto".
+	Auto *voice.AutoConfig `json:"auto,omitempty"`
+
+	// speakerSet marks Speaker as chosen by a voice reference, so that
+	// speaker 0 is used rather than read as unset.
+	speakerSet bool
+}
+
+// SessionNamesEnabled reports whether spoken output names its session.
+func (c *Config) SessionNamesEnabled() bool {
+	return c == nil || c.Voice == nil || c.Voice.SessionNames == nil || *c.Voice.SessionNames
+}
+
+// Playback modes for voice.playback.
+const (
+	PlaybackWait   = "wait"
+	PlaybackDetach = "detach"
+)
+
+// PlaybackMode returns how audio spoken for a hook event is played: the
+// voice.playback entry naming the event (case-insensitively), else the "*"
+// entry, else wait. Output that is not for a hook event (event "") waits.
+func (c *Config) PlaybackMode(event string) string {
+	if c == nil || c.Voice == nil || event == "" {
+		return PlaybackWait
+	}
+	mode := c.Voice.Playback["*"]
+	for name, m := range c.Voice.Playback {
+		if strings.EqualFold(name, event) {
+			mode = m
+			break
+		}
+	}
+	if mode == PlaybackDetach {
+		return PlaybackDetach
+	}
+	return PlaybackWait
 }
 
 // ToVoiceInput converts the unified config into the small resolver input used
 // for persona-level precedence.
 func (c *Config) ToVoiceInput() voice.PersonaVoiceInput {
-	if c == nil || c.Voice == nil {
+	if c == nil {
 		return voice.PersonaVoiceInput{}
 	}
-	return voice.PersonaVoiceInput{
-		Provider: c.Voice.Provider,
-		Speaker:  c.Voice.Speaker,
-		Volume:   c.Voice.Volume,
-		Speed:    c.Voice.Speed,
+	var input voice.PersonaVoiceInput
+	if v := c.activeVoice(); v != nil {
+		input = voice.PersonaVoiceInput{
+			Provider:   v.Provider,
+			Speaker:    v.Speaker,
+			SpeakerSet: v.speakerSet,
+			Volume:     v.Volume,
+			Speed:      v.Speed,
+		}
+	}
+	// The accessibility profile slows speech unless a speed is set explicitly.
+	if input.Speed == 0 && c.Accessibility.IsEnabled() {
+		input.Speed = c.Accessibility.EffectiveSpeed()
 	}
+	return input
+}
+
+// VoiceBaseConfig returns the voice defaults with persona-level reading
+// settings applied. Pass it to VoiceOptions.ToConfig.
+func (c *Config) VoiceBaseConfig() *voice.Config {
+	base := voice.DefaultConfig()
+	if c == nil {
+		return base
+	}
+	base.Persona = c.Name
+	if c.Accessibility.IsEnabled() {
+		base.Accessibility = c.Accessibility
+	}
+	if c.Voice != nil {
+		base.DiffMode = c.Voice.DiffMode
+		if c.Voice.ReadingMode != "" {
+			base.ReadingMode = c.Voice.ReadingMode
+		}
+		base.MaxChars = c.Voice.MaxChars
+		base.Adaptive = c.Voice.Adaptive
+		base.UUIDMode = c.Voice.UUIDMode
+		base.Japanese = c.Voice.Japanese
+		base.Caption = c.Voice.Caption
+		base.Avatar = c.Voice.Avatar
+		base.Output = c.Voice.Output
+		base.Recent = c.Voice.Recent
+		base.Cache = c.Voice.Cache
+		if c.Voice.RetryLadder != nil {
+			base.RetryLadder = *c.Voice.RetryLadder
+		}
+	}
+	return base
 }
 
 // ToVoiceConfigFile projects the unified config onto the voice resolver's file
@@ -62,20 +350,22 @@ func (c *Config) ToVoiceConfigFile() *voice.ConfigFile {
 	out := &voice.ConfigFile{
 		Engines: c.Engines,
 	}
-	if c.Voice == nil {
+	v := c.activeVoice()
+	if v == nil {
 		return out
 	}
 
-	provider := c.Voice.Provider
+	provider := v.Provider
 	out.DefaultProvider = provider
+	out.Auto = v.Auto
 	out.Defaults = &voice.DefaultsConfig{
-		Volume: c.Voice.Volume,
-		Speed:  c.Voice.Speed,
+		Volume: v.Volume,
+		Speed:  v.Speed,
 	}
 
 	if provider != "" {
 		out.Providers = map[string]voice.ProviderConfig{
-			provider: c.Voice.ToProviderConfig(),
+			provider: v.ToProviderConfig(),
 		}
 	}
 	return out
@@ -105,6 +395,15 @@ func (v *VoiceConfig) ToProviderConfig() voice.ProviderConfig {
 		Region:          v.Region,
 		Engine:          v.Engine,
 		SampleRate:      v.SampleRate,
+		ReferenceWAV:    v.ReferenceWAV,
+		Language:        v.Language,
+		SSML:            v.SSML,
+		ChunkChars:      v.ChunkChars,
+		ParallelChunks:  v.ParallelChunks,
+		SpeedRamp:       v.SpeedRamp,
+		Prosody:         v.Prosody,
+		SpeakerStyle:    v.SpeakerStyle,
+		SpeakerStyles:   v.SpeakerStyles,
 		Volume:          v.Volume,
 	}
 }
@@ -0,0 +1,115 @@
+package persona
+
+import (
+	"os"
+	"slices"
+
+	"github.com/daikw/ccpersona/internal/voice"
+	"github.com/rs/zerolog/log"
+)
+
+// VoicesConfig names voices and lists favorite ones. Aliases are accepted
+// wherever a voice is: --voice, voice.voice, and notification rules.
+type VoicesConfig struct {
+	Aliases voice.VoiceAliases `json:"aliases,omitempty"`
+	// Favorites are aliases or provider:voice references that list-voices
+	// shows first.
+	Favorites []string `json:"favorites,omitempty"`
+}
+
+// Validate checks the aliases. Favorites may name aliases of the global
+// config, so they are only checked once merged (see FavoriteVoices).
+func (v *VoicesConfig) Validate() error {
+	if v == nil {
+		return nil
+	}
+	return v.Aliases.Validate()
+}
+
+// LookupVoice resolves a voice alias or a provider:voice reference; safe on
+// nil.
+func (c *Config) LookupVoice(name string) (voice.VoiceRef, bool) {
+	var aliases voice.VoiceAliases
+	if c != nil && c.Voices != nil {
+		aliases = c.Voices.Aliases
+	}
+	return aliases.Lookup(name)
+}
+
+// FavoriteVoices returns the favorites in the configured order, skipping
+// (and logging) names that are neither an alias nor a reference.
+func (c *Config) FavoriteVoices() []voice.VoiceRef {
+	if c == nil || c.Voices == nil {
+		return nil
+	}
+	var refs []voice.VoiceRef
+	for _, favorite := range c.Voices.Favorites {
+		ref, ok := c.LookupVoice(favorite)
+		if !ok {
+			log.Warn().Str("favorite", favorite).Msg("voices.favorites names no alias or provider:voice reference")
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// activeVoice returns the voice block with an alias or provider:voice
+// reference in voice.voice expanded into the provider and voice or speaker.
+func (c *Config) activeVoice() *VoiceConfig {
+	if c.Voice == nil || c.Voice.Voice == "" {
+		return c.Voice
+	}
+	ref, ok := c.LookupVoice(c.Voice.Voice)
+	if !ok {
+		return c.Voice
+	}
+	v := *c.Voice
+	v.Provider = ref.Provider
+	if ref.HasSpeaker {
+		v.Speaker, v.speakerSet, v.Voice = ref.Speaker, true, ""
+	} else {
+		v.Voice = ref.Voice
+	}
+	return &v
+}
+
+// WithGlobalVoices adds the global config's voice aliases and favorites to
+// a project config; the project's own aliases win and its favorites come
+// first.
+func WithGlobalVoices(config *Config) *Config {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return config
+	}
+	global, err := loadConfigFile(ConfigPath(homeDir))
+	if err != nil {
+		log.Debug().Err(err).Msg("Ignoring voice aliases of unreadable global config")
+		return config
+	}
+	return mergeVoices(config, global)
+}
+
+func mergeVoices(config, global *Config) *Config {
+	if config == nil || global == nil || global.Voices == nil {
+		return config
+	}
+	merged := VoicesConfig{Aliases: voice.VoiceAliases{}}
+	for name, target := range global.Voices.Aliases {
+		merged.Aliases[name] = target
+	}
+	if config.Voices != nil {
+		for name, target := range config.Voices.Aliases {
+			merged.Aliases[name] = target
+		}
+		merged.Favorites = append(merged.Favorites, config.Voices.Favorites...)
+	}
+	for _, favorite := range global.Voices.Favorites {
+		if !slices.Contains(merged.Favorites, favorite) {
+			merged.Favorites = append(merged.Favorites, favorite)
+		}
+	}
+	out := *config
+	out.Voices = &merged
+	return &out
+}
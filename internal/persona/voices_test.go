@@ -0,0 +1,75 @@
+package persona
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/voice"
+)
+
+func TestActiveVoiceExpandsAlias(t *testing.T) {
+	config := &Config{
+		Voice:  &VoiceConfig{Provider: "openai", Voice: "zunda", Speaker: 1},
+		Voices: &VoicesConfig{Aliases: voice.VoiceAliases{"zunda": "voicevox:3", "narrator": "elevenlabs:abc"}},
+	}
+	if got := config.activeVoice(); got.Provider != "voicevox" || got.Speaker != 3 || got.Voice != "" {
+		t.Errorf("alias not expanded: %+v", got)
+	}
+	if config.Voice.Voice != "zunda" {
+		t.Error("expanding an alias should not modify the config")
+	}
+
+	config.Voice.Voice = "narrator"
+	if got := config.activeVoice(); got.Provider != "elevenlabs" || got.Voice != "abc" {
+		t.Errorf("alias not expanded: %+v", got)
+	}
+
+	config.Voice.Voice = "nova"
+	if got := config.activeVoice(); got.Provider != "openai" || got.Voice != "nova" {
+		t.Errorf("a plain voice ID should be kept: %+v", got)
+	}
+
+	config.Voice.Voice = "voicevox:0"
+	if got := config.ToVoiceInput(); got.Provider != "voicevox" || got.Speaker != 0 || !got.SpeakerSet {
+		t.Errorf("VOICEVOX style 0 not selected: %+v", got)
+	}
+}
+
+func TestMergeVoices(t *testing.T) {
+	project := &Config{Voices: &VoicesConfig{
+		Aliases:   voice.VoiceAliases{"zunda": "voicevox:3"},
+		Favorites: []string{"zunda"},
+	}}
+	global := &Config{Voices: &VoicesConfig{
+		Aliases:   voice.VoiceAliases{"zunda": "voicevox:1", "narrator": "elevenlabs:abc"},
+		Favorites: []string{"narrator", "zunda"},
+	}}
+	merged := mergeVoices(project, global)
+	if merged.Voices.Aliases["zunda"] != "voicevox:3" || merged.Voices.Aliases["narrator"] != "elevenlabs:abc" {
+		t.Errorf("aliases = %v", merged.Voices.Aliases)
+	}
+	if !slices.Equal(merged.Voices.Favorites, []string{"zunda", "narrator"}) {
+		t.Errorf("favorites = %v", merged.Voices.Favorites)
+	}
+	if project.Voices.Aliases["narrator"] != "" {
+		t.Error("merging should not modify the project config")
+	}
+	if got := mergeVoices(project, &Config{}); got != project {
+		t.Error("a global config without voices should leave the project config as is")
+	}
+}
+
+func TestFavoriteVoices(t *testing.T) {
+	config := &Config{Voices: &VoicesConfig{
+		Aliases:   voice.VoiceAliases{"zunda": "voicevox:3"},
+		Favorites: []string{"zunda", "missing", "openai:nova"},
+	}}
+	got := config.FavoriteVoices()
+	want := []voice.VoiceRef{{Provider: "voicevox", Speaker: 3, HasSpeaker: true}, {Provider: "openai", Voice: "nova"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("FavoriteVoices() = %+v, want %+v", got, want)
+	}
+	if (*Config)(nil).FavoriteVoices() != nil {
+		t.Error("nil config should have no favorites")
+	}
+}
@@ -0,0 +1,74 @@
+package persona
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// RootConfig is the configuration found in one workspace root. Root is empty
+// for configuration that did not come from a root (the working directory or
+// global fallback).
+type RootConfig struct {
+	Root   string
+	Config *Config
+}
+
+// RootForFile returns the workspace root that contains path, preferring the
+// deepest root when roots are nested. It returns "" when path is empty or
+// outside every root.
+func RootForFile(roots []string, path string) string {
+	if path == "" {
+		return ""
+	}
+	path = filepath.Clean(path)
+	best := ""
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if len(root) > len(best) {
+			best = root
+		}
+	}
+	return best
+}
+
+// ResolveWorkspaceConfigs resolves the configuration for a multi-root
+// workspace. When filePath lies inside a root, only that root is considered.
+// Otherwise every root with its own .agents/ccpersona.json contributes one
+// entry, in root order; the first entry is the primary configuration. When
+// no root has a configuration (or roots is empty), the usual working
+// directory and global fallback applies.
+func ResolveWorkspaceConfigs(platform string, roots []string, filePath string) ([]RootConfig, error) {
+	if root := RootForFile(roots, filePath); root != "" {
+		roots = []string{root}
+	}
+
+	var resolved []RootConfig
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		if seen[root] {
+			continue
+		}
+		seen[root] = true
+		config, err := LoadConfigForPlatform(root, platform)
+		if err != nil {
+			return nil, err
+		}
+		if config != nil {
+			resolved = append(resolved, RootConfig{Root: root, Config: WithPersonaVoice(ApplyEnvOverrides(ApplyWorktreeRules(config, root)))})
+		}
+	}
+	if len(resolved) > 0 {
+		return resolved, nil
+	}
+
+	config, err := LoadConfigWithFallbackForPlatform(platform)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	return []RootConfig{{Config: config}}, nil
+}
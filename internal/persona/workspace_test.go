@@ -0,0 +1,140 @@
+package persona
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRootForFile(t *testing.T) {
+	roots := []string{"/work/api", "/work/web", "/work/web/packages/ui"}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/work/api/main.go", "/work/api"},
+		{"/work/web/src/app.ts", "/work/web"},
+		{"/work/web/packages/ui/button.tsx", "/work/web/packages/ui"},
+		{"/work/webapp/index.ts", ""},
+		{"/elsewhere/file.go", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := RootForFile(roots, filepath.FromSlash(tt.path)); got != filepath.FromSlash(tt.want) {
+			t.Errorf("RootForFile(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// setupWorkspace creates three roots: api (persona careful), web (persona
+// playful with custom instructions), and docs (no config).
+func setupWorkspace(t *testing.T) (home string, roots []string) {
+	t.Helper()
+	home = t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CCPERSONA_PERSONA", "")
+	t.Chdir(t.TempDir())
+
+	personasDir := filepath.Join(home, AgentsDir, "ccpersona", "personas")
+	if err := os.MkdirAll(personasDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{"careful": "# 人格: careful\n", "playful": "# 人格: playful\n"} {
+		if err := os.WriteFile(filepath.Join(personasDir, name+".md"), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	base := t.TempDir()
+	api, web, docs := filepath.Join(base, "api"), filepath.Join(base, "web"), filepath.Join(base, "docs")
+	for _, dir := range []string{api, web, docs} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SaveConfig(api, &Config{Name: "careful"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveConfig(web, &Config{Name: "playful", CustomInstructions: "Use pnpm."}); err != nil {
+		t.Fatal(err)
+	}
+	return home, []string{docs, api, web}
+}
+
+func TestResolveWorkspaceConfigs(t *testing.T) {
+	home, roots := setupWorkspace(t)
+	docs, api, web := roots[0], roots[1], roots[2]
+
+	configs, err := ResolveWorkspaceConfigs("cursor", roots, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 2 || configs[0].Root != api || configs[1].Root != web {
+		t.Fatalf("configs = %+v, want api then web", configs)
+	}
+
+	configs, err = ResolveWorkspaceConfigs("cursor", roots, filepath.Join(web, "src", "app.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].Config.Name != "playful" {
+		t.Fatalf("file in web root: configs = %+v", configs)
+	}
+
+	// A file in a root without config falls back to the global config.
+	if err := SaveConfig(home, &Config{Name: "global"}); err != nil {
+		t.Fatal(err)
+	}
+	configs, err = ResolveWorkspaceConfigs("cursor", roots, filepath.Join(docs, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].Root != "" || configs[0].Config.Name != "global" {
+		t.Fatalf("file in docs root: configs = %+v", configs)
+	}
+
+	t.Setenv("CCPERSONA_PERSONA", "careful")
+	configs, _ = ResolveWorkspaceConfigs("cursor", roots, "")
+	for _, rc := range configs {
+		if rc.Config.Name != "careful" {
+			t.Errorf("override not applied to %s: %s", rc.Root, rc.Config.Name)
+		}
+	}
+}
+
+func TestHandleSessionStartForWorkspace(t *testing.T) {
+	_, roots := setupWorkspace(t)
+	web := roots[2]
+
+	capture := func(filePath string) string {
+		t.Helper()
+		origStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		err := HandleSessionStartForWorkspace("cursor", "", roots, filePath)
+		_ = w.Close()
+		os.Stdout = origStdout
+		out, _ := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out)
+	}
+
+	out := capture("")
+	if !strings.HasPrefix(out, "# 人格: careful\n") {
+		t.Errorf("primary persona should come first:\n%s", out)
+	}
+	for _, want := range []string{"## ワークスペース: web", web + " 配下", "# 人格: playful", "Use pnpm."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	out = capture(filepath.Join(web, "index.ts"))
+	if !strings.HasPrefix(out, "# 人格: playful\n") || strings.Contains(out, "careful") || strings.Contains(out, "ワークスペース") {
+		t.Errorf("file in web root should apply only its persona:\n%s", out)
+	}
+}
@@ -0,0 +1,153 @@
+package persona
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ScopeWorktree is reported by ResolveActive when a worktree rule picked the
+// persona.
+const ScopeWorktree = "worktree"
+
+// Worktree is the git checkout a directory belongs to.
+type Worktree struct {
+	// Root is the top-level directory of the checkout.
+	Root string
+	// Branch is the checked-out branch, or "" when HEAD is detached.
+	Branch string
+	// Linked reports a secondary worktree created by `git worktree add`.
+	Linked bool
+}
+
+// WorktreeRule selects a persona for matching git checkouts. Branch and
+// Worktree are glob patterns in path.Match syntax; an empty field matches
+// everything, but a rule needs at least one of them. The first matching rule
+// wins.
+type WorktreeRule struct {
+	// Branch matches the checked-out branch, e.g. "main" or "exp/*".
+	Branch string `json:"branch,omitempty"`
+	// Worktree matches the checkout's directory name or its full path.
+	Worktree string `json:"worktree,omitempty"`
+	Persona  string `json:"persona"`
+}
+
+func (r WorktreeRule) matches(wt *Worktree) bool {
+	if r.Branch != "" {
+		if ok, _ := path.Match(r.Branch, wt.Branch); !ok {
+			return false
+		}
+	}
+	if r.Worktree != "" {
+		root := filepath.ToSlash(wt.Root)
+		nameOK, _ := path.Match(r.Worktree, path.Base(root))
+		pathOK, _ := path.Match(r.Worktree, root)
+		if !nameOK && !pathOK {
+			return false
+		}
+	}
+	return true
+}
+
+func validateWorktreeRules(rules []WorktreeRule) error {
+	for i, rule := range rules {
+		if rule.Persona == "" {
+			return fmt.Errorf("worktrees[%d]: persona is required", i)
+		}
+		if rule.Branch == "" && rule.Worktree == "" {
+			return fmt.Errorf("worktrees[%d]: branch or worktree is required", i)
+		}
+		for _, pattern := range []string{rule.Branch, rule.Worktree} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("worktrees[%d]: invalid pattern %q: %w", i, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// DetectWorktree finds the git checkout containing dir by reading .git and
+// HEAD directly, without running git, so it is cheap enough for every hook
+// and shell prompt. It returns nil outside a repository.
+func DetectWorktree(dir string) *Worktree {
+	d, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+	for {
+		gitPath := filepath.Join(d, ".git")
+		if info, err := os.Stat(gitPath); err == nil {
+			return readWorktree(d, gitPath, info.IsDir())
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return nil
+		}
+		d = parent
+	}
+}
+
+func readWorktree(root, gitPath string, isDir bool) *Worktree {
+	wt := &Worktree{Root: root}
+	gitDir := gitPath
+	if !isDir {
+		// Linked worktrees and submodules have a .git file pointing at
+		// their git directory.
+		data, err := os.ReadFile(gitPath)
+		if err != nil {
+			return nil
+		}
+		line := strings.TrimSpace(string(data))
+		if !strings.HasPrefix(line, "gitdir:") {
+			return nil
+		}
+		gitDir = strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(root, gitDir)
+		}
+		wt.Linked = strings.Contains(filepath.ToSlash(gitDir), "/worktrees/")
+	}
+	if head, err := os.ReadFile(filepath.Join(gitDir, "HEAD")); err == nil {
+		ref := strings.TrimSpace(string(head))
+		wt.Branch = strings.TrimPrefix(ref, "ref: refs/heads/")
+		if wt.Branch == ref {
+			wt.Branch = "" // detached HEAD
+		}
+	}
+	return wt
+}
+
+// WorktreePersona returns the persona selected by the first rule matching
+// the checkout containing dir, or "" when none matches.
+func WorktreePersona(rules []WorktreeRule, dir string) string {
+	if len(rules) == 0 {
+		return ""
+	}
+	wt := DetectWorktree(dir)
+	if wt == nil {
+		return ""
+	}
+	for _, rule := range rules {
+		if rule.Persona != "" && rule.matches(wt) {
+			return rule.Persona
+		}
+	}
+	return ""
+}
+
+// ApplyWorktreeRules returns config with the persona chosen by its worktree
+// rules for dir. Like ApplyEnvOverrides, the input is never modified.
+func ApplyWorktreeRules(config *Config, dir string) *Config {
+	if config == nil {
+		return nil
+	}
+	name := WorktreePersona(config.Worktrees, dir)
+	if name == "" {
+		return config
+	}
+	out := *config
+	out.Name = name
+	return &out
+}
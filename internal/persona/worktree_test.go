@@ -0,0 +1,137 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeRepo creates a repository at dir/main on branch main and a linked
+// worktree at dir/experiments on branch exp/voice, laid out the way
+// `git worktree add` does.
+func fakeRepo(t *testing.T) (mainDir, linkedDir string) {
+	t.Helper()
+	dir := t.TempDir()
+	mainDir = filepath.Join(dir, "main")
+	linkedDir = filepath.Join(dir, "experiments")
+	gitDir := filepath.Join(mainDir, ".git")
+	linkedGitDir := filepath.Join(gitDir, "worktrees", "experiments")
+	for _, d := range []string{filepath.Join(mainDir, "src"), linkedDir, linkedGitDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(gitDir, "HEAD"):         "ref: refs/heads/main\n",
+		filepath.Join(linkedGitDir, "HEAD"):   "ref: refs/heads/exp/voice\n",
+		filepath.Join(linkedDir, ".git"):      "gitdir: " + linkedGitDir + "\n",
+		filepath.Join(mainDir, "src", "x.go"): "package x\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return mainDir, linkedDir
+}
+
+func TestDetectWorktree(t *testing.T) {
+	mainDir, linkedDir := fakeRepo(t)
+
+	wt := DetectWorktree(filepath.Join(mainDir, "src"))
+	if wt == nil || wt.Root != mainDir || wt.Branch != "main" || wt.Linked {
+		t.Fatalf("main checkout = %+v", wt)
+	}
+	wt = DetectWorktree(linkedDir)
+	if wt == nil || wt.Root != linkedDir || wt.Branch != "exp/voice" || !wt.Linked {
+		t.Fatalf("linked worktree = %+v", wt)
+	}
+
+	head := filepath.Join(mainDir, ".git", "HEAD")
+	if err := os.WriteFile(head, []byte("0123456789abcdef0123456789abcdef01234567\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if wt := DetectWorktree(mainDir); wt == nil || wt.Branch != "" {
+		t.Fatalf("detached HEAD = %+v", wt)
+	}
+	if wt := DetectWorktree(t.TempDir()); wt != nil {
+		t.Fatalf("outside a repository = %+v", wt)
+	}
+}
+
+func TestWorktreePersona(t *testing.T) {
+	mainDir, linkedDir := fakeRepo(t)
+	rules := []WorktreeRule{
+		{Branch: "main", Persona: "careful"},
+		{Branch: "exp/*", Worktree: "other-*", Persona: "never"},
+		{Worktree: "experiments", Persona: "playful"},
+	}
+	if got := WorktreePersona(rules, mainDir); got != "careful" {
+		t.Errorf("main = %q, want careful", got)
+	}
+	if got := WorktreePersona(rules, linkedDir); got != "playful" {
+		t.Errorf("experiments = %q, want playful", got)
+	}
+	if got := WorktreePersona(rules[:2], linkedDir); got != "" {
+		t.Errorf("no matching rule = %q", got)
+	}
+	pathRule := []WorktreeRule{{Worktree: filepath.ToSlash(filepath.Dir(linkedDir)) + "/exp*", Persona: "by-path"}}
+	if got := WorktreePersona(pathRule, linkedDir); got != "by-path" {
+		t.Errorf("full path pattern = %q", got)
+	}
+
+	config := &Config{Name: "default", Worktrees: rules}
+	if got := ApplyWorktreeRules(config, mainDir); got.Name != "careful" || config.Name != "default" {
+		t.Errorf("ApplyWorktreeRules = %q (input %q)", got.Name, config.Name)
+	}
+}
+
+func TestWorktreeRulesResolution(t *testing.T) {
+	mainDir, linkedDir := fakeRepo(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvPersona, "")
+	config := &Config{Name: "default", Worktrees: []WorktreeRule{
+		{Branch: "main", Persona: "careful"},
+		{Branch: "exp/*", Persona: "playful"},
+	}}
+	for _, dir := range []string{mainDir, linkedDir} {
+		if err := SaveConfig(dir, config); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Chdir(linkedDir)
+	loaded, err := LoadConfigWithFallback()
+	if err != nil || loaded.Name != "playful" {
+		t.Fatalf("LoadConfigWithFallback = %+v, %v", loaded, err)
+	}
+	active, err := ResolveActive(mainDir, home)
+	if err != nil || active.Name != "careful" || active.Scope != ScopeWorktree {
+		t.Fatalf("ResolveActive = %+v, %v", active, err)
+	}
+
+	t.Setenv(EnvPersona, "forced")
+	if loaded, _ := LoadConfigWithFallback(); loaded.Name != "forced" {
+		t.Errorf("CCPERSONA_PERSONA should win over worktree rules, got %q", loaded.Name)
+	}
+}
+
+func TestValidateWorktreeRules(t *testing.T) {
+	tests := []struct {
+		rule    WorktreeRule
+		wantErr bool
+	}{
+		{WorktreeRule{Branch: "main", Persona: "careful"}, false},
+		{WorktreeRule{Worktree: "exp-*", Persona: "playful"}, false},
+		{WorktreeRule{Branch: "main"}, true},
+		{WorktreeRule{Persona: "careful"}, true},
+		{WorktreeRule{Branch: "[", Persona: "careful"}, true},
+	}
+	for _, tt := range tests {
+		err := ValidateConfig(&Config{Name: "default", Worktrees: []WorktreeRule{tt.rule}})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateConfig(%+v) error = %v, wantErr %v", tt.rule, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+// Package projectpath encodes project directories into flat names, matching
+// the scheme Claude Code uses for ~/.claude/projects.
+package projectpath
+
+import "strings"
+
+// Encode replaces every non-alphanumeric character of an absolute project
+// path with '-', e.g. "/Users/me/my.app" becomes "-Users-me-my-app".
+func Encode(projectDir string) string {
+	var b strings.Builder
+	for _, r := range projectDir {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
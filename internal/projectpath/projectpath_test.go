@@ -0,0 +1,16 @@
+package projectpath
+
+import "testing"
+
+func TestEncode(t *testing.T) {
+	tests := map[string]string{
+		"/Users/me/src/my.app": "-Users-me-src-my-app",
+		"/root/module":         "-root-module",
+		"/tmp/日本":              "-tmp---",
+	}
+	for in, want := range tests {
+		if got := Encode(in); got != want {
+			t.Errorf("Encode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
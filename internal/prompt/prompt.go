@@ -0,0 +1,164 @@
+// Package prompt renders the active persona for shell prompts (PS1, starship)
+// and caches the result so repeated calls stay cheap.
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/daikw/ccpersona/internal/persona"
+	"github.com/daikw/ccpersona/internal/voice"
+)
+
+// DefaultFormat prints only the persona name.
+const DefaultFormat = "{persona}"
+
+// maxCacheEntries bounds the cache file; the least recently stored entries are
+// dropped first.
+const maxCacheEntries = 256
+
+// Format renders active using placeholders {persona}, {scope}, and {provider}.
+// A nil active persona renders as an empty string so prompt segments can hide.
+func Format(format string, active *persona.ActivePersona) string {
+	if active == nil || active.Name == "" {
+		return ""
+	}
+	if format == "" {
+		format = DefaultFormat
+	}
+	return strings.NewReplacer(
+		"{persona}", active.Name,
+		"{scope}", active.Scope,
+		"{provider}", active.Provider,
+	).Replace(format)
+}
+
+// stamp identifies a config file version without reading it.
+type stamp struct {
+	ModTime int64 `json:"mtime"`
+	Size    int64 `json:"size"`
+}
+
+func statStamp(path string) stamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+}
+
+type cacheEntry struct {
+	Project  stamp                  `json:"project"`
+	Global   stamp                  `json:"global"`
+	Checkout string                 `json:"checkout,omitempty"`
+	Active   *persona.ActivePersona `json:"active,omitempty"`
+	StoredAt int64                  `json:"stored_at"`
+}
+
+// Cache remembers resolved personas per directory. Entries are validated
+// against the modification time and size of the project and global configs,
+// so edits are picked up immediately.
+type Cache struct {
+	Path string
+}
+
+// DefaultCachePath returns the cache location under the user cache directory.
+func DefaultCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	return filepath.Join(dir, "ccpersona", "prompt-cache.json"), nil
+}
+
+// Resolve returns the active persona for dir, using the cache when the
+// underlying config files are unchanged, with environment overrides applied.
+// A nil cache disables caching.
+func Resolve(cache *Cache, dir, homeDir string) (*persona.ActivePersona, error) {
+	project := statStamp(persona.ConfigPath(dir))
+	global := statStamp(persona.ConfigPath(homeDir))
+	checkout := checkoutStamp(dir)
+
+	var entries map[string]cacheEntry
+	if cache != nil {
+		entries = cache.load()
+		if entry, ok := entries[dir]; ok && entry.Project == project && entry.Global == global && entry.Checkout == checkout {
+			return applyEnv(entry.Active), nil
+		}
+	}
+
+	active, err := persona.ResolveActive(dir, homeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil {
+		if entries == nil {
+			entries = make(map[string]cacheEntry)
+		}
+		entries[dir] = cacheEntry{Project: project, Global: global, Checkout: checkout, Active: active, StoredAt: time.Now().UnixNano()}
+		// Caching is best effort; a read-only cache dir must not break prompts.
+		_ = cache.save(entries)
+	}
+	return applyEnv(active), nil
+}
+
+// checkoutStamp identifies the git checkout and branch of dir, so switching
+// branches invalidates entries resolved by worktree rules.
+func checkoutStamp(dir string) string {
+	wt := persona.DetectWorktree(dir)
+	if wt == nil {
+		return ""
+	}
+	return wt.Root + "@" + wt.Branch
+}
+
+// applyEnv layers CCPERSONA_PERSONA and CCPERSONA_PROVIDER over a file-based
+// result. Overrides are per process, so they are never cached.
+func applyEnv(active *persona.ActivePersona) *persona.ActivePersona {
+	active = persona.ApplyEnvToActive(active)
+	if provider := voice.ProviderOverride(); provider != "" && active != nil {
+		out := *active
+		out.Provider = provider
+		active = &out
+	}
+	return active
+}
+
+func (c *Cache) load() map[string]cacheEntry {
+	data, err := os.ReadFile(c.Path)
+	if err != nil {
+		return nil
+	}
+	var entries map[string]cacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil
+	}
+	return entries
+}
+
+func (c *Cache) save(entries map[string]cacheEntry) error {
+	for len(entries) > maxCacheEntries {
+		oldestKey := ""
+		var oldest int64
+		for k, e := range entries {
+			if oldestKey == "" || e.StoredAt < oldest {
+				oldestKey, oldest = k, e.StoredAt
+			}
+		}
+		delete(entries, oldestKey)
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	// Several shells may render prompts at once; replace the file atomically
+	// so readers never see a partial cache.
+	return fsutil.WriteFile(c.Path, data, 0644)
+}
@@ -0,0 +1,130 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/persona"
+)
+
+func writeConfig(t *testing.T, base, contents string) {
+	t.Helper()
+	path := persona.ConfigPath(base)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	active := &persona.ActivePersona{Name: "fable", Scope: "project", Provider: "voicevox"}
+	if got := Format("", active); got != "fable" {
+		t.Errorf("Format default = %q", got)
+	}
+	if got := Format("🎭 {persona} ({scope}/{provider})", active); got != "🎭 fable (project/voicevox)" {
+		t.Errorf("Format custom = %q", got)
+	}
+	if got := Format("{persona}", nil); got != "" {
+		t.Errorf("Format(nil) = %q, want empty", got)
+	}
+}
+
+func TestResolve_ProjectThenGlobal(t *testing.T) {
+	project := t.TempDir()
+	home := t.TempDir()
+	writeConfig(t, home, `{"name":"global-one"}`)
+
+	active, err := Resolve(nil, project, home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active == nil || active.Name != "global-one" || active.Scope != persona.ScopeGlobal {
+		t.Fatalf("unexpected active persona: %+v", active)
+	}
+
+	writeConfig(t, project, `{"name":"fable","voice":{"provider":"openai"}}`)
+	active, err = Resolve(nil, project, home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active.Name != "fable" || active.Scope != persona.ScopeProject || active.Provider != "openai" {
+		t.Fatalf("unexpected active persona: %+v", active)
+	}
+}
+
+func TestResolve_CacheInvalidatesOnChange(t *testing.T) {
+	project := t.TempDir()
+	home := t.TempDir()
+	cache := &Cache{Path: filepath.Join(t.TempDir(), "cache", "prompt-cache.json")}
+
+	writeConfig(t, project, `{"name":"first"}`)
+	active, err := Resolve(cache, project, home)
+	if err != nil || active.Name != "first" {
+		t.Fatalf("Resolve() = %+v, %v", active, err)
+	}
+	if _, err := os.Stat(cache.Path); err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+
+	// A cached hit is returned while the config is unchanged.
+	entries := cache.load()
+	entry := entries[project]
+	entry.Active = &persona.ActivePersona{Name: "from-cache", Scope: persona.ScopeProject}
+	entries[project] = entry
+	if err := cache.save(entries); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := Resolve(cache, project, home); active.Name != "from-cache" {
+		t.Errorf("expected cache hit, got %+v", active)
+	}
+
+	writeConfig(t, project, `{"name":"second-persona"}`)
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(persona.ConfigPath(project), future, future); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := Resolve(cache, project, home); active.Name != "second-persona" {
+		t.Errorf("expected cache invalidation, got %+v", active)
+	}
+}
+
+func TestCacheSave_BoundsEntries(t *testing.T) {
+	cache := &Cache{Path: filepath.Join(t.TempDir(), "prompt-cache.json")}
+	entries := make(map[string]cacheEntry)
+	for i := 0; i < maxCacheEntries+10; i++ {
+		entries[filepath.Join("/dir", string(rune('a'+i%26)), time.Duration(i).String())] = cacheEntry{StoredAt: int64(i)}
+	}
+	if err := cache.save(entries); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(cache.load()); got != maxCacheEntries {
+		t.Errorf("cache has %d entries, want %d", got, maxCacheEntries)
+	}
+}
+
+func TestResolve_EnvOverridesAreNotCached(t *testing.T) {
+	project := t.TempDir()
+	home := t.TempDir()
+	cache := &Cache{Path: filepath.Join(t.TempDir(), "prompt-cache.json")}
+	writeConfig(t, project, `{"name":"fable","voice":{"provider":"voicevox"}}`)
+
+	t.Setenv(persona.EnvPersona, "strict")
+	t.Setenv("CCPERSONA_PROVIDER", "openai")
+	active, err := Resolve(cache, project, home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active.Name != "strict" || active.Scope != persona.ScopeEnv || active.Provider != "openai" {
+		t.Errorf("unexpected active persona: %+v", active)
+	}
+
+	t.Setenv(persona.EnvPersona, "")
+	t.Setenv("CCPERSONA_PROVIDER", "")
+	if active, _ := Resolve(cache, project, home); active.Name != "fable" || active.Provider != "voicevox" {
+		t.Errorf("cached entry should hold file-based result, got %+v", active)
+	}
+}
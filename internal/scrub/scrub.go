@@ -0,0 +1,77 @@
+// Package scrub removes credentials from text before it reaches logs and
+// debug output, where prompts, payloads, and synthesized text may carry
+// secrets pasted by the user.
+package scrub
+
+import (
+	"io"
+	"os"
+	"regexp"
+)
+
+// EnvNoRedact disables scrubbing of log and debug output when set to a
+// non-empty value. The --no-redact flag sets it, so hooks and players the
+// process starts inherit the choice.
+const EnvNoRedact = "CCPERSONA_NO_REDACT"
+
+// Placeholder replaces each secret.
+const Placeholder = "<secret>"
+
+// assignmentPattern matches .env-style and key/value assignments whose name
+// suggests a credential, such as OPENAI_API_KEY=... or "password": "...".
+// The name and separator are kept so the output still shows what was set.
+var assignmentPattern = regexp.MustCompile(`(?i)\b([A-Za-z0-9_.-]*(?:api[_-]?key|secret|token|password|passwd|credentials?|private[_-]?key)["']?\s*[=:]\s*)("[^"]*"|'[^']*'|[^\s"',;]+)`)
+
+// secretPatterns match credentials by shape, wherever they appear.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk[-_](?:ant-|proj-)?[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`github_pat_[A-Za-z0-9_]{20,}`),
+	regexp.MustCompile(`xox[abprs]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`AKIA[0-9A-Z]{16}`),
+	regexp.MustCompile(`AIza[0-9A-Za-z_-]{35}`),
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]+`),
+	regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]{16,}`),
+}
+
+// Secrets replaces API keys, bearer tokens, and credential assignments in s
+// with Placeholder. It always scrubs; use Enabled to honor --no-redact.
+func Secrets(s string) string {
+	s = assignmentPattern.ReplaceAllString(s, "${1}"+Placeholder)
+	for _, p := range secretPatterns {
+		s = p.ReplaceAllString(s, Placeholder)
+	}
+	return s
+}
+
+// Enabled reports whether log and debug output is scrubbed.
+func Enabled() bool {
+	return os.Getenv(EnvNoRedact) == ""
+}
+
+// String scrubs s unless scrubbing is disabled.
+func String(s string) string {
+	if !Enabled() {
+		return s
+	}
+	return Secrets(s)
+}
+
+// Writer returns a writer that scrubs each write to w unless scrubbing is
+// disabled at the time of the write. Loggers write one record per call, so
+// a secret is never split across writes.
+func Writer(w io.Writer) io.Writer {
+	return writer{w}
+}
+
+type writer struct{ w io.Writer }
+
+func (sw writer) Write(p []byte) (int, error) {
+	if !Enabled() {
+		return sw.w.Write(p)
+	}
+	if _, err := io.WriteString(sw.w, Secrets(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,355 @@
+// Package settings reads, validates, and updates the hook configuration in
+// Claude Code's settings.json. Everything outside "hooks" is carried through
+// untouched, and top-level and event key order is preserved on write.
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// Events are the hook events Claude Code recognises.
+var Events = []string{
+	"PreToolUse",
+	"PostToolUse",
+	"Notification",
+	"UserPromptSubmit",
+	"Stop",
+	"SubagentStop",
+	"PreCompact",
+	"SessionStart",
+	"SessionEnd",
+}
+
+// Hook types Claude Code runs.
+const (
+	HookCommand = "command"
+	HookPrompt  = "prompt"
+)
+
+// Hook is one command, or one prompt for the model, run for an event.
+type Hook struct {
+	Type    string `json:"type"`
+	Command string `json:"command,omitempty"`
+	Prompt  string `json:"prompt,omitempty"`
+	// Timeout is in seconds; zero uses Claude Code's default.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// entry is one matcher entry of an event being edited. The entry and its
+// hooks stay raw JSON, so fields ccpersona does not know survive a write.
+type entry struct {
+	obj   *object
+	hooks []json.RawMessage
+}
+
+func (e *entry) matcher() string {
+	var m string
+	_ = json.Unmarshal(e.obj.values["matcher"], &m)
+	return m
+}
+
+// hookCommand returns the command of a raw hook, or "" for other types.
+func hookCommand(raw json.RawMessage) string {
+	var h Hook
+	if json.Unmarshal(raw, &h) != nil || h.Type != HookCommand {
+		return ""
+	}
+	return h.Command
+}
+
+// Matcher groups the hooks run for an event. Matcher selects tools for
+// PreToolUse and PostToolUse and is empty elsewhere.
+type Matcher struct {
+	Matcher string `json:"matcher,omitempty"`
+	Hooks   []Hook `json:"hooks"`
+}
+
+// Settings is a parsed settings.json.
+type Settings struct {
+	// Path is where the settings were loaded from and are saved to.
+	Path string
+	top  *object
+}
+
+// UserPath returns the user-level settings file, ~/.claude/settings.json.
+func UserPath(homeDir string) string {
+	return filepath.Join(homeDir, ".claude", "settings.json")
+}
+
+// ProjectPath returns the shared project settings file under root.
+func ProjectPath(root string) string {
+	return filepath.Join(root, ".claude", "settings.json")
+}
+
+// Load reads the settings at path. A missing file yields empty settings, so
+// callers can merge hooks into a file that does not exist yet.
+func Load(path string) (*Settings, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Settings{Path: path, top: &object{values: map[string]json.RawMessage{}}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	s.Path = path
+	return s, nil
+}
+
+// Parse parses settings.json content. Only JSON syntax errors and a
+// non-object document fail; structural problems in hooks are reported by
+// Validate.
+func Parse(data []byte) (*Settings, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return &Settings{top: &object{values: map[string]json.RawMessage{}}}, nil
+	}
+	top, err := decodeObject(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Settings{top: top}, nil
+}
+
+// Hooks decodes the hooks section. It fails when the section has the wrong
+// shape; Validate explains where.
+func (s *Settings) Hooks() (map[string][]Matcher, error) {
+	hooks := map[string][]Matcher{}
+	raw, ok := s.top.values["hooks"]
+	if !ok {
+		return hooks, nil
+	}
+	if err := json.Unmarshal(raw, &hooks); err != nil {
+		return nil, fmt.Errorf("invalid hooks section: %w", err)
+	}
+	return hooks, nil
+}
+
+// Describe lists the configured hooks as "Event: command" lines, with the
+// matcher in brackets when there is one, in file order.
+func (s *Settings) Describe() []string {
+	hooks, err := s.hookObject()
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	for _, event := range hooks.keys {
+		var matchers []Matcher
+		if json.Unmarshal(hooks.values[event], &matchers) != nil {
+			continue
+		}
+		for _, m := range matchers {
+			label := event
+			if m.Matcher != "" {
+				label += " [" + m.Matcher + "]"
+			}
+			for _, h := range m.Hooks {
+				if h.Type == HookPrompt {
+					lines = append(lines, label+": prompt "+strconv.Quote(h.Prompt))
+					continue
+				}
+				lines = append(lines, label+": "+h.Command)
+			}
+		}
+	}
+	return lines
+}
+
+// AddHook adds command to event under matcher, reusing an existing matcher
+// entry when there is one. It reports false when the command is already
+// configured for that event and matcher.
+func (s *Settings) AddHook(event, matcher string, hook Hook) (bool, error) {
+	if hook.Type == "" {
+		hook.Type = HookCommand
+	}
+	hooks, entries, err := s.eventEntries(event)
+	if err != nil {
+		return false, err
+	}
+	var target *entry
+	for _, e := range entries {
+		if e.matcher() != matcher {
+			continue
+		}
+		for _, h := range e.hooks {
+			if hook.Type == HookCommand && hookCommand(h) == hook.Command {
+				return false, nil
+			}
+		}
+		if target == nil {
+			target = e
+		}
+	}
+	if target == nil {
+		target = &entry{obj: &object{values: map[string]json.RawMessage{}}}
+		if matcher != "" {
+			raw, err := marshal(matcher)
+			if err != nil {
+				return false, err
+			}
+			target.obj.set("matcher", raw)
+		}
+		entries = append(entries, target)
+	}
+	raw, err := marshal(hook)
+	if err != nil {
+		return false, err
+	}
+	target.hooks = append(target.hooks, raw)
+	return true, s.setEventEntries(hooks, event, entries)
+}
+
+// RemoveHook removes command from every matcher of event, dropping matcher
+// entries and events left empty. It reports whether anything was removed.
+func (s *Settings) RemoveHook(event, command string) (bool, error) {
+	hooks, entries, err := s.eventEntries(event)
+	if err != nil {
+		return false, err
+	}
+	removed := false
+	kept := entries[:0]
+	for _, e := range entries {
+		hs := e.hooks[:0]
+		for _, h := range e.hooks {
+			if hookCommand(h) == command {
+				removed = true
+				continue
+			}
+			hs = append(hs, h)
+		}
+		if e.hooks = hs; len(hs) > 0 {
+			kept = append(kept, e)
+		}
+	}
+	if !removed {
+		return false, nil
+	}
+	return true, s.setEventEntries(hooks, event, kept)
+}
+
+func (s *Settings) hookObject() (*object, error) {
+	raw, ok := s.top.values["hooks"]
+	if !ok {
+		return &object{values: map[string]json.RawMessage{}}, nil
+	}
+	hooks, err := decodeObject(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hooks section: %w", err)
+	}
+	return hooks, nil
+}
+
+// eventEntries decodes one event for editing. Edits are refused while the
+// hooks section has problems, so a write never drops entries it could not
+// understand.
+func (s *Settings) eventEntries(event string) (*object, []*entry, error) {
+	if issues := s.Validate(); len(issues) > 0 {
+		return nil, nil, fmt.Errorf("%s has %d problem(s) in hooks (first: %s); fix them before editing", s.displayPath(), len(issues), issues[0])
+	}
+	hooks, err := s.hookObject()
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, ok := hooks.values[event]
+	if !ok {
+		return hooks, nil, nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s hooks: %w", event, err)
+	}
+	entries := make([]*entry, 0, len(list))
+	for _, item := range list {
+		obj, err := decodeObject(item)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s hooks: %w", event, err)
+		}
+		e := &entry{obj: obj}
+		if err := json.Unmarshal(obj.values["hooks"], &e.hooks); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s hooks: %w", event, err)
+		}
+		entries = append(entries, e)
+	}
+	return hooks, entries, nil
+}
+
+func (s *Settings) setEventEntries(hooks *object, event string, entries []*entry) error {
+	if len(entries) == 0 {
+		hooks.delete(event)
+	} else {
+		list := make([]json.RawMessage, 0, len(entries))
+		for _, e := range entries {
+			raw, err := marshal(e.hooks)
+			if err != nil {
+				return err
+			}
+			e.obj.set("hooks", raw)
+			if raw, err = e.obj.encode(); err != nil {
+				return err
+			}
+			list = append(list, raw)
+		}
+		raw, err := marshal(list)
+		if err != nil {
+			return err
+		}
+		hooks.set(event, raw)
+	}
+	if len(hooks.keys) == 0 {
+		s.top.delete("hooks")
+		return nil
+	}
+	raw, err := hooks.encode()
+	if err != nil {
+		return err
+	}
+	s.top.set("hooks", raw)
+	return nil
+}
+
+func (s *Settings) displayPath() string {
+	if s.Path == "" {
+		return "settings"
+	}
+	return s.Path
+}
+
+// Bytes returns the settings as indented JSON with a trailing newline.
+func (s *Settings) Bytes() ([]byte, error) {
+	raw, err := s.top.encode()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// Save writes the settings back to Path, keeping the file's permissions.
+func (s *Settings) Save() error {
+	data, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(s.Path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := fsutil.WriteFileLocked(s.Path, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.Path, err)
+	}
+	return nil
+}
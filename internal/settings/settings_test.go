@@ -0,0 +1,209 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sample = `{
+  "model": "opus",
+  "hooks": {
+    "Stop": [
+      {
+        "hooks": [
+          {"type": "command", "command": "say done && true"}
+        ]
+      }
+    ],
+    "PreToolUse": [
+      {
+        "matcher": "Bash",
+        "hooks": [{"type": "command", "command": "./check.sh", "timeout": 10}]
+      }
+    ]
+  },
+  "env": {"FOO": "bar"}
+}
+`
+
+func TestParseAndDescribe(t *testing.T) {
+	s, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := s.Validate(); len(issues) != 0 {
+		t.Fatalf("Validate = %v", issues)
+	}
+	hooks, err := s.Hooks()
+	if err != nil || len(hooks["PreToolUse"]) != 1 || hooks["PreToolUse"][0].Hooks[0].Timeout != 10 {
+		t.Fatalf("Hooks = %+v, %v", hooks, err)
+	}
+	got := strings.Join(s.Describe(), "\n")
+	want := "Stop: say done && true\nPreToolUse [Bash]: ./check.sh"
+	if got != want {
+		t.Errorf("Describe =\n%s\nwant\n%s", got, want)
+	}
+
+	for _, bad := range []string{"[]", "{", `{"a": 1} {}`} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		hooks string
+		want  []string
+	}{
+		{`{"stop": []}`, []string{`hooks.stop: unknown event "stop" (did you mean "Stop"?)`}},
+		{`{"OnSave": []}`, []string{`hooks.OnSave: unknown event "OnSave"`}},
+		{`{"Stop": {"hooks": []}}`, []string{"hooks.Stop: must be an array of matcher entries; wrap the entry in [ ]"}},
+		{`{"Stop": [{"type": "command", "command": "x"}]}`, []string{"hooks.Stop[0]: missing hooks array; nest the command"}},
+		{`{"Stop": [{"hooks": {}}]}`, []string{"hooks.Stop[0].hooks: must be an array"}},
+		{`{"Stop": [{"hooks": [{"command": ""}]}]}`, []string{
+			`hooks.Stop[0].hooks[0].type: must be "command"`,
+			"hooks.Stop[0].hooks[0].command: is missing or empty",
+		}},
+		{`{"PreToolUse": [{"matcher": 1, "hooks": [{"type": "command", "command": "x", "timeout": -1}]}]}`, []string{
+			"hooks.PreToolUse[0].matcher: must be a string",
+			"hooks.PreToolUse[0].hooks[0].timeout: must be a positive number",
+		}},
+		{`[]`, []string{"hooks: must be an object keyed by event name"}},
+	}
+	for _, tt := range tests {
+		s, err := Parse([]byte(`{"hooks": ` + tt.hooks + `}`))
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", tt.hooks, err)
+		}
+		issues := s.Validate()
+		if len(issues) != len(tt.want) {
+			t.Errorf("Validate(%s) = %v, want %d issue(s)", tt.hooks, issues, len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if !strings.HasPrefix(issues[i].String(), want) {
+				t.Errorf("Validate(%s)[%d] = %q, want prefix %q", tt.hooks, i, issues[i], want)
+			}
+		}
+	}
+}
+
+func TestAddAndRemoveHook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".claude", "settings.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		added, err := s.AddHook("SessionStart", "", Hook{Command: "ccpersona runtime hook"})
+		if err != nil || added != (i == 0) {
+			t.Fatalf("AddHook #%d = %v, %v", i, added, err)
+		}
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(sample), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added, err := s.AddHook("Stop", "", Hook{Command: "ccpersona runtime voice"}); !added || err != nil {
+		t.Fatalf("AddHook(Stop) = %v, %v", added, err)
+	}
+	if _, err := s.AddHook("SessionStart", "", Hook{Command: "ccpersona runtime hook"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	text := string(data)
+	// Other settings and key order survive; && is not escaped.
+	if !strings.Contains(text, `"say done && true"`) || !strings.Contains(text, `"FOO": "bar"`) {
+		t.Errorf("settings lost content:\n%s", text)
+	}
+	if strings.Index(text, `"model"`) > strings.Index(text, `"hooks"`) || strings.Index(text, `"PreToolUse"`) > strings.Index(text, `"SessionStart"`) {
+		t.Errorf("key order changed:\n%s", text)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("perm = %v, want 0600", info.Mode().Perm())
+	}
+	s, _ = Load(path)
+	hooks, _ := s.Hooks()
+	if len(hooks["Stop"]) != 1 || len(hooks["Stop"][0].Hooks) != 2 || hooks["Stop"][0].Hooks[1].Type != "command" {
+		t.Errorf("Stop hooks = %+v", hooks["Stop"])
+	}
+
+	if removed, err := s.RemoveHook("SessionStart", "ccpersona runtime hook"); !removed || err != nil {
+		t.Fatalf("RemoveHook = %v, %v", removed, err)
+	}
+	if removed, _ := s.RemoveHook("SessionStart", "ccpersona runtime hook"); removed {
+		t.Error("second RemoveHook should report nothing removed")
+	}
+	if hooks, _ := s.Hooks(); hooks["SessionStart"] != nil {
+		t.Errorf("empty event should be dropped: %+v", hooks)
+	}
+}
+
+func TestEditRefusedWithIssues(t *testing.T) {
+	s, err := Parse([]byte(`{"hooks": {"Stop": {"hooks": []}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddHook("Stop", "", Hook{Command: "x"}); err == nil {
+		t.Error("AddHook should refuse to edit malformed hooks")
+	}
+}
+
+func TestEditKeepsUnknownFieldsAndPromptHooks(t *testing.T) {
+	s, err := Parse([]byte(`{"hooks": {"Stop": [{
+  "note": "mine",
+  "hooks": [
+    {"type": "prompt", "prompt": "Is the task done?", "timeout": 30},
+    {"type": "command", "command": "say done", "async": true}
+  ]
+}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := s.Validate(); len(issues) != 0 {
+		t.Fatalf("Validate = %v", issues)
+	}
+	if added, err := s.AddHook("Stop", "", Hook{Command: "ccpersona runtime voice"}); !added || err != nil {
+		t.Fatalf("AddHook = %v, %v", added, err)
+	}
+	if removed, err := s.RemoveHook("Stop", "say done"); !removed || err != nil {
+		t.Fatalf("RemoveHook = %v, %v", removed, err)
+	}
+	data, err := s.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+	for _, want := range []string{`"note": "mine"`, `"prompt": "Is the task done?"`, `"timeout": 30`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("settings lost %s:\n%s", want, text)
+		}
+	}
+	got := strings.Join(s.Describe(), "\n")
+	want := "Stop: prompt \"Is the task done?\"\nStop: ccpersona runtime voice"
+	if got != want {
+		t.Errorf("Describe =\n%s\nwant\n%s", got, want)
+	}
+
+	s, _ = Parse([]byte(`{"hooks": {"Stop": [{"hooks": [{"type": "prompt", "prompt": " "}, {"type": "agent", "command": "x"}]}]}}`))
+	issues := s.Validate()
+	if len(issues) != 2 || issues[0].Path != "hooks.Stop[0].hooks[0].prompt" || issues[1].Path != "hooks.Stop[0].hooks[1].type" {
+		t.Errorf("Validate = %v", issues)
+	}
+}
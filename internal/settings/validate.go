@@ -0,0 +1,158 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Issue is one misconfiguration in the hooks section. Path locates it in
+// the document, e.g. "hooks.Stop[0].hooks[1].command".
+type Issue struct {
+	Path    string
+	Message string
+}
+
+func (i Issue) String() string {
+	return i.Path + ": " + i.Message
+}
+
+// Validate reports misconfigured hooks: unknown event names, events that are
+// not arrays of matcher entries, entries without a hooks array, and hooks of
+// an unknown type or without their command or prompt. Claude Code silently
+// skips most of these, so they show up as hooks that never run.
+func (s *Settings) Validate() []Issue {
+	raw, ok := s.top.values["hooks"]
+	if !ok {
+		return nil
+	}
+	hooks, err := decodeObject(raw)
+	if err != nil {
+		return []Issue{{"hooks", "must be an object keyed by event name"}}
+	}
+
+	var issues []Issue
+	add := func(path, format string, args ...interface{}) {
+		issues = append(issues, Issue{path, fmt.Sprintf(format, args...)})
+	}
+	for _, event := range hooks.keys {
+		path := "hooks." + event
+		if !knownEvent(event) {
+			if known := suggestEvent(event); known != "" {
+				add(path, "unknown event %q (did you mean %q?)", event, known)
+			} else {
+				add(path, "unknown event %q (known: %s)", event, strings.Join(Events, ", "))
+			}
+		}
+
+		var entries []json.RawMessage
+		if json.Unmarshal(hooks.values[event], &entries) != nil {
+			if isObjectWith(hooks.values[event], "hooks") {
+				add(path, "must be an array of matcher entries; wrap the entry in [ ]")
+			} else {
+				add(path, "must be an array of matcher entries")
+			}
+			continue
+		}
+		for i, entry := range entries {
+			validateEntry(fmt.Sprintf("%s[%d]", path, i), entry, add)
+		}
+	}
+	return issues
+}
+
+func validateEntry(path string, entry json.RawMessage, add func(path, format string, args ...interface{})) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(entry, &fields) != nil || fields == nil {
+		add(path, "must be an object with a hooks array")
+		return
+	}
+	if matcher, ok := fields["matcher"]; ok {
+		var s string
+		if json.Unmarshal(matcher, &s) != nil {
+			add(path+".matcher", "must be a string")
+		}
+	}
+	list, ok := fields["hooks"]
+	if !ok {
+		if _, flat := fields["command"]; flat {
+			add(path, `missing hooks array; nest the command as {"hooks": [{"type": "command", "command": ...}]}`)
+		} else {
+			add(path, "missing hooks array")
+		}
+		return
+	}
+	var hooks []json.RawMessage
+	if json.Unmarshal(list, &hooks) != nil {
+		add(path+".hooks", "must be an array")
+		return
+	}
+	if len(hooks) == 0 {
+		add(path+".hooks", "is empty")
+	}
+	for i, hook := range hooks {
+		validateHook(fmt.Sprintf("%s.hooks[%d]", path, i), hook, add)
+	}
+}
+
+func validateHook(path string, raw json.RawMessage, add func(path, format string, args ...interface{})) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil || fields == nil {
+		add(path, "must be an object")
+		return
+	}
+	var typ string
+	_ = json.Unmarshal(fields["type"], &typ)
+	// A command hook is the likely intent of a hook with a bad type unless
+	// it carries a prompt.
+	body := HookCommand
+	switch typ {
+	case HookCommand, HookPrompt:
+		body = typ
+	default:
+		add(path+".type", `must be %q or %q`, HookCommand, HookPrompt)
+		if _, ok := fields[HookPrompt]; ok {
+			body = HookPrompt
+		}
+	}
+	var text string
+	if json.Unmarshal(fields[body], &text) != nil || strings.TrimSpace(text) == "" {
+		add(path+"."+body, "is missing or empty")
+	}
+	if timeout, ok := fields["timeout"]; ok {
+		var seconds float64
+		if json.Unmarshal(timeout, &seconds) != nil || seconds <= 0 {
+			add(path+".timeout", "must be a positive number of seconds")
+		}
+	}
+}
+
+func knownEvent(name string) bool {
+	for _, e := range Events {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestEvent returns the known event that name differs from only in case
+// or separators, e.g. "stop" or "session_start".
+func suggestEvent(name string) string {
+	norm := strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(name))
+	for _, e := range Events {
+		if strings.ToLower(e) == norm {
+			return e
+		}
+	}
+	return ""
+}
+
+func isObjectWith(raw json.RawMessage, key string) bool {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil {
+		return false
+	}
+	_, ok := fields[key]
+	return ok
+}
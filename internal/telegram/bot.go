@@ -0,0 +1,158 @@
+// Package telegram is a minimal Telegram Bot API client: it sends messages
+// and long-polls for the commands users send back.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultAPI is the Bot API endpoint.
+const DefaultAPI = "https://api.telegram.org"
+
+// requestTimeout bounds calls other than long polls, so an unreachable API
+// never stalls a hook for long.
+const requestTimeout = 10 * time.Second
+
+// maxMessageLength is the longest text Telegram accepts in one message.
+const maxMessageLength = 4096
+
+// Bot calls the Bot API with a bot token.
+type Bot struct {
+	token  string
+	api    string
+	client *http.Client
+}
+
+// NewBot returns a client for the bot with token.
+func NewBot(token string) *Bot {
+	return &Bot{token: token, api: DefaultAPI, client: &http.Client{}}
+}
+
+// WithAPI points the client at another Bot API server, such as a local
+// telegram-bot-api instance or a test server.
+func (b *Bot) WithAPI(api string) *Bot {
+	b.api = strings.TrimSuffix(api, "/")
+	return b
+}
+
+// Update is an incoming update; only messages are requested.
+type Update struct {
+	ID      int64    `json:"update_id"`
+	Message *Message `json:"message,omitempty"`
+}
+
+// Message is a text message sent to the bot.
+type Message struct {
+	ID   int64 `json:"message_id"`
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	From *struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username,omitempty"`
+	} `json:"from,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+// SendMessage sends text to a chat as plain text, shortened to the message
+// size limit.
+func (b *Bot) SendMessage(ctx context.Context, chatID int64, text string) error {
+	if utf8.RuneCountInString(text) > maxMessageLength {
+		text = string([]rune(text)[:maxMessageLength-1]) + "…"
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	return b.call(ctx, "sendMessage", map[string]any{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}, nil)
+}
+
+// Updates long-polls for messages after offset, waiting up to wait for one
+// to arrive. An offset of -1 returns only the latest pending update.
+func (b *Bot) Updates(ctx context.Context, offset int64, wait time.Duration) ([]Update, error) {
+	ctx, cancel := context.WithTimeout(ctx, wait+requestTimeout)
+	defer cancel()
+	var updates []Update
+	err := b.call(ctx, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         int(wait.Seconds()),
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	return updates, err
+}
+
+// call posts params to a Bot API method and decodes its result. Errors never
+// include the request URL, which contains the token.
+func (b *Bot) call(ctx context.Context, method string, params, result any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.api+"/bot"+b.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: invalid API URL", method)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var reply struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		Description string          `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("%s: %s", method, resp.Status)
+	}
+	if !reply.OK {
+		return fmt.Errorf("%s: %s", method, reply.Description)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(reply.Result, result)
+}
+
+// Command is a bot command such as "/persona zundamon".
+type Command struct {
+	// Name is the lowercase command without the slash or bot mention.
+	Name string
+	Args string
+}
+
+// ParseCommand parses a message starting with a slash. In groups Telegram
+// appends the bot name, as in "/mute@ccpersona_bot", which is dropped.
+func ParseCommand(text string) (Command, bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return Command{}, false
+	}
+	name, args := text[1:], ""
+	if i := strings.IndexFunc(name, unicode.IsSpace); i >= 0 {
+		name, args = name[:i], name[i:]
+	}
+	name, _, _ = strings.Cut(name, "@")
+	if name == "" {
+		return Command{}, false
+	}
+	return Command{Name: strings.ToLower(name), Args: strings.TrimSpace(args)}, true
+}
@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text string
+		want Command
+		ok   bool
+	}{
+		{"/mute", Command{Name: "mute"}, true},
+		{" /Persona  zundamon ", Command{Name: "persona", Args: "zundamon"}, true},
+		{"/persona@ccpersona_bot\nfable", Command{Name: "persona", Args: "fable"}, true},
+		{"hello", Command{}, false},
+		{"/", Command{}, false},
+		{"/@bot", Command{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseCommand(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseCommand(%q) = %+v, %v; want %+v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	var path string
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	defer server.Close()
+
+	long := strings.Repeat("a", 5000)
+	if err := NewBot("123:secret").WithAPI(server.URL).SendMessage(context.Background(), -42, long); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/bot123:secret/sendMessage" {
+		t.Errorf("path = %q", path)
+	}
+	if body["chat_id"] != float64(-42) {
+		t.Errorf("chat_id = %v", body["chat_id"])
+	}
+	if text := body["text"].(string); len([]rune(text)) != maxMessageLength || !strings.HasSuffix(text, "…") {
+		t.Errorf("text was not shortened to %d runes", maxMessageLength)
+	}
+}
+
+func TestUpdates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		if params["offset"] != float64(7) || params["timeout"] != float64(1) {
+			t.Errorf("params = %v", params)
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"result":[{"update_id":7,"message":{"message_id":3,"chat":{"id":99},"text":"/last"}}]}`))
+	}))
+	defer server.Close()
+
+	updates, err := NewBot("t").WithAPI(server.URL).Updates(context.Background(), 7, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0].ID != 7 || updates[0].Message.Chat.ID != 99 || updates[0].Message.Text != "/last" {
+		t.Errorf("updates = %+v", updates)
+	}
+}
+
+func TestErrorsHideToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`))
+	}))
+	err := NewBot("123:secret").WithAPI(server.URL).SendMessage(context.Background(), 1, "hi")
+	if err == nil || err.Error() != "sendMessage: Unauthorized" {
+		t.Errorf("API error = %v", err)
+	}
+
+	server.Close()
+	err = NewBot("123:secret").WithAPI(server.URL).SendMessage(context.Background(), 1, "hi")
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("connection error = %v should not contain the token", err)
+	}
+}
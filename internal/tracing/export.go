@@ -0,0 +1,237 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The standard OpenTelemetry exporter variables read by ccpersona.
+const (
+	envEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	envTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	envHeaders        = "OTEL_EXPORTER_OTLP_HEADERS"
+	envTracesHeaders  = "OTEL_EXPORTER_OTLP_TRACES_HEADERS"
+	envProtocol       = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	envTracesProtocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+	envTracesExporter = "OTEL_TRACES_EXPORTER"
+	envSDKDisabled    = "OTEL_SDK_DISABLED"
+	envServiceName    = "OTEL_SERVICE_NAME"
+	envResourceAttrs  = "OTEL_RESOURCE_ATTRIBUTES"
+)
+
+// scopeName is the instrumentation scope of every span.
+const scopeName = "github.com/daikw/ccpersona"
+
+type exporter struct {
+	url      string
+	headers  map[string]string
+	resource []attribute
+	client   *http.Client
+}
+
+// exporterFromEnv configures the OTLP/HTTP JSON exporter from the standard
+// variables. It returns nil when no endpoint is set or tracing is turned off.
+func exporterFromEnv() (*exporter, error) {
+	if strings.EqualFold(os.Getenv(envSDKDisabled), "true") || strings.EqualFold(os.Getenv(envTracesExporter), "none") {
+		return nil, nil
+	}
+	target := os.Getenv(envTracesEndpoint)
+	if target == "" {
+		base := os.Getenv(envEndpoint)
+		if base == "" {
+			return nil, nil
+		}
+		target = strings.TrimRight(base, "/") + "/v1/traces"
+	}
+	if _, err := url.ParseRequestURI(target); err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: %w", target, err)
+	}
+	protocol := os.Getenv(envTracesProtocol)
+	if protocol == "" {
+		protocol = os.Getenv(envProtocol)
+	}
+	if protocol == "grpc" {
+		return nil, fmt.Errorf("OTLP protocol %q is not supported; use an OTLP/HTTP endpoint", protocol)
+	}
+
+	headers := parseList(os.Getenv(envHeaders))
+	for k, v := range parseList(os.Getenv(envTracesHeaders)) {
+		headers[k] = v
+	}
+	service := os.Getenv(envServiceName)
+	attrs := parseList(os.Getenv(envResourceAttrs))
+	if service == "" {
+		service = attrs["service.name"]
+	}
+	if service == "" {
+		service = "ccpersona"
+	}
+	resource := []attribute{{"service.name", service}}
+	for k, v := range attrs {
+		if k != "service.name" {
+			resource = append(resource, attribute{k, v})
+		}
+	}
+	return &exporter{
+		url:      target,
+		headers:  headers,
+		resource: resource,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// parseList parses the "key1=value1,key2=value2" form of the OTLP header and
+// resource attribute variables, with URL-encoded values.
+func parseList(value string) map[string]string {
+	out := map[string]string{}
+	for _, item := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(item, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			continue
+		}
+		if decoded, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = decoded
+		}
+		out[k] = strings.TrimSpace(v)
+	}
+	return out
+}
+
+func (e *exporter) export(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(e.payload(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to export spans: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// The OTLP JSON encoding: ids are hex, and 64-bit integers are strings.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            *otlpStatus    `json:"status,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+const (
+	spanKindInternal = 1
+	statusError      = 2
+)
+
+func (e *exporter) payload(spans []*Span) otlpRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.context.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.context.SpanID[:]),
+			ParentSpanID:      hex.EncodeToString(s.parent[:]),
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        keyValues(s.attributes),
+		}
+		if s.err != "" {
+			span.Status = &otlpStatus{Code: statusError, Message: s.err}
+		}
+		s.mu.Unlock()
+		out = append(out, span)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: keyValues(e.resource)},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: out}},
+	}}}
+}
+
+func keyValues(attrs []attribute) []otlpKeyValue {
+	out := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		var v otlpValue
+		switch value := a.value.(type) {
+		case string:
+			v.StringValue = &value
+		case bool:
+			v.BoolValue = &value
+		case int:
+			s := strconv.Itoa(value)
+			v.IntValue = &s
+		case int64:
+			s := strconv.FormatInt(value, 10)
+			v.IntValue = &s
+		case float64:
+			v.DoubleValue = &value
+		default:
+			s := fmt.Sprint(value)
+			v.StringValue = &s
+		}
+		out = append(out, otlpKeyValue{Key: a.key, Value: v})
+	}
+	return out
+}
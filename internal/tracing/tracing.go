@@ -0,0 +1,243 @@
+// Package tracing records spans for ccpersona's hook processing when the
+// caller passes W3C trace context, and exports them to an OpenTelemetry
+// collector over OTLP/HTTP, so notification latency shows up in the trace of
+// the agent workflow that raised the hook.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvTraceParent carries trace context into a process, as a W3C traceparent
+// value.
+const EnvTraceParent = "TRACEPARENT"
+
+// SpanContext identifies a span across processes.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// ParseTraceParent parses a W3C traceparent header value such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ParseTraceParent(value string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("invalid traceparent %q", value)
+	}
+	// Version ff is forbidden; version 00 has exactly four fields.
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("invalid traceparent %q", value)
+	}
+	var flags [1]byte
+	for _, field := range []struct {
+		dst []byte
+		src string
+	}{{sc.TraceID[:], parts[1]}, {sc.SpanID[:], parts[2]}, {flags[:], parts[3]}} {
+		if strings.ToLower(field.src) != field.src {
+			return sc, fmt.Errorf("invalid traceparent %q", value)
+		}
+		if _, err := hex.Decode(field.dst, []byte(field.src)); err != nil {
+			return sc, fmt.Errorf("invalid traceparent %q", value)
+		}
+	}
+	if sc.TraceID == ([16]byte{}) || sc.SpanID == ([8]byte{}) {
+		return sc, fmt.Errorf("invalid traceparent %q: zero id", value)
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// TraceParent formats sc as a traceparent header value.
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%x-%x-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// Tracer collects the spans of one process under a remote parent and
+// exports them on Flush. A nil Tracer records nothing.
+type Tracer struct {
+	parent   SpanContext
+	exporter *exporter
+
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// New returns a tracer for spans under the traceparent value, falling back
+// to TRACEPARENT when it is empty. It returns nil, without error, when there
+// is no trace context, the parent is not sampled, or no OTLP endpoint is
+// configured, so tracing costs nothing unless the caller asked for it.
+func New(traceparent string) (*Tracer, error) {
+	if traceparent == "" {
+		traceparent = os.Getenv(EnvTraceParent)
+	}
+	if traceparent == "" {
+		return nil, nil
+	}
+	parent, err := ParseTraceParent(traceparent)
+	if err != nil {
+		return nil, err
+	}
+	if !parent.Sampled {
+		return nil, nil
+	}
+	exp, err := exporterFromEnv()
+	if exp == nil || err != nil {
+		return nil, err
+	}
+	return &Tracer{parent: parent, exporter: exp}, nil
+}
+
+// Start begins the root span of this process, a child of the remote parent.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	return t.start(ctx, name, t.parent)
+}
+
+func (t *Tracer) start(ctx context.Context, name string, parent SpanContext) (context.Context, *Span) {
+	span := &Span{
+		tracer: t,
+		name:   name,
+		parent: parent.SpanID,
+		start:  time.Now(),
+		context: SpanContext{
+			TraceID: parent.TraceID,
+			SpanID:  newSpanID(),
+			Sampled: true,
+		},
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Flush exports the ended spans. Spans still open are dropped.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+	return t.exporter.export(ctx, spans)
+}
+
+type spanKey struct{}
+
+// Start begins a span under the span in ctx. Without one it returns a nil
+// Span, whose methods do nothing, so callers instrument unconditionally.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent, _ := ctx.Value(spanKey{}).(*Span)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.start(ctx, name, parent.context)
+}
+
+// Span is one timed step of the pipeline.
+type Span struct {
+	tracer  *Tracer
+	name    string
+	context SpanContext
+	parent  [8]byte
+	start   time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes []attribute
+	err        string
+}
+
+type attribute struct {
+	key   string
+	value any
+}
+
+// Set records an attribute; value is a string, bool, int, int64, or
+// float64.
+func (s *Span) Set(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes = append(s.attributes, attribute{key, value})
+}
+
+// Fail marks the span as failed with err; a nil err is ignored.
+func (s *Span) Fail(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End finishes the span. Only the first call counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
+
+// TraceParent returns the traceparent value that makes a span in another
+// process a child of s, or "" for a nil Span.
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return s.context.TraceParent()
+}
+
+// Environ returns env with TRACEPARENT set to continue the trace of the span
+// in ctx, for a process started from it. Without a span env is unchanged.
+func Environ(ctx context.Context, env []string) []string {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	if span == nil {
+		return env
+	}
+	out := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, EnvTraceParent+"=") {
+			out = append(out, kv)
+		}
+	}
+	return append(out, EnvTraceParent+"="+span.TraceParent())
+}
+
+func newSpanID() [8]byte {
+	var id [8]byte
+	for id == ([8]byte{}) {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
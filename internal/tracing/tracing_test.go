@@ -0,0 +1,196 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const parentTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	sc, err := ParseTraceParent(parentTraceParent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sc.Sampled || sc.TraceParent() != parentTraceParent {
+		t.Errorf("ParseTraceParent() = %+v, formats as %q", sc, sc.TraceParent())
+	}
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceParent(value); err == nil {
+			t.Errorf("ParseTraceParent(%q) should fail", value)
+		}
+	}
+
+	// Later versions may append fields.
+	if _, err := ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Errorf("future version: %v", err)
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	t.Setenv(EnvTraceParent, "")
+	t.Setenv(envEndpoint, "http://127.0.0.1:4318")
+	t.Setenv(envTracesEndpoint, "")
+
+	if tracer, err := New(""); tracer != nil || err != nil {
+		t.Errorf("no trace context: New() = %v, %v", tracer, err)
+	}
+	if tracer, err := New("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"); tracer != nil || err != nil {
+		t.Errorf("unsampled parent: New() = %v, %v", tracer, err)
+	}
+	if _, err := New("garbage"); err == nil {
+		t.Error("invalid trace context should fail")
+	}
+
+	t.Setenv(envTracesExporter, "none")
+	if tracer, _ := New(parentTraceParent); tracer != nil {
+		t.Error("OTEL_TRACES_EXPORTER=none should disable tracing")
+	}
+	t.Setenv(envTracesExporter, "")
+	t.Setenv(envEndpoint, "")
+	if tracer, _ := New(parentTraceParent); tracer != nil {
+		t.Error("no endpoint should disable tracing")
+	}
+}
+
+func TestNilSafe(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "root")
+	span.Set("k", "v")
+	span.Fail(errors.New("boom"))
+	span.End()
+	if _, child := Start(ctx, "child"); child != nil {
+		t.Error("Start without a span should return nil")
+	}
+	if span.TraceParent() != "" {
+		t.Error("nil span should have no traceparent")
+	}
+	if err := tracer.Flush(ctx); err != nil {
+		t.Error(err)
+	}
+	env := []string{"A=1"}
+	if got := Environ(ctx, env); len(got) != 1 {
+		t.Errorf("Environ() = %v", got)
+	}
+}
+
+func TestExport(t *testing.T) {
+	var body otlpRequest
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv(EnvTraceParent, parentTraceParent)
+	t.Setenv(envEndpoint, server.URL+"/")
+	t.Setenv(envTracesEndpoint, "")
+	t.Setenv(envHeaders, "Authorization=Bearer%20token")
+	t.Setenv(envServiceName, "")
+	t.Setenv(envResourceAttrs, "service.name=agent,deployment.environment=dev")
+
+	tracer, err := New("")
+	if err != nil || tracer == nil {
+		t.Fatalf("New() = %v, %v", tracer, err)
+	}
+	ctx, root := tracer.Start(context.Background(), "ccpersona notify Stop")
+	root.Set("ccpersona.event", "Stop")
+	childCtx, child := Start(ctx, "voice.synthesize")
+	child.Set("ccpersona.text_length", 12)
+	child.Fail(errors.New("engine down"))
+	child.End()
+	child.End()
+	root.End()
+
+	env := Environ(childCtx, []string{"TRACEPARENT=old", "A=1"})
+	if len(env) != 2 || env[1] != "TRACEPARENT="+child.TraceParent() {
+		t.Errorf("Environ() = %v", env)
+	}
+
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if auth != "Bearer token" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if len(body.ResourceSpans) != 1 {
+		t.Fatalf("payload = %+v", body)
+	}
+	resource := body.ResourceSpans[0].Resource.Attributes
+	if len(resource) != 2 || *resource[0].Value.StringValue != "agent" {
+		t.Errorf("resource = %+v", resource)
+	}
+	spans := body.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("spans = %+v", spans)
+	}
+	synth, notify := spans[0], spans[1]
+	if notify.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || notify.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("root span = %+v", notify)
+	}
+	if synth.TraceID != notify.TraceID || synth.ParentSpanID != notify.SpanID {
+		t.Errorf("child span = %+v", synth)
+	}
+	if synth.Status == nil || synth.Status.Code != statusError || synth.Status.Message != "engine down" {
+		t.Errorf("child status = %+v", synth.Status)
+	}
+	if len(synth.Attributes) != 1 || synth.Attributes[0].Value.IntValue == nil || *synth.Attributes[0].Value.IntValue != "12" {
+		t.Errorf("child attributes = %+v", synth.Attributes)
+	}
+
+	// Nothing left to send.
+	if err := tracer.Flush(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	t.Setenv(envTracesEndpoint, server.URL+"/custom")
+	tracer, err := New(parentTraceParent)
+	if err != nil || tracer == nil {
+		t.Fatalf("New() = %v, %v", tracer, err)
+	}
+	_, span := tracer.Start(context.Background(), "root")
+	span.End()
+	err = tracer.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("Flush() error = %v", err)
+	}
+}
+
+func TestExporterProtocol(t *testing.T) {
+	t.Setenv(envEndpoint, "http://127.0.0.1:4317")
+	t.Setenv(envProtocol, "grpc")
+	if _, err := New(parentTraceParent); err == nil {
+		t.Error("gRPC protocol should be rejected")
+	}
+}
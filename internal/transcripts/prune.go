@@ -0,0 +1,244 @@
+// Package transcripts finds and prunes old Claude Code session transcripts
+// under ~/.claude/projects, which otherwise grow without bound.
+package transcripts
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AutoInterval is the minimum time between automatic prune runs.
+const AutoInterval = 24 * time.Hour
+
+// File is a session transcript selected for pruning. Size includes the
+// session's sidecar directory (subagent transcripts and tool results).
+type File struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Result summarizes a prune run.
+type Result struct {
+	Files int
+	Bytes int64
+}
+
+// ProjectsDir returns the directory Claude Code writes transcripts to.
+func ProjectsDir(homeDir string) string {
+	return filepath.Join(homeDir, ".claude", "projects")
+}
+
+// ParseAge parses an age such as "30d", "2w", or any time.ParseDuration
+// value. The age must be positive.
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	if n, ok := strings.CutSuffix(s, "d"); ok {
+		days, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q (want e.g. 30d, 2w, 12h)", s)
+		}
+		d = time.Duration(days) * 24 * time.Hour
+	} else if n, ok := strings.CutSuffix(s, "w"); ok {
+		weeks, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q (want e.g. 30d, 2w, 12h)", s)
+		}
+		d = time.Duration(weeks) * 7 * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid age %q (want e.g. 30d, 2w, 12h)", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("age %q must be positive", s)
+	}
+	return d, nil
+}
+
+// Find returns the session transcripts in the project directories under dir
+// that were last modified before cutoff, oldest first. A missing dir has no
+// transcripts.
+func Find(dir string, cutoff time.Time) ([]File, error) {
+	projects, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var files []File
+	for _, project := range projects {
+		if !project.IsDir() {
+			continue
+		}
+		projectDir := filepath.Join(dir, project.Name())
+		entries, err := os.ReadDir(projectDir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || !info.ModTime().Before(cutoff) {
+				continue
+			}
+			path := filepath.Join(projectDir, entry.Name())
+			files = append(files, File{Path: path, Size: info.Size() + dirSize(sidecar(path)), ModTime: info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime.Before(files[j].ModTime) })
+	return files, nil
+}
+
+// Prune deletes files, or moves them under archiveDir (which may start with
+// "~/") at the same path relative to dir when archiveDir is set. Each transcript's sidecar
+// directory goes with it, and project directories left empty are removed.
+// It stops at the first failure, returning what was done so far.
+func Prune(dir string, files []File, archiveDir string) (Result, error) {
+	archiveDir = expandHome(archiveDir)
+	var result Result
+	for _, f := range files {
+		paths := []string{f.Path}
+		if _, err := os.Stat(sidecar(f.Path)); err == nil {
+			paths = append(paths, sidecar(f.Path))
+		}
+		for _, path := range paths {
+			if err := prunePath(dir, path, archiveDir); err != nil {
+				return result, err
+			}
+		}
+		result.Files++
+		result.Bytes += f.Size
+		// Fails harmlessly while the project still has other files.
+		_ = os.Remove(filepath.Dir(f.Path))
+	}
+	return result, nil
+}
+
+func prunePath(dir, path, archiveDir string) error {
+	if archiveDir == "" {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(archiveDir, rel)
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if err := move(path, target); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", path, err)
+	}
+	return nil
+}
+
+// move renames src to dst, copying across filesystems.
+func move(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o700)
+		}
+		return copyFile(path, target)
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// sidecar returns the directory Claude Code keeps next to a session
+// transcript for its subagents and large tool results.
+func sidecar(transcript string) string {
+	return strings.TrimSuffix(transcript, ".jsonl")
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// AutoDue reports whether an automatic run is due: the stamp file is
+// missing or older than AutoInterval.
+func AutoDue(stamp string, now time.Time) bool {
+	info, err := os.Stat(stamp)
+	return err != nil || now.Sub(info.ModTime()) >= AutoInterval
+}
+
+// MarkAutoRun records an automatic run by touching the stamp file.
+func MarkAutoRun(stamp string, now time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(stamp), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(stamp, nil, 0o600); err != nil {
+		return err
+	}
+	return os.Chtimes(stamp, now, now)
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
+
+// StampPath returns the file that records the last automatic run.
+func StampPath(homeDir string) string {
+	return filepath.Join(homeDir, ".agents", "ccpersona", "transcripts-pruned")
+}
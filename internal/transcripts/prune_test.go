@@ -0,0 +1,115 @@
+package transcripts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+	}
+	for in, want := range tests {
+		if got, err := ParseAge(in); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "thirty days", "0d", "-1h"} {
+		if _, err := ParseAge(in); err == nil {
+			t.Errorf("ParseAge(%q) = nil error", in)
+		}
+	}
+}
+
+// writeTree creates files with the given ages under dir.
+func writeTree(t *testing.T, dir string, files map[string]time.Duration) {
+	t.Helper()
+	now := time.Now()
+	for rel, age := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("0123456789"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindAndPrune(t *testing.T) {
+	const day = 24 * time.Hour
+	for _, archive := range []bool{false, true} {
+		dir := filepath.Join(t.TempDir(), "projects")
+		writeTree(t, dir, map[string]time.Duration{
+			"-old/a.jsonl":                   40 * day,
+			"-old/a/subagents/agent-1.jsonl": 40 * day,
+			"-mixed/b.jsonl":                 35 * day,
+			"-mixed/c.jsonl":                 time.Hour,
+			"-mixed/notes.txt":               40 * day,
+		})
+
+		files, err := Find(dir, time.Now().Add(-30*day))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 2 || filepath.Base(files[0].Path) != "a.jsonl" || files[0].Size != 20 {
+			t.Fatalf("Find = %+v, want a.jsonl (with its sidecar) then b.jsonl", files)
+		}
+
+		var archiveDir string
+		if archive {
+			archiveDir = t.TempDir()
+		}
+		result, err := Prune(dir, files, archiveDir)
+		if err != nil || result.Files != 2 || result.Bytes != 30 {
+			t.Fatalf("Prune = %+v, %v", result, err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "-old")); !os.IsNotExist(err) {
+			t.Error("an emptied project directory should be removed")
+		}
+		for _, keep := range []string{"-mixed/c.jsonl", "-mixed/notes.txt"} {
+			if _, err := os.Stat(filepath.Join(dir, keep)); err != nil {
+				t.Errorf("%s should be kept: %v", keep, err)
+			}
+		}
+		if archive {
+			for _, moved := range []string{"-old/a.jsonl", "-old/a/subagents/agent-1.jsonl", "-mixed/b.jsonl"} {
+				if _, err := os.Stat(filepath.Join(archiveDir, moved)); err != nil {
+					t.Errorf("%s should be archived: %v", moved, err)
+				}
+			}
+		}
+	}
+}
+
+func TestFindMissingDir(t *testing.T) {
+	files, err := Find(filepath.Join(t.TempDir(), "none"), time.Now())
+	if err != nil || files != nil {
+		t.Errorf("Find(missing) = %v, %v", files, err)
+	}
+}
+
+func TestAutoDue(t *testing.T) {
+	stamp := filepath.Join(t.TempDir(), "ccpersona", "stamp")
+	now := time.Now()
+	if !AutoDue(stamp, now) {
+		t.Error("a missing stamp should be due")
+	}
+	if err := MarkAutoRun(stamp, now); err != nil {
+		t.Fatal(err)
+	}
+	if AutoDue(stamp, now.Add(time.Hour)) {
+		t.Error("a run an hour ago should not be due")
+	}
+	if !AutoDue(stamp, now.Add(AutoInterval)) {
+		t.Error("a run a day ago should be due")
+	}
+}
@@ -0,0 +1,16 @@
+package tray
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// checkHost fails early without a D-Bus session, where the Linux tray
+// backend would wait forever for a tray host it cannot reach.
+func checkHost() error {
+	if _, err := dbus.SessionBus(); err != nil {
+		return fmt.Errorf("the tray needs a desktop session with D-Bus: %w", err)
+	}
+	return nil
+}
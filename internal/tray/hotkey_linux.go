@@ -0,0 +1,122 @@
+package tray
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// The XDG desktop portal's GlobalShortcuts interface, implemented by KDE
+// Plasma and GNOME 48 and later, on X11 and Wayland alike.
+const (
+	portalDest     = "org.freedesktop.portal.Desktop"
+	portalPath     = "/org/freedesktop/portal/desktop"
+	shortcutsIface = "org.freedesktop.portal.GlobalShortcuts"
+	requestIface   = "org.freedesktop.portal.Request"
+)
+
+var portalToken atomic.Uint32
+
+// BindHotkey registers a desktop-wide shortcut and calls fn on every press
+// until ctx is done. trigger uses the portal's notation, such as
+// "CTRL+ALT+period"; the desktop may ask the user to confirm it or pick
+// another.
+func BindHotkey(ctx context.Context, id, description, trigger string, fn func()) error {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrHotkeyUnsupported, err)
+	}
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+	for _, member := range []struct{ iface, name string }{{requestIface, "Response"}, {shortcutsIface, "Activated"}} {
+		if err := conn.AddMatchSignal(dbus.WithMatchInterface(member.iface), dbus.WithMatchMember(member.name)); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	portal := conn.Object(portalDest, portalPath)
+
+	results, err := portalRequest(ctx, portal, signals, "CreateSession", map[string]dbus.Variant{
+		"session_handle_token": dbus.MakeVariant(nextToken()),
+	})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	handle, _ := results["session_handle"].Value().(string)
+	if handle == "" {
+		conn.Close()
+		return fmt.Errorf("%w: the portal returned no session", ErrHotkeyUnsupported)
+	}
+	session := dbus.ObjectPath(handle)
+
+	shortcuts := []struct {
+		ID      string
+		Options map[string]dbus.Variant
+	}{{id, map[string]dbus.Variant{
+		"description":       dbus.MakeVariant(description),
+		"preferred_trigger": dbus.MakeVariant(trigger),
+	}}}
+	if _, err := portalRequest(ctx, portal, signals, "BindShortcuts", session, shortcuts, "", map[string]dbus.Variant{}); err != nil {
+		conn.Close()
+		return err
+	}
+
+	go func() {
+		defer conn.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case s := <-signals:
+				if s.Name != shortcutsIface+".Activated" || len(s.Body) < 2 {
+					continue
+				}
+				if path, _ := s.Body[0].(dbus.ObjectPath); path != session {
+					continue
+				}
+				if shortcut, _ := s.Body[1].(string); shortcut == id {
+					fn()
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// portalRequest calls a GlobalShortcuts method whose last argument is the
+// options map, then waits for the Response signal of the Request it returns.
+func portalRequest(ctx context.Context, portal dbus.BusObject, signals <-chan *dbus.Signal, method string, args ...interface{}) (map[string]dbus.Variant, error) {
+	options := args[len(args)-1].(map[string]dbus.Variant)
+	options["handle_token"] = dbus.MakeVariant(nextToken())
+	var request dbus.ObjectPath
+	if err := portal.CallWithContext(ctx, shortcutsIface+"."+method, 0, args...).Store(&request); err != nil {
+		if strings.Contains(err.Error(), "No such interface") || strings.Contains(err.Error(), "UnknownMethod") {
+			return nil, fmt.Errorf("%w: the desktop has no global shortcuts portal", ErrHotkeyUnsupported)
+		}
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case s := <-signals:
+			if s.Path != request || s.Name != requestIface+".Response" || len(s.Body) < 2 {
+				continue
+			}
+			if code, _ := s.Body[0].(uint32); code != 0 {
+				return nil, fmt.Errorf("%s was declined or cancelled (response %d)", method, code)
+			}
+			results, _ := s.Body[1].(map[string]dbus.Variant)
+			return results, nil
+		}
+	}
+}
+
+func nextToken() string {
+	return "ccpersona" + strconv.FormatUint(uint64(portalToken.Add(1)), 10)
+}
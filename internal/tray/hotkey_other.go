@@ -0,0 +1,10 @@
+//go:build !linux
+
+package tray
+
+import "context"
+
+// BindHotkey is only implemented on Linux, through the desktop portal.
+func BindHotkey(ctx context.Context, id, description, trigger string, fn func()) error {
+	return ErrHotkeyUnsupported
+}
@@ -0,0 +1,71 @@
+package tray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// iconSize is the edge of the generated icon in pixels; tray hosts scale it.
+const iconSize = 32
+
+var (
+	activeColor = color.NRGBA{R: 0x5b, G: 0x8d, B: 0xef, A: 0xff}
+	mutedColor  = color.NRGBA{R: 0x8a, G: 0x8a, B: 0x8a, A: 0xff}
+	slashColor  = color.NRGBA{R: 0xe0, G: 0x3e, B: 0x3e, A: 0xff}
+)
+
+// Icon returns the tray icon for the mute state: a blue disc, or a grey one
+// struck through when muted. Windows gets an ICO container, other platforms
+// a PNG.
+func Icon(muted bool, goos string) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, iconSize, iconSize))
+	fill := activeColor
+	if muted {
+		fill = mutedColor
+	}
+	const center, radius = iconSize / 2, iconSize/2 - 2
+	for y := 0; y < iconSize; y++ {
+		for x := 0; x < iconSize; x++ {
+			dx, dy := x-center, y-center
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetNRGBA(x, y, fill)
+			}
+		}
+	}
+	if muted {
+		for i := 3; i < iconSize-3; i++ {
+			for w := -1; w <= 1; w++ {
+				if x := i + w; x >= 0 && x < iconSize {
+					img.SetNRGBA(x, iconSize-1-i, slashColor)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	if goos == "windows" {
+		return wrapICO(buf.Bytes())
+	}
+	return buf.Bytes()
+}
+
+// wrapICO puts a PNG image into an ICO container with one entry, which
+// Windows Vista and later read directly.
+func wrapICO(pngData []byte) []byte {
+	var buf bytes.Buffer
+	const headerSize, entrySize = 6, 16
+	for _, v := range []any{
+		uint16(0), uint16(1), uint16(1), // reserved, type icon, one image
+		uint8(iconSize), uint8(iconSize), uint8(0), uint8(0), // width, height, palette, reserved
+		uint16(1), uint16(32), // color planes, bits per pixel
+		uint32(len(pngData)), uint32(headerSize + entrySize),
+	} {
+		_ = binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.Write(pngData)
+	return buf.Bytes()
+}
@@ -0,0 +1,134 @@
+//go:build linux || windows || (darwin && cgo)
+
+package tray
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"fyne.io/systray"
+)
+
+// Run shows the tray icon and menu until Quit is chosen or ctx is done.
+func Run(ctx context.Context, actions Actions) error {
+	if err := checkHost(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	systray.Run(func() { newMenu(actions).serve(ctx) }, cancel)
+	return nil
+}
+
+type menu struct {
+	actions  Actions
+	status   *systray.MenuItem
+	mute     *systray.MenuItem
+	personas *systray.MenuItem
+	replay   *systray.MenuItem
+	skip     *systray.MenuItem
+	config   *systray.MenuItem
+	quit     *systray.MenuItem
+
+	mu       sync.Mutex
+	state    State
+	shown    bool
+	choices  map[string]*systray.MenuItem
+	personaC chan string
+}
+
+func newMenu(actions Actions) *menu {
+	m := &menu{actions: actions, choices: map[string]*systray.MenuItem{}, personaC: make(chan string)}
+	m.status = systray.AddMenuItem("ccpersona", "")
+	m.status.Disable()
+	systray.AddSeparator()
+	m.mute = systray.AddMenuItemCheckbox("Mute voice", "Mute or unmute spoken messages everywhere", false)
+	m.personas = systray.AddMenuItem("Switch persona", "Set the persona in the config the tray shows")
+	m.replay = systray.AddMenuItem("Replay last message", "Speak the latest assistant message again")
+	m.skip = systray.AddMenuItem("Skip speech", "Stop the message being read and drop the ones waiting")
+	m.config = systray.AddMenuItem("Open config", "Open the ccpersona config file")
+	systray.AddSeparator()
+	m.quit = systray.AddMenuItem("Quit", "Close the tray")
+	return m
+}
+
+func (m *menu) serve(ctx context.Context) {
+	m.refresh()
+	ticker := time.NewTicker(RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			systray.Quit()
+			return
+		case <-m.quit.ClickedCh:
+			systray.Quit()
+			return
+		case <-ticker.C:
+			m.refresh()
+		case <-m.mute.ClickedCh:
+			muted := !m.mute.Checked()
+			go m.actions.run("mute", func() error {
+				defer m.refresh()
+				return m.actions.SetMuted(muted)
+			})
+		case name := <-m.personaC:
+			go m.actions.run("switch persona", func() error {
+				defer m.refresh()
+				return m.actions.SetPersona(name)
+			})
+		case <-m.replay.ClickedCh:
+			go m.actions.run("replay", m.actions.ReplayLast)
+		case <-m.skip.ClickedCh:
+			go m.actions.run("skip", m.actions.Skip)
+		case <-m.config.ClickedCh:
+			go m.actions.run("open config", m.actions.OpenConfig)
+		}
+	}
+}
+
+// refresh rereads the state and persona list and updates what changed.
+func (m *menu) refresh() {
+	state := m.actions.State()
+	names, err := m.actions.Personas()
+	if err != nil {
+		names = nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, name := range names {
+		if m.choices[name] == nil {
+			item := m.personas.AddSubMenuItemCheckbox(name, "", false)
+			m.choices[name] = item
+			go func(name string) {
+				for range item.ClickedCh {
+					m.personaC <- name
+				}
+			}(name)
+		}
+	}
+	for name, item := range m.choices {
+		switch active := name == state.Persona; {
+		case active && !item.Checked():
+			item.Check()
+		case !active && item.Checked():
+			item.Uncheck()
+		}
+	}
+	if m.shown && state == m.state {
+		return
+	}
+	m.state, m.shown = state, true
+	systray.SetIcon(Icon(state.Muted, runtime.GOOS))
+	systray.SetTitle(state.Title())
+	systray.SetTooltip(state.Tooltip())
+	m.status.SetTitle(state.Tooltip())
+	if state.Muted {
+		m.mute.Check()
+	} else {
+		m.mute.Uncheck()
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !(linux || windows || (darwin && cgo))
+
+package tray
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// Run reports that this build has no tray backend; on macOS the backend
+// needs cgo.
+func Run(ctx context.Context, actions Actions) error {
+	return fmt.Errorf("%w on %s", ErrUnsupported, runtime.GOOS)
+}
@@ -0,0 +1,80 @@
+// Package tray shows ccpersona in the system tray or menu bar: an icon for
+// the mute state, the current persona as title and tooltip, and a menu to
+// mute, switch persona, replay or skip a message, and open the config.
+package tray
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnsupported is returned by Run in builds without a tray backend, such
+// as macOS builds without cgo.
+var ErrUnsupported = errors.New("the tray is not supported by this build")
+
+// ErrHotkeyUnsupported is returned by BindHotkey where no global shortcut
+// service is available. Bind `ccpersona runtime skip` in the desktop's
+// keyboard settings instead.
+var ErrHotkeyUnsupported = errors.New("global shortcuts are not supported here")
+
+// RefreshInterval is how often the tray rereads the state, so mute and
+// persona changes made from a terminal show up.
+const RefreshInterval = 2 * time.Second
+
+// State is what the tray shows.
+type State struct {
+	// Persona is the active persona; "" when no config exists.
+	Persona string
+	// Scope is where the persona comes from, such as "project" or "global".
+	Scope string
+	Muted bool
+}
+
+// Title is the text next to the icon where the platform shows one.
+func (s State) Title() string {
+	name := s.Persona
+	if name == "" {
+		name = "ccpersona"
+	}
+	if s.Muted {
+		return name + " (muted)"
+	}
+	return name
+}
+
+// Tooltip describes the state on hover.
+func (s State) Tooltip() string {
+	persona := "no persona configured"
+	if s.Persona != "" {
+		persona = fmt.Sprintf("persona %s", s.Persona)
+		if s.Scope != "" {
+			persona += fmt.Sprintf(" (%s)", s.Scope)
+		}
+	}
+	voice := "voice on"
+	if s.Muted {
+		voice = "voice muted"
+	}
+	return fmt.Sprintf("ccpersona: %s, %s", persona, voice)
+}
+
+// Actions connect the menu to ccpersona. Each runs on its own goroutine;
+// errors are passed to Report.
+type Actions struct {
+	State      func() State
+	SetMuted   func(muted bool) error
+	Personas   func() ([]string, error)
+	SetPersona func(name string) error
+	ReplayLast func() error
+	Skip       func() error
+	OpenConfig func() error
+	// Report shows or logs an action's error.
+	Report func(action string, err error)
+}
+
+func (a Actions) run(action string, fn func() error) {
+	if err := fn(); err != nil && a.Report != nil {
+		a.Report(action, err)
+	}
+}
@@ -0,0 +1,54 @@
+package tray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"testing"
+)
+
+func TestStateText(t *testing.T) {
+	tests := []struct {
+		state   State
+		title   string
+		tooltip string
+	}{
+		{State{Persona: "fable", Scope: "project"}, "fable", "ccpersona: persona fable (project), voice on"},
+		{State{Persona: "fable", Muted: true}, "fable (muted)", "ccpersona: persona fable, voice muted"},
+		{State{}, "ccpersona", "ccpersona: no persona configured, voice on"},
+	}
+	for _, tt := range tests {
+		if got := tt.state.Title(); got != tt.title {
+			t.Errorf("%+v Title() = %q, want %q", tt.state, got, tt.title)
+		}
+		if got := tt.state.Tooltip(); got != tt.tooltip {
+			t.Errorf("%+v Tooltip() = %q, want %q", tt.state, got, tt.tooltip)
+		}
+	}
+}
+
+func TestIcon(t *testing.T) {
+	active, muted := Icon(false, "linux"), Icon(true, "linux")
+	for _, data := range [][]byte{active, muted} {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("icon is not a PNG: %v", err)
+		}
+		if b := img.Bounds(); b.Dx() != iconSize || b.Dy() != iconSize {
+			t.Errorf("icon size = %v", b)
+		}
+	}
+	if bytes.Equal(active, muted) {
+		t.Error("muted icon should differ")
+	}
+
+	ico := Icon(false, "windows")
+	if binary.LittleEndian.Uint16(ico[2:]) != 1 || binary.LittleEndian.Uint16(ico[4:]) != 1 {
+		t.Fatalf("ICO header = %v", ico[:6])
+	}
+	size := binary.LittleEndian.Uint32(ico[14:])
+	offset := binary.LittleEndian.Uint32(ico[18:])
+	if int(offset+size) != len(ico) || !bytes.Equal(ico[offset:], active) {
+		t.Errorf("ICO entry size %d offset %d does not frame the PNG", size, offset)
+	}
+}
@@ -0,0 +1,159 @@
+// Package trust verifies persona bundles signed with minisign keys against a
+// local store of trusted public keys.
+//
+// A persona bundle is a persona markdown file and a detached minisign
+// signature next to it (<file>.minisig). Organizations sign vetted personas
+// with `minisign -S -m persona.md`; users trust the organization's public key
+// once and can then verify every persona it publishes.
+package trust
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	untrustedPrefix = "untrusted comment:"
+	trustedPrefix   = "trusted comment:"
+)
+
+var (
+	// ErrBadSignature means the content or trusted comment does not match
+	// the signature: the bundle was modified after signing.
+	ErrBadSignature = errors.New("signature verification failed")
+	// ErrUntrustedKey means the signature names a key that is not in the
+	// trust store.
+	ErrUntrustedKey = errors.New("signing key is not trusted")
+)
+
+// Key is a minisign Ed25519 public key.
+type Key struct {
+	// ID is the key ID as minisign prints it (16 uppercase hex digits).
+	ID string
+	// Comment is the untrusted comment from the key file, usually the
+	// signer's name.
+	Comment   string
+	PublicKey ed25519.PublicKey
+	keyNum    [8]byte
+}
+
+// ParsePublicKey parses a minisign public key, either a whole .pub file or
+// the bare base64 line as passed to `minisign -P`.
+func ParsePublicKey(text string) (*Key, error) {
+	comment := ""
+	encoded := ""
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, untrustedPrefix):
+			comment = strings.TrimSpace(strings.TrimPrefix(line, untrustedPrefix))
+		case encoded == "":
+			encoded = line
+		default:
+			return nil, fmt.Errorf("unexpected line in public key: %q", line)
+		}
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize {
+		return nil, fmt.Errorf("not a minisign public key")
+	}
+	if string(raw[:2]) != "Ed" {
+		return nil, fmt.Errorf("unsupported key algorithm %q", raw[:2])
+	}
+	key := &Key{Comment: comment, PublicKey: ed25519.PublicKey(raw[10:])}
+	copy(key.keyNum[:], raw[2:10])
+	key.ID = keyID(key.keyNum)
+	return key, nil
+}
+
+// Encode returns the key in minisign .pub file format.
+func (k *Key) Encode() string {
+	raw := append([]byte("Ed"), k.keyNum[:]...)
+	raw = append(raw, k.PublicKey...)
+	comment := k.Comment
+	if comment == "" {
+		comment = "minisign public key " + k.ID
+	}
+	return fmt.Sprintf("%s %s\n%s\n", untrustedPrefix, comment, base64.StdEncoding.EncodeToString(raw))
+}
+
+// Signature is a parsed minisign detached signature.
+type Signature struct {
+	// KeyID identifies the signing key in the same form as Key.ID.
+	KeyID string
+	// TrustedComment is signed along with the content; minisign puts a
+	// timestamp and file name there by default.
+	TrustedComment string
+
+	prehashed bool
+	keyNum    [8]byte
+	sig       []byte
+	globalSig []byte
+}
+
+// ParseSignature parses a .minisig file.
+func ParseSignature(data []byte) (*Signature, error) {
+	lines := strings.Split(strings.TrimSpace(string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")))), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], untrustedPrefix) || !strings.HasPrefix(lines[2], trustedPrefix) {
+		return nil, fmt.Errorf("not a minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return nil, fmt.Errorf("not a minisign signature")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("malformed trusted comment signature")
+	}
+
+	sig := &Signature{
+		TrustedComment: strings.TrimPrefix(strings.TrimPrefix(lines[2], trustedPrefix), " "),
+		sig:            raw[10:],
+		globalSig:      global,
+	}
+	switch string(raw[:2]) {
+	case "Ed":
+	case "ED":
+		sig.prehashed = true
+	default:
+		return nil, fmt.Errorf("unsupported signature algorithm %q", raw[:2])
+	}
+	copy(sig.keyNum[:], raw[2:10])
+	sig.KeyID = keyID(sig.keyNum)
+	return sig, nil
+}
+
+// Verify checks that sig is k's signature over data, including the trusted
+// comment.
+func (k *Key) Verify(data []byte, sig *Signature) error {
+	if sig.keyNum != k.keyNum {
+		return fmt.Errorf("%w: signed by key %s, not %s", ErrBadSignature, sig.KeyID, k.ID)
+	}
+	message := data
+	if sig.prehashed {
+		sum := blake2b.Sum512(data)
+		message = sum[:]
+	}
+	if !ed25519.Verify(k.PublicKey, message, sig.sig) {
+		return ErrBadSignature
+	}
+	global := append(append([]byte{}, sig.sig...), sig.TrustedComment...)
+	if !ed25519.Verify(k.PublicKey, global, sig.globalSig) {
+		return fmt.Errorf("%w: trusted comment was modified", ErrBadSignature)
+	}
+	return nil
+}
+
+// keyID formats a key number the way minisign does: as a little-endian
+// 64-bit integer in hex.
+func keyID(keyNum [8]byte) string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(keyNum[:]))
+}
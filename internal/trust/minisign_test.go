@@ -0,0 +1,107 @@
+package trust
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testSigner produces keys and signatures in minisign's file formats.
+type testSigner struct {
+	keyNum [8]byte
+	priv   ed25519.PrivateKey
+	pub    ed25519.PublicKey
+}
+
+func newTestSigner(t *testing.T, seed byte) *testSigner {
+	t.Helper()
+	s := &testSigner{keyNum: [8]byte{seed, 2, 3, 4, 5, 6, 7, 8}}
+	s.priv = ed25519.NewKeyFromSeed(append([]byte{seed}, make([]byte, ed25519.SeedSize-1)...))
+	s.pub = s.priv.Public().(ed25519.PublicKey)
+	return s
+}
+
+func (s *testSigner) publicKey(comment string) string {
+	raw := append([]byte("Ed"), s.keyNum[:]...)
+	raw = append(raw, s.pub...)
+	return fmt.Sprintf("untrusted comment: %s\n%s\n", comment, base64.StdEncoding.EncodeToString(raw))
+}
+
+func (s *testSigner) sign(data []byte, prehash bool, trusted string) []byte {
+	alg, message := "Ed", data
+	if prehash {
+		sum := blake2b.Sum512(data)
+		alg, message = "ED", sum[:]
+	}
+	sig := ed25519.Sign(s.priv, message)
+	raw := append(append([]byte(alg), s.keyNum[:]...), sig...)
+	global := ed25519.Sign(s.priv, append(append([]byte{}, sig...), trusted...))
+	return []byte(fmt.Sprintf("untrusted comment: signature from test key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), trusted, base64.StdEncoding.EncodeToString(global)))
+}
+
+func TestParsePublicKey(t *testing.T) {
+	s := newTestSigner(t, 1)
+	key, err := ParsePublicKey(s.publicKey("acme personas"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.ID != "0807060504030201" {
+		t.Errorf("ID = %s, want minisign's little-endian hex", key.ID)
+	}
+	if key.Comment != "acme personas" {
+		t.Errorf("Comment = %q", key.Comment)
+	}
+
+	bare := strings.Split(s.publicKey("x"), "\n")[1]
+	if key, err := ParsePublicKey(bare); err != nil || key.ID != "0807060504030201" {
+		t.Fatalf("bare key = %+v, %v", key, err)
+	}
+	round, err := ParsePublicKey(key.Encode())
+	if err != nil || round.ID != key.ID || !round.PublicKey.Equal(key.PublicKey) {
+		t.Fatalf("Encode round trip = %+v, %v", round, err)
+	}
+
+	for _, bad := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParsePublicKey(bad); err == nil {
+			t.Errorf("ParsePublicKey(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestKeyVerify(t *testing.T) {
+	s := newTestSigner(t, 1)
+	key, _ := ParsePublicKey(s.publicKey("acme"))
+	data := []byte("# 人格: reviewed\n")
+
+	for _, prehash := range []bool{false, true} {
+		sig, err := ParseSignature(s.sign(data, prehash, "timestamp:1700000000\tfile:reviewed.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := key.Verify(data, sig); err != nil {
+			t.Errorf("prehash=%v: %v", prehash, err)
+		}
+		if sig.TrustedComment != "timestamp:1700000000\tfile:reviewed.md" {
+			t.Errorf("TrustedComment = %q", sig.TrustedComment)
+		}
+		if err := key.Verify([]byte("# 人格: tampered\n"), sig); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("prehash=%v tampered content error = %v", prehash, err)
+		}
+	}
+
+	signed := string(s.sign(data, true, "original"))
+	forged := strings.Replace(signed, "trusted comment: original", "trusted comment: forged", 1)
+	sig, err := ParseSignature([]byte(forged))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := key.Verify(data, sig); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("forged trusted comment error = %v", err)
+	}
+}
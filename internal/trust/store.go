@@ -0,0 +1,119 @@
+package trust
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// Store is a directory of trusted minisign public keys, one <ID>.pub file per
+// key. Keys can also be dropped in by hand or provisioned by configuration
+// management.
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store backed by dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultStore returns the store at ~/.agents/ccpersona/trust.
+func DefaultStore() (*Store, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return NewStore(filepath.Join(homeDir, ".agents", "ccpersona", "trust")), nil
+}
+
+// Dir returns the store directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Add trusts key and returns the file it was written to. Adding a key that
+// is already trusted updates its comment.
+func (s *Store) Add(key *Key) (string, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create trust store: %w", err)
+	}
+	path := filepath.Join(s.dir, key.ID+".pub")
+	if err := fsutil.WriteFile(path, []byte(key.Encode()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write trusted key: %w", err)
+	}
+	return path, nil
+}
+
+// Keys returns the trusted keys sorted by ID. A missing store has no keys.
+func (s *Store) Keys() ([]*Key, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read trust store: %w", err)
+	}
+	var keys []*Key
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pub") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trusted key: %w", err)
+		}
+		key, err := ParsePublicKey(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+// Remove stops trusting the key with the given ID.
+func (s *Store) Remove(id string) error {
+	keys, err := s.Keys()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if strings.EqualFold(key.ID, id) {
+			if err := os.Remove(filepath.Join(s.dir, key.ID+".pub")); err != nil {
+				return fmt.Errorf("failed to remove trusted key: %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("key %s is not trusted", id)
+}
+
+// Verify checks a detached signature over data against the trusted keys and
+// returns the key that signed it. It wraps ErrUntrustedKey when the signing
+// key is unknown and ErrBadSignature when the signature does not match.
+func (s *Store) Verify(data, signature []byte) (*Key, *Signature, error) {
+	sig, err := ParseSignature(signature)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys, err := s.Keys()
+	if err != nil {
+		return nil, sig, err
+	}
+	for _, key := range keys {
+		if key.keyNum != sig.keyNum {
+			continue
+		}
+		if err := key.Verify(data, sig); err != nil {
+			return nil, sig, err
+		}
+		return key, sig, nil
+	}
+	return nil, sig, fmt.Errorf("%w: key %s (add it with: ccpersona trust add <public key>)", ErrUntrustedKey, sig.KeyID)
+}
@@ -0,0 +1,46 @@
+package trust
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "trust"))
+	if keys, err := store.Keys(); err != nil || len(keys) != 0 {
+		t.Fatalf("empty store = %v, %v", keys, err)
+	}
+
+	acme, other := newTestSigner(t, 1), newTestSigner(t, 9)
+	key, _ := ParsePublicKey(acme.publicKey("acme"))
+	if _, err := store.Add(key); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("# persona\n")
+	got, sig, err := store.Verify(data, acme.sign(data, true, "ok"))
+	if err != nil || got.ID != key.ID || sig.TrustedComment != "ok" {
+		t.Fatalf("Verify = %+v, %+v, %v", got, sig, err)
+	}
+	if _, _, err := store.Verify(data, other.sign(data, true, "ok")); !errors.Is(err, ErrUntrustedKey) {
+		t.Fatalf("unknown key error = %v", err)
+	}
+	if _, _, err := store.Verify([]byte("changed"), acme.sign(data, true, "ok")); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("tampered error = %v", err)
+	}
+	if _, _, err := store.Verify(data, []byte("garbage")); err == nil {
+		t.Fatal("expected malformed signature to fail")
+	}
+
+	if err := store.Remove(strings.ToLower(key.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := store.Keys(); len(keys) != 0 {
+		t.Fatalf("keys after remove = %v", keys)
+	}
+	if err := store.Remove(key.ID); err == nil {
+		t.Fatal("expected removing an unknown key to fail")
+	}
+}
@@ -0,0 +1,171 @@
+package usage
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxComments is how many recent comments a persona summary keeps.
+const maxComments = 3
+
+// ParseRating reads a thumbs up or down, or an ASCII spelling of one, as +1
+// or -1.
+func ParseRating(s string) (int, bool) {
+	// Drop skin tone modifiers and the emoji variation selector.
+	s = strings.Map(func(r rune) rune {
+		if (r >= 0x1F3FB && r <= 0x1F3FF) || r == 0xFE0F {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(s)))
+	switch s {
+	case "👍", "+", "+1", "up", "good", "yes", "y":
+		return 1, true
+	case "👎", "-", "down", "bad", "no", "n":
+		return -1, true
+	}
+	return 0, false
+}
+
+// RatingSymbol returns 👍 or 👎 for a rating.
+func RatingSymbol(rating int) string {
+	if rating < 0 {
+		return "👎"
+	}
+	return "👍"
+}
+
+// LatestSession returns the most recent session recorded in dir or in a
+// directory containing it, such as the project root above a subdirectory.
+func LatestSession(entries []Entry, dir string) (Entry, bool) {
+	var latest Entry
+	found := false
+	for _, entry := range entries {
+		if entry.Kind != KindSession || entry.Project == "" || !within(dir, entry.Project) {
+			continue
+		}
+		if !found || !entry.Time.Before(latest.Time) {
+			latest, found = entry, true
+		}
+	}
+	return latest, found
+}
+
+// FindSession returns the session entry with id.
+func FindSession(entries []Entry, id string) (Entry, bool) {
+	for _, entry := range entries {
+		if entry.Kind == KindSession && entry.Session == id {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+func within(dir, root string) bool {
+	rel, err := filepath.Rel(root, dir)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// PersonaFeedback is the satisfaction recorded for one persona.
+type PersonaFeedback struct {
+	Name string `json:"name"`
+	// Sessions counts the persona's sessions in the period, rated or not.
+	Sessions int `json:"sessions"`
+	Up       int `json:"up"`
+	Down     int `json:"down"`
+	// Comments are the most recent notes, newest first.
+	Comments []Comment `json:"comments,omitempty"`
+}
+
+// Comment is a note left with a rating.
+type Comment struct {
+	Time   time.Time `json:"time"`
+	Rating int       `json:"rating"`
+	Text   string    `json:"text"`
+}
+
+// Satisfaction returns the share of ratings that were thumbs up, and false
+// when the persona has no ratings.
+func (p PersonaFeedback) Satisfaction() (float64, bool) {
+	if p.Up+p.Down == 0 {
+		return 0, false
+	}
+	return float64(p.Up) / float64(p.Up+p.Down), true
+}
+
+// FeedbackByPersona summarizes ratings per persona. Rating a session again
+// replaces its earlier rating; feedback without a session counts on its own.
+// Personas with the most ratings come first.
+func FeedbackByPersona(entries []Entry) []PersonaFeedback {
+	personas := map[string]*PersonaFeedback{}
+	get := func(name string) *PersonaFeedback {
+		if name == "" {
+			name = "(none)"
+		}
+		if personas[name] == nil {
+			personas[name] = &PersonaFeedback{Name: name}
+		}
+		return personas[name]
+	}
+
+	seen := map[string]bool{}
+	var ratings []Entry
+	latest := map[string]int{}
+	for _, entry := range entries {
+		switch entry.Kind {
+		case KindSession:
+			if entry.Session != "" {
+				if seen[entry.Session] {
+					continue
+				}
+				seen[entry.Session] = true
+			}
+			get(entry.Persona).Sessions++
+		case KindFeedback:
+			if entry.Rating == 0 {
+				continue
+			}
+			if entry.Session != "" {
+				if i, ok := latest[entry.Session]; ok {
+					ratings[i] = entry
+					continue
+				}
+				latest[entry.Session] = len(ratings)
+			}
+			ratings = append(ratings, entry)
+		}
+	}
+
+	for _, entry := range ratings {
+		p := get(entry.Persona)
+		if entry.Rating > 0 {
+			p.Up++
+		} else {
+			p.Down++
+		}
+		if entry.Comment != "" {
+			p.Comments = append(p.Comments, Comment{Time: entry.Time, Rating: entry.Rating, Text: entry.Comment})
+		}
+	}
+
+	out := make([]PersonaFeedback, 0, len(personas))
+	for _, p := range personas {
+		sort.SliceStable(p.Comments, func(i, j int) bool { return p.Comments[i].Time.After(p.Comments[j].Time) })
+		if len(p.Comments) > maxComments {
+			p.Comments = p.Comments[:maxComments]
+		}
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if a, b := out[i].Up+out[i].Down, out[j].Up+out[j].Down; a != b {
+			return a > b
+		}
+		if out[i].Sessions != out[j].Sessions {
+			return out[i].Sessions > out[j].Sessions
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
@@ -0,0 +1,85 @@
+package usage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRating(t *testing.T) {
+	tests := map[string]int{
+		"👍": 1, "👍🏽": 1, "+": 1, "Up": 1, "good": 1,
+		"👎": -1, "👎️": -1, "-": -1, "down": -1, "BAD": -1,
+		"meh": 0, "": 0,
+	}
+	for in, want := range tests {
+		got, ok := ParseRating(in)
+		if got != want || ok != (want != 0) {
+			t.Errorf("ParseRating(%q) = %d, %v; want %d", in, got, ok, want)
+		}
+	}
+}
+
+func TestLatestSession(t *testing.T) {
+	now := time.Now().UTC()
+	entries := []Entry{
+		{Time: now.Add(-3 * time.Hour), Kind: KindSession, Project: "/w/api", Session: "old"},
+		{Time: now.Add(-2 * time.Hour), Kind: KindSession, Project: "/w/api", Session: "new"},
+		{Time: now.Add(-time.Hour), Kind: KindSession, Project: "/w/web", Session: "web"},
+		{Time: now, Kind: KindSpeech, Project: "/w/api"},
+	}
+	if got, ok := LatestSession(entries, "/w/api/internal"); !ok || got.Session != "new" {
+		t.Errorf("LatestSession(subdir) = %+v, %v", got, ok)
+	}
+	if _, ok := LatestSession(entries, "/w/apiserver"); ok {
+		t.Error("a sibling with a common prefix should not match")
+	}
+	if got, ok := FindSession(entries, "web"); !ok || got.Project != "/w/web" {
+		t.Errorf("FindSession = %+v, %v", got, ok)
+	}
+}
+
+func TestFeedbackByPersona(t *testing.T) {
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: at, Kind: KindSession, Persona: "fable", Session: "s1"},
+		{Time: at, Kind: KindSession, Persona: "fable", Session: "s1"},
+		{Time: at, Kind: KindSession, Persona: "fable", Session: "s2"},
+		{Time: at, Kind: KindSession, Persona: "calm", Session: "s3"},
+		{Time: at, Kind: KindSession, Persona: "quiet", Session: "s4"},
+		{Time: at.Add(time.Minute), Kind: KindFeedback, Persona: "fable", Session: "s1", Rating: -1, Comment: "too chatty"},
+		{Time: at.Add(2 * time.Minute), Kind: KindFeedback, Persona: "fable", Session: "s1", Rating: 1, Comment: "better after all"},
+		{Time: at.Add(3 * time.Minute), Kind: KindFeedback, Persona: "fable", Session: "s2", Rating: 1},
+		{Time: at.Add(4 * time.Minute), Kind: KindFeedback, Persona: "calm", Rating: -1},
+		{Time: at.Add(5 * time.Minute), Kind: KindFeedback, Persona: "calm", Rating: 1, Comment: "nice"},
+		{Time: at.Add(6 * time.Minute), Kind: KindFeedback, Persona: "calm", Rating: 1},
+	}
+	got := FeedbackByPersona(entries)
+	if len(got) != 3 {
+		t.Fatalf("FeedbackByPersona = %+v", got)
+	}
+	calm, fable, quiet := got[0], got[1], got[2]
+	if calm.Name != "calm" || calm.Up != 2 || calm.Down != 1 || calm.Sessions != 1 {
+		t.Errorf("calm = %+v", calm)
+	}
+	if fable.Name != "fable" || fable.Up != 2 || fable.Down != 0 || fable.Sessions != 2 {
+		t.Errorf("fable = %+v", fable)
+	}
+	if len(fable.Comments) != 1 || fable.Comments[0].Text != "better after all" {
+		t.Errorf("a re-rated session should keep only the latest comment: %+v", fable.Comments)
+	}
+	if share, ok := fable.Satisfaction(); !ok || share != 1 {
+		t.Errorf("fable satisfaction = %v, %v", share, ok)
+	}
+	if _, ok := quiet.Satisfaction(); ok || quiet.Sessions != 1 {
+		t.Errorf("quiet = %+v", quiet)
+	}
+}
+
+func TestAddDisabled(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(EnvLedger, "0")
+	if err := Add(Entry{Kind: KindFeedback, Rating: 1}); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Add = %v, want ErrDisabled", err)
+	}
+}
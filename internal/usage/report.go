@@ -0,0 +1,237 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultPrices are list prices in USD per million characters for the
+// standard voices of the cloud providers, used to estimate costs. Config
+// prices override them; premium voices cost more.
+var DefaultPrices = map[string]float64{
+	"openai":     15,
+	"elevenlabs": 300,
+	"polly":      4,
+	"gcp":        4,
+	"azure":      15,
+}
+
+// Report summarizes the ledger over a period.
+type Report struct {
+	Since, Until time.Time
+
+	Sessions  int
+	Messages  int
+	Seconds   float64
+	Cost      float64
+	Projects  []Group
+	Personas  []Group
+	Providers []ProviderUsage
+}
+
+// Group is the usage of one project or persona.
+type Group struct {
+	Name     string
+	Sessions int
+	Messages int
+	Seconds  float64
+}
+
+// ProviderUsage is the speech synthesized by one provider.
+type ProviderUsage struct {
+	Name     string
+	Messages int
+	Chars    int
+	Seconds  float64
+	Cost     float64
+	// Priced is false for a cloud provider without a known price.
+	Priced bool
+}
+
+// Summarize builds the report for entries between since and until. prices
+// add to or override DefaultPrices.
+func Summarize(entries []Entry, since, until time.Time, prices map[string]float64) *Report {
+	r := &Report{Since: since, Until: until}
+	projects := map[string]*Group{}
+	personas := map[string]*Group{}
+	providers := map[string]*ProviderUsage{}
+	seen := map[string]bool{}
+
+	group := func(groups map[string]*Group, name string) *Group {
+		if name == "" {
+			name = "(none)"
+		}
+		if groups[name] == nil {
+			groups[name] = &Group{Name: name}
+		}
+		return groups[name]
+	}
+
+	for _, entry := range entries {
+		if entry.Time.Before(since) || entry.Time.After(until) {
+			continue
+		}
+		switch entry.Kind {
+		case KindSession:
+			// SessionStart fires again on resume and with each legacy prompt.
+			if entry.Session != "" {
+				if seen[entry.Session] {
+					continue
+				}
+				seen[entry.Session] = true
+			}
+			r.Sessions++
+			group(projects, entry.Project).Sessions++
+			group(personas, entry.Persona).Sessions++
+		case KindSpeech:
+			r.Messages++
+			r.Seconds += entry.Seconds
+			for _, g := range []*Group{group(projects, entry.Project), group(personas, entry.Persona)} {
+				g.Messages++
+				g.Seconds += entry.Seconds
+			}
+			name := entry.Provider
+			if name == "" {
+				name = "voicevox/aivisspeech"
+			}
+			if entry.Local && DefaultPrices[name] > 0 {
+				name += " (custom server)"
+			}
+			p := providers[name]
+			if p == nil {
+				p = &ProviderUsage{Name: name}
+				providers[name] = p
+			}
+			p.Messages++
+			p.Chars += entry.Chars
+			p.Seconds += entry.Seconds
+			if entry.Local {
+				p.Priced = true
+				continue
+			}
+			if price, ok := lookupPrice(prices, entry.Provider); ok {
+				p.Priced = true
+				cost := float64(entry.Chars) / 1e6 * price
+				p.Cost += cost
+				r.Cost += cost
+			}
+		}
+	}
+
+	r.Projects = sortedGroups(projects)
+	r.Personas = sortedGroups(personas)
+	for _, p := range providers {
+		r.Providers = append(r.Providers, *p)
+	}
+	sort.Slice(r.Providers, func(i, j int) bool {
+		if r.Providers[i].Messages != r.Providers[j].Messages {
+			return r.Providers[i].Messages > r.Providers[j].Messages
+		}
+		return r.Providers[i].Name < r.Providers[j].Name
+	})
+	return r
+}
+
+func lookupPrice(prices map[string]float64, provider string) (float64, bool) {
+	if price, ok := prices[provider]; ok {
+		return price, true
+	}
+	price, ok := DefaultPrices[provider]
+	return price, ok
+}
+
+func sortedGroups(groups map[string]*Group) []Group {
+	out := make([]Group, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Sessions != out[j].Sessions {
+			return out[i].Sessions > out[j].Sessions
+		}
+		if out[i].Messages != out[j].Messages {
+			return out[i].Messages > out[j].Messages
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// Markdown renders the report for people, e.g. to mail or commit.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# ccpersona report\n\n")
+	fmt.Fprintf(&b, "%s to %s\n\n", r.Since.Local().Format("2006-01-02 15:04"), r.Until.Local().Format("2006-01-02 15:04"))
+
+	fmt.Fprintf(&b, "## Summary\n\n")
+	fmt.Fprintf(&b, "- Sessions: %d in %s\n", r.Sessions, plural(countSessions(r.Projects), "project"))
+	fmt.Fprintf(&b, "- Spoken: %s in %s\n", minutes(r.Seconds), plural(r.Messages, "message"))
+	fmt.Fprintf(&b, "- Estimated provider cost: %s\n", dollars(r.Cost))
+	if r.Sessions == 0 && r.Messages == 0 {
+		fmt.Fprintf(&b, "\nNothing was recorded in this period.\n")
+		return b.String()
+	}
+
+	groupTable(&b, "Projects", "Project", r.Projects)
+	groupTable(&b, "Personas", "Persona", r.Personas)
+
+	if len(r.Providers) > 0 {
+		fmt.Fprintf(&b, "\n## Providers\n\n")
+		fmt.Fprintf(&b, "| Provider | Messages | Characters | Minutes | Est. cost |\n")
+		fmt.Fprintf(&b, "| --- | ---: | ---: | ---: | ---: |\n")
+		for _, p := range r.Providers {
+			cost := "unknown"
+			if p.Priced {
+				cost = dollars(p.Cost)
+			}
+			fmt.Fprintf(&b, "| %s | %d | %d | %.1f | %s |\n", cell(p.Name), p.Messages, p.Chars, p.Seconds/60, cost)
+		}
+		fmt.Fprintf(&b, "\nCosts are estimates from list prices per character; check your provider's billing for actual charges.\n")
+	}
+	return b.String()
+}
+
+// countSessions counts the groups with sessions.
+func countSessions(groups []Group) int {
+	n := 0
+	for _, g := range groups {
+		if g.Sessions > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func groupTable(b *strings.Builder, title, column string, groups []Group) {
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	fmt.Fprintf(b, "| %s | Sessions | Messages | Minutes |\n", column)
+	fmt.Fprintf(b, "| --- | ---: | ---: | ---: |\n")
+	for _, g := range groups {
+		fmt.Fprintf(b, "| %s | %d | %d | %.1f |\n", cell(g.Name), g.Sessions, g.Messages, g.Seconds/60)
+	}
+}
+
+// cell escapes a value for a Markdown table.
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func minutes(seconds float64) string {
+	return fmt.Sprintf("%.1f minutes", seconds/60)
+}
+
+func dollars(amount float64) string {
+	return fmt.Sprintf("$%.2f", amount)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,169 @@
+// Package usage keeps a local ledger of agent sessions and spoken messages,
+// which `ccpersona config report` summarizes per project, persona, and
+// provider. Like the experiment log, it never leaves this machine.
+package usage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+// Entry kinds.
+const (
+	KindSession  = "session"
+	KindSpeech   = "speech"
+	KindFeedback = "feedback"
+)
+
+// EnvLedger turns the ledger off when set to "0".
+const EnvLedger = "CCPERSONA_USAGE_LEDGER"
+
+// Entry is one line of the ledger.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Project  string    `json:"project,omitempty"`
+	Persona  string    `json:"persona,omitempty"`
+	Platform string    `json:"platform,omitempty"`
+	Session  string    `json:"session,omitempty"`
+	// Speech entries only.
+	Provider string  `json:"provider,omitempty"`
+	Chars    int     `json:"chars,omitempty"`
+	Seconds  float64 `json:"seconds,omitempty"`
+	// Local marks speech from a local engine or a custom server, which
+	// costs nothing per character.
+	Local bool `json:"local,omitempty"`
+	// Feedback entries only: +1 or -1, and an optional note.
+	Rating  int    `json:"rating,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// Retention bounds the ledger: once it grows past maxLedgerBytes, entries
+// older than retention are dropped on the next write.
+const (
+	maxLedgerBytes = 4 << 20
+	retention      = 180 * 24 * time.Hour
+)
+
+// Path returns the ledger location.
+func Path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".agents", "ccpersona", "usage.jsonl"), nil
+}
+
+// ErrDisabled is returned by Add when CCPERSONA_USAGE_LEDGER=0.
+var ErrDisabled = errors.New("the usage ledger is off (" + EnvLedger + "=0)")
+
+// Record appends entry, stamping the time when it is zero. Failures are
+// logged and never reach the caller, since recording must not disturb a
+// hook.
+func Record(entry Entry) {
+	if err := Add(entry); err != nil && !errors.Is(err, ErrDisabled) {
+		log.Debug().Err(err).Msg("Failed to record usage")
+	}
+}
+
+// Add appends entry like Record but returns failures, for entries a user
+// asked to store.
+func Add(entry Entry) error {
+	if os.Getenv(EnvLedger) == "0" {
+		return ErrDisabled
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	return appendEntry(path, entry)
+}
+
+func appendEntry(path string, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WithLock(path, func() error {
+		if info, err := os.Stat(path); err == nil && info.Size() > maxLedgerBytes {
+			if err := compact(path, entry.Time.Add(-retention)); err != nil {
+				return err
+			}
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
+
+// compact rewrites the ledger without entries before cutoff.
+func compact(path string, cutoff time.Time) error {
+	entries, err := read(path, cutoff)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	return fsutil.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// Load returns the entries recorded at or after since. A missing ledger
+// yields no entries.
+func Load(since time.Time) ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return read(path, since)
+}
+
+func read(path string, since time.Time) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // tolerate a torn line
+		}
+		if !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
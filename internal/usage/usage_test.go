@@ -0,0 +1,141 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndLoad(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvLedger, "")
+
+	now := time.Now().UTC()
+	Record(Entry{Time: now.Add(-10 * 24 * time.Hour), Kind: KindSession, Session: "old"})
+	Record(Entry{Kind: KindSession, Project: "/w", Persona: "fable", Session: "s1"})
+	Record(Entry{Kind: KindSpeech, Provider: "openai", Chars: 100, Seconds: 6})
+
+	entries, err := Load(now.Add(-7 * 24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Session != "s1" || entries[1].Chars != 100 {
+		t.Fatalf("Load() = %+v", entries)
+	}
+	if entries[0].Time.IsZero() {
+		t.Error("Record should stamp the time")
+	}
+
+	info, err := os.Stat(filepath.Join(home, ".agents", "ccpersona", "usage.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("ledger mode = %v", info.Mode().Perm())
+	}
+}
+
+func TestRecordDisabled(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvLedger, "0")
+
+	Record(Entry{Kind: KindSession, Session: "s"})
+	if _, err := os.Stat(filepath.Join(home, ".agents", "ccpersona", "usage.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("ledger should not be written, stat error = %v", err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	now := time.Now().UTC()
+	for _, entry := range []Entry{
+		{Time: now.Add(-200 * 24 * time.Hour), Kind: KindSession, Session: "old"},
+		{Time: now, Kind: KindSession, Session: "new"},
+	} {
+		if err := appendEntry(path, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := compact(path, now.Add(-retention)); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := read(path, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Session != "new" {
+		t.Errorf("after compact = %+v", entries)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-7 * 24 * time.Hour)
+	at := now.Add(-time.Hour)
+	entries := []Entry{
+		{Time: at, Kind: KindSession, Project: "~/api", Persona: "fable", Session: "s1"},
+		{Time: at, Kind: KindSession, Project: "~/api", Persona: "fable", Session: "s1"}, // resumed
+		{Time: at, Kind: KindSession, Project: "~/web", Persona: "zundamon", Session: "s2"},
+		{Time: at, Kind: KindSession, Project: "~/web", Persona: "zundamon", Session: "s3"},
+		{Time: at, Kind: KindSpeech, Project: "~/api", Persona: "fable", Provider: "openai", Chars: 200000, Seconds: 90},
+		{Time: at, Kind: KindSpeech, Project: "~/web", Persona: "zundamon", Chars: 50, Seconds: 30, Local: true},
+		{Time: at, Kind: KindSpeech, Project: "~/web", Persona: "zundamon", Provider: "openai", Chars: 500, Seconds: 3, Local: true},
+		{Time: at, Kind: KindSpeech, Project: "~/web", Persona: "zundamon", Provider: "elevenlabs", Chars: 1000000, Seconds: 60},
+		{Time: at, Kind: KindSpeech, Provider: "acme", Chars: 10},
+		{Time: since.Add(-time.Hour), Kind: KindSession, Session: "before"},
+	}
+	r := Summarize(entries, since, now, map[string]float64{"elevenlabs": 165})
+
+	if r.Sessions != 3 || r.Messages != 5 || r.Seconds != 183 {
+		t.Errorf("totals = %d sessions, %d messages, %g seconds", r.Sessions, r.Messages, r.Seconds)
+	}
+	if want := 0.2*15 + 165; r.Cost != want {
+		t.Errorf("Cost = %g, want %g", r.Cost, want)
+	}
+	if r.Projects[0].Name != "~/web" || r.Projects[0].Sessions != 2 || r.Projects[0].Messages != 3 {
+		t.Errorf("Projects = %+v", r.Projects)
+	}
+	if r.Personas[0].Name != "zundamon" {
+		t.Errorf("Personas = %+v", r.Personas)
+	}
+
+	providers := map[string]ProviderUsage{}
+	for _, p := range r.Providers {
+		providers[p.Name] = p
+	}
+	if p := providers["openai (custom server)"]; !p.Priced || p.Cost != 0 {
+		t.Errorf("custom server = %+v", p)
+	}
+	if p := providers["voicevox/aivisspeech"]; !p.Priced || p.Messages != 1 {
+		t.Errorf("local engine = %+v", p)
+	}
+	if p := providers["acme"]; p.Priced {
+		t.Errorf("unknown provider should be unpriced: %+v", p)
+	}
+
+	md := r.Markdown()
+	for _, want := range []string{
+		"- Sessions: 3 in 2 projects",
+		"- Spoken: 3.0 minutes in 5 messages",
+		"- Estimated provider cost: $168.00",
+		"| ~/web | 2 | 3 | 1.6 |",
+		"| openai | 1 | 200000 | 1.5 | $3.00 |",
+		"| acme | 1 | 10 | 0.0 | unknown |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	now := time.Now()
+	md := Summarize(nil, now.Add(-time.Hour), now, nil).Markdown()
+	if !strings.Contains(md, "Nothing was recorded") || strings.Contains(md, "## Projects") {
+		t.Errorf("Markdown() = %s", md)
+	}
+}
@@ -0,0 +1,230 @@
+package voice
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Spell modes for AccessibilityOptions.SpellIdentifiers.
+const (
+	SpellKeyword = "keyword"
+	SpellAlways  = "always"
+	SpellNever   = "never"
+)
+
+// DefaultAccessibilitySpeed is the speech speed used by the accessibility
+// profile when the persona does not set one.
+const DefaultAccessibilitySpeed = 0.8
+
+// DefaultSpellKeywords trigger identifier spelling in SpellKeyword mode.
+var DefaultSpellKeywords = []string{"spell", "spelled", "spelling", "スペル", "綴り"}
+
+// AccessibilityOptions is the accessibility profile for low-vision users who
+// rely on spoken output.
+type AccessibilityOptions struct {
+	Enabled bool `json:"enabled"`
+	// Speed is the default speech speed (0.8 when unset). An explicit
+	// voice.speed still wins.
+	Speed float64 `json:"speed,omitempty"`
+	// SpellIdentifiers controls spelling of `code` spans character by
+	// character: keyword (default), always, or never.
+	SpellIdentifiers string `json:"spell_identifiers,omitempty"`
+	// SpellKeywords override DefaultSpellKeywords.
+	SpellKeywords []string `json:"spell_keywords,omitempty"`
+	// StructuralCues announces paragraphs, headings, lists, and code blocks
+	// (default true).
+	StructuralCues *bool `json:"structural_cues,omitempty"`
+}
+
+// IsEnabled reports whether the profile applies.
+func (a *AccessibilityOptions) IsEnabled() bool {
+	return a != nil && a.Enabled
+}
+
+// EffectiveSpeed returns the profile's default speech speed.
+func (a *AccessibilityOptions) EffectiveSpeed() float64 {
+	if a == nil || a.Speed <= 0 {
+		return DefaultAccessibilitySpeed
+	}
+	return a.Speed
+}
+
+func (a *AccessibilityOptions) cuesEnabled() bool {
+	return a.StructuralCues == nil || *a.StructuralCues
+}
+
+func (a *AccessibilityOptions) shouldSpell(text string) bool {
+	switch a.SpellIdentifiers {
+	case SpellAlways:
+		return true
+	case SpellNever:
+		return false
+	}
+	keywords := a.SpellKeywords
+	if len(keywords) == 0 {
+		keywords = DefaultSpellKeywords
+	}
+	lower := strings.ToLower(text)
+	for _, kw := range keywords {
+		if kw != "" && strings.Contains(lower, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	codeSpan     = regexp.MustCompile("`([^`\n]{1,64})`")
+	headingLine  = regexp.MustCompile(`^#{1,6}\s+(.+)$`)
+	listItemLine = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.+)$`)
+)
+
+// ApplyAccessibility rewrites markdown text for listening: it adds spoken
+// structural cues and spells identifiers when requested. Text is returned
+// unchanged when the profile is disabled.
+func ApplyAccessibility(text string, opts *AccessibilityOptions) string {
+	if !opts.IsEnabled() {
+		return text
+	}
+	if opts.shouldSpell(text) {
+		text = codeSpan.ReplaceAllStringFunc(text, func(span string) string {
+			ident := span[1 : len(span)-1]
+			return fmt.Sprintf("%s, spelled %s,", ident, SpellOut(ident))
+		})
+	}
+	if !opts.cuesEnabled() {
+		return text
+	}
+	return addStructuralCues(text)
+}
+
+func addStructuralCues(text string) string {
+	lines := strings.Split(text, "\n")
+	var out []string
+	var list []string
+	inCode := false
+	codeLines := 0
+	pendingParagraph := false
+
+	flushList := func() {
+		if len(list) == 0 {
+			return
+		}
+		out = append(out, fmt.Sprintf("List of %s.", countNoun(len(list), "item")))
+		for i, item := range list {
+			out = append(out, fmt.Sprintf("Item %s: %s", numberWord(i+1), item))
+		}
+		out = append(out, "End of list.")
+		list = nil
+	}
+	emit := func(line string) {
+		if pendingParagraph && len(out) > 0 {
+			out = append(out, "New paragraph.")
+		}
+		pendingParagraph = false
+		out = append(out, line)
+	}
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			if inCode {
+				emit(fmt.Sprintf("Code block, %s, skipped.", countNoun(codeLines, "line")))
+				inCode = false
+			} else {
+				flushList()
+				inCode = true
+				codeLines = 0
+			}
+			continue
+		}
+		if inCode {
+			codeLines++
+			continue
+		}
+
+		if m := listItemLine.FindStringSubmatch(line); m != nil {
+			if len(list) == 0 && pendingParagraph && len(out) > 0 {
+				out = append(out, "New paragraph.")
+				pendingParagraph = false
+			}
+			list = append(list, strings.TrimSpace(m[1]))
+			continue
+		}
+		flushList()
+
+		switch {
+		case trimmed == "":
+			pendingParagraph = true
+		case headingLine.MatchString(trimmed):
+			emit("Heading: " + headingLine.FindStringSubmatch(trimmed)[1] + ".")
+		default:
+			emit(line)
+		}
+	}
+	flushList()
+	if inCode {
+		// Unterminated fence (e.g. truncated output).
+		emit(fmt.Sprintf("Code block, %s, skipped.", countNoun(codeLines, "line")))
+	}
+	return strings.Join(out, "\n")
+}
+
+var charNames = map[rune]string{
+	'_':  "underscore",
+	'-':  "dash",
+	'.':  "dot",
+	'/':  "slash",
+	'\\': "backslash",
+	':':  "colon",
+	'(':  "open paren",
+	')':  "close paren",
+	'[':  "open bracket",
+	']':  "close bracket",
+	'{':  "open brace",
+	'}':  "close brace",
+	'<':  "less than",
+	'>':  "greater than",
+	'=':  "equals",
+	'#':  "hash",
+	'@':  "at",
+	'$':  "dollar",
+	'*':  "star",
+	' ':  "space",
+}
+
+// SpellOut spells an identifier character by character, e.g. "getX_1"
+// becomes "g, e, t, capital X, underscore, 1".
+func SpellOut(ident string) string {
+	parts := make([]string, 0, len(ident))
+	for _, r := range ident {
+		switch {
+		case unicode.IsUpper(r):
+			parts = append(parts, "capital "+string(r))
+		case charNames[r] != "":
+			parts = append(parts, charNames[r])
+		default:
+			parts = append(parts, string(r))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+var smallNumbers = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"}
+
+func numberWord(n int) string {
+	if n >= 0 && n < len(smallNumbers) {
+		return smallNumbers[n]
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// countNoun renders "one line", "three lines", and so on.
+func countNoun(n int, noun string) string {
+	if n != 1 {
+		noun += "s"
+	}
+	return numberWord(n) + " " + noun
+}
@@ -0,0 +1,122 @@
+package voice
+
+import "testing"
+
+func TestApplyAccessibilityDisabled(t *testing.T) {
+	input := "# Title\n\n- a\n- b"
+	if got := ApplyAccessibility(input, nil); got != input {
+		t.Errorf("nil options changed text: %q", got)
+	}
+	if got := ApplyAccessibility(input, &AccessibilityOptions{}); got != input {
+		t.Errorf("disabled options changed text: %q", got)
+	}
+}
+
+func TestApplyAccessibilityStructuralCues(t *testing.T) {
+	input := "# Summary\nFixed the bug.\n\nChanges:\n1. parser\n2. lexer\n3. tests\n\n```go\nfunc f() {}\nreturn\n```\nDone."
+	want := "Heading: Summary.\n" +
+		"Fixed the bug.\n" +
+		"New paragraph.\n" +
+		"Changes:\n" +
+		"List of three items.\n" +
+		"Item one: parser\n" +
+		"Item two: lexer\n" +
+		"Item three: tests\n" +
+		"End of list.\n" +
+		"New paragraph.\n" +
+		"Code block, two lines, skipped.\n" +
+		"Done."
+
+	got := ApplyAccessibility(input, &AccessibilityOptions{Enabled: true})
+	if got != want {
+		t.Errorf("ApplyAccessibility() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestApplyAccessibilityCuesDisabled(t *testing.T) {
+	off := false
+	input := "# Title\n- a"
+	got := ApplyAccessibility(input, &AccessibilityOptions{Enabled: true, StructuralCues: &off})
+	if got != input {
+		t.Errorf("cues disabled: got %q", got)
+	}
+}
+
+func TestApplyAccessibilitySpelling(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  AccessibilityOptions
+		want  string
+	}{
+		{
+			name:  "keyword triggers spelling",
+			input: "The function is spelled `getX`.",
+			opts:  AccessibilityOptions{Enabled: true},
+			want:  "The function is spelled getX, spelled g, e, t, capital X,.",
+		},
+		{
+			name:  "no keyword leaves code spans",
+			input: "Call `getX` now.",
+			opts:  AccessibilityOptions{Enabled: true},
+			want:  "Call `getX` now.",
+		},
+		{
+			name:  "japanese keyword",
+			input: "`ab` の綴りです",
+			opts:  AccessibilityOptions{Enabled: true},
+			want:  "ab, spelled a, b, の綴りです",
+		},
+		{
+			name:  "always",
+			input: "Use `a.b`",
+			opts:  AccessibilityOptions{Enabled: true, SpellIdentifiers: SpellAlways},
+			want:  "Use a.b, spelled a, dot, b,",
+		},
+		{
+			name:  "never",
+			input: "Spell `ab`",
+			opts:  AccessibilityOptions{Enabled: true, SpellIdentifiers: SpellNever},
+			want:  "Spell `ab`",
+		},
+		{
+			name:  "custom keywords",
+			input: "Letters of `ab`",
+			opts:  AccessibilityOptions{Enabled: true, SpellKeywords: []string{"letters"}},
+			want:  "Letters of ab, spelled a, b,",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyAccessibility(tt.input, &tt.opts); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpellOut(t *testing.T) {
+	tests := map[string]string{
+		"getX_1": "g, e, t, capital X, underscore, 1",
+		"a-b/c":  "a, dash, b, slash, c",
+		"f(x)":   "f, open paren, x, close paren",
+		"":       "",
+		"日本":     "日, 本",
+	}
+	for input, want := range tests {
+		if got := SpellOut(input); got != want {
+			t.Errorf("SpellOut(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestAccessibilityEffectiveSpeed(t *testing.T) {
+	var nilOpts *AccessibilityOptions
+	if got := nilOpts.EffectiveSpeed(); got != DefaultAccessibilitySpeed {
+		t.Errorf("nil speed = %v", got)
+	}
+	if got := (&AccessibilityOptions{Speed: 0.7}).EffectiveSpeed(); got != 0.7 {
+		t.Errorf("custom speed = %v", got)
+	}
+}
@@ -0,0 +1,222 @@
+package voice
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/daikw/ccpersona/internal/editor"
+	"github.com/rs/zerolog/log"
+)
+
+// Default thresholds of the adaptive reading mode, in characters.
+const (
+	DefaultAdaptiveShortChars   = 200
+	DefaultAdaptiveLongChars    = 800
+	DefaultAdaptiveSummaryChars = 200
+)
+
+// summaryCommandTimeout bounds summary_command so a slow model cannot stall
+// the hook; the heuristic summary is used instead.
+const summaryCommandTimeout = 20 * time.Second
+
+// Strategies of the adaptive reading mode.
+const (
+	StrategyFull      = "full"
+	StrategyParagraph = "paragraph"
+	StrategySummary   = "summary"
+)
+
+// AdaptiveOptions tunes the adaptive reading mode, which picks a strategy by
+// message length: short messages are read in full, medium ones up to the end
+// of their first paragraph, and long ones as a summary.
+type AdaptiveOptions struct {
+	// ShortChars is the longest message read in full (default 200).
+	ShortChars int `json:"short_chars,omitempty"`
+	// LongChars is the longest message read up to its first paragraph;
+	// longer ones are summarized (default 800).
+	LongChars int `json:"long_chars,omitempty"`
+	// SummaryChars caps the heuristic summary (default 200).
+	SummaryChars int `json:"summary_chars,omitempty"`
+	// SummaryCommand summarizes long messages instead of the heuristic, for
+	// example with an LLM CLI. It reads the message on stdin and prints the
+	// summary, and runs without a shell. The heuristic is used when it fails
+	// or prints nothing.
+	SummaryCommand string `json:"summary_command,omitempty"`
+}
+
+func (a *AdaptiveOptions) shortChars() int {
+	if a == nil || a.ShortChars <= 0 {
+		return DefaultAdaptiveShortChars
+	}
+	return a.ShortChars
+}
+
+func (a *AdaptiveOptions) longChars() int {
+	if a == nil || a.LongChars <= 0 {
+		return max(DefaultAdaptiveLongChars, a.shortChars())
+	}
+	return max(a.LongChars, a.shortChars())
+}
+
+func (a *AdaptiveOptions) summaryChars() int {
+	if a == nil || a.SummaryChars <= 0 {
+		return DefaultAdaptiveSummaryChars
+	}
+	return a.SummaryChars
+}
+
+// Strategy returns how the adaptive mode reads text.
+func (a *AdaptiveOptions) Strategy(text string) string {
+	switch n := utf8.RuneCountInString(strings.TrimSpace(text)); {
+	case n <= a.shortChars():
+		return StrategyFull
+	case n <= a.longChars():
+		return StrategyParagraph
+	default:
+		return StrategySummary
+	}
+}
+
+// ReadAdaptive returns the part of text the adaptive mode reads, with line
+// breaks kept, and the strategy used.
+func (a *AdaptiveOptions) ReadAdaptive(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	strategy := a.Strategy(text)
+	switch strategy {
+	case StrategyParagraph:
+		if paras := proseParagraphs(text); len(paras) > 0 {
+			return paras[0], strategy
+		}
+	case StrategySummary:
+		if a != nil && a.SummaryCommand != "" {
+			summary, err := runSummaryCommand(a.SummaryCommand, text)
+			if err == nil && summary != "" {
+				return summary, strategy
+			}
+			log.Warn().Err(err).Str("command", a.SummaryCommand).Msg("Summary command failed, using heuristic summary")
+		}
+		return Summarize(text, a.summaryChars()), strategy
+	}
+	return text, strategy
+}
+
+func runSummaryCommand(command, text string) (string, error) {
+	args, err := editor.Split(command)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), summaryCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// summaryMarker matches a line that introduces an agent's own summary: a
+// heading or label such as "## Summary", "**TL;DR:**", or "まとめ：".
+var summaryMarker = regexp.MustCompile(`(?i)^(?:#+\s*|\*\*)?(?:summary|tl;?dr|要約|まとめ)(?:\*\*)?\s*(?:[:：]|$)(?:\*\*)?\s*`)
+
+// Summarize shortens text to at most limit characters without a model. An
+// explicit summary section ("Summary", "TL;DR", "まとめ") is preferred;
+// otherwise the first sentence of the first and of the last paragraph are
+// combined. Code blocks and headings are skipped, and whole sentences are
+// kept where possible.
+func Summarize(text string, limit int) string {
+	summary := markedSummary(text)
+	if summary == "" {
+		paras := proseParagraphs(text)
+		if len(paras) == 0 {
+			return clipSentences(strings.TrimSpace(text), limit)
+		}
+		summary = firstSentence(paras[0])
+		if len(paras) > 1 {
+			if last := firstSentence(paras[len(paras)-1]); last != summary {
+				summary += " " + last
+			}
+		}
+	}
+	return clipSentences(summary, limit)
+}
+
+// markedSummary returns the text of an explicit summary section: the rest of
+// the marker line, or the paragraph after it.
+func markedSummary(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		loc := summaryMarker.FindStringIndex(trimmed)
+		if loc == nil || loc[1] == 0 {
+			continue
+		}
+		if rest := strings.TrimSpace(trimmed[loc[1]:]); rest != "" {
+			return rest
+		}
+		if paras := proseParagraphs(strings.Join(lines[i+1:], "\n")); len(paras) > 0 {
+			return paras[0]
+		}
+	}
+	return ""
+}
+
+// proseParagraphs splits text at blank lines, dropping fenced code blocks and
+// heading lines.
+func proseParagraphs(text string) []string {
+	var paras, current []string
+	inFence := false
+	flush := func() {
+		if len(current) > 0 {
+			paras = append(paras, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inFence = !inFence
+			flush()
+		case inFence:
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "#"):
+			flush()
+		default:
+			current = append(current, trimmed)
+		}
+	}
+	flush()
+	return paras
+}
+
+func firstSentence(text string) string {
+	sentences := splitSentences(strings.Join(strings.Fields(text), " "))
+	if len(sentences) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(sentences[0])
+}
+
+// clipSentences keeps the leading sentences of text that fit in limit
+// characters, cutting the first sentence only when it alone is too long.
+func clipSentences(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	var out string
+	for _, s := range splitSentences(text) {
+		next := strings.TrimSpace(out + s)
+		if utf8.RuneCountInString(next) > limit {
+			break
+		}
+		out = next
+	}
+	if out == "" {
+		out = string([]rune(text)[:limit])
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package voice
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAdaptiveStrategy(t *testing.T) {
+	var defaults *AdaptiveOptions
+	tests := []struct {
+		opts *AdaptiveOptions
+		n    int
+		want string
+	}{
+		{defaults, 200, StrategyFull},
+		{defaults, 201, StrategyParagraph},
+		{defaults, 800, StrategyParagraph},
+		{defaults, 801, StrategySummary},
+		{&AdaptiveOptions{ShortChars: 10, LongChars: 20}, 15, StrategyParagraph},
+		// long_chars below short_chars leaves no paragraph band
+		{&AdaptiveOptions{ShortChars: 10, LongChars: 5}, 11, StrategySummary},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.Strategy(strings.Repeat("あ", tt.n)); got != tt.want {
+			t.Errorf("Strategy(%+v, %d chars) = %s, want %s", tt.opts, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestReadAdaptive(t *testing.T) {
+	opts := &AdaptiveOptions{ShortChars: 30, LongChars: 120, SummaryChars: 80}
+
+	short := "Done. Tests pass."
+	if got, strategy := opts.ReadAdaptive(short); got != short || strategy != StrategyFull {
+		t.Errorf("short = %q (%s)", got, strategy)
+	}
+
+	medium := "## Result\n\nUpdated the config loader.\nIt now reads YAML.\n\n```go\nfunc main() {}\n```\n\nMore notes."
+	if got, strategy := opts.ReadAdaptive(medium); got != "Updated the config loader.\nIt now reads YAML." || strategy != StrategyParagraph {
+		t.Errorf("medium = %q (%s)", got, strategy)
+	}
+
+	long := "I looked at the failing build first. It was the lockfile.\n\n" +
+		strings.Repeat("Then I checked every module in turn. ", 5) +
+		"\n\nEverything is green now. Let me know if you want more."
+	got, strategy := opts.ReadAdaptive(long)
+	if strategy != StrategySummary || got != "I looked at the failing build first. Everything is green now." {
+		t.Errorf("long = %q (%s)", got, strategy)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{"summary heading", "Lots of work.\n\n## Summary\n\nAdded retries to the client.", 100, "Added retries to the client."},
+		{"inline tl;dr", "**TL;DR:** the cache was stale.\n\nLong story follows.", 100, "the cache was stale."},
+		{"japanese label", "調査しました。\n\nまとめ：キャッシュが古かったのだ。", 100, "キャッシュが古かったのだ。"},
+		{"summary of is not a marker", "Summary of changes below.\n\nAll done.", 100, "Summary of changes below. All done."},
+		{"clips whole sentences", "One two three. Four five six.\n\nSeven.", 20, "One two three."},
+		{"cuts an overlong sentence", "abcdefghijklmnopqrstuvwxyz", 5, "abcde"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.text, tt.limit); got != tt.want {
+				t.Errorf("Summarize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadAdaptiveSummaryCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	script := filepath.Join(t.TempDir(), "summarize")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat >/dev/null\necho 'Model summary.'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	long := strings.Repeat("A long sentence here. ", 10)
+	opts := &AdaptiveOptions{ShortChars: 10, LongChars: 20, SummaryCommand: script}
+	if got, _ := opts.ReadAdaptive(long); got != "Model summary." {
+		t.Errorf("summary command = %q", got)
+	}
+	opts.SummaryCommand = filepath.Join(t.TempDir(), "missing")
+	if got, _ := opts.ReadAdaptive(long); got != "A long sentence here." {
+		t.Errorf("fallback summary = %q", got)
+	}
+}
@@ -0,0 +1,109 @@
+package voice
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/voice/provider"
+)
+
+// VoiceRef names a voice together with its provider, written
+// "provider:voice": a voice ID of a cloud provider
+// ("elevenlabs:pNInz6obpgDQGcFmaJgB") or a speaker ID of a local engine
+// ("voicevox:3").
+type VoiceRef struct {
+	Provider string
+	Voice    string
+	// Speaker is the speaker ID for VOICEVOX and AivisSpeech, set when
+	// HasSpeaker is; 0 is a valid VOICEVOX style.
+	Speaker    int
+	HasSpeaker bool
+}
+
+// ParseVoiceRef parses a "provider:voice" reference. It reports false for
+// text without a known provider prefix, such as a plain voice ID.
+func ParseVoiceRef(s string) (VoiceRef, bool) {
+	name, id, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok || id == "" {
+		return VoiceRef{}, false
+	}
+	ref := VoiceRef{Provider: name}
+	switch {
+	case name == EngineVoicevox || name == EngineAivisSpeech:
+		speaker, err := strconv.Atoi(id)
+		if err != nil || speaker < 0 {
+			return VoiceRef{}, false
+		}
+		ref.Speaker, ref.HasSpeaker = speaker, true
+	case slices.Contains(provider.AllProviders, name):
+		ref.Voice = id
+	default:
+		return VoiceRef{}, false
+	}
+	return ref, true
+}
+
+// String formats the reference as "provider:voice".
+func (r VoiceRef) String() string {
+	if r.HasSpeaker {
+		return fmt.Sprintf("%s:%d", r.Provider, r.Speaker)
+	}
+	return r.Provider + ":" + r.Voice
+}
+
+// Matches reports whether the reference names voice v of provider
+// providerName; an empty providerName matches any provider.
+func (r VoiceRef) Matches(providerName string, v provider.Voice) bool {
+	if providerName != "" && providerName != r.Provider {
+		return false
+	}
+	if r.HasSpeaker {
+		return v.ID == strconv.Itoa(r.Speaker)
+	}
+	return v.ID == r.Voice
+}
+
+// VoiceAliases name voice references, so a voice can be chosen by a
+// memorable name: {"narrator": "elevenlabs:pNInz6obpgDQGcFmaJgB",
+// "zunda": "voicevox:3"}.
+type VoiceAliases map[string]string
+
+// Validate checks that every alias is a plain name and names a reference.
+func (a VoiceAliases) Validate() error {
+	for name, target := range a {
+		if name == "" || strings.ContainsAny(name, ": ") {
+			return fmt.Errorf("voices.aliases: %q is not a valid alias name", name)
+		}
+		if _, ok := ParseVoiceRef(target); !ok {
+			return fmt.Errorf("voices.aliases.%s: %q is not a provider:voice reference", name, target)
+		}
+	}
+	return nil
+}
+
+// Lookup resolves an alias, or a "provider:voice" reference written out. It
+// reports false for anything else, which callers treat as a plain voice ID.
+func (a VoiceAliases) Lookup(name string) (VoiceRef, bool) {
+	if target, ok := a[name]; ok {
+		return ParseVoiceRef(target)
+	}
+	return ParseVoiceRef(name)
+}
+
+// WithVoice returns the options speaking with ref's voice. Options resolved
+// for another provider should be resolved again for ref.Provider first, so
+// that provider settings such as API keys match.
+func (o VoiceOptions) WithVoice(ref VoiceRef) VoiceOptions {
+	o.Provider = ref.Provider
+	switch ref.Provider {
+	case EngineVoicevox:
+		o.VoicevoxSpeaker, o.SpeakerSet = ref.Speaker, true
+	case EngineAivisSpeech:
+		o.AivisSpeechSpeaker, o.SpeakerSet = ref.Speaker, true
+	default:
+		o.Voice = ref.Voice
+	}
+	return o
+}
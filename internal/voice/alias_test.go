@@ -0,0 +1,82 @@
+package voice
+
+import (
+	"testing"
+
+	"github.com/daikw/ccpersona/internal/voice/provider"
+)
+
+func TestParseVoiceRef(t *testing.T) {
+	tests := []struct {
+		in   string
+		want VoiceRef
+		ok   bool
+	}{
+		{"voicevox:3", VoiceRef{Provider: "voicevox", Speaker: 3, HasSpeaker: true}, true},
+		{"voicevox:0", VoiceRef{Provider: "voicevox", HasSpeaker: true}, true},
+		{"aivisspeech:888753760", VoiceRef{Provider: "aivisspeech", Speaker: 888753760, HasSpeaker: true}, true},
+		{"elevenlabs:pNInz6obpgDQGcFmaJgB", VoiceRef{Provider: "elevenlabs", Voice: "pNInz6obpgDQGcFmaJgB"}, true},
+		{"azure:ja-JP-NanamiNeural", VoiceRef{Provider: "azure", Voice: "ja-JP-NanamiNeural"}, true},
+		{"voicevox:zunda", VoiceRef{}, false},
+		{"voicevox:-1", VoiceRef{}, false},
+		{"elevenlabs:", VoiceRef{}, false},
+		{"pNInz6obpgDQGcFmaJgB", VoiceRef{}, false},
+		{"narrator:x", VoiceRef{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseVoiceRef(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseVoiceRef(%q) = %+v, %v; want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+		if ok && got.String() != tt.in {
+			t.Errorf("String() = %q, want %q", got.String(), tt.in)
+		}
+	}
+}
+
+func TestVoiceAliases(t *testing.T) {
+	aliases := VoiceAliases{"narrator": "elevenlabs:pNInz6obpgDQGcFmaJgB", "zunda": "voicevox:3"}
+	if err := aliases.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if ref, ok := aliases.Lookup("zunda"); !ok || ref.Speaker != 3 {
+		t.Errorf("Lookup(zunda) = %+v, %v", ref, ok)
+	}
+	if ref, ok := aliases.Lookup("openai:nova"); !ok || ref.Voice != "nova" {
+		t.Errorf("a written-out reference should resolve, got %+v, %v", ref, ok)
+	}
+	if _, ok := aliases.Lookup("nova"); ok {
+		t.Error("a plain voice ID is not an alias")
+	}
+
+	for _, bad := range []VoiceAliases{{"a:b": "voicevox:3"}, {"x": "voicevox"}, {"x": "nobody:1"}} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Validate(%v) should fail", bad)
+		}
+	}
+}
+
+func TestWithVoice(t *testing.T) {
+	base := VoiceOptions{Provider: "openai", Voice: "nova", VoicevoxSpeaker: 1}
+	o := base.WithVoice(VoiceRef{Provider: "voicevox", Speaker: 3, HasSpeaker: true})
+	if o.Provider != "voicevox" || o.VoicevoxSpeaker != 3 {
+		t.Errorf("WithVoice(voicevox:3) = %+v", o)
+	}
+	o = base.WithVoice(VoiceRef{Provider: "elevenlabs", Voice: "abc"})
+	if o.Provider != "elevenlabs" || o.Voice != "abc" {
+		t.Errorf("WithVoice(elevenlabs:abc) = %+v", o)
+	}
+	if base.Provider != "openai" {
+		t.Error("WithVoice should not modify the receiver")
+	}
+}
+
+func TestVoiceRefMatches(t *testing.T) {
+	ref := VoiceRef{Provider: "voicevox", Speaker: 3, HasSpeaker: true}
+	if !ref.Matches("voicevox", provider.Voice{ID: "3"}) || !ref.Matches("", provider.Voice{ID: "3"}) {
+		t.Error("should match speaker 3")
+	}
+	if ref.Matches("aivisspeech", provider.Voice{ID: "3"}) {
+		t.Error("should not match another provider")
+	}
+}
@@ -0,0 +1,332 @@
+package voice
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+// Audio cache defaults.
+const (
+	DefaultCacheMaxChars = 200
+	DefaultCacheMaxMB    = 100
+)
+
+// cacheStatsFile keeps the hit counts; the leading dot keeps it apart from
+// cached audio.
+const cacheStatsFile = ".stats.json"
+
+// CacheConfig tunes the audio cache, which keeps cloud provider audio for
+// short messages so repeated phrases are synthesized once. It is on unless
+// Enabled is false.
+type CacheConfig struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	// MaxChars is the longest message cached (default 200 characters).
+	MaxChars int `json:"max_chars,omitempty"`
+	// MaxMB bounds the cache; the least recently used audio goes first
+	// (default 100).
+	MaxMB int `json:"max_mb,omitempty"`
+}
+
+// IsEnabled reports whether audio is cached; safe on nil.
+func (c *CacheConfig) IsEnabled() bool {
+	return c == nil || c.Enabled == nil || *c.Enabled
+}
+
+// Validate checks that the limits are not negative.
+func (c *CacheConfig) Validate() error {
+	if c != nil && (c.MaxChars < 0 || c.MaxMB < 0) {
+		return fmt.Errorf("voice.cache limits must not be negative")
+	}
+	return nil
+}
+
+func (c *CacheConfig) maxChars() int {
+	if c == nil || c.MaxChars == 0 {
+		return DefaultCacheMaxChars
+	}
+	return c.MaxChars
+}
+
+func (c *CacheConfig) maxBytes() int64 {
+	mb := DefaultCacheMaxMB
+	if c != nil && c.MaxMB > 0 {
+		mb = c.MaxMB
+	}
+	return int64(mb) << 20
+}
+
+// AudioCache stores synthesized audio under a hash of the text and every
+// setting that changes the audio.
+type AudioCache struct {
+	dir      string
+	maxBytes int64
+}
+
+// CacheStats summarizes the audio cache.
+type CacheStats struct {
+	Dir     string `json:"-"`
+	Entries int    `json:"-"`
+	Bytes   int64  `json:"-"`
+	Limit   int64  `json:"-"`
+	Hits    int    `json:"hits"`
+	Misses  int    `json:"misses"`
+	// CharsSaved counts characters not sent to a provider thanks to hits.
+	CharsSaved int `json:"chars_saved"`
+}
+
+// AudioCacheDir returns the cache directory, ~/.cache/ccpersona/audio.
+func AudioCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve cache dir: %w", err)
+	}
+	return filepath.Join(base, "ccpersona", "audio"), nil
+}
+
+// NewAudioCache returns the cache with the limits of config.
+func NewAudioCache(config *CacheConfig) (*AudioCache, error) {
+	dir, err := AudioCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &AudioCache{dir: dir, maxBytes: config.maxBytes()}, nil
+}
+
+// audioCacheKey hashes the inputs that determine the audio. Output options,
+// credentials, and transport settings do not change the result and are left
+// out.
+func audioCacheKey(text string, opts VoiceOptions) string {
+	opts.OutputPath = ""
+	opts.ToStdout = false
+	opts.PlayAudio = false
+	opts.Recent = nil
+	opts.Subtitles = ""
+	opts.APIKey = ""
+	opts.TimeoutSeconds = 0
+	opts.ParallelChunks = 0
+	data, _ := json.Marshal(struct {
+		Text    string
+		Options VoiceOptions
+	}{text, opts})
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%x", sum[:16])
+}
+
+// cacheSlot is the place of one message in the cache: looked up before
+// synthesis and filled after a miss.
+type cacheSlot struct {
+	cache *AudioCache
+	key   string
+	chars int
+}
+
+// cacheSlot returns the slot of text, or nil when it is not cached: the
+// cache is off, the text is long, the provider is local, or speech marks are
+// needed, which cached audio lacks.
+func (vm *VoiceManager) cacheSlot(text string, options VoiceOptions) *cacheSlot {
+	var config *CacheConfig
+	if vm.config != nil {
+		config = vm.config.Cache
+	}
+	chars := utf8.RuneCountInString(text)
+	if !config.IsEnabled() || TestMode() || chars > config.maxChars() ||
+		IsLocalProvider(options.Provider) || options.BaseURL != "" || options.Provider == ProviderAuto ||
+		len(vm.markTypes(options)) > 0 {
+		return nil
+	}
+	cache, err := NewAudioCache(config)
+	if err != nil {
+		return nil
+	}
+	return &cacheSlot{cache: cache, key: audioCacheKey(text, options), chars: chars}
+}
+
+// use writes the cached audio to the destinations of options and returns
+// the file to play; false on a miss.
+func (s *cacheSlot) use(options VoiceOptions) (string, bool) {
+	matches, _ := filepath.Glob(filepath.Join(s.cache.dir, s.key+".*"))
+	if len(matches) == 0 {
+		s.cache.record(false, 0)
+		return "", false
+	}
+	src, err := os.Open(matches[0])
+	if err != nil {
+		return "", false
+	}
+	defer src.Close()
+	options.cache = nil
+	audioFile, err := writeAudio(src, options, strings.TrimPrefix(filepath.Ext(matches[0]), "."))
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to use cached audio")
+		return "", false
+	}
+	now := time.Now()
+	_ = os.Chtimes(matches[0], now, now)
+	s.cache.record(true, s.chars)
+	log.Debug().Str("key", s.key).Msg("Using cached audio")
+	return audioFile, true
+}
+
+// cacheEntry is audio being written to the cache.
+type cacheEntry struct {
+	slot *cacheSlot
+	file *os.File
+	ext  string
+}
+
+func (s *cacheSlot) create(ext string) (*cacheEntry, error) {
+	if err := os.MkdirAll(s.cache.dir, 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(s.cache.dir, ".audio-*")
+	if err != nil {
+		return nil, err
+	}
+	return &cacheEntry{slot: s, file: file, ext: ext}, nil
+}
+
+// commit moves complete audio into place, so readers never see part of a
+// file, and evicts old audio beyond the size limit.
+func (e *cacheEntry) commit(ok bool) {
+	_ = e.file.Close()
+	if !ok {
+		_ = os.Remove(e.file.Name())
+		return
+	}
+	path := filepath.Join(e.slot.cache.dir, e.slot.key+"."+e.ext)
+	if err := os.Rename(e.file.Name(), path); err != nil {
+		_ = os.Remove(e.file.Name())
+		return
+	}
+	e.slot.cache.evict()
+}
+
+// entries lists the cached audio, least recently used first.
+func (ac *AudioCache) entries() ([]os.FileInfo, error) {
+	dirEntries, err := os.ReadDir(ac.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var infos []os.FileInfo
+	for _, entry := range dirEntries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+	return infos, nil
+}
+
+func (ac *AudioCache) evict() {
+	infos, err := ac.entries()
+	if err != nil {
+		return
+	}
+	var total int64
+	for _, info := range infos {
+		total += info.Size()
+	}
+	for _, info := range infos {
+		if total <= ac.maxBytes {
+			return
+		}
+		if os.Remove(filepath.Join(ac.dir, info.Name())) == nil {
+			total -= info.Size()
+		}
+	}
+}
+
+// record counts a lookup. Counting is best effort.
+func (ac *AudioCache) record(hit bool, chars int) {
+	if err := os.MkdirAll(ac.dir, 0o755); err != nil {
+		return
+	}
+	path := filepath.Join(ac.dir, cacheStatsFile)
+	err := fsutil.WithLock(path, func() error {
+		stats := ac.readCounts()
+		if hit {
+			stats.Hits++
+			stats.CharsSaved += chars
+		} else {
+			stats.Misses++
+		}
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		return fsutil.WriteFile(path, append(data, '\n'), 0o644)
+	})
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to record audio cache stats")
+	}
+}
+
+func (ac *AudioCache) readCounts() CacheStats {
+	var stats CacheStats
+	if data, err := os.ReadFile(filepath.Join(ac.dir, cacheStatsFile)); err == nil {
+		_ = json.Unmarshal(data, &stats)
+	}
+	return stats
+}
+
+// Stats returns the size of the cache and how often it was used.
+func (ac *AudioCache) Stats() (CacheStats, error) {
+	stats := ac.readCounts()
+	stats.Dir = ac.dir
+	stats.Limit = ac.maxBytes
+	infos, err := ac.entries()
+	if err != nil {
+		return stats, err
+	}
+	stats.Entries = len(infos)
+	for _, info := range infos {
+		stats.Bytes += info.Size()
+	}
+	return stats, nil
+}
+
+// Clear removes the cached audio and the counts, and returns how many files
+// were removed.
+func (ac *AudioCache) Clear() (int, error) {
+	infos, err := ac.entries()
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	removed := 0
+	for _, info := range infos {
+		if err := os.Remove(filepath.Join(ac.dir, info.Name())); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed++
+	}
+	if err := os.Remove(filepath.Join(ac.dir, cacheStatsFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		errs = append(errs, err)
+	}
+	// Audio left half-written by a process that was killed.
+	partial, _ := filepath.Glob(filepath.Join(ac.dir, ".audio-*"))
+	for _, path := range partial {
+		_ = os.Remove(path)
+	}
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,167 @@
+package voice
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudioCacheKey(t *testing.T) {
+	opts := VoiceOptions{Provider: "openai", Voice: "nova", Speed: 1.0}
+	key := audioCacheKey("Task completed", opts)
+
+	output := opts
+	output.OutputPath, output.PlayAudio, output.APIKey = "out.mp3", true, "sk-secret"
+	assert.Equal(t, key, audioCacheKey("Task completed", output), "output options and credentials do not change the audio")
+
+	for name, changed := range map[string]VoiceOptions{
+		"provider": {Provider: "elevenlabs", Voice: "nova", Speed: 1.0},
+		"voice":    {Provider: "openai", Voice: "alloy", Speed: 1.0},
+		"speed":    {Provider: "openai", Voice: "nova", Speed: 1.2},
+	} {
+		assert.NotEqual(t, key, audioCacheKey("Task completed", changed), name)
+	}
+	assert.NotEqual(t, key, audioCacheKey("Task failed", opts))
+}
+
+func TestCacheSlot_Eligibility(t *testing.T) {
+	t.Setenv(EnvTestMode, "")
+	off := false
+	tests := []struct {
+		name    string
+		config  *CacheConfig
+		text    string
+		options VoiceOptions
+		want    bool
+	}{
+		{"cloud provider", nil, "Task completed", VoiceOptions{Provider: "openai"}, true},
+		{"local engine", nil, "Task completed", VoiceOptions{Provider: EngineVoicevox}, false},
+		{"local server", nil, "Task completed", VoiceOptions{Provider: "openai", BaseURL: "http://127.0.0.1:8000"}, false},
+		{"long text", &CacheConfig{MaxChars: 5}, "Task completed", VoiceOptions{Provider: "openai"}, false},
+		{"disabled", &CacheConfig{Enabled: &off}, "Task completed", VoiceOptions{Provider: "openai"}, false},
+		{"subtitles", nil, "Task completed", VoiceOptions{Provider: "openai", Subtitles: "srt"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Cache = tt.config
+			slot := NewVoiceManager(config).cacheSlot(tt.text, tt.options)
+			assert.Equal(t, tt.want, slot != nil)
+		})
+	}
+}
+
+// isolateCache points the user cache directory at a temporary one, so tests
+// synthesizing with a fake cloud provider neither read nor fill the real
+// audio cache.
+func isolateCache(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("LocalAppData", t.TempDir())
+}
+
+func newTestSlot(t *testing.T, key string, maxBytes int64) *cacheSlot {
+	t.Helper()
+	return &cacheSlot{cache: &AudioCache{dir: t.TempDir(), maxBytes: maxBytes}, key: key, chars: 14}
+}
+
+func TestCacheSlot_StoreAndUse(t *testing.T) {
+	slot := newTestSlot(t, "abc", 1<<20)
+
+	_, ok := slot.use(VoiceOptions{})
+	assert.False(t, ok, "empty cache misses")
+
+	// A miss stores what was synthesized alongside the played file.
+	played, err := writeAudio(strings.NewReader("audio"), VoiceOptions{cache: slot}, "mp3")
+	require.NoError(t, err)
+	defer os.Remove(played)
+	cached, err := os.ReadFile(filepath.Join(slot.cache.dir, "abc.mp3"))
+	require.NoError(t, err)
+	assert.Equal(t, "audio", string(cached))
+
+	output := filepath.Join(t.TempDir(), "out.mp3")
+	audioFile, ok := slot.use(VoiceOptions{OutputPath: output})
+	require.True(t, ok)
+	assert.Equal(t, output, audioFile)
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "audio", string(data))
+
+	stats, err := slot.cache.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, int64(5), stats.Bytes)
+	assert.Equal(t, 1, stats.Hits)
+	assert.Equal(t, 1, stats.Misses)
+	assert.Equal(t, 14, stats.CharsSaved)
+}
+
+func TestSynthesize_CachesCloudAudio(t *testing.T) {
+	isolateCache(t)
+	t.Setenv(EnvTestMode, "")
+	fake := &chunkProvider{}
+	manager := NewVoiceManager(DefaultConfig())
+	manager.providerFactory = chunkFactory{fake}
+	opts := VoiceOptions{Provider: "openai", Format: "mp3"}
+
+	for range 2 {
+		audioFile, err := manager.Synthesize(context.Background(), "Task completed", opts)
+		require.NoError(t, err)
+		data, err := os.ReadFile(audioFile)
+		require.NoError(t, err)
+		assert.Equal(t, "[Task completed]", string(data))
+		require.NoError(t, os.Remove(audioFile))
+	}
+	assert.Len(t, fake.inputs, 1, "the second message is served from the cache")
+
+	opts.Speed = 1.5
+	audioFile, err := manager.Synthesize(context.Background(), "Task completed", opts)
+	require.NoError(t, err)
+	defer os.Remove(audioFile)
+	assert.Len(t, fake.inputs, 2, "another speed is synthesized again")
+}
+
+func TestAudioCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := &AudioCache{dir: t.TempDir(), maxBytes: 10}
+	old := time.Now().Add(-time.Hour)
+	for i, name := range []string{"old.mp3", "new.mp3"} {
+		path := filepath.Join(cache.dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("12345"), 0o644))
+		at := old.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, os.Chtimes(path, at, at))
+	}
+
+	slot := &cacheSlot{cache: cache, key: "newest"}
+	played, err := writeAudio(strings.NewReader("12345"), VoiceOptions{cache: slot}, "mp3")
+	require.NoError(t, err)
+	defer os.Remove(played)
+
+	_, err = os.Stat(filepath.Join(cache.dir, "old.mp3"))
+	assert.True(t, os.IsNotExist(err), "least recently used audio is evicted")
+	assert.FileExists(t, filepath.Join(cache.dir, "new.mp3"))
+	assert.FileExists(t, filepath.Join(cache.dir, "newest.mp3"))
+}
+
+func TestAudioCache_Clear(t *testing.T) {
+	slot := newTestSlot(t, "abc", 1<<20)
+	played, err := writeAudio(strings.NewReader("audio"), VoiceOptions{cache: slot}, "mp3")
+	require.NoError(t, err)
+	defer os.Remove(played)
+	hit, ok := slot.use(VoiceOptions{})
+	require.True(t, ok)
+	defer os.Remove(hit)
+
+	removed, err := slot.cache.Clear()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	stats, err := slot.cache.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, CacheStats{Dir: slot.cache.dir, Limit: 1 << 20}, stats)
+}
@@ -0,0 +1,272 @@
+package voice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/daikw/ccpersona/internal/voice/provider"
+)
+
+// ProviderAuto picks a provider per message from the candidates in
+// voice.auto, by measured latency and health.
+const ProviderAuto = "auto"
+
+// DefaultAutoLongChars is the length from which text counts as long and
+// goes to the quality provider.
+const DefaultAutoLongChars = 200
+
+const (
+	// latencyAlpha weighs the newest sample in the moving averages.
+	latencyAlpha = 0.3
+	// failureBackoff is how long a provider is passed over per consecutive
+	// failure, up to maxFailureBackoff.
+	failureBackoff    = time.Minute
+	maxFailureBackoff = 30 * time.Minute
+)
+
+// AutoConfig configures provider "auto".
+type AutoConfig struct {
+	// Providers are the candidates, each with its own settings.
+	Providers map[string]ProviderConfig `json:"providers"`
+	// Quality is used for long text while it is healthy. Short text always
+	// goes to the fastest healthy candidate.
+	Quality string `json:"quality,omitempty"`
+	// LongChars is the length from which text is long (default 200).
+	LongChars int `json:"long_chars,omitempty"`
+}
+
+// Validate checks that the candidates are providers ccpersona knows.
+func (a *AutoConfig) Validate() error {
+	if a == nil {
+		return nil
+	}
+	if len(a.Providers) == 0 {
+		return errors.New("voice.auto.providers must name at least one provider")
+	}
+	for name := range a.Providers {
+		if name != EngineVoicevox && name != EngineAivisSpeech && !slices.Contains(provider.AllProviders, name) {
+			return fmt.Errorf("voice.auto.providers: unknown provider %q", name)
+		}
+	}
+	if _, ok := a.Providers[a.Quality]; a.Quality != "" && !ok {
+		return fmt.Errorf("voice.auto.quality %q is not one of voice.auto.providers", a.Quality)
+	}
+	if a.LongChars < 0 {
+		return errors.New("voice.auto.long_chars must not be negative")
+	}
+	return nil
+}
+
+// selection resolves each candidate as if it were the configured provider,
+// with its own settings from Providers. The top-level speaker belongs to no
+// candidate in particular and is left out; volume and speed apply to all.
+func (a *AutoConfig) selection(persona PersonaVoiceInput, fileConfig *ConfigFile) *AutoSelection {
+	names := make([]string, 0, len(a.Providers))
+	for name := range a.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sel := &AutoSelection{Quality: a.Quality, LongChars: a.LongChars}
+	for _, name := range names {
+		candidate := *fileConfig
+		candidate.Auto = nil
+		candidate.Providers = map[string]ProviderConfig{name: a.Providers[name]}
+		input := PersonaVoiceInput{Provider: name, Volume: persona.Volume, Speed: persona.Speed}
+		sel.Candidates = append(sel.Candidates, Resolve(input, &candidate, name))
+	}
+	return sel
+}
+
+// AutoSelection holds each candidate's resolved options.
+type AutoSelection struct {
+	// Candidates are ordered by provider name, which breaks ties.
+	Candidates []VoiceOptions `json:"candidates"`
+	Quality    string         `json:"quality,omitempty"`
+	LongChars  int            `json:"long_chars,omitempty"`
+}
+
+// AutoChoice is a candidate and why it was ranked where it is.
+type AutoChoice struct {
+	Options VoiceOptions
+	Reason  string
+}
+
+// Rank orders the candidates for text: for long text the quality provider
+// first, then healthy providers by measured latency (unmeasured ones first,
+// so each gets measured), then those backing off after failures.
+func (a *AutoSelection) Rank(text string, stats map[string]ProviderStat, now time.Time) []AutoChoice {
+	limit := a.LongChars
+	if limit <= 0 {
+		limit = DefaultAutoLongChars
+	}
+	long := len([]rune(text)) >= limit
+
+	var healthy, backingOff []VoiceOptions
+	var quality *VoiceOptions
+	for i, c := range a.Candidates {
+		switch {
+		case !stats[c.Provider].Healthy(now):
+			backingOff = append(backingOff, c)
+		case long && c.Provider == a.Quality:
+			quality = &a.Candidates[i]
+		default:
+			healthy = append(healthy, c)
+		}
+	}
+	sort.SliceStable(healthy, func(i, j int) bool {
+		si, sj := stats[healthy[i].Provider], stats[healthy[j].Provider]
+		if (si.Samples == 0) != (sj.Samples == 0) {
+			return si.Samples == 0
+		}
+		return si.LatencyMS < sj.LatencyMS
+	})
+	sort.SliceStable(backingOff, func(i, j int) bool {
+		return stats[backingOff[i].Provider].retryAt().Before(stats[backingOff[j].Provider].retryAt())
+	})
+
+	var out []AutoChoice
+	if quality != nil {
+		out = append(out, AutoChoice{*quality, fmt.Sprintf("quality provider for long text (%d+ chars)", limit)})
+	}
+	for i, c := range healthy {
+		s := stats[c.Provider]
+		reason := "not measured yet"
+		switch {
+		case s.Samples == 0:
+		case i == 0 && quality == nil:
+			reason = fmt.Sprintf("fastest healthy provider (%.0f ms average)", s.LatencyMS)
+		default:
+			reason = fmt.Sprintf("healthy, %.0f ms average", s.LatencyMS)
+		}
+		out = append(out, AutoChoice{c, reason})
+	}
+	for _, c := range backingOff {
+		s := stats[c.Provider]
+		out = append(out, AutoChoice{c, fmt.Sprintf("%d consecutive failures, retried after %s", s.Failures, s.retryAt().Local().Format("15:04:05"))})
+	}
+	return out
+}
+
+// ProviderStat is the measured synthesis performance of one provider.
+type ProviderStat struct {
+	// LatencyMS is the moving average of successful syntheses of short
+	// text; long text would skew it.
+	LatencyMS float64 `json:"latency_ms"`
+	// ErrorRate is the moving average of failures, from 0 to 1.
+	ErrorRate float64 `json:"error_rate"`
+	// Samples counts latency measurements.
+	Samples int `json:"samples"`
+	// Failures counts consecutive failures.
+	Failures  int       `json:"failures,omitempty"`
+	LastError time.Time `json:"last_error,omitempty"`
+	Updated   time.Time `json:"updated"`
+}
+
+// Healthy reports whether the provider may be tried: it has not failed, or
+// its backoff has passed.
+func (s ProviderStat) Healthy(now time.Time) bool {
+	return s.Failures == 0 || !now.Before(s.retryAt())
+}
+
+func (s ProviderStat) retryAt() time.Time {
+	backoff := time.Duration(s.Failures) * failureBackoff
+	if backoff > maxFailureBackoff {
+		backoff = maxFailureBackoff
+	}
+	return s.LastError.Add(backoff)
+}
+
+// observe folds one synthesis into the averages. Latency is only sampled
+// for short text.
+func (s ProviderStat) observe(latency time.Duration, failed, short bool, now time.Time) ProviderStat {
+	first := s.Updated.IsZero()
+	s.Updated = now
+	sample := 0.0
+	if failed {
+		sample = 1
+		s.Failures++
+		s.LastError = now
+	} else {
+		s.Failures = 0
+	}
+	s.ErrorRate = ema(s.ErrorRate, sample, first)
+	if !failed && short {
+		ms := float64(latency) / float64(time.Millisecond)
+		if s.Samples == 0 {
+			s.LatencyMS = ms
+		} else {
+			s.LatencyMS = ema(s.LatencyMS, ms, false)
+		}
+		s.Samples++
+	}
+	return s
+}
+
+func ema(avg, sample float64, first bool) float64 {
+	if first {
+		return sample
+	}
+	return latencyAlpha*sample + (1-latencyAlpha)*avg
+}
+
+// ProviderStatsPath returns where latency and error rates are kept, in the
+// user cache directory since they only steer choices.
+func ProviderStatsPath() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve cache dir: %w", err)
+	}
+	return filepath.Join(base, "ccpersona", "provider-stats.json"), nil
+}
+
+// LoadProviderStats reads the measured stats by provider. A missing or
+// unreadable file yields none, so selection falls back to config order.
+func LoadProviderStats() map[string]ProviderStat {
+	path, err := ProviderStatsPath()
+	if err != nil {
+		return nil
+	}
+	return readProviderStats(path)
+}
+
+func readProviderStats(path string) map[string]ProviderStat {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var stats map[string]ProviderStat
+	if json.Unmarshal(data, &stats) != nil {
+		return nil
+	}
+	return stats
+}
+
+// recordProviderStat folds a synthesis by name into the stored stats.
+func recordProviderStat(name string, latency time.Duration, failed, short bool) error {
+	path, err := ProviderStatsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return fsutil.WithLock(path, func() error {
+		stats := readProviderStats(path)
+		if stats == nil {
+			stats = map[string]ProviderStat{}
+		}
+		stats[name] = stats[name].observe(latency, failed, short, time.Now().UTC())
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		return fsutil.WriteFile(path, append(data, '\n'), 0o644)
+	})
+}
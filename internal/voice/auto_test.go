@@ -0,0 +1,153 @@
+package voice
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func autoProviders(choices []AutoChoice) []string {
+	out := make([]string, len(choices))
+	for i, c := range choices {
+		out[i] = c.Options.Provider
+	}
+	return out
+}
+
+func TestResolveAuto(t *testing.T) {
+	fileConfig := &ConfigFile{
+		DefaultProvider: ProviderAuto,
+		Defaults:        &DefaultsConfig{Speed: 1.2},
+		Auto: &AutoConfig{
+			Providers: map[string]ProviderConfig{
+				"voicevox":   {Speaker: 3},
+				"elevenlabs": {Voice: "narrator"},
+			},
+			Quality: "elevenlabs",
+		},
+	}
+	opts := Resolve(PersonaVoiceInput{Speaker: 42}, fileConfig, "")
+	if opts.Provider != ProviderAuto || opts.Auto == nil {
+		t.Fatalf("opts = %+v, want provider auto with candidates", opts)
+	}
+	if len(opts.Auto.Candidates) != 2 {
+		t.Fatalf("candidates = %+v", opts.Auto.Candidates)
+	}
+	eleven, vv := opts.Auto.Candidates[0], opts.Auto.Candidates[1]
+	if eleven.Provider != "elevenlabs" || eleven.Voice != "narrator" || eleven.Speed != 1.2 {
+		t.Errorf("elevenlabs candidate = %+v", eleven)
+	}
+	if vv.Provider != "voicevox" || vv.VoicevoxSpeaker != 3 {
+		t.Errorf("voicevox candidate = %+v, want its own speaker", vv)
+	}
+
+	if opts := Resolve(PersonaVoiceInput{}, fileConfig, "openai"); opts.Auto != nil {
+		t.Error("an explicit provider should bypass auto")
+	}
+}
+
+func TestAutoRank(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	sel := &AutoSelection{
+		Candidates: []VoiceOptions{{Provider: "elevenlabs"}, {Provider: "openai"}, {Provider: "voicevox"}},
+		Quality:    "elevenlabs",
+		LongChars:  10,
+	}
+	stats := map[string]ProviderStat{
+		"elevenlabs": {LatencyMS: 900, Samples: 5},
+		"openai":     {LatencyMS: 400, Samples: 5},
+		"voicevox":   {LatencyMS: 150, Samples: 5},
+	}
+	rank := func(text string) string {
+		return strings.Join(autoProviders(sel.Rank(text, stats, now)), ",")
+	}
+
+	if got := rank("short"); got != "voicevox,openai,elevenlabs" {
+		t.Errorf("short text = %s, want fastest first", got)
+	}
+	if got := rank("a much longer summary"); got != "elevenlabs,voicevox,openai" {
+		t.Errorf("long text = %s, want the quality provider first", got)
+	}
+
+	stats["voicevox"] = ProviderStat{LatencyMS: 150, Samples: 5, Failures: 2, LastError: now.Add(-time.Minute)}
+	if got := rank("short"); got != "openai,elevenlabs,voicevox" {
+		t.Errorf("with voicevox failing = %s, want it last", got)
+	}
+	stats["elevenlabs"] = ProviderStat{LatencyMS: 900, Samples: 5, Failures: 1, LastError: now.Add(-30 * time.Second)}
+	if got := rank("a much longer summary"); got != "openai,elevenlabs,voicevox" {
+		t.Errorf("long text with quality failing = %s, want the fastest healthy provider", got)
+	}
+
+	delete(stats, "openai")
+	if got := rank("short"); got != "openai,elevenlabs,voicevox" {
+		t.Errorf("unmeasured = %s, want it tried first", got)
+	}
+}
+
+func TestProviderStatObserve(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	var s ProviderStat
+	s = s.observe(100*time.Millisecond, false, true, now)
+	if s.LatencyMS != 100 || s.Samples != 1 || s.ErrorRate != 0 {
+		t.Fatalf("after one sample: %+v", s)
+	}
+	s = s.observe(200*time.Millisecond, false, true, now)
+	if s.LatencyMS != 130 {
+		t.Errorf("LatencyMS = %v, want 0.3*200 + 0.7*100", s.LatencyMS)
+	}
+	s = s.observe(5*time.Second, false, false, now)
+	if s.LatencyMS != 130 || s.Samples != 2 {
+		t.Errorf("long text should not move latency: %+v", s)
+	}
+
+	s = s.observe(0, true, true, now)
+	if s.Failures != 1 || s.ErrorRate < 0.29 || s.ErrorRate > 0.31 {
+		t.Errorf("after a failure: %+v", s)
+	}
+	if s.Healthy(now.Add(30*time.Second)) || !s.Healthy(now.Add(time.Minute)) {
+		t.Error("one failure should back off for a minute")
+	}
+	s = s.observe(0, true, true, now)
+	if s.Healthy(now.Add(time.Minute)) || !s.Healthy(now.Add(2*time.Minute)) {
+		t.Error("two failures should back off for two minutes")
+	}
+	s = s.observe(100*time.Millisecond, false, true, now)
+	if s.Failures != 0 || !s.Healthy(now) {
+		t.Errorf("a success should clear the backoff: %+v", s)
+	}
+}
+
+func TestRecordProviderStat(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	if err := recordProviderStat("openai", 250*time.Millisecond, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordProviderStat("voicevox", 0, true, true); err != nil {
+		t.Fatal(err)
+	}
+	stats := LoadProviderStats()
+	if stats["openai"].LatencyMS != 250 || stats["openai"].Samples != 1 {
+		t.Errorf("openai = %+v", stats["openai"])
+	}
+	if stats["voicevox"].Failures != 1 {
+		t.Errorf("voicevox = %+v", stats["voicevox"])
+	}
+}
+
+func TestAutoConfigValidate(t *testing.T) {
+	valid := &AutoConfig{Providers: map[string]ProviderConfig{"voicevox": {}, "openai": {}}, Quality: "openai"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+	for name, cfg := range map[string]*AutoConfig{
+		"empty":           {},
+		"unknown":         {Providers: map[string]ProviderConfig{"nope": {}}},
+		"nested auto":     {Providers: map[string]ProviderConfig{ProviderAuto: {}}},
+		"quality missing": {Providers: map[string]ProviderConfig{"voicevox": {}}, Quality: "openai"},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
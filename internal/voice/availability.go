@@ -0,0 +1,54 @@
+package voice
+
+import (
+	"sync"
+	"time"
+)
+
+// availability remembers engines and providers that answered an
+// availability check, so a long-running process does not ask again before
+// every message. It is off until CacheAvailability is called; one-shot hook
+// processes check every time.
+var availability struct {
+	sync.Mutex
+	ttl  time.Duration
+	seen map[string]time.Time
+}
+
+// CacheAvailability trusts a successful availability check for ttl. Failed
+// checks are not cached, so an engine that starts is picked up at once.
+func CacheAvailability(ttl time.Duration) {
+	availability.Lock()
+	defer availability.Unlock()
+	availability.ttl = ttl
+	availability.seen = map[string]time.Time{}
+}
+
+// checkAvailable runs check unless it succeeded for key within the cache
+// period.
+func checkAvailable(key string, check func() bool) bool {
+	availability.Lock()
+	checked, ok := availability.seen[key]
+	fresh := ok && time.Since(checked) < availability.ttl
+	availability.Unlock()
+	if fresh {
+		return true
+	}
+	if !check() {
+		return false
+	}
+	availability.Lock()
+	defer availability.Unlock()
+	if availability.seen != nil {
+		availability.seen[key] = time.Now()
+	}
+	return true
+}
+
+// forgetAvailable drops key after a request to it failed, so the next
+// message checks again.
+func forgetAvailable(key string) {
+	availability.Lock()
+	defer availability.Unlock()
+	delete(availability.seen, key)
+}
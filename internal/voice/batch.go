@@ -0,0 +1,386 @@
+package voice
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// Document split modes for batch synthesis.
+const (
+	SplitHeadings   = "headings"
+	SplitParagraphs = "paragraphs"
+)
+
+// BatchManifestName is the manifest written to a batch output directory.
+const BatchManifestName = "manifest.json"
+
+// Batch section states.
+const (
+	BatchDone   = "done"
+	BatchFailed = "failed"
+)
+
+// Section is one part of a document synthesized to its own file.
+type Section struct {
+	Title string
+	Text  string
+}
+
+// SplitDocument splits markdown into sections at headings or blank lines.
+// Lines inside fenced code blocks never split.
+func SplitDocument(content, mode string) ([]Section, error) {
+	if mode != SplitHeadings && mode != SplitParagraphs {
+		return nil, fmt.Errorf("unknown split mode %q (use %s or %s)", mode, SplitHeadings, SplitParagraphs)
+	}
+
+	var sections []Section
+	var current Section
+	var body []string
+	flush := func() {
+		current.Text = strings.TrimSpace(strings.Join(body, "\n"))
+		if current.Text != "" {
+			if current.Title == "" && mode == SplitParagraphs {
+				current.Title = firstWords(current.Text, 6)
+			}
+			sections = append(sections, current)
+		}
+		current, body = Section{}, nil
+	}
+
+	inFence := false
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if !inFence {
+			if m := headingLine.FindStringSubmatch(line); m != nil && mode == SplitHeadings {
+				flush()
+				current.Title = strings.TrimSpace(m[1])
+				body = append(body, withPeriod(current.Title))
+				continue
+			}
+			if trimmed == "" && mode == SplitParagraphs {
+				flush()
+				continue
+			}
+		}
+		body = append(body, line)
+	}
+	flush()
+	return sections, nil
+}
+
+// withPeriod ends a heading like a sentence, so it is read with a pause.
+func withPeriod(title string) string {
+	r := []rune(title)
+	if len(r) == 0 || strings.ContainsRune(sentenceEnds+".", r[len(r)-1]) {
+		return title
+	}
+	return title + "."
+}
+
+func firstWords(text string, n int) string {
+	words := strings.Fields(text)
+	if len(words) > n {
+		words = words[:n]
+	}
+	return strings.Join(words, " ")
+}
+
+// BatchManifest records the files of a batch and what produced them, so an
+// interrupted batch resumes where it stopped.
+type BatchManifest struct {
+	Source   string         `json:"source"`
+	Split    string         `json:"split"`
+	Sections []BatchSection `json:"sections"`
+}
+
+// BatchSection is the manifest entry of one section.
+type BatchSection struct {
+	Index      int    `json:"index"`
+	Title      string `json:"title"`
+	File       string `json:"file"`
+	Chars      int    `json:"chars"`
+	Hash       string `json:"hash"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Skipped is set when a resumed batch kept the existing file.
+	Skipped bool `json:"-"`
+}
+
+// BatchOptions controls SynthesizeBatch.
+type BatchOptions struct {
+	OutDir string
+	Source string
+	Split  string
+	// Parallel sections are synthesized at once (default 1).
+	Parallel int
+	// PerMinute limits how many sections start per minute (0 = unlimited).
+	PerMinute int
+	// Force synthesizes every section, even those done in an earlier run.
+	Force bool
+	// Voice is the synthesis options; output fields are set per section.
+	Voice VoiceOptions
+	// OnSection is called as each section finishes.
+	OnSection func(BatchSection)
+}
+
+// SynthesizeBatch synthesizes each section to its own file in OutDir and
+// keeps a manifest there. Sections whose text and voice settings match a
+// finished entry of the existing manifest are skipped. Failed sections do
+// not stop the others; an error reports how many failed.
+func (vm *VoiceManager) SynthesizeBatch(ctx context.Context, sections []Section, opts BatchOptions) (*BatchManifest, error) {
+	if err := os.MkdirAll(opts.OutDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	manifestPath := filepath.Join(opts.OutDir, BatchManifestName)
+	previous := map[string]BatchSection{}
+	if !opts.Force {
+		if old, err := loadBatchManifest(manifestPath); err == nil {
+			for _, s := range old.Sections {
+				previous[s.File] = s
+			}
+		}
+	}
+
+	fingerprint := voiceFingerprint(opts.Voice)
+	ext := outputExtension(opts.Voice)
+	manifest := &BatchManifest{Source: opts.Source, Split: opts.Split}
+	texts := make([]string, len(sections))
+	for i, section := range sections {
+		texts[i] = strings.TrimSpace(StripMarkdown(section.Text))
+		entry := BatchSection{
+			Index: i + 1,
+			Title: section.Title,
+			File:  fmt.Sprintf("%03d-%s.%s", i+1, fileSlug(section.Title), ext),
+			Chars: len([]rune(texts[i])),
+			Hash:  contentHash(texts[i] + "\x00" + fingerprint),
+		}
+		if old, ok := previous[entry.File]; ok && old.Status == BatchDone && old.Hash == entry.Hash {
+			if _, err := os.Stat(filepath.Join(opts.OutDir, entry.File)); err == nil {
+				entry.Status, entry.DurationMS, entry.Skipped = BatchDone, old.DurationMS, true
+			}
+		}
+		manifest.Sections = append(manifest.Sections, entry)
+	}
+
+	var mu sync.Mutex
+	save := func() error {
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		return fsutil.WriteFile(manifestPath, append(data, '\n'), 0644)
+	}
+	if err := save(); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	parallel := opts.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	limiter := newRateLimiter(opts.PerMinute)
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i := range manifest.Sections {
+		mu.Lock()
+		entry := manifest.Sections[i]
+		mu.Unlock()
+		if entry.Skipped {
+			if opts.OnSection != nil {
+				opts.OnSection(entry)
+			}
+			continue
+		}
+		if texts[i] == "" {
+			continue
+		}
+		if err := limiter.wait(ctx); err != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, entry BatchSection) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			entry.Status, entry.Error = BatchDone, ""
+			d, err := vm.synthesizeSection(ctx, texts[i], filepath.Join(opts.OutDir, entry.File), opts.Voice)
+			if err != nil {
+				entry.Status, entry.Error = BatchFailed, err.Error()
+			}
+			entry.DurationMS = d.Milliseconds()
+
+			mu.Lock()
+			manifest.Sections[i] = entry
+			_ = save()
+			mu.Unlock()
+			if opts.OnSection != nil {
+				opts.OnSection(entry)
+			}
+		}(i, entry)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return manifest, err
+	}
+	failed := 0
+	for _, entry := range manifest.Sections {
+		if entry.Status == BatchFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return manifest, fmt.Errorf("%d of %d sections failed", failed, len(manifest.Sections))
+	}
+	return manifest, nil
+}
+
+// synthesizeSection synthesizes text into path and returns its duration,
+// when the format allows reading it.
+func (vm *VoiceManager) synthesizeSection(ctx context.Context, text, path string, options VoiceOptions) (time.Duration, error) {
+	options.OutputPath = path
+	options.PlayAudio = false
+	options.ToStdout = false
+	audioFile, err := vm.Synthesize(ctx, text, options)
+	if err != nil {
+		return 0, err
+	}
+	// Local engines synthesize to a temporary file.
+	if audioFile != path {
+		if err := moveFile(audioFile, path); err != nil {
+			return 0, err
+		}
+	}
+	d, _ := AudioDuration(path)
+	return d, nil
+}
+
+func loadBatchManifest(path string) (*BatchManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest BatchManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// voiceFingerprint identifies the settings that change how text sounds.
+func voiceFingerprint(o VoiceOptions) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%g|%g|%d|%d|%t|%s|%s|%s|%t", o.Provider, o.Voice, o.Model, o.Format,
+		o.Speed, o.Volume, o.VoicevoxSpeaker, o.AivisSpeechSpeaker, o.SpeakerSet, o.Engine, o.Language, o.ReferenceWAV, o.SSML)
+}
+
+// outputExtension is the extension of the audio the options produce.
+func outputExtension(o VoiceOptions) string {
+	if IsLocalProvider(o.Provider) {
+		return "wav"
+	}
+	return getFileExtension(o.Format)
+}
+
+func contentHash(s string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+}
+
+// fileSlug turns a title into a file name part, keeping letters and digits
+// of any script.
+func fileSlug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := []rune(strings.TrimSuffix(b.String(), "-"))
+	if len(slug) > 40 {
+		slug = []rune(strings.TrimSuffix(string(slug[:40]), "-"))
+	}
+	if len(slug) == 0 {
+		return "section"
+	}
+	return string(slug)
+}
+
+func moveFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	// Across file systems: copy, then remove the original.
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
+// rateLimiter spaces out starts evenly, perMinute per minute.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	if perMinute <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{interval: time.Minute / time.Duration(perMinute)}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,104 @@
+package voice
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const batchDocument = `Preamble text.
+
+# Getting Started
+
+Install it.
+
+` + "```sh\n# not a heading\nmake\n```" + `
+
+## Why?
+
+Because.
+`
+
+func TestSplitDocumentHeadings(t *testing.T) {
+	sections, err := SplitDocument(batchDocument, SplitHeadings)
+	require.NoError(t, err)
+	require.Len(t, sections, 3)
+	assert.Equal(t, Section{Title: "", Text: "Preamble text."}, sections[0])
+	assert.Equal(t, "Getting Started", sections[1].Title)
+	assert.Contains(t, sections[1].Text, "# not a heading", "fenced code does not split")
+	assert.Equal(t, Section{Title: "Why?", Text: "Why?\n\nBecause."}, sections[2])
+}
+
+func TestSplitDocumentParagraphs(t *testing.T) {
+	sections, err := SplitDocument("One two three four five six seven.\n\n\nSecond.\n", SplitParagraphs)
+	require.NoError(t, err)
+	require.Len(t, sections, 2)
+	assert.Equal(t, "One two three four five six", sections[0].Title)
+	assert.Equal(t, "Second.", sections[1].Text)
+
+	_, err = SplitDocument("x", "chapters")
+	assert.Error(t, err)
+}
+
+func TestFileSlug(t *testing.T) {
+	assert.Equal(t, "getting-started", fileSlug("Getting Started!"))
+	assert.Equal(t, "はじめに", fileSlug("はじめに"))
+	assert.Equal(t, "section", fileSlug("???"))
+	assert.Len(t, []rune(fileSlug("aaaaaaaaaa bbbbbbbbbb cccccccccc dddddddddd eeeeeeeeee")), 40)
+}
+
+func TestSynthesizeBatchResume(t *testing.T) {
+	isolateCache(t)
+	fake := &chunkProvider{}
+	manager := NewVoiceManager(DefaultConfig())
+	manager.providerFactory = chunkFactory{fake}
+
+	dir := t.TempDir()
+	sections := []Section{{Title: "Intro", Text: "Hello."}, {Title: "Body", Text: "World."}}
+	opts := BatchOptions{OutDir: dir, Parallel: 2, Voice: VoiceOptions{Provider: "openai", Format: "mp3"}}
+
+	manifest, err := manager.SynthesizeBatch(context.Background(), sections, opts)
+	require.NoError(t, err)
+	require.Len(t, manifest.Sections, 2)
+	assert.Equal(t, "001-intro.mp3", manifest.Sections[0].File)
+	data, err := os.ReadFile(filepath.Join(dir, "002-body.mp3"))
+	require.NoError(t, err)
+	assert.Equal(t, "[World.]", string(data))
+	assert.FileExists(t, filepath.Join(dir, BatchManifestName))
+	assert.Len(t, fake.inputs, 2)
+
+	// A second run only synthesizes the changed section.
+	sections[1].Text = "Everyone."
+	manifest, err = manager.SynthesizeBatch(context.Background(), sections, opts)
+	require.NoError(t, err)
+	assert.True(t, manifest.Sections[0].Skipped)
+	assert.False(t, manifest.Sections[1].Skipped)
+	assert.Equal(t, "Everyone.", fake.inputs[2])
+	assert.Len(t, fake.inputs, 3)
+
+	// Other voice settings count as a change.
+	opts.Voice.Voice = "nova"
+	manifest, err = manager.SynthesizeBatch(context.Background(), sections, opts)
+	require.NoError(t, err)
+	assert.False(t, manifest.Sections[0].Skipped)
+	assert.Len(t, fake.inputs, 5)
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(600) // one start per 100ms
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, limiter.wait(ctx))
+	assert.NoError(t, newRateLimiter(0).wait(context.Background()))
+}
@@ -0,0 +1,59 @@
+package voice
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+// bookmarkDir holds transcript bookmarks inside the dedup directory. It is a
+// subdirectory so the 24-hour marker cleanup leaves bookmarks alone.
+const bookmarkDir = "bookmarks"
+
+// TranscriptBookmark is the high-water mark of a transcript: the ID of the
+// last message read aloud from it. It lets repeated reads of the same
+// transcript skip messages already spoken, across processes.
+type TranscriptBookmark struct {
+	transcriptPath string
+	dir            string
+}
+
+// NewTranscriptBookmark creates the bookmark for the transcript at path.
+func NewTranscriptBookmark(transcriptPath string) *TranscriptBookmark {
+	if abs, err := filepath.Abs(transcriptPath); err == nil {
+		transcriptPath = abs
+	}
+	return &TranscriptBookmark{
+		transcriptPath: transcriptPath,
+		dir:            filepath.Join(os.TempDir(), dedupDir, bookmarkDir),
+	}
+}
+
+// Spoken reports whether the message with this ID was the last one spoken.
+func (b *TranscriptBookmark) Spoken(messageID string) bool {
+	stored, err := os.ReadFile(b.path())
+	if err != nil {
+		return false
+	}
+	return messageID != "" && strings.TrimSpace(string(stored)) == messageID
+}
+
+// Record marks the message with this ID as spoken.
+func (b *TranscriptBookmark) Record(messageID string) {
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		log.Debug().Err(err).Msg("Failed to create bookmark directory")
+		return
+	}
+	if err := fsutil.WriteFile(b.path(), []byte(messageID), 0644); err != nil {
+		log.Debug().Err(err).Msg("Failed to write transcript bookmark")
+	}
+}
+
+// path keys the bookmark by a hash of the transcript path, which may hold
+// characters that are not safe in a filename.
+func (b *TranscriptBookmark) path() string {
+	return filepath.Join(b.dir, hashText(b.transcriptPath)+".bookmark")
+}
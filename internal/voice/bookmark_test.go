@@ -0,0 +1,77 @@
+package voice
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTranscriptBookmark(t *testing.T) {
+	dir := t.TempDir()
+	b := NewTranscriptBookmark("transcript.jsonl")
+	b.dir = dir
+
+	if b.Spoken("uuid-1") {
+		t.Error("nothing should be spoken before the first record")
+	}
+	b.Record("uuid-1")
+	if !b.Spoken("uuid-1") {
+		t.Error("recorded message should be spoken")
+	}
+	if b.Spoken("uuid-2") || b.Spoken("") {
+		t.Error("other messages should not be spoken")
+	}
+
+	// A new bookmark for the same transcript reads the recorded state, as a
+	// later process would.
+	again := NewTranscriptBookmark(filepath.Join(".", "transcript.jsonl"))
+	again.dir = dir
+	if !again.Spoken("uuid-1") {
+		t.Error("bookmark should persist across instances")
+	}
+
+	other := NewTranscriptBookmark("other.jsonl")
+	other.dir = dir
+	if other.Spoken("uuid-1") {
+		t.Error("bookmarks should be kept per transcript")
+	}
+}
+
+func TestBookmarkSurvivesDedupCleanup(t *testing.T) {
+	b := NewTranscriptBookmark("transcript.jsonl")
+	dt := NewDedupTracker("session")
+	if filepath.Dir(b.dir) != dt.dir {
+		t.Fatalf("bookmark dir %s should be inside the dedup dir %s", b.dir, dt.dir)
+	}
+}
+
+func TestGetLatestAssistantMessageWithID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	lines := []string{
+		`{"type":"user","uuid":"u1","message":{"role":"user","content":"go"}}`,
+		`{"type":"assistant","uuid":"l1","message":{"id":"msg_a","role":"assistant","content":[{"type":"text","text":"First."}]}}`,
+		`{"type":"assistant","uuid":"l2","message":{"id":"msg_a","role":"assistant","content":[{"type":"text","text":"Second."}]}}`,
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range []string{ModeShort, ModeFull} {
+		config := DefaultConfig()
+		config.ReadingMode = mode
+		_, id, err := NewTranscriptReader(config).GetLatestAssistantMessageWithID(path)
+		if err != nil || id != "l2" {
+			t.Errorf("%s mode id = %q, %v, want l2", mode, id, err)
+		}
+	}
+
+	// Without uuids the ID is derived from the text.
+	if err := os.WriteFile(path, []byte(`{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Done."}]}}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, id, err := NewTranscriptReader(DefaultConfig()).GetLatestAssistantMessageWithID(path)
+	if err != nil || id != hashText("Done.") {
+		t.Errorf("id without uuid = %q, %v", id, err)
+	}
+}
@@ -0,0 +1,122 @@
+package voice
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+// Caption formats.
+const (
+	CaptionFormatText = "text"
+	CaptionFormatJSON = "json"
+)
+
+// CaptionConfig writes the text being spoken to a file or named pipe while it
+// plays, for example as an OBS text source showing the agent's speech bubble.
+type CaptionConfig struct {
+	// Path is a regular file, replaced atomically on every update, or a
+	// named pipe (FIFO), which receives one record per update.
+	Path string `json:"path"`
+	// Format is "text" (default), "json", or a template in which {persona}
+	// and {text} are replaced, such as "{persona}: {text}".
+	Format string `json:"format,omitempty"`
+	// Clear empties the caption when playback ends.
+	Clear bool `json:"clear,omitempty"`
+}
+
+// captionRecord is the JSON format of a caption.
+type captionRecord struct {
+	Persona  string `json:"persona,omitempty"`
+	Text     string `json:"text"`
+	Speaking bool   `json:"speaking"`
+	Time     string `json:"time"`
+}
+
+// render formats a caption. An empty text is the cleared caption.
+func (c *CaptionConfig) render(persona, text string) []byte {
+	switch c.Format {
+	case "", CaptionFormatText:
+		return []byte(text)
+	case CaptionFormatJSON:
+		data, _ := json.Marshal(captionRecord{
+			Persona:  persona,
+			Text:     text,
+			Speaking: text != "",
+			Time:     time.Now().UTC().Format(time.RFC3339),
+		})
+		return data
+	default:
+		if text == "" {
+			return nil
+		}
+		return []byte(strings.NewReplacer("{persona}", persona, "{text}", text).Replace(c.Format))
+	}
+}
+
+// write shows data in the caption. A named pipe gets one line per record and
+// is skipped when nobody is reading it, so playback never waits for a reader.
+func (c *CaptionConfig) write(data []byte) error {
+	path := expandHomePath(c.Path)
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		f, err := openFIFO(path)
+		if err != nil || f == nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write(append(data, '\n'))
+		return err
+	}
+	return fsutil.WriteFile(path, data, 0644)
+}
+
+// show writes the caption of text spoken by persona.
+func (c *CaptionConfig) show(persona, text string) {
+	if err := c.write(c.render(persona, text)); err != nil {
+		log.Debug().Err(err).Str("path", c.Path).Msg("Failed to write caption")
+	}
+}
+
+// clear empties the caption after playback, if configured to.
+func (c *CaptionConfig) clear(persona string) {
+	if !c.Clear {
+		return
+	}
+	if err := c.write(c.render(persona, "")); err != nil {
+		log.Debug().Err(err).Str("path", c.Path).Msg("Failed to clear caption")
+	}
+}
+
+// Validate checks the caption settings.
+func (c *CaptionConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.Path == "" {
+		return fmt.Errorf("voice.caption.path is required")
+	}
+	switch c.Format {
+	case "", CaptionFormatText, CaptionFormatJSON:
+		return nil
+	}
+	if !strings.Contains(c.Format, "{text}") {
+		return fmt.Errorf("voice.caption.format must be text, json, or a template containing {text}")
+	}
+	return nil
+}
+
+// expandHomePath expands a leading ~/ in path.
+func expandHomePath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,78 @@
+package voice
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptionRender(t *testing.T) {
+	assert.Equal(t, "hello", string((&CaptionConfig{}).render("zundamon", "hello")))
+	assert.Equal(t, "zundamon: hello", string((&CaptionConfig{Format: "{persona}: {text}"}).render("zundamon", "hello")))
+	assert.Empty(t, (&CaptionConfig{Format: "{persona}: {text}"}).render("zundamon", ""))
+
+	var record captionRecord
+	require.NoError(t, json.Unmarshal((&CaptionConfig{Format: CaptionFormatJSON}).render("zundamon", "hello"), &record))
+	assert.Equal(t, "zundamon", record.Persona)
+	assert.Equal(t, "hello", record.Text)
+	assert.True(t, record.Speaking)
+
+	require.NoError(t, json.Unmarshal((&CaptionConfig{Format: CaptionFormatJSON}).render("zundamon", ""), &record))
+	assert.False(t, record.Speaking)
+}
+
+func TestCaptionValidate(t *testing.T) {
+	assert.NoError(t, (*CaptionConfig)(nil).Validate())
+	assert.NoError(t, (&CaptionConfig{Path: "caption.txt", Format: CaptionFormatJSON}).Validate())
+	assert.NoError(t, (&CaptionConfig{Path: "caption.txt", Format: "> {text}"}).Validate())
+	assert.Error(t, (&CaptionConfig{}).Validate())
+	assert.Error(t, (&CaptionConfig{Path: "caption.txt", Format: "{persona}"}).Validate())
+}
+
+func TestCaptionDuringPlayback(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "caption.txt")
+	audio := filepath.Join(dir, "speech.wav")
+	require.NoError(t, os.WriteFile(audio, []byte("RIFF"), 0600))
+
+	var during string
+	restore := SetPlayer(func(string) error {
+		data, err := os.ReadFile(path)
+		during = string(data)
+		return err
+	})
+	defer restore()
+
+	setSpeech(audio, &speech{persona: "zundamon", text: "done", caption: &CaptionConfig{Path: path, Clear: true}})
+	require.NoError(t, NewVoiceEngine(DefaultConfig()).PlayWithOptions(audio, true))
+
+	assert.Equal(t, "done", during)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, data, "clear empties the caption after playback")
+
+	assert.Nil(t, takeSpeech(audio))
+}
+
+func TestSaveSpeech(t *testing.T) {
+	audio := filepath.Join(t.TempDir(), "speech.wav")
+	caption := &CaptionConfig{Path: "caption.txt", Clear: true}
+
+	require.NoError(t, SaveSpeech(audio), "audio without speech has nothing to save")
+	setSpeech(audio, &speech{persona: "zundamon", text: "done", caption: caption})
+	require.NoError(t, SaveSpeech(audio))
+	assert.Nil(t, takeSpeech(audio), "saved speech leaves this process")
+
+	require.NoError(t, RestoreSpeech(audio))
+	s := takeSpeech(audio)
+	require.NotNil(t, s)
+	assert.Equal(t, "zundamon", s.persona)
+	assert.Equal(t, "done", s.text)
+	assert.Equal(t, caption, s.caption)
+	assert.NoFileExists(t, speechPath(audio))
+	assert.NoError(t, RestoreSpeech(audio))
+}
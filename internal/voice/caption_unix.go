@@ -0,0 +1,19 @@
+//go:build !windows
+
+package voice
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// openFIFO opens a named pipe for writing without blocking. It returns a nil
+// file when no process has the pipe open for reading.
+func openFIFO(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if errors.Is(err, syscall.ENXIO) {
+		return nil, nil
+	}
+	return f, err
+}
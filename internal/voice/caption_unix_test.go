@@ -0,0 +1,20 @@
+//go:build !windows
+
+package voice
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptionFIFOWithoutReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "caption.fifo")
+	require.NoError(t, syscall.Mkfifo(path, 0600))
+
+	// Nobody reads the pipe: the write is skipped instead of blocking.
+	assert.NoError(t, (&CaptionConfig{Path: path}).write([]byte("hello")))
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package voice
+
+import (
+	"errors"
+	"os"
+)
+
+// openFIFO is not supported: Windows has no named pipes in the file system.
+func openFIFO(path string) (*os.File, error) {
+	return nil, errors.New("named pipes are not supported on Windows")
+}
@@ -0,0 +1,132 @@
+package voice
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// providerMaxChars are conservative per-request input limits. Cloud APIs
+// reject longer input; local engines accept it but slow down sharply, so
+// they use short chunks that also start playing sooner.
+var providerMaxChars = map[string]int{
+	"openai":          4000, // API limit 4096
+	"elevenlabs":      4500, // 5000 on most models
+	"polly":           2800, // 3000 billed characters
+	"gcp":             1500, // 5000 bytes; 3-byte CJK characters
+	"azure":           3000, // 10 minutes of audio per request
+	"xtts":            250,  // quality degrades on long inputs
+	"sherpa-cli":      500,
+	EngineVoicevox:    200,
+	EngineAivisSpeech: 200,
+}
+
+// defaultMaxChars applies to providers without a known limit.
+const defaultMaxChars = 1000
+
+// ChunkLimit returns the chunk size for a provider. A positive override wins
+// but never exceeds the provider's own limit.
+func ChunkLimit(provider string, override int) int {
+	if provider == "" {
+		provider = EngineAivisSpeech
+	}
+	limit, ok := providerMaxChars[provider]
+	if !ok {
+		limit = defaultMaxChars
+	}
+	if override > 0 && override < limit {
+		return override
+	}
+	return limit
+}
+
+// sentenceEnds are characters after which text may be split.
+const sentenceEnds = "。．！？!?\n"
+
+// clauseBreaks are weaker split points used for over-long sentences.
+const clauseBreaks = "、，,;；:： "
+
+// SplitText splits text into chunks of at most max runes, preferring sentence
+// boundaries, then clause boundaries, then a hard cut. Chunks are trimmed and
+// empty chunks dropped.
+func SplitText(text string, max int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if max <= 0 || utf8.RuneCountInString(text) <= max {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		current.Reset()
+		currentLen = 0
+	}
+
+	for _, sentence := range splitAfterAny(text, sentenceEnds) {
+		n := utf8.RuneCountInString(sentence)
+		if currentLen+n <= max {
+			current.WriteString(sentence)
+			currentLen += n
+			continue
+		}
+		flush()
+		if n <= max {
+			current.WriteString(sentence)
+			currentLen = n
+			continue
+		}
+		// The sentence alone is too long: pack its clauses, hard-cutting any
+		// clause that still does not fit.
+		for _, clause := range splitAfterAny(sentence, clauseBreaks) {
+			for _, piece := range hardSplit(clause, max) {
+				pn := utf8.RuneCountInString(piece)
+				if currentLen+pn > max {
+					flush()
+				}
+				current.WriteString(piece)
+				currentLen += pn
+			}
+		}
+	}
+	flush()
+	return chunks
+}
+
+// splitAfterAny splits s after every rune in seps, keeping the separators.
+func splitAfterAny(s, seps string) []string {
+	var parts []string
+	start := 0
+	for i, r := range s {
+		if strings.ContainsRune(seps, r) {
+			end := i + utf8.RuneLen(r)
+			parts = append(parts, s[start:end])
+			start = end
+		}
+	}
+	if start < len(s) {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+func hardSplit(s string, max int) []string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return []string{s}
+	}
+	var parts []string
+	for len(runes) > max {
+		parts = append(parts, string(runes[:max]))
+		runes = runes[max:]
+	}
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
+}
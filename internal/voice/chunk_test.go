@@ -0,0 +1,64 @@
+package voice
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunkLimit(t *testing.T) {
+	if got := ChunkLimit("openai", 0); got != 4000 {
+		t.Errorf("openai default = %d", got)
+	}
+	if got := ChunkLimit("openai", 500); got != 500 {
+		t.Errorf("override below limit = %d", got)
+	}
+	if got := ChunkLimit("openai", 99999); got != 4000 {
+		t.Errorf("override above limit must be capped, got %d", got)
+	}
+	if got := ChunkLimit("", 0); got != ChunkLimit(EngineAivisSpeech, 0) {
+		t.Errorf("empty provider should use the local engine limit, got %d", got)
+	}
+	if got := ChunkLimit("unknown", 0); got != defaultMaxChars {
+		t.Errorf("unknown provider = %d", got)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want []string
+	}{
+		{"fits", "short text", 20, []string{"short text"}},
+		{"empty", "   ", 10, nil},
+		{"sentences packed", "One. Two. Three.", 10, []string{"One. Two.", "Three."}},
+		{"japanese sentences", "こんにちは。元気ですか？はい。", 10, []string{"こんにちは。", "元気ですか？はい。"}},
+		{"clauses", "alpha, beta, gamma, delta", 13, []string{"alpha, beta,", "gamma, delta"}},
+		{"hard cut", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitText(tt.text, tt.max)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("SplitText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTextRespectsLimit(t *testing.T) {
+	text := strings.Repeat("これは長い文章です、とても長いです。", 40) + strings.Repeat("x", 300)
+	chunks := SplitText(text, 50)
+	var joined strings.Builder
+	for _, c := range chunks {
+		if n := utf8.RuneCountInString(c); n > 50 {
+			t.Fatalf("chunk of %d runes exceeds limit: %q", n, c)
+		}
+		joined.WriteString(c)
+	}
+	if strings.ReplaceAll(joined.String(), " ", "") != strings.ReplaceAll(text, " ", "") {
+		t.Error("chunks must preserve all text")
+	}
+}
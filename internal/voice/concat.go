@@ -0,0 +1,132 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ConcatAudio joins audio files produced for consecutive chunks into w. WAV
+// files are merged into a single RIFF container (all chunks must share one
+// format); MP3 and other streamable formats are concatenated frame-wise, with
+// ID3v2 tags removed from all but the first file.
+func ConcatAudio(w io.Writer, paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("no audio to concatenate")
+	}
+	first, err := os.ReadFile(paths[0])
+	if err != nil {
+		return err
+	}
+	if isWAV(first) {
+		return concatWAV(w, first, paths[1:])
+	}
+
+	if _, err := w.Write(first); err != nil {
+		return err
+	}
+	for _, path := range paths[1:] {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(stripID3v2(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isWAV(data []byte) bool {
+	return len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WAVE"))
+}
+
+// wavParts extracts the fmt chunk body and PCM data of a WAV file.
+func wavParts(data []byte) (format, pcm []byte, err error) {
+	if !isWAV(data) {
+		return nil, nil, fmt.Errorf("not a WAV file")
+	}
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := pos + 8
+		end := body + size
+		if end > len(data) || size < 0 {
+			// Streaming engines may write a placeholder size; take the rest.
+			end = len(data)
+		}
+		switch id {
+		case "fmt ":
+			format = data[body:end]
+		case "data":
+			pcm = data[body:end]
+		}
+		pos = end + size%2 // chunks are word-aligned
+	}
+	if format == nil || pcm == nil {
+		return nil, nil, fmt.Errorf("WAV file is missing fmt or data chunk")
+	}
+	return format, pcm, nil
+}
+
+func concatWAV(w io.Writer, first []byte, rest []string) error {
+	format, pcm, err := wavParts(first)
+	if err != nil {
+		return err
+	}
+	datas := [][]byte{pcm}
+	total := len(pcm)
+	for _, path := range rest {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f, p, err := wavParts(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if !bytes.Equal(f, format) {
+			return fmt.Errorf("%s: WAV format differs from the first chunk", path)
+		}
+		datas = append(datas, p)
+		total += len(p)
+	}
+
+	var header bytes.Buffer
+	header.WriteString("RIFF")
+	_ = binary.Write(&header, binary.LittleEndian, uint32(4+8+len(format)+8+total))
+	header.WriteString("WAVEfmt ")
+	_ = binary.Write(&header, binary.LittleEndian, uint32(len(format)))
+	header.Write(format)
+	header.WriteString("data")
+	_ = binary.Write(&header, binary.LittleEndian, uint32(total))
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	for _, p := range datas {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stripID3v2 removes a leading ID3v2 tag so concatenated MP3 streams do not
+// contain tags mid-stream.
+func stripID3v2(data []byte) []byte {
+	if len(data) < 10 || !bytes.Equal(data[0:3], []byte("ID3")) {
+		return data
+	}
+	// Tag size is a 28-bit syncsafe integer.
+	size := int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f)
+	end := 10 + size
+	if data[5]&0x10 != 0 {
+		end += 10 // footer present
+	}
+	if end > len(data) {
+		return data
+	}
+	return data[end:]
+}
@@ -0,0 +1,74 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func makeWAV(pcm []byte) []byte {
+	format := []byte{1, 0, 1, 0, 0x80, 0xbb, 0, 0, 0, 0x77, 1, 0, 2, 0, 16, 0}
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(4+8+len(format)+8+len(pcm)))
+	b.WriteString("WAVEfmt ")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(len(format)))
+	b.Write(format)
+	b.WriteString("data")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(len(pcm)))
+	b.Write(pcm)
+	return b.Bytes()
+}
+
+func writeFiles(t *testing.T, contents ...[]byte) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for i, c := range contents {
+		path := filepath.Join(dir, string(rune('a'+i)))
+		if err := os.WriteFile(path, c, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestConcatAudioWAV(t *testing.T) {
+	paths := writeFiles(t, makeWAV([]byte{1, 2, 3, 4}), makeWAV([]byte{5, 6}))
+
+	var out bytes.Buffer
+	if err := ConcatAudio(&out, paths); err != nil {
+		t.Fatalf("ConcatAudio() error = %v", err)
+	}
+	if want := makeWAV([]byte{1, 2, 3, 4, 5, 6}); !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("merged WAV mismatch:\n got %v\nwant %v", out.Bytes(), want)
+	}
+}
+
+func TestConcatAudioWAVFormatMismatch(t *testing.T) {
+	other := makeWAV([]byte{1, 2})
+	other[24] = 0x44 // different sample rate
+	paths := writeFiles(t, makeWAV([]byte{1, 2}), other)
+	if err := ConcatAudio(&bytes.Buffer{}, paths); err == nil {
+		t.Error("expected error for mismatched WAV formats")
+	}
+}
+
+func TestConcatAudioMP3StripsLaterID3(t *testing.T) {
+	id3 := []byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, 2, 'x', 'x'}
+	first := append(append([]byte{}, id3...), 0xff, 0xfb, 1)
+	second := append(append([]byte{}, id3...), 0xff, 0xfb, 2)
+	paths := writeFiles(t, first, second)
+
+	var out bytes.Buffer
+	if err := ConcatAudio(&out, paths); err != nil {
+		t.Fatal(err)
+	}
+	want := append(append([]byte{}, first...), 0xff, 0xfb, 2)
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("got %v, want %v", out.Bytes(), want)
+	}
+}
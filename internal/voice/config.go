@@ -21,6 +21,8 @@ type ConfigFile struct {
 	// subcommand can manage (status/start/stop/install) alongside the built-in
 	// VOICEVOX / AivisSpeech engines. Keyed by unique engine name.
 	Engines map[string]EngineUserConfig `json:"engines,omitempty"`
+	// Auto lists the candidates of provider "auto".
+	Auto *AutoConfig `json:"auto,omitempty"`
 }
 
 // EngineUserConfig declares a user-defined TTS engine for the `engine`
@@ -75,6 +77,31 @@ type ProviderConfig struct {
 	Engine     string `json:"engine,omitempty"`
 	SampleRate string `json:"sample_rate,omitempty"`
 
+	// Coqui XTTS options: reference_wav is a managed reference name
+	// (~/.agents/ccpersona/voices/<name>.wav) or a path to a WAV file.
+	ReferenceWAV string `json:"reference_wav,omitempty"`
+	Language     string `json:"language,omitempty"`
+
+	// Azure options: ssml sends text starting with <speak as SSML.
+	SSML bool `json:"ssml,omitempty"`
+
+	// Long-text chunking: chunk_chars lowers the provider's per-request
+	// limit; parallel_chunks synthesizes up to N chunks at once.
+	ChunkChars     int `json:"chunk_chars,omitempty"`
+	ParallelChunks int `json:"parallel_chunks,omitempty"`
+
+	// SpeedRamp speeds up long readouts after the first sentence.
+	SpeedRamp *SpeedRamp `json:"speed_ramp,omitempty"`
+
+	// Prosody sets VOICEVOX/AivisSpeech intonation, pitch, and silence.
+	Prosody *Prosody `json:"prosody,omitempty"`
+
+	// SpeakerStyle selects a style of the speaker by name with its
+	// emotional intensity; SpeakerStyles override it per notification
+	// urgency.
+	SpeakerStyle  *SpeakerStyle            `json:"speaker_style,omitempty"`
+	SpeakerStyles map[string]*SpeakerStyle `json:"speaker_styles,omitempty"`
+
 	// Volume control (provider-specific override)
 	Volume float64 `json:"volume,omitempty"`
 }
@@ -289,12 +316,26 @@ func validateProviderConfig(name string, config *ProviderConfig) []string {
 		if config.Region != "" && !contains(validRegions, config.Region) {
 			errors = append(errors, fmt.Sprintf("%s: region '%s' may not be valid", name, config.Region))
 		}
+	case "azure":
+		if config.APIKey == "" && os.Getenv("AZURE_SPEECH_KEY") == "" {
+			errors = append(errors, fmt.Sprintf("%s: api_key is required (use ${AZURE_SPEECH_KEY} for env var)", name))
+		}
+		if config.Region == "" && os.Getenv("AZURE_SPEECH_REGION") == "" {
+			errors = append(errors, fmt.Sprintf("%s: region is required, e.g. japaneast (or set AZURE_SPEECH_REGION)", name))
+		}
 	case "voicevox", "aivisspeech":
 		if config.Port != 0 && (config.Port < 1 || config.Port > 65535) {
 			errors = append(errors, fmt.Sprintf("%s: port must be between 1 and 65535", name))
 		}
 	}
 
+	if err := config.Prosody.Validate(); err != nil {
+		errors = append(errors, fmt.Sprintf("%s: %v", name, err))
+	}
+	if err := ValidateStyles(config.SpeakerStyle, config.SpeakerStyles); err != nil {
+		errors = append(errors, fmt.Sprintf("%s: %v", name, err))
+	}
+
 	if config.Speed != 0 && (config.Speed < 0.25 || config.Speed > 4.0) {
 		errors = append(errors, fmt.Sprintf("%s: speed must be between 0.25 and 4.0", name))
 	}
@@ -354,6 +395,11 @@ func GenerateExampleConfig() string {
 				Engine:     "neural",
 				SampleRate: "22050",
 			},
+			"azure": {
+				APIKey: "${AZURE_SPEECH_KEY}",
+				Region: "japaneast",
+				Voice:  "ja-JP-NanamiNeural",
+			},
 			"voicevox": {
 				Host:    "localhost",
 				Port:    50021,
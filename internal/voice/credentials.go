@@ -0,0 +1,61 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/daikw/ccpersona/internal/voice/provider"
+)
+
+// CheckCredentials makes a minimal live request with the credentials of
+// options, so that a bad key shows when it is saved rather than at hook
+// time. It returns the account behind the key where the provider's API
+// reports it, or nil. Local engines and "auto" have no credentials to check
+// and return nil, nil.
+func CheckCredentials(ctx context.Context, options VoiceOptions) (*provider.Account, error) {
+	if !hasCredentials(options.Provider) {
+		return nil, nil
+	}
+	prov, err := credentialProvider(options)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := prov.(provider.AccountProvider); ok {
+		return p.Account(ctx)
+	}
+	if !prov.IsAvailable(ctx) {
+		return nil, fmt.Errorf("%s did not accept the credentials", options.Provider)
+	}
+	return nil, nil
+}
+
+// MissingCredentials reports, without a request, why the provider of
+// options cannot be set up, such as an API key in neither the config nor
+// the environment. It returns nil for local engines and "auto".
+func MissingCredentials(options VoiceOptions) error {
+	if !hasCredentials(options.Provider) {
+		return nil
+	}
+	_, err := credentialProvider(options)
+	return err
+}
+
+func hasCredentials(name string) bool {
+	return name != ProviderAuto && !IsLocalProvider(name)
+}
+
+// credentialProvider creates the provider of options from its credentials
+// alone; API keys fall back to the environment as in synthesis.
+func credentialProvider(options VoiceOptions) (provider.Provider, error) {
+	settings := make(map[string]interface{})
+	if options.APIKey != "" {
+		settings["api_key"] = options.APIKey
+	}
+	if options.BaseURL != "" {
+		settings["base_url"] = options.BaseURL
+	}
+	if options.Region != "" {
+		settings["region"] = options.Region
+	}
+	return provider.NewFactory().CreateProvider(options.Provider, settings)
+}
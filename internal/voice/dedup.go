@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/daikw/ccpersona/internal/fsutil"
 	"github.com/rs/zerolog/log"
 )
 
@@ -22,6 +23,7 @@ const dedupDir = "ccpersona-voice"
 type DedupTracker struct {
 	sessionID string
 	dir       string
+	ext       string
 }
 
 // NewDedupTracker creates a tracker for the given session.
@@ -29,9 +31,19 @@ func NewDedupTracker(sessionID string) *DedupTracker {
 	return &DedupTracker{
 		sessionID: sessionID,
 		dir:       filepath.Join(os.TempDir(), dedupDir),
+		ext:       ".lastread",
 	}
 }
 
+// NewMessageDedupTracker creates a tracker for the assistant messages whose
+// triggers and alerts already ran in the session. It is kept apart from the
+// spoken text, which is the message after processing.
+func NewMessageDedupTracker(sessionID string) *DedupTracker {
+	dt := NewDedupTracker(sessionID)
+	dt.ext = ".lastmessage"
+	return dt
+}
+
 // IsDuplicate returns true if this text was already synthesized in the current session.
 func (dt *DedupTracker) IsDuplicate(text string) bool {
 	hash := hashText(text)
@@ -49,7 +61,7 @@ func (dt *DedupTracker) Record(text string) {
 		return
 	}
 	hash := hashText(text)
-	if err := os.WriteFile(dt.markerPath(), []byte(hash), 0644); err != nil {
+	if err := fsutil.WriteFile(dt.markerPath(), []byte(hash), 0644); err != nil {
 		log.Debug().Err(err).Msg("Failed to write dedup marker")
 	}
 }
@@ -77,25 +89,25 @@ func (dt *DedupTracker) Cleanup() {
 }
 
 func (dt *DedupTracker) markerPath() string {
-	return filepath.Join(dt.dir, safeMarkerName(dt.sessionID))
+	return filepath.Join(dt.dir, safeSessionName(dt.sessionID)+dt.ext)
 }
 
 // maxSessionIDLen caps how long a sessionID may be embedded verbatim in the
 // marker filename; longer IDs risk ENAMETOOLONG on common filesystems.
 const maxSessionIDLen = 128
 
-// safeMarkerName derives a filename that can never escape the dedup directory.
+// safeSessionName derives a filename that can never escape the dedup directory.
 // sessionID originates from the untrusted Stop hook JSON, so anything outside
 // the safe character set (e.g. path separators or "..") or over-long input is
 // replaced with a stable sha256 hex digest rather than rejected, to avoid
 // breaking the hook.
-func safeMarkerName(sessionID string) string {
+func safeSessionName(sessionID string) string {
 	name := sessionID
 	if len(sessionID) > maxSessionIDLen || !safeSessionID.MatchString(sessionID) {
 		h := sha256.Sum256([]byte(sessionID))
 		name = fmt.Sprintf("%x", h)
 	}
-	return name + ".lastread"
+	return name
 }
 
 func hashText(text string) string {
@@ -48,6 +48,23 @@ func TestDedupTracker_DifferentSessions(t *testing.T) {
 	}
 }
 
+func TestMessageDedupTracker_SeparateFromSpoken(t *testing.T) {
+	dir := t.TempDir()
+	spoken := NewDedupTracker("session")
+	spoken.dir = dir
+	messages := NewMessageDedupTracker("session")
+	messages.dir = dir
+
+	messages.Record("**Done.**")
+	spoken.Record("Done.")
+	if !messages.IsDuplicate("**Done.**") {
+		t.Error("recording spoken text should keep the message marker")
+	}
+	if spoken.IsDuplicate("**Done.**") {
+		t.Error("the message marker should not count as spoken")
+	}
+}
+
 func TestDedupTracker_OverwritesPrevious(t *testing.T) {
 	dt := NewDedupTracker("test-overwrite")
 	dt.dir = t.TempDir()
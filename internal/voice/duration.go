@@ -0,0 +1,119 @@
+package voice
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrUnknownDuration is returned by AudioDuration for formats whose length
+// cannot be read from a header.
+var ErrUnknownDuration = errors.New("duration unknown for this audio format")
+
+// AudioDuration returns the playing time of a WAV file, computed from its
+// byte rate and data size, or of an MP3 file, summed over its frames. Other
+// formats report ErrUnknownDuration.
+func AudioDuration(path string) (time.Duration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if !isWAV(data) {
+		if d, ok := mp3Duration(data); ok {
+			return d, nil
+		}
+		return 0, ErrUnknownDuration
+	}
+	format, pcm, err := wavParts(data)
+	if err != nil {
+		return 0, err
+	}
+	if len(format) < 12 {
+		return 0, fmt.Errorf("WAV fmt chunk too short")
+	}
+	byteRate := binary.LittleEndian.Uint32(format[8:12])
+	if byteRate == 0 {
+		return 0, fmt.Errorf("WAV byte rate is zero")
+	}
+	return time.Duration(float64(len(pcm)) / float64(byteRate) * float64(time.Second)), nil
+}
+
+// MPEG audio bitrates in kbps by [version is MPEG-1][layer index][bitrate
+// index]; layer index 0 is Layer I.
+var mp3Bitrates = [2][3][15]int{
+	{ // MPEG-2 and 2.5
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+	{ // MPEG-1
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+}
+
+// mp3SampleRates by version bits (0 = MPEG-2.5, 2 = MPEG-2, 3 = MPEG-1).
+var mp3SampleRates = map[byte][3]int{
+	0: {11025, 12000, 8000},
+	2: {22050, 24000, 16000},
+	3: {44100, 48000, 32000},
+}
+
+// mp3Duration sums the samples of every MPEG audio frame, which is exact for
+// constant and variable bitrates alike. It reports false when data holds no
+// frames.
+func mp3Duration(data []byte) (time.Duration, bool) {
+	data = stripID3v2(data)
+	var seconds float64
+	frames := 0
+	for i := 0; i+4 <= len(data); {
+		size, samples, rate := mp3Frame(data[i : i+4])
+		if size == 0 {
+			i++ // resynchronize
+			continue
+		}
+		seconds += float64(samples) / float64(rate)
+		frames++
+		i += size
+	}
+	if frames == 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// mp3Frame decodes an MPEG audio frame header, returning a zero size if the
+// bytes are not one.
+func mp3Frame(h []byte) (size, samples, rate int) {
+	if h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return 0, 0, 0
+	}
+	version := (h[1] >> 3) & 3
+	layerBits := (h[1] >> 1) & 3
+	bitrateIndex := int(h[2] >> 4)
+	rateIndex := int(h[2]>>2) & 3
+	padding := int(h[2]>>1) & 1
+	rates, ok := mp3SampleRates[version]
+	if !ok || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return 0, 0, 0
+	}
+	layer := 3 - int(layerBits) // 0 = Layer I
+	mpeg1 := version == 3
+	v := 0
+	if mpeg1 {
+		v = 1
+	}
+	bitrate := mp3Bitrates[v][layer][bitrateIndex] * 1000
+	rate = rates[rateIndex]
+	switch {
+	case layer == 0:
+		return (12*bitrate/rate + padding) * 4, 384, rate
+	case layer == 2 && !mpeg1:
+		return 72*bitrate/rate + padding, 576, rate
+	default:
+		return 144*bitrate/rate + padding, 1152, rate
+	}
+}
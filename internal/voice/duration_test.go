@@ -0,0 +1,33 @@
+package voice
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAudioDuration(t *testing.T) {
+	// makeWAV writes 48 kHz mono 16-bit audio: 96000 bytes per second.
+	paths := writeFiles(t, makeWAV(make([]byte, 48000)), []byte("ID3\x03fake mp3"))
+
+	got, err := AudioDuration(paths[0])
+	if err != nil || got != 500*time.Millisecond {
+		t.Errorf("AudioDuration(wav) = %v, %v; want 500ms", got, err)
+	}
+	if _, err := AudioDuration(paths[1]); !errors.Is(err, ErrUnknownDuration) {
+		t.Errorf("AudioDuration(mp3) error = %v, want ErrUnknownDuration", err)
+	}
+}
+
+func TestAudioDurationMP3(t *testing.T) {
+	// MPEG-1 Layer III, 128 kbps, 44.1 kHz: 417-byte frames of 1152 samples.
+	frame := append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 413)...)
+	paths := writeFiles(t, append([]byte("ID3\x03\x00\x00\x00\x00\x00\x00"), bytes.Repeat(frame, 100)...))
+
+	// 100 frames * 1152 / 44100 Hz = 2.612s
+	got, err := AudioDuration(paths[0])
+	if err != nil || got.Milliseconds() != 2612 {
+		t.Errorf("AudioDuration(mp3) = %v, %v; want 2.612s", got, err)
+	}
+}
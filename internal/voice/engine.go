@@ -10,48 +10,70 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/daikw/ccpersona/internal/detach"
 	"github.com/rs/zerolog/log"
 )
 
 // VoiceEngine handles voice synthesis
 type VoiceEngine struct {
-	config     *Config
-	httpClient *http.Client
+	config         *Config
+	httpClient     *http.Client
+	voicevoxURL    string
+	aivisSpeechURL string
 }
 
-// NewVoiceEngine creates a new voice engine
+// NewVoiceEngine creates a new voice engine. CCPERSONA_VOICEVOX_URL and
+// CCPERSONA_AIVISSPEECH_URL point the local engines at another address.
 func NewVoiceEngine(config *Config) *VoiceEngine {
 	return &VoiceEngine{
 		config: config,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		voicevoxURL:    engineURL("CCPERSONA_VOICEVOX_URL", VoicevoxURL),
+		aivisSpeechURL: engineURL("CCPERSONA_AIVISSPEECH_URL", AivisSpeechURL),
 	}
 }
 
+func engineURL(envVar, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(envVar)); value != "" {
+		return strings.TrimSuffix(value, "/")
+	}
+	return fallback
+}
+
 // CheckEngines checks which voice engines are available
 func (ve *VoiceEngine) CheckEngines() (voicevoxAvailable, aivisSpeechAvailable bool) {
-	// Check VOICEVOX
-	resp, err := ve.httpClient.Get(VoicevoxURL + "/version")
-	if err == nil && resp.StatusCode == http.StatusOK {
-		voicevoxAvailable = true
-		_ = resp.Body.Close()
+	voicevoxAvailable = checkAvailable("engine "+ve.voicevoxURL, func() bool {
+		return ve.answers(ve.voicevoxURL + "/version")
+	})
+	if voicevoxAvailable {
 		log.Debug().Msg("VOICEVOX ENGINE is available")
 	}
-
-	// Check AivisSpeech
-	resp, err = ve.httpClient.Get(AivisSpeechURL + "/speakers")
-	if err == nil && resp.StatusCode == http.StatusOK {
-		aivisSpeechAvailable = true
-		_ = resp.Body.Close()
+	aivisSpeechAvailable = checkAvailable("engine "+ve.aivisSpeechURL, func() bool {
+		return ve.answers(ve.aivisSpeechURL + "/speakers")
+	})
+	if aivisSpeechAvailable {
 		log.Debug().Msg("AivisSpeech is available")
 	}
-
 	return
 }
 
+// answers reports whether a GET of url succeeds. The body is drained so the
+// connection can be reused.
+func (ve *VoiceEngine) answers(url string) bool {
+	resp, err := ve.httpClient.Get(url)
+	if err != nil {
+		return false
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
 // SelectEngine selects which engine to use based on availability and priority
 func (ve *VoiceEngine) SelectEngine() (string, error) {
 	voicevox, aivisspeech := ve.CheckEngines()
@@ -72,7 +94,7 @@ func (ve *VoiceEngine) SelectEngine() (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("no voice engine available")
+	return "", unavailable("no voice engine available")
 }
 
 // Synthesize generates audio from text
@@ -83,6 +105,7 @@ func (ve *VoiceEngine) Synthesize(text string) (string, error) {
 	}
 
 	log.Info().Str("engine", engine).Msg("Using voice engine")
+	text = NormalizeJapanese(text, ve.config.Japanese)
 	log.Debug().
 		Str("engine", engine).
 		Int("voicevox_speaker", ve.config.VoicevoxSpeaker).
@@ -105,7 +128,8 @@ func (ve *VoiceEngine) Synthesize(text string) (string, error) {
 // synthesizeVoicevox uses VOICEVOX ENGINE for synthesis
 func (ve *VoiceEngine) synthesizeVoicevox(text string) (string, error) {
 	// Create audio query
-	queryURL := fmt.Sprintf("%s/audio_query?speaker=%d", VoicevoxURL, ve.config.VoicevoxSpeaker)
+	speaker := ve.styleID(EngineVoicevox, int64(ve.config.VoicevoxSpeaker))
+	queryURL := fmt.Sprintf("%s/audio_query?speaker=%d", ve.voicevoxURL, speaker)
 	queryURL += "&text=" + url.QueryEscape(text)
 
 	resp, err := ve.httpClient.Post(queryURL, "application/json", nil)
@@ -125,22 +149,10 @@ func (ve *VoiceEngine) synthesizeVoicevox(text string) (string, error) {
 		return "", fmt.Errorf("failed to read query response: %w", err)
 	}
 
-	// Apply volume/speed scale if not default
-	if ve.config.VolumeScale != 1.0 || ve.config.SpeedScale != 1.0 {
-		var query map[string]interface{}
-		if err := json.Unmarshal(queryData, &query); err == nil {
-			if ve.config.VolumeScale != 1.0 {
-				query["volumeScale"] = ve.config.VolumeScale
-			}
-			if ve.config.SpeedScale != 1.0 {
-				query["speedScale"] = ve.config.SpeedScale
-			}
-			queryData, _ = json.Marshal(query)
-		}
-	}
+	queryData = ve.adjustQuery(queryData)
 
 	// Synthesize audio
-	synthURL := fmt.Sprintf("%s/synthesis?speaker=%d", VoicevoxURL, ve.config.VoicevoxSpeaker)
+	synthURL := fmt.Sprintf("%s/synthesis?speaker=%d", ve.voicevoxURL, speaker)
 
 	resp, err = ve.httpClient.Post(synthURL, "application/json", bytes.NewReader(queryData))
 	if err != nil {
@@ -169,13 +181,15 @@ func (ve *VoiceEngine) synthesizeVoicevox(text string) (string, error) {
 		return "", fmt.Errorf("failed to save audio: %w", err)
 	}
 
+	ve.recordMarks(tmpFile.Name(), queryData)
 	return tmpFile.Name(), nil
 }
 
 // synthesizeAivisSpeech uses AivisSpeech for synthesis
 func (ve *VoiceEngine) synthesizeAivisSpeech(text string) (string, error) {
 	// Create audio query (VOICEVOX compatible API)
-	queryURL := fmt.Sprintf("%s/audio_query?speaker=%d", AivisSpeechURL, ve.config.AivisSpeechSpeaker)
+	speaker := ve.styleID(EngineAivisSpeech, ve.config.AivisSpeechSpeaker)
+	queryURL := fmt.Sprintf("%s/audio_query?speaker=%d", ve.aivisSpeechURL, speaker)
 	queryURL += "&text=" + url.QueryEscape(text)
 
 	resp, err := ve.httpClient.Post(queryURL, "application/json", nil)
@@ -196,22 +210,10 @@ func (ve *VoiceEngine) synthesizeAivisSpeech(text string) (string, error) {
 		return "", fmt.Errorf("failed to read query response: %w", err)
 	}
 
-	// Apply volume/speed scale if not default
-	if ve.config.VolumeScale != 1.0 || ve.config.SpeedScale != 1.0 {
-		var query map[string]interface{}
-		if err := json.Unmarshal(queryData, &query); err == nil {
-			if ve.config.VolumeScale != 1.0 {
-				query["volumeScale"] = ve.config.VolumeScale
-			}
-			if ve.config.SpeedScale != 1.0 {
-				query["speedScale"] = ve.config.SpeedScale
-			}
-			queryData, _ = json.Marshal(query)
-		}
-	}
+	queryData = ve.adjustQuery(queryData)
 
 	// Synthesize audio
-	synthURL := fmt.Sprintf("%s/synthesis?speaker=%d", AivisSpeechURL, ve.config.AivisSpeechSpeaker)
+	synthURL := fmt.Sprintf("%s/synthesis?speaker=%d", ve.aivisSpeechURL, speaker)
 
 	resp, err = ve.httpClient.Post(synthURL, "application/json", bytes.NewReader(queryData))
 	if err != nil {
@@ -241,9 +243,64 @@ func (ve *VoiceEngine) synthesizeAivisSpeech(text string) (string, error) {
 		return "", fmt.Errorf("failed to save audio: %w", err)
 	}
 
+	ve.recordMarks(tmpFile.Name(), queryData)
 	return tmpFile.Name(), nil
 }
 
+// adjustQuery applies the volume and speed scales, the prosody, and the
+// Japanese pause length to an audio query. A query that does not parse is
+// sent unchanged.
+func (ve *VoiceEngine) adjustQuery(queryData []byte) []byte {
+	pause := 0.0
+	if ve.config.Japanese.IsEnabled() {
+		pause = ve.config.Japanese.Pause
+	}
+	emotion := ve.config.SpeakerStyle != nil && ve.config.SpeakerStyle.Emotion != nil
+	if ve.config.VolumeScale == 1.0 && ve.config.SpeedScale == 1.0 && pause <= 0 && !ve.config.Prosody.IsSet() && !emotion {
+		return queryData
+	}
+	var query map[string]interface{}
+	if err := json.Unmarshal(queryData, &query); err != nil {
+		return queryData
+	}
+	if ve.config.VolumeScale != 1.0 {
+		query["volumeScale"] = ve.config.VolumeScale
+	}
+	if ve.config.SpeedScale != 1.0 {
+		query["speedScale"] = ve.config.SpeedScale
+	}
+	ve.config.Prosody.apply(query)
+	if emotion {
+		query["intonationScale"] = *ve.config.SpeakerStyle.Emotion
+	}
+	if pause > 0 {
+		// The engines put a pause mora after each 、 and 。.
+		phrases, _ := query["accent_phrases"].([]interface{})
+		for _, p := range phrases {
+			phrase, _ := p.(map[string]interface{})
+			if mora, ok := phrase["pause_mora"].(map[string]interface{}); ok {
+				mora["vowel_length"] = pause
+			}
+		}
+	}
+	adjusted, err := json.Marshal(query)
+	if err != nil {
+		return queryData
+	}
+	return adjusted
+}
+
+// recordMarks keeps the mora timing of a synthesized file for the avatar
+// bridge, when it is configured.
+func (ve *VoiceEngine) recordMarks(audioFile string, queryData []byte) {
+	if ve.config == nil || ve.config.Avatar == nil {
+		return
+	}
+	if marks := moraMarks(queryData); len(marks) > 0 {
+		setMarks(audioFile, marks)
+	}
+}
+
 // Play plays the audio file
 func (ve *VoiceEngine) Play(audioFile string) error {
 	return ve.PlayWithOptions(audioFile, false)
@@ -252,50 +309,166 @@ func (ve *VoiceEngine) Play(audioFile string) error {
 // PlayWithOptions plays the audio file with options
 // If wait is true, blocks until playback completes (useful for hooks)
 func (ve *VoiceEngine) PlayWithOptions(audioFile string, wait bool) error {
-	// Detect the platform and use appropriate player
-	var cmd *exec.Cmd
-
-	switch {
-	case isCommandAvailable("afplay"):
-		// macOS
-		cmd = exec.Command("afplay", audioFile)
-	case isCommandAvailable("aplay"):
-		// Linux with ALSA
-		cmd = exec.Command("aplay", audioFile)
-	case isCommandAvailable("paplay"):
-		// Linux with PulseAudio
-		cmd = exec.Command("paplay", audioFile)
-	case isCommandAvailable("ffplay"):
-		// Cross-platform with ffmpeg
-		cmd = exec.Command("ffplay", "-nodisp", "-autoexit", audioFile)
-	default:
-		return fmt.Errorf("no audio player found")
+	// Audio requested before the latest skip is dropped unplayed.
+	since := requestedAt(audioFile)
+	if skipped(since) {
+		log.Debug().Str("file", audioFile).Msg("Dropping audio requested before a skip")
+		takeSpeech(audioFile)
+		_ = os.Remove(audioFile)
+		return nil
+	}
+
+	// Captions and avatar events last exactly as long as the audio plays.
+	s := takeSpeech(audioFile)
+	s.start(audioFile)
+
+	if play := currentPlayer(); play != nil {
+		defer os.Remove(audioFile)
+		defer s.end()
+		return play(audioFile)
+	}
+
+	cmds, err := ve.playerCommands(audioFile)
+	if err != nil {
+		s.end()
+		return err
+	}
+
+	if err := startAll(cmds); err != nil {
+		s.end()
+		return fmt.Errorf("failed to play audio: %w", err)
 	}
 
 	if wait {
 		// For hooks: wait for playback to complete, then clean up
-		if err := cmd.Run(); err != nil {
+		_, err := waitOrSkip(cmds, since)
+		s.end()
+		if err != nil {
 			return fmt.Errorf("failed to play audio: %w", err)
 		}
 		_ = os.Remove(audioFile)
 		return nil
 	}
 
-	// For interactive use: start in background
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to play audio: %w", err)
-	}
-
 	// Remove the file once playback finishes. If the process outlives this
 	// goroutine (e.g. on exit), the temp file is left to the OS, same as before.
 	go func() {
-		_ = cmd.Wait()
+		_, _ = waitOrSkip(cmds, since)
+		s.end()
 		_ = os.Remove(audioFile)
 	}()
 
 	return nil
 }
 
+// PlayFile plays an audio file the caller owns, such as a notification sound,
+// and blocks until playback completes. Unlike PlayWithOptions it never deletes
+// the file.
+func (ve *VoiceEngine) PlayFile(path string) error {
+	if play := currentPlayer(); play != nil {
+		return play(path)
+	}
+
+	cmd, err := playerCommand(path)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to play audio: %w", err)
+	}
+	return nil
+}
+
+// StartFile starts playing an audio file the caller owns, like PlayFile,
+// without waiting for it: the external player is detached so the sound
+// finishes even when the hook exits first. An in-process player still plays
+// it to completion.
+func (ve *VoiceEngine) StartFile(path string) error {
+	if play := currentPlayer(); play != nil {
+		return play(path)
+	}
+
+	cmd, err := playerCommand(path)
+	if err != nil {
+		return err
+	}
+	if err := detach.Start(cmd); err != nil {
+		return fmt.Errorf("failed to play audio: %w", err)
+	}
+	return nil
+}
+
+// Player plays an audio file to completion.
+type Player func(audioFile string) error
+
+var (
+	playerMu       sync.Mutex
+	playerOverride Player
+)
+
+// SetPlayer routes all playback in this process through p instead of an
+// external audio player, until the returned function restores the previous
+// player. Tests use it to run the full pipeline without a sound device.
+func SetPlayer(p Player) (restore func()) {
+	playerMu.Lock()
+	previous := playerOverride
+	playerOverride = p
+	playerMu.Unlock()
+	return func() {
+		playerMu.Lock()
+		playerOverride = previous
+		playerMu.Unlock()
+	}
+}
+
+// currentPlayer returns the SetPlayer override, else the recording player
+// in test mode, else nil for the external player.
+func currentPlayer() Player {
+	playerMu.Lock()
+	defer playerMu.Unlock()
+	if playerOverride == nil && TestMode() {
+		return testPlayer
+	}
+	return playerOverride
+}
+
+// audioPlayers are tried in order: afplay (macOS), aplay (ALSA), paplay
+// (PulseAudio), and ffplay (cross-platform with ffmpeg).
+var audioPlayers = []struct {
+	name string
+	args []string
+}{
+	{"afplay", nil},
+	{"aplay", nil},
+	{"paplay", nil},
+	{"ffplay", []string{"-nodisp", "-autoexit"}},
+}
+
+// playerCommand picks the first available audio player for this platform.
+func playerCommand(audioFile string) (*exec.Cmd, error) {
+	for _, p := range audioPlayers {
+		if isCommandAvailable(p.name) {
+			return exec.Command(p.name, append(p.args, audioFile)...), nil
+		}
+	}
+	return nil, fmt.Errorf("no audio player found")
+}
+
+// PlayerName returns the player PlayFile would use: "in-process" while
+// SetPlayer is in effect, otherwise the external command, or "" when no
+// player is installed.
+func PlayerName() string {
+	if currentPlayer() != nil {
+		return "in-process"
+	}
+	for _, p := range audioPlayers {
+		if isCommandAvailable(p.name) {
+			return p.name
+		}
+	}
+	return ""
+}
+
 // isCommandAvailable checks if a command is available
 func isCommandAvailable(cmd string) bool {
 	_, err := exec.LookPath(cmd)
@@ -1,7 +1,13 @@
 package voice
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -102,6 +108,73 @@ func TestNewVoiceEngine(t *testing.T) {
 	})
 }
 
+func TestNewVoiceEngine_URLOverrides(t *testing.T) {
+	t.Setenv("CCPERSONA_VOICEVOX_URL", "http://voicevox.lan:50021/")
+	t.Setenv("CCPERSONA_AIVISSPEECH_URL", "")
+
+	engine := NewVoiceEngine(DefaultConfig())
+	assert.Equal(t, "http://voicevox.lan:50021", engine.voicevoxURL)
+	assert.Equal(t, AivisSpeechURL, engine.aivisSpeechURL)
+}
+
+func TestCheckEngines_CachesAvailability(t *testing.T) {
+	var hits atomic.Int32
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("CCPERSONA_VOICEVOX_URL", server.URL)
+	t.Setenv("CCPERSONA_AIVISSPEECH_URL", "http://127.0.0.1:1")
+
+	engine := NewVoiceEngine(DefaultConfig())
+	engine.CheckEngines()
+	engine.CheckEngines()
+	assert.Equal(t, int32(2), hits.Load(), "without the cache every call checks")
+
+	CacheAvailability(time.Minute)
+	defer CacheAvailability(0)
+	hits.Store(0)
+	voicevox, aivis := engine.CheckEngines()
+	assert.True(t, voicevox)
+	assert.False(t, aivis)
+	down.Store(true)
+	voicevox, _ = engine.CheckEngines()
+	assert.True(t, voicevox, "a cached answer is trusted")
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestSetPlayer(t *testing.T) {
+	var played []string
+	restore := SetPlayer(func(path string) error {
+		played = append(played, filepath.Base(path))
+		return nil
+	})
+	defer restore()
+
+	dir := t.TempDir()
+	owned := filepath.Join(dir, "owned.wav")
+	temp := filepath.Join(dir, "temp.wav")
+	for _, path := range []string{owned, temp} {
+		assert.NoError(t, os.WriteFile(path, []byte("RIFF"), 0600))
+	}
+
+	engine := NewVoiceEngine(DefaultConfig())
+	assert.NoError(t, engine.PlayFile(owned))
+	assert.NoError(t, engine.StartFile(owned))
+	assert.NoError(t, engine.PlayWithOptions(temp, false))
+	assert.Equal(t, []string{"owned.wav", "owned.wav", "temp.wav"}, played)
+
+	assert.FileExists(t, owned, "PlayFile and StartFile must keep caller-owned files")
+	assert.NoFileExists(t, temp, "PlayWithOptions removes synthesized files")
+
+	restore()
+	assert.Nil(t, currentPlayer())
+}
+
 func TestVoiceEngine_SelectEngine(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping test that connects to local voice engines")
@@ -0,0 +1,44 @@
+package voice
+
+import (
+	"os"
+	"strings"
+)
+
+// Environment variables that override configuration for the current process.
+const (
+	// EnvProvider forces the TTS provider, below an explicit --provider flag.
+	EnvProvider = "CCPERSONA_PROVIDER"
+	// EnvMute forces the mute gate on ("1", "true", "yes", "on") or off
+	// ("0", "false", "no", "off"), taking precedence over the mute marker.
+	EnvMute = "CCPERSONA_MUTE"
+	// EnvTestMode replaces synthesis and playback with null implementations
+	// that record what would have been spoken (see TestMode).
+	EnvTestMode = "CCPERSONA_TEST_MODE"
+	// EnvTestRecord is the file test mode appends its records to.
+	EnvTestRecord = "CCPERSONA_TEST_RECORD"
+)
+
+// ProviderOverride returns the provider forced by CCPERSONA_PROVIDER, or "".
+func ProviderOverride() string {
+	return strings.ToLower(strings.TrimSpace(os.Getenv(EnvProvider)))
+}
+
+// MuteOverride reports the mute state forced by CCPERSONA_MUTE. ok is false
+// when the variable is unset or not a recognized boolean.
+func MuteOverride() (muted bool, ok bool) {
+	return envBool(EnvMute)
+}
+
+// envBool parses a boolean environment variable. ok is false when it is
+// unset or not a recognized boolean.
+func envBool(name string) (value bool, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(name))) {
+	case "1", "true", "yes", "on":
+		return true, true
+	case "0", "false", "no", "off":
+		return false, true
+	default:
+		return false, false
+	}
+}
@@ -0,0 +1,62 @@
+package voice
+
+import "testing"
+
+func TestMuteOverride(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantMuted bool
+		wantOK    bool
+	}{
+		{"", false, false},
+		{"1", true, true},
+		{"TRUE", true, true},
+		{"on", true, true},
+		{"0", false, true},
+		{"no", false, true},
+		{"maybe", false, false},
+	}
+	for _, tt := range tests {
+		t.Setenv(EnvMute, tt.value)
+		muted, ok := MuteOverride()
+		if muted != tt.wantMuted || ok != tt.wantOK {
+			t.Errorf("MuteOverride() with %q = (%v, %v), want (%v, %v)", tt.value, muted, ok, tt.wantMuted, tt.wantOK)
+		}
+	}
+}
+
+func TestIsMuted_EnvOverridesMarker(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	t.Setenv(EnvMute, "1")
+	if !IsMuted() {
+		t.Error("CCPERSONA_MUTE=1 should mute without a marker")
+	}
+
+	if _, err := Mute("marker"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvMute, "0")
+	if IsMuted() {
+		t.Error("CCPERSONA_MUTE=0 should override the mute marker")
+	}
+
+	t.Setenv(EnvMute, "")
+	if !IsMuted() {
+		t.Error("marker should apply when CCPERSONA_MUTE is unset")
+	}
+}
+
+func TestResolve_EnvProvider(t *testing.T) {
+	t.Setenv(EnvProvider, " OpenAI ")
+
+	opts := Resolve(PersonaVoiceInput{Provider: "voicevox"}, nil, "")
+	if opts.Provider != "openai" {
+		t.Errorf("env provider should override persona, got %q", opts.Provider)
+	}
+
+	opts = Resolve(PersonaVoiceInput{Provider: "voicevox"}, nil, "polly")
+	if opts.Provider != "polly" {
+		t.Errorf("CLI provider should override env, got %q", opts.Provider)
+	}
+}
@@ -0,0 +1,22 @@
+package voice
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrProviderUnavailable matches errors for a voice provider or engine that
+// cannot be used at all: not running, not reachable, or missing
+// credentials. Failures of a reachable provider do not match.
+var ErrProviderUnavailable = errors.New("voice provider unavailable")
+
+// unavailableError keeps the original message while matching
+// ErrProviderUnavailable.
+type unavailableError struct{ err error }
+
+func (e *unavailableError) Error() string   { return e.err.Error() }
+func (e *unavailableError) Unwrap() []error { return []error{e.err, ErrProviderUnavailable} }
+
+func unavailable(format string, args ...any) error {
+	return &unavailableError{err: fmt.Errorf(format, args...)}
+}
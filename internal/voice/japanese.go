@@ -0,0 +1,127 @@
+package voice
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// JapaneseOptions normalizes text for the Japanese engines, VOICEVOX and
+// AivisSpeech, before synthesis. Captions and subtitles keep the original
+// text.
+type JapaneseOptions struct {
+	Enabled bool `json:"enabled"`
+	// Symbols reads ASCII symbols such as & and % as words (default true).
+	Symbols *bool `json:"symbols,omitempty"`
+	// Pause is the length in seconds of the pauses at 、 and 。 (0 = engine
+	// default).
+	Pause float64 `json:"pause,omitempty"`
+	// Readings replace words before synthesis, such as project jargon the
+	// engine misreads: {"k8s": "クバネティス", "冪等": "べきとう"}. Longer
+	// words win, and matching is case-sensitive.
+	Readings map[string]string `json:"readings,omitempty"`
+}
+
+// IsEnabled reports whether normalization applies; safe on nil.
+func (j *JapaneseOptions) IsEnabled() bool {
+	return j != nil && j.Enabled
+}
+
+func (j *JapaneseOptions) symbolsEnabled() bool {
+	return j.Symbols == nil || *j.Symbols
+}
+
+// spokenSymbols are the spoken forms of ASCII symbols. Symbols the engines
+// already read, or that mark up text rather than stand for a word, are left
+// alone.
+var spokenSymbols = strings.NewReplacer(
+	"&", "アンド",
+	"%", "パーセント",
+	"+", "プラス",
+	"=", "イコール",
+	"@", "アット",
+	"#", "シャープ",
+)
+
+var (
+	// numberRange matches ranges like 1~3 and 10〜20.
+	numberRange = regexp.MustCompile(`(\d)\s*[~〜]\s*(\d)`)
+	// asciiComma and asciiPeriod match ASCII punctuation after Japanese
+	// text, which the engines read without a pause.
+	asciiComma  = regexp.MustCompile(`([\p{Han}\p{Hiragana}\p{Katakana}ー])\s*,\s*`)
+	asciiPeriod = regexp.MustCompile(`([\p{Han}\p{Hiragana}\p{Katakana}ー])\s*\.(\s+|$)`)
+)
+
+// NormalizeJapanese rewrites text for the Japanese engines: full-width
+// letters, digits, and symbols become half-width and half-width katakana
+// full-width; reading dictionary entries are applied; symbols are spelled
+// out; and commas, periods, and line breaks after Japanese text become 、
+// and 。 so the engines pause there. Text is returned unchanged when
+// normalization is off.
+func NormalizeJapanese(text string, opts *JapaneseOptions) string {
+	if !opts.IsEnabled() {
+		return text
+	}
+	// Fold maps half-width dakuten to combining marks; NFC joins them with
+	// the kana again, so ｶﾞ becomes ガ.
+	text = norm.NFC.String(width.Fold.String(text))
+	text = applyReadings(text, opts.Readings)
+	if opts.symbolsEnabled() {
+		text = numberRange.ReplaceAllString(text, "${1}から${2}")
+		text = spokenSymbols.Replace(text)
+	}
+	text = asciiComma.ReplaceAllString(text, "${1}、")
+	text = asciiPeriod.ReplaceAllString(text, "${1}。")
+	return joinLines(text)
+}
+
+// applyReadings replaces dictionary words, longest first.
+func applyReadings(text string, readings map[string]string) string {
+	if len(readings) == 0 {
+		return text
+	}
+	words := make([]string, 0, len(readings))
+	for word := range readings {
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	// strings.Replacer tries the pairs in order at each position.
+	sort.Slice(words, func(i, j int) bool {
+		if len(words[i]) != len(words[j]) {
+			return len(words[i]) > len(words[j])
+		}
+		return words[i] < words[j]
+	})
+	pairs := make([]string, 0, 2*len(words))
+	for _, word := range words {
+		// Entries are folded like the text, so a full-width key still matches.
+		pairs = append(pairs, norm.NFC.String(width.Fold.String(word)), readings[word])
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// joinLines joins lines with 。 where a line ends without punctuation, so
+// each line is read as its own sentence.
+func joinLines(text string) string {
+	if !strings.Contains(text, "\n") {
+		return text
+	}
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		last := []rune(line)[len([]rune(line))-1]
+		if !unicode.IsPunct(last) {
+			line += "。"
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "")
+}
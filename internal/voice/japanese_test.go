@@ -0,0 +1,65 @@
+package voice
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNormalizeJapanese(t *testing.T) {
+	off := false
+	tests := []struct {
+		name string
+		opts *JapaneseOptions
+		in   string
+		want string
+	}{
+		{"disabled", nil, "ＡＢＣ＆ｶﾞ", "ＡＢＣ＆ｶﾞ"},
+		{"width", &JapaneseOptions{Enabled: true}, "ＡＰＩは１２３件ｶﾞｷﾞｸﾞ", "APIは123件ガギグ"},
+		{"symbols", &JapaneseOptions{Enabled: true}, "R&D は 50% 完了、C++ と a=b", "RアンドD は 50パーセント 完了、Cプラスプラス と aイコールb"},
+		{"range", &JapaneseOptions{Enabled: true}, "1〜3件と10 ~ 20件", "1から3件と10から20件"},
+		{"symbols off", &JapaneseOptions{Enabled: true, Symbols: &off}, "R＆D", "R&D"},
+		{"punctuation", &JapaneseOptions{Enabled: true}, "完了しました, 次へ. v1.2 は OK", "完了しました、次へ。v1.2 は OK"},
+		{"lines", &JapaneseOptions{Enabled: true}, "ビルド成功\n\nテスト完了！\n次へ", "ビルド成功。テスト完了!次へ。"},
+		{"readings", &JapaneseOptions{Enabled: true, Readings: map[string]string{
+			"k8s": "クバネティス", "k8s-operator": "オペレーター", "冪等": "べきとう", "ＣＩ": "シーアイ",
+		}}, "k8s と k8s-operator は冪等、CIも", "クバネティス と オペレーター はべきとう、シーアイも"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeJapanese(tt.in, tt.opts); got != tt.want {
+				t.Errorf("NormalizeJapanese(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdjustQueryPause(t *testing.T) {
+	query := `{"accent_phrases":[{"moras":[],"pause_mora":{"vowel_length":0.3}},{"moras":[],"pause_mora":null}],"speedScale":1}`
+
+	ve := NewVoiceEngine(&Config{VolumeScale: 1, SpeedScale: 1})
+	if got := string(ve.adjustQuery([]byte(query))); got != query {
+		t.Errorf("query without adjustments should be unchanged, got %s", got)
+	}
+
+	ve = NewVoiceEngine(&Config{VolumeScale: 1, SpeedScale: 1.2, Japanese: &JapaneseOptions{Enabled: true, Pause: 0.6}})
+	var got struct {
+		AccentPhrases []struct {
+			PauseMora *struct {
+				VowelLength float64 `json:"vowel_length"`
+			} `json:"pause_mora"`
+		} `json:"accent_phrases"`
+		SpeedScale float64 `json:"speedScale"`
+	}
+	if err := json.Unmarshal(ve.adjustQuery([]byte(query)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.SpeedScale != 1.2 {
+		t.Errorf("speedScale = %v, want 1.2", got.SpeedScale)
+	}
+	if got.AccentPhrases[0].PauseMora == nil || got.AccentPhrases[0].PauseMora.VowelLength != 0.6 {
+		t.Errorf("pause mora = %+v, want 0.6", got.AccentPhrases[0].PauseMora)
+	}
+	if got.AccentPhrases[1].PauseMora != nil {
+		t.Error("phrases without a pause should not get one")
+	}
+}
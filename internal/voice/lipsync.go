@@ -0,0 +1,125 @@
+package voice
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/avatar"
+	"github.com/daikw/ccpersona/internal/voice/provider"
+)
+
+// voicevoxQuery is the part of a VOICEVOX/AivisSpeech audio query that
+// carries phoneme timing. Lengths are in seconds at speed 1.
+type voicevoxQuery struct {
+	AccentPhrases []struct {
+		Moras     []voicevoxMora `json:"moras"`
+		PauseMora *voicevoxMora  `json:"pause_mora"`
+	} `json:"accent_phrases"`
+	SpeedScale       float64 `json:"speedScale"`
+	PrePhonemeLength float64 `json:"prePhonemeLength"`
+}
+
+type voicevoxMora struct {
+	Text            string   `json:"text"`
+	Consonant       *string  `json:"consonant"`
+	ConsonantLength *float64 `json:"consonant_length"`
+	Vowel           string   `json:"vowel"`
+	VowelLength     float64  `json:"vowel_length"`
+}
+
+// moraMarks converts the moras of an audio query into viseme marks, one per
+// mora, timed from the start of the audio. The mouth shape of a mora is its
+// vowel's, held across the consonant too.
+func moraMarks(queryData []byte) []avatar.Mark {
+	var query voicevoxQuery
+	if err := json.Unmarshal(queryData, &query); err != nil {
+		return nil
+	}
+	speed := query.SpeedScale
+	if speed <= 0 {
+		speed = 1
+	}
+	seconds := func(s float64) time.Duration {
+		return time.Duration(s / speed * float64(time.Second))
+	}
+
+	var marks []avatar.Mark
+	at := seconds(query.PrePhonemeLength)
+	add := func(mora voicevoxMora) {
+		length := seconds(mora.VowelLength)
+		if mora.ConsonantLength != nil {
+			length += seconds(*mora.ConsonantLength)
+		}
+		marks = append(marks, avatar.Mark{
+			TimeMS:     at.Milliseconds(),
+			DurationMS: length.Milliseconds(),
+			Type:       avatar.MarkViseme,
+			Value:      avatar.VowelViseme(mora.Vowel),
+			Phoneme:    mora.Text,
+		})
+		at += length
+	}
+	for _, phrase := range query.AccentPhrases {
+		for _, mora := range phrase.Moras {
+			add(mora)
+		}
+		if phrase.PauseMora != nil {
+			add(*phrase.PauseMora)
+		}
+	}
+	return marks
+}
+
+// providerMarks converts the speech marks of a cloud provider. A viseme
+// lasts until the next one starts.
+func providerMarks(marks []provider.SpeechMark) []avatar.Mark {
+	converted := make([]avatar.Mark, 0, len(marks))
+	for i, mark := range marks {
+		m := avatar.Mark{TimeMS: mark.Time.Milliseconds(), Type: mark.Type, Value: mark.Value}
+		if mark.Type == provider.MarkViseme {
+			m.Value = avatar.PollyViseme(mark.Value)
+			m.Phoneme = mark.Value
+			for _, next := range marks[i+1:] {
+				if next.Type == provider.MarkViseme {
+					m.DurationMS = (next.Time - mark.Time).Milliseconds()
+					break
+				}
+			}
+		}
+		converted = append(converted, m)
+	}
+	return converted
+}
+
+// offsetMarks returns marks shifted later by offset.
+func offsetMarks(marks []avatar.Mark, offset time.Duration) []avatar.Mark {
+	shifted := make([]avatar.Mark, len(marks))
+	for i, mark := range marks {
+		mark.TimeMS += offset.Milliseconds()
+		shifted[i] = mark
+	}
+	return shifted
+}
+
+// joinMarks takes the marks recorded for chunk files and times them within
+// the joined audio. Without a duration for every chunk the timing is lost.
+func joinMarks(paths []string) []avatar.Mark {
+	var joined []avatar.Mark
+	var offset time.Duration
+	known := true
+	for _, path := range paths {
+		marks := takeMarks(path)
+		if !known {
+			continue
+		}
+		joined = append(joined, offsetMarks(marks, offset)...)
+		d, err := AudioDuration(path)
+		if err != nil {
+			known = false
+			joined = nil
+			continue
+		}
+		offset += d
+	}
+	return joined
+}
@@ -0,0 +1,82 @@
+package voice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/avatar"
+	"github.com/daikw/ccpersona/internal/voice/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleQuery = `{
+  "accent_phrases": [
+    {
+      "moras": [
+        {"text": "コ", "consonant": "k", "consonant_length": 0.05, "vowel": "o", "vowel_length": 0.1, "pitch": 5.5},
+        {"text": "ン", "consonant": null, "consonant_length": null, "vowel": "N", "vowel_length": 0.1, "pitch": 5.6}
+      ],
+      "accent": 1,
+      "pause_mora": {"text": "、", "consonant": null, "consonant_length": null, "vowel": "pau", "vowel_length": 0.2, "pitch": 0}
+    },
+    {
+      "moras": [
+        {"text": "ワ", "consonant": "w", "consonant_length": 0.05, "vowel": "a", "vowel_length": 0.15, "pitch": 5.8}
+      ],
+      "accent": 1,
+      "pause_mora": null
+    }
+  ],
+  "speedScale": 1.0,
+  "prePhonemeLength": 0.1,
+  "postPhonemeLength": 0.1
+}`
+
+func TestMoraMarks(t *testing.T) {
+	marks := moraMarks([]byte(sampleQuery))
+	require.Len(t, marks, 4)
+
+	assert.Equal(t, avatar.Mark{TimeMS: 100, DurationMS: 150, Type: avatar.MarkViseme, Value: avatar.VisemeO, Phoneme: "コ"}, marks[0])
+	assert.Equal(t, int64(250), marks[1].TimeMS)
+	assert.Equal(t, avatar.VisemeClosed, marks[1].Value)
+	assert.Equal(t, avatar.VisemeSilence, marks[2].Value, "pause")
+	assert.Equal(t, int64(550), marks[3].TimeMS)
+	assert.Equal(t, avatar.VisemeA, marks[3].Value)
+}
+
+func TestMoraMarksSpeed(t *testing.T) {
+	query := `{"accent_phrases":[{"moras":[{"text":"ア","vowel":"a","vowel_length":0.2}]}],"speedScale":2.0,"prePhonemeLength":0.1}`
+	marks := moraMarks([]byte(query))
+	require.Len(t, marks, 1)
+	assert.Equal(t, int64(50), marks[0].TimeMS)
+	assert.Equal(t, int64(100), marks[0].DurationMS)
+
+	assert.Nil(t, moraMarks([]byte("not json")))
+}
+
+func TestJoinMarks(t *testing.T) {
+	// 9600 bytes at 96000 bytes/s is 100ms.
+	paths := writeFiles(t, makeWAV(make([]byte, 9600)), makeWAV(make([]byte, 9600)))
+	setMarks(paths[0], []avatar.Mark{{TimeMS: 10, Type: avatar.MarkViseme, Value: avatar.VisemeA}})
+	setMarks(paths[1], []avatar.Mark{{TimeMS: 20, Type: avatar.MarkViseme, Value: avatar.VisemeI}})
+
+	marks := joinMarks(paths)
+	require.Len(t, marks, 2)
+	assert.Equal(t, int64(10), marks[0].TimeMS)
+	assert.Equal(t, int64(120), marks[1].TimeMS)
+	assert.Empty(t, takeMarks(paths[0]), "chunk marks are consumed")
+}
+
+func TestProviderMarks(t *testing.T) {
+	marks := providerMarks([]provider.SpeechMark{
+		{Time: 0, Type: provider.MarkWord, Value: "Hello", End: 5},
+		{Time: 10 * time.Millisecond, Type: provider.MarkViseme, Value: "k"},
+		{Time: 90 * time.Millisecond, Type: provider.MarkViseme, Value: "o"},
+	})
+	require.Len(t, marks, 3)
+	assert.Equal(t, avatar.Mark{Type: avatar.MarkWord, Value: "Hello"}, marks[0])
+	assert.Equal(t, avatar.Mark{TimeMS: 10, DurationMS: 80, Type: avatar.MarkViseme, Value: avatar.VisemeI, Phoneme: "k"}, marks[1])
+	assert.Equal(t, avatar.VisemeO, marks[2].Value)
+	assert.Zero(t, marks[2].DurationMS, "last viseme has no known end")
+}
@@ -2,6 +2,7 @@ package voice
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +11,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/daikw/ccpersona/internal/analytics"
+	"github.com/daikw/ccpersona/internal/tracing"
 	"github.com/daikw/ccpersona/internal/voice/provider"
 	"github.com/rs/zerolog/log"
 )
@@ -69,19 +72,59 @@ type VoiceOptions struct {
 	Style           float64
 	UseSpeakerBoost bool
 
-	// Local engine speaker override (0 = use Config default)
+	// Local engine speaker override (0 = use Config default, unless
+	// SpeakerSet selects speaker 0 of the Provider's engine)
 	VoicevoxSpeaker    int
 	AivisSpeechSpeaker int
+	SpeakerSet         bool
 
 	// Amazon Polly-specific options
 	Region     string
 	Engine     string
 	SampleRate string
 
-	// Output options
+	// Voice cloning (XTTS): managed reference name or WAV path, and language
+	ReferenceWAV string
+	Language     string
+
+	// SSML sends Azure text starting with <speak as SSML instead of reading
+	// it out.
+	SSML bool
+
+	// Long-text chunking: ChunkChars lowers the provider's per-request limit
+	// (0 = provider default); ParallelChunks synthesizes chunks concurrently.
+	ChunkChars     int
+	ParallelChunks int
+
+	// SpeedRamp speeds up long text progressively (nil = constant speed).
+	SpeedRamp *SpeedRamp
+
+	// Prosody shapes VOICEVOX and AivisSpeech speech (nil = engine defaults).
+	Prosody *Prosody
+
+	// SpeakerStyle picks a style of the VOICEVOX or AivisSpeech speaker;
+	// SpeakerStyles override it per notification urgency (see ForUrgency).
+	SpeakerStyle  *SpeakerStyle
+	SpeakerStyles map[string]*SpeakerStyle
+
+	// Subtitles writes an srt or vtt file next to OutputPath ("" = none).
+	Subtitles string
+
+	// Auto holds the candidates when Provider is "auto"; Synthesize picks
+	// one per message.
+	Auto *AutoSelection
+
+	// Output options. Any combination may be set: audio written to stdout
+	// is also saved to OutputPath and played when those are set too.
 	OutputPath string
 	PlayAudio  bool
 	ToStdout   bool
+	// Recent also saves the audio to the recent audio directory (nil = the
+	// config's voice.recent).
+	Recent *RecentConfig
+
+	// cache stores the audio after a cache miss (nil = not cached).
+	cache *cacheSlot
 }
 
 // ListVoices lists available voices for all providers
@@ -130,50 +173,161 @@ func (vm *VoiceManager) ListVoices(ctx context.Context, providerName string) ([]
 		return allVoices, nil
 	}
 
-	// Handle local engines
+	// Handle local engines: one voice per speaker style
 	if providerName == "voicevox" || providerName == "aivisspeech" {
-		voicevoxAvail, aivisAvail := vm.legacyEngine.CheckEngines()
-		var voices []provider.Voice
-
-		if providerName == "voicevox" && voicevoxAvail {
-			voices = append(voices, provider.Voice{
-				ID:          "voicevox",
-				Name:        "VOICEVOX Local Engine",
-				Language:    "ja",
-				Description: "Local VOICEVOX engine with configurable speakers",
-			})
-		}
-
-		if providerName == "aivisspeech" && aivisAvail {
-			voices = append(voices, provider.Voice{
-				ID:          "aivisspeech",
-				Name:        "AivisSpeech Local Engine",
-				Language:    "ja",
-				Description: "Local AivisSpeech engine with configurable speakers",
-			})
+		speakers, err := vm.legacyEngine.Speakers(providerName)
+		if err != nil {
+			return nil, err
 		}
-
-		return voices, nil
+		return speakerVoices(speakers), nil
 	}
 
 	// Handle cloud providers
 	prov, err := vm.providerFactory.GetProviderWithDefaults(providerName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create provider %s: %w", providerName, err)
+		return nil, unavailable("failed to create provider %s: %w", providerName, err)
 	}
 
 	if !prov.IsAvailable(ctx) {
-		return nil, fmt.Errorf("provider %s is not available", providerName)
+		return nil, unavailable("provider %s is not available", providerName)
 	}
 
 	return prov.ListVoices(ctx)
 }
 
-// Synthesize generates audio using the specified provider
+// Synthesize generates audio using the specified provider. With a caption
+// or avatar bridge configured, the text is shown there while the returned
+// file plays.
 func (vm *VoiceManager) Synthesize(ctx context.Context, text string, options VoiceOptions) (string, error) {
+	ctx, span := tracing.Start(ctx, "voice.synthesize")
+	defer span.End()
+	if options.Provider != "" {
+		span.Set("ccpersona.provider", options.Provider)
+	}
+	span.Set("ccpersona.text_length", len([]rune(text)))
+	if options.Recent == nil && vm.config != nil {
+		options.Recent = vm.config.Recent
+	}
+	start := time.Now()
+	audioFile, used, err := vm.synthesizeAuto(ctx, text, options)
+	if errors.Is(err, provider.ErrTextRejected) && ctx.Err() == nil {
+		// Speak less rather than nothing; captions show what was spoken.
+		audioFile, used, text, err = vm.synthesizeShorter(ctx, text, options, err)
+	}
+	span.Fail(err)
+	if err == nil && TestMode() {
+		recordTestSynthesis(text, audioFile, used)
+	}
+	if err != nil || audioFile == "" {
+		return audioFile, err
+	}
+	if options.OutputPath == "" {
+		// Date the temporary file to the request, so a skip during synthesis
+		// also drops it.
+		_ = os.Chtimes(audioFile, start, start)
+	}
+	marks := takeMarks(audioFile)
+	if options.Subtitles != "" && options.OutputPath != "" {
+		path, err := writeSubtitles(audioFile, text, marks, options.Subtitles)
+		if err != nil {
+			return audioFile, fmt.Errorf("failed to write subtitles: %w", err)
+		}
+		log.Debug().Str("path", path).Msg("Subtitles saved")
+	}
+	if vm.config != nil && (vm.config.Caption != nil || vm.config.Avatar != nil) {
+		setSpeech(audioFile, &speech{
+			persona: vm.config.Persona,
+			text:    text,
+			caption: vm.config.Caption,
+			avatar:  vm.config.Avatar,
+			marks:   marks,
+		})
+	}
+	return audioFile, nil
+}
+
+// synthesizeAuto synthesizes with the configured provider, or with provider
+// "auto" tries the candidates in ranked order until one succeeds. Each
+// attempt's latency and outcome feed the ranking. It also returns the
+// options that were used.
+func (vm *VoiceManager) synthesizeAuto(ctx context.Context, text string, options VoiceOptions) (string, VoiceOptions, error) {
+	if options.Provider != ProviderAuto || options.Auto == nil {
+		audioFile, err := vm.synthesizeMeasured(ctx, text, options, DefaultAutoLongChars)
+		return audioFile, options, err
+	}
+	ranked := options.Auto.Rank(text, LoadProviderStats(), time.Now())
+	var errs []error
+	for i, choice := range ranked {
+		log.Info().
+			Str("provider", choice.Options.Provider).
+			Str("reason", choice.Reason).
+			Int("rank", i+1).
+			Int("text_length", len([]rune(text))).
+			Msg("Auto provider selected")
+		opts := choice.Options
+		opts.OutputPath, opts.PlayAudio, opts.ToStdout, opts.Recent, opts.Subtitles = options.OutputPath, options.PlayAudio, options.ToStdout, options.Recent, options.Subtitles
+		audioFile, err := vm.synthesizeMeasured(ctx, text, opts, options.Auto.LongChars)
+		// Audio already streamed to stdout cannot be retried.
+		if err == nil || ctx.Err() != nil || options.ToStdout {
+			return audioFile, opts, err
+		}
+		log.Warn().Err(err).Str("provider", opts.Provider).Msg("Auto provider failed, trying the next")
+		errs = append(errs, fmt.Errorf("%s: %w", opts.Provider, err))
+	}
+	if len(errs) == 0 {
+		return "", options, fmt.Errorf("voice.auto has no providers")
+	}
+	return "", options, fmt.Errorf("every auto provider failed: %w", errors.Join(errs...))
+}
+
+// synthesizeMeasured synthesizes and records the provider's latency and
+// outcome. Latency is only sampled for text shorter than long.
+func (vm *VoiceManager) synthesizeMeasured(ctx context.Context, text string, options VoiceOptions, long int) (string, error) {
+	// A cache hit never reaches the provider, so it is not measured.
+	if slot := vm.cacheSlot(text, options); slot != nil {
+		if audioFile, ok := slot.use(options); ok {
+			return audioFile, nil
+		}
+		options.cache = slot
+	}
+	start := time.Now()
+	audioFile, err := vm.synthesize(ctx, text, options)
+	if options.Provider == "" || options.Provider == ProviderAuto || TestMode() || ctx.Err() != nil {
+		return audioFile, err
+	}
+	if long <= 0 {
+		long = DefaultAutoLongChars
+	}
+	if recErr := recordProviderStat(options.Provider, time.Since(start), err != nil, len([]rune(text)) < long); recErr != nil {
+		log.Debug().Err(recErr).Msg("Failed to record provider latency")
+	}
+	return audioFile, err
+}
+
+func (vm *VoiceManager) synthesize(ctx context.Context, text string, options VoiceOptions) (string, error) {
 	if text == "" {
 		return "", fmt.Errorf("text cannot be empty")
 	}
+	if options.Provider != "" {
+		analytics.Record(analytics.KindProvider, options.Provider)
+	} else {
+		analytics.Record(analytics.KindProvider, options.ToConfig(vm.config).EnginePriority)
+	}
+
+	limit := ChunkLimit(options.Provider, options.ChunkChars)
+	if chunks := options.SpeedRamp.chunks(text, limit); len(chunks) > 1 {
+		return vm.synthesizeChunks(ctx, chunks, options)
+	}
+	if chunks := SplitText(text, limit); len(chunks) > 1 {
+		return vm.synthesizeChunks(ctx, plainChunks(chunks), options)
+	}
+	return vm.synthesizeOne(ctx, text, options)
+}
+
+func (vm *VoiceManager) synthesizeOne(ctx context.Context, text string, options VoiceOptions) (string, error) {
+	if TestMode() {
+		return synthesizeTest(text, options)
+	}
 
 	// Handle local engines (legacy)
 	if options.Provider == "" || options.Provider == "voicevox" || options.Provider == "aivisspeech" {
@@ -184,13 +338,129 @@ func (vm *VoiceManager) Synthesize(ctx context.Context, text string, options Voi
 	return vm.synthesizeCloud(ctx, text, options)
 }
 
+// IsLocalProvider reports whether provider runs on a local engine rather
+// than a cloud service; "" is the VOICEVOX/AivisSpeech engine selection.
+func IsLocalProvider(provider string) bool {
+	switch provider {
+	case "", EngineVoicevox, EngineAivisSpeech, "sherpa-cli", "xtts":
+		return true
+	}
+	return false
+}
+
+// synthesizeChunks synthesizes text that exceeds the provider's input limit,
+// or is read with a speed ramp, chunk by chunk (up to ParallelChunks at a
+// time) and joins the audio into a single file, so playback is one seamless
+// clip.
+func (vm *VoiceManager) synthesizeChunks(ctx context.Context, chunks []speechChunk, options VoiceOptions) (string, error) {
+	log.Debug().
+		Str("provider", options.Provider).
+		Int("chunks", len(chunks)).
+		Int("parallel", options.ParallelChunks).
+		Msg("Splitting long text for synthesis")
+
+	chunkOpts := options
+	chunkOpts.OutputPath = ""
+	chunkOpts.ToStdout = false
+	chunkOpts.Recent = nil
+	chunkOpts.cache = nil
+	baseSpeed := options.Speed
+	if baseSpeed <= 0 && vm.config != nil {
+		baseSpeed = vm.config.SpeedScale
+	}
+	if baseSpeed <= 0 {
+		baseSpeed = 1
+	}
+
+	parallel := options.ParallelChunks
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	paths := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk speechChunk) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+			opts := chunkOpts
+			if chunk.factor != 1 {
+				opts.Speed = baseSpeed * chunk.factor
+			}
+			paths[i], errs[i] = vm.synthesizeOne(ctx, chunk.text, opts)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	defer func() {
+		for _, path := range paths {
+			if path != "" {
+				os.Remove(path)
+			}
+		}
+	}()
+	for i, err := range errs {
+		if err != nil {
+			return "", fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err)
+		}
+	}
+	marks := joinMarks(paths)
+
+	ext := strings.TrimPrefix(filepath.Ext(paths[0]), ".")
+	sinks, err := openSinks(options, getFileExtension(ext))
+	if err != nil {
+		return "", err
+	}
+	if err := ConcatAudio(sinks, paths); err != nil {
+		return sinks.finish(fmt.Errorf("failed to join audio chunks: %w", err))
+	}
+	outputPath, err := sinks.finish(nil)
+	if err != nil {
+		return "", err
+	}
+	if len(marks) > 0 && outputPath != "" {
+		setMarks(outputPath, marks)
+	}
+	return outputPath, nil
+}
+
 // synthesizeLocal uses the legacy local engines.
 // Reading-mode fields (ReadingMode, MaxChars, UUIDMode) are taken from vm.config;
 // all synthesis settings (provider, speaker, speed, volume) come from options.
 func (vm *VoiceManager) synthesizeLocal(text string, options VoiceOptions) (string, error) {
 	cfg := options.ToConfig(vm.config)
 	engine := NewVoiceEngine(cfg)
-	return engine.Synthesize(text)
+	audioFile, err := engine.Synthesize(text)
+	if err != nil || (options.OutputPath == "" && !options.ToStdout && options.Recent == nil) {
+		return audioFile, err
+	}
+
+	// The engine writes a temporary file; copy it to the other sinks.
+	defer os.Remove(audioFile)
+	src, err := os.Open(audioFile)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	path, err := writeAudio(src, options, "wav")
+	if marks := takeMarks(audioFile); len(marks) > 0 && path != "" {
+		setMarks(path, marks)
+	}
+	return path, err
 }
 
 // synthesizeCloud uses cloud providers
@@ -210,21 +480,47 @@ func (vm *VoiceManager) synthesizeCloud(ctx context.Context, text string, option
 		config["timeout_seconds"] = options.TimeoutSeconds
 	}
 
-	// Add Polly-specific config
-	if options.Provider == "polly" {
+	// Add Polly- and Azure-specific config
+	if options.Provider == "polly" || options.Provider == "azure" {
 		if options.Region != "" {
 			config["region"] = options.Region
 		}
 	}
+	if options.Provider == "azure" && options.SSML {
+		config["ssml"] = true
+	}
+
+	// Add sherpa-onnx config. "tts-1" is the resolver's OpenAI default, not a
+	// model name; without a model the provider uses the only pulled model.
+	if options.Provider == "sherpa-cli" {
+		options.Format = "wav"
+		if options.Model != "" && options.Model != "tts-1" {
+			config["model"] = options.Model
+		}
+	}
+
+	// Add XTTS-specific config. The server always returns WAV.
+	if options.Provider == "xtts" {
+		options.Format = "wav"
+		wav, err := ResolveReference(options.ReferenceWAV)
+		if err != nil {
+			return "", err
+		}
+		config["speaker_wav"] = wav
+		if options.Language != "" {
+			config["language"] = options.Language
+		}
+	}
 
 	// Create provider
 	prov, err := vm.providerFactory.CreateProvider(options.Provider, config)
 	if err != nil {
-		return "", fmt.Errorf("failed to create provider: %w", err)
+		return "", unavailable("failed to create provider: %w", err)
 	}
 
-	if !prov.IsAvailable(ctx) {
-		return "", fmt.Errorf("provider %s is not available", options.Provider)
+	availKey := "provider " + options.Provider + " " + options.BaseURL + " " + options.APIKey
+	if !checkAvailable(availKey, func() bool { return prov.IsAvailable(ctx) }) {
+		return "", unavailable("provider %s is not available", options.Provider)
 	}
 
 	// Set synthesis options
@@ -234,6 +530,7 @@ func (vm *VoiceManager) synthesizeCloud(ctx context.Context, text string, option
 		Volume:          options.Volume,
 		Format:          options.Format,
 		Quality:         options.Quality,
+		Language:        options.Language,
 		Model:           options.Model,
 		Stability:       options.Stability,
 		SimilarityBoost: options.SimilarityBoost,
@@ -254,53 +551,53 @@ func (vm *VoiceManager) synthesizeCloud(ctx context.Context, text string, option
 			Msg("volume option passed to provider (may not be supported)")
 	}
 
-	// Synthesize
-	audioStream, err := prov.Synthesize(ctx, text, synthOptions)
-	if err != nil {
-		return "", fmt.Errorf("synthesis failed: %w", err)
-	}
-	defer audioStream.Close()
-
-	// Handle output
-	if options.ToStdout {
-		// Stream directly to stdout
-		_, err := io.Copy(os.Stdout, audioStream)
-		return "", err
-	}
-
-	// Save to file
-	var outputPath string
-	if options.OutputPath != "" {
-		outputPath = options.OutputPath
-	} else {
-		// Create temporary file
-		tmpFile, err := os.CreateTemp("", "voice_*."+getFileExtension(options.Format))
+	// Synthesize, with speech marks when something shows them
+	var audioStream io.ReadCloser
+	var marks []provider.SpeechMark
+	markTypes := vm.markTypes(options)
+	if timed, ok := prov.(provider.TimedProvider); ok && len(markTypes) > 0 {
+		result, err := timed.SynthesizeWithMarks(ctx, text, synthOptions, markTypes)
 		if err != nil {
-			return "", fmt.Errorf("failed to create temp file: %w", err)
+			forgetAvailable(availKey)
+			return "", fmt.Errorf("synthesis failed: %w", err)
 		}
-		outputPath = tmpFile.Name()
-		if err := tmpFile.Close(); err != nil {
-			return "", fmt.Errorf("failed to close temp file: %w", err)
+		audioStream, marks = result.Audio, result.Marks
+	} else {
+		audioStream, err = prov.Synthesize(ctx, text, synthOptions)
+		if err != nil {
+			forgetAvailable(availKey)
+			return "", fmt.Errorf("synthesis failed: %w", err)
 		}
 	}
+	defer audioStream.Close()
 
-	// Write audio data to file
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create output file: %w", err)
+	// Tee the stream to stdout, the output file, and recent audio at once
+	outputPath, err := writeAudio(audioStream, options, getFileExtension(options.Format))
+	if err != nil || outputPath == "" {
+		return "", err
 	}
-	defer file.Close()
 
-	_, err = io.Copy(file, audioStream)
-	if err != nil {
-		os.Remove(outputPath)
-		return "", fmt.Errorf("failed to write audio data: %w", err)
+	if len(marks) > 0 {
+		setMarks(outputPath, providerMarks(marks))
 	}
-
 	log.Debug().Str("path", outputPath).Msg("Audio saved")
 	return outputPath, nil
 }
 
+// markTypes returns the speech marks synthesized audio needs: sentences for
+// subtitles, words and visemes for the avatar bridge. Some providers charge
+// an extra request for them.
+func (vm *VoiceManager) markTypes(options VoiceOptions) []string {
+	var types []string
+	if options.Subtitles != "" {
+		types = append(types, provider.MarkSentence)
+	}
+	if vm.config != nil && vm.config.Avatar != nil {
+		types = append(types, provider.MarkWord, provider.MarkViseme)
+	}
+	return types
+}
+
 // PlayAudio plays an audio file using the legacy engine's player
 func (vm *VoiceManager) PlayAudio(audioPath string) error {
 	return vm.legacyEngine.Play(audioPath)
@@ -2,9 +2,17 @@ package voice
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/daikw/ccpersona/internal/voice/provider"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -132,3 +140,99 @@ func TestVoiceOptions(t *testing.T) {
 		assert.True(t, opts.PlayAudio)
 	})
 }
+
+// chunkProvider records inputs and returns each text as its "audio".
+type chunkProvider struct {
+	mu     sync.Mutex
+	inputs []string
+	speeds map[string]float64
+}
+
+func (p *chunkProvider) Name() string { return "openai" }
+func (p *chunkProvider) ListVoices(ctx context.Context) ([]provider.Voice, error) {
+	return nil, nil
+}
+func (p *chunkProvider) IsAvailable(ctx context.Context) bool { return true }
+func (p *chunkProvider) Synthesize(ctx context.Context, text string, opts provider.SynthesizeOptions) (io.ReadCloser, error) {
+	p.mu.Lock()
+	p.inputs = append(p.inputs, text)
+	if p.speeds == nil {
+		p.speeds = map[string]float64{}
+	}
+	p.speeds[text] = opts.Speed
+	p.mu.Unlock()
+	return io.NopCloser(strings.NewReader("[" + text + "]")), nil
+}
+
+type chunkFactory struct{ p *chunkProvider }
+
+func (f chunkFactory) CreateProvider(string, map[string]interface{}) (provider.Provider, error) {
+	return f.p, nil
+}
+func (f chunkFactory) GetProviderWithDefaults(string) (provider.Provider, error) { return f.p, nil }
+func (f chunkFactory) ListProviders() []string                                   { return []string{"openai"} }
+
+func TestSynthesizeChunksLongText(t *testing.T) {
+	isolateCache(t)
+	fake := &chunkProvider{}
+	manager := NewVoiceManager(DefaultConfig())
+	manager.providerFactory = chunkFactory{fake}
+
+	out := filepath.Join(t.TempDir(), "out.mp3")
+	text := "First sentence. Second sentence. Third sentence."
+	path, err := manager.Synthesize(context.Background(), text, VoiceOptions{
+		Provider:       "openai",
+		Format:         "mp3",
+		ChunkChars:     20,
+		ParallelChunks: 3,
+		OutputPath:     out,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, out, path)
+	assert.Len(t, fake.inputs, 3)
+
+	data, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	// Chunks are joined in order regardless of completion order.
+	assert.Equal(t, "[First sentence.][Second sentence.][Third sentence.]", string(data))
+}
+
+func TestSynthesizeSpeedRamp(t *testing.T) {
+	isolateCache(t)
+	fake := &chunkProvider{}
+	manager := NewVoiceManager(DefaultConfig())
+	manager.providerFactory = chunkFactory{fake}
+
+	text := "Opening line. " + strings.Repeat("Filler sentence here. ", 10)
+	out := filepath.Join(t.TempDir(), "out.mp3")
+	_, err := manager.Synthesize(context.Background(), text, VoiceOptions{
+		Provider:   "openai",
+		Format:     "mp3",
+		Speed:      1.2,
+		SpeedRamp:  &SpeedRamp{MinChars: 50, RampChars: 100},
+		OutputPath: out,
+	})
+	assert.NoError(t, err)
+	assert.Greater(t, len(fake.inputs), 2)
+	// The first sentence keeps the configured speed; the end runs at 1.5x.
+	assert.Equal(t, 1.2, fake.speeds["Opening line. Filler sentence here."])
+	last := 0.0
+	for _, input := range fake.inputs {
+		last = math.Max(last, fake.speeds[input])
+	}
+	assert.InDelta(t, 1.8, last, 1e-9)
+
+	data, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Equal(t, len(fake.inputs), strings.Count(string(data), "["))
+}
+
+func TestUnavailableError(t *testing.T) {
+	err := fmt.Errorf("failed to synthesize voice: %w", unavailable("provider %s is not available", "openai"))
+	if !errors.Is(err, ErrProviderUnavailable) {
+		t.Error("unavailable errors must match ErrProviderUnavailable")
+	}
+	if got := err.Error(); got != "failed to synthesize voice: provider openai is not available" {
+		t.Errorf("Error() = %q, want the original message", got)
+	}
+}
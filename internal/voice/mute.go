@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
 )
 
 // MuteStatus represents the global mute state snapshot.
@@ -35,9 +37,13 @@ func legacyMutePath() (string, error) {
 }
 
 // IsMuted reports whether global voice synthesis is currently muted.
-// Errors (e.g. missing HOME) are treated as "not muted" so the gate
-// fails open and does not break hook-driven callers.
+// CCPERSONA_MUTE takes precedence over the marker file. Errors (e.g. missing
+// HOME) are treated as "not muted" so the gate fails open and does not break
+// hook-driven callers.
 func IsMuted() bool {
+	if muted, ok := MuteOverride(); ok {
+		return muted
+	}
 	path, err := MutePath()
 	if err != nil {
 		return false
@@ -68,7 +74,7 @@ func Mute(reason string) (*MuteStatus, error) {
 	if err != nil {
 		return nil, fmt.Errorf("marshal mute status: %w", err)
 	}
-	if err := os.WriteFile(path, data, 0o644); err != nil {
+	if err := fsutil.WriteFile(path, data, 0o644); err != nil {
 		return nil, fmt.Errorf("write mute file: %w", err)
 	}
 	return status, nil
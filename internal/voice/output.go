@@ -0,0 +1,124 @@
+package voice
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// OutputConfig plays speech on a chosen audio device, such as a BlackHole or
+// VB-Cable virtual microphone that a call or a recording listens to.
+type OutputConfig struct {
+	// Device is a PulseAudio or PipeWire sink (paplay) or an ALSA device
+	// (aplay) on Linux, and a Core Audio (macOS) or waveaudio (Windows)
+	// device name played with sox.
+	Device string `json:"device"`
+	// Confirm must be true for Device to be used. It acknowledges that
+	// everything ccpersona speaks is heard by whoever listens to the device.
+	Confirm bool `json:"confirm"`
+	// Monitor also plays speech on the default device, so you hear it too.
+	Monitor bool `json:"monitor,omitempty"`
+}
+
+// Active reports whether speech goes to the device; safe on nil.
+func (o *OutputConfig) Active() bool {
+	return o != nil && o.Device != "" && o.Confirm
+}
+
+// Validate checks that a device is named.
+func (o *OutputConfig) Validate() error {
+	if o != nil && o.Device == "" {
+		return fmt.Errorf("voice.output.device is required")
+	}
+	return nil
+}
+
+var warnUnconfirmedOutput sync.Once
+
+// playerCommands returns the commands that play audioFile: the default
+// player, the output device, or both while monitoring.
+func (ve *VoiceEngine) playerCommands(audioFile string) ([]*exec.Cmd, error) {
+	var output *OutputConfig
+	if ve.config != nil {
+		output = ve.config.Output
+	}
+	if output != nil && output.Device != "" && !output.Confirm {
+		warnUnconfirmedOutput.Do(func() {
+			log.Warn().Str("device", output.Device).Msg("voice.output.device is ignored until voice.output.confirm is true")
+		})
+	}
+	if !output.Active() {
+		cmd, err := playerCommand(audioFile)
+		if err != nil {
+			return nil, err
+		}
+		return []*exec.Cmd{cmd}, nil
+	}
+
+	cmd, err := deviceCommand(runtime.GOOS, output.Device, audioFile, isCommandAvailable)
+	if err != nil {
+		return nil, err
+	}
+	cmds := []*exec.Cmd{cmd}
+	if output.Monitor {
+		monitor, err := playerCommand(audioFile)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, monitor)
+	}
+	return cmds, nil
+}
+
+// deviceCommand builds the command that plays audioFile on device.
+func deviceCommand(goos, device, audioFile string, available func(string) bool) (*exec.Cmd, error) {
+	switch goos {
+	case "linux":
+		if available("paplay") {
+			return exec.Command("paplay", "--device="+device, audioFile), nil
+		}
+		if available("aplay") {
+			return exec.Command("aplay", "-D", device, audioFile), nil
+		}
+		return nil, errors.New("playing on an output device needs paplay or aplay")
+	case "darwin", "windows":
+		if !available("sox") {
+			return nil, errors.New("playing on an output device needs sox")
+		}
+		driver := "coreaudio"
+		if goos == "windows" {
+			driver = "waveaudio"
+		}
+		return exec.Command("sox", "-q", audioFile, "-t", driver, device), nil
+	default:
+		return nil, fmt.Errorf("playing on an output device is not supported on %s", goos)
+	}
+}
+
+// startAll starts every command, stopping those already started if one
+// fails.
+func startAll(cmds []*exec.Cmd) error {
+	for i, cmd := range cmds {
+		if err := cmd.Start(); err != nil {
+			for _, started := range cmds[:i] {
+				_ = started.Process.Kill()
+				_ = started.Wait()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// waitAll waits for every started command.
+func waitAll(cmds []*exec.Cmd) error {
+	var errs []error
+	for _, cmd := range cmds {
+		errs = append(errs, cmd.Wait())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,56 @@
+package voice
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutputConfigActive(t *testing.T) {
+	if (*OutputConfig)(nil).Active() || (&OutputConfig{Device: "BlackHole 2ch"}).Active() {
+		t.Error("an unconfirmed or missing device should not be used")
+	}
+	if !(&OutputConfig{Device: "BlackHole 2ch", Confirm: true}).Active() {
+		t.Error("a confirmed device should be used")
+	}
+	if err := (&OutputConfig{Confirm: true}).Validate(); err == nil {
+		t.Error("Validate() should require a device")
+	}
+}
+
+func TestDeviceCommand(t *testing.T) {
+	only := func(names ...string) func(string) bool {
+		return func(name string) bool {
+			for _, n := range names {
+				if n == name {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	tests := []struct {
+		goos      string
+		available []string
+		want      string
+	}{
+		{"linux", []string{"paplay", "aplay"}, "paplay --device=vcable out.wav"},
+		{"linux", []string{"aplay"}, "aplay -D vcable out.wav"},
+		{"darwin", []string{"sox"}, "sox -q out.wav -t coreaudio vcable"},
+		{"windows", []string{"sox"}, "sox -q out.wav -t waveaudio vcable"},
+	}
+	for _, tt := range tests {
+		cmd, err := deviceCommand(tt.goos, "vcable", "out.wav", only(tt.available...))
+		if err != nil {
+			t.Errorf("deviceCommand(%s) error = %v", tt.goos, err)
+			continue
+		}
+		if got := strings.Join(cmd.Args, " "); got != tt.want {
+			t.Errorf("deviceCommand(%s) = %q, want %q", tt.goos, got, tt.want)
+		}
+	}
+	for _, goos := range []string{"linux", "darwin", "plan9"} {
+		if _, err := deviceCommand(goos, "vcable", "out.wav", only()); err == nil {
+			t.Errorf("deviceCommand(%s) without a player should fail", goos)
+		}
+	}
+}
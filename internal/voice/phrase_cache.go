@@ -0,0 +1,95 @@
+package voice
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// PhraseCache stores synthesized audio for short fixed phrases, such as
+// prompt acknowledgements, so they play without a synthesis round trip.
+// Entries are keyed by persona, text, and every setting that changes the
+// audio, so editing the voice config naturally invalidates them.
+type PhraseCache struct {
+	dir string
+}
+
+// NewPhraseCache returns the cache under the user cache directory.
+func NewPhraseCache() (*PhraseCache, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("resolve cache dir: %w", err)
+	}
+	return &PhraseCache{dir: filepath.Join(base, "ccpersona", "phrases")}, nil
+}
+
+// Get returns the cached audio for text, synthesizing and storing it on a
+// miss. The returned file belongs to the cache; play it with PlayFile.
+func (pc *PhraseCache) Get(ctx context.Context, vm *VoiceManager, persona, text string, opts VoiceOptions) (string, error) {
+	key := phraseKey(persona, text, opts)
+	if matches, _ := filepath.Glob(filepath.Join(pc.dir, key+".*")); len(matches) > 0 {
+		return matches[0], nil
+	}
+
+	opts.OutputPath = ""
+	opts.ToStdout = false
+	opts.PlayAudio = false
+	audio, err := vm.Synthesize(ctx, text, opts)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(audio)
+	return pc.store(key, audio)
+}
+
+// store copies audio into the cache atomically, keeping its extension.
+func (pc *PhraseCache) store(key, audio string) (string, error) {
+	if err := os.MkdirAll(pc.dir, 0755); err != nil {
+		return "", fmt.Errorf("create phrase cache: %w", err)
+	}
+	src, err := os.Open(audio)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(pc.dir, ".phrase-*")
+	if err != nil {
+		return "", fmt.Errorf("write phrase cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("write phrase cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("write phrase cache: %w", err)
+	}
+	path := filepath.Join(pc.dir, key+filepath.Ext(audio))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("write phrase cache: %w", err)
+	}
+	return path, nil
+}
+
+// phraseKey hashes the inputs that determine the audio. Output options and
+// the API key do not change the result and are left out.
+func phraseKey(persona, text string, opts VoiceOptions) string {
+	opts.OutputPath = ""
+	opts.ToStdout = false
+	opts.PlayAudio = false
+	opts.Recent = nil
+	opts.APIKey = ""
+	opts.ParallelChunks = 0
+	data, _ := json.Marshal(struct {
+		Persona string
+		Text    string
+		Options VoiceOptions
+	}{persona, text, opts})
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%x", sum[:16])
+}
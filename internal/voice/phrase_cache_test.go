@@ -0,0 +1,49 @@
+package voice
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhraseKey(t *testing.T) {
+	base := VoiceOptions{Provider: "openai", Voice: "nova", Speed: 1.0}
+	key := phraseKey("zundamon", "了解なのだ", base)
+
+	withOutput := base
+	withOutput.OutputPath = "/tmp/x.mp3"
+	withOutput.APIKey = "sk-secret"
+	assert.Equal(t, key, phraseKey("zundamon", "了解なのだ", withOutput), "output and credentials must not affect the key")
+
+	otherVoice := base
+	otherVoice.Voice = "alloy"
+	assert.NotEqual(t, key, phraseKey("zundamon", "了解なのだ", otherVoice))
+	assert.NotEqual(t, key, phraseKey("other", "了解なのだ", base))
+	assert.NotEqual(t, key, phraseKey("zundamon", "任せるのだ", base))
+}
+
+func TestPhraseCacheGet(t *testing.T) {
+	isolateCache(t)
+	fake := &chunkProvider{}
+	manager := NewVoiceManager(DefaultConfig())
+	manager.providerFactory = chunkFactory{fake}
+	cache := &PhraseCache{dir: t.TempDir()}
+	opts := VoiceOptions{Provider: "openai", Format: "mp3"}
+
+	first, err := cache.Get(context.Background(), manager, "p", "了解", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.dir, filepath.Dir(first))
+	assert.Equal(t, ".mp3", filepath.Ext(first))
+
+	second, err := cache.Get(context.Background(), manager, "p", "了解", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Len(t, fake.inputs, 1, "second lookup must be served from the cache")
+
+	data, err := os.ReadFile(first)
+	assert.NoError(t, err)
+	assert.Equal(t, "[了解]", string(data))
+}
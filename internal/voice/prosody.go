@@ -0,0 +1,72 @@
+package voice
+
+import "fmt"
+
+// Prosody sets the VOICEVOX audio query parameters that shape how a speaker
+// sounds, so personas sharing a speaker can still sound distinct. Unset
+// fields keep the engine's value. AivisSpeech accepts the same query, where
+// intonation_scale sets the strength of the speaker's emotion.
+type Prosody struct {
+	// IntonationScale is 0 (monotone) to 2; the engine default is 1.
+	IntonationScale *float64 `json:"intonation_scale,omitempty"`
+	// PitchScale is -0.15 to 0.15; the engine default is 0.
+	PitchScale *float64 `json:"pitch_scale,omitempty"`
+	// PrePhonemeLength and PostPhonemeLength are the silence in seconds
+	// before and after the speech, 0 to 1.5.
+	PrePhonemeLength  *float64 `json:"pre_phoneme_length,omitempty"`
+	PostPhonemeLength *float64 `json:"post_phoneme_length,omitempty"`
+}
+
+// prosodyField is one audio query parameter of Prosody.
+type prosodyField struct {
+	name, key string
+	value     *float64
+	min, max  float64
+}
+
+func (p *Prosody) fields() []prosodyField {
+	return []prosodyField{
+		{"intonation_scale", "intonationScale", p.IntonationScale, 0, 2},
+		{"pitch_scale", "pitchScale", p.PitchScale, -0.15, 0.15},
+		{"pre_phoneme_length", "prePhonemeLength", p.PrePhonemeLength, 0, 1.5},
+		{"post_phoneme_length", "postPhonemeLength", p.PostPhonemeLength, 0, 1.5},
+	}
+}
+
+// IsSet reports whether any parameter is set; safe on nil.
+func (p *Prosody) IsSet() bool {
+	if p == nil {
+		return false
+	}
+	for _, f := range p.fields() {
+		if f.value != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the parameters against the ranges the engines accept.
+func (p *Prosody) Validate() error {
+	if p == nil {
+		return nil
+	}
+	for _, f := range p.fields() {
+		if f.value != nil && (*f.value < f.min || *f.value > f.max) {
+			return fmt.Errorf("prosody.%s must be between %g and %g, got %g", f.name, f.min, f.max, *f.value)
+		}
+	}
+	return nil
+}
+
+// apply sets the parameters on a decoded audio query.
+func (p *Prosody) apply(query map[string]interface{}) {
+	if p == nil {
+		return
+	}
+	for _, f := range p.fields() {
+		if f.value != nil {
+			query[f.key] = *f.value
+		}
+	}
+}
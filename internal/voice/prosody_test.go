@@ -0,0 +1,53 @@
+package voice
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProsodyValidate(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	valid := []*Prosody{
+		nil,
+		{},
+		{IntonationScale: f(0), PitchScale: f(-0.15), PrePhonemeLength: f(0.1), PostPhonemeLength: f(1.5)},
+	}
+	for _, p := range valid {
+		if err := p.Validate(); err != nil {
+			t.Errorf("%+v: %v", p, err)
+		}
+	}
+	invalid := []*Prosody{
+		{IntonationScale: f(2.5)},
+		{PitchScale: f(0.3)},
+		{PrePhonemeLength: f(-1)},
+		{PostPhonemeLength: f(2)},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", p)
+		}
+	}
+}
+
+func TestAdjustQueryProsody(t *testing.T) {
+	monotone, pitch := 0.0, 0.08
+	ve := NewVoiceEngine(&Config{VolumeScale: 1, SpeedScale: 1, Prosody: &Prosody{IntonationScale: &monotone, PitchScale: &pitch}})
+	query := `{"accent_phrases":[],"intonationScale":1,"pitchScale":0,"prePhonemeLength":0.1,"postPhonemeLength":0.1}`
+
+	var got struct {
+		IntonationScale   float64 `json:"intonationScale"`
+		PitchScale        float64 `json:"pitchScale"`
+		PrePhonemeLength  float64 `json:"prePhonemeLength"`
+		PostPhonemeLength float64 `json:"postPhonemeLength"`
+	}
+	if err := json.Unmarshal(ve.adjustQuery([]byte(query)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.IntonationScale != 0 || got.PitchScale != 0.08 {
+		t.Errorf("intonationScale, pitchScale = %v, %v; want 0, 0.08", got.IntonationScale, got.PitchScale)
+	}
+	if got.PrePhonemeLength != 0.1 || got.PostPhonemeLength != 0.1 {
+		t.Errorf("unset parameters should keep the engine's values, got %v, %v", got.PrePhonemeLength, got.PostPhonemeLength)
+	}
+}
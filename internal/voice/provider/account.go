@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// Account describes the plan and quota behind a credential, as far as the
+// provider's API reports them.
+type Account struct {
+	Plan string
+	// Used and Limit count Unit, e.g. characters, in the current billing
+	// period; Limit is 0 when the API does not report one.
+	Used    int64
+	Limit   int64
+	Unit    string
+	ResetAt time.Time
+	// Detail is a further note, such as the TTS models a key can use.
+	Detail string
+}
+
+// AccountProvider is implemented by providers that can look up the account
+// behind their credential. The lookup is a minimal authenticated request, so
+// an error means the credential does not work.
+type AccountProvider interface {
+	Provider
+	Account(ctx context.Context) (*Account, error)
+}
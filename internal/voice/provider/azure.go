@@ -0,0 +1,272 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// AzureDefaultVoice is a Japanese neural voice.
+	AzureDefaultVoice     = "ja-JP-NanamiNeural"
+	AzureTTSEndpoint      = "/cognitiveservices/v1"
+	AzureVoicesEndpoint   = "/cognitiveservices/voices/list"
+	azureSubscriptionKey  = "Ocp-Apim-Subscription-Key"
+	azureOutputFormatName = "X-Microsoft-OutputFormat"
+)
+
+// azureRegion matches region names such as "japaneast". The region becomes
+// part of the host the key is sent to, so nothing else is accepted.
+var azureRegion = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// AzureProvider implements the Provider interface for the Azure AI Speech
+// (Cognitive Services) text-to-speech REST API.
+type AzureProvider struct {
+	apiKey     string
+	region     string
+	baseURL    string
+	ssml       bool
+	httpClient *http.Client
+}
+
+// NewAzureProvider creates an Azure Speech provider for a resource's key and
+// region, such as "japaneast".
+func NewAzureProvider(apiKey, region string) (*AzureProvider, error) {
+	if !azureRegion.MatchString(region) {
+		return nil, fmt.Errorf("invalid Azure Speech region %q: use a region name such as japaneast", region)
+	}
+	return &AzureProvider{
+		apiKey:     apiKey,
+		region:     region,
+		baseURL:    fmt.Sprintf("https://%s.tts.speech.microsoft.com", region),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Name returns the provider name
+func (p *AzureProvider) Name() string {
+	return "azure"
+}
+
+// AzureVoice is a voice as listed by the Azure voices API.
+type AzureVoice struct {
+	Name        string   `json:"Name"`
+	DisplayName string   `json:"DisplayName"`
+	LocalName   string   `json:"LocalName"`
+	ShortName   string   `json:"ShortName"`
+	Gender      string   `json:"Gender"`
+	Locale      string   `json:"Locale"`
+	VoiceType   string   `json:"VoiceType"`
+	StyleList   []string `json:"StyleList,omitempty"`
+}
+
+// ListVoices returns the neural voices available in the resource's region.
+func (p *AzureProvider) ListVoices(ctx context.Context) ([]Voice, error) {
+	resp, err := p.get(ctx, p.httpClient)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Azure voices API error: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var azureVoices []AzureVoice
+	if err := json.NewDecoder(resp.Body).Decode(&azureVoices); err != nil {
+		return nil, fmt.Errorf("failed to decode voices response: %w", err)
+	}
+
+	voices := make([]Voice, 0, len(azureVoices))
+	for _, v := range azureVoices {
+		if v.VoiceType != "" && v.VoiceType != "Neural" {
+			continue // Standard voices are retired
+		}
+		description := v.LocalName
+		if len(v.StyleList) > 0 {
+			description += " (styles: " + strings.Join(v.StyleList, ", ") + ")"
+		}
+		voices = append(voices, Voice{
+			ID:          v.ShortName,
+			Name:        v.DisplayName,
+			Language:    v.Locale,
+			Gender:      strings.ToLower(v.Gender),
+			Description: description,
+		})
+	}
+
+	log.Debug().
+		Int("voice_count", len(voices)).
+		Str("region", p.region).
+		Msg("Azure voices retrieved successfully")
+
+	return voices, nil
+}
+
+// Synthesize generates audio from text with an Azure neural voice. With SSML
+// enabled, text that is already SSML is sent as is; otherwise it is read out
+// like any other text.
+func (p *AzureProvider) Synthesize(ctx context.Context, text string, options SynthesizeOptions) (io.ReadCloser, error) {
+	if text == "" {
+		return nil, fmt.Errorf("text cannot be empty")
+	}
+
+	voice := options.Voice
+	if voice == "" {
+		voice = AzureDefaultVoice
+	}
+	format := convertToAzureFormat(options.Format)
+
+	ssml := text
+	if !p.ssml || !strings.HasPrefix(strings.TrimSpace(text), "<speak") {
+		ssml = azureSSML(text, voice, options.Language, options.Speed, options.Volume)
+	}
+
+	endpoint := p.baseURL + AzureTTSEndpoint
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader([]byte(ssml)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/ssml+xml")
+	req.Header.Set(azureSubscriptionKey, p.apiKey)
+	req.Header.Set(azureOutputFormatName, format)
+	req.Header.Set("User-Agent", "ccpersona")
+
+	log.Debug().
+		Str("endpoint", endpoint).
+		Str("voice", voice).
+		Str("format", format).
+		Msg("Making Azure TTS request")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{API: "Azure TTS", Status: resp.StatusCode, Body: string(body)}
+	}
+
+	log.Debug().
+		Int("status", resp.StatusCode).
+		Str("content_type", resp.Header.Get("Content-Type")).
+		Msg("Azure TTS request successful")
+
+	return resp.Body, nil
+}
+
+// IsAvailable checks the key and region by listing voices.
+func (p *AzureProvider) IsAvailable(ctx context.Context) bool {
+	if p.apiKey == "" || p.region == "" {
+		return false
+	}
+	resp, err := p.get(ctx, &http.Client{Timeout: 5 * time.Second})
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// get requests the voices list, which doubles as a credential check.
+func (p *AzureProvider) get(ctx context.Context, client *http.Client) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+AzureVoicesEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create voices request: %w", err)
+	}
+	req.Header.Set(azureSubscriptionKey, p.apiKey)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make voices request: %w", err)
+	}
+	return resp, nil
+}
+
+// AzureProviderFromConfig creates an Azure provider from configuration. The
+// key and region fall back to AZURE_SPEECH_KEY and AZURE_SPEECH_REGION.
+func AzureProviderFromConfig(config map[string]interface{}) (*AzureProvider, error) {
+	apiKey, _ := config["api_key"].(string)
+	if apiKey == "" {
+		apiKey = os.Getenv("AZURE_SPEECH_KEY")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("Azure Speech key not found in config or AZURE_SPEECH_KEY environment variable")
+	}
+	region, _ := config["region"].(string)
+	if region == "" {
+		region = os.Getenv("AZURE_SPEECH_REGION")
+	}
+	if region == "" {
+		return nil, fmt.Errorf("Azure Speech region not found in config or AZURE_SPEECH_REGION environment variable")
+	}
+
+	provider, err := NewAzureProvider(apiKey, region)
+	if err != nil {
+		return nil, err
+	}
+	// Optional base URL override (sovereign clouds, custom endpoints)
+	if baseURL, ok := config["base_url"].(string); ok && baseURL != "" {
+		provider.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+	provider.ssml, _ = config["ssml"].(bool)
+	return provider, nil
+}
+
+// azureSSML wraps plain text in SSML for voice. The language defaults to the
+// voice's locale ("ja-JP" for "ja-JP-NanamiNeural"); speed and volume are
+// relative to the voice's defaults, 0 leaving them unchanged.
+func azureSSML(text, voice, language string, speed, volume float64) string {
+	if language == "" {
+		language = azureVoiceLocale(voice)
+	}
+	var escaped bytes.Buffer
+	_ = xml.EscapeText(&escaped, []byte(text))
+	body := escaped.String()
+
+	var prosody []string
+	if speed > 0 && speed != 1 {
+		prosody = append(prosody, fmt.Sprintf(`rate="%s"`, strconv.FormatFloat(speed, 'f', -1, 64)))
+	}
+	if volume > 0 && volume != 1 {
+		prosody = append(prosody, fmt.Sprintf(`volume="%+.0f%%"`, (volume-1)*100))
+	}
+	if len(prosody) > 0 {
+		body = "<prosody " + strings.Join(prosody, " ") + ">" + body + "</prosody>"
+	}
+	return fmt.Sprintf(`<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="%s"><voice name="%s">%s</voice></speak>`,
+		language, voice, body)
+}
+
+// azureVoiceLocale returns the locale prefix of a voice short name.
+func azureVoiceLocale(voice string) string {
+	parts := strings.SplitN(voice, "-", 3)
+	if len(parts) < 3 {
+		return "ja-JP"
+	}
+	return parts[0] + "-" + parts[1]
+}
+
+// convertToAzureFormat converts common format names to Azure output formats.
+func convertToAzureFormat(format string) string {
+	switch strings.ToLower(format) {
+	case "wav", "wave", "flac":
+		return "riff-24khz-16bit-mono-pcm" // Azure has no FLAC output; use WAV
+	case "ogg":
+		return "ogg-24khz-16bit-mono-opus"
+	default:
+		return "audio-24khz-48kbitrate-mono-mp3"
+	}
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureProvider_ListVoices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, AzureVoicesEndpoint, r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("Ocp-Apim-Subscription-Key"))
+		_, _ = w.Write([]byte(`[
+			{"ShortName": "ja-JP-NanamiNeural", "DisplayName": "Nanami", "LocalName": "七海", "Gender": "Female", "Locale": "ja-JP", "VoiceType": "Neural", "StyleList": ["chat", "cheerful"]},
+			{"ShortName": "ja-JP-AyumiRUS", "DisplayName": "Ayumi", "LocalName": "歩美", "Gender": "Female", "Locale": "ja-JP", "VoiceType": "Standard"}
+		]`))
+	}))
+	defer server.Close()
+
+	provider, err := NewAzureProvider("test-key", "japaneast")
+	require.NoError(t, err)
+	provider.baseURL = server.URL
+	voices, err := provider.ListVoices(context.Background())
+	require.NoError(t, err)
+	require.Len(t, voices, 1, "standard voices are skipped")
+	assert.Equal(t, Voice{
+		ID:          "ja-JP-NanamiNeural",
+		Name:        "Nanami",
+		Language:    "ja-JP",
+		Gender:      "female",
+		Description: "七海 (styles: chat, cheerful)",
+	}, voices[0])
+}
+
+func TestAzureProvider_Synthesize(t *testing.T) {
+	var body, format, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, AzureTTSEndpoint, r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("Ocp-Apim-Subscription-Key"))
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		format = r.Header.Get("X-Microsoft-OutputFormat")
+		contentType = r.Header.Get("Content-Type")
+		_, _ = w.Write([]byte("RIFF"))
+	}))
+	defer server.Close()
+
+	provider, err := NewAzureProvider("test-key", "japaneast")
+	require.NoError(t, err)
+	provider.baseURL = server.URL
+	audio, err := provider.Synthesize(context.Background(), "A&B <完了>", SynthesizeOptions{
+		Voice:  "ja-JP-KeitaNeural",
+		Format: "wav",
+		Speed:  1.2,
+		Volume: 1.5,
+	})
+	require.NoError(t, err)
+	defer audio.Close()
+	data, _ := io.ReadAll(audio)
+	assert.Equal(t, "RIFF", string(data))
+
+	assert.Equal(t, "application/ssml+xml", contentType)
+	assert.Equal(t, "riff-24khz-16bit-mono-pcm", format)
+	assert.Equal(t, `<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="ja-JP"><voice name="ja-JP-KeitaNeural"><prosody rate="1.2" volume="+50%">A&amp;B &lt;完了&gt;</prosody></voice></speak>`, body)
+
+	t.Run("sends SSML as is only when enabled", func(t *testing.T) {
+		ssml := `<speak version="1.0" xml:lang="en-US"><voice name="en-US-JennyNeural">Hi</voice></speak>`
+		audio, err := provider.Synthesize(context.Background(), ssml, SynthesizeOptions{})
+		require.NoError(t, err)
+		audio.Close()
+		assert.Contains(t, body, "&lt;speak")
+		assert.Equal(t, "audio-24khz-48kbitrate-mono-mp3", format)
+
+		provider.ssml = true
+		defer func() { provider.ssml = false }()
+		audio, err = provider.Synthesize(context.Background(), ssml, SynthesizeOptions{})
+		require.NoError(t, err)
+		audio.Close()
+		assert.Equal(t, ssml, body)
+	})
+
+	t.Run("reports API errors", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer failing.Close()
+		provider.baseURL = failing.URL
+		_, err := provider.Synthesize(context.Background(), "こんにちは", SynthesizeOptions{})
+		assert.ErrorContains(t, err, "status 401")
+		assert.False(t, provider.IsAvailable(context.Background()))
+	})
+}
+
+func TestAzureProviderFromConfig(t *testing.T) {
+	t.Setenv("AZURE_SPEECH_KEY", "")
+	t.Setenv("AZURE_SPEECH_REGION", "")
+
+	_, err := AzureProviderFromConfig(map[string]interface{}{"region": "japaneast"})
+	assert.ErrorContains(t, err, "AZURE_SPEECH_KEY")
+	_, err = AzureProviderFromConfig(map[string]interface{}{"api_key": "key"})
+	assert.ErrorContains(t, err, "AZURE_SPEECH_REGION")
+
+	t.Setenv("AZURE_SPEECH_KEY", "env-key")
+	t.Setenv("AZURE_SPEECH_REGION", "japaneast")
+	provider, err := AzureProviderFromConfig(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, "env-key", provider.apiKey)
+	assert.Equal(t, "https://japaneast.tts.speech.microsoft.com", provider.baseURL)
+
+	provider, err = AzureProviderFromConfig(map[string]interface{}{"api_key": "key", "region": "westeurope", "base_url": "http://localhost:8080/"})
+	require.NoError(t, err)
+	assert.Equal(t, "key", provider.apiKey)
+	assert.Equal(t, "westeurope", provider.region)
+	assert.Equal(t, "http://localhost:8080", provider.baseURL)
+	assert.False(t, provider.ssml)
+
+	provider, err = AzureProviderFromConfig(map[string]interface{}{"api_key": "key", "region": "japaneast", "ssml": true})
+	require.NoError(t, err)
+	assert.True(t, provider.ssml)
+
+	for _, region := range []string{"evil.example/x?", "japan east", "JapanEast", "a.b"} {
+		_, err = AzureProviderFromConfig(map[string]interface{}{"api_key": "key", "region": region})
+		assert.ErrorContains(t, err, "invalid Azure Speech region", region)
+	}
+}
+
+func TestAzureVoiceLocale(t *testing.T) {
+	assert.Equal(t, "ja-JP", azureVoiceLocale("ja-JP-NanamiNeural"))
+	assert.Equal(t, "en-US", azureVoiceLocale("en-US-AvaMultilingualNeural"))
+	assert.Equal(t, "ja-JP", azureVoiceLocale("custom"))
+}
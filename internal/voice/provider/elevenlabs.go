@@ -14,9 +14,10 @@ import (
 )
 
 const (
-	ElevenLabsBaseURL        = "https://api.elevenlabs.io/v1"
-	ElevenLabsTTSEndpoint    = "/text-to-speech"
-	ElevenLabsVoicesEndpoint = "/voices"
+	ElevenLabsBaseURL              = "https://api.elevenlabs.io/v1"
+	ElevenLabsTTSEndpoint          = "/text-to-speech"
+	ElevenLabsVoicesEndpoint       = "/voices"
+	ElevenLabsSubscriptionEndpoint = "/user/subscription"
 )
 
 // ElevenLabsProvider implements the Provider interface for ElevenLabs TTS API v1
@@ -251,12 +252,12 @@ func (p *ElevenLabsProvider) Synthesize(ctx context.Context, text string, option
 		body, _ := io.ReadAll(resp.Body)
 
 		// Parse error response if possible
+		detail := string(body)
 		var errorResp ElevenLabsError
 		if json.Unmarshal(body, &errorResp) == nil && errorResp.Detail != nil {
-			return nil, fmt.Errorf("ElevenLabs API error: %s", errorResp.String())
+			detail = errorResp.String()
 		}
-
-		return nil, fmt.Errorf("ElevenLabs API error: status %d, body: %s", resp.StatusCode, string(body))
+		return nil, &APIError{API: "ElevenLabs", Status: resp.StatusCode, Body: detail}
 	}
 
 	log.Debug().
@@ -292,6 +293,55 @@ func (p *ElevenLabsProvider) IsAvailable(ctx context.Context) bool {
 	return resp.StatusCode == http.StatusOK
 }
 
+// elevenLabsSubscription is the part of the subscription response that
+// Account reports.
+type elevenLabsSubscription struct {
+	Tier                        string `json:"tier"`
+	Status                      string `json:"status"`
+	CharacterCount              int64  `json:"character_count"`
+	CharacterLimit              int64  `json:"character_limit"`
+	NextCharacterCountResetUnix int64  `json:"next_character_count_reset_unix"`
+}
+
+// Account reports the subscription tier and character quota of the API key.
+func (p *ElevenLabsProvider) Account(ctx context.Context) (*Account, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+ElevenLabsSubscriptionEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription request: %w", err)
+	}
+	req.Header.Set("xi-api-key", p.apiKey)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make subscription request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("ElevenLabs subscription API error: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var sub elevenLabsSubscription
+	if err := json.NewDecoder(resp.Body).Decode(&sub); err != nil {
+		return nil, fmt.Errorf("failed to decode subscription response: %w", err)
+	}
+	account := &Account{
+		Plan:  sub.Tier,
+		Used:  sub.CharacterCount,
+		Limit: sub.CharacterLimit,
+		Unit:  "characters",
+	}
+	if sub.NextCharacterCountResetUnix > 0 {
+		account.ResetAt = time.Unix(sub.NextCharacterCountResetUnix, 0)
+	}
+	if sub.Status != "" && sub.Status != "active" {
+		account.Detail = "subscription " + sub.Status
+	}
+	return account, nil
+}
+
 // ElevenLabsProviderFromConfig creates an ElevenLabs provider from configuration
 func ElevenLabsProviderFromConfig(config map[string]interface{}) (*ElevenLabsProvider, error) {
 	apiKey, ok := config["api_key"].(string)
@@ -294,3 +294,41 @@ func TestGetPrebuiltVoices(t *testing.T) {
 	assert.True(t, voiceNames["Rachel"])
 	assert.True(t, voiceNames["Adam"])
 }
+
+func TestElevenLabsProvider_Account(t *testing.T) {
+	t.Run("reports tier and character quota", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, ElevenLabsSubscriptionEndpoint, r.URL.Path)
+			assert.Equal(t, "test-api-key", r.Header.Get("xi-api-key"))
+			_, _ = w.Write([]byte(`{"tier": "creator", "status": "active", "character_count": 12345,
+				"character_limit": 100000, "next_character_count_reset_unix": 1798761600}`))
+		}))
+		defer server.Close()
+
+		provider := NewElevenLabsProvider("test-api-key")
+		provider.baseURL = server.URL
+
+		account, err := provider.Account(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "creator", account.Plan)
+		assert.Equal(t, int64(12345), account.Used)
+		assert.Equal(t, int64(100000), account.Limit)
+		assert.Equal(t, "characters", account.Unit)
+		assert.Equal(t, int64(1798761600), account.ResetAt.Unix())
+		assert.Empty(t, account.Detail)
+	})
+
+	t.Run("rejected key is an error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"detail": {"status": "invalid_api_key"}}`))
+		}))
+		defer server.Close()
+
+		provider := NewElevenLabsProvider("bad-key")
+		provider.baseURL = server.URL
+
+		_, err := provider.Account(context.Background())
+		assert.ErrorContains(t, err, "status 401")
+	})
+}
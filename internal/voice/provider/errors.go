@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrTextRejected matches errors for text a provider refused because of its
+// length or content: HTTP 413, or a 400 or 422 whose body names the text as
+// the problem. Sending shorter text may succeed; bad voices or parameters and
+// failures of the provider itself do not match.
+var ErrTextRejected = errors.New("text rejected by provider")
+
+// APIError is an error response of a provider's HTTP API.
+type APIError struct {
+	// API names the API, such as "OpenAI" or "Azure TTS".
+	API    string
+	Status int
+	// Body is the response body, or the error detail parsed from it.
+	Body string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error: status %d, body: %s", e.API, e.Status, e.Body)
+}
+
+// textRejections are the body fragments, lower-cased, with which each API
+// refuses text in a 400 or 422 response.
+var textRejections = map[string][]string{
+	"OpenAI":     {"string_too_long", "string should have at most", "input is too long"},
+	"ElevenLabs": {"max_character_limit_exceeded", "text_too_long", "text is too long"},
+	"Azure TTS":  {"text is too long", "exceeds the maximum", "too many characters"},
+	"XTTS":       {"text is too long", "text length exceeds", "character limit"},
+}
+
+// Is matches ErrTextRejected for responses that blame the text: too large,
+// or refused with one of the API's text rejection messages.
+func (e *APIError) Is(target error) bool {
+	if target != ErrTextRejected {
+		return false
+	}
+	switch e.Status {
+	case http.StatusRequestEntityTooLarge:
+		return true
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		body := strings.ToLower(e.Body)
+		for _, fragment := range textRejections[e.API] {
+			if strings.Contains(body, fragment) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rejectedError keeps an SDK error while matching ErrTextRejected.
+type rejectedError struct{ err error }
+
+func (e *rejectedError) Error() string   { return e.err.Error() }
+func (e *rejectedError) Unwrap() []error { return []error{e.err, ErrTextRejected} }
+
+// textRejected marks err, which an SDK returned for the text, as
+// ErrTextRejected.
+func textRejected(err error) error {
+	return &rejectedError{err: err}
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIError_TextRejected(t *testing.T) {
+	for status, want := range map[int]bool{400: false, 413: true, 422: false, 401: false, 429: false, 500: false} {
+		err := fmt.Errorf("synthesize: %w", &APIError{API: "OpenAI", Status: status, Body: "detail"})
+		assert.Equal(t, want, errors.Is(err, ErrTextRejected), status)
+
+		var apiErr *APIError
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, status, apiErr.Status)
+	}
+
+	tests := []struct {
+		api    string
+		status int
+		body   string
+		want   bool
+	}{
+		{"OpenAI", 400, `{"error":{"message":"[{'type': 'string_too_long', 'loc': ('body', 'input')}]"}}`, true},
+		{"OpenAI", 400, `{"error":{"message":"Invalid value: 'nova2'. Supported values are: 'alloy'"}}`, false},
+		{"ElevenLabs", 400, "max_character_limit_exceeded: Text is too long", true},
+		{"ElevenLabs", 400, "voice_not_found: A voice with the voice_id was not found", false},
+		{"ElevenLabs", 422, "Invalid stability value", false},
+		{"XTTS", 422, "Text length exceeds the character limit of 250", true},
+		{"Unknown", 400, "text is too long", false},
+	}
+	for _, tt := range tests {
+		err := &APIError{API: tt.api, Status: tt.status, Body: tt.body}
+		assert.Equal(t, tt.want, errors.Is(err, ErrTextRejected), tt.body)
+	}
+	assert.Equal(t, "OpenAI API error: status 413, body: too long",
+		(&APIError{API: "OpenAI", Status: 413, Body: "too long"}).Error())
+}
+
+func TestTextRejected(t *testing.T) {
+	sdkErr := errors.New("TextLengthExceededException: too long")
+	err := textRejected(sdkErr)
+	assert.ErrorIs(t, err, ErrTextRejected)
+	assert.ErrorIs(t, err, sdkErr)
+	assert.Equal(t, sdkErr.Error(), err.Error())
+}
@@ -20,18 +20,31 @@ func (f *DefaultFactory) CreateProvider(providerName string, config map[string]i
 		return f.createOpenAIProvider(config)
 	case "elevenlabs":
 		return f.createElevenLabsProvider(config)
-	case "polly":
-		return f.createPollyProvider(config)
-	case "gcp":
-		return f.createGCPProvider(config)
-	default:
-		return nil, fmt.Errorf("unknown provider: %s", providerName)
+	case "xtts":
+		return XTTSProviderFromConfig(config)
+	case "sherpa-cli":
+		return SherpaCLIProviderFromConfig(config)
+	case "azure":
+		return AzureProviderFromConfig(config)
+	}
+	if create, ok := optionalProviders[providerName]; ok {
+		return create(config)
 	}
+	if isKnownProvider(providerName) {
+		return nil, notCompiledError(providerName)
+	}
+	return nil, fmt.Errorf("unknown provider: %s", providerName)
 }
 
-// ListProviders returns available provider names
+// ListProviders returns the provider names compiled into this binary
 func (f *DefaultFactory) ListProviders() []string {
-	return []string{"openai", "elevenlabs", "polly", "gcp"}
+	var names []string
+	for _, name := range AllProviders {
+		if Compiled(name) {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
 // createOpenAIProvider creates an OpenAI provider with configuration.
@@ -88,18 +101,6 @@ func (f *DefaultFactory) createElevenLabsProvider(config map[string]interface{})
 	return ElevenLabsProviderFromConfig(config)
 }
 
-// createPollyProvider creates an Amazon Polly provider with configuration
-func (f *DefaultFactory) createPollyProvider(config map[string]interface{}) (Provider, error) {
-	// Region is optional, defaults to us-east-1 in the provider
-	return PollyProviderFromConfig(config)
-}
-
-// createGCPProvider creates a Google Cloud TTS provider with configuration
-func (f *DefaultFactory) createGCPProvider(config map[string]interface{}) (Provider, error) {
-	// Authentication is handled via GOOGLE_APPLICATION_CREDENTIALS or ADC
-	return GCPProviderFromConfig(config)
-}
-
 // GetProviderWithDefaults creates a provider with default configuration
 func (f *DefaultFactory) GetProviderWithDefaults(providerName string) (Provider, error) {
 	config := make(map[string]interface{})
@@ -133,6 +134,14 @@ func (f *DefaultFactory) GetProviderWithDefaults(providerName string) (Provider,
 		config["language"] = "ja-JP"
 		config["engine"] = "neural2"
 		config["format"] = "mp3"
+	case "azure":
+		// Azure Speech defaults - key and region from AZURE_SPEECH_KEY/REGION
+		config["voice"] = AzureDefaultVoice
+		config["format"] = "mp3"
+	case "xtts":
+		// Coqui XTTS v2 defaults - local server, studio speaker voice
+		config["base_url"] = XTTSBaseURL
+		config["language"] = XTTSDefaultLanguage
 	}
 
 	return f.CreateProvider(providerName, config)
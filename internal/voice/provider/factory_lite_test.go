@@ -0,0 +1,19 @@
+//go:build lite
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateProvider_CompiledOut(t *testing.T) {
+	factory := NewFactory()
+	for _, name := range []string{"polly", "gcp"} {
+		assert.False(t, Compiled(name))
+		_, err := factory.CreateProvider(name, map[string]interface{}{})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not included in this lite build")
+	}
+}
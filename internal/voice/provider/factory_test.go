@@ -15,11 +15,18 @@ func TestListProviders(t *testing.T) {
 	factory := NewFactory()
 	providers := factory.ListProviders()
 
-	assert.Len(t, providers, 4)
+	if BuildFlavor == "lite" {
+		assert.Equal(t, []string{"openai", "elevenlabs", "xtts", "sherpa-cli", "azure"}, providers)
+		return
+	}
+	assert.Len(t, providers, 7)
 	assert.Contains(t, providers, "openai")
 	assert.Contains(t, providers, "elevenlabs")
 	assert.Contains(t, providers, "polly")
 	assert.Contains(t, providers, "gcp")
+	assert.Contains(t, providers, "xtts")
+	assert.Contains(t, providers, "sherpa-cli")
+	assert.Contains(t, providers, "azure")
 }
 
 func TestCreateProvider_UnknownProvider(t *testing.T) {
@@ -161,6 +168,9 @@ func TestCreateProvider_ElevenLabs(t *testing.T) {
 }
 
 func TestCreateProvider_Polly(t *testing.T) {
+	if !Compiled("polly") {
+		t.Skip("polly is not included in the lite build")
+	}
 	factory := NewFactory()
 
 	t.Run("creates polly provider with default config", func(t *testing.T) {
@@ -185,6 +195,9 @@ func TestCreateProvider_GCP(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping GCP provider test in short mode - requires network access")
 	}
+	if !Compiled("gcp") {
+		t.Skip("gcp is not included in the lite build")
+	}
 
 	factory := NewFactory()
 
@@ -219,6 +232,9 @@ func TestGetProviderWithDefaults(t *testing.T) {
 	})
 
 	t.Run("polly with defaults", func(t *testing.T) {
+		if !Compiled("polly") {
+			t.Skip("polly is not included in the lite build")
+		}
 		provider, err := factory.GetProviderWithDefaults("polly")
 		assert.NoError(t, err)
 		assert.NotNil(t, provider)
@@ -229,6 +245,9 @@ func TestGetProviderWithDefaults(t *testing.T) {
 		if testing.Short() {
 			t.Skip("Skipping GCP provider test in short mode - requires network access")
 		}
+		if !Compiled("gcp") {
+			t.Skip("gcp is not included in the lite build")
+		}
 		provider, err := factory.GetProviderWithDefaults("gcp")
 		assert.NoError(t, err)
 		assert.NotNil(t, provider)
@@ -244,8 +263,29 @@ func TestGetProviderWithDefaults(t *testing.T) {
 		assert.Equal(t, "elevenlabs", provider.Name())
 	})
 
+	t.Run("azure with defaults", func(t *testing.T) {
+		t.Setenv("AZURE_SPEECH_KEY", "test-key")
+		t.Setenv("AZURE_SPEECH_REGION", "japaneast")
+
+		provider, err := factory.GetProviderWithDefaults("azure")
+		assert.NoError(t, err)
+		assert.NotNil(t, provider)
+		assert.Equal(t, "azure", provider.Name())
+	})
+
 	t.Run("unknown provider returns error", func(t *testing.T) {
 		_, err := factory.GetProviderWithDefaults("unknown")
 		assert.Error(t, err)
 	})
 }
+
+func TestCompiled(t *testing.T) {
+	for _, name := range []string{"openai", "elevenlabs", "xtts", "sherpa-cli", "azure"} {
+		assert.True(t, Compiled(name), name)
+	}
+	assert.False(t, Compiled("unknown"))
+	if BuildFlavor == "full" {
+		assert.True(t, Compiled("polly"))
+		assert.True(t, Compiled("gcp"))
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !lite
+
+package provider
+
+// BuildFlavor names the provider set compiled into this binary: "full"
+// includes every provider, "lite" (-tags lite) omits the AWS and Google Cloud
+// SDKs.
+const BuildFlavor = "full"
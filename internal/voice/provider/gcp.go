@@ -1,3 +1,5 @@
+//go:build !lite
+
 package provider
 
 import (
@@ -10,8 +12,17 @@ import (
 	texttospeech "cloud.google.com/go/texttospeech/apiv1"
 	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+func init() {
+	// Authentication is handled via GOOGLE_APPLICATION_CREDENTIALS or ADC
+	registerOptional("gcp", func(config map[string]interface{}) (Provider, error) {
+		return GCPProviderFromConfig(config)
+	})
+}
+
 // GCPProvider implements the Provider interface for Google Cloud Text-to-Speech
 type GCPProvider struct {
 	client    *texttospeech.Client
@@ -215,6 +226,10 @@ func (p *GCPProvider) Synthesize(ctx context.Context, text string, options Synth
 		Voice:       voiceSelection,
 		AudioConfig: audioConfig,
 	})
+	if status.Code(err) == codes.InvalidArgument {
+		// Text over the request limit, or invalid SSML.
+		err = textRejected(err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to synthesize speech: %w", err)
 	}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -135,7 +136,7 @@ func (p *OpenAIProvider) Synthesize(ctx context.Context, text string, options Sy
 	if resp.StatusCode != http.StatusOK {
 		defer resp.Body.Close()
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("OpenAI API error: status %d, body: %s", resp.StatusCode, string(body))
+		return nil, &APIError{API: "OpenAI", Status: resp.StatusCode, Body: string(body)}
 	}
 
 	log.Debug().
@@ -177,6 +178,54 @@ func (p *OpenAIProvider) IsAvailable(ctx context.Context) bool {
 	return resp.StatusCode == http.StatusOK
 }
 
+// Account lists the speech models the key can use. OpenAI does not report
+// plans or quotas to API keys, so only Detail is filled in.
+func (p *OpenAIProvider) Account(ctx context.Context) (*Account, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+OpenAIModelsEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create models request: %w", err)
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make models request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("OpenAI models API error: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var models struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&models); err != nil {
+		return nil, fmt.Errorf("failed to decode models response: %w", err)
+	}
+	var speech []string
+	for _, m := range models.Data {
+		if strings.Contains(m.ID, "tts") {
+			speech = append(speech, m.ID)
+		}
+	}
+	sort.Strings(speech)
+	account := &Account{}
+	switch {
+	case len(speech) > 0:
+		account.Detail = "speech models: " + strings.Join(speech, ", ")
+	case len(models.Data) > 0:
+		account.Detail = "no speech models listed for this key"
+	}
+	return account, nil
+}
+
 // openAIOfficialHost is the host of the official OpenAI API. A base_url that
 // resolves to this host is treated as the official endpoint regardless of path
 // or trailing slash, so api_key cannot be bypassed via spelling variations.
@@ -353,3 +353,34 @@ func TestOpenAIError_String(t *testing.T) {
 	assert.Contains(t, result, "invalid_request_error")
 	assert.Contains(t, result, "invalid_api_key")
 }
+
+func TestOpenAIProvider_Account(t *testing.T) {
+	t.Run("lists speech models", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, OpenAIModelsEndpoint, r.URL.Path)
+			assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"data": [{"id": "gpt-4o"}, {"id": "tts-1-hd"}, {"id": "gpt-4o-mini-tts"}, {"id": "tts-1"}]}`))
+		}))
+		defer server.Close()
+
+		provider := NewOpenAIProvider("sk-test")
+		provider.baseURL = server.URL
+
+		account, err := provider.Account(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "speech models: gpt-4o-mini-tts, tts-1, tts-1-hd", account.Detail)
+	})
+
+	t.Run("rejected key is an error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		provider := NewOpenAIProvider("sk-bad")
+		provider.baseURL = server.URL
+
+		_, err := provider.Account(context.Background())
+		assert.ErrorContains(t, err, "status 401")
+	})
+}
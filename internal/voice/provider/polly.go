@@ -1,7 +1,11 @@
+//go:build !lite
+
 package provider
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -16,6 +20,13 @@ import (
 	"golang.org/x/text/language"
 )
 
+func init() {
+	// Region is optional, defaults to us-east-1 in the provider
+	registerOptional("polly", func(config map[string]interface{}) (Provider, error) {
+		return PollyProviderFromConfig(config)
+	})
+}
+
 // PollyClient interface defines the methods we need from the Polly client
 type PollyClient interface {
 	DescribeVoices(ctx context.Context, params *polly.DescribeVoicesInput, optFns ...func(*polly.Options)) (*polly.DescribeVoicesOutput, error)
@@ -90,8 +101,117 @@ func (p *PollyProvider) ListVoices(ctx context.Context) ([]Voice, error) {
 	return voices, nil
 }
 
+// pollyError marks the errors Polly returns for text that is too long or
+// invalid SSML as ErrTextRejected.
+func pollyError(err error) error {
+	var tooLong *types.TextLengthExceededException
+	var badSSML *types.InvalidSsmlException
+	if errors.As(err, &tooLong) || errors.As(err, &badSSML) {
+		return textRejected(err)
+	}
+	return err
+}
+
 // Synthesize generates audio from text using Amazon Polly
 func (p *PollyProvider) Synthesize(ctx context.Context, text string, options SynthesizeOptions) (io.ReadCloser, error) {
+	input, err := p.speechInput(text, options)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug().
+		Str("voice_id", string(input.VoiceId)).
+		Str("output_format", string(input.OutputFormat)).
+		Str("engine", string(input.Engine)).
+		Str("text_type", string(input.TextType)).
+		Msg("Making Polly synthesis request")
+
+	// Make synthesis request
+	result, err := p.client.SynthesizeSpeech(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to synthesize speech: %w", pollyError(err))
+	}
+
+	log.Debug().
+		Str("content_type", aws.ToString(result.ContentType)).
+		Msg("Polly synthesis request successful")
+
+	return result.AudioStream, nil
+}
+
+// SynthesizeWithMarks generates audio and requests the speech marks of the
+// given types (sentence, word, viseme) for the same text and voice. Polly
+// returns marks from a separate request, so they cost one more call.
+func (p *PollyProvider) SynthesizeWithMarks(ctx context.Context, text string, options SynthesizeOptions, markTypes []string) (*SynthesisResult, error) {
+	input, err := p.speechInput(text, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var marks []SpeechMark
+	if len(markTypes) > 0 {
+		marks, err = p.speechMarks(ctx, *input, markTypes)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to get Polly speech marks; continuing without timing")
+		}
+	}
+
+	audio, err := p.Synthesize(ctx, text, options)
+	if err != nil {
+		return nil, err
+	}
+	return &SynthesisResult{Audio: audio, Marks: marks}, nil
+}
+
+// speechMarks requests the speech marks for an audio request.
+func (p *PollyProvider) speechMarks(ctx context.Context, input polly.SynthesizeSpeechInput, markTypes []string) ([]SpeechMark, error) {
+	input.OutputFormat = types.OutputFormatJson
+	input.SampleRate = nil
+	input.SpeechMarkTypes = nil
+	for _, markType := range markTypes {
+		input.SpeechMarkTypes = append(input.SpeechMarkTypes, types.SpeechMarkType(markType))
+	}
+
+	result, err := p.client.SynthesizeSpeech(ctx, &input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get speech marks: %w", err)
+	}
+	defer result.AudioStream.Close()
+	return parseSpeechMarks(result.AudioStream)
+}
+
+// pollySpeechMark is one line of Polly's speech marks stream.
+type pollySpeechMark struct {
+	Time  int64  `json:"time"`
+	Type  string `json:"type"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Value string `json:"value"`
+}
+
+// parseSpeechMarks reads Polly's newline-delimited JSON speech marks.
+func parseSpeechMarks(r io.Reader) ([]SpeechMark, error) {
+	var marks []SpeechMark
+	decoder := json.NewDecoder(r)
+	for {
+		var mark pollySpeechMark
+		if err := decoder.Decode(&mark); err == io.EOF {
+			return marks, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid speech marks: %w", err)
+		}
+		marks = append(marks, SpeechMark{
+			Time:  time.Duration(mark.Time) * time.Millisecond,
+			Type:  mark.Type,
+			Value: mark.Value,
+			Start: mark.Start,
+			End:   mark.End,
+		})
+	}
+}
+
+// speechInput builds the synthesis request for text and options.
+func (p *PollyProvider) speechInput(text string, options SynthesizeOptions) (*polly.SynthesizeSpeechInput, error) {
 	if text == "" {
 		return nil, fmt.Errorf("text cannot be empty")
 	}
@@ -162,25 +282,7 @@ func (p *PollyProvider) Synthesize(ctx context.Context, text string, options Syn
 	} else {
 		input.TextType = types.TextTypeText
 	}
-
-	log.Debug().
-		Str("voice_id", voiceID).
-		Str("output_format", string(pollyFormat)).
-		Str("engine", string(engine)).
-		Str("text_type", string(input.TextType)).
-		Msg("Making Polly synthesis request")
-
-	// Make synthesis request
-	result, err := p.client.SynthesizeSpeech(ctx, input)
-	if err != nil {
-		return nil, fmt.Errorf("failed to synthesize speech: %w", err)
-	}
-
-	log.Debug().
-		Str("content_type", aws.ToString(result.ContentType)).
-		Msg("Polly synthesis request successful")
-
-	return result.AudioStream, nil
+	return input, nil
 }
 
 // IsAvailable checks if Amazon Polly provider is available
@@ -1,3 +1,5 @@
+//go:build !lite
+
 package provider
 
 import (
@@ -7,6 +9,7 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/polly"
@@ -592,3 +595,64 @@ func TestFormatSupportedEngines(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSpeechMarks(t *testing.T) {
+	stream := `{"time":6,"type":"word","start":0,"end":5,"value":"Hello"}
+{"time":6,"type":"viseme","value":"k"}
+{"time":120,"type":"viseme","value":"@"}
+`
+	marks, err := parseSpeechMarks(strings.NewReader(stream))
+	assert.NoError(t, err)
+	assert.Equal(t, []SpeechMark{
+		{Time: 6 * time.Millisecond, Type: MarkWord, Value: "Hello", Start: 0, End: 5},
+		{Time: 6 * time.Millisecond, Type: MarkViseme, Value: "k"},
+		{Time: 120 * time.Millisecond, Type: MarkViseme, Value: "@"},
+	}, marks)
+
+	_, err = parseSpeechMarks(strings.NewReader("not json"))
+	assert.Error(t, err)
+}
+
+func TestPollyProvider_SynthesizeWithMarks(t *testing.T) {
+	isMarks := func(input *polly.SynthesizeSpeechInput) bool {
+		return input.OutputFormat == types.OutputFormatJson
+	}
+
+	t.Run("audio and marks", func(t *testing.T) {
+		mockClient := &MockPollyClient{}
+		provider := &PollyProvider{client: mockClient, region: "us-east-1"}
+		mockClient.On("SynthesizeSpeech", mock.Anything, mock.MatchedBy(func(input *polly.SynthesizeSpeechInput) bool {
+			if !isMarks(input) {
+				return false
+			}
+			assert.Equal(t, []types.SpeechMarkType{types.SpeechMarkTypeWord, types.SpeechMarkTypeViseme}, input.SpeechMarkTypes)
+			assert.Nil(t, input.SampleRate)
+			return true
+		})).Return(&polly.SynthesizeSpeechOutput{
+			AudioStream: NewMockReadCloser([]byte(`{"time":0,"type":"word","start":0,"end":2,"value":"Hi"}`)),
+		}, nil)
+		mockClient.On("SynthesizeSpeech", mock.Anything, mock.MatchedBy(func(input *polly.SynthesizeSpeechInput) bool {
+			return !isMarks(input)
+		})).Return(&polly.SynthesizeSpeechOutput{AudioStream: NewMockReadCloser([]byte("audio"))}, nil)
+
+		result, err := provider.SynthesizeWithMarks(context.Background(), "Hi", SynthesizeOptions{SampleRate: "24000"}, []string{MarkWord, MarkViseme})
+		assert.NoError(t, err)
+		data, _ := io.ReadAll(result.Audio)
+		assert.Equal(t, "audio", string(data))
+		assert.Equal(t, []SpeechMark{{Type: MarkWord, Value: "Hi", End: 2}}, result.Marks)
+	})
+
+	t.Run("marks failure keeps audio", func(t *testing.T) {
+		mockClient := &MockPollyClient{}
+		provider := &PollyProvider{client: mockClient, region: "us-east-1"}
+		mockClient.On("SynthesizeSpeech", mock.Anything, mock.MatchedBy(isMarks)).Return(nil, errors.New("engine does not support speech marks"))
+		mockClient.On("SynthesizeSpeech", mock.Anything, mock.MatchedBy(func(input *polly.SynthesizeSpeechInput) bool {
+			return !isMarks(input)
+		})).Return(&polly.SynthesizeSpeechOutput{AudioStream: NewMockReadCloser([]byte("audio"))}, nil)
+
+		result, err := provider.SynthesizeWithMarks(context.Background(), "Hi", SynthesizeOptions{}, []string{MarkViseme})
+		assert.NoError(t, err)
+		assert.NotNil(t, result.Audio)
+		assert.Empty(t, result.Marks)
+	})
+}
@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"io"
+	"time"
 )
 
 // Provider defines the interface for TTS providers
@@ -20,6 +21,38 @@ type Provider interface {
 	IsAvailable(ctx context.Context) bool
 }
 
+// Speech mark types, named as in Amazon Polly.
+const (
+	MarkSentence = "sentence"
+	MarkWord     = "word"
+	MarkViseme   = "viseme"
+)
+
+// SpeechMark is a timed sentence, word, or viseme within synthesized audio.
+// Start and End are the byte offsets of sentences and words in the input
+// text; Value is the word, sentence, or the provider's viseme code.
+type SpeechMark struct {
+	Time  time.Duration
+	Type  string
+	Value string
+	Start int
+	End   int
+}
+
+// SynthesisResult is synthesized audio with the timing of its contents.
+type SynthesisResult struct {
+	Audio io.ReadCloser
+	Marks []SpeechMark
+}
+
+// TimedProvider is implemented by providers that can report speech marks
+// alongside the audio. Marks are best effort: when they cannot be retrieved,
+// the audio is returned without them.
+type TimedProvider interface {
+	Provider
+	SynthesizeWithMarks(ctx context.Context, text string, options SynthesizeOptions, markTypes []string) (*SynthesisResult, error)
+}
+
 // Voice represents a voice option
 type Voice struct {
 	ID          string `json:"id"`
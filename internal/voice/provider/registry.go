@@ -0,0 +1,41 @@
+package provider
+
+import "fmt"
+
+// AllProviders lists every cloud/HTTP provider ccpersona supports, whether or
+// not it is compiled into the running binary.
+var AllProviders = []string{"openai", "elevenlabs", "polly", "gcp", "xtts", "sherpa-cli", "azure"}
+
+// optionalProviders holds providers with heavy SDK dependencies (AWS, Google
+// Cloud). Their files carry the !lite build tag and register themselves here,
+// so a lite build drops the SDKs entirely.
+var optionalProviders = map[string]func(config map[string]interface{}) (Provider, error){}
+
+func registerOptional(name string, create func(config map[string]interface{}) (Provider, error)) {
+	optionalProviders[name] = create
+}
+
+// Compiled reports whether the named provider is available in this binary.
+func Compiled(name string) bool {
+	switch name {
+	case "openai", "elevenlabs", "xtts", "sherpa-cli", "azure":
+		return true
+	}
+	_, ok := optionalProviders[name]
+	return ok
+}
+
+func isKnownProvider(name string) bool {
+	for _, p := range AllProviders {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// notCompiledError explains that a provider exists but was left out of this
+// build, instead of reporting it as unknown.
+func notCompiledError(name string) error {
+	return fmt.Errorf("provider %q is not included in this %s build of ccpersona; install the full release, or build without -tags lite", name, BuildFlavor)
+}
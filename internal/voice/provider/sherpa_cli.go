@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/daikw/ccpersona/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// SherpaDefaultBinary is the sherpa-onnx offline TTS executable shipped with
+// sherpa-onnx releases.
+const SherpaDefaultBinary = "sherpa-onnx-offline-tts"
+
+// SherpaCLIProvider synthesizes speech offline with sherpa-onnx VITS models
+// through the external sherpa-onnx-offline-tts binary, which must be
+// installed separately. No HTTP engine is involved, but nothing runs
+// in-process either: each request runs the binary against model files on
+// disk. (The Go bindings need cgo, which release builds disable.)
+type SherpaCLIProvider struct {
+	binary   string
+	modelDir string
+	threads  int
+}
+
+// sherpaModelFiles is the VITS layout found in a model directory.
+type sherpaModelFiles struct {
+	Model   string
+	Tokens  string
+	DataDir string // espeak-ng-data (piper models)
+	Lexicon string // lexicon.txt (non-piper models)
+}
+
+// NewSherpaCLIProvider creates a provider for a model directory.
+func NewSherpaCLIProvider(modelDir string) *SherpaCLIProvider {
+	return &SherpaCLIProvider{binary: SherpaDefaultBinary, modelDir: modelDir, threads: 2}
+}
+
+// Name returns the provider name
+func (p *SherpaCLIProvider) Name() string {
+	return "sherpa-cli"
+}
+
+// ListVoices returns the installed models. The speaker ID within a
+// multi-speaker model is chosen with the voice option.
+func (p *SherpaCLIProvider) ListVoices(ctx context.Context) ([]Voice, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	installed, err := models.List(home)
+	if err != nil {
+		return nil, err
+	}
+	voices := make([]Voice, 0, len(installed))
+	for _, m := range installed {
+		voices = append(voices, Voice{ID: m.Name, Name: m.Name, Description: "sherpa-onnx model (" + m.Path + ")"})
+	}
+	return voices, nil
+}
+
+// Synthesize runs the sherpa-onnx binary and returns the WAV it produces.
+func (p *SherpaCLIProvider) Synthesize(ctx context.Context, text string, options SynthesizeOptions) (io.ReadCloser, error) {
+	if text == "" {
+		return nil, fmt.Errorf("text cannot be empty")
+	}
+	files, err := findSherpaModelFiles(p.modelDir)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := os.CreateTemp("", "ccpersona_voice_sherpa_*.wav")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	outPath := out.Name()
+	out.Close()
+	defer os.Remove(outPath)
+
+	args := sherpaArgs(files, options, p.threads, outPath, text)
+	cmd := exec.CommandContext(ctx, p.binary, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	log.Debug().
+		Str("binary", p.binary).
+		Str("model", files.Model).
+		Str("voice", options.Voice).
+		Msg("Running sherpa-onnx TTS")
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("sherpa-onnx failed: %w: %s", err, strings.TrimSpace(lastLines(stderr.String(), 3)))
+	}
+
+	audio, err := os.ReadFile(outPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sherpa-onnx output: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(audio)), nil
+}
+
+// IsAvailable reports whether the binary is on PATH and the model directory
+// has a usable layout.
+func (p *SherpaCLIProvider) IsAvailable(ctx context.Context) bool {
+	if _, err := exec.LookPath(p.binary); err != nil {
+		return false
+	}
+	_, err := findSherpaModelFiles(p.modelDir)
+	return err == nil
+}
+
+// sherpaArgs builds the sherpa-onnx-offline-tts command line. The text is the
+// final positional argument and never goes through a shell.
+func sherpaArgs(files sherpaModelFiles, options SynthesizeOptions, threads int, outPath, text string) []string {
+	args := []string{
+		"--vits-model=" + files.Model,
+		"--vits-tokens=" + files.Tokens,
+		"--num-threads=" + strconv.Itoa(threads),
+		"--output-filename=" + outPath,
+	}
+	if files.DataDir != "" {
+		args = append(args, "--vits-data-dir="+files.DataDir)
+	}
+	if files.Lexicon != "" {
+		args = append(args, "--vits-lexicon="+files.Lexicon)
+	}
+	if sid, err := strconv.Atoi(options.Voice); err == nil && sid >= 0 {
+		args = append(args, "--sid="+strconv.Itoa(sid))
+	}
+	if options.Speed > 0 && options.Speed != 1.0 {
+		// VITS length scale is the inverse of speaking rate.
+		args = append(args, "--vits-length-scale="+strconv.FormatFloat(1/options.Speed, 'f', 3, 64))
+	}
+	// A leading '-' would be parsed as a flag.
+	if strings.HasPrefix(text, "-") {
+		text = " " + text
+	}
+	return append(args, text)
+}
+
+// findSherpaModelFiles locates the VITS model files in dir.
+func findSherpaModelFiles(dir string) (sherpaModelFiles, error) {
+	var files sherpaModelFiles
+	if dir == "" {
+		return files, fmt.Errorf("no sherpa-onnx model configured (set voice.model to an installed model name)")
+	}
+	onnx, _ := filepath.Glob(filepath.Join(dir, "*.onnx"))
+	if len(onnx) == 0 {
+		return files, fmt.Errorf("no .onnx model found in %s", dir)
+	}
+	sort.Strings(onnx)
+	files.Model = onnx[0]
+	// Prefer the full-precision model when an int8 variant is also present.
+	for _, path := range onnx {
+		if !strings.Contains(filepath.Base(path), "int8") {
+			files.Model = path
+			break
+		}
+	}
+
+	files.Tokens = filepath.Join(dir, "tokens.txt")
+	if _, err := os.Stat(files.Tokens); err != nil {
+		return files, fmt.Errorf("tokens.txt not found in %s", dir)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "espeak-ng-data")); err == nil && info.IsDir() {
+		files.DataDir = filepath.Join(dir, "espeak-ng-data")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "lexicon.txt")); err == nil {
+		files.Lexicon = filepath.Join(dir, "lexicon.txt")
+	}
+	return files, nil
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SherpaCLIProviderFromConfig creates a sherpa-onnx CLI provider. model is an
+// installed model name or a model directory; binary and num_threads are
+// optional.
+func SherpaCLIProviderFromConfig(config map[string]interface{}) (*SherpaCLIProvider, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("resolve home dir: %w", err)
+	}
+	modelValue, _ := config["model"].(string)
+	modelDir := ""
+	if modelValue != "" {
+		modelDir, err = models.ResolveDir(home, modelValue)
+		if err != nil {
+			return nil, err
+		}
+	} else if installed, err := models.List(home); err == nil && len(installed) == 1 {
+		// With a single pulled model there is nothing to choose.
+		modelDir = installed[0].Path
+	}
+
+	provider := NewSherpaCLIProvider(modelDir)
+	if binary, ok := config["binary"].(string); ok && binary != "" {
+		provider.binary = binary
+	}
+	if threads, ok := configInt(config["num_threads"]); ok && threads > 0 {
+		provider.threads = threads
+	}
+	return provider, nil
+}
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSherpaModel(t *testing.T, withData bool) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "voice.int8.onnx"), []byte("x"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "voice.onnx"), []byte("x"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tokens.txt"), []byte("a 1"), 0o644))
+	if withData {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "espeak-ng-data"), 0o755))
+	}
+	return dir
+}
+
+func TestFindSherpaModelFiles(t *testing.T) {
+	dir := writeSherpaModel(t, true)
+	files, err := findSherpaModelFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "voice.onnx"), files.Model, "full-precision model preferred")
+	assert.Equal(t, filepath.Join(dir, "tokens.txt"), files.Tokens)
+	assert.Equal(t, filepath.Join(dir, "espeak-ng-data"), files.DataDir)
+	assert.Empty(t, files.Lexicon)
+
+	_, err = findSherpaModelFiles(t.TempDir())
+	assert.Error(t, err)
+	_, err = findSherpaModelFiles("")
+	assert.Error(t, err)
+}
+
+func TestSherpaArgs(t *testing.T) {
+	files := sherpaModelFiles{Model: "m.onnx", Tokens: "t.txt", DataDir: "data"}
+	args := sherpaArgs(files, SynthesizeOptions{Voice: "3", Speed: 2.0}, 4, "/tmp/out.wav", "-rf hello")
+
+	assert.Contains(t, args, "--vits-model=m.onnx")
+	assert.Contains(t, args, "--vits-tokens=t.txt")
+	assert.Contains(t, args, "--vits-data-dir=data")
+	assert.Contains(t, args, "--sid=3")
+	assert.Contains(t, args, "--num-threads=4")
+	assert.Contains(t, args, "--vits-length-scale=0.500")
+	assert.Contains(t, args, "--output-filename=/tmp/out.wav")
+	assert.Equal(t, " -rf hello", args[len(args)-1], "text must not be parsed as a flag")
+
+	args = sherpaArgs(files, SynthesizeOptions{Voice: "alloy", Speed: 1.0}, 2, "o.wav", "hi")
+	for _, a := range args {
+		assert.False(t, strings.HasPrefix(a, "--sid="), "non-numeric voice is not a speaker id")
+		assert.False(t, strings.HasPrefix(a, "--vits-length-scale="))
+	}
+}
+
+func TestSherpaCLIProvider_SynthesizeRunsBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as a fake binary")
+	}
+	bin := filepath.Join(t.TempDir(), "fake-sherpa")
+	script := "#!/bin/sh\nfor a in \"$@\"; do case \"$a\" in --output-filename=*) out=\"${a#--output-filename=}\";; esac; done\nprintf 'RIFF-audio' > \"$out\"\n"
+	require.NoError(t, os.WriteFile(bin, []byte(script), 0o755))
+
+	p := NewSherpaCLIProvider(writeSherpaModel(t, false))
+	p.binary = bin
+	assert.Equal(t, "sherpa-cli", p.Name())
+	assert.True(t, p.IsAvailable(context.Background()))
+
+	rc, err := p.Synthesize(context.Background(), "hello", SynthesizeOptions{})
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "RIFF-audio", string(data))
+}
+
+func TestSherpaCLIProviderFromConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	_, err := SherpaCLIProviderFromConfig(map[string]interface{}{"model": "not-installed"})
+	assert.Error(t, err)
+
+	dir := writeSherpaModel(t, false)
+	p, err := SherpaCLIProviderFromConfig(map[string]interface{}{"model": dir, "binary": "custom", "num_threads": 8})
+	require.NoError(t, err)
+	assert.Equal(t, dir, p.modelDir)
+	assert.Equal(t, "custom", p.binary)
+	assert.Equal(t, 8, p.threads)
+}
@@ -0,0 +1,328 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	XTTSBaseURL               = "http://127.0.0.1:8000"
+	XTTSCloneSpeakerEndpoint  = "/clone_speaker"
+	XTTSTTSEndpoint           = "/tts"
+	XTTSStudioSpeakerEndpoint = "/studio_speakers"
+	XTTSLanguagesEndpoint     = "/languages"
+	XTTSDefaultLanguage       = "ja"
+)
+
+// xttsSpeaker holds the conditioning latents XTTS v2 needs for a voice. They
+// are kept as raw JSON because ccpersona only passes them back to the server.
+type xttsSpeaker struct {
+	GPTCondLatent    json.RawMessage `json:"gpt_cond_latent"`
+	SpeakerEmbedding json.RawMessage `json:"speaker_embedding"`
+}
+
+// XTTSProvider implements the Provider interface for Coqui XTTS v2 servers
+// (coqui-ai/xtts-streaming-server API). With a reference WAV it clones that
+// voice zero-shot; otherwise it uses one of the server's studio speakers.
+type XTTSProvider struct {
+	baseURL    string
+	speakerWAV string
+	language   string
+	cacheDir   string
+	httpClient *http.Client
+}
+
+// NewXTTSProvider creates a new XTTS provider for the given server.
+func NewXTTSProvider(baseURL string) *XTTSProvider {
+	cacheDir := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "ccpersona", "xtts")
+	}
+	return &XTTSProvider{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		language: XTTSDefaultLanguage,
+		cacheDir: cacheDir,
+		httpClient: &http.Client{
+			// Local GPU inference is slower than cloud APIs.
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+// Name returns the provider name
+func (p *XTTSProvider) Name() string {
+	return "xtts"
+}
+
+// ListVoices returns the configured reference voice and the server's studio
+// speakers.
+func (p *XTTSProvider) ListVoices(ctx context.Context) ([]Voice, error) {
+	var voices []Voice
+	if p.speakerWAV != "" {
+		voices = append(voices, Voice{
+			ID:          "reference",
+			Name:        filepath.Base(p.speakerWAV),
+			Language:    p.language,
+			Description: "Cloned from the persona's reference WAV",
+		})
+	}
+
+	speakers, err := p.studioSpeakers(ctx)
+	if err != nil {
+		return voices, err
+	}
+	names := make([]string, 0, len(speakers))
+	for name := range speakers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		voices = append(voices, Voice{ID: name, Name: name, Language: "multilingual", Description: "XTTS studio speaker"})
+	}
+	return voices, nil
+}
+
+// Synthesize generates WAV audio. The reference WAV wins over options.Voice,
+// which selects a studio speaker.
+func (p *XTTSProvider) Synthesize(ctx context.Context, text string, options SynthesizeOptions) (io.ReadCloser, error) {
+	if text == "" {
+		return nil, fmt.Errorf("text cannot be empty")
+	}
+
+	speaker, err := p.speaker(ctx, options.Voice)
+	if err != nil {
+		return nil, err
+	}
+
+	language := options.Language
+	if language == "" {
+		language = p.language
+	}
+
+	requestBody := map[string]interface{}{
+		"text":              text,
+		"language":          language,
+		"gpt_cond_latent":   speaker.GPTCondLatent,
+		"speaker_embedding": speaker.SpeakerEmbedding,
+		"add_wav_header":    true,
+	}
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := p.baseURL + XTTSTTSEndpoint
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	log.Debug().
+		Str("endpoint", endpoint).
+		Str("language", language).
+		Bool("cloned", p.speakerWAV != "").
+		Msg("Making XTTS request")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{API: "XTTS", Status: resp.StatusCode, Body: string(body)}
+	}
+
+	// The server responds with the WAV as a base64 JSON string.
+	var encoded string
+	if err := json.NewDecoder(resp.Body).Decode(&encoded); err != nil {
+		return nil, fmt.Errorf("failed to decode XTTS response: %w", err)
+	}
+	audio, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode XTTS audio: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(audio)), nil
+}
+
+// IsAvailable checks whether the XTTS server responds.
+func (p *XTTSProvider) IsAvailable(ctx context.Context) bool {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+XTTSLanguagesEndpoint, nil)
+	if err != nil {
+		return false
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// speaker returns the conditioning latents for the request.
+func (p *XTTSProvider) speaker(ctx context.Context, voice string) (*xttsSpeaker, error) {
+	if p.speakerWAV != "" {
+		return p.clonedSpeaker(ctx)
+	}
+
+	speakers, err := p.studioSpeakers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if voice != "" {
+		if s, ok := speakers[voice]; ok {
+			return &s, nil
+		}
+		return nil, fmt.Errorf("XTTS studio speaker %q not found", voice)
+	}
+	// No reference and no voice: pick the first studio speaker by name so the
+	// choice is stable across runs.
+	names := make([]string, 0, len(speakers))
+	for name := range speakers {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("XTTS server has no studio speakers; configure reference_wav")
+	}
+	sort.Strings(names)
+	s := speakers[names[0]]
+	return &s, nil
+}
+
+// clonedSpeaker computes (or loads cached) latents for the reference WAV.
+// Cloning takes seconds on the server, so latents are cached by the WAV's
+// content hash.
+func (p *XTTSProvider) clonedSpeaker(ctx context.Context) (*xttsSpeaker, error) {
+	data, err := os.ReadFile(p.speakerWAV)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reference audio: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	cachePath := ""
+	if p.cacheDir != "" {
+		cachePath = filepath.Join(p.cacheDir, hex.EncodeToString(sum[:])+".json")
+		if cached, err := os.ReadFile(cachePath); err == nil {
+			var s xttsSpeaker
+			if err := json.Unmarshal(cached, &s); err == nil && len(s.GPTCondLatent) > 0 {
+				return &s, nil
+			}
+		}
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("wav_file", filepath.Base(p.speakerWAV))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build clone request: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to build clone request: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build clone request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+XTTSCloneSpeakerEndpoint, &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	log.Debug().Str("reference", p.speakerWAV).Msg("Cloning XTTS speaker from reference audio")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone speaker: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("XTTS clone error: status %d, body: %s", resp.StatusCode, string(msg))
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clone response: %w", err)
+	}
+	var s xttsSpeaker
+	if err := json.Unmarshal(raw, &s); err != nil || len(s.GPTCondLatent) == 0 {
+		return nil, fmt.Errorf("unexpected XTTS clone response")
+	}
+
+	if cachePath != "" {
+		if err := os.MkdirAll(p.cacheDir, 0o755); err == nil {
+			if err := os.WriteFile(cachePath, raw, 0o644); err != nil {
+				log.Debug().Err(err).Msg("Failed to cache XTTS speaker latents")
+			}
+		}
+	}
+	return &s, nil
+}
+
+func (p *XTTSProvider) studioSpeakers(ctx context.Context) (map[string]xttsSpeaker, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+XTTSStudioSpeakerEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list studio speakers: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("XTTS API error: status %d", resp.StatusCode)
+	}
+	var speakers map[string]xttsSpeaker
+	if err := json.NewDecoder(resp.Body).Decode(&speakers); err != nil {
+		return nil, fmt.Errorf("failed to decode studio speakers: %w", err)
+	}
+	return speakers, nil
+}
+
+// XTTSProviderFromConfig creates an XTTS provider from configuration. All
+// keys are optional: base_url, speaker_wav (resolved path), language,
+// cache_dir, and timeout_seconds.
+func XTTSProviderFromConfig(config map[string]interface{}) (*XTTSProvider, error) {
+	baseURL, _ := config["base_url"].(string)
+	if baseURL == "" {
+		baseURL = XTTSBaseURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid base_url %q: scheme must be http or https", baseURL)
+	}
+
+	provider := NewXTTSProvider(baseURL)
+	if wav, ok := config["speaker_wav"].(string); ok {
+		provider.speakerWAV = wav
+	}
+	if language, ok := config["language"].(string); ok && language != "" {
+		provider.language = language
+	}
+	if dir, ok := config["cache_dir"].(string); ok && dir != "" {
+		provider.cacheDir = dir
+	}
+	if timeout, ok := configInt(config["timeout_seconds"]); ok && timeout > 0 {
+		provider.httpClient.Timeout = time.Duration(timeout) * time.Second
+	}
+	return provider, nil
+}
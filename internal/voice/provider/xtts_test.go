@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newXTTSTestServer(t *testing.T, clones *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case XTTSLanguagesEndpoint:
+			_ = json.NewEncoder(w).Encode([]string{"en", "ja"})
+		case XTTSStudioSpeakerEndpoint:
+			_ = json.NewEncoder(w).Encode(map[string]xttsSpeaker{
+				"Bob":   {GPTCondLatent: json.RawMessage(`[[2]]`), SpeakerEmbedding: json.RawMessage(`[2]`)},
+				"Alice": {GPTCondLatent: json.RawMessage(`[[1]]`), SpeakerEmbedding: json.RawMessage(`[1]`)},
+			})
+		case XTTSCloneSpeakerEndpoint:
+			atomic.AddInt32(clones, 1)
+			file, _, err := r.FormFile("wav_file")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			data, _ := io.ReadAll(file)
+			assert.Equal(t, "RIFFref", string(data))
+			_, _ = w.Write([]byte(`{"gpt_cond_latent":[[9]],"speaker_embedding":[9]}`))
+		case XTTSTTSEndpoint:
+			var body map[string]json.RawMessage
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			audio := "wav:" + string(body["speaker_embedding"]) + ":" + string(body["language"])
+			_ = json.NewEncoder(w).Encode(base64.StdEncoding.EncodeToString([]byte(audio)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestXTTSProvider_SynthesizeStudioSpeaker(t *testing.T) {
+	var clones int32
+	server := newXTTSTestServer(t, &clones)
+	defer server.Close()
+
+	p, err := XTTSProviderFromConfig(map[string]interface{}{"base_url": server.URL, "language": "en"})
+	require.NoError(t, err)
+	assert.Equal(t, "xtts", p.Name())
+	assert.True(t, p.IsAvailable(context.Background()))
+
+	// No voice selects the first studio speaker by name.
+	audio := synthesizeString(t, p, SynthesizeOptions{})
+	assert.Equal(t, `wav:[1]:"en"`, audio)
+
+	audio = synthesizeString(t, p, SynthesizeOptions{Voice: "Bob", Language: "ja"})
+	assert.Equal(t, `wav:[2]:"ja"`, audio)
+
+	_, err = p.Synthesize(context.Background(), "hi", SynthesizeOptions{Voice: "Nobody"})
+	assert.Error(t, err)
+}
+
+func TestXTTSProvider_SynthesizeClonedVoiceCachesLatents(t *testing.T) {
+	var clones int32
+	server := newXTTSTestServer(t, &clones)
+	defer server.Close()
+
+	dir := t.TempDir()
+	wav := filepath.Join(dir, "ref.wav")
+	require.NoError(t, os.WriteFile(wav, []byte("RIFFref"), 0o644))
+
+	p, err := XTTSProviderFromConfig(map[string]interface{}{
+		"base_url":    server.URL,
+		"speaker_wav": wav,
+		"cache_dir":   filepath.Join(dir, "cache"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, `wav:[9]:"ja"`, synthesizeString(t, p, SynthesizeOptions{Voice: "Bob"}))
+	assert.Equal(t, `wav:[9]:"ja"`, synthesizeString(t, p, SynthesizeOptions{}))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&clones), "latents should be cloned once and cached")
+
+	voices, err := p.ListVoices(context.Background())
+	require.NoError(t, err)
+	require.Len(t, voices, 3)
+	assert.Equal(t, "reference", voices[0].ID)
+}
+
+func TestXTTSProviderFromConfig(t *testing.T) {
+	p, err := XTTSProviderFromConfig(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, XTTSBaseURL, p.baseURL)
+	assert.Equal(t, XTTSDefaultLanguage, p.language)
+
+	_, err = XTTSProviderFromConfig(map[string]interface{}{"base_url": "ftp://host"})
+	assert.Error(t, err)
+}
+
+func synthesizeString(t *testing.T, p *XTTSProvider, opts SynthesizeOptions) string {
+	t.Helper()
+	rc, err := p.Synthesize(context.Background(), "hello", opts)
+	require.NoError(t, err)
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,117 @@
+package voice
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Defaults of the speed ramp.
+const (
+	DefaultRampMaxSpeed = 1.5
+	DefaultRampMinChars = 200
+	DefaultRampChars    = 400
+)
+
+// rampStep rounds ramp speeds so neighbouring sentences share a request.
+const rampStep = 0.1
+
+// SpeedRamp speeds up long readouts progressively. The first sentence is read
+// at the configured speed; later sentences speed up in steps until the
+// configured speed times MaxSpeed is reached. Each step is synthesized with
+// the provider's speed parameter, so providers without one (ElevenLabs,
+// Polly, XTTS) read at a constant speed.
+type SpeedRamp struct {
+	// MaxSpeed is the speed multiplier reached at the end of the ramp
+	// (default 1.5).
+	MaxSpeed float64 `json:"max_speed,omitempty"`
+	// MinChars is the shortest text that is ramped (default 200).
+	MinChars int `json:"min_chars,omitempty"`
+	// RampChars is how many characters after the first sentence it takes to
+	// reach MaxSpeed (default 400).
+	RampChars int `json:"ramp_chars,omitempty"`
+}
+
+func (r *SpeedRamp) maxSpeed() float64 {
+	if r.MaxSpeed <= 0 {
+		return DefaultRampMaxSpeed
+	}
+	return r.MaxSpeed
+}
+
+func (r *SpeedRamp) minChars() int {
+	if r.MinChars <= 0 {
+		return DefaultRampMinChars
+	}
+	return r.MinChars
+}
+
+func (r *SpeedRamp) rampChars() int {
+	if r.RampChars <= 0 {
+		return DefaultRampChars
+	}
+	return r.RampChars
+}
+
+// Factor returns the speed multiplier for text that starts pos characters
+// after the first sentence, rounded to steps of 0.1.
+func (r *SpeedRamp) Factor(pos int) float64 {
+	max := r.maxSpeed()
+	progress := math.Min(1, float64(pos)/float64(r.rampChars()))
+	factor := math.Round((1+(max-1)*progress)/rampStep) * rampStep
+	if (max >= 1 && factor > max) || (max < 1 && factor < max) {
+		factor = max
+	}
+	return factor
+}
+
+// speechChunk is one synthesis request of a long text. factor scales the
+// configured speed; 1 keeps it.
+type speechChunk struct {
+	text   string
+	factor float64
+}
+
+// plainChunks wraps chunks read at the configured speed.
+func plainChunks(texts []string) []speechChunk {
+	chunks := make([]speechChunk, len(texts))
+	for i, text := range texts {
+		chunks[i] = speechChunk{text: text, factor: 1}
+	}
+	return chunks
+}
+
+// chunks splits text into requests of at most limit characters with ramped
+// speeds. Consecutive sentences with the same speed share a request. It
+// returns nil when the ramp is off or the text is too short.
+func (r *SpeedRamp) chunks(text string, limit int) []speechChunk {
+	text = strings.TrimSpace(text)
+	if r == nil || utf8.RuneCountInString(text) < r.minChars() {
+		return nil
+	}
+	var chunks []speechChunk
+	pos := 0
+	for i, sentence := range splitSentences(text) {
+		factor := 1.0
+		if i > 0 {
+			factor = r.Factor(pos)
+			pos += utf8.RuneCountInString(sentence)
+		}
+		pieces := []string{sentence}
+		if utf8.RuneCountInString(sentence) > limit {
+			pieces = SplitText(sentence, limit)
+		}
+		for _, piece := range pieces {
+			if n := len(chunks); n > 0 && chunks[n-1].factor == factor &&
+				utf8.RuneCountInString(chunks[n-1].text)+utf8.RuneCountInString(piece) <= limit {
+				chunks[n-1].text += piece
+				continue
+			}
+			chunks = append(chunks, speechChunk{text: piece, factor: factor})
+		}
+	}
+	for i := range chunks {
+		chunks[i].text = strings.TrimSpace(chunks[i].text)
+	}
+	return chunks
+}
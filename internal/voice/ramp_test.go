@@ -0,0 +1,66 @@
+package voice
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSpeedRampFactor(t *testing.T) {
+	r := &SpeedRamp{}
+	tests := []struct {
+		pos  int
+		want float64
+	}{
+		{0, 1.0},
+		{200, 1.3},
+		{400, 1.5},
+		{5000, 1.5},
+	}
+	for _, tt := range tests {
+		if got := r.Factor(tt.pos); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("Factor(%d) = %v, want %v", tt.pos, got, tt.want)
+		}
+	}
+	if got := (&SpeedRamp{MaxSpeed: 2}).Factor(400); got != 2 {
+		t.Errorf("MaxSpeed 2 Factor(400) = %v", got)
+	}
+}
+
+func TestSpeedRampChunks(t *testing.T) {
+	var nilRamp *SpeedRamp
+	if nilRamp.chunks(strings.Repeat("長い文です。", 100), 200) != nil {
+		t.Error("nil ramp should not split")
+	}
+	r := &SpeedRamp{}
+	if r.chunks("短い文です。", 200) != nil {
+		t.Error("text under min_chars should not be ramped")
+	}
+
+	text := "最初の文はそのまま読むのだ。" + strings.Repeat("次の文はだんだん速くなるのだ。", 40)
+	chunks := r.chunks(text, 200)
+	if len(chunks) < 3 {
+		t.Fatalf("got %d chunks, want a ramp", len(chunks))
+	}
+	if chunks[0].factor != 1 || !strings.HasPrefix(chunks[0].text, "最初の文はそのまま読むのだ。") {
+		t.Errorf("first chunk = %+v, want the first sentence at 1.0", chunks[0])
+	}
+	var joined strings.Builder
+	prev := 0.0
+	for _, c := range chunks {
+		if c.factor < prev {
+			t.Errorf("speed went down: %v after %v", c.factor, prev)
+		}
+		prev = c.factor
+		if utf8.RuneCountInString(c.text) > 200 {
+			t.Errorf("chunk over the limit: %d runes", utf8.RuneCountInString(c.text))
+		}
+		joined.WriteString(c.text)
+	}
+	if prev < 1.5-1e-9 {
+		t.Errorf("ramp ended at %v, want 1.5", prev)
+	}
+	if joined.String() != text {
+		t.Error("chunks should cover the whole text in order")
+	}
+}
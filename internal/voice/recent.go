@@ -0,0 +1,102 @@
+package voice
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultRecentKeep is how many files the recent audio directory keeps
+// unless configured otherwise.
+const DefaultRecentKeep = 20
+
+// recentSubdir is the directory ccpersona owns inside a configured
+// voice.recent.dir, so pruning never touches the user's own files there.
+const recentSubdir = "ccpersona-recent"
+
+// recentName matches the names createRecent gives; pruning removes nothing
+// else.
+var recentName = regexp.MustCompile(`^\d{8}-\d{6}\.\d{3}-\d+\.(mp3|wav|ogg|flac|aac)$`)
+
+// RecentConfig also saves every synthesized message to a directory that
+// keeps the newest Keep files, so recent speech can be replayed or shared
+// after it played.
+type RecentConfig struct {
+	// Dir defaults to ~/.cache/ccpersona/recent; a configured directory
+	// gets its files in a ccpersona-recent subdirectory. It is read from the
+	// global config only.
+	Dir string `json:"dir,omitempty"`
+	// Keep defaults to DefaultRecentKeep.
+	Keep int `json:"keep,omitempty"`
+}
+
+// Path returns the recent audio directory.
+func (r *RecentConfig) Path() (string, error) {
+	if r != nil && r.Dir != "" {
+		return filepath.Join(expandHomePath(r.Dir), recentSubdir), nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve cache dir: %w", err)
+	}
+	return filepath.Join(base, "ccpersona", "recent"), nil
+}
+
+// Validate checks that Keep is not negative.
+func (r *RecentConfig) Validate() error {
+	if r != nil && r.Keep < 0 {
+		return fmt.Errorf("voice.recent.keep must not be negative")
+	}
+	return nil
+}
+
+func (r *RecentConfig) keep() int {
+	if r == nil || r.Keep == 0 {
+		return DefaultRecentKeep
+	}
+	return r.Keep
+}
+
+// createRecent creates a file in the recent audio directory named after
+// the current time, so names sort oldest first.
+func createRecent(recent *RecentConfig, ext string) (*os.File, error) {
+	dir, err := recent.Path()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(dir, time.Now().Format("20060102-150405.000")+"-*."+ext)
+}
+
+// pruneRecent removes the oldest recent audio files beyond the limit. Only
+// files named by createRecent count.
+func pruneRecent(recent *RecentConfig) {
+	dir, err := recent.Path()
+	if err != nil {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && recentName.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for len(names) > recent.keep() {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			log.Debug().Err(err).Str("file", names[0]).Msg("Failed to prune recent audio")
+		}
+		names = names[1:]
+	}
+}
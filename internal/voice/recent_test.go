@@ -0,0 +1,127 @@
+package voice
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAudio_Tee(t *testing.T) {
+	dir := t.TempDir()
+	recent := &RecentConfig{Dir: filepath.Join(dir, "recent")}
+	output := filepath.Join(dir, "out.mp3")
+
+	// Stdout and a file at once.
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	path, err := writeAudio(strings.NewReader("audio"), VoiceOptions{OutputPath: output, ToStdout: true, Recent: recent}, "mp3")
+	os.Stdout = stdout
+	require.NoError(t, w.Close())
+	require.NoError(t, err)
+	streamed, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, output, path)
+	assert.Equal(t, "audio", string(streamed))
+	saved, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "audio", string(saved))
+	recentDir, err := recent.Path()
+	require.NoError(t, err)
+	matches, err := filepath.Glob(filepath.Join(recentDir, "*.mp3"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	copied, err := os.ReadFile(matches[0])
+	require.NoError(t, err)
+	assert.Equal(t, "audio", string(copied))
+}
+
+func TestWriteAudio_PlayFromStdout(t *testing.T) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer devNull.Close()
+	stdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	path, err := writeAudio(strings.NewReader("audio"), VoiceOptions{ToStdout: true}, "mp3")
+	require.NoError(t, err)
+	assert.Empty(t, path, "audio only streamed has no file")
+
+	path, err = writeAudio(strings.NewReader("audio"), VoiceOptions{ToStdout: true, PlayAudio: true}, "mp3")
+	require.NoError(t, err)
+	defer os.Remove(path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "audio", string(data), "streamed audio is also kept to play")
+}
+
+func TestPruneRecent(t *testing.T) {
+	recent := &RecentConfig{Dir: t.TempDir(), Keep: 2}
+	dir, err := recent.Path()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	for _, name := range []string{"20261016-100000.000-1.wav", "20261016-090000.000-1.wav", "20261016-110000.000-1.wav", ".keep"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+
+	pruneRecent(recent)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{".keep", "20261016-100000.000-1.wav", "20261016-110000.000-1.wav"}, names)
+}
+
+func TestPruneRecent_KeepsUnrelatedFiles(t *testing.T) {
+	// A directory that holds the user's own files, like a home directory.
+	home := t.TempDir()
+	recent := &RecentConfig{Dir: home, Keep: 1}
+	for _, name := range []string{".bashrc", "notes.txt", "song.mp3", "20261016-090000.000-1.wav"} {
+		require.NoError(t, os.WriteFile(filepath.Join(home, name), nil, 0o644))
+	}
+	dir, err := recent.Path()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, recentSubdir), dir, "speech goes to a subdirectory ccpersona owns")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	for _, name := range []string{"mix.mp3", "20261016-090000.000-1.wav", "20261016-100000.000-2.mp3"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+
+	pruneRecent(recent)
+
+	for _, name := range []string{".bashrc", "notes.txt", "song.mp3", "20261016-090000.000-1.wav"} {
+		assert.FileExists(t, filepath.Join(home, name))
+	}
+	assert.FileExists(t, filepath.Join(dir, "mix.mp3"), "files createRecent did not name are never pruned")
+	assert.FileExists(t, filepath.Join(dir, "20261016-100000.000-2.mp3"))
+	assert.NoFileExists(t, filepath.Join(dir, "20261016-090000.000-1.wav"))
+}
+
+func TestSynthesize_RecentFromConfig(t *testing.T) {
+	t.Setenv(EnvTestMode, "1")
+	t.Setenv(EnvTestRecord, filepath.Join(t.TempDir(), "spoken.jsonl"))
+	recentDir := t.TempDir()
+	config := DefaultConfig()
+	config.Recent = &RecentConfig{Dir: recentDir}
+
+	audioFile, err := NewVoiceManager(config).Synthesize(context.Background(), "こんにちは", VoiceOptions{Provider: "openai"})
+	require.NoError(t, err)
+	defer os.Remove(audioFile)
+
+	matches, err := filepath.Glob(filepath.Join(recentDir, recentSubdir, "*.wav"))
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+	assert.NotEqual(t, recentDir, filepath.Dir(audioFile), "the played file stays temporary")
+}
@@ -0,0 +1,173 @@
+package voice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ReferenceAudio is a managed reference recording used for voice cloning.
+type ReferenceAudio struct {
+	Name string
+	Path string
+	Size int64
+}
+
+// maxReferenceBytes bounds a reference WAV. XTTS needs only a few seconds of
+// speech; anything much larger is almost certainly the wrong file.
+const maxReferenceBytes = 50 << 20
+
+var referenceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// ReferenceDir returns the directory holding managed reference WAVs
+// (~/.agents/ccpersona/voices).
+func ReferenceDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home dir: %w", err)
+	}
+	return filepath.Join(home, ".agents", "ccpersona", "voices"), nil
+}
+
+// ResolveReference maps a persona's reference_wav value to a file path. Bare
+// names refer to managed references; anything that looks like a path is used
+// as-is, with a leading ~ expanded.
+func ResolveReference(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if referenceNamePattern.MatchString(value) {
+		dir, err := ReferenceDir()
+		if err != nil {
+			return "", err
+		}
+		path := filepath.Join(dir, value+".wav")
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("reference voice %q not found (add it with 'ccpersona runtime voice ref add %s <file.wav>')", value, value)
+		}
+		return path, nil
+	}
+	if strings.HasPrefix(value, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("resolve home dir: %w", err)
+		}
+		value = filepath.Join(home, value[2:])
+	}
+	if _, err := os.Stat(value); err != nil {
+		return "", fmt.Errorf("reference audio: %w", err)
+	}
+	return value, nil
+}
+
+// AddReference copies a WAV file into the managed reference directory under
+// name, replacing any existing reference with that name.
+func AddReference(name, src string) (*ReferenceAudio, error) {
+	if !referenceNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid reference name %q (use letters, digits, '-' and '_')", name)
+	}
+	data, err := readReferenceWAV(src)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := ReferenceDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create reference dir: %w", err)
+	}
+
+	dst := filepath.Join(dir, name+".wav")
+	tmp, err := os.CreateTemp(dir, "."+name+"-*.wav")
+	if err != nil {
+		return nil, fmt.Errorf("create reference file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("write reference file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("write reference file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return nil, fmt.Errorf("save reference file: %w", err)
+	}
+	return &ReferenceAudio{Name: name, Path: dst, Size: int64(len(data))}, nil
+}
+
+// ListReferences returns the managed references sorted by name.
+func ListReferences() ([]ReferenceAudio, error) {
+	dir, err := ReferenceDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read reference dir: %w", err)
+	}
+
+	var refs []ReferenceAudio
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".wav")
+		if entry.IsDir() || !ok || !referenceNamePattern.MatchString(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		refs = append(refs, ReferenceAudio{Name: name, Path: filepath.Join(dir, entry.Name()), Size: info.Size()})
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Name < refs[j].Name })
+	return refs, nil
+}
+
+// RemoveReference deletes a managed reference.
+func RemoveReference(name string) error {
+	if !referenceNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid reference name %q", name)
+	}
+	dir, err := ReferenceDir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, name+".wav")); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("reference voice %q not found", name)
+		}
+		return err
+	}
+	return nil
+}
+
+// readReferenceWAV reads src and checks that it is a RIFF/WAVE file of a
+// reasonable size.
+func readReferenceWAV(src string) ([]byte, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("open reference audio: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxReferenceBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read reference audio: %w", err)
+	}
+	if len(data) > maxReferenceBytes {
+		return nil, fmt.Errorf("reference audio is larger than %d MB", maxReferenceBytes>>20)
+	}
+	if len(data) < 44 || !bytes.Equal(data[0:4], []byte("RIFF")) || !bytes.Equal(data[8:12], []byte("WAVE")) {
+		return nil, fmt.Errorf("%s is not a WAV file", src)
+	}
+	return data, nil
+}
@@ -0,0 +1,90 @@
+package voice
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestWAV(t *testing.T, dir string) string {
+	t.Helper()
+	data := append([]byte("RIFF\x00\x00\x00\x00WAVEfmt "), make([]byte, 64)...)
+	path := filepath.Join(dir, "sample.wav")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReferenceLifecycle(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	src := writeTestWAV(t, t.TempDir())
+
+	ref, err := AddReference("narrator", src)
+	if err != nil {
+		t.Fatalf("AddReference() error = %v", err)
+	}
+	want := filepath.Join(home, ".agents", "ccpersona", "voices", "narrator.wav")
+	if ref.Path != want {
+		t.Errorf("path = %s, want %s", ref.Path, want)
+	}
+
+	refs, err := ListReferences()
+	if err != nil || len(refs) != 1 || refs[0].Name != "narrator" {
+		t.Fatalf("ListReferences() = %+v, %v", refs, err)
+	}
+
+	resolved, err := ResolveReference("narrator")
+	if err != nil || resolved != want {
+		t.Errorf("ResolveReference(name) = %q, %v", resolved, err)
+	}
+
+	if err := RemoveReference("narrator"); err != nil {
+		t.Fatalf("RemoveReference() error = %v", err)
+	}
+	if _, err := ResolveReference("narrator"); err == nil {
+		t.Error("expected error for removed reference")
+	}
+	if err := RemoveReference("narrator"); err == nil {
+		t.Error("expected error removing a missing reference")
+	}
+}
+
+func TestAddReferenceRejectsInvalidInput(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	src := writeTestWAV(t, dir)
+
+	if _, err := AddReference("../evil", src); err == nil {
+		t.Error("expected error for path-like name")
+	}
+
+	notWAV := filepath.Join(dir, "note.txt")
+	if err := os.WriteFile(notWAV, []byte(strings.Repeat("x", 100)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddReference("note", notWAV); err == nil || !strings.Contains(err.Error(), "not a WAV") {
+		t.Errorf("expected WAV validation error, got %v", err)
+	}
+}
+
+func TestResolveReferencePaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	src := writeTestWAV(t, home)
+
+	if got, err := ResolveReference(""); err != nil || got != "" {
+		t.Errorf("empty value = %q, %v", got, err)
+	}
+	if got, err := ResolveReference(src); err != nil || got != src {
+		t.Errorf("absolute path = %q, %v", got, err)
+	}
+	if got, err := ResolveReference("~/sample.wav"); err != nil || got != src {
+		t.Errorf("tilde path = %q, %v", got, err)
+	}
+	if _, err := ResolveReference(filepath.Join(home, "missing.wav")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
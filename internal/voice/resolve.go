@@ -7,8 +7,10 @@ import "github.com/rs/zerolog/log"
 type PersonaVoiceInput struct {
 	Provider string
 	Speaker  int
-	Volume   float64
-	Speed    float64
+	// SpeakerSet applies Speaker even when it is 0, a valid VOICEVOX style.
+	SpeakerSet bool
+	Volume     float64
+	Speed      float64
 }
 
 // Resolve merges all configuration sources into a single VoiceOptions.
@@ -17,10 +19,11 @@ type PersonaVoiceInput struct {
 //
 // Priority (highest → lowest):
 //  1. cliProvider argument (provider name only; caller applies CLI speaker/flags after)
-//  2. persona (PersonaVoiceInput)
-//  3. fileConfig.Providers[effectiveProvider] (per-provider overrides)
-//  4. fileConfig.Defaults (global defaults from config file)
-//  5. DefaultConfig() hard-coded values
+//  2. CCPERSONA_PROVIDER environment variable (provider name only)
+//  3. persona (PersonaVoiceInput)
+//  4. fileConfig.Providers[effectiveProvider] (per-provider overrides)
+//  5. fileConfig.Defaults (global defaults from config file)
+//  6. DefaultConfig() hard-coded values
 func Resolve(persona PersonaVoiceInput, fileConfig *ConfigFile, cliProvider string) VoiceOptions {
 	defaults := DefaultConfig()
 
@@ -33,12 +36,11 @@ func Resolve(persona PersonaVoiceInput, fileConfig *ConfigFile, cliProvider stri
 		SimilarityBoost: 0.5,
 		Style:           0.0,
 		UseSpeakerBoost: true,
-		Region:          "us-east-1",
 		Engine:          "neural",
 		SampleRate:      "22050",
 	}
 
-	// Layer 4: fileConfig.Defaults
+	// Layer 5: fileConfig.Defaults
 	if fileConfig != nil && fileConfig.Defaults != nil {
 		if fileConfig.Defaults.Volume > 0 {
 			opts.Volume = fileConfig.Defaults.Volume
@@ -57,11 +59,14 @@ func Resolve(persona PersonaVoiceInput, fileConfig *ConfigFile, cliProvider stri
 	if persona.Provider != "" {
 		effectiveProvider = persona.Provider
 	}
+	if envProvider := ProviderOverride(); envProvider != "" {
+		effectiveProvider = envProvider
+	}
 	if cliProvider != "" {
 		effectiveProvider = cliProvider
 	}
 
-	// Layer 3: fileConfig.Providers[effectiveProvider]
+	// Layer 4: fileConfig.Providers[effectiveProvider]
 	if fileConfig != nil && effectiveProvider != "" {
 		if provCfg := fileConfig.GetProviderConfig(effectiveProvider); provCfg != nil {
 			if provCfg.Volume > 0 {
@@ -116,11 +121,39 @@ func Resolve(persona PersonaVoiceInput, fileConfig *ConfigFile, cliProvider stri
 			if provCfg.SampleRate != "" {
 				opts.SampleRate = provCfg.SampleRate
 			}
+			if provCfg.ReferenceWAV != "" {
+				opts.ReferenceWAV = provCfg.ReferenceWAV
+			}
+			if provCfg.Language != "" {
+				opts.Language = provCfg.Language
+			}
+			if provCfg.SSML {
+				opts.SSML = true
+			}
+			if provCfg.ChunkChars > 0 {
+				opts.ChunkChars = provCfg.ChunkChars
+			}
+			if provCfg.ParallelChunks > 0 {
+				opts.ParallelChunks = provCfg.ParallelChunks
+			}
+			if provCfg.SpeedRamp != nil {
+				opts.SpeedRamp = provCfg.SpeedRamp
+			}
+			if provCfg.Prosody != nil {
+				opts.Prosody = provCfg.Prosody
+			}
+			if provCfg.SpeakerStyle != nil {
+				opts.SpeakerStyle = provCfg.SpeakerStyle
+			}
+			if provCfg.SpeakerStyles != nil {
+				opts.SpeakerStyles = provCfg.SpeakerStyles
+			}
 		}
 	}
 
-	// Layer 2: persona
-	if persona.Speaker > 0 {
+	// Layer 3: persona
+	if persona.Speaker > 0 || persona.SpeakerSet {
+		opts.SpeakerSet = true
 		// Use effectiveProvider so the speaker always lands in the correct field
 		// even when persona.Provider is empty.
 		if effectiveProvider == EngineAivisSpeech {
@@ -137,6 +170,9 @@ func Resolve(persona PersonaVoiceInput, fileConfig *ConfigFile, cliProvider stri
 	}
 
 	opts.Provider = effectiveProvider
+	if effectiveProvider == ProviderAuto && fileConfig != nil && fileConfig.Auto != nil {
+		opts.Auto = fileConfig.Auto.selection(persona, fileConfig)
+	}
 
 	log.Debug().
 		Str("provider", effectiveProvider).
@@ -155,10 +191,10 @@ func (o VoiceOptions) ToConfig(base *Config) *Config {
 	if o.Provider != "" {
 		cfg.EnginePriority = o.Provider
 	}
-	if o.AivisSpeechSpeaker > 0 {
+	if o.AivisSpeechSpeaker > 0 || o.SpeakerSet && o.Provider == EngineAivisSpeech {
 		cfg.AivisSpeechSpeaker = int64(o.AivisSpeechSpeaker)
 	}
-	if o.VoicevoxSpeaker > 0 {
+	if o.VoicevoxSpeaker > 0 || o.SpeakerSet && o.Provider == EngineVoicevox {
 		cfg.VoicevoxSpeaker = o.VoicevoxSpeaker
 	}
 	if o.Speed > 0 {
@@ -167,5 +203,11 @@ func (o VoiceOptions) ToConfig(base *Config) *Config {
 	if o.Volume > 0 {
 		cfg.VolumeScale = o.Volume
 	}
+	if o.Prosody != nil {
+		cfg.Prosody = o.Prosody
+	}
+	if o.SpeakerStyle != nil {
+		cfg.SpeakerStyle = o.SpeakerStyle
+	}
 	return &cfg
 }
@@ -173,6 +173,23 @@ func TestResolve_CLIProviderVoicevox_PersonaSpeakerGoesToVoicevox(t *testing.T)
 	}
 }
 
+func TestResolve_PersonaSpeakerZero(t *testing.T) {
+	fileConfig := &ConfigFile{Providers: map[string]ProviderConfig{"voicevox": {Speaker: 8}}}
+
+	cfg := Resolve(PersonaVoiceInput{Provider: "voicevox", SpeakerSet: true}, fileConfig, "").ToConfig(DefaultConfig())
+	if cfg.VoicevoxSpeaker != 0 {
+		t.Errorf("expected VoicevoxSpeaker=0 for a chosen style 0, got %d", cfg.VoicevoxSpeaker)
+	}
+	if cfg.AivisSpeechSpeaker != DefaultConfig().AivisSpeechSpeaker {
+		t.Errorf("AivisSpeechSpeaker should keep its default, got %d", cfg.AivisSpeechSpeaker)
+	}
+
+	cfg = Resolve(PersonaVoiceInput{Provider: "voicevox"}, nil, "").ToConfig(DefaultConfig())
+	if cfg.VoicevoxSpeaker != DefaultConfig().VoicevoxSpeaker {
+		t.Errorf("an unset speaker should use the default, got %d", cfg.VoicevoxSpeaker)
+	}
+}
+
 func TestResolve_ProviderConfigBaseURLAndTimeout(t *testing.T) {
 	fileConfig := &ConfigFile{
 		Providers: map[string]ProviderConfig{
@@ -245,3 +262,41 @@ func TestResolve_PersonaOverridesProviderConfig(t *testing.T) {
 		t.Errorf("expected opts.Speed=0.8, got %v", opts.Speed)
 	}
 }
+
+func TestResolve_XTTSReferenceAndLanguage(t *testing.T) {
+	fileConfig := &ConfigFile{
+		DefaultProvider: "xtts",
+		Providers: map[string]ProviderConfig{
+			"xtts": {ReferenceWAV: "narrator", Language: "en", BaseURL: "http://gpu:8000"},
+		},
+	}
+
+	opts := Resolve(PersonaVoiceInput{}, fileConfig, "")
+
+	if opts.ReferenceWAV != "narrator" {
+		t.Errorf("expected ReferenceWAV=narrator, got %q", opts.ReferenceWAV)
+	}
+	if opts.Language != "en" {
+		t.Errorf("expected Language=en, got %q", opts.Language)
+	}
+	if opts.BaseURL != "http://gpu:8000" {
+		t.Errorf("expected BaseURL from provider config, got %q", opts.BaseURL)
+	}
+}
+
+func TestResolve_ProsodyReachesEngineConfig(t *testing.T) {
+	pitch := 0.05
+	fileConfig := &ConfigFile{
+		DefaultProvider: "voicevox",
+		Providers: map[string]ProviderConfig{
+			"voicevox": {Speaker: 3, Prosody: &Prosody{PitchScale: &pitch}},
+		},
+	}
+
+	opts := Resolve(PersonaVoiceInput{}, fileConfig, "")
+	cfg := opts.ToConfig(DefaultConfig())
+
+	if cfg.Prosody == nil || cfg.Prosody.PitchScale == nil || *cfg.Prosody.PitchScale != pitch {
+		t.Errorf("expected pitch_scale %v in the engine config, got %+v", pitch, cfg.Prosody)
+	}
+}
@@ -0,0 +1,121 @@
+package voice
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+// sessionNameDir holds session names inside the dedup directory. It is a
+// subdirectory so the 24-hour marker cleanup leaves it alone; the registry
+// expires its own entries.
+const sessionNameDir = "sessions"
+
+// ActiveSessionWindow is how recently a session must have sent a hook event
+// to count as active.
+const ActiveSessionWindow = 30 * time.Minute
+
+// sessionNameTTL is how long an idle session keeps its name.
+const sessionNameTTL = 24 * time.Hour
+
+// sessionWords are short words that are easy to say and hear apart.
+var sessionWords = []string{
+	"apple", "banana", "cherry", "grape", "lemon", "mango", "melon", "peach",
+	"plum", "kiwi", "olive", "berry", "tiger", "panda", "koala", "otter",
+	"zebra", "camel", "eagle", "falcon", "robin", "dolphin", "whale", "salmon",
+	"maple", "cedar", "willow", "lotus", "tulip", "violet", "coral", "amber",
+	"ruby", "jade", "pearl", "silver", "copper", "cobalt", "indigo", "crimson",
+	"comet", "meteor", "nova", "orbit", "planet", "rocket", "galaxy", "aurora",
+	"river", "canyon", "island", "valley", "glacier", "desert", "forest", "meadow",
+	"piano", "guitar", "violin", "trumpet", "drum", "banjo", "cello", "flute",
+}
+
+// SessionRegistry gives each agent session a pronounceable name, stable for
+// the session's lifetime, and tracks which sessions are active. State lives
+// in the voice state directory so every hook process sees the same names.
+type SessionRegistry struct {
+	dir string
+	now func() time.Time
+}
+
+// NewSessionRegistry creates a registry in the voice state directory.
+func NewSessionRegistry() *SessionRegistry {
+	return &SessionRegistry{
+		dir: filepath.Join(os.TempDir(), dedupDir, sessionNameDir),
+		now: time.Now,
+	}
+}
+
+// Touch marks the session active and returns its name and how many other
+// sessions are active. A new session gets the word its ID hashes to, or the
+// next word not held by another active session.
+func (r *SessionRegistry) Touch(sessionID string) (name string, others int) {
+	if sessionID == "" {
+		return "", 0
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		log.Debug().Err(err).Msg("Failed to create session name directory")
+		return SessionWord(sessionID), 0
+	}
+
+	now := r.now()
+	self := safeSessionName(sessionID)
+	taken := map[string]bool{}
+	entries, _ := os.ReadDir(r.dir)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(r.dir, entry.Name())
+		age := now.Sub(info.ModTime())
+		if age > sessionNameTTL {
+			_ = os.Remove(path)
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if entry.Name() == self {
+			name = strings.TrimSpace(string(data))
+			continue
+		}
+		if age <= ActiveSessionWindow {
+			others++
+			taken[strings.TrimSpace(string(data))] = true
+		}
+	}
+
+	if name == "" {
+		start := wordIndex(sessionID)
+		name = sessionWords[start]
+		for i := 1; i < len(sessionWords) && taken[name]; i++ {
+			name = sessionWords[(start+i)%len(sessionWords)]
+		}
+	}
+	path := filepath.Join(r.dir, self)
+	if err := fsutil.WriteFile(path, []byte(name), 0644); err != nil {
+		log.Debug().Err(err).Msg("Failed to write session name")
+	} else {
+		_ = os.Chtimes(path, now, now)
+	}
+	return name, others
+}
+
+// SessionWord returns the word a session ID hashes to, without consulting
+// the registry.
+func SessionWord(sessionID string) string {
+	return sessionWords[wordIndex(sessionID)]
+}
+
+func wordIndex(sessionID string) int {
+	h := sha256.Sum256([]byte(sessionID))
+	return int(binary.BigEndian.Uint32(h[:4]) % uint32(len(sessionWords)))
+}
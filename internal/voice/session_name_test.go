@@ -0,0 +1,66 @@
+package voice
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessionRegistryTouch(t *testing.T) {
+	now := time.Now()
+	r := &SessionRegistry{dir: t.TempDir(), now: func() time.Time { return now }}
+
+	name, others := r.Touch("session-a")
+	if name != SessionWord("session-a") || others != 0 {
+		t.Fatalf("first session = %q, %d", name, others)
+	}
+	if again, _ := r.Touch("session-a"); again != name {
+		t.Errorf("name changed from %q to %q", name, again)
+	}
+
+	nameB, others := r.Touch("session-b")
+	if others != 1 || nameB == name {
+		t.Errorf("second session = %q, %d others, want a distinct name and 1 other", nameB, others)
+	}
+	if _, others := r.Touch("session-a"); others != 1 {
+		t.Errorf("first session sees %d others, want 1", others)
+	}
+	if name, _ := r.Touch(""); name != "" {
+		t.Errorf("empty session ID named %q", name)
+	}
+}
+
+func TestSessionRegistryAvoidsActiveCollision(t *testing.T) {
+	now := time.Now()
+	r := &SessionRegistry{dir: t.TempDir(), now: func() time.Time { return now }}
+	// Hold session-b's word with another active session.
+	held := SessionWord("session-b")
+	if err := os.WriteFile(filepath.Join(r.dir, "session-a"), []byte(held), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := r.Touch("session-b"); name == held {
+		t.Errorf("session-b took %q, held by an active session", name)
+	}
+}
+
+func TestSessionRegistryExpiry(t *testing.T) {
+	now := time.Now()
+	r := &SessionRegistry{dir: t.TempDir(), now: func() time.Time { return now }}
+	r.Touch("idle")
+	r.Touch("stale")
+	idle := now.Add(-ActiveSessionWindow - time.Minute)
+	stale := now.Add(-sessionNameTTL - time.Minute)
+	_ = os.Chtimes(filepath.Join(r.dir, "idle"), idle, idle)
+	_ = os.Chtimes(filepath.Join(r.dir, "stale"), stale, stale)
+
+	if _, others := r.Touch("current"); others != 0 {
+		t.Errorf("idle sessions counted as active: %d", others)
+	}
+	if _, err := os.Stat(filepath.Join(r.dir, "stale")); !os.IsNotExist(err) {
+		t.Error("expired session name should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(r.dir, "idle")); err != nil {
+		t.Error("idle session should keep its name")
+	}
+}
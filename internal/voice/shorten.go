@@ -0,0 +1,115 @@
+package voice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/daikw/ccpersona/internal/voice/provider"
+	"github.com/rs/zerolog/log"
+)
+
+// Steps of the retry ladder, besides percentages ("50%") and character
+// counts ("300").
+const (
+	ShortenSummary       = "summary"
+	ShortenFirstSentence = "first-sentence"
+)
+
+// DefaultRetryLadder is tried, step by step, when a provider rejects text
+// for its length or content: half the text, a summary, then only the first
+// sentence.
+var DefaultRetryLadder = []string{"50%", ShortenSummary, ShortenFirstSentence}
+
+// ValidateRetryLadder checks every step of voice.retry_ladder.
+func ValidateRetryLadder(ladder []string) error {
+	for _, step := range ladder {
+		if _, err := shortenText(step, "x"); err != nil {
+			return fmt.Errorf("voice.retry_ladder: %w", err)
+		}
+	}
+	return nil
+}
+
+// shortenText applies one step of the retry ladder to text. Cuts keep whole
+// sentences where possible.
+func shortenText(step, text string) (string, error) {
+	switch step {
+	case ShortenSummary:
+		return Summarize(text, DefaultAdaptiveSummaryChars), nil
+	case ShortenFirstSentence:
+		if first := firstSentence(text); first != "" {
+			return first, nil
+		}
+		return text, nil
+	}
+	if pct, ok := strings.CutSuffix(step, "%"); ok {
+		n, err := strconv.Atoi(pct)
+		if err != nil || n <= 0 || n >= 100 {
+			return "", fmt.Errorf("step %q must be a percentage between 1%% and 99%%", step)
+		}
+		return clipSentences(text, max(1, utf8.RuneCountInString(text)*n/100)), nil
+	}
+	n, err := strconv.Atoi(step)
+	if err != nil || n <= 0 {
+		return "", fmt.Errorf("unknown step %q (use a percentage, a character count, %q, or %q)", step, ShortenSummary, ShortenFirstSentence)
+	}
+	return clipSentences(text, n), nil
+}
+
+// droppedText returns what shortening original to kept left out: the tail
+// after a cut, or the whole original when it was rewritten.
+func droppedText(original, kept string) string {
+	if rest, ok := strings.CutPrefix(original, kept); ok {
+		return strings.TrimSpace(rest)
+	}
+	return original
+}
+
+// retryLadder returns the steps tried after a rejection.
+func (vm *VoiceManager) retryLadder() []string {
+	if vm.config == nil || vm.config.RetryLadder == nil {
+		return DefaultRetryLadder
+	}
+	return vm.config.RetryLadder
+}
+
+// synthesizeShorter retries text that a provider rejected with the steps
+// of the retry ladder, each applied to the original text, until one is
+// accepted. It returns the audio and the text that was spoken, or the first
+// error when every step fails.
+func (vm *VoiceManager) synthesizeShorter(ctx context.Context, text string, options VoiceOptions, rejected error) (string, VoiceOptions, string, error) {
+	last := text
+	for i, step := range vm.retryLadder() {
+		shorter, err := shortenText(step, text)
+		if err != nil {
+			log.Warn().Err(err).Msg("Skipping invalid retry step")
+			continue
+		}
+		shorter = strings.TrimSpace(shorter)
+		if shorter == "" || utf8.RuneCountInString(shorter) >= utf8.RuneCountInString(last) {
+			continue
+		}
+		last = shorter
+		log.Warn().
+			Err(rejected).
+			Str("step", step).
+			Int("attempt", i+1).
+			Int("chars", utf8.RuneCountInString(text)).
+			Int("kept_chars", utf8.RuneCountInString(shorter)).
+			Str("dropped", droppedText(text, shorter)).
+			Msg("Provider rejected the text, retrying shorter")
+		audioFile, used, err := vm.synthesizeAuto(ctx, shorter, options)
+		if err == nil {
+			return audioFile, used, shorter, nil
+		}
+		if !errors.Is(err, provider.ErrTextRejected) {
+			return "", used, text, err
+		}
+		rejected = err
+	}
+	return "", options, text, rejected
+}
@@ -0,0 +1,118 @@
+package voice
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/daikw/ccpersona/internal/voice/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// limitProvider rejects text longer than limit characters, like an API
+// answering 413.
+type limitProvider struct {
+	chunkProvider
+	limit int
+}
+
+func (p *limitProvider) Synthesize(ctx context.Context, text string, opts provider.SynthesizeOptions) (io.ReadCloser, error) {
+	if utf8.RuneCountInString(text) > p.limit {
+		p.mu.Lock()
+		p.inputs = append(p.inputs, text)
+		p.mu.Unlock()
+		return nil, &provider.APIError{API: "OpenAI", Status: 413, Body: "input too long"}
+	}
+	return p.chunkProvider.Synthesize(ctx, text, opts)
+}
+
+type limitFactory struct{ p *limitProvider }
+
+func (f limitFactory) CreateProvider(string, map[string]interface{}) (provider.Provider, error) {
+	return f.p, nil
+}
+func (f limitFactory) GetProviderWithDefaults(string) (provider.Provider, error) { return f.p, nil }
+func (f limitFactory) ListProviders() []string                                   { return []string{"openai"} }
+
+func TestShortenText(t *testing.T) {
+	text := "Short one. Short two. A much longer third sentence that goes on."
+	tests := []struct {
+		step string
+		want string
+	}{
+		{"50%", "Short one. Short two."},
+		{"15", "Short one."},
+		{ShortenSummary, "Short one."},
+		{ShortenFirstSentence, "Short one."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.step, func(t *testing.T) {
+			got, err := shortenText(tt.step, text)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, step := range []string{"0%", "100%", "half", "-5"} {
+		_, err := shortenText(step, text)
+		assert.Error(t, err, step)
+	}
+	assert.NoError(t, ValidateRetryLadder(DefaultRetryLadder))
+	assert.ErrorContains(t, ValidateRetryLadder([]string{"50%", "tldr"}), `voice.retry_ladder: unknown step "tldr"`)
+}
+
+func TestDroppedText(t *testing.T) {
+	assert.Equal(t, "Second.", droppedText("First. Second.", "First."))
+	assert.Equal(t, "Rewritten text.", droppedText("Rewritten text.", "Summary."))
+}
+
+func TestSynthesize_RetriesRejectedText(t *testing.T) {
+	isolateCache(t)
+	t.Setenv(EnvTestMode, "")
+	text := "Short one. Short two. A much longer third sentence that goes on."
+	fake := &limitProvider{limit: 15}
+	manager := NewVoiceManager(DefaultConfig())
+	manager.providerFactory = limitFactory{fake}
+
+	audioFile, err := manager.Synthesize(context.Background(), text, VoiceOptions{Provider: "openai", Format: "mp3"})
+	require.NoError(t, err)
+	defer os.Remove(audioFile)
+	data, err := os.ReadFile(audioFile)
+	require.NoError(t, err)
+	assert.Equal(t, "[Short one.]", string(data))
+	assert.Equal(t, []string{text, "Short one. Short two.", "Short one."}, fake.inputs)
+}
+
+func TestSynthesize_RetryLadder(t *testing.T) {
+	isolateCache(t)
+	t.Setenv(EnvTestMode, "")
+	text := "First sentence here. Second sentence here."
+
+	t.Run("disabled", func(t *testing.T) {
+		fake := &limitProvider{limit: 25}
+		config := DefaultConfig()
+		config.RetryLadder = []string{}
+		manager := NewVoiceManager(config)
+		manager.providerFactory = limitFactory{fake}
+
+		_, err := manager.Synthesize(context.Background(), text, VoiceOptions{Provider: "openai", Format: "mp3"})
+		assert.ErrorIs(t, err, provider.ErrTextRejected)
+		assert.Len(t, fake.inputs, 1)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		fake := &limitProvider{limit: 5}
+		config := DefaultConfig()
+		config.RetryLadder = []string{"50%", "30", ShortenFirstSentence}
+		manager := NewVoiceManager(config)
+		manager.providerFactory = limitFactory{fake}
+
+		_, err := manager.Synthesize(context.Background(), text, VoiceOptions{Provider: "openai", Format: "mp3"})
+		assert.ErrorIs(t, err, provider.ErrTextRejected)
+		assert.Equal(t, []string{text, "First sentence here."}, fake.inputs,
+			"steps that do not shorten the text further are skipped")
+	})
+}
@@ -0,0 +1,103 @@
+package voice
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+)
+
+// audioSinks writes synthesized audio to every destination of a request in
+// one pass: stdout, the output or playback file, the recent audio
+// directory, and the audio cache.
+type audioSinks struct {
+	io.Writer
+	files  []*os.File
+	path   string
+	recent *RecentConfig
+	cache  *cacheEntry
+}
+
+// openSinks opens the destinations options select for audio with extension
+// ext. Audio goes to a file unless it only goes to stdout; without
+// OutputPath that file is temporary.
+func openSinks(options VoiceOptions, ext string) (*audioSinks, error) {
+	s := &audioSinks{recent: options.Recent}
+	var writers []io.Writer
+	if options.ToStdout {
+		writers = append(writers, os.Stdout)
+	}
+	if options.OutputPath != "" || options.PlayAudio || !options.ToStdout {
+		var file *os.File
+		var err error
+		if options.OutputPath != "" {
+			file, err = os.Create(options.OutputPath)
+		} else {
+			file, err = os.CreateTemp("", "voice_*."+ext)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create output file: %w", err)
+		}
+		s.files = append(s.files, file)
+		s.path = file.Name()
+		writers = append(writers, file)
+	}
+	// Recent audio and the cache are conveniences; failing to write them
+	// never fails speech.
+	if s.recent != nil {
+		if file, err := createRecent(s.recent, ext); err != nil {
+			log.Warn().Err(err).Msg("Failed to save recent audio")
+			s.recent = nil
+		} else {
+			s.files = append(s.files, file)
+			writers = append(writers, file)
+		}
+	}
+	if options.cache != nil {
+		if entry, err := options.cache.create(ext); err != nil {
+			log.Debug().Err(err).Msg("Failed to cache audio")
+		} else {
+			s.cache = entry
+			writers = append(writers, entry.file)
+		}
+	}
+	s.Writer = io.MultiWriter(writers...)
+	return s, nil
+}
+
+// finish closes the files and returns the file to play, "" when audio only
+// went to stdout. After a failed write the files are removed.
+func (s *audioSinks) finish(err error) (string, error) {
+	for _, file := range s.files {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write audio: %w", closeErr)
+		}
+	}
+	if s.cache != nil {
+		s.cache.commit(err == nil)
+	}
+	if err != nil {
+		for _, file := range s.files {
+			_ = os.Remove(file.Name())
+		}
+		return "", err
+	}
+	if s.recent != nil {
+		pruneRecent(s.recent)
+	}
+	return s.path, nil
+}
+
+// writeAudio copies audio to the destinations options select and returns
+// the file to play.
+func writeAudio(audio io.Reader, options VoiceOptions, ext string) (string, error) {
+	sinks, err := openSinks(options, ext)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(sinks, audio); err != nil {
+		return sinks.finish(fmt.Errorf("failed to write audio data: %w", err))
+	}
+	return sinks.finish(nil)
+}
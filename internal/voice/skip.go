@@ -0,0 +1,99 @@
+package voice
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+// skipPollInterval is how often playback checks for a skip request.
+const skipPollInterval = 100 * time.Millisecond
+
+// SkipPath returns the skip marker (~/.agents/ccpersona/skip). Its
+// modification time is when speech was last skipped.
+func SkipPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home dir: %w", err)
+	}
+	return filepath.Join(home, ".agents", "ccpersona", "skip"), nil
+}
+
+// Skip stops the utterance playing now, in any process, and drops audio
+// synthesized before the call that has not started yet, such as hooks
+// waiting for the device or detached players still starting.
+func Skip() error {
+	path, err := SkipPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create skip dir: %w", err)
+	}
+	now := time.Now()
+	if err := fsutil.WriteFile(path, []byte(now.UTC().Format(time.RFC3339Nano)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write skip file: %w", err)
+	}
+	// Set the time explicitly so two skips within the filesystem's timestamp
+	// granularity still move it forward.
+	return os.Chtimes(path, now, now)
+}
+
+// SkippedAt returns when speech was last skipped, or the zero time.
+func SkippedAt() time.Time {
+	path, err := SkipPath()
+	if err != nil {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// requestedAt is when audioFile was asked for: Synthesize stamps temporary
+// files with the time synthesis started. Files it cannot stat count as new.
+func requestedAt(audioFile string) time.Time {
+	info, err := os.Stat(audioFile)
+	if err != nil {
+		return time.Now()
+	}
+	return info.ModTime()
+}
+
+// skipped reports whether a skip came after since.
+func skipped(since time.Time) bool {
+	return SkippedAt().After(since)
+}
+
+// waitOrSkip waits for the player commands, killing them when a skip
+// arrives for audio requested at since. It reports whether playback was
+// skipped.
+func waitOrSkip(cmds []*exec.Cmd, since time.Time) (bool, error) {
+	done := make(chan error, 1)
+	go func() { done <- waitAll(cmds) }()
+	ticker := time.NewTicker(skipPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			return false, err
+		case <-ticker.C:
+			if !skipped(since) {
+				continue
+			}
+			log.Debug().Msg("Playback skipped")
+			for _, cmd := range cmds {
+				_ = cmd.Process.Kill()
+			}
+			<-done
+			return true, nil
+		}
+	}
+}
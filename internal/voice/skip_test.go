@@ -0,0 +1,94 @@
+package voice
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSkip_DropsAudioRequestedBefore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var played []string
+	restore := SetPlayer(func(path string) error {
+		played = append(played, path)
+		return nil
+	})
+	defer restore()
+
+	if !SkippedAt().IsZero() {
+		t.Fatal("expected no skip on a fresh home")
+	}
+
+	dir := t.TempDir()
+	queued := filepath.Join(dir, "queued.wav")
+	if err := os.WriteFile(queued, []byte("RIFF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(queued, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Skip(); err != nil {
+		t.Fatalf("Skip: %v", err)
+	}
+	if SkippedAt().Before(past) {
+		t.Fatalf("SkippedAt = %v, want after %v", SkippedAt(), past)
+	}
+
+	if err := NewVoiceEngine(DefaultConfig()).PlayWithOptions(queued, true); err != nil {
+		t.Fatalf("PlayWithOptions: %v", err)
+	}
+	if len(played) != 0 {
+		t.Errorf("audio requested before the skip was played: %v", played)
+	}
+	if _, err := os.Stat(queued); !os.IsNotExist(err) {
+		t.Error("dropped audio should be removed")
+	}
+
+	fresh := filepath.Join(dir, "fresh.wav")
+	if err := os.WriteFile(fresh, []byte("RIFF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(fresh, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewVoiceEngine(DefaultConfig()).PlayWithOptions(fresh, true); err != nil {
+		t.Fatalf("PlayWithOptions: %v", err)
+	}
+	if len(played) != 1 || played[0] != fresh {
+		t.Errorf("played = %v, want the audio requested after the skip", played)
+	}
+}
+
+func TestWaitOrSkip_StopsPlayer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	t.Setenv("HOME", t.TempDir())
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	since := time.Now().Add(-time.Second)
+	go func() {
+		time.Sleep(2 * skipPollInterval)
+		_ = Skip()
+	}()
+
+	start := time.Now()
+	wasSkipped, err := waitOrSkip([]*exec.Cmd{cmd}, since)
+	if err != nil {
+		t.Fatalf("waitOrSkip: %v", err)
+	}
+	if !wasSkipped {
+		t.Error("expected playback to be skipped")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("skip took %v", elapsed)
+	}
+}
@@ -0,0 +1,167 @@
+package voice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/daikw/ccpersona/internal/avatar"
+	"github.com/rs/zerolog/log"
+)
+
+// AvatarConfig sends speech events to the avatar bridge daemon
+// (`ccpersona runtime avatar serve`), which relays them to lip-sync clients.
+type AvatarConfig struct {
+	// Addr is the daemon's address (default avatar.DefaultAddr).
+	Addr string `json:"addr,omitempty"`
+}
+
+// speech is the text behind a synthesized file. While the file plays it is
+// shown in the caption and announced to avatar frontends.
+type speech struct {
+	persona string
+	text    string
+	caption *CaptionConfig
+	avatar  *AvatarConfig
+	marks   []avatar.Mark
+}
+
+// pendingSpeech maps synthesized audio files to their speech, so every
+// playback path shows it without passing text along. pendingMarks holds the
+// timing engines report until the speech is registered.
+var (
+	speechMu      sync.Mutex
+	pendingSpeech = map[string]*speech{}
+	pendingMarks  = map[string][]avatar.Mark{}
+)
+
+func setSpeech(audioFile string, s *speech) {
+	speechMu.Lock()
+	defer speechMu.Unlock()
+	pendingSpeech[audioFile] = s
+}
+
+// takeSpeech returns and forgets the speech of audioFile, or nil.
+func takeSpeech(audioFile string) *speech {
+	speechMu.Lock()
+	defer speechMu.Unlock()
+	s := pendingSpeech[audioFile]
+	delete(pendingSpeech, audioFile)
+	return s
+}
+
+func setMarks(audioFile string, marks []avatar.Mark) {
+	speechMu.Lock()
+	defer speechMu.Unlock()
+	pendingMarks[audioFile] = marks
+}
+
+// takeMarks returns and forgets the timing of audioFile.
+func takeMarks(audioFile string) []avatar.Mark {
+	speechMu.Lock()
+	defer speechMu.Unlock()
+	marks := pendingMarks[audioFile]
+	delete(pendingMarks, audioFile)
+	return marks
+}
+
+// savedSpeech is a speech handed to another process with SaveSpeech.
+type savedSpeech struct {
+	Persona string         `json:"persona,omitempty"`
+	Text    string         `json:"text"`
+	Caption *CaptionConfig `json:"caption,omitempty"`
+	Avatar  *AvatarConfig  `json:"avatar,omitempty"`
+	Marks   []avatar.Mark  `json:"marks,omitempty"`
+}
+
+func speechPath(audioFile string) string {
+	return audioFile + ".speech.json"
+}
+
+// SaveSpeech moves the speech of audioFile, if any, into a file next to it,
+// so the process that plays the file shows the caption and avatar events.
+func SaveSpeech(audioFile string) error {
+	s := takeSpeech(audioFile)
+	if s == nil {
+		return nil
+	}
+	data, err := json.Marshal(savedSpeech{Persona: s.persona, Text: s.text, Caption: s.caption, Avatar: s.avatar, Marks: s.marks})
+	if err == nil {
+		err = os.WriteFile(speechPath(audioFile), data, 0o600)
+	}
+	if err != nil {
+		setSpeech(audioFile, s)
+		return err
+	}
+	return nil
+}
+
+// DiscardAudio deletes synthesized audio that will not be played, forgetting
+// its caption and avatar events.
+func DiscardAudio(audioFile string) {
+	takeSpeech(audioFile)
+	_ = os.Remove(audioFile)
+}
+
+// RestoreSpeech registers the speech saved for audioFile by SaveSpeech and
+// removes its file. Audio without saved speech is not an error.
+func RestoreSpeech(audioFile string) error {
+	path := speechPath(audioFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	_ = os.Remove(path)
+	var saved savedSpeech
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	setSpeech(audioFile, &speech{persona: saved.Persona, text: saved.Text, caption: saved.Caption, avatar: saved.Avatar, marks: saved.Marks})
+	return nil
+}
+
+// start is called as playback of audioFile begins.
+func (s *speech) start(audioFile string) {
+	if s == nil {
+		return
+	}
+	if s.caption != nil {
+		s.caption.show(s.persona, s.text)
+	}
+	if s.avatar != nil {
+		event := avatar.NewEvent(avatar.EventSpeechStart)
+		event.Persona = s.persona
+		event.Text = s.text
+		event.Marks = s.marks
+		if d, err := AudioDuration(audioFile); err == nil {
+			event.DurationMS = d.Milliseconds()
+		}
+		s.publish(event)
+	}
+}
+
+// end is called when playback has finished or failed to start.
+func (s *speech) end() {
+	if s == nil {
+		return
+	}
+	if s.caption != nil {
+		s.caption.clear(s.persona)
+	}
+	if s.avatar != nil {
+		event := avatar.NewEvent(avatar.EventSpeechEnd)
+		event.Persona = s.persona
+		s.publish(event)
+	}
+}
+
+func (s *speech) publish(event avatar.Event) {
+	if err := avatar.Publish(context.Background(), s.avatar.Addr, event); err != nil {
+		log.Debug().Err(err).Str("event", event.Type).Msg("Failed to send avatar event")
+	}
+}
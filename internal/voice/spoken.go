@@ -0,0 +1,110 @@
+package voice
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+// SpokenHistory remembers the last message read aloud in a session so diff
+// mode can skip sentences the user has already heard. It shares the dedup
+// directory and its 24-hour cleanup.
+type SpokenHistory struct {
+	sessionID string
+	dir       string
+}
+
+// NewSpokenHistory creates the history for the given session.
+func NewSpokenHistory(sessionID string) *SpokenHistory {
+	return &SpokenHistory{
+		sessionID: sessionID,
+		dir:       filepath.Join(os.TempDir(), dedupDir),
+	}
+}
+
+// Last returns the previously spoken message, or "" when there is none.
+func (h *SpokenHistory) Last() string {
+	data, err := os.ReadFile(h.path())
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Record stores text as the last spoken message for this session.
+func (h *SpokenHistory) Record(text string) {
+	if err := os.MkdirAll(h.dir, 0755); err != nil {
+		log.Debug().Err(err).Msg("Failed to create spoken history directory")
+		return
+	}
+	// The file holds message text, not a hash, so keep it private.
+	if err := fsutil.WriteFile(h.path(), []byte(text), 0600); err != nil {
+		log.Debug().Err(err).Msg("Failed to write spoken history")
+	}
+}
+
+func (h *SpokenHistory) path() string {
+	return filepath.Join(h.dir, safeSessionName(h.sessionID)+".lastspoken")
+}
+
+// NewSentences returns the sentences of current that do not appear in
+// previous, in their original order. Sentences are compared with whitespace
+// collapsed, so reflowed text still matches. It returns "" when nothing is
+// new.
+func NewSentences(previous, current string) string {
+	if strings.TrimSpace(previous) == "" {
+		return strings.TrimSpace(current)
+	}
+	seen := make(map[string]bool)
+	for _, s := range splitSentences(previous) {
+		seen[normalizeSentence(s)] = true
+	}
+
+	var out []string
+	for _, s := range splitSentences(current) {
+		key := normalizeSentence(s)
+		if key == "" || seen[key] {
+			continue
+		}
+		// A sentence repeated within the new message is read once.
+		seen[key] = true
+		out = append(out, strings.TrimSpace(s))
+	}
+	return strings.Join(out, " ")
+}
+
+// splitSentences splits after sentence-ending punctuation. ASCII periods end
+// a sentence only before whitespace or the end of text, so version numbers
+// and file names stay intact.
+func splitSentences(text string) []string {
+	var parts []string
+	start := 0
+	for i, r := range text {
+		end := i + utf8.RuneLen(r)
+		switch {
+		case strings.ContainsRune(sentenceEnds, r):
+		case r == '.':
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			if end < len(text) && !unicode.IsSpace(next) {
+				continue
+			}
+		default:
+			continue
+		}
+		parts = append(parts, text[start:end])
+		start = end
+	}
+	if start < len(text) {
+		parts = append(parts, text[start:])
+	}
+	return parts
+}
+
+func normalizeSentence(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
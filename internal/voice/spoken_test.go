@@ -0,0 +1,59 @@
+package voice
+
+import (
+	"testing"
+)
+
+func TestNewSentences(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string
+		current  string
+		want     string
+	}{
+		{"no history", "", "Hello. World.", "Hello. World."},
+		{"appended sentence", "Fixed the bug. Tests pass.", "Fixed the bug. Tests pass. Also updated docs.", "Also updated docs."},
+		{"changed sentence", "Build failed. Retrying.", "Build failed. Build passed now.", "Build passed now."},
+		{"nothing new", "Done. Ready.", "Done.\nReady.", ""},
+		{"reflowed whitespace", "Line one  is here.", "Line one is here. New line.", "New line."},
+		{"japanese", "修正しました。テストも通ります。", "修正しました。ドキュメントも更新しました。", "ドキュメントも更新しました。"},
+		{"version numbers", "Bumped to v1.2.3 today.", "Bumped to v1.2.4 today.", "Bumped to v1.2.4 today."},
+		{"repeat within message", "", "Same. Same.", "Same. Same."},
+		{"repeat within new text", "Old.", "New. New.", "New."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewSentences(tt.previous, tt.current); got != tt.want {
+				t.Errorf("NewSentences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpokenHistory(t *testing.T) {
+	dir := t.TempDir()
+	h := NewSpokenHistory("session-a")
+	h.dir = dir
+
+	if got := h.Last(); got != "" {
+		t.Errorf("Last() before Record = %q", got)
+	}
+	h.Record("first message")
+	if got := h.Last(); got != "first message" {
+		t.Errorf("Last() = %q", got)
+	}
+
+	other := NewSpokenHistory("session-b")
+	other.dir = dir
+	if got := other.Last(); got != "" {
+		t.Errorf("other session Last() = %q", got)
+	}
+
+	// Unsafe IDs are hashed and cannot escape the directory.
+	evil := NewSpokenHistory("../../etc/passwd")
+	evil.dir = dir
+	evil.Record("x")
+	if got := evil.Last(); got != "x" {
+		t.Errorf("hashed session Last() = %q", got)
+	}
+}
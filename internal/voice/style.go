@@ -0,0 +1,170 @@
+package voice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/daikw/ccpersona/internal/voice/provider"
+	"github.com/rs/zerolog/log"
+)
+
+// SpeakerStyle picks a style of the configured VOICEVOX or AivisSpeech
+// speaker by name, such as "あまあま" or "怒り", and sets how strongly
+// AivisSpeech expresses its emotion.
+type SpeakerStyle struct {
+	// Name is a style of the speaker that owns the configured speaker ID.
+	Name string `json:"name,omitempty"`
+	// Emotion is the emotional intensity, 0 to 2 (engine default 1). It is
+	// sent as intonationScale, which AivisSpeech reads as the strength of
+	// the style's emotion, and overrides prosody.intonation_scale.
+	Emotion *float64 `json:"emotion,omitempty"`
+}
+
+// Validate checks the emotional intensity.
+func (s *SpeakerStyle) Validate() error {
+	if s != nil && s.Emotion != nil && (*s.Emotion < 0 || *s.Emotion > 2) {
+		return fmt.Errorf("emotion must be between 0 and 2, got %g", *s.Emotion)
+	}
+	return nil
+}
+
+// ValidateStyles checks a speaker style and the per-urgency styles.
+func ValidateStyles(style *SpeakerStyle, styles map[string]*SpeakerStyle) error {
+	if err := style.Validate(); err != nil {
+		return fmt.Errorf("speaker_style: %w", err)
+	}
+	for urgency, s := range styles {
+		switch urgency {
+		case "low", "normal", "high", "critical":
+		default:
+			return fmt.Errorf("speaker_styles.%s: urgency must be low, normal, high, or critical", urgency)
+		}
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("speaker_styles.%s: %w", urgency, err)
+		}
+	}
+	return nil
+}
+
+// ForUrgency returns the options with the style for an urgency level, when
+// one is configured. Fields the urgency style leaves unset keep the base
+// style's.
+func (o VoiceOptions) ForUrgency(urgency string) VoiceOptions {
+	override := o.SpeakerStyles[urgency]
+	if override == nil {
+		return o
+	}
+	merged := SpeakerStyle{}
+	if o.SpeakerStyle != nil {
+		merged = *o.SpeakerStyle
+	}
+	if override.Name != "" {
+		merged.Name = override.Name
+	}
+	if override.Emotion != nil {
+		merged.Emotion = override.Emotion
+	}
+	o.SpeakerStyle = &merged
+	return o
+}
+
+// EngineSpeaker is a speaker of a VOICEVOX-compatible engine with its
+// styles, as returned by GET /speakers.
+type EngineSpeaker struct {
+	Name   string        `json:"name"`
+	UUID   string        `json:"speaker_uuid"`
+	Styles []EngineStyle `json:"styles"`
+}
+
+// EngineStyle is one style of a speaker. Its ID is the speaker ID that
+// audio_query and synthesis take.
+type EngineStyle struct {
+	Name string `json:"name"`
+	ID   int64  `json:"id"`
+	// Type is "talk" for speech styles; others (singing) cannot read text.
+	Type string `json:"type,omitempty"`
+}
+
+// Speakers lists the speakers and styles of a local engine.
+func (ve *VoiceEngine) Speakers(engine string) ([]EngineSpeaker, error) {
+	base := ve.voicevoxURL
+	if engine == EngineAivisSpeech {
+		base = ve.aivisSpeechURL
+	}
+	resp, err := ve.httpClient.Get(base + "/speakers")
+	if err != nil {
+		return nil, unavailable("failed to list %s speakers: %w", engine, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list %s speakers: status %d", engine, resp.StatusCode)
+	}
+	var speakers []EngineSpeaker
+	if err := json.NewDecoder(resp.Body).Decode(&speakers); err != nil {
+		return nil, fmt.Errorf("failed to parse %s speakers: %w", engine, err)
+	}
+	return speakers, nil
+}
+
+// styleID returns the ID of the configured style of the speaker that owns
+// id, or id itself when no style is configured or it cannot be found.
+func (ve *VoiceEngine) styleID(engine string, id int64) int64 {
+	if ve.config.SpeakerStyle == nil || ve.config.SpeakerStyle.Name == "" {
+		return id
+	}
+	speakers, err := ve.Speakers(engine)
+	if err != nil {
+		log.Warn().Err(err).Msg("Cannot look up the voice style; using the configured speaker")
+		return id
+	}
+	if styleID, ok := findStyle(speakers, id, ve.config.SpeakerStyle.Name); ok {
+		return styleID
+	}
+	log.Warn().Str("style", ve.config.SpeakerStyle.Name).Int64("speaker", id).Msg("The speaker has no such style; using the configured speaker")
+	return id
+}
+
+// findStyle finds a style by name among the styles of the speaker that owns
+// the style ID id.
+func findStyle(speakers []EngineSpeaker, id int64, name string) (int64, bool) {
+	for _, speaker := range speakers {
+		owns := false
+		for _, style := range speaker.Styles {
+			owns = owns || style.ID == id
+		}
+		if !owns {
+			continue
+		}
+		for _, style := range speaker.Styles {
+			if style.Name == name {
+				return style.ID, true
+			}
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+// speakerVoices lists every talk style as a voice: ID is the style ID to
+// configure as the speaker, Name the speaker, and Description the style.
+func speakerVoices(speakers []EngineSpeaker) []provider.Voice {
+	var voices []provider.Voice
+	for _, speaker := range speakers {
+		for _, style := range speaker.Styles {
+			if style.Type != "" && style.Type != "talk" {
+				continue
+			}
+			voices = append(voices, provider.Voice{
+				ID:          strconv.FormatInt(style.ID, 10),
+				Name:        speaker.Name,
+				Language:    "ja",
+				Description: style.Name,
+			})
+		}
+	}
+	return voices
+}
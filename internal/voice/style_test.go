@@ -0,0 +1,115 @@
+package voice
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+const speakersJSON = `[
+	{"name": "まお", "speaker_uuid": "a", "styles": [
+		{"name": "ノーマル", "id": 888753760, "type": "talk"},
+		{"name": "あまあま", "id": 888753761, "type": "talk"}
+	]},
+	{"name": "ずんだもん", "speaker_uuid": "b", "styles": [
+		{"name": "ノーマル", "id": 3},
+		{"name": "ハミング", "id": 3001, "type": "humming"}
+	]}
+]`
+
+func TestFindStyle(t *testing.T) {
+	var speakers []EngineSpeaker
+	if err := json.Unmarshal([]byte(speakersJSON), &speakers); err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := findStyle(speakers, 888753761, "ノーマル"); !ok || id != 888753760 {
+		t.Errorf("findStyle = %d, %v; want the ノーマル style of the same speaker", id, ok)
+	}
+	if _, ok := findStyle(speakers, 3, "あまあま"); ok {
+		t.Error("a style of another speaker should not match")
+	}
+
+	voices := speakerVoices(speakers)
+	if len(voices) != 3 {
+		t.Fatalf("expected 3 talk styles, got %+v", voices)
+	}
+	if voices[1].ID != "888753761" || voices[1].Name != "まお" || voices[1].Description != "あまあま" {
+		t.Errorf("voice = %+v", voices[1])
+	}
+}
+
+func TestForUrgency(t *testing.T) {
+	calm, tense := 0.8, 1.6
+	opts := VoiceOptions{
+		SpeakerStyle:  &SpeakerStyle{Name: "ノーマル", Emotion: &calm},
+		SpeakerStyles: map[string]*SpeakerStyle{"critical": {Name: "怒り"}, "high": {Emotion: &tense}},
+	}
+	if got := opts.ForUrgency("normal").SpeakerStyle; got.Name != "ノーマル" || *got.Emotion != calm {
+		t.Errorf("normal = %+v, want the base style", got)
+	}
+	if got := opts.ForUrgency("critical").SpeakerStyle; got.Name != "怒り" || *got.Emotion != calm {
+		t.Errorf("critical = %+v, want 怒り with the base emotion", got)
+	}
+	if got := opts.ForUrgency("high").SpeakerStyle; got.Name != "ノーマル" || *got.Emotion != tense {
+		t.Errorf("high = %+v, want ノーマル with emotion %v", got, tense)
+	}
+	if opts.SpeakerStyle.Name != "ノーマル" {
+		t.Error("ForUrgency should not change the base style")
+	}
+
+	if err := ValidateStyles(nil, map[string]*SpeakerStyle{"urgent": {}}); err == nil {
+		t.Error("an unknown urgency should fail")
+	}
+	tooMuch := 3.0
+	if err := ValidateStyles(&SpeakerStyle{Emotion: &tooMuch}, nil); err == nil {
+		t.Error("emotion above 2 should fail")
+	}
+}
+
+func TestSynthesizeWithSpeakerStyle(t *testing.T) {
+	var querySpeaker, synthSpeaker string
+	var intonation float64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/speakers":
+			_, _ = io.WriteString(w, speakersJSON)
+		case "/audio_query":
+			querySpeaker = r.URL.Query().Get("speaker")
+			_, _ = io.WriteString(w, `{"accent_phrases":[],"intonationScale":1}`)
+		case "/synthesis":
+			synthSpeaker = r.URL.Query().Get("speaker")
+			var query struct {
+				IntonationScale float64 `json:"intonationScale"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&query)
+			intonation = query.IntonationScale
+			_, _ = io.WriteString(w, "RIFF")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("CCPERSONA_AIVISSPEECH_URL", srv.URL)
+
+	emotion := 1.4
+	ve := NewVoiceEngine(&Config{
+		AivisSpeechSpeaker: 888753760,
+		VolumeScale:        1,
+		SpeedScale:         1,
+		SpeakerStyle:       &SpeakerStyle{Name: "あまあま", Emotion: &emotion},
+	})
+	audio, err := ve.synthesizeAivisSpeech("こんにちは")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(audio)
+	if querySpeaker != "888753761" || synthSpeaker != "888753761" {
+		t.Errorf("speaker = %s/%s, want the あまあま style 888753761", querySpeaker, synthSpeaker)
+	}
+	if intonation != emotion {
+		t.Errorf("intonationScale = %v, want %v", intonation, emotion)
+	}
+}
@@ -0,0 +1,110 @@
+package voice
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/daikw/ccpersona/internal/avatar"
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// Subtitle formats.
+const (
+	SubtitleSRT = "srt"
+	SubtitleVTT = "vtt"
+)
+
+// Cue is one subtitle, shown from Start to End.
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// ValidSubtitleFormat reports whether format is srt or vtt.
+func ValidSubtitleFormat(format string) bool {
+	return format == SubtitleSRT || format == SubtitleVTT
+}
+
+// SubtitlePath returns the subtitle file written next to audioFile.
+func SubtitlePath(audioFile, format string) string {
+	return strings.TrimSuffix(audioFile, filepath.Ext(audioFile)) + "." + format
+}
+
+// SentenceCues times each sentence of text within audio lasting total.
+// Sentence marks from the provider give exact start times; without them the
+// duration is shared out by sentence length.
+func SentenceCues(text string, marks []avatar.Mark, total time.Duration) []Cue {
+	var cues []Cue
+	for _, mark := range marks {
+		if mark.Type != avatar.MarkSentence {
+			continue
+		}
+		start := time.Duration(mark.TimeMS) * time.Millisecond
+		if n := len(cues); n > 0 {
+			cues[n-1].End = start
+		}
+		cues = append(cues, Cue{Start: start, End: total, Text: normalizeSentence(mark.Value)})
+	}
+	if len(cues) > 0 {
+		return cues
+	}
+
+	var sentences []string
+	weight := 0
+	for _, s := range splitSentences(text) {
+		if s = normalizeSentence(s); s != "" {
+			sentences = append(sentences, s)
+			weight += utf8.RuneCountInString(s)
+		}
+	}
+	at := time.Duration(0)
+	done := 0
+	for _, s := range sentences {
+		done += utf8.RuneCountInString(s)
+		end := time.Duration(float64(total) * float64(done) / float64(weight))
+		cues = append(cues, Cue{Start: at, End: end, Text: s})
+		at = end
+	}
+	return cues
+}
+
+// FormatSubtitles renders cues as SRT or WebVTT.
+func FormatSubtitles(cues []Cue, format string) string {
+	var b strings.Builder
+	if format == SubtitleVTT {
+		b.WriteString("WEBVTT\n\n")
+	}
+	for i, cue := range cues {
+		if format == SubtitleVTT {
+			text := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(cue.Text)
+			fmt.Fprintf(&b, "%s --> %s\n%s\n\n", subtitleTime(cue.Start, "."), subtitleTime(cue.End, "."), text)
+			continue
+		}
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, subtitleTime(cue.Start, ","), subtitleTime(cue.End, ","), cue.Text)
+	}
+	return b.String()
+}
+
+// subtitleTime formats d as HH:MM:SS followed by sep and milliseconds.
+func subtitleTime(d time.Duration, sep string) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// writeSubtitles writes the subtitles of text spoken in audioFile next to it.
+func writeSubtitles(audioFile, text string, marks []avatar.Mark, format string) (string, error) {
+	total, err := AudioDuration(audioFile)
+	if err != nil {
+		return "", fmt.Errorf("cannot time subtitles: %w (use WAV or MP3 output)", err)
+	}
+	path := SubtitlePath(audioFile, format)
+	content := FormatSubtitles(SentenceCues(text, marks, total), format)
+	if err := fsutil.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
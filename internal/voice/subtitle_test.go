@@ -0,0 +1,60 @@
+package voice
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/avatar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSentenceCuesEstimated(t *testing.T) {
+	// 3 and 9 runes: the first sentence gets a quarter of the time.
+	cues := SentenceCues("Ok.  All done.", nil, 4*time.Second)
+	assert.Equal(t, []Cue{
+		{Start: 0, End: time.Second, Text: "Ok."},
+		{Start: time.Second, End: 4 * time.Second, Text: "All done."},
+	}, cues)
+
+	assert.Len(t, SentenceCues("完了しました。テストも通りました。", nil, time.Second), 2)
+}
+
+func TestSentenceCuesFromMarks(t *testing.T) {
+	marks := []avatar.Mark{
+		{TimeMS: 0, Type: avatar.MarkSentence, Value: "First."},
+		{TimeMS: 50, Type: avatar.MarkViseme, Value: avatar.VisemeA},
+		{TimeMS: 1200, Type: avatar.MarkSentence, Value: "Second."},
+	}
+	cues := SentenceCues("ignored", marks, 3*time.Second)
+	assert.Equal(t, []Cue{
+		{Start: 0, End: 1200 * time.Millisecond, Text: "First."},
+		{Start: 1200 * time.Millisecond, End: 3 * time.Second, Text: "Second."},
+	}, cues)
+}
+
+func TestFormatSubtitles(t *testing.T) {
+	cues := []Cue{
+		{Start: 0, End: 1500 * time.Millisecond, Text: "a < b"},
+		{Start: 1500 * time.Millisecond, End: 3723004 * time.Millisecond, Text: "Done."},
+	}
+	assert.Equal(t, "1\n00:00:00,000 --> 00:00:01,500\na < b\n\n2\n00:00:01,500 --> 01:02:03,004\nDone.\n\n", FormatSubtitles(cues, SubtitleSRT))
+	assert.Equal(t, "WEBVTT\n\n00:00:00.000 --> 00:00:01.500\na &lt; b\n\n00:00:01.500 --> 01:02:03.004\nDone.\n\n", FormatSubtitles(cues, SubtitleVTT))
+}
+
+func TestWriteSubtitles(t *testing.T) {
+	paths := writeFiles(t, makeWAV(make([]byte, 96000)), []byte("not audio"))
+	audio := paths[0] + ".wav"
+	require.NoError(t, os.Rename(paths[0], audio))
+
+	path, err := writeSubtitles(audio, "One. Two.", nil, SubtitleSRT)
+	require.NoError(t, err)
+	assert.Equal(t, paths[0]+".srt", path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "1\n00:00:00,000 --> 00:00:00,500\nOne.\n\n2\n00:00:00,500 --> 00:00:01,000\nTwo.\n\n", string(data))
+
+	_, err = writeSubtitles(paths[1], "One.", nil, SubtitleVTT)
+	assert.Error(t, err, "duration unknown")
+}
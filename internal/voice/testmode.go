@@ -0,0 +1,120 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Test mode runs the whole pipeline without a TTS engine or sound device:
+// every synthesis returns silent WAV audio and every playback only records
+// the file, so integration tests and headless CI exercise the rest of the
+// logic safely. What would have been spoken is appended to TestRecordPath.
+
+// TestMode reports whether CCPERSONA_TEST_MODE is on.
+func TestMode() bool {
+	on, _ := envBool(EnvTestMode)
+	return on
+}
+
+// TestRecordPath is the file test mode writes its records to:
+// CCPERSONA_TEST_RECORD, or ccpersona-test-mode.jsonl in the temp directory.
+func TestRecordPath() string {
+	if path := os.Getenv(EnvTestRecord); path != "" {
+		return path
+	}
+	return filepath.Join(os.TempDir(), "ccpersona-test-mode.jsonl")
+}
+
+// TestRecord is one line of the test mode record.
+type TestRecord struct {
+	Time time.Time `json:"time"`
+	// Action is "synthesize" or "play".
+	Action   string `json:"action"`
+	Provider string `json:"provider,omitempty"`
+	Voice    string `json:"voice,omitempty"`
+	Text     string `json:"text,omitempty"`
+	File     string `json:"file,omitempty"`
+}
+
+const (
+	testSampleRate = 16000
+	// testRuneDuration is how long test audio lasts per character, so
+	// duration-dependent logic sees plausible values.
+	testRuneDuration = 50 * time.Millisecond
+	maxTestDuration  = 30 * time.Second
+)
+
+// testSpoken maps synthesized test audio to its text for the play record.
+var (
+	testMu     sync.Mutex
+	testSpoken = map[string]string{}
+)
+
+// synthesizeTest writes silent audio for text where the provider's output
+// would have gone.
+func synthesizeTest(text string, options VoiceOptions) (string, error) {
+	audio := silentWAV(min(time.Duration(utf8.RuneCountInString(text))*testRuneDuration, maxTestDuration))
+	return writeAudio(bytes.NewReader(audio), options, "wav")
+}
+
+// recordTestSynthesis records the text of a finished synthesis.
+func recordTestSynthesis(text, audioFile string, options VoiceOptions) {
+	if audioFile != "" {
+		testMu.Lock()
+		testSpoken[audioFile] = text
+		testMu.Unlock()
+	}
+	provider := options.Provider
+	if provider == "" {
+		provider = "auto"
+	}
+	writeTestRecord(TestRecord{Action: "synthesize", Provider: provider, Voice: options.Voice, Text: text, File: audioFile})
+}
+
+// testPlayer records playback instead of playing.
+func testPlayer(audioFile string) error {
+	testMu.Lock()
+	text := testSpoken[audioFile]
+	delete(testSpoken, audioFile)
+	testMu.Unlock()
+	writeTestRecord(TestRecord{Action: "play", Text: text, File: audioFile})
+	return nil
+}
+
+func writeTestRecord(record TestRecord) {
+	record.Time = time.Now()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	testMu.Lock()
+	defer testMu.Unlock()
+	f, err := os.OpenFile(TestRecordPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.Write(append(data, '\n'))
+}
+
+// silentWAV returns 16 kHz mono 16-bit PCM silence lasting d.
+func silentWAV(d time.Duration) []byte {
+	size := int(d*testSampleRate/time.Second) * 2
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(36+size))
+	b.WriteString("WAVEfmt ")
+	for _, v := range []any{uint32(16), uint16(1), uint16(1), uint32(testSampleRate), uint32(testSampleRate * 2), uint16(2), uint16(16)} {
+		_ = binary.Write(&b, binary.LittleEndian, v)
+	}
+	b.WriteString("data")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(size))
+	b.Write(make([]byte, size))
+	return b.Bytes()
+}
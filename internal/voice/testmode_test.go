@@ -0,0 +1,71 @@
+package voice
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTestMode(t *testing.T) {
+	record := filepath.Join(t.TempDir(), "spoken.jsonl")
+	t.Setenv(EnvTestMode, "1")
+	t.Setenv(EnvTestRecord, record)
+
+	// No OpenAI key or server is needed: synthesis never reaches the provider.
+	manager := NewVoiceManager(DefaultConfig())
+	audioFile, err := manager.Synthesize(context.Background(), "こんにちは", VoiceOptions{Provider: "openai", Voice: "nova"})
+	if err != nil {
+		t.Fatalf("Synthesize() error = %v", err)
+	}
+	if d, err := AudioDuration(audioFile); err != nil || d != 5*testRuneDuration {
+		t.Errorf("AudioDuration() = %v, %v; want %v of silence", d, err, 5*testRuneDuration)
+	}
+	if err := NewVoiceEngine(DefaultConfig()).PlayWithOptions(audioFile, true); err != nil {
+		t.Fatalf("PlayWithOptions() error = %v", err)
+	}
+	if _, err := os.Stat(audioFile); !os.IsNotExist(err) {
+		t.Errorf("played file should be removed, stat error = %v", err)
+	}
+
+	f, err := os.Open(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []TestRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r TestRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	want := []TestRecord{
+		{Action: "synthesize", Provider: "openai", Voice: "nova", Text: "こんにちは", File: audioFile},
+		{Action: "play", Text: "こんにちは", File: audioFile},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("records = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		got[i].Time = time.Time{}
+		if got[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTestMode_Off(t *testing.T) {
+	t.Setenv(EnvTestMode, "")
+	if TestMode() {
+		t.Error("TestMode() should be off when CCPERSONA_TEST_MODE is unset")
+	}
+	if currentPlayer() != nil {
+		t.Error("currentPlayer() should use the external player outside test mode")
+	}
+}
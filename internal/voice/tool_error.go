@@ -0,0 +1,179 @@
+package voice
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// maxToolErrorChars caps the error detail in a tool error summary.
+const maxToolErrorChars = 120
+
+// ToolError is a failed tool call that ended the final turn of a transcript
+// without a reply to read.
+type ToolError struct {
+	// Tool is the name of the failed tool, or "" when its call was not found.
+	Tool string
+	// Output is the tool's error output.
+	Output string
+}
+
+var (
+	toolErrorTag = regexp.MustCompile(`</?tool_use_error>`)
+	exitCodeLine = regexp.MustCompile(`^(?:Exit code|exit status) (\d+)$`)
+	errorWords   = regexp.MustCompile(`(?i)error|fail|fatal|panic|denied|not found|no such|cannot|can't|invalid|refused|timed out|timeout`)
+)
+
+// GetFinalToolError returns the tool error that ends the transcript's final
+// turn, or nil when the turn ends with assistant text, a successful tool
+// result, or a prompt.
+func (tr *TranscriptReader) GetFinalToolError(transcriptPath string) (*ToolError, error) {
+	file, err := os.Open(transcriptPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	lines, err := tr.readLinesReverseUntil(file, func(lines []string) bool {
+		_, decided := finalToolError(lines)
+		return decided
+	})
+	if err != nil {
+		return nil, err
+	}
+	toolErr, _ := finalToolError(lines)
+	return toolErr, nil
+}
+
+// finalToolError inspects lines given newest first. The newest main-thread
+// entry with content decides: an error tool_result is returned with the name
+// of the tool_use it answers, anything else yields nil. decided reports that
+// no older line is needed.
+func finalToolError(lines []string) (toolErr *ToolError, decided bool) {
+	var toolUseID string
+	for _, line := range lines {
+		entry, ok := parseEntry(line)
+		if !ok || entry.IsSidechain {
+			continue
+		}
+		if toolErr != nil {
+			if entry.isAssistant() {
+				if name := entry.toolName(toolUseID); name != "" {
+					toolErr.Tool = name
+					return toolErr, true
+				}
+			} else if entry.Type == "user" && !entry.isToolResult() {
+				// The call lies in an earlier turn or was not recorded.
+				return toolErr, true
+			}
+			continue
+		}
+		switch {
+		case entry.isToolResult():
+			block, ok := entry.errorResult()
+			if !ok {
+				return nil, true
+			}
+			toolErr, toolUseID = &ToolError{Output: blockText(block.Content)}, block.ToolUseID
+		case entry.isAssistant():
+			if len(entry.spokenTexts()) > 0 {
+				return nil, true
+			}
+		case entry.Type == "user":
+			return nil, true
+		}
+	}
+	return toolErr, false
+}
+
+// errorResult returns the entry's failed tool_result block, if any.
+func (e *transcriptEntry) errorResult() (contentBlock, bool) {
+	for _, block := range e.Message.Content {
+		if block.Type == "tool_result" && block.IsError {
+			return block, true
+		}
+	}
+	return contentBlock{}, false
+}
+
+// toolName returns the name of the entry's tool_use block with the given id.
+func (e *transcriptEntry) toolName(id string) string {
+	for _, block := range e.Message.Content {
+		if block.Type == "tool_use" && block.ID == id {
+			return block.Name
+		}
+	}
+	return ""
+}
+
+// blockText returns the text of tool_result content given as a string or as
+// text blocks.
+func blockText(raw json.RawMessage) string {
+	var blocks contentBlocks
+	if len(raw) == 0 || json.Unmarshal(raw, &blocks) != nil {
+		return ""
+	}
+	var texts []string
+	for _, block := range blocks {
+		if block.Type == "text" && block.Text != "" {
+			texts = append(texts, block.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Summary describes the failure in one short spoken sentence, such as
+// "Bash failed with exit code 1: go: cannot find main module". The detail
+// is the first output line that reads like an error, or the first line.
+func (e *ToolError) Summary() string {
+	var exitCode, detail, first string
+	for _, line := range strings.Split(toolErrorTag.ReplaceAllString(e.Output, ""), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if m := exitCodeLine.FindStringSubmatch(line); m != nil {
+			if exitCode == "" {
+				exitCode = m[1]
+			}
+			continue
+		}
+		if first == "" {
+			first = line
+		}
+		if detail == "" && errorWords.MatchString(line) {
+			detail = line
+		}
+	}
+	if detail == "" {
+		detail = first
+	}
+
+	msg := speakableToolName(e.Tool) + " failed"
+	if exitCode != "" {
+		msg += " with exit code " + exitCode
+	}
+	if detail != "" {
+		if runes := []rune(detail); len(runes) > maxToolErrorChars {
+			detail = string(runes[:maxToolErrorChars]) + "…"
+		}
+		msg += ": " + detail
+	}
+	return msg
+}
+
+// speakableToolName turns MCP tool names such as mcp__github__create_issue
+// into "github create_issue".
+func speakableToolName(name string) string {
+	if name == "" {
+		return "A tool"
+	}
+	if rest, ok := strings.CutPrefix(name, "mcp__"); ok {
+		return strings.ReplaceAll(rest, "__", " ")
+	}
+	return name
+}
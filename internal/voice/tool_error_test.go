@@ -0,0 +1,70 @@
+package voice
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetFinalToolError(t *testing.T) {
+	prompt := `{"type":"user","uuid":"u1","message":{"role":"user","content":"run the tests"}}`
+	intro := `{"type":"assistant","uuid":"a1","message":{"id":"msg_a","role":"assistant","content":[{"type":"text","text":"Running the tests."}]}}`
+	call := `{"type":"assistant","uuid":"a2","message":{"id":"msg_a","role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"Bash"}]}}`
+	failed := `{"type":"user","uuid":"u2","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","is_error":true,"content":"Exit code 2\n--- FAIL: TestX\nFAIL\tpkg 0.1s"}]}}`
+	succeeded := `{"type":"user","uuid":"u2","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"ok"}]}}`
+	sidechain := `{"type":"assistant","uuid":"s1","isSidechain":true,"message":{"id":"msg_s","role":"assistant","content":[{"type":"text","text":"Subagent."}]}}`
+	reply := `{"type":"assistant","uuid":"a3","message":{"id":"msg_b","role":"assistant","content":[{"type":"text","text":"Tests fail."}]}}`
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  *ToolError
+	}{
+		{"ends on an error", []string{prompt, intro, call, failed, sidechain}, &ToolError{Tool: "Bash", Output: "Exit code 2\n--- FAIL: TestX\nFAIL\tpkg 0.1s"}},
+		{"reply after the error", []string{prompt, intro, call, failed, reply}, nil},
+		{"ends on a success", []string{prompt, intro, call, succeeded}, nil},
+		{"ends on a prompt", []string{intro, call, failed, prompt}, nil},
+		{"call not recorded", []string{prompt, failed}, &ToolError{Output: "Exit code 2\n--- FAIL: TestX\nFAIL\tpkg 0.1s"}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "t.jsonl")
+		if err := os.WriteFile(path, []byte(strings.Join(tt.lines, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := NewTranscriptReader(DefaultConfig()).GetFinalToolError(path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: GetFinalToolError = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBlockText(t *testing.T) {
+	if got := blockText([]byte(`[{"type":"text","text":"one"},{"type":"image"},{"type":"text","text":"two"}]`)); got != "one\ntwo" {
+		t.Errorf("blockText(blocks) = %q", got)
+	}
+	if got := blockText([]byte(`{"unexpected":true}`)); got != "" {
+		t.Errorf("blockText(object) = %q, want empty", got)
+	}
+}
+
+func TestToolErrorSummary(t *testing.T) {
+	tests := []struct {
+		err  ToolError
+		want string
+	}{
+		{ToolError{Tool: "Bash", Output: "Exit code 1\ncompiling...\ngo: cannot find main module"}, "Bash failed with exit code 1: go: cannot find main module"},
+		{ToolError{Tool: "Read", Output: "<tool_use_error>File does not exist.</tool_use_error>"}, "Read failed: File does not exist."},
+		{ToolError{Tool: "mcp__github__create_issue", Output: "Bad credentials"}, "github create_issue failed: Bad credentials"},
+		{ToolError{}, "A tool failed"},
+		{ToolError{Tool: "Bash", Output: "error: " + strings.Repeat("x", 200)}, "Bash failed: error: " + strings.Repeat("x", maxToolErrorChars-len("error: ")) + "…"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Summary(); got != tt.want {
+			t.Errorf("Summary(%+v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
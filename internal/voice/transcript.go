@@ -2,14 +2,15 @@ package voice
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
+	"time"
 
+	"github.com/daikw/ccpersona/internal/projectpath"
 	"github.com/rs/zerolog/log"
 )
 
@@ -25,8 +26,17 @@ func NewTranscriptReader(config *Config) *TranscriptReader {
 	}
 }
 
-// FindLatestTranscript finds the most recent transcript file
+// FindLatestTranscript finds the most recent transcript of the current
+// directory's project by mapping it to its Claude Code transcript directory,
+// so only that directory is read. When the project has no transcripts it
+// falls back to the newest transcript anywhere under ~/.claude/projects.
 func (tr *TranscriptReader) FindLatestTranscript() (string, error) {
+	if cwd, err := os.Getwd(); err == nil {
+		if path, err := tr.FindProjectTranscript(cwd); err == nil {
+			return path, nil
+		}
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get home directory: %w", err)
@@ -34,13 +44,21 @@ func (tr *TranscriptReader) FindLatestTranscript() (string, error) {
 
 	projectsDir := filepath.Join(homeDir, ".claude", "projects")
 
-	var transcriptFiles []string
-	err = filepath.Walk(projectsDir, func(path string, info os.FileInfo, err error) error {
+	var newest string
+	var newestTime time.Time
+	err = filepath.WalkDir(projectsDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil // Skip errors
 		}
-		if !info.IsDir() && strings.HasSuffix(path, ".jsonl") {
-			transcriptFiles = append(transcriptFiles, path)
+		if d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = path, info.ModTime()
 		}
 		return nil
 	})
@@ -49,128 +67,283 @@ func (tr *TranscriptReader) FindLatestTranscript() (string, error) {
 		return "", fmt.Errorf("failed to walk projects directory: %w", err)
 	}
 
-	if len(transcriptFiles) == 0 {
+	if newest == "" {
 		return "", fmt.Errorf("no transcript files found")
 	}
 
-	// Sort by modification time (newest first)
-	sort.Slice(transcriptFiles, func(i, j int) bool {
-		infoI, errI := os.Stat(transcriptFiles[i])
-		infoJ, errJ := os.Stat(transcriptFiles[j])
-		if errI != nil || errJ != nil {
-			return false
+	log.Debug().Str("file", newest).Msg("Found latest transcript")
+	return newest, nil
+}
+
+// FindProjectTranscript finds the most recent transcript of projectDir or,
+// when Claude Code has none for it, of its nearest parent directory that has
+// one, so the command works from a subdirectory of the project.
+func (tr *TranscriptReader) FindProjectTranscript(projectDir string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	dir := filepath.Clean(projectDir)
+	for {
+		transcriptDir := filepath.Join(homeDir, ".claude", "projects", projectpath.Encode(dir))
+		if path := newestTranscript(transcriptDir); path != "" {
+			log.Debug().Str("file", path).Msg("Found project transcript")
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no transcript files found for %s", projectDir)
+		}
+		dir = parent
+	}
+}
+
+// newestTranscript returns the most recently modified .jsonl file in dir, or
+// "" when there is none.
+func newestTranscript(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var newest string
+	var newestTime time.Time
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
 		}
-		return infoI.ModTime().After(infoJ.ModTime())
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest, newestTime = filepath.Join(dir, entry.Name()), info.ModTime()
+		}
+	}
+	return newest
+}
+
+// GetRecentAssistantMessages returns the answers of the last n assistant
+// turns of the transcript, oldest first. Each answer is the text of a whole
+// turn, as in full mode.
+func (tr *TranscriptReader) GetRecentAssistantMessages(transcriptPath string, n int) ([]string, error) {
+	file, err := os.Open(transcriptPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	lines, err := tr.readLinesReverseUntil(file, func(lines []string) bool {
+		_, complete := recentTurns(lines, n)
+		return complete
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	turns, _ := recentTurns(lines, n)
+	if len(turns) == 0 {
+		return nil, fmt.Errorf("no assistant message found")
+	}
+	return turns, nil
+}
 
-	log.Debug().Str("file", transcriptFiles[0]).Msg("Found latest transcript")
-	return transcriptFiles[0], nil
+// recentTurns collects the text of up to n assistant turns from lines given
+// newest first and returns them oldest first. Turns are separated by user
+// prompts; tool results do not end a turn. Turns without spoken text are
+// skipped. complete reports that the prompt opening the oldest returned turn
+// was seen, so no fragment of it can lie further back.
+func recentTurns(lines []string, n int) (turns []string, complete bool) {
+	var current []string
+	for _, line := range lines {
+		entry, ok := parseEntry(line)
+		if !ok || entry.IsSidechain {
+			continue
+		}
+		switch {
+		case entry.isAssistant():
+			spoken := entry.spokenTexts()
+			for i := len(spoken) - 1; i >= 0; i-- {
+				current = append(current, spoken[i])
+			}
+		case entry.Type == "user" && !entry.isToolResult():
+			if len(current) == 0 {
+				continue
+			}
+			turns = append(turns, joinReversed(current))
+			current = nil
+			if len(turns) == n {
+				return reverseStrings(turns), true
+			}
+		}
+	}
+	if len(current) > 0 && len(turns) < n {
+		turns = append(turns, joinReversed(current))
+	}
+	return reverseStrings(turns), false
+}
+
+// joinReversed joins text blocks collected newest first as paragraphs in
+// reading order.
+func joinReversed(texts []string) string {
+	return strings.Join(reverseStrings(texts), "\n\n")
+}
+
+func reverseStrings(s []string) []string {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+	return s
 }
 
 // GetLatestAssistantMessage extracts the latest assistant message from transcript
 func (tr *TranscriptReader) GetLatestAssistantMessage(transcriptPath string) (string, error) {
+	text, _, err := tr.GetLatestAssistantMessageWithID(transcriptPath)
+	return text, err
+}
+
+// GetLatestAssistantMessageWithID is GetLatestAssistantMessage that also
+// returns an ID for the message: the uuid of its newest transcript line, or a
+// hash of the text when the transcript has no uuids.
+func (tr *TranscriptReader) GetLatestAssistantMessageWithID(transcriptPath string) (string, string, error) {
 	file, err := os.Open(transcriptPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to open transcript: %w", err)
+		return "", "", fmt.Errorf("failed to open transcript: %w", err)
 	}
 	defer func() {
 		_ = file.Close()
 	}()
 
+	var text, id string
+	if tr.readsTurn() {
+		text, id, err = tr.getMessageWithUUID(file)
+	} else {
+		text, id, err = tr.getMessageSimple(file)
+	}
+	if err == nil && id == "" {
+		id = hashText(text)
+	}
+	return text, id, err
+}
+
+// readsTurn reports whether the whole final assistant turn is read. Short
+// mode speaks one line, so the newest text block is enough; full and adaptive
+// modes read the entire answer.
+func (tr *TranscriptReader) readsTurn() bool {
 	if tr.config.UUIDMode {
-		return tr.getMessageWithUUID(file)
+		return true
 	}
-	return tr.getMessageSimple(file)
+	mode := NormalizeReadingMode(tr.config.ReadingMode)
+	return mode == ModeFull || mode == ModeAdaptive
 }
 
 // getMessageSimple extracts the first text from the latest assistant message (fast mode)
-func (tr *TranscriptReader) getMessageSimple(file *os.File) (string, error) {
+func (tr *TranscriptReader) getMessageSimple(file *os.File) (string, string, error) {
 	// Read file in reverse to find the latest assistant message
 	lines, err := tr.readLinesReverse(file)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	for _, line := range lines {
-		var msg TranscriptMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		entry, ok := parseEntry(line)
+		if !ok || !entry.isAssistant() {
 			continue
 		}
-
-		if msg.Type == "assistant" && msg.Message.Role == "assistant" {
-			// Find first text content
-			for _, content := range msg.Message.Content {
-				if content.Type == "text" && strings.TrimSpace(content.Text) != "" {
-					log.Debug().Str("text_length", fmt.Sprintf("%d", len(content.Text))).Msg("Found assistant message")
-					return content.Text, nil
-				}
-			}
+		if texts := entry.spokenTexts(); len(texts) > 0 {
+			log.Debug().Str("text_length", fmt.Sprintf("%d", len(texts[0]))).Msg("Found assistant message")
+			return texts[0], entry.UUID, nil
 		}
 	}
 
-	return "", fmt.Errorf("no assistant message found")
+	return "", "", fmt.Errorf("no assistant message found")
 }
 
-// getMessageWithUUID extracts all text from messages with the same UUID (complete mode)
-func (tr *TranscriptReader) getMessageWithUUID(file *os.File) (string, error) {
+// getMessageWithUUID extracts all text of the final assistant turn (complete
+// mode)
+func (tr *TranscriptReader) getMessageWithUUID(file *os.File) (string, string, error) {
 	lines, err := tr.readLinesReverse(file)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	// Find the latest assistant UUID
-	var latestUUID string
-	for _, line := range lines {
-		var msg TranscriptMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			continue
-		}
+	texts, keys, _ := finalTurn(lines)
+	if len(texts) == 0 {
+		return "", "", fmt.Errorf("no assistant message found")
+	}
 
-		if msg.Type == "assistant" && msg.UUID != "" {
-			latestUUID = msg.UUID
+	// The turn ends with the newest assistant line.
+	var id string
+	for _, line := range lines {
+		if entry, ok := parseEntry(line); ok && entry.isAssistant() && entry.messageKey() != "" {
+			id = entry.UUID
 			break
 		}
 	}
 
-	if latestUUID == "" {
-		return "", fmt.Errorf("no assistant message found")
-	}
+	result := strings.Join(texts, " ")
+	log.Debug().
+		Int("message_count", keys).
+		Int("text_count", len(texts)).
+		Int("total_length", len(result)).
+		Msg("Found assistant turn")
+
+	return result, id, nil
+}
 
-	// Collect all text from messages with this UUID
-	var texts []string
+// finalTurn collects the text of the final assistant turn from lines given
+// newest first, returning it in reading order with the number of model
+// responses it spans. The turn is the newest response (every line sharing its
+// message key) plus the responses chained to it through tool calls: a
+// response followed by a tool result belongs to the turn that continues
+// after it. A prompt, or an older response not followed by a tool result,
+// ends the chain.
+//
+// complete reports that the lines reach past the start of the turn: an
+// assistant response outside the turn was seen after the chain ended, so no
+// fragment of the turn's responses can lie further back.
+func finalTurn(lines []string) (texts []string, keys int, complete bool) {
+	included := map[string]bool{}
+	chained, ended := false, false
 	for _, line := range lines {
-		var msg TranscriptMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		entry, ok := parseEntry(line)
+		if !ok || entry.IsSidechain {
 			continue
 		}
-
-		if msg.UUID == latestUUID && msg.Message.Role == "assistant" {
-			for _, content := range msg.Message.Content {
-				if content.Type == "text" && content.Text != "" {
-					texts = append(texts, content.Text)
+		switch {
+		case entry.isAssistant():
+			key := entry.messageKey()
+			if key == "" {
+				continue
+			}
+			if !included[key] {
+				if len(included) > 0 && (ended || !chained) {
+					complete, ended = true, true
+					continue
 				}
+				included[key] = true
+				chained = false
+			}
+			// Lines are newest first; add the entry's blocks in reverse so the
+			// final reversal restores their order.
+			spoken := entry.spokenTexts()
+			for i := len(spoken) - 1; i >= 0; i-- {
+				texts = append(texts, spoken[i])
+			}
+		case entry.isToolResult():
+			if len(included) > 0 && !ended {
+				chained = true
+			}
+		case entry.Type == "user":
+			if len(included) > 0 {
+				ended = true
 			}
 		}
 	}
 
-	if len(texts) == 0 {
-		return "", fmt.Errorf("no assistant message found")
-	}
-
-	// Reverse to get original order
-	for i := len(texts)/2 - 1; i >= 0; i-- {
-		opp := len(texts) - 1 - i
-		texts[i], texts[opp] = texts[opp], texts[i]
-	}
-
-	result := strings.Join(texts, " ")
-	log.Debug().
-		Str("uuid", latestUUID).
-		Int("text_count", len(texts)).
-		Int("total_length", len(result)).
-		Msg("Found assistant message with UUID")
-
-	return result, nil
+	return reverseStrings(texts), len(included), complete
 }
 
 // tailWindowSize is the chunk of the transcript tail read per pass. Transcript
@@ -186,15 +359,16 @@ const tailWindowSize = 1024 * 1024 // 1MB
 // The stop condition differs per mode because of what each consumer needs:
 //   - simple mode reads only the newest assistant text, so one usable assistant
 //     text block in the window is enough.
-//   - UUID mode joins every fragment of the newest assistant message, which
-//     spans multiple JSONL lines. A window holding only one assistant UUID may
-//     have cut off earlier fragments of that same message, so we require two
-//     distinct assistant UUIDs: seeing an older message's UUID proves all
-//     fragments of the newer one lie after it, i.e. fully inside the window.
+//   - turn mode (UUID mode, full, adaptive) joins every fragment of the final
+//     assistant turn, which spans multiple JSONL lines and, with tool calls,
+//     several responses. A window holding only part of the turn may have cut
+//     off earlier fragments, so we require the turn to have ended and an older
+//     response outside it to be visible: that response proves all fragments
+//     of the turn lie after it, i.e. fully inside the window.
 func (tr *TranscriptReader) readLinesReverse(file *os.File) ([]string, error) {
 	stop := containsAssistantTextLine
-	if tr.config.UUIDMode {
-		stop = containsTwoAssistantUUIDs
+	if tr.readsTurn() {
+		stop = containsCompleteTurn
 	}
 	return tr.readLinesReverseUntil(file, stop)
 }
@@ -293,46 +467,24 @@ func fileSize(file *os.File) (int64, error) {
 	return info.Size(), nil
 }
 
-// containsAssistantTextLine reports whether any line parses as an assistant
-// message containing non-empty text, matching getMessageSimple's usable input.
+// containsAssistantTextLine reports whether any line is an assistant message
+// with spoken text, matching getMessageSimple's usable input.
 func containsAssistantTextLine(lines []string) bool {
 	for _, line := range lines {
-		var msg TranscriptMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			continue
-		}
-		if msg.Type != "assistant" || msg.Message.Role != "assistant" {
-			continue
-		}
-		for _, content := range msg.Message.Content {
-			if content.Type == "text" && strings.TrimSpace(content.Text) != "" {
-				return true
-			}
+		entry, ok := parseEntry(line)
+		if ok && entry.isAssistant() && len(entry.spokenTexts()) > 0 {
+			return true
 		}
 	}
 	return false
 }
 
-// containsTwoAssistantUUIDs reports whether the lines hold assistant messages
-// with at least two distinct non-empty UUIDs. See readLinesReverse for why
-// this guarantees the newest message's fragments are complete in the window.
-func containsTwoAssistantUUIDs(lines []string) bool {
-	var first string
-	for _, line := range lines {
-		var msg TranscriptMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			continue
-		}
-		if msg.Type != "assistant" || msg.UUID == "" {
-			continue
-		}
-		if first == "" {
-			first = msg.UUID
-		} else if msg.UUID != first {
-			return true
-		}
-	}
-	return false
+// containsCompleteTurn reports whether the lines hold the whole final
+// assistant turn. See readLinesReverse for why this requires a response from
+// before the turn.
+func containsCompleteTurn(lines []string) bool {
+	_, _, complete := finalTurn(lines)
+	return complete
 }
 
 // ProcessText applies reading mode restrictions to the text
@@ -347,8 +499,16 @@ func (tr *TranscriptReader) ProcessText(text string) string {
 		if idx := strings.Index(text, "\n"); idx != -1 {
 			text = strings.TrimSpace(text[:idx])
 		}
+		text = ApplyAccessibility(text, tr.config.Accessibility)
 
-	case ModeFull:
+	case ModeFull, ModeAdaptive:
+		if normalizedMode == ModeAdaptive {
+			var strategy string
+			text, strategy = tr.config.Adaptive.ReadAdaptive(text)
+			log.Debug().Str("strategy", strategy).Msg("Adaptive reading")
+		}
+		// Structural cues need the original line breaks, so apply them first.
+		text = ApplyAccessibility(text, tr.config.Accessibility)
 		// Full text with newlines replaced by spaces (formerly full_text/char_limit)
 		text = strings.ReplaceAll(text, "\n", " ")
 		// Apply character limit if specified
@@ -0,0 +1,161 @@
+package voice
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// transcriptEntry is the part of a transcript line the reader inspects. Unlike
+// TranscriptMessage it keeps the sidechain and model fields and also accepts
+// string content.
+type transcriptEntry struct {
+	Type        string `json:"type"`
+	UUID        string `json:"uuid"`
+	IsSidechain bool   `json:"isSidechain"`
+	Message     struct {
+		ID      string        `json:"id"`
+		Role    string        `json:"role"`
+		Model   string        `json:"model"`
+		Content contentBlocks `json:"content"`
+	} `json:"message"`
+}
+
+type contentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	// ID and Name identify a tool_use block; ToolUseID, IsError, and
+	// Content belong to the tool_result block answering it. Content is kept
+	// raw so an unexpected shape cannot hide the rest of the entry.
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	ToolUseID string          `json:"tool_use_id"`
+	IsError   bool            `json:"is_error"`
+	Content   json.RawMessage `json:"content"`
+}
+
+// contentBlocks decodes message content given as a string or as blocks.
+type contentBlocks []contentBlock
+
+func (c *contentBlocks) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		*c = contentBlocks{{Type: "text", Text: s}}
+		return nil
+	}
+	var blocks []contentBlock
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return err
+	}
+	*c = blocks
+	return nil
+}
+
+func parseEntry(line string) (transcriptEntry, bool) {
+	var e transcriptEntry
+	if err := json.Unmarshal([]byte(line), &e); err != nil {
+		return e, false
+	}
+	return e, true
+}
+
+// isAssistant reports whether the entry is a main-thread reply written by the
+// model. Subagent (sidechain) replies and Claude Code's synthetic messages are
+// not the user-facing answer.
+func (e *transcriptEntry) isAssistant() bool {
+	return e.Type == "assistant" && e.Message.Role == "assistant" &&
+		!e.IsSidechain && e.Message.Model != "<synthetic>"
+}
+
+// messageKey identifies the model response the entry belongs to. Claude Code
+// writes each streamed content block of a response as its own line with its
+// own uuid, all sharing the response's message.id.
+func (e *transcriptEntry) messageKey() string {
+	if e.Message.ID != "" {
+		return e.Message.ID
+	}
+	return e.UUID
+}
+
+// isToolResult reports whether the entry returns tool output to the model,
+// which Claude Code records as a user message.
+func (e *transcriptEntry) isToolResult() bool {
+	if e.Type != "user" {
+		return false
+	}
+	for _, block := range e.Message.Content {
+		if block.Type == "tool_result" {
+			return true
+		}
+	}
+	return false
+}
+
+// spokenTexts returns the user-facing text blocks of the entry. Thinking,
+// tool_use, and other non-text blocks are skipped, and tagged reasoning, tool
+// output echoes, and plan preambles are removed from the text.
+func (e *transcriptEntry) spokenTexts() []string {
+	var texts []string
+	for _, block := range e.Message.Content {
+		if block.Type != "text" {
+			continue
+		}
+		if text := stripUnspoken(block.Text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+// taggedSection matches reasoning some models write inline instead of in
+// thinking blocks, and tool output echoed back into the reply.
+var taggedSection = regexp.MustCompile(`(?s)<(?:thinking|reasoning|plan|tool_result|function_results)>.*?</(?:thinking|reasoning|plan|tool_result|function_results)>`)
+
+// unclosedSection matches such a tag left open to the end of the text, as in
+// a reply cut off mid-thought.
+var unclosedSection = regexp.MustCompile(`(?s)<(?:thinking|reasoning|plan|tool_result|function_results)>.*$`)
+
+// planHeading matches a line that opens a plan preamble, such as "## Plan"
+// or "**計画:**".
+var planHeading = regexp.MustCompile(`(?i)^(?:#+\s*|\*\*)?(?:plan|my plan|implementation plan|計画|方針)(?:\*\*)?\s*[:：]?(?:\*\*)?$`)
+
+var listItem = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s`)
+
+// stripUnspoken removes tagged sections and a leading plan section from
+// text.
+func stripUnspoken(text string) string {
+	text = taggedSection.ReplaceAllString(text, "")
+	text = unclosedSection.ReplaceAllString(text, "")
+	return stripPlanPreamble(strings.TrimSpace(text))
+}
+
+// stripPlanPreamble drops a plan section at the start of text: the plan
+// heading and its list, up to the next heading or the first paragraph that is
+// not a list.
+func stripPlanPreamble(text string) string {
+	lines := strings.Split(text, "\n")
+	if !planHeading.MatchString(strings.TrimSpace(lines[0])) {
+		return text
+	}
+	i := 1
+	for i < len(lines) {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		if trimmed == "" {
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next < len(lines) && !listItem.MatchString(strings.TrimSpace(lines[next])) {
+				i = next
+				break
+			}
+			i = next
+			continue
+		}
+		i++
+	}
+	return strings.TrimSpace(strings.Join(lines[i:], "\n"))
+}
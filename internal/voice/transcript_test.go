@@ -6,6 +6,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/projectpath"
 )
 
 // TestReadLinesReverseLongLine tests reading files with very long lines
@@ -527,6 +530,33 @@ func TestProcessTextModes(t *testing.T) {
 				MaxChars:    16,
 			},
 		},
+		{
+			name:     "Full mode with accessibility cues",
+			input:    "Steps:\n- build\n- test",
+			expected: "Steps: List of two items. Item one: build Item two: test End of list.",
+			config: &Config{
+				ReadingMode:   ModeFull,
+				Accessibility: &AccessibilityOptions{Enabled: true},
+			},
+		},
+		{
+			name:     "Adaptive mode reads the first paragraph of a medium message",
+			input:    "Fixed the parser.\nIt handles tabs now.\n\nDetails follow about the tokenizer changes.",
+			expected: "Fixed the parser. It handles tabs now.",
+			config: &Config{
+				ReadingMode: ModeAdaptive,
+				Adaptive:    &AdaptiveOptions{ShortChars: 20},
+			},
+		},
+		{
+			name:     "Short mode spells identifiers",
+			input:    "Spell `a_B` please\nmore",
+			expected: "Spell a_B, spelled a, underscore, capital B, please",
+			config: &Config{
+				ReadingMode:   ModeShort,
+				Accessibility: &AccessibilityOptions{Enabled: true},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -807,3 +837,294 @@ func TestReadLinesWithTruncation(t *testing.T) {
 		t.Error("Expected to find at least one valid JSON line after truncation")
 	}
 }
+
+// TestGetLatestAssistantMessageSkipsUnspoken verifies that thinking, plan
+// preambles, tool output, and non-main-thread replies are not read aloud.
+func TestGetLatestAssistantMessageSkipsUnspoken(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{
+			name: "thinking block",
+			lines: []string{
+				`{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"thinking","thinking":"Let me check."},{"type":"text","text":"Done."}]}}`,
+			},
+			want: "Done.",
+		},
+		{
+			name: "inline thinking tags",
+			lines: []string{
+				`{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"<thinking>The user wants a fix.</thinking>\nFixed the bug."}]}}`,
+			},
+			want: "Fixed the bug.",
+		},
+		{
+			name: "echoed tool output",
+			lines: []string{
+				`{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"<tool_result>ok 12 tests</tool_result>All tests pass."}]}}`,
+			},
+			want: "All tests pass.",
+		},
+		{
+			name: "plan preamble",
+			lines: []string{
+				`{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"## Plan\n1. Read the config\n2. Patch the loader\n\n- Add a test\n\nThe loader now reads both files."}]}}`,
+			},
+			want: "The loader now reads both files.",
+		},
+		{
+			name: "plan only block",
+			lines: []string{
+				`{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"Earlier answer."}]}}`,
+				`{"type":"assistant","uuid":"a2","message":{"role":"assistant","content":[{"type":"text","text":"**計画:**\n- 設定を読む"},{"type":"tool_use","name":"Read"}]}}`,
+			},
+			want: "Earlier answer.",
+		},
+		{
+			name: "tool result and sidechain lines",
+			lines: []string{
+				`{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"Main answer."}]}}`,
+				`{"type":"user","uuid":"u1","message":{"role":"user","content":[{"type":"tool_result","content":"file contents"}]}}`,
+				`{"type":"assistant","uuid":"s1","isSidechain":true,"message":{"role":"assistant","content":[{"type":"text","text":"Subagent report."}]}}`,
+			},
+			want: "Main answer.",
+		},
+		{
+			name: "synthetic message",
+			lines: []string{
+				`{"type":"assistant","uuid":"a1","message":{"role":"assistant","content":[{"type":"text","text":"Real answer."}]}}`,
+				`{"type":"user","uuid":"u1","message":{"role":"user","content":"continue"}}`,
+				`{"type":"assistant","uuid":"a2","message":{"role":"assistant","model":"<synthetic>","content":[{"type":"text","text":"No response requested."}]}}`,
+			},
+			want: "Real answer.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "transcript.jsonl")
+			if err := os.WriteFile(path, []byte(strings.Join(tt.lines, "\n")+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for _, uuidMode := range []bool{false, true} {
+				config := DefaultConfig()
+				config.UUIDMode = uuidMode
+				got, err := NewTranscriptReader(config).GetLatestAssistantMessage(path)
+				if uuidMode && tt.name == "plan only block" {
+					// UUID mode reads only the newest message, which is all plan.
+					if err == nil {
+						t.Errorf("UUID mode = %q, want no message", got)
+					}
+					continue
+				}
+				if err != nil || got != tt.want {
+					t.Errorf("GetLatestAssistantMessage(uuid=%v) = %q, %v, want %q", uuidMode, got, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStripUnspoken(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Plain answer.", "Plain answer."},
+		{"Before <reasoning>hidden</reasoning>after.", "Before after."},
+		{"Answer.\n<thinking>cut off mid", "Answer."},
+		{"Plan:\n- step one\n- step two", ""},
+		{"## Implementation plan\n- a\n## Result\nWorks.", "## Result\nWorks."},
+		{"The plan worked.", "The plan worked."},
+	}
+	for _, tt := range tests {
+		if got := stripUnspoken(tt.input); got != tt.want {
+			t.Errorf("stripUnspoken(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestGetMessageWithUUIDFinalTurn verifies UUID mode reads every response of
+// the final turn: streamed blocks sharing a message.id and responses chained
+// through tool calls, but nothing before the turn's prompt.
+func TestGetMessageWithUUIDFinalTurn(t *testing.T) {
+	lines := []string{
+		`{"type":"user","uuid":"u0","message":{"role":"user","content":"first task"}}`,
+		`{"type":"assistant","uuid":"l1","message":{"id":"msg_old","role":"assistant","content":[{"type":"text","text":"Previous turn."}]}}`,
+		`{"type":"user","uuid":"u1","message":{"role":"user","content":"fix the build"}}`,
+		`{"type":"assistant","uuid":"l2","message":{"id":"msg_a","role":"assistant","content":[{"type":"text","text":"Checking the logs."}]}}`,
+		`{"type":"assistant","uuid":"l3","message":{"id":"msg_a","role":"assistant","content":[{"type":"tool_use","name":"Bash"}]}}`,
+		`{"type":"user","uuid":"u2","message":{"role":"user","content":[{"type":"tool_result","content":"` + strings.Repeat("z", tailWindowSize+512*1024) + `"}]}}`,
+		`{"type":"assistant","uuid":"l4","message":{"id":"msg_b","role":"assistant","content":[{"type":"tool_use","name":"Edit"}]}}`,
+		`{"type":"user","uuid":"u3","message":{"role":"user","content":[{"type":"tool_result","content":"ok"}]}}`,
+		`{"type":"assistant","uuid":"l5","message":{"id":"msg_c","role":"assistant","content":[{"type":"text","text":"The lockfile was stale."}]}}`,
+		`{"type":"assistant","uuid":"l6","message":{"id":"msg_c","role":"assistant","content":[{"type":"text","text":"I regenerated it and the build passes."}]}}`,
+	}
+	path := filepath.Join(t.TempDir(), "turn.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	config.UUIDMode = true
+	got, err := NewTranscriptReader(config).GetLatestAssistantMessage(path)
+	want := "Checking the logs. The lockfile was stale. I regenerated it and the build passes."
+	if err != nil || got != want {
+		t.Errorf("GetLatestAssistantMessage = %q, %v, want %q", got, err, want)
+	}
+
+	// Full mode reads the whole turn too.
+	config = DefaultConfig()
+	config.ReadingMode = ModeFull
+	if got, err := NewTranscriptReader(config).GetLatestAssistantMessage(path); err != nil || got != want {
+		t.Errorf("full mode = %q, %v, want %q", got, err, want)
+	}
+
+	// Short mode still reads only the newest text block.
+	got, err = NewTranscriptReader(DefaultConfig()).GetLatestAssistantMessage(path)
+	if err != nil || got != "I regenerated it and the build passes." {
+		t.Errorf("simple mode = %q, %v", got, err)
+	}
+}
+
+func TestFinalTurn(t *testing.T) {
+	// Newest first, as readLinesReverse returns them.
+	lines := []string{
+		`{"type":"assistant","uuid":"l3","message":{"id":"msg_b","role":"assistant","content":[{"type":"text","text":"Done."}]}}`,
+		`{"type":"user","uuid":"u2","message":{"role":"user","content":[{"type":"tool_result","content":"ok"}]}}`,
+		`{"type":"assistant","uuid":"s1","isSidechain":true,"message":{"id":"msg_s","role":"assistant","content":[{"type":"text","text":"Subagent."}]}}`,
+		`{"type":"assistant","uuid":"l2","message":{"id":"msg_a","role":"assistant","content":[{"type":"text","text":"One."},{"type":"text","text":"Two."},{"type":"tool_use","name":"Task"}]}}`,
+	}
+	texts, keys, complete := finalTurn(lines)
+	if got := strings.Join(texts, " "); got != "One. Two. Done." || keys != 2 || complete {
+		t.Errorf("finalTurn = %q, %d, %v", got, keys, complete)
+	}
+
+	lines = append(lines,
+		`{"type":"user","uuid":"u1","message":{"role":"user","content":"go"}}`,
+		`{"type":"assistant","uuid":"l1","message":{"id":"msg_old","role":"assistant","content":[{"type":"text","text":"Old."}]}}`,
+	)
+	if texts, _, complete := finalTurn(lines); strings.Join(texts, " ") != "One. Two. Done." || !complete {
+		t.Errorf("finalTurn with prompt = %q, %v", texts, complete)
+	}
+}
+
+func TestGetRecentAssistantMessages(t *testing.T) {
+	lines := []string{
+		`{"type":"user","uuid":"u0","message":{"role":"user","content":"first task"}}`,
+		`{"type":"assistant","uuid":"l1","message":{"id":"msg_1","role":"assistant","content":[{"type":"text","text":"First answer."}]}}`,
+		`{"type":"user","uuid":"u1","message":{"role":"user","content":"second task"}}`,
+		`{"type":"assistant","uuid":"l2","message":{"id":"msg_2","role":"assistant","content":[{"type":"text","text":"Looking."},{"type":"tool_use","name":"Bash"}]}}`,
+		`{"type":"user","uuid":"u2","message":{"role":"user","content":[{"type":"tool_result","content":"ok"}]}}`,
+		`{"type":"assistant","uuid":"l3","message":{"id":"msg_3","role":"assistant","content":[{"type":"text","text":"Second answer."}]}}`,
+		`{"type":"user","uuid":"u3","message":{"role":"user","content":"third task"}}`,
+		`{"type":"assistant","uuid":"l4","message":{"id":"msg_4","role":"assistant","content":[{"type":"tool_use","name":"Edit"}]}}`,
+		`{"type":"user","uuid":"u4","message":{"role":"user","content":"fourth task"}}`,
+		`{"type":"assistant","uuid":"l5","message":{"id":"msg_5","role":"assistant","content":[{"type":"text","text":"Fourth answer."}]}}`,
+	}
+	path := filepath.Join(t.TempDir(), "recent.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reader := NewTranscriptReader(DefaultConfig())
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{1, []string{"Fourth answer."}},
+		// The third turn has no text and is skipped.
+		{2, []string{"Looking.\n\nSecond answer.", "Fourth answer."}},
+		{5, []string{"First answer.", "Looking.\n\nSecond answer.", "Fourth answer."}},
+	}
+	for _, tt := range tests {
+		got, err := reader.GetRecentAssistantMessages(path, tt.n)
+		if err != nil || strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("GetRecentAssistantMessages(%d) = %q, %v, want %q", tt.n, got, err, tt.want)
+		}
+	}
+}
+
+func TestFindProjectTranscript(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	project := filepath.Join(home, "work", "app")
+	sub := filepath.Join(project, "src")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(home, ".claude", "projects", projectpath.Encode(project))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	older, newer := filepath.Join(dir, "a.jsonl"), filepath.Join(dir, "b.jsonl")
+	for _, p := range []string{older, newer} {
+		if err := os.WriteFile(p, []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(older, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := NewTranscriptReader(DefaultConfig())
+	for _, start := range []string{project, sub} {
+		if got, err := reader.FindProjectTranscript(start); err != nil || got != newer {
+			t.Errorf("FindProjectTranscript(%s) = %q, %v, want %q", start, got, err, newer)
+		}
+	}
+	if _, err := reader.FindProjectTranscript(filepath.Join(home, "elsewhere")); err == nil {
+		t.Error("expected an error for a project without transcripts")
+	}
+}
+
+func TestFindLatestTranscript(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	project := filepath.Join(home, "work", "app")
+	elsewhere := filepath.Join(home, "elsewhere")
+	for _, d := range []string{project, elsewhere} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(dir, name string, age time.Duration) string {
+		t.Helper()
+		transcriptDir := filepath.Join(home, ".claude", "projects", projectpath.Encode(dir))
+		if err := os.MkdirAll(transcriptDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(transcriptDir, name)
+		if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	own := write(project, "own.jsonl", time.Hour)
+	newest := write(filepath.Join(home, "other"), "other.jsonl", time.Minute)
+
+	reader := NewTranscriptReader(DefaultConfig())
+
+	// The current project's transcript wins over a newer one elsewhere.
+	t.Chdir(project)
+	if got, err := reader.FindLatestTranscript(); err != nil || got != own {
+		t.Errorf("FindLatestTranscript() in project = %q, %v, want %q", got, err, own)
+	}
+
+	// Without transcripts for the current directory, the newest overall is used.
+	t.Chdir(elsewhere)
+	if got, err := reader.FindLatestTranscript(); err != nil || got != newest {
+		t.Errorf("FindLatestTranscript() elsewhere = %q, %v, want %q", got, err, newest)
+	}
+}
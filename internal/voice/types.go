@@ -3,18 +3,44 @@ package voice
 // Config represents voice synthesis configuration
 type Config struct {
 	// Engine settings
-	EnginePriority     string  `json:"engine_priority"`     // "voicevox" or "aivisspeech"
-	VoicevoxSpeaker    int     `json:"voicevox_speaker"`    // VOICEVOX speaker ID
-	AivisSpeechSpeaker int64   `json:"aivisspeech_speaker"` // AivisSpeech speaker ID
-	VolumeScale        float64 `json:"volume_scale"`        // Volume scale (0.0-2.0, default 1.0)
-	SpeedScale         float64 `json:"speed_scale"`         // Speed scale (0.5-2.0, default 1.0)
+	EnginePriority     string        `json:"engine_priority"`         // "voicevox" or "aivisspeech"
+	VoicevoxSpeaker    int           `json:"voicevox_speaker"`        // VOICEVOX speaker ID
+	AivisSpeechSpeaker int64         `json:"aivisspeech_speaker"`     // AivisSpeech speaker ID
+	VolumeScale        float64       `json:"volume_scale"`            // Volume scale (0.0-2.0, default 1.0)
+	SpeedScale         float64       `json:"speed_scale"`             // Speed scale (0.5-2.0, default 1.0)
+	Prosody            *Prosody      `json:"prosody,omitempty"`       // Intonation, pitch, and silence (nil = engine defaults)
+	SpeakerStyle       *SpeakerStyle `json:"speaker_style,omitempty"` // Style and emotional intensity (nil = configured speaker)
 
 	// Reading settings
-	ReadingMode string `json:"reading_mode"` // short (first line) or full (entire text)
-	MaxChars    int    `json:"max_chars"`    // Character limit for 'full' mode (0 = unlimited)
+	ReadingMode string           `json:"reading_mode"` // short (first line), full (entire text), or adaptive
+	MaxChars    int              `json:"max_chars"`    // Character limit for 'full' and 'adaptive' modes (0 = unlimited)
+	Adaptive    *AdaptiveOptions `json:"adaptive,omitempty"`
 
 	// Processing settings
 	UUIDMode bool `json:"uuid_mode"` // Use UUID search mode (slower but complete)
+	DiffMode bool `json:"diff_mode"` // Read only sentences not in the session's last spoken message
+
+	// Accessibility adds structural cues and identifier spelling (nil = off)
+	Accessibility *AccessibilityOptions `json:"accessibility,omitempty"`
+
+	// Japanese normalizes text for VOICEVOX and AivisSpeech (nil = off)
+	Japanese *JapaneseOptions `json:"japanese,omitempty"`
+
+	// Caption shows the text being spoken in a file or named pipe (nil = off)
+	Caption *CaptionConfig `json:"caption,omitempty"`
+	// Avatar sends speech events with lip-sync timing to the avatar bridge (nil = off)
+	Avatar *AvatarConfig `json:"avatar,omitempty"`
+	// Output plays speech on a chosen audio device (nil = default device)
+	Output *OutputConfig `json:"output,omitempty"`
+	// Recent also saves speech to the recent audio directory (nil = off)
+	Recent *RecentConfig `json:"recent,omitempty"`
+	// Cache keeps cloud audio of short messages (nil = on with defaults)
+	Cache *CacheConfig `json:"cache,omitempty"`
+	// RetryLadder shortens text a provider rejected, one step per retry
+	// (nil = DefaultRetryLadder, empty = no retries)
+	RetryLadder []string `json:"retry_ladder"`
+	// Persona is the active persona's name, for captions and avatar events
+	Persona string `json:"-"`
 }
 
 // DefaultConfig returns the default voice configuration
@@ -57,8 +83,9 @@ type AudioQuery struct {
 // ReadingMode constants
 // Primary modes (recommended):
 const (
-	ModeShort = "short" // Read first line only
-	ModeFull  = "full"  // Read full text (with optional char limit)
+	ModeShort    = "short"    // Read first line only
+	ModeFull     = "full"     // Read full text (with optional char limit)
+	ModeAdaptive = "adaptive" // Choose full, first paragraph, or summary by length
 )
 
 // Legacy mode aliases for backward compatibility:
@@ -77,6 +104,8 @@ func NormalizeReadingMode(mode string) string {
 		return ModeShort
 	case ModeFullText, ModeFull, ModeLineLimit, ModeAfterFirst, ModeCharLimit:
 		return ModeFull
+	case ModeAdaptive:
+		return ModeAdaptive
 	default:
 		return ModeShort // Default
 	}
@@ -0,0 +1,280 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultSubject is the VAPID contact sent when none is configured.
+const DefaultSubject = "https://github.com/daikw/ccpersona"
+
+// DefaultTTL is how long push services keep a message for an offline
+// browser when no TTL is configured.
+const DefaultTTL = time.Hour
+
+// sendTimeout bounds each request to a push service, so an unreachable one
+// never stalls a hook for long.
+const sendTimeout = 5 * time.Second
+
+// maxPayload keeps the encrypted message within the 4096 bytes every push
+// service accepts: the plaintext plus the 86-byte header, the padding
+// delimiter, and the 16-byte tag.
+const maxPayload = 3993
+
+// recordSize is the aes128gcm record size announced in the header. A
+// payload always fits one record.
+const recordSize = 4096
+
+// ErrGone means the push service no longer knows the subscription, because
+// the browser unsubscribed or the subscription expired.
+var ErrGone = errors.New("subscription is gone")
+
+// Message is the payload the subscription page's service worker shows as a
+// notification.
+type Message struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Urgency is the ccpersona urgency; critical notifications stay until
+	// dismissed.
+	Urgency string `json:"urgency,omitempty"`
+	// Tag makes a newer notification replace an older one with the same tag.
+	Tag string `json:"tag,omitempty"`
+}
+
+// Payload encodes m, shortening the body until it fits a push message.
+func (m Message) Payload() ([]byte, error) {
+	for {
+		data, err := json.Marshal(m)
+		if err != nil || len(data) <= maxPayload {
+			return data, err
+		}
+		n := utf8.RuneCountInString(m.Body)
+		if n == 0 {
+			return nil, errors.New("push message is too large")
+		}
+		m.Body = string([]rune(m.Body)[:n*3/4]) + "…"
+	}
+}
+
+// Options are the per-message settings sent to the push service.
+type Options struct {
+	// Subject is the VAPID contact, a mailto: or https: URL.
+	Subject string
+	TTL     time.Duration
+	// Urgency is the ccpersona urgency, mapped to the Web Push Urgency
+	// header so phones can deliver critical messages right away.
+	Urgency string
+}
+
+// Send encrypts payload for sub and posts it to the subscription's push
+// service. It returns ErrGone when the subscription should be dropped.
+func Send(ctx context.Context, client *http.Client, key *Key, sub Subscription, payload []byte, opts Options) error {
+	keys, err := sub.keys()
+	if err != nil {
+		return err
+	}
+	local, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, 16)
+	_, _ = rand.Read(salt)
+	body, err := encrypt(payload, keys, local, salt)
+	if err != nil {
+		return err
+	}
+	authorization, err := key.authorization(sub.Endpoint, opts.Subject, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	req.Header.Set("Urgency", pushUrgency(opts.Urgency))
+
+	if client == nil {
+		client = &http.Client{Timeout: sendTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrGone
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push service returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// pushUrgency maps ccpersona urgencies to the Urgency header of RFC 8030.
+func pushUrgency(urgency string) string {
+	switch urgency {
+	case "critical", "high":
+		return "high"
+	case "low":
+		return "low"
+	default:
+		return "normal"
+	}
+}
+
+// encrypt applies the aes128gcm content encoding of RFC 8291 with the
+// sender's ephemeral key local and a random salt.
+func encrypt(plaintext []byte, keys subscriptionKeys, local *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	remote, err := ecdh.P256().NewPublicKey(keys.p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription key: %w", err)
+	}
+	secret, err := local.ECDH(remote)
+	if err != nil {
+		return nil, err
+	}
+	localPublic := local.PublicKey().Bytes()
+
+	keyInfo := append([]byte("WebPush: info\x00"), keys.p256dh...)
+	keyInfo = append(keyInfo, localPublic...)
+	ikm, err := hkdf.Key(sha256.New, secret, keys.auth, string(keyInfo), 32)
+	if err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, 16+4+1+len(localPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(localPublic)))
+	header = append(header, localPublic...)
+	// 0x02 marks the last (and only) record, with no further padding.
+	padded := append(append(make([]byte, 0, len(plaintext)+1), plaintext...), 0x02)
+	return gcm.Seal(header, nonce, padded, nil), nil
+}
+
+// authorization returns the VAPID Authorization header for endpoint: an
+// ES256 JWT for the push service's origin, valid for 12 hours.
+func (k *Key) authorization(endpoint, subject string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, _ := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, k.private, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return fmt.Sprintf("vapid t=%s.%s, k=%s", unsigned, enc.EncodeToString(signature), k.PublicKey()), nil
+}
+
+// Result is the outcome of a Broadcast.
+type Result struct {
+	Sent    int
+	Removed int
+	// Errors holds the failures of subscriptions that are kept.
+	Errors []error
+}
+
+// Broadcast sends payload to every subscription and removes those the push
+// service reports gone.
+func (s *Store) Broadcast(ctx context.Context, client *http.Client, payload []byte, opts Options) (Result, error) {
+	var result Result
+	subs, err := s.Subscriptions()
+	if err != nil || len(subs) == 0 {
+		return result, err
+	}
+	key, err := s.Key()
+	if err != nil {
+		return result, err
+	}
+	var gone []string
+	for _, sub := range subs {
+		switch err := Send(ctx, client, key, sub, payload, opts); {
+		case errors.Is(err, ErrGone):
+			gone = append(gone, sub.Endpoint)
+		case err != nil:
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", endpointHost(sub.Endpoint), err))
+		default:
+			result.Sent++
+		}
+	}
+	if len(gone) > 0 {
+		if err := s.Remove(gone...); err != nil {
+			return result, err
+		}
+		result.Removed = len(gone)
+	}
+	return result, nil
+}
+
+// endpointHost names a subscription by its push service in messages; the
+// full endpoint is a capability and stays out of logs.
+func endpointHost(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "push service"
+}
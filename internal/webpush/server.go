@@ -0,0 +1,217 @@
+package webpush
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAddr is where the subscription page listens unless configured
+// otherwise.
+const DefaultAddr = "127.0.0.1:50091"
+
+// maxSubscriptionSize caps subscription bodies posted by the page.
+const maxSubscriptionSize = 16 << 10
+
+// Handler serves the subscription page, its service worker, and the
+// subscription API. Subscriptions are only accepted from the local machine,
+// which includes browsers reaching a remote server through an SSH tunnel,
+// so exposing the page does not let others receive notifications.
+func Handler(store *Store, key *Key) http.Handler {
+	return handler(store, key, true)
+}
+
+// AuthenticatedHandler is Handler for callers that authenticate requests
+// themselves, such as the team hub: subscriptions are accepted from any
+// address, though still only from the page itself. The page uses relative
+// URLs, so it can be served under a path prefix.
+func AuthenticatedHandler(store *Store, key *Key) http.Handler {
+	return handler(store, key, false)
+}
+
+func handler(store *Store, key *Key, localOnly bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, strings.Replace(pageHTML, "{{key}}", key.PublicKey(), 1))
+	})
+	mux.HandleFunc("GET /sw.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		_, _ = io.WriteString(w, serviceWorkerJS)
+	})
+	mux.HandleFunc("POST /subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		var sub Subscription
+		if !decodeLocal(w, r, &sub, localOnly) {
+			return
+		}
+		sub.Added = time.Time{}
+		sub.UserAgent = r.UserAgent()
+		if err := store.Add(sub); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		var sub Subscription
+		if !decodeLocal(w, r, &sub, localOnly) {
+			return
+		}
+		if err := store.Remove(sub.Endpoint); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+// decodeLocal decodes a JSON body sent by the page itself, from the local
+// machine when localOnly is set, writing the error response otherwise.
+// Checking the origin keeps other sites open in the same browser from adding
+// their own endpoints, and checking the Host keeps them from passing as the
+// page by pointing a DNS name of their own at this machine.
+func decodeLocal(w http.ResponseWriter, r *http.Request, v any, localOnly bool) bool {
+	if localOnly && !isLoopback(r.RemoteAddr) {
+		http.Error(w, "subscriptions are only accepted from localhost", http.StatusForbidden)
+		return false
+	}
+	if localOnly && !loopbackHost(r.Host) {
+		http.Error(w, "subscriptions are only accepted at localhost", http.StatusForbidden)
+		return false
+	}
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin requests are not accepted", http.StatusForbidden)
+		return false
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxSubscriptionSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		http.Error(w, fmt.Sprintf("invalid subscription: %v", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// sameOrigin reports whether a request comes from a page served by this
+// handler. Browsers always send Origin with fetch POST and DELETE requests.
+func sameOrigin(r *http.Request) bool {
+	origin, err := url.Parse(r.Header.Get("Origin"))
+	return err == nil && origin.Host == r.Host
+}
+
+// loopbackHost reports whether a Host header names the local machine:
+// localhost or a loopback address, with or without a port.
+func loopbackHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]")
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+const pageHTML = `<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>ccpersona push notifications</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 32rem; margin: 3rem auto; padding: 0 1rem; line-height: 1.5; }
+button { font-size: 1rem; padding: .5rem 1rem; }
+#status { color: #555; }
+</style>
+</head>
+<body>
+<h1>ccpersona push notifications</h1>
+<p>Subscribe this browser to receive notifications from ccpersona, even
+when this page is closed.</p>
+<p id="status">Checking…</p>
+<p><button id="toggle" hidden></button></p>
+<script>
+const key = "{{key}}";
+const status = document.getElementById("status");
+const toggle = document.getElementById("toggle");
+
+function keyBytes(s) {
+  const b64 = (s + "=".repeat((4 - s.length % 4) % 4)).replace(/-/g, "+").replace(/_/g, "/");
+  return Uint8Array.from(atob(b64), c => c.charCodeAt(0));
+}
+
+async function send(method, sub) {
+  const res = await fetch("subscriptions", {method, headers: {"Content-Type": "application/json"}, body: JSON.stringify(sub)});
+  if (!res.ok) throw new Error(await res.text());
+}
+
+async function render(reg) {
+  const sub = await reg.pushManager.getSubscription();
+  status.textContent = sub ? "This browser is subscribed." : "This browser is not subscribed.";
+  toggle.textContent = sub ? "Unsubscribe" : "Subscribe";
+  toggle.hidden = false;
+  toggle.onclick = async () => {
+    toggle.disabled = true;
+    try {
+      if (sub) {
+        await send("DELETE", sub);
+        await sub.unsubscribe();
+      } else {
+        if (await Notification.requestPermission() !== "granted") throw new Error("notifications are blocked for this page");
+        const created = await reg.pushManager.subscribe({userVisibleOnly: true, applicationServerKey: keyBytes(key)});
+        await send("POST", created);
+      }
+      await render(reg);
+    } catch (err) {
+      status.textContent = "Failed: " + err.message;
+    } finally {
+      toggle.disabled = false;
+    }
+  };
+}
+
+if (!("serviceWorker" in navigator) || !("PushManager" in window)) {
+  status.textContent = window.isSecureContext
+    ? "This browser does not support push notifications."
+    : "Push needs a secure context: open this page as http://localhost (for example through an SSH tunnel) or over HTTPS.";
+} else {
+  navigator.serviceWorker.register("sw.js").then(render).catch(err => { status.textContent = "Failed: " + err.message; });
+}
+</script>
+</body>
+</html>
+`
+
+const serviceWorkerJS = `self.addEventListener("push", event => {
+  let msg = {title: "ccpersona", body: ""};
+  try { msg = event.data.json(); } catch (err) { if (event.data) msg.body = event.data.text(); }
+  event.waitUntil(self.registration.showNotification(msg.title, {
+    body: msg.body,
+    tag: msg.tag || undefined,
+    renotify: Boolean(msg.tag),
+    requireInteraction: msg.urgency === "critical",
+  }));
+});
+
+self.addEventListener("notificationclick", event => event.notification.close());
+`
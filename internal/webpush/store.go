@@ -0,0 +1,273 @@
+// Package webpush sends Web Push notifications (RFC 8030) to browsers,
+// signed with a VAPID key (RFC 8292) and encrypted for each subscription
+// (RFC 8291), and serves the page browsers subscribe from.
+package webpush
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/daikw/ccpersona/internal/fsutil"
+)
+
+// Store keeps the VAPID key and the browser subscriptions in a directory.
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store backed by dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultStore returns the store at ~/.agents/ccpersona/push.
+func DefaultStore() (*Store, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return NewStore(filepath.Join(homeDir, ".agents", "ccpersona", "push")), nil
+}
+
+// Dir returns the store directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Key is the VAPID key pair that identifies this machine to push services.
+// Browsers bind their subscriptions to its public key.
+type Key struct {
+	private *ecdsa.PrivateKey
+	public  []byte
+}
+
+// PublicKey returns the uncompressed public key in unpadded base64url, the
+// form browsers take as applicationServerKey.
+func (k *Key) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(k.public)
+}
+
+type keyFile struct {
+	PrivateKey string `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+}
+
+// Key returns the VAPID key, generating it on first use. Replacing the key
+// invalidates every subscription, so an unreadable key file is an error
+// rather than a reason to start over.
+func (s *Store) Key() (*Key, error) {
+	path := filepath.Join(s.dir, "vapid.json")
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create push directory: %w", err)
+	}
+	var key *Key
+	err := fsutil.WithLock(path, func() error {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			key, err = parseKey(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return err
+		}
+		if key, err = newKey(private); err != nil {
+			return err
+		}
+		d, err := private.Bytes()
+		if err != nil {
+			return err
+		}
+		data, err = json.MarshalIndent(keyFile{
+			PrivateKey: base64.RawURLEncoding.EncodeToString(d),
+			PublicKey:  key.PublicKey(),
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		return fsutil.WriteFile(path, append(data, '\n'), 0600)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load VAPID key: %w", err)
+	}
+	return key, nil
+}
+
+func parseKey(data []byte) (*Key, error) {
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	d, err := base64.RawURLEncoding.DecodeString(file.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private_key: %w", err)
+	}
+	private, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private_key: %w", err)
+	}
+	return newKey(private)
+}
+
+func newKey(private *ecdsa.PrivateKey) (*Key, error) {
+	public, err := private.PublicKey.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return &Key{private: private, public: public}, nil
+}
+
+// Subscription is a browser's push subscription, in the JSON form of the
+// browser's PushSubscription.
+type Subscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		// P256dh is the browser's ECDH public key and Auth the shared
+		// authentication secret, both unpadded base64url.
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+	// Added and UserAgent are recorded by the store to tell browsers apart.
+	Added     time.Time `json:"added,omitzero"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+// Validate checks that the subscription can be sent to.
+func (sub Subscription) Validate() error {
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("endpoint must be an https URL")
+	}
+	if _, err := sub.keys(); err != nil {
+		return err
+	}
+	return nil
+}
+
+type subscriptionKeys struct {
+	p256dh, auth []byte
+}
+
+func (sub Subscription) keys() (subscriptionKeys, error) {
+	p256dh, err := decodeBase64URL(sub.Keys.P256dh)
+	if err != nil || len(p256dh) != 65 {
+		return subscriptionKeys{}, errors.New("keys.p256dh must be an uncompressed P-256 public key")
+	}
+	auth, err := decodeBase64URL(sub.Keys.Auth)
+	if err != nil || len(auth) != 16 {
+		return subscriptionKeys{}, errors.New("keys.auth must be 16 bytes")
+	}
+	return subscriptionKeys{p256dh: p256dh, auth: auth}, nil
+}
+
+// decodeBase64URL accepts base64url with or without padding; browsers omit
+// it, but some libraries keep it.
+func decodeBase64URL(s string) ([]byte, error) {
+	if len(s)%4 != 0 {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.URLEncoding.DecodeString(s)
+}
+
+func (s *Store) subscriptionsPath() string {
+	return filepath.Join(s.dir, "subscriptions.json")
+}
+
+// Subscriptions returns the stored subscriptions, oldest first.
+func (s *Store) Subscriptions() ([]Subscription, error) {
+	subs, err := readSubscriptions(s.subscriptionsPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read push subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// Add stores sub, replacing an earlier subscription with the same endpoint.
+func (s *Store) Add(sub Subscription) error {
+	if err := sub.Validate(); err != nil {
+		return err
+	}
+	if sub.Added.IsZero() {
+		sub.Added = time.Now().UTC()
+	}
+	return s.update(func(subs []Subscription) []Subscription {
+		return append(without(subs, sub.Endpoint), sub)
+	})
+}
+
+// Remove deletes the subscriptions with the given endpoints.
+func (s *Store) Remove(endpoints ...string) error {
+	return s.update(func(subs []Subscription) []Subscription {
+		return without(subs, endpoints...)
+	})
+}
+
+func (s *Store) update(fn func([]Subscription) []Subscription) error {
+	path := s.subscriptionsPath()
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create push directory: %w", err)
+	}
+	err := fsutil.WithLock(path, func() error {
+		subs, err := readSubscriptions(path)
+		if err != nil {
+			return err
+		}
+		subs = fn(subs)
+		if subs == nil {
+			subs = []Subscription{}
+		}
+		data, err := json.MarshalIndent(subs, "", "  ")
+		if err != nil {
+			return err
+		}
+		return fsutil.WriteFile(path, append(data, '\n'), 0600)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update push subscriptions: %w", err)
+	}
+	return nil
+}
+
+func readSubscriptions(path string) ([]Subscription, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var subs []Subscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return subs, nil
+}
+
+func without(subs []Subscription, endpoints ...string) []Subscription {
+	kept := subs[:0]
+	for _, sub := range subs {
+		drop := false
+		for _, endpoint := range endpoints {
+			drop = drop || sub.Endpoint == endpoint
+		}
+		if !drop {
+			kept = append(kept, sub)
+		}
+	}
+	return kept
+}
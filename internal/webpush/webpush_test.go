@@ -0,0 +1,360 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func b64(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// TestEncryptRFC8291 checks the example in RFC 8291, Appendix A.
+func TestEncryptRFC8291(t *testing.T) {
+	local, err := ecdh.P256().NewPrivateKey(b64(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := subscriptionKeys{
+		p256dh: b64(t, "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"),
+		auth:   b64(t, "BTBZMqHH6r4Tts7J_aSIgg"),
+	}
+	got, err := encrypt([]byte("When I grow up, I want to be a watermelon"), keys, local, b64(t, "DGv6ra1nlYgDCS1FRnbzlw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if enc := base64.RawURLEncoding.EncodeToString(got); enc != want {
+		t.Errorf("encrypt() = %s\nwant %s", enc, want)
+	}
+}
+
+// browser is the receiving side of a subscription.
+type browser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newBrowser(t *testing.T, endpoint string) (*browser, Subscription) {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &browser{key: key, auth: make([]byte, 16)}
+	_, _ = rand.Read(b.auth)
+	var sub Subscription
+	sub.Endpoint = endpoint
+	sub.Keys.P256dh = base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes())
+	sub.Keys.Auth = base64.RawURLEncoding.EncodeToString(b.auth)
+	return b, sub
+}
+
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	salt, rs, idLen := body[:16], binary.BigEndian.Uint32(body[16:20]), int(body[20])
+	if rs != recordSize || idLen != 65 {
+		t.Fatalf("header rs=%d idlen=%d", rs, idLen)
+	}
+	senderKey, err := ecdh.P256().NewPublicKey(body[21 : 21+idLen])
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := b.key.ECDH(senderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := append([]byte("WebPush: info\x00"), b.key.PublicKey().Bytes()...)
+	info = append(info, senderKey.Bytes()...)
+	ikm, _ := hkdf.Key(sha256.New, secret, b.auth, string(info), 32)
+	cek, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plain, err := gcm.Open(nil, nonce, body[21+idLen:], nil)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if plain[len(plain)-1] != 0x02 {
+		t.Fatalf("missing last-record delimiter")
+	}
+	return plain[:len(plain)-1]
+}
+
+// verifyVAPID checks the Authorization header's JWT against the key.
+func verifyVAPID(t *testing.T, header string, key *Key, audience string) {
+	t.Helper()
+	var token, k string
+	for _, part := range strings.Split(strings.TrimPrefix(header, "vapid "), ", ") {
+		switch {
+		case strings.HasPrefix(part, "t="):
+			token = part[2:]
+		case strings.HasPrefix(part, "k="):
+			k = part[2:]
+		}
+	}
+	if k != key.PublicKey() {
+		t.Errorf("k = %q, want %q", k, key.PublicKey())
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token = %q", token)
+	}
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	if err := json.Unmarshal(b64(t, parts[1]), &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Aud != audience || claims.Sub != "mailto:dev@example.com" || claims.Exp <= time.Now().Unix() {
+		t.Errorf("claims = %+v", claims)
+	}
+	public, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), b64(t, k))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := b64(t, parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(public, digest[:], r, s) {
+		t.Error("VAPID signature does not verify")
+	}
+}
+
+func TestSend(t *testing.T) {
+	store := NewStore(t.TempDir())
+	key, err := store.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *http.Request
+	var body []byte
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	b, sub := newBrowser(t, server.URL+"/push/abc")
+	opts := Options{Subject: "mailto:dev@example.com", TTL: 10 * time.Minute, Urgency: "critical"}
+	if err := Send(context.Background(), server.Client(), key, sub, []byte(`{"title":"hi"}`), opts); err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Get("Content-Encoding") != "aes128gcm" || got.Header.Get("TTL") != "600" || got.Header.Get("Urgency") != "high" {
+		t.Errorf("headers = %v", got.Header)
+	}
+	verifyVAPID(t, got.Header.Get("Authorization"), key, server.URL)
+	if plain := b.decrypt(t, body); string(plain) != `{"title":"hi"}` {
+		t.Errorf("payload = %q", plain)
+	}
+}
+
+func TestBroadcastRemovesGone(t *testing.T) {
+	store := NewStore(t.TempDir())
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/broken":
+			http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+	for _, path := range []string{"/ok", "/gone", "/broken"} {
+		_, sub := newBrowser(t, server.URL+path)
+		if err := store.Add(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := store.Broadcast(context.Background(), server.Client(), []byte("{}"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Sent != 1 || result.Removed != 1 || len(result.Errors) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if msg := result.Errors[0].Error(); !strings.Contains(msg, "429") || strings.Contains(msg, "/broken") {
+		t.Errorf("error = %q should name the status but not the endpoint", msg)
+	}
+	subs, _ := store.Subscriptions()
+	if len(subs) != 2 || subs[0].Endpoint != server.URL+"/ok" || subs[1].Endpoint != server.URL+"/broken" {
+		t.Errorf("subscriptions = %+v", subs)
+	}
+}
+
+func TestKeyPersists(t *testing.T) {
+	dir := t.TempDir()
+	first, err := NewStore(dir).Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewStore(dir).Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.PublicKey() != second.PublicKey() {
+		t.Error("key changed between loads")
+	}
+	info, err := os.Stat(filepath.Join(dir, "vapid.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode = %v", info.Mode().Perm())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "vapid.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStore(dir).Key(); err == nil {
+		t.Error("a broken key file should not be replaced")
+	}
+}
+
+func TestStoreAddReplaces(t *testing.T) {
+	store := NewStore(t.TempDir())
+	_, sub := newBrowser(t, "https://push.example.com/a")
+	if err := store.Add(sub); err != nil {
+		t.Fatal(err)
+	}
+	_, sub2 := newBrowser(t, "https://push.example.com/a")
+	if err := store.Add(sub2); err != nil {
+		t.Fatal(err)
+	}
+	subs, _ := store.Subscriptions()
+	if len(subs) != 1 || subs[0].Keys.P256dh != sub2.Keys.P256dh || subs[0].Added.IsZero() {
+		t.Errorf("subscriptions = %+v", subs)
+	}
+
+	sub.Endpoint = "http://push.example.com/a"
+	if err := store.Add(sub); err == nil {
+		t.Error("plain http endpoints should be rejected")
+	}
+	sub.Endpoint, sub.Keys.Auth = "https://push.example.com/b", "short"
+	if err := store.Add(sub); err == nil {
+		t.Error("invalid keys should be rejected")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	store := NewStore(t.TempDir())
+	key, err := store.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := Handler(store, key)
+	_, sub := newBrowser(t, "https://push.example.com/a")
+	data, _ := json.Marshal(sub)
+
+	requestAt := func(method, base, remote, origin string) int {
+		req := httptest.NewRequest(method, base+"/subscriptions", bytes.NewReader(data))
+		req.RemoteAddr = remote
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	request := func(method, remote, origin string) int {
+		return requestAt(method, "http://localhost:50091", remote, origin)
+	}
+	if code := request(http.MethodPost, "192.0.2.1:1234", "http://localhost:50091"); code != http.StatusForbidden {
+		t.Errorf("remote subscribe = %d", code)
+	}
+	if code := request(http.MethodPost, "127.0.0.1:1234", "https://evil.example"); code != http.StatusForbidden {
+		t.Errorf("cross-origin subscribe = %d", code)
+	}
+	if code := requestAt(http.MethodPost, "http://evil.example:50091", "127.0.0.1:1234", "http://evil.example:50091"); code != http.StatusForbidden {
+		t.Errorf("DNS rebinding subscribe = %d", code)
+	}
+	if code := request(http.MethodPost, "127.0.0.1:1234", "http://localhost:50091"); code != http.StatusNoContent {
+		t.Fatalf("subscribe = %d", code)
+	}
+	if code := requestAt(http.MethodDelete, "http://[::1]:50091", "[::1]:1234", "http://[::1]:50091"); code != http.StatusNoContent {
+		t.Fatalf("unsubscribe at [::1] = %d", code)
+	}
+	if code := request(http.MethodPost, "127.0.0.1:1234", "http://localhost:50091"); code != http.StatusNoContent {
+		t.Fatalf("subscribe = %d", code)
+	}
+	if subs, _ := store.Subscriptions(); len(subs) != 1 {
+		t.Fatalf("subscriptions = %+v", subs)
+	}
+	if code := request(http.MethodDelete, "[::1]:1234", "http://localhost:50091"); code != http.StatusNoContent {
+		t.Fatalf("unsubscribe = %d", code)
+	}
+	if subs, _ := store.Subscriptions(); len(subs) != 0 {
+		t.Errorf("subscriptions after unsubscribe = %+v", subs)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), key.PublicKey()) {
+		t.Error("page should embed the public key")
+	}
+}
+
+func TestPayloadTruncates(t *testing.T) {
+	data, err := Message{Title: "api: Notification", Body: strings.Repeat("あ", 5000)}.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) > maxPayload {
+		t.Errorf("payload is %d bytes", len(data))
+	}
+	var m Message
+	if err := json.Unmarshal(data, &m); err != nil || !strings.HasSuffix(m.Body, "…") {
+		t.Errorf("payload = %s, %v", data, err)
+	}
+	if _, err := (Message{Title: strings.Repeat("x", 5000)}).Payload(); err == nil {
+		t.Error("an oversized title should fail")
+	}
+}
+
+func TestSendGone(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	key, err := NewStore(t.TempDir()).Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sub := newBrowser(t, server.URL)
+	if err := Send(context.Background(), server.Client(), key, sub, []byte("{}"), Options{}); !errors.Is(err, ErrGone) {
+		t.Errorf("Send() = %v, want ErrGone", err)
+	}
+}